@@ -44,6 +44,11 @@ const (
 	SoftWalletPassPhraseFlagName = "soft_wallet_pass_phrase"
 	SoftWalletPath               = "soft_wallet_path"
 
+	RemoteSignerFlagName     = "remote_signer"
+	RemoteSignerCertFlagName = "remote_signer_cert"
+	RemoteSignerKeyFlagName  = "remote_signer_key"
+	RemoteSignerCAFlagName   = "remote_signer_ca"
+
 	IsScannerFlagName = "is_scanner"
 
 	IsUploadNodeData = "is_upload_node_data"
@@ -56,9 +61,20 @@ const (
 
 	IsStartMine = "is_start_mine"
 	NoDiscovery = "no_discovery"
+	FastSync    = "fast_sync"
+	GCMode      = "gc_mode"
+	Retention   = "state_retention"
+	TxHistory   = "tx_history"
+	VmAOT       = "vm_aot"
 	Nat         = "nat"
 
-	AllowHostsFlagName = "allow_hosts"
+	PoolScheme          = "pool_scheme"
+	PoolPPLNSWindow     = "pool_pplns_window"
+	PoolShareMultiple   = "pool_share_multiple"
+	PoolPayoutThreshold = "pool_payout_threshold"
+
+	AllowHostsFlagName    = "allow_hosts"
+	RpcAccessConfFlagName = "rpc_access_conf"
 
 	MetricsPortFlagName = "m_port"
 
@@ -86,6 +102,10 @@ var (
 		SoftWalletPasswordFlag,
 		SoftWalletPassPhraseFlag,
 		SoftWalletPathFlag,
+		RemoteSignerFlag,
+		RemoteSignerCertFlag,
+		RemoteSignerKeyFlag,
+		RemoteSignerCAFlag,
 		IsScannerFlag,
 		IsUploadNodeDataFlag,
 		//IsPerformanceFlag,
@@ -95,8 +115,18 @@ var (
 
 		IsStartMineFlag,
 		NoDiscoveryFlag,
+		FastSyncFlag,
+		GCModeFlag,
+		RetentionFlag,
+		TxHistoryFlag,
+		VmAOTFlag,
+		PoolSchemeFlag,
+		PoolPPLNSWindowFlag,
+		PoolShareMultipleFlag,
+		PoolPayoutThresholdFlag,
 		NatFlag,
 		AllowHostsFlag,
+		RpcAccessConfFlag,
 	}
 )
 
@@ -111,6 +141,26 @@ var (
 		Usage: "set rpc client allow hosts",
 		Value: &cli.StringSlice{"localhost", "127.0.0.1"},
 	}
+	RpcAccessConfFlag = cli.StringFlag{
+		Name:  RpcAccessConfFlagName,
+		Usage: "set the json file of the api keys, JWT secret, method rules and rate limits of the http and websocket rpc",
+	}
+	RemoteSignerFlag = cli.StringFlag{
+		Name:  RemoteSignerFlagName,
+		Usage: "sign with the keys of a signer daemon instead of the soft wallet, the ipc path or https url of the daemon",
+	}
+	RemoteSignerCertFlag = cli.StringFlag{
+		Name:  RemoteSignerCertFlagName,
+		Usage: "set the client certificate presented to the https signer daemon",
+	}
+	RemoteSignerKeyFlag = cli.StringFlag{
+		Name:  RemoteSignerKeyFlagName,
+		Usage: "set the key of the client certificate presented to the https signer daemon",
+	}
+	RemoteSignerCAFlag = cli.StringFlag{
+		Name:  RemoteSignerCAFlagName,
+		Usage: "set the CA which issues the certificate of the https signer daemon",
+	}
 	UploadURLFlag = cli.StringFlag{
 		Name:  UploadURL,
 		Usage: "set uploading data url",
@@ -231,6 +281,60 @@ var (
 		Usage: "whether closing node discovery",
 	}
 
+	FastSyncFlag = cli.IntFlag{
+		Name:  FastSync,
+		Value: 0,
+		Usage: "set whether downloading the state of a recent block instead of processing all blocks from the genesis，0 no，1 yes",
+	}
+
+	GCModeFlag = cli.StringFlag{
+		Name:  GCMode,
+		Value: "full",
+		Usage: "set the garbage collection mode of the state, full keeps the states of the latest blocks, archive keeps all",
+	}
+
+	RetentionFlag = cli.Uint64Flag{
+		Name:  Retention,
+		Value: 0,
+		Usage: "set the number of the latest block states kept by the full gc mode, 0 for the least number",
+	}
+
+	TxHistoryFlag = cli.IntFlag{
+		Name:  TxHistory,
+		Value: 0,
+		Usage: "set whether keeping the tx history of the addresses, the blocks inserted before aren't indexed，0 no，1 yes",
+	}
+
+	VmAOTFlag = cli.IntFlag{
+		Name:  VmAOT,
+		Value: 0,
+		Usage: "set whether running the hot contracts with the native code compiled by the C compiler，0 no，1 yes",
+	}
+
+	PoolSchemeFlag = cli.StringFlag{
+		Name:  PoolScheme,
+		Value: "",
+		Usage: "set the share scheme of the mine master pool, pplns or prop, the worker rewards aren't accounted if it's empty",
+	}
+
+	PoolPPLNSWindowFlag = cli.Uint64Flag{
+		Name:  PoolPPLNSWindow,
+		Value: 200,
+		Usage: "set the number of the latest shares counted by the pplns scheme",
+	}
+
+	PoolShareMultipleFlag = cli.Uint64Flag{
+		Name:  PoolShareMultiple,
+		Value: 100,
+		Usage: "set the share target to the multiple of the block target, the blocks are the only shares if it's less than 2",
+	}
+
+	PoolPayoutThresholdFlag = cli.StringFlag{
+		Name:  PoolPayoutThreshold,
+		Value: "1000000000000000000",
+		Usage: "set the least worker balance in WU paid by a payout transaction, 0 disables the automatic payouts",
+	}
+
 	NatFlag = cli.StringFlag{
 		Name:  Nat,
 		Value: "",
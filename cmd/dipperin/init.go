@@ -0,0 +1,70 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/dipperin/dipperin-core/cmd/dipperin/config"
+	"github.com/dipperin/dipperin-core/cmd/utils"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/urfave/cli"
+)
+
+// initCommand writes the genesis spec and the genesis block to the data dir
+var initCommand = cli.Command{
+	Name:      "init",
+	Usage:     "initialize the data dir with a genesis spec file (json or toml) to run a private network",
+	ArgsUsage: "<genesis file>",
+	Flags:     []cli.Flag{config.DataDirFlag},
+	Action:    initGenesis,
+}
+
+func initGenesis(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return g_error.ErrGenesisSpecPathEmpty
+	}
+	spec, err := chain.LoadGenesisSpec(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	// the data dir initialized by another spec is refused, the genesis block of the boot env is refused
+	// by the genesis setup
+	dataDir := c.String(config.DataDirFlagName)
+	stored, err := chain.ReadGenesisSpec(dataDir)
+	if err != nil {
+		return err
+	}
+	if stored != nil && stored.Hash() != spec.Hash() {
+		return g_error.ErrGenesisSpecMismatch
+	}
+
+	if err = spec.Apply(); err != nil {
+		return err
+	}
+	hash, err := utils.InitGenesis(dataDir, chain_config.GetChainConfig())
+	if err != nil {
+		return err
+	}
+	if err = spec.Write(dataDir); err != nil {
+		return err
+	}
+	log.Info("init the genesis successful", "hash", hash.Hex(), "data dir", dataDir)
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/dipperin/dipperin-core/cmd/base"
+	"github.com/dipperin/dipperin-core/cmd/utils"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testGenesisSpec = `{
+  "config": {"chainId": 1600, "networkId": 1601},
+  "verifiers": ["0x0000970e8128aB834E8EAC17aB8E3812f010678CF791"],
+  "alloc": {"0x00001111111111111111111111111111111111111111": 100}
+}`
+
+func Test_initGenesis(t *testing.T) {
+	dataDir := "/tmp/dipperin_init_test"
+	defer os.RemoveAll(dataDir)
+	defer os.RemoveAll(dataDir + "_default")
+	conf := *chain_config.GetChainConfig()
+	defer chain_config.SetChainConfig(&conf)
+	utils.SetupGenesis(dataDir+"_default", chain_config.GetChainConfig())
+
+	specPath := filepath.Join(os.TempDir(), "dipperin_init_genesis.json")
+	assert.NoError(t, ioutil.WriteFile(specPath, []byte(testGenesisSpec), 0644))
+	defer os.Remove(specPath)
+
+	app := base.NewApp("dipperin", "dipperin node and console")
+	app.Commands = []cli.Command{initCommand}
+	assert.Equal(t, g_error.ErrGenesisSpecPathEmpty, app.Run([]string{"dipperin", "init", "--data_dir", dataDir}))
+
+	// the same spec can be initialized again
+	assert.NoError(t, app.Run([]string{"dipperin", "init", "--data_dir", dataDir, specPath}))
+	assert.NoError(t, app.Run([]string{"dipperin", "init", "--data_dir", dataDir, specPath}))
+
+	// a different spec is refused
+	otherPath := filepath.Join(os.TempDir(), "dipperin_init_other.json")
+	assert.NoError(t, ioutil.WriteFile(otherPath, []byte(strings.Replace(testGenesisSpec, "1601", "1602", 1)), 0644))
+	defer os.Remove(otherPath)
+	assert.Equal(t, g_error.ErrGenesisSpecMismatch, app.Run([]string{"dipperin", "init", "--data_dir", dataDir, otherPath}))
+
+	// the data dir of the boot env genesis is refused
+	assert.Error(t, app.Run([]string{"dipperin", "init", "--data_dir", dataDir + "_default", specPath}))
+}
@@ -32,6 +32,7 @@ func main() {
 	log.Info("~~~~~~~~~start app ~~~~~~~~~~~~")
 	app := base.NewApp("dipperin", "dipperin node and console")
 	app.Flags = append(config.Flags, debug.Flags...)
+	app.Commands = []cli.Command{initCommand, pruneCommand}
 	app.Action = func(c *cli.Context) error {
 		//use pprof
 		debug.Setup(c)
@@ -0,0 +1,60 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/dipperin/dipperin-core/cmd/dipperin/config"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-state"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-writer"
+	"github.com/dipperin/dipperin-core/core/dipperin"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/urfave/cli"
+)
+
+// pruneCommand deletes the history state of a stopped node
+var pruneCommand = cli.Command{
+	Name:   "prune",
+	Usage:  "delete the state tries of the history blocks in the data dir, the node must be stopped",
+	Flags:  []cli.Flag{config.DataDirFlag, config.RetentionFlag},
+	Action: pruneState,
+}
+
+func pruneState(c *cli.Context) error {
+	if err := dipperin.LoadGenesisSpec(c.String(config.DataDirFlagName)); err != nil {
+		return err
+	}
+	chainConfig := chain_config.GetChainConfig()
+	retention := c.Uint64(config.Retention)
+	if min := chain_state.MinStateRetention(chainConfig); retention < min {
+		retention = min
+	}
+
+	cs := chain_state.NewChainState(&chain_state.ChainStateConfig{
+		ChainConfig:   chainConfig,
+		DataDir:       c.String(config.DataDirFlagName),
+		WriterFactory: chain_writer.NewChainWriterFactory(),
+	})
+	defer cs.GetDB().Close()
+
+	deleted, err := cs.PruneHistoryState(retention)
+	if err != nil {
+		return err
+	}
+	log.Info("prune the history state finished", "retention", retention, "deleted nodes", deleted)
+	return nil
+}
@@ -32,6 +32,10 @@ func StartNode(c *cli.Context, async bool, logToConsole bool, logToFile bool) (d
 	extraBeforeStart(c, logToConsole, logToFile)
 	// make a node
 	nodeConf := getNodeConf(c)
+	// the chain config of the genesis spec is used by the config check
+	if err := dipperin.LoadGenesisSpec(nodeConf.DataDir); err != nil {
+		return nil, err
+	}
 	if err := nodeConf.NodeConfigCheck(); err != nil {
 		return nil, err
 	}
@@ -99,12 +103,26 @@ func getNodeConf(c *cli.Context) dipperin.NodeConfig {
 	nodeConf.SoftWalletPassword = c.String(config.SoftWalletPasswordFlagName)
 	nodeConf.SoftWalletPassPhrase = c.String(config.SoftWalletPassPhraseFlagName)
 	nodeConf.SoftWalletPath = c.String(config.SoftWalletPath)
+	nodeConf.RemoteSigner = c.String(config.RemoteSignerFlagName)
+	nodeConf.RemoteSignerCert = c.String(config.RemoteSignerCertFlagName)
+	nodeConf.RemoteSignerKey = c.String(config.RemoteSignerKeyFlagName)
+	nodeConf.RemoteSignerCA = c.String(config.RemoteSignerCAFlagName)
 	nodeConf.IsScanner = c.Int(config.IsScannerFlagName)
 	nodeConf.IsUploadNodeData = c.Int(config.IsUploadNodeData)
 	nodeConf.UploadURL = c.String(config.UploadURL)
 	nodeConf.NoDiscovery = c.Int(config.NoDiscovery)
+	nodeConf.FastSync = c.Int(config.FastSync) == 1
+	nodeConf.GCMode = c.String(config.GCMode)
+	nodeConf.StateRetention = c.Uint64(config.Retention)
+	nodeConf.TxHistoryIndex = c.Int(config.TxHistory) == 1
+	nodeConf.VmAOT = c.Int(config.VmAOT) == 1
+	nodeConf.PoolScheme = c.String(config.PoolScheme)
+	nodeConf.PoolPPLNSWindow = c.Uint64(config.PoolPPLNSWindow)
+	nodeConf.PoolShareMultiple = c.Uint64(config.PoolShareMultiple)
+	nodeConf.PoolPayoutThreshold = c.String(config.PoolPayoutThreshold)
 	nodeConf.Nat = c.String(config.Nat)
 	nodeConf.AllowHosts = c.StringSlice(config.AllowHostsFlagName)
+	nodeConf.RpcAccessConf = c.String(config.RpcAccessConfFlagName)
 	nodeConf.PMetricsPort = c.Int(config.MetricsPortFlagName)
 
 	if c.Int(config.IsStartMine) == 0 {
@@ -85,6 +85,16 @@ var CliCommands = []cli.Command{
 			return nil
 		},
 	},
+	{
+		Name:  "txpool",
+		Usage: "txpool func",
+		Flags: commonFlags,
+		Action: func(c *cli.Context) error {
+			RpcCall(c)
+			return nil
+		},
+	},
+	offlineCommand,
 }
 
 var commonFlags = []cli.Flag{
@@ -95,7 +105,7 @@ var txFlags = []cli.Flag{
 	cli.StringFlag{Name: "p", Usage: "parameters"},
 	cli.StringFlag{Name: "abi", Usage: "abi path"},
 	cli.StringFlag{Name: "wasm", Usage: "wasm path"},
-	cli.StringFlag{Name: "input", Usage: "contract params"},
+	cli.StringFlag{Name: "input", Usage: "contract params separated by commas, arrays as [a,b] and structs as (a,b)"},
 	cli.BoolFlag{Name: "is-create", Usage: "create contract or not"},
 	cli.StringFlag{Name: "func-name", Usage: "call function name"},
 }
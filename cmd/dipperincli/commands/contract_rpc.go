@@ -3,6 +3,7 @@ package commands
 import (
 	"errors"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/common/hexutil"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -10,8 +11,26 @@ import (
 	"io/ioutil"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
+// revertReason decodes the reason from the error of the reverted contract
+func revertReason(err error) (string, bool) {
+	msg := err.Error()
+	if !strings.HasPrefix(msg, g_error.ErrExecutionReverted.Error()) {
+		return "", false
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(msg, g_error.ErrExecutionReverted.Error()), ": "), true
+}
+
+func printCallError(method string, err error) {
+	if reason, ok := revertReason(err); ok {
+		l.Error(method+" reverted", "reason", reason)
+		return
+	}
+	l.Error(method+" failed", "err", err.Error())
+}
+
 func (caller *rpcCaller) GetContractAddressByTxHash(c *cli.Context) {
 	_, cParams, err := getRpcMethodAndParam(c)
 	if err != nil {
@@ -89,7 +108,7 @@ func (caller *rpcCaller) CallContract(c *cli.Context) {
 
 	var resp string
 	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), from, to, inputRlp, blockNum); err != nil {
-		l.Error("CallContract failed", "err", err)
+		printCallError("CallContract", err)
 		return
 	}
 	l.Info("CallContract", "resp", resp)
@@ -104,13 +123,13 @@ func (caller *rpcCaller) EstimateGas(c *cli.Context) {
 	if isCreate(c) {
 		resp, err = contractCreate(c)
 		if err != nil {
-			l.Error("EstimateGas failed", "err", err.Error())
+			printCallError("EstimateGas", err)
 			return
 		}
 	} else {
 		resp, err = contractCall(c)
 		if err != nil {
-			l.Error("EstimateGas failed", "err", err.Error())
+			printCallError("EstimateGas", err)
 			return
 		}
 	}
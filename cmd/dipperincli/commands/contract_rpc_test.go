@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -200,3 +201,19 @@ func TestRpcCaller_SendTransactionContract(t *testing.T) {
 	app.Run([]string{"xxx", "SendTransactionContract"})
 	client = nil
 }
+
+func TestRevertReason(t *testing.T) {
+	reason, ok := revertReason(&g_error.RevertError{Reason: "insufficient token"})
+	assert.True(t, ok)
+	assert.Equal(t, "insufficient token", reason)
+
+	reason, ok = revertReason(g_error.ErrExecutionReverted)
+	assert.True(t, ok)
+	assert.Equal(t, "", reason)
+
+	_, ok = revertReason(testErr)
+	assert.False(t, ok)
+
+	printCallError("CallContract", &g_error.RevertError{Reason: "insufficient token"})
+	printCallError("CallContract", testErr)
+}
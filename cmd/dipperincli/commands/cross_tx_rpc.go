@@ -0,0 +1,230 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/urfave/cli"
+	"math/big"
+	"strconv"
+)
+
+// send lock transaction, to can claim the value with the hash key before the time lock expired
+func (caller *rpcCaller) SendLockTransaction(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 7 {
+		l.Error("SendLockTransaction need：from to hashLock timeLock value gasPrice gasLimit")
+		return
+	}
+
+	from, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the from address is invalid", "err", err)
+		return
+	}
+
+	to, err := CheckAndChangeHexToAddress(cParams[1])
+	if err != nil {
+		l.Error("the to address is invalid", "err", err)
+		return
+	}
+
+	hashLock, err := hexutil.Decode(cParams[2])
+	if err != nil || len(hashLock) != common.HashLength {
+		l.Error("the parameter hashLock invalid", "err", err)
+		return
+	}
+
+	timeLock, ok := new(big.Int).SetString(cParams[3], 10)
+	if !ok {
+		l.Error("the parameter timeLock invalid")
+		return
+	}
+
+	value, err := MoneyValueToCSCoin(cParams[4])
+	if err != nil {
+		l.Error("the parameter value invalid", "err", err)
+		return
+	}
+
+	gasPrice, err := MoneyValueToCSCoin(cParams[5])
+	if err != nil {
+		l.Error("the parameter gasPrice invalid", "err", err)
+		return
+	}
+
+	gasLimit, err := strconv.ParseUint(cParams[6], 10, 64)
+	if err != nil {
+		l.Error("the parameter gasLimit invalid", "err", err)
+		return
+	}
+
+	var resp common.Hash
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), from, to, common.BytesToHash(hashLock), timeLock, value, gasPrice, gasLimit, nil); err != nil {
+		l.Error("call send lock transaction", "err", err)
+		return
+	}
+	l.Info("SendLockTransaction result", "txId", resp.Hex())
+}
+
+// send claim transaction, take the value locked by alice with the hash key
+func (caller *rpcCaller) SendClaimTransaction(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 5 {
+		l.Error("SendClaimTransaction need：from alice hashKey gasPrice gasLimit")
+		return
+	}
+
+	from, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the from address is invalid", "err", err)
+		return
+	}
+
+	alice, err := CheckAndChangeHexToAddress(cParams[1])
+	if err != nil {
+		l.Error("the alice address is invalid", "err", err)
+		return
+	}
+
+	hashKey, err := hexutil.Decode(cParams[2])
+	if err != nil {
+		l.Error("the parameter hashKey invalid", "err", err)
+		return
+	}
+
+	gasPrice, err := MoneyValueToCSCoin(cParams[3])
+	if err != nil {
+		l.Error("the parameter gasPrice invalid", "err", err)
+		return
+	}
+
+	gasLimit, err := strconv.ParseUint(cParams[4], 10, 64)
+	if err != nil {
+		l.Error("the parameter gasLimit invalid", "err", err)
+		return
+	}
+
+	var resp common.Hash
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), from, alice, hexutil.Bytes(hashKey), gasPrice, gasLimit, nil); err != nil {
+		l.Error("call send claim transaction", "err", err)
+		return
+	}
+	l.Info("SendClaimTransaction result", "txId", resp.Hex())
+}
+
+// send refund transaction, take back the value locked for bob after the time lock expired
+func (caller *rpcCaller) SendRefundTransaction(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 4 {
+		l.Error("SendRefundTransaction need：from bob gasPrice gasLimit")
+		return
+	}
+
+	from, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the from address is invalid", "err", err)
+		return
+	}
+
+	bob, err := CheckAndChangeHexToAddress(cParams[1])
+	if err != nil {
+		l.Error("the bob address is invalid", "err", err)
+		return
+	}
+
+	gasPrice, err := MoneyValueToCSCoin(cParams[2])
+	if err != nil {
+		l.Error("the parameter gasPrice invalid", "err", err)
+		return
+	}
+
+	gasLimit, err := strconv.ParseUint(cParams[3], 10, 64)
+	if err != nil {
+		l.Error("the parameter gasLimit invalid", "err", err)
+		return
+	}
+
+	var resp common.Hash
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), from, bob, gasPrice, gasLimit, nil); err != nil {
+		l.Error("call send refund transaction", "err", err)
+		return
+	}
+	l.Info("SendRefundTransaction result", "txId", resp.Hex())
+}
+
+// get the lock info between alice and bob
+func (caller *rpcCaller) GetLockInfo(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 2 {
+		l.Error("GetLockInfo need：alice bob")
+		return
+	}
+
+	alice, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the alice address is invalid", "err", err)
+		return
+	}
+
+	bob, err := CheckAndChangeHexToAddress(cParams[1])
+	if err != nil {
+		l.Error("the bob address is invalid", "err", err)
+		return
+	}
+
+	var resp rpc_interface.LockInfoResp
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), alice, bob); err != nil {
+		l.Error("call get lock info", "err", err)
+		return
+	}
+	l.Info("the lock info is:", "lockAddress", resp.LockAddress.Hex(), "balance", resp.Balance, "hashLock", resp.HashLock.Hex(), "timeLock", resp.TimeLock)
+}
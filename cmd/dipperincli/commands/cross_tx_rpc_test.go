@@ -0,0 +1,221 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"os"
+	"testing"
+)
+
+func TestRpcCaller_SendLockTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		SyncStatus.Store(true)
+		caller.SendLockTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	hashLock := common.HexToHash("0x123").Hex()
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+		SyncStatus.Store(false)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any()).Return(testErr).Times(1)
+		caller.SendLockTransaction(c)
+
+		SyncStatus.Store(true)
+		c.Set("p", "test")
+		caller.SendLockTransaction(c)
+
+		c.Set("p", "from,to,hashLock,timeLock,value,gasPrice,gasLimit")
+		caller.SendLockTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,to,hashLock,timeLock,value,gasPrice,gasLimit", from))
+		caller.SendLockTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,hashLock,timeLock,value,gasPrice,gasLimit", from, to))
+		caller.SendLockTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,timeLock,value,gasPrice,gasLimit", from, to, hashLock))
+		caller.SendLockTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,value,gasPrice,gasLimit", from, to, hashLock, "100"))
+		caller.SendLockTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s,gasPrice,gasLimit", from, to, hashLock, "100", "10dip"))
+		caller.SendLockTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s,%s,gasLimit", from, to, hashLock, "100", "10dip", "1wu"))
+		caller.SendLockTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s", from, to, hashLock, "100", "10dip", "1wu", "21000"))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.SendLockTransaction(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		caller.SendLockTransaction(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "SendLockTransaction"}))
+	client = nil
+}
+
+func TestRpcCaller_SendClaimTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		SyncStatus.Store(true)
+		caller.SendClaimTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+		SyncStatus.Store(false)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any()).Return(testErr).Times(1)
+		caller.SendClaimTransaction(c)
+
+		SyncStatus.Store(true)
+		c.Set("p", "test")
+		caller.SendClaimTransaction(c)
+
+		c.Set("p", "from,alice,hashKey,gasPrice,gasLimit")
+		caller.SendClaimTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,alice,hashKey,gasPrice,gasLimit", to))
+		caller.SendClaimTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,hashKey,gasPrice,gasLimit", to, from))
+		caller.SendClaimTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,gasPrice,gasLimit", to, from, "0x1234"))
+		caller.SendClaimTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,gasLimit", to, from, "0x1234", "1wu"))
+		caller.SendClaimTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s", to, from, "0x1234", "1wu", "21000"))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.SendClaimTransaction(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		caller.SendClaimTransaction(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "SendClaimTransaction"}))
+	client = nil
+}
+
+func TestRpcCaller_SendRefundTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		SyncStatus.Store(true)
+		caller.SendRefundTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+		SyncStatus.Store(false)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any()).Return(testErr).Times(1)
+		caller.SendRefundTransaction(c)
+
+		SyncStatus.Store(true)
+		c.Set("p", "test")
+		caller.SendRefundTransaction(c)
+
+		c.Set("p", "from,bob,gasPrice,gasLimit")
+		caller.SendRefundTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,bob,gasPrice,gasLimit", from))
+		caller.SendRefundTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,gasPrice,gasLimit", from, to))
+		caller.SendRefundTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,gasLimit", from, to, "1wu"))
+		caller.SendRefundTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s", from, to, "1wu", "21000"))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.SendRefundTransaction(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		caller.SendRefundTransaction(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "SendRefundTransaction"}))
+	client = nil
+}
+
+func TestRpcCaller_GetLockInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		caller.GetLockInfo(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		c.Set("p", "test")
+		caller.GetLockInfo(c)
+
+		c.Set("p", "alice,bob")
+		caller.GetLockInfo(c)
+
+		c.Set("p", fmt.Sprintf("%s,bob", from))
+		caller.GetLockInfo(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s", from, to))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.GetLockInfo(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*rpc_interface.LockInfoResp) = rpc_interface.LockInfoResp{LockAddress: common.HexToAddress("0x123")}
+			return nil
+		})
+		caller.GetLockInfo(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "GetLockInfo"}))
+	client = nil
+}
@@ -587,3 +587,70 @@ func (caller *rpcCaller) ERC20Balance(c *cli.Context) {
 
 	l.Info("contract info", "address", owner, "token balance", ts+unit)
 }
+
+// ERC20Holders prints a page of the holders of the token from the largest balance
+func (caller *rpcCaller) ERC20Holders(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error", "err", err)
+		return
+	}
+
+	if len(cParams) < 1 || len(cParams) > 3 {
+		l.Error("parameters need：contract_address [offset] [limit]")
+		return
+	}
+
+	contractAdr, offset, limit, err := getAddressAndPage(cParams)
+	if err != nil {
+		l.Error("the input address, offset or limit is invalid", "err", err)
+		return
+	}
+
+	var resp []*rpc_interface.ERC20HolderResp
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), contractAdr, offset, limit); err != nil {
+		l.Error("call ERC20Holders", "err", err)
+		return
+	}
+
+	decimal := getERC20Decimal(contractAdr)
+	unit := getERC20Symbol(contractAdr)
+	l.Info("the holders of the token", "contract", contractAdr.Hex(), "count", len(resp))
+	for _, holder := range resp {
+		balance, _ := InterToDecimal(holder.Balance, decimal)
+		l.Info("token holder", "address", holder.Address.Hex(), "token balance", balance+unit)
+	}
+}
+
+// ERC20TransfersByAddress prints a page of the token transfers sent or received by the address from the latest one,
+// the values are in the smallest unit of the tokens
+func (caller *rpcCaller) ERC20TransfersByAddress(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error", "err", err)
+		return
+	}
+
+	if len(cParams) < 1 || len(cParams) > 3 {
+		l.Error("parameters need：address [offset] [limit]")
+		return
+	}
+
+	address, offset, limit, err := getAddressAndPage(cParams)
+	if err != nil {
+		l.Error("the input address, offset or limit is invalid", "err", err)
+		return
+	}
+
+	var resp []*rpc_interface.ERC20TransferResp
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), address, offset, limit); err != nil {
+		l.Error("call ERC20TransfersByAddress", "err", err)
+		return
+	}
+
+	l.Info("the token transfers of the address", "address", address.Hex(), "count", len(resp))
+	for _, transfer := range resp {
+		l.Info("token transfer", "contract", transfer.Token.Hex(), "txId", transfer.TxHash.Hex(), "block", transfer.BlockNumber,
+			"from", transfer.From.Hex(), "to", transfer.To.Hex(), "value", transfer.Value.ToInt().String())
+	}
+}
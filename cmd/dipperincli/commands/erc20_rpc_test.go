@@ -26,6 +26,7 @@ import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/consts"
 	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
 	"github.com/golang/mock/gomock"
 	"github.com/urfave/cli"
 )
@@ -502,3 +503,84 @@ func TestRpcCaller_ERC20GetInfo(t *testing.T) {
 	assert.NoError(t, app.Run([]string{os.Args[0], "ERC20GetInfo"}))
 	client = nil
 }
+
+func TestRpcCaller_ERC20Holders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(context *cli.Context) {
+		c := &rpcCaller{}
+		c.ERC20Holders(context)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(context *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		c := &rpcCaller{}
+
+		context.Set("p", "")
+		c.ERC20Holders(context)
+
+		context.Set("p", "contractAddr")
+		c.ERC20Holders(context)
+
+		context.Set("p", fmt.Sprintf("%s,1,10,1", contractAddr))
+		c.ERC20Holders(context)
+
+		context.Set("p", fmt.Sprintf("%s,1,10", contractAddr))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_eRC20Holders", gomock.Any(), uint64(1), uint64(10)).Return(testErr)
+		c.ERC20Holders(context)
+
+		context.Set("p", contractAddr)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_eRC20Holders", gomock.Any(), uint64(0), uint64(0)).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*[]*rpc_interface.ERC20HolderResp) = []*rpc_interface.ERC20HolderResp{{Address: common.HexToAddress(from), Balance: (*hexutil.Big)(big.NewInt(1e18))}}
+			return nil
+		})
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(0, consts.DIPDecimalBits)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(0, "dip")
+		c.ERC20Holders(context)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0], "ERC20Holders"}))
+	client = nil
+}
+
+func TestRpcCaller_ERC20TransfersByAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(context *cli.Context) {
+		c := &rpcCaller{}
+		c.ERC20TransfersByAddress(context)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(context *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		c := &rpcCaller{}
+
+		context.Set("p", "")
+		c.ERC20TransfersByAddress(context)
+
+		context.Set("p", from+",offset")
+		c.ERC20TransfersByAddress(context)
+
+		context.Set("p", from+",1,10")
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_eRC20TransfersByAddress", gomock.Any(), uint64(1), uint64(10)).Return(testErr)
+		c.ERC20TransfersByAddress(context)
+
+		context.Set("p", from)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_eRC20TransfersByAddress", gomock.Any(), uint64(0), uint64(0)).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*[]*rpc_interface.ERC20TransferResp) = []*rpc_interface.ERC20TransferResp{{
+				Token: common.HexToAddress(contractAddr),
+				From:  common.HexToAddress(from),
+				Value: (*hexutil.Big)(big.NewInt(1)),
+			}}
+			return nil
+		})
+		c.ERC20TransfersByAddress(context)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0], "ERC20TransfersByAddress"}))
+	client = nil
+}
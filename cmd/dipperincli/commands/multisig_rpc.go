@@ -0,0 +1,301 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+// send the transaction creating a multisig account, the signers are the params after gasLimit
+func (caller *rpcCaller) CreateMultiSigAccount(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) < 6 {
+		l.Error("CreateMultiSigAccount need：from threshold value gasPrice gasLimit signer1 [signer2 ...]")
+		return
+	}
+
+	from, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the from address is invalid", "err", err)
+		return
+	}
+
+	threshold, err := strconv.ParseUint(cParams[1], 10, 64)
+	if err != nil {
+		l.Error("the parameter threshold invalid", "err", err)
+		return
+	}
+
+	value, err := MoneyValueToCSCoin(cParams[2])
+	if err != nil {
+		l.Error("the parameter value invalid", "err", err)
+		return
+	}
+
+	gasPrice, err := MoneyValueToCSCoin(cParams[3])
+	if err != nil {
+		l.Error("the parameter gasPrice invalid", "err", err)
+		return
+	}
+
+	gasLimit, err := strconv.ParseUint(cParams[4], 10, 64)
+	if err != nil {
+		l.Error("the parameter gasLimit invalid", "err", err)
+		return
+	}
+
+	signers := make([]common.Address, 0, len(cParams)-5)
+	for _, param := range cParams[5:] {
+		signer, err := CheckAndChangeHexToAddress(param)
+		if err != nil {
+			l.Error("the signer address is invalid", "err", err)
+			return
+		}
+		signers = append(signers, signer)
+	}
+
+	var resp common.Hash
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), from, threshold, signers, value, gasPrice, gasLimit, nil); err != nil {
+		l.Error("call create multisig account", "err", err)
+		return
+	}
+	l.Info("CreateMultiSigAccount result", "txId", resp.Hex())
+}
+
+// get the signers, threshold, balance and nonce of the multisig account
+func (caller *rpcCaller) GetMultiSigAccount(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 1 {
+		l.Error("GetMultiSigAccount need：address")
+		return
+	}
+
+	address, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the address is invalid", "err", err)
+		return
+	}
+
+	var resp rpc_interface.MultiSigAccountResp
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), address); err != nil {
+		l.Error("call get multisig account", "err", err)
+		return
+	}
+	l.Info("the multisig account is:", "address", resp.Address.Hex(), "threshold", resp.Threshold, "signers", resp.Signers, "balance", resp.Balance, "nonce", resp.Nonce)
+}
+
+// create the unsigned rlp of a transaction sent from the multisig account
+func (caller *rpcCaller) NewMultiSigTransaction(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 5 && len(cParams) != 6 {
+		l.Error("NewMultiSigTransaction need：from to value gasPrice gasLimit [data]")
+		return
+	}
+
+	from, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the from address is invalid", "err", err)
+		return
+	}
+
+	to, err := CheckAndChangeHexToAddress(cParams[1])
+	if err != nil {
+		l.Error("the to address is invalid", "err", err)
+		return
+	}
+
+	value, err := MoneyValueToCSCoin(cParams[2])
+	if err != nil {
+		l.Error("the parameter value invalid", "err", err)
+		return
+	}
+
+	gasPrice, err := MoneyValueToCSCoin(cParams[3])
+	if err != nil {
+		l.Error("the parameter gasPrice invalid", "err", err)
+		return
+	}
+
+	gasLimit, err := strconv.ParseUint(cParams[4], 10, 64)
+	if err != nil {
+		l.Error("the parameter gasLimit invalid", "err", err)
+		return
+	}
+
+	var data []byte
+	if len(cParams) == 6 {
+		if data, err = hexutil.Decode(cParams[5]); err != nil {
+			l.Error("the parameter data invalid", "err", err)
+			return
+		}
+	}
+
+	var resp hexutil.Bytes
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), from, to, value, gasPrice, gasLimit, hexutil.Bytes(data), nil); err != nil {
+		l.Error("call new multisig transaction", "err", err)
+		return
+	}
+	l.Info("NewMultiSigTransaction result", "tx", resp.String())
+}
+
+// add the signature of the signer in the wallet to the multisig transaction
+func (caller *rpcCaller) SignMultiSigTransaction(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 2 {
+		l.Error("SignMultiSigTransaction need：signer tx")
+		return
+	}
+
+	signer, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the signer address is invalid", "err", err)
+		return
+	}
+
+	txRlp, err := hexutil.Decode(cParams[1])
+	if err != nil {
+		l.Error("the parameter tx invalid", "err", err)
+		return
+	}
+
+	var resp hexutil.Bytes
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), signer, hexutil.Bytes(txRlp)); err != nil {
+		l.Error("call sign multisig transaction", "err", err)
+		return
+	}
+	l.Info("SignMultiSigTransaction result", "tx", resp.String())
+}
+
+// combine the signatures of the partially signed multisig transactions, it's done locally
+func (caller *rpcCaller) CombineMultiSigTransaction(c *cli.Context) {
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) < 2 {
+		l.Error("CombineMultiSigTransaction need：tx1 tx2 [tx3 ...]")
+		return
+	}
+
+	txs := make([]*model.Transaction, 0, len(cParams))
+	for _, param := range cParams {
+		tx, err := decodeMultiSigTx(param)
+		if err != nil {
+			l.Error("the parameter tx invalid", "err", err)
+			return
+		}
+		txs = append(txs, tx)
+	}
+
+	for _, tx := range txs[1:] {
+		if err = txs[0].CombineMultiSig(tx); err != nil {
+			l.Error("combine multisig transaction", "err", err)
+			return
+		}
+	}
+
+	combined, err := rlp.EncodeToBytes(txs[0])
+	if err != nil {
+		l.Error("encode multisig transaction", "err", err)
+		return
+	}
+	l.Info("CombineMultiSigTransaction result", "tx", hexutil.Encode(combined), "signatures", len(txs[0].MultiSig().Sigs))
+}
+
+// send the multisig transaction signed by enough signers
+func (caller *rpcCaller) SendMultiSigTransaction(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 1 {
+		l.Error("SendMultiSigTransaction need：tx")
+		return
+	}
+
+	tx, err := decodeMultiSigTx(cParams[0])
+	if err != nil {
+		l.Error("the parameter tx invalid", "err", err)
+		return
+	}
+	txRlp, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		l.Error("encode multisig transaction", "err", err)
+		return
+	}
+
+	var resp common.Hash
+	if err = client.Call(&resp, getDipperinRpcMethodByName("NewTransaction"), txRlp); err != nil {
+		l.Error("call send multisig transaction", "err", err)
+		return
+	}
+	l.Info("SendMultiSigTransaction result", "txId", resp.Hex())
+}
+
+func decodeMultiSigTx(param string) (*model.Transaction, error) {
+	txRlp, err := hexutil.Decode(param)
+	if err != nil {
+		return nil, err
+	}
+	var tx model.Transaction
+	if err = rlp.DecodeBytes(txRlp, &tx); err != nil {
+		return nil, err
+	}
+	if tx.MultiSig() == nil {
+		return nil, g_error.ErrNotMultiSigTx
+	}
+	return &tx, nil
+}
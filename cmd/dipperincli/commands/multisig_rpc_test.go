@@ -0,0 +1,303 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"math/big"
+	"os"
+	"testing"
+)
+
+// createPartialMultiSigTxs returns the rlp of a 2-of-2 multisig tx signed by each signer separately
+func createPartialMultiSigTxs(t *testing.T) []string {
+	key1, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	key2, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	info, err := model.NewMultiSigInfo(2, []common.Address{cs_crypto.GetNormalAddress(key1.PublicKey), cs_crypto.GetNormalAddress(key2.PublicKey)})
+	assert.NoError(t, err)
+
+	var txs []string
+	for _, key := range []*ecdsa.PrivateKey{key1, key2} {
+		tx := model.NewMultiSigTransaction(0, info, common.HexToAddress(to), big.NewInt(1), big.NewInt(1), 21000, nil)
+		_, err = tx.SignMultiSigTx(key, model.NewSigner(big.NewInt(1)))
+		assert.NoError(t, err)
+		txRlp, err := rlp.EncodeToBytes(tx)
+		assert.NoError(t, err)
+		txs = append(txs, hexutil.Encode(txRlp))
+	}
+	return txs
+}
+
+func TestRpcCaller_CreateMultiSigAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		SyncStatus.Store(true)
+		caller.CreateMultiSigAccount(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+		SyncStatus.Store(false)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any()).Return(testErr).Times(1)
+		caller.CreateMultiSigAccount(c)
+
+		SyncStatus.Store(true)
+		c.Set("p", "test")
+		caller.CreateMultiSigAccount(c)
+
+		c.Set("p", "from,threshold,value,gasPrice,gasLimit,signer")
+		caller.CreateMultiSigAccount(c)
+
+		c.Set("p", fmt.Sprintf("%s,threshold,value,gasPrice,gasLimit,signer", from))
+		caller.CreateMultiSigAccount(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,value,gasPrice,gasLimit,signer", from, "1"))
+		caller.CreateMultiSigAccount(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,gasPrice,gasLimit,signer", from, "1", "10dip"))
+		caller.CreateMultiSigAccount(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,gasLimit,signer", from, "1", "10dip", "1wu"))
+		caller.CreateMultiSigAccount(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s,signer", from, "1", "10dip", "1wu", "21000"))
+		caller.CreateMultiSigAccount(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s", from, "1", "10dip", "1wu", "21000", from, to))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.CreateMultiSigAccount(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		caller.CreateMultiSigAccount(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "CreateMultiSigAccount"}))
+	client = nil
+}
+
+func TestRpcCaller_GetMultiSigAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		caller.GetMultiSigAccount(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		c.Set("p", "")
+		caller.GetMultiSigAccount(c)
+
+		c.Set("p", "address")
+		caller.GetMultiSigAccount(c)
+
+		c.Set("p", from)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.GetMultiSigAccount(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*rpc_interface.MultiSigAccountResp) = rpc_interface.MultiSigAccountResp{Address: common.HexToAddress(from), Threshold: 1}
+			return nil
+		})
+		caller.GetMultiSigAccount(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "GetMultiSigAccount"}))
+	client = nil
+}
+
+func TestRpcCaller_NewMultiSigTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		caller.NewMultiSigTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		c.Set("p", "test")
+		caller.NewMultiSigTransaction(c)
+
+		c.Set("p", "from,to,value,gasPrice,gasLimit")
+		caller.NewMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,to,value,gasPrice,gasLimit", from))
+		caller.NewMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,value,gasPrice,gasLimit", from, to))
+		caller.NewMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,gasPrice,gasLimit", from, to, "10dip"))
+		caller.NewMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,gasLimit", from, to, "10dip", "1wu"))
+		caller.NewMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s,data", from, to, "10dip", "1wu", "21000"))
+		caller.NewMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s,%s", from, to, "10dip", "1wu", "21000", "0x1234"))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.NewMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s,%s,%s,%s", from, to, "10dip", "1wu", "21000"))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		caller.NewMultiSigTransaction(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "NewMultiSigTransaction"}))
+	client = nil
+}
+
+func TestRpcCaller_SignMultiSigTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		caller.SignMultiSigTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		c.Set("p", "test")
+		caller.SignMultiSigTransaction(c)
+
+		c.Set("p", "signer,tx")
+		caller.SignMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,tx", from))
+		caller.SignMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s", from, "0x1234"))
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.SignMultiSigTransaction(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		caller.SignMultiSigTransaction(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "SignMultiSigTransaction"}))
+	client = nil
+}
+
+func TestRpcCaller_CombineMultiSigTransaction(t *testing.T) {
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		caller.CombineMultiSigTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	txs := createPartialMultiSigTxs(t)
+	otherTxs := createPartialMultiSigTxs(t)
+	normalTx, err := rlp.EncodeToBytes(model.NewTransaction(0, common.HexToAddress(to), big.NewInt(1), big.NewInt(1), 21000, nil))
+	assert.NoError(t, err)
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+
+		c.Set("p", txs[0])
+		caller.CombineMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,tx", txs[0]))
+		caller.CombineMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s", txs[0], hexutil.Encode(normalTx)))
+		caller.CombineMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s", txs[0], otherTxs[1]))
+		caller.CombineMultiSigTransaction(c)
+
+		c.Set("p", fmt.Sprintf("%s,%s", txs[0], txs[1]))
+		caller.CombineMultiSigTransaction(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "CombineMultiSigTransaction"}))
+}
+
+func TestRpcCaller_SendMultiSigTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		SyncStatus.Store(true)
+		caller.SendMultiSigTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	txs := createPartialMultiSigTxs(t)
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+		SyncStatus.Store(false)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any()).Return(testErr).Times(1)
+		caller.SendMultiSigTransaction(c)
+
+		SyncStatus.Store(true)
+		c.Set("p", "")
+		caller.SendMultiSigTransaction(c)
+
+		c.Set("p", "tx")
+		caller.SendMultiSigTransaction(c)
+
+		c.Set("p", txs[0])
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(testErr)
+		caller.SendMultiSigTransaction(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		caller.SendMultiSigTransaction(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "SendMultiSigTransaction"}))
+	client = nil
+}
@@ -0,0 +1,891 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dipperin/dipperin-core/cmd/dipperin-prompts"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/dipperin/dipperin-core/core/accounts/soft-wallet"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/dipperin/dipperin-core/core/vm/common/utils"
+	"github.com/dipperin/dipperin-core/third-party/rpc"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// the transaction types that can be built by offline build
+const (
+	OfflineTxNormal   = "normal"
+	OfflineTxRegister = "register"
+	OfflineTxUnStake  = "unstake"
+	OfflineTxCancel   = "cancel"
+	OfflineTxCreate   = "create"
+	OfflineTxCall     = "call"
+	OfflineTxLock     = "lock"
+	OfflineTxClaim    = "claim"
+	OfflineTxRefund   = "refund"
+	OfflineTxEvidence = "evidence"
+	// the tx creating a multisig account and the tx sent from a multisig account
+	OfflineTxMultiSigCreate = "multisig-create"
+	OfflineTxMultiSig       = "multisig"
+
+	OfflineFormatJson = "json"
+	OfflineFormatRlp  = "rlp"
+)
+
+var (
+	// the http client is only created for the offline build and send, dialRpc is replaced in the tests
+	dialRpc = func(url string) (RpcClient, error) {
+		return rpc.DialHTTP(url)
+	}
+	walletPassword = dipperin_prompts.WalletPassword
+)
+
+// OfflineTx is the unsigned transaction handed from the networked machine to the air-gapped signer.
+// Tx is the rlp of the unsigned transaction, From and ChainId tell the signer which key and chain to sign with.
+// From is the multisig account for the multisig txs, they are signed and merged in this format until they are sent.
+type OfflineTx struct {
+	From    common.Address `json:"from"`
+	ChainId *hexutil.Big   `json:"chainId"`
+	Tx      hexutil.Bytes  `json:"tx"`
+}
+
+var offlineBuildFlags = []cli.Flag{
+	cli.StringFlag{Name: "rpc", Usage: "http url of the node providing the nonce and gas, the console node is used if it's empty"},
+	cli.StringFlag{Name: "type", Value: OfflineTxNormal, Usage: "tx type: normal, register, unstake, cancel, create, call, lock, claim, refund, evidence, multisig-create or multisig"},
+	cli.StringFlag{Name: "p", Usage: "parameters"},
+	cli.StringFlag{Name: "gas-price", Usage: "gas price, the node suggested gas price is used if it's empty"},
+	cli.StringFlag{Name: "gas-limit", Usage: "gas limit, the estimated gas is used if it's empty"},
+	cli.StringFlag{Name: "nonce", Usage: "nonce, the nonce of from on the node is used if it's empty"},
+	cli.StringFlag{Name: "abi", Usage: "abi path, the abi of a called contract is fetched from the node if it's empty"},
+	cli.StringFlag{Name: "wasm", Usage: "wasm path"},
+	cli.StringFlag{Name: "input", Usage: "contract params separated by commas, arrays as [a,b] and structs as (a,b)"},
+	cli.StringFlag{Name: "func-name", Usage: "call function name"},
+	cli.StringFlag{Name: "vote-a", Usage: "json file of the first vote signed by the target of the evidence"},
+	cli.StringFlag{Name: "vote-b", Usage: "json file of the conflicting vote signed by the target of the evidence"},
+	cli.StringFlag{Name: "format", Value: OfflineFormatJson, Usage: "output format: json or rlp"},
+	cli.StringFlag{Name: "out", Usage: "output file, the tx is printed if it's empty"},
+}
+
+var offlineSignFlags = []cli.Flag{
+	cli.StringFlag{Name: "in", Usage: "file of the unsigned tx built by offline build"},
+	cli.StringFlag{Name: "p", Usage: "the unsigned tx, used if in is empty"},
+	cli.StringFlag{Name: "wallet", Usage: "path of the soft wallet file"},
+	cli.StringFlag{Name: "password-file", Usage: "file of the wallet password, it's asked for if it's empty"},
+	cli.StringFlag{Name: "from", Usage: "signer address, required by the rlp format"},
+	cli.StringFlag{Name: "signer", Usage: "signer address of the multisig tx, from is the multisig account"},
+	cli.StringFlag{Name: "chain-id", Usage: "chain id, required by the rlp format"},
+	cli.StringFlag{Name: "out", Usage: "output file, the signed tx is printed if it's empty"},
+}
+
+var offlineMergeFlags = []cli.Flag{
+	cli.StringSliceFlag{Name: "in", Usage: "files of the multisig tx signed by different signers"},
+	cli.StringFlag{Name: "out", Usage: "output file, the merged tx is printed if it's empty"},
+}
+
+var offlineSendFlags = []cli.Flag{
+	cli.StringFlag{Name: "rpc", Usage: "http url of the node broadcasting the tx, the console node is used if it's empty"},
+	cli.StringFlag{Name: "in", Usage: "file of the signed tx, the rlp or the json of a multisig tx"},
+	cli.StringFlag{Name: "p", Usage: "the signed tx, used if in is empty"},
+}
+
+var offlineCommand = cli.Command{
+	Name:  "offline",
+	Usage: "build, sign and send txs with the keys kept on an air-gapped machine",
+	Subcommands: []cli.Command{
+		{
+			Name:  "build",
+			Usage: "build an unsigned tx with the nonce and gas fetched from a node",
+			Flags: offlineBuildFlags,
+			Action: func(c *cli.Context) error {
+				OfflineBuild(c)
+				return nil
+			},
+		},
+		{
+			Name:  "sign",
+			Usage: "sign an unsigned tx with a soft wallet file, no node is needed",
+			Flags: offlineSignFlags,
+			Action: func(c *cli.Context) error {
+				OfflineSign(c)
+				return nil
+			},
+		},
+		{
+			Name:  "merge",
+			Usage: "merge the signatures of a multisig tx signed by different signers",
+			Flags: offlineMergeFlags,
+			Action: func(c *cli.Context) error {
+				OfflineMerge(c)
+				return nil
+			},
+		},
+		{
+			Name:  "send",
+			Usage: "send a signed tx to a node",
+			Flags: offlineSendFlags,
+			Action: func(c *cli.Context) error {
+				OfflineSend(c)
+				return nil
+			},
+		},
+	},
+}
+
+// build the unsigned tx, the params of -p are:
+//
+//	normal: from,to,value[,data]
+//	register: from,stake
+//	unstake, cancel: from
+//	create: from,value with --wasm --abi --input
+//	call: from,to,value with --func-name --input
+//	lock: from,to,hashLock,timeLock,value
+//	claim: from,alice,hashKey
+//	refund: from,bob
+//	evidence: from,target with --vote-a --vote-b
+//	multisig-create: from,threshold,value,signer1[,signer2...]
+//	multisig: multisigAccount,to,value[,data]
+func OfflineBuild(c *cli.Context) {
+	rpcClient, err := getOfflineRpcClient(c)
+	if err != nil {
+		l.Error("get the rpc client", "err", err)
+		return
+	}
+
+	offlineTx, err := buildOfflineTx(c, rpcClient)
+	if err != nil {
+		l.Error("build the unsigned tx", "err", err)
+		return
+	}
+
+	var out []byte
+	switch c.String("format") {
+	case OfflineFormatJson:
+		out, err = json.MarshalIndent(offlineTx, "", "  ")
+		if err != nil {
+			l.Error("encode the unsigned tx", "err", err)
+			return
+		}
+	case OfflineFormatRlp:
+		out = []byte(offlineTx.Tx.String())
+	default:
+		l.Error("the parameter format invalid", "format", c.String("format"))
+		return
+	}
+
+	if err = writeOfflineOutput(c.String("out"), out); err != nil {
+		l.Error("write the unsigned tx", "err", err)
+		return
+	}
+	l.Info("OfflineBuild result", "from", offlineTx.From.Hex(), "chainId", offlineTx.ChainId.ToInt())
+}
+
+// sign the unsigned tx with the key in the wallet file, it never connects to a node
+func OfflineSign(c *cli.Context) {
+	offlineTx, err := readOfflineTx(c)
+	if err != nil {
+		l.Error("read the unsigned tx", "err", err)
+		return
+	}
+
+	var tx model.Transaction
+	if err = rlp.DecodeBytes(offlineTx.Tx, &tx); err != nil {
+		l.Error("decode the unsigned tx", "err", err)
+		return
+	}
+	printOfflineTx(offlineTx, &tx)
+
+	walletPath := c.String("wallet")
+	if walletPath == "" {
+		l.Error("the wallet path is need")
+		return
+	}
+	password, err := readOfflinePassword(c)
+	if err != nil {
+		l.Error("read the wallet password", "err", err)
+		return
+	}
+
+	wallet, err := soft_wallet.NewSoftWallet()
+	if err != nil {
+		l.Error("new soft wallet", "err", err)
+		return
+	}
+	if err = wallet.Open(walletPath, filepath.Base(walletPath), password); err != nil {
+		l.Error("open the wallet", "err", err)
+		return
+	}
+	defer wallet.Close()
+
+	if tx.MultiSig() != nil {
+		signOfflineMultiSigTx(c, wallet, offlineTx, &tx)
+		return
+	}
+
+	signedTx, err := wallet.SignTx(accounts.Account{Address: offlineTx.From}, &tx, offlineTx.ChainId.ToInt())
+	if err != nil {
+		l.Error("sign the tx", "err", err)
+		return
+	}
+	txRlp, err := rlp.EncodeToBytes(signedTx)
+	if err != nil {
+		l.Error("encode the signed tx", "err", err)
+		return
+	}
+
+	if err = writeOfflineOutput(c.String("out"), []byte(hexutil.Encode(txRlp))); err != nil {
+		l.Error("write the signed tx", "err", err)
+		return
+	}
+	l.Info("OfflineSign result", "txId", signedTx.CalTxId().Hex())
+}
+
+// add the signature of the signer to the multisig tx, the tx is written in the json format again
+// so that the other signers can sign it or it can be merged with their copies
+func signOfflineMultiSigTx(c *cli.Context, wallet *soft_wallet.SoftWallet, offlineTx *OfflineTx, tx *model.Transaction) {
+	if !tx.MultiSig().Info.Address().IsEqual(offlineTx.From) {
+		l.Error("the from isn't the multisig account of the tx", "from", offlineTx.From.Hex(), "account", tx.MultiSig().Info.Address().Hex())
+		return
+	}
+	signer, err := CheckAndChangeHexToAddress(c.String("signer"))
+	if err != nil {
+		l.Error("the signer of the multisig tx is invalid", "err", err)
+		return
+	}
+
+	s := model.NewSigner(offlineTx.ChainId.ToInt())
+	hash, err := s.GetSignHash(tx)
+	if err != nil {
+		l.Error("get the sign hash", "err", err)
+		return
+	}
+	sig, err := wallet.SignHash(accounts.Account{Address: signer}, hash[:])
+	if err != nil {
+		l.Error("sign the tx", "err", err)
+		return
+	}
+	if err = tx.AddMultiSigSignature(sig, s); err != nil {
+		l.Error("add the multisig signature", "err", err)
+		return
+	}
+
+	if err = writeOfflineTx(c.String("out"), offlineTx, tx); err != nil {
+		l.Error("write the signed tx", "err", err)
+		return
+	}
+	l.Info("OfflineSign result", "signer", signer.Hex(), "signatures", len(tx.MultiSig().Sigs), "threshold", tx.MultiSig().Info.Threshold)
+}
+
+// merge the signatures of the copies of a multisig tx signed by different signers
+func OfflineMerge(c *cli.Context) {
+	paths := c.StringSlice("in")
+	if len(paths) < 2 {
+		l.Error("merge need at least two signed txs")
+		return
+	}
+
+	var merged *OfflineTx
+	var mergedTx *model.Transaction
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			l.Error("read the signed tx", "path", path, "err", err)
+			return
+		}
+		offlineTx, err := parseOfflineTx(strings.TrimSpace(string(content)))
+		if err != nil {
+			l.Error("the signed tx invalid", "path", path, "err", err)
+			return
+		}
+		var tx model.Transaction
+		if err = rlp.DecodeBytes(offlineTx.Tx, &tx); err != nil {
+			l.Error("decode the signed tx", "path", path, "err", err)
+			return
+		}
+
+		if merged == nil {
+			merged, mergedTx = offlineTx, &tx
+			continue
+		}
+		if !merged.From.IsEqual(offlineTx.From) || merged.ChainId == nil || offlineTx.ChainId == nil || merged.ChainId.ToInt().Cmp(offlineTx.ChainId.ToInt()) != 0 {
+			l.Error("the from or the chain id of the signed txs are different", "path", path)
+			return
+		}
+		if err = mergedTx.CombineMultiSig(&tx); err != nil {
+			l.Error("merge the signed tx", "path", path, "err", err)
+			return
+		}
+	}
+
+	if err := writeOfflineTx(c.String("out"), merged, mergedTx); err != nil {
+		l.Error("write the merged tx", "err", err)
+		return
+	}
+	l.Info("OfflineMerge result", "signatures", len(mergedTx.MultiSig().Sigs), "threshold", mergedTx.MultiSig().Info.Threshold)
+}
+
+// send the signed tx through the raw tx endpoint
+func OfflineSend(c *cli.Context) {
+	param, err := readOfflineParam(c)
+	if err != nil {
+		l.Error("read the signed tx", "err", err)
+		return
+	}
+	// the multisig txs are kept in the json format
+	offlineTx, err := parseOfflineTx(param)
+	if err != nil {
+		l.Error("the signed tx invalid", "err", err)
+		return
+	}
+	txRlp := []byte(offlineTx.Tx)
+	var tx model.Transaction
+	if err = rlp.DecodeBytes(txRlp, &tx); err != nil {
+		l.Error("decode the signed tx", "err", err)
+		return
+	}
+
+	rpcClient, err := getOfflineRpcClient(c)
+	if err != nil {
+		l.Error("get the rpc client", "err", err)
+		return
+	}
+
+	var resp common.Hash
+	if err = rpcClient.Call(&resp, getDipperinRpcMethodByName("NewTransaction"), txRlp); err != nil {
+		l.Error("call send offline transaction", "err", err)
+		return
+	}
+	l.Info("OfflineSend result", "txId", resp.Hex())
+}
+
+func getOfflineRpcClient(c *cli.Context) (RpcClient, error) {
+	if url := c.String("rpc"); url != "" {
+		return dialRpc(url)
+	}
+	if client == nil {
+		return nil, errors.New("the rpc url is need")
+	}
+	return client, nil
+}
+
+func buildOfflineTx(c *cli.Context, rpcClient RpcClient) (*OfflineTx, error) {
+	cParams := getRpcParamFromString(c.String("p"))
+	if len(cParams) == 0 {
+		return nil, errors.New("the parameter from is need")
+	}
+	from, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var chainConfig chain_config.ChainConfig
+	if err = rpcClient.Call(&chainConfig, getDipperinRpcMethodByName("GetChainConfig")); err != nil {
+		return nil, err
+	}
+	if chainConfig.ChainId == nil {
+		return nil, errors.New("the node returned no chain id")
+	}
+
+	nonce, err := getOfflineNonce(c, rpcClient, from)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := getOfflineGasPrice(c, rpcClient)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := newOfflineTx(c, rpcClient, cParams, from, nonce, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	txRlp, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineTx{
+		From:    from,
+		ChainId: (*hexutil.Big)(chainConfig.ChainId),
+		Tx:      txRlp,
+	}, nil
+}
+
+func newOfflineTx(c *cli.Context, rpcClient RpcClient, cParams []string, from common.Address, nonce uint64, gasPrice *big.Int) (*model.Transaction, error) {
+	txType := c.String("type")
+	switch txType {
+	case OfflineTxNormal:
+		if len(cParams) != 3 && len(cParams) != 4 {
+			return nil, errors.New("normal tx need：from to value [data]")
+		}
+		to, err := CheckAndChangeHexToAddress(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		value, err := MoneyValueToCSCoin(cParams[2])
+		if err != nil {
+			return nil, err
+		}
+		var data []byte
+		if len(cParams) == 4 {
+			if data, err = hexutil.Decode(cParams[3]); err != nil {
+				return nil, err
+			}
+		}
+		gasLimit, err := getOfflineGasLimit(c, data)
+		if err != nil {
+			return nil, err
+		}
+		return model.NewTransaction(nonce, to, value, gasPrice, gasLimit, data), nil
+	case OfflineTxRegister:
+		if len(cParams) != 2 {
+			return nil, errors.New("register tx need：from stake")
+		}
+		stake, err := MoneyValueToCSCoin(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineGasLimit(c, nil)
+		if err != nil {
+			return nil, err
+		}
+		return model.NewRegisterTransaction(nonce, stake, gasPrice, gasLimit), nil
+	case OfflineTxUnStake, OfflineTxCancel:
+		if len(cParams) != 1 {
+			return nil, fmt.Errorf("%s tx need：from", txType)
+		}
+		gasLimit, err := getOfflineGasLimit(c, nil)
+		if err != nil {
+			return nil, err
+		}
+		if txType == OfflineTxUnStake {
+			return model.NewUnStakeTransaction(nonce, gasPrice, gasLimit), nil
+		}
+		return model.NewCancelTransaction(nonce, gasPrice, gasLimit), nil
+	case OfflineTxCreate:
+		if len(cParams) != 2 {
+			return nil, errors.New("create tx need：from value")
+		}
+		value, err := MoneyValueToCSCoin(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		data, err := getCreateExtraData(c)
+		if err != nil {
+			return nil, err
+		}
+		to := common.HexToAddress(common.AddressContractCreate)
+		extraData, err := utils.ParseCreateContractData(data)
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineContractGasLimit(c, rpcClient, from, to, value, gasPrice, data, nonce)
+		if err != nil {
+			return nil, err
+		}
+		return model.NewTransaction(nonce, to, value, gasPrice, gasLimit, extraData), nil
+	case OfflineTxCall:
+		if len(cParams) != 3 {
+			return nil, errors.New("call tx need：from to value")
+		}
+		to, err := CheckAndChangeHexToAddress(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		if to.GetAddressType() != common.AddressTypeContractCall {
+			return nil, g_error.ErrInvalidContractType
+		}
+		value, err := MoneyValueToCSCoin(cParams[2])
+		if err != nil {
+			return nil, err
+		}
+		funcName, err := getCalledFuncName(c)
+		if err != nil {
+			return nil, err
+		}
+		// RLP([funcName][param1,param2,param3...])
+		data, err := rlp.EncodeToBytes([]interface{}{funcName, getRpcSpecialParam(c, "input")})
+		if err != nil {
+			return nil, err
+		}
+		abi, err := getOfflineAbi(c, rpcClient, to)
+		if err != nil {
+			return nil, err
+		}
+		extraData, err := utils.ParseCallContractData(abi, data)
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineContractGasLimit(c, rpcClient, from, to, value, gasPrice, data, nonce)
+		if err != nil {
+			return nil, err
+		}
+		return model.NewTransaction(nonce, to, value, gasPrice, gasLimit, extraData), nil
+	case OfflineTxLock:
+		if len(cParams) != 5 {
+			return nil, errors.New("lock tx need：from to hashLock timeLock value")
+		}
+		to, err := CheckAndChangeHexToAddress(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		hashLock, err := hexutil.Decode(cParams[2])
+		if err != nil {
+			return nil, err
+		}
+		timeLock, ok := new(big.Int).SetString(cParams[3], 10)
+		if !ok {
+			return nil, errors.New("the parameter timeLock invalid")
+		}
+		value, err := MoneyValueToCSCoin(cParams[4])
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineGasLimit(c, nil)
+		if err != nil {
+			return nil, err
+		}
+		return model.CreateRawLockTx(nonce, common.BytesToHash(hashLock), timeLock, value, gasPrice, gasLimit, from, to), nil
+	case OfflineTxClaim:
+		if len(cParams) != 3 {
+			return nil, errors.New("claim tx need：from alice hashKey")
+		}
+		alice, err := CheckAndChangeHexToAddress(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		hashKey, err := hexutil.Decode(cParams[2])
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineGasLimit(c, nil)
+		if err != nil {
+			return nil, err
+		}
+		return model.CreateRawClaimTx(nonce, hashKey, big.NewInt(0), gasPrice, gasLimit, alice, from), nil
+	case OfflineTxRefund:
+		if len(cParams) != 2 {
+			return nil, errors.New("refund tx need：from bob")
+		}
+		bob, err := CheckAndChangeHexToAddress(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineGasLimit(c, nil)
+		if err != nil {
+			return nil, err
+		}
+		return model.CreateRawRefundTx(nonce, big.NewInt(0), gasPrice, gasLimit, from, bob), nil
+	case OfflineTxEvidence:
+		if len(cParams) != 2 {
+			return nil, errors.New("evidence tx need：from target")
+		}
+		target, err := CheckAndChangeHexToAddress(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		voteA, err := readOfflineVote(c.String("vote-a"))
+		if err != nil {
+			return nil, err
+		}
+		voteB, err := readOfflineVote(c.String("vote-b"))
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineGasLimit(c, model.NewEvidenceTransaction(nonce, gasPrice, 0, &target, voteA, voteB).ExtraData())
+		if err != nil {
+			return nil, err
+		}
+		return model.NewEvidenceTransaction(nonce, gasPrice, gasLimit, &target, voteA, voteB), nil
+	case OfflineTxMultiSigCreate:
+		if len(cParams) < 4 {
+			return nil, errors.New("multisig-create tx need：from threshold value signer1 [signer2 ...]")
+		}
+		threshold, err := strconv.ParseUint(cParams[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		value, err := MoneyValueToCSCoin(cParams[2])
+		if err != nil {
+			return nil, err
+		}
+		signers := make([]common.Address, 0, len(cParams)-3)
+		for _, param := range cParams[3:] {
+			signer, err := CheckAndChangeHexToAddress(param)
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, signer)
+		}
+		info, err := model.NewMultiSigInfo(threshold, signers)
+		if err != nil {
+			return nil, err
+		}
+		data, err := rlp.EncodeToBytes(info)
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineGasLimit(c, data)
+		if err != nil {
+			return nil, err
+		}
+		return model.NewMultiSigAccountTransaction(nonce, info, value, gasPrice, gasLimit)
+	case OfflineTxMultiSig:
+		if len(cParams) != 3 && len(cParams) != 4 {
+			return nil, errors.New("multisig tx need：multisigAccount to value [data]")
+		}
+		to, err := CheckAndChangeHexToAddress(cParams[1])
+		if err != nil {
+			return nil, err
+		}
+		value, err := MoneyValueToCSCoin(cParams[2])
+		if err != nil {
+			return nil, err
+		}
+		var data []byte
+		if len(cParams) == 4 {
+			if data, err = hexutil.Decode(cParams[3]); err != nil {
+				return nil, err
+			}
+		}
+		info, err := getOfflineMultiSigInfo(rpcClient, from)
+		if err != nil {
+			return nil, err
+		}
+		gasLimit, err := getOfflineGasLimit(c, data)
+		if err != nil {
+			return nil, err
+		}
+		return model.NewMultiSigTransaction(nonce, info, to, value, gasPrice, gasLimit, data), nil
+	}
+	return nil, fmt.Errorf("unknown tx type %v", txType)
+}
+
+func getOfflineNonce(c *cli.Context, rpcClient RpcClient, from common.Address) (uint64, error) {
+	if nonce := c.String("nonce"); nonce != "" {
+		return strconv.ParseUint(nonce, 10, 64)
+	}
+	var nonce uint64
+	if err := rpcClient.Call(&nonce, getDipperinRpcMethodByName("GetTransactionNonce"), from); err != nil {
+		return 0, err
+	}
+	return nonce, nil
+}
+
+func getOfflineGasPrice(c *cli.Context, rpcClient RpcClient) (*big.Int, error) {
+	if gasPrice := c.String("gas-price"); gasPrice != "" {
+		return MoneyValueToCSCoin(gasPrice)
+	}
+	var resp rpc_interface.CurBalanceResp
+	if err := rpcClient.Call(&resp, getDipperinRpcMethodByName("SuggestGasPrice")); err != nil {
+		return nil, err
+	}
+	if resp.Balance == nil {
+		return nil, errors.New("the node returned no gas price")
+	}
+	return resp.Balance.ToInt(), nil
+}
+
+// the txs without contract only cost the intrinsic gas
+func getOfflineGasLimit(c *cli.Context, data []byte) (uint64, error) {
+	if gasLimit := c.String("gas-limit"); gasLimit != "" {
+		return strconv.ParseUint(gasLimit, 10, 64)
+	}
+	return model.IntrinsicGas(data, false, false, true)
+}
+
+func getOfflineContractGasLimit(c *cli.Context, rpcClient RpcClient, from, to common.Address, value, gasPrice *big.Int, data []byte, nonce uint64) (uint64, error) {
+	if gasLimit := c.String("gas-limit"); gasLimit != "" {
+		return strconv.ParseUint(gasLimit, 10, 64)
+	}
+	var resp hexutil.Uint64
+	if err := rpcClient.Call(&resp, getDipperinRpcMethodByName("EstimateGas"), from, to, value, gasPrice, uint64(0), data, &nonce); err != nil {
+		return 0, err
+	}
+	return uint64(resp), nil
+}
+
+func getOfflineAbi(c *cli.Context, rpcClient RpcClient, to common.Address) ([]byte, error) {
+	if abiPath := c.String("abi"); abiPath != "" {
+		return ioutil.ReadFile(abiPath)
+	}
+	var abi json.RawMessage
+	if err := rpcClient.Call(&abi, getDipperinRpcMethodByName("GetABI"), to); err != nil {
+		return nil, err
+	}
+	return abi, nil
+}
+
+// the signer set of the multisig account is fetched from the node and checked against the account address
+func getOfflineMultiSigInfo(rpcClient RpcClient, account common.Address) (*model.MultiSigInfo, error) {
+	if account.GetAddressType() != common.AddressTypeMultiSig {
+		return nil, g_error.ErrNotMultiSigTx
+	}
+	var resp rpc_interface.MultiSigAccountResp
+	if err := rpcClient.Call(&resp, getDipperinRpcMethodByName("GetMultiSigAccount"), account); err != nil {
+		return nil, err
+	}
+	info, err := model.NewMultiSigInfo(resp.Threshold, resp.Signers)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Address().IsEqual(account) {
+		return nil, fmt.Errorf("the signers returned by the node don't belong to the multisig account %v", account.Hex())
+	}
+	return info, nil
+}
+
+// the votes are in the json format of the rpc
+func readOfflineVote(path string) (*model.VoteMsg, error) {
+	if path == "" {
+		return nil, errors.New("the votes of the evidence are need")
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vote model.VoteMsg
+	if err = json.Unmarshal(content, &vote); err != nil {
+		return nil, err
+	}
+	return &vote, nil
+}
+
+// the password is never taken from the command line, it would be kept in the shell history
+func readOfflinePassword(c *cli.Context) (string, error) {
+	if path := c.String("password-file"); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+	return walletPassword()
+}
+
+// read the unsigned tx in the json or rlp format, the flags from and chain-id must agree with the json
+func readOfflineTx(c *cli.Context) (*OfflineTx, error) {
+	param, err := readOfflineParam(c)
+	if err != nil {
+		return nil, err
+	}
+	offlineTx, err := parseOfflineTx(param)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromStr := c.String("from"); fromStr != "" {
+		from, err := CheckAndChangeHexToAddress(fromStr)
+		if err != nil {
+			return nil, err
+		}
+		if !offlineTx.From.IsEmpty() && !offlineTx.From.IsEqual(from) {
+			return nil, fmt.Errorf("the from %v isn't the from %v of the tx", from.Hex(), offlineTx.From.Hex())
+		}
+		offlineTx.From = from
+	}
+	if chainIdStr := c.String("chain-id"); chainIdStr != "" {
+		chainId, ok := new(big.Int).SetString(chainIdStr, 10)
+		if !ok {
+			return nil, errors.New("the parameter chain-id invalid")
+		}
+		if offlineTx.ChainId != nil && offlineTx.ChainId.ToInt().Cmp(chainId) != 0 {
+			return nil, fmt.Errorf("the chain id %v isn't the chain id %v of the tx", chainId, offlineTx.ChainId.ToInt())
+		}
+		offlineTx.ChainId = (*hexutil.Big)(chainId)
+	}
+
+	if offlineTx.From.IsEmpty() {
+		return nil, errors.New("the from address is need")
+	}
+	if offlineTx.ChainId == nil {
+		return nil, errors.New("the chain id is need")
+	}
+	return offlineTx, nil
+}
+
+func parseOfflineTx(param string) (*OfflineTx, error) {
+	var offlineTx OfflineTx
+	if strings.HasPrefix(param, "{") {
+		if err := json.Unmarshal([]byte(param), &offlineTx); err != nil {
+			return nil, err
+		}
+		return &offlineTx, nil
+	}
+	txRlp, err := hexutil.Decode(param)
+	if err != nil {
+		return nil, err
+	}
+	offlineTx.Tx = txRlp
+	return &offlineTx, nil
+}
+
+func readOfflineParam(c *cli.Context) (string, error) {
+	if path := c.String("in"); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	if param := strings.TrimSpace(c.String("p")); param != "" {
+		return param, nil
+	}
+	return "", errors.New("the tx is need")
+}
+
+func writeOfflineTx(path string, offlineTx *OfflineTx, tx *model.Transaction) (err error) {
+	if offlineTx.Tx, err = rlp.EncodeToBytes(tx); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(offlineTx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOfflineOutput(path, out)
+}
+
+func writeOfflineOutput(path string, out []byte) error {
+	if path == "" {
+		fmt.Println(string(out))
+		return nil
+	}
+	return ioutil.WriteFile(path, append(out, '\n'), 0600)
+}
+
+// print what is going to be signed so that it can be checked on the air-gapped machine
+func printOfflineTx(offlineTx *OfflineTx, tx *model.Transaction) {
+	to := "nil"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	l.Info("the tx to sign is:", "from", offlineTx.From.Hex(), "chainId", offlineTx.ChainId.ToInt(), "nonce", tx.Nonce(), "to", to,
+		"value", tx.Amount(), "gasPrice", tx.GetGasPrice(), "gasLimit", tx.GetGasLimit(), "dataLen", len(tx.ExtraData()))
+}
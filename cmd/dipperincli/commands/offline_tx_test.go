@@ -0,0 +1,375 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"encoding/json"
+	"github.com/dipperin/dipperin-core/cmd/dipperin-prompts"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/core/accounts/soft-wallet"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/rpc"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func getOfflineTestApp() *cli.App {
+	app := cli.NewApp()
+	app.Commands = []cli.Command{offlineCommand}
+	return app
+}
+
+func mockOfflineDial(ctrl *gomock.Controller) *MockRpcClient {
+	mockClient := NewMockRpcClient(ctrl)
+	dialRpc = func(url string) (RpcClient, error) {
+		return mockClient, nil
+	}
+	return mockClient
+}
+
+func expectOfflineBuildCalls(mockClient *MockRpcClient, nonce uint64) {
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("GetChainConfig")).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+		result.(*chain_config.ChainConfig).ChainId = big.NewInt(1600)
+		return nil
+	})
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("GetTransactionNonce"), gomock.Any()).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+		*result.(*uint64) = nonce
+		return nil
+	})
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("SuggestGasPrice")).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+		result.(*rpc_interface.CurBalanceResp).Balance = (*hexutil.Big)(big.NewInt(3))
+		return nil
+	})
+}
+
+func readOfflineTestTx(t *testing.T, path string) (*OfflineTx, *model.Transaction) {
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	var offlineTx OfflineTx
+	assert.NoError(t, json.Unmarshal(content, &offlineTx))
+	var tx model.Transaction
+	assert.NoError(t, rlp.DecodeBytes(offlineTx.Tx, &tx))
+	return &offlineTx, &tx
+}
+
+func TestOfflineBuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer func() {
+		dialRpc = func(url string) (RpcClient, error) {
+			return rpc.DialHTTP(url)
+		}
+	}()
+
+	dir, err := ioutil.TempDir("", "offline_build")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "unsigned.json")
+
+	// no rpc url and no console client
+	app := getOfflineTestApp()
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "-p", from + "," + to + ",10dip", "--out", out}))
+	_, err = os.Stat(out)
+	assert.True(t, os.IsNotExist(err))
+
+	mockClient := mockOfflineDial(ctrl)
+	expectOfflineBuildCalls(mockClient, 5)
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "--rpc", "http://127.0.0.1:3035", "-p", from + "," + to + ",10dip", "--out", out}))
+	offlineTx, tx := readOfflineTestTx(t, out)
+	assert.Equal(t, common.HexToAddress(from), offlineTx.From)
+	assert.Equal(t, big.NewInt(1600), offlineTx.ChainId.ToInt())
+	assert.Equal(t, uint64(5), tx.Nonce())
+	assert.Equal(t, common.HexToAddress(to), *tx.To())
+	assert.Equal(t, big.NewInt(3), tx.GetGasPrice())
+	assert.Equal(t, uint64(21000), tx.GetGasLimit())
+
+	// the gas flags and nonce flag take the place of the node values
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("GetChainConfig")).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+		result.(*chain_config.ChainConfig).ChainId = big.NewInt(1600)
+		return nil
+	})
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "--rpc", "http://127.0.0.1:3035", "--type", "register", "-p", from + ",100dip",
+		"--nonce", "9", "--gas-price", "2wu", "--gas-limit", "30000", "--out", out}))
+	_, tx = readOfflineTestTx(t, out)
+	assert.Equal(t, common.HexToAddress(common.AddressStake), *tx.To())
+	assert.Equal(t, uint64(9), tx.Nonce())
+	assert.Equal(t, big.NewInt(2), tx.GetGasPrice())
+	assert.Equal(t, uint64(30000), tx.GetGasLimit())
+
+	for _, txType := range []string{OfflineTxUnStake, OfflineTxCancel} {
+		expectOfflineBuildCalls(mockClient, 1)
+		assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "--rpc", "http://127.0.0.1:3035", "--type", txType, "-p", from, "--out", out}))
+		_, tx = readOfflineTestTx(t, out)
+		assert.Equal(t, uint64(1), tx.Nonce())
+	}
+
+	// the evidence tx carries the conflicting votes of the target
+	target := common.HexToAddress(to)
+	voteA := model.CreateSignedVote(1, 2, common.HexToHash("0x123456"), model.VoteMessage)
+	voteB := model.CreateSignedVote(1, 2, common.HexToHash("0x654321"), model.VoteMessage)
+	voteAPath, voteBPath := filepath.Join(dir, "vote_a.json"), filepath.Join(dir, "vote_b.json")
+	for path, vote := range map[string]*model.VoteMsg{voteAPath: voteA, voteBPath: voteB} {
+		content, err := json.Marshal(vote)
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(path, content, 0600))
+	}
+	expectOfflineBuildCalls(mockClient, 2)
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "--rpc", "http://127.0.0.1:3035", "--type", "evidence", "-p", from + "," + to,
+		"--vote-a", voteAPath, "--vote-b", voteBPath, "--out", out}))
+	_, tx = readOfflineTestTx(t, out)
+	assert.Equal(t, cs_crypto.GetEvidenceAddress(target), *tx.To())
+	expectedGas, err := model.IntrinsicGas(tx.ExtraData(), false, false, true)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedGas, tx.GetGasLimit())
+
+	// the multisig account is created by a normal sender
+	signers := []common.Address{common.HexToAddress(from), common.HexToAddress(to)}
+	info, err := model.NewMultiSigInfo(2, signers)
+	assert.NoError(t, err)
+	expectOfflineBuildCalls(mockClient, 3)
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "--rpc", "http://127.0.0.1:3035", "--type", "multisig-create", "-p", from + ",2,10dip," + from + "," + to, "--out", out}))
+	_, tx = readOfflineTestTx(t, out)
+	assert.Equal(t, info.Address(), *tx.To())
+	assert.Nil(t, tx.MultiSig())
+
+	// the tx sent from the multisig account carries the signer set fetched from the node
+	expectOfflineBuildCalls(mockClient, 4)
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("GetMultiSigAccount"), info.Address()).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+		result.(*rpc_interface.MultiSigAccountResp).Threshold = 2
+		result.(*rpc_interface.MultiSigAccountResp).Signers = signers
+		return nil
+	})
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "--rpc", "http://127.0.0.1:3035", "--type", "multisig", "-p", info.Address().Hex() + "," + to + ",1dip", "--out", out}))
+	offlineTx, tx = readOfflineTestTx(t, out)
+	assert.Equal(t, info.Address(), offlineTx.From)
+	assert.Equal(t, uint64(4), tx.Nonce())
+	assert.True(t, info.Equal(&tx.MultiSig().Info))
+
+	// the node returns a signer set of another account
+	expectOfflineBuildCalls(mockClient, 4)
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("GetMultiSigAccount"), info.Address()).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+		result.(*rpc_interface.MultiSigAccountResp).Threshold = 1
+		result.(*rpc_interface.MultiSigAccountResp).Signers = signers
+		return nil
+	})
+	assert.NoError(t, os.Remove(out))
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "--rpc", "http://127.0.0.1:3035", "--type", "multisig", "-p", info.Address().Hex() + "," + to + ",1dip", "--out", out}))
+	_, err = os.Stat(out)
+	assert.True(t, os.IsNotExist(err))
+
+	// wrong params are rejected before anything is written
+	expectOfflineBuildCalls(mockClient, 1)
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "build", "--rpc", "http://127.0.0.1:3035", "--type", "unknown", "-p", from, "--out", out}))
+	_, err = os.Stat(out)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOfflineSign(t *testing.T) {
+	dir := filepath.Join(util.HomeDir(), "tmp", "testOfflineWallet")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+	walletPath := filepath.Join(dir, "wallet")
+	out := filepath.Join(dir, "signed")
+
+	wallet, err := soft_wallet.NewSoftWallet()
+	assert.NoError(t, err)
+	_, err = wallet.Establish(walletPath, "wallet", "12345678", "")
+	assert.NoError(t, err)
+	walletAccounts, err := wallet.Accounts()
+	assert.NoError(t, err)
+	signer := walletAccounts[0].Address
+
+	tx := model.NewTransaction(3, common.HexToAddress(to), big.NewInt(10), big.NewInt(1), 21000, nil)
+	txRlp, err := rlp.EncodeToBytes(tx)
+	assert.NoError(t, err)
+	content, err := json.Marshal(&OfflineTx{From: signer, ChainId: (*hexutil.Big)(big.NewInt(1600)), Tx: txRlp})
+	assert.NoError(t, err)
+	in := filepath.Join(dir, "unsigned.json")
+	assert.NoError(t, ioutil.WriteFile(in, content, 0600))
+	passwordFile := filepath.Join(dir, "password")
+	assert.NoError(t, ioutil.WriteFile(passwordFile, []byte("12345678\n"), 0600))
+	wrongPasswordFile := filepath.Join(dir, "wrong_password")
+	assert.NoError(t, ioutil.WriteFile(wrongPasswordFile, []byte("87654321"), 0600))
+
+	app := getOfflineTestApp()
+	// the chain id doesn't agree with the tx
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "sign", "--in", in, "--wallet", walletPath, "--password-file", passwordFile, "--chain-id", "1", "--out", out}))
+	_, err = os.Stat(out)
+	assert.True(t, os.IsNotExist(err))
+
+	// the wrong password
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "sign", "--in", in, "--wallet", walletPath, "--password-file", wrongPasswordFile, "--out", out}))
+	_, err = os.Stat(out)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "sign", "--in", in, "--wallet", walletPath, "--password-file", passwordFile, "--out", out}))
+	signedTx := decodeOfflineSignedTx(t, out)
+	sender, err := signedTx.Sender(model.NewSigner(big.NewInt(1600)))
+	assert.NoError(t, err)
+	assert.Equal(t, signer, sender)
+	assert.Equal(t, uint64(3), signedTx.Nonce())
+
+	// the rlp format needs from and chain id from the flags
+	assert.NoError(t, os.Remove(out))
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "sign", "-p", hexutil.Encode(txRlp), "--wallet", walletPath, "--password-file", passwordFile, "--out", out}))
+	_, err = os.Stat(out)
+	assert.True(t, os.IsNotExist(err))
+
+	walletPassword = func() (string, error) {
+		return "12345678", nil
+	}
+	defer func() {
+		walletPassword = dipperin_prompts.WalletPassword
+	}()
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "sign", "-p", hexutil.Encode(txRlp), "--wallet", walletPath, "--from", signer.Hex(), "--chain-id", "1600", "--out", out}))
+	signedTx = decodeOfflineSignedTx(t, out)
+	sender, err = signedTx.Sender(model.NewSigner(big.NewInt(1600)))
+	assert.NoError(t, err)
+	assert.Equal(t, signer, sender)
+}
+
+func TestOfflineMultiSig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer func() {
+		dialRpc = func(url string) (RpcClient, error) {
+			return rpc.DialHTTP(url)
+		}
+	}()
+
+	dir := filepath.Join(util.HomeDir(), "tmp", "testOfflineMultiSigWallet")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+	passwordFile := filepath.Join(dir, "password")
+
+	// the two signers keep their keys in different wallets
+	var walletPaths []string
+	var signers []common.Address
+	for _, name := range []string{"wallet1", "wallet2"} {
+		wallet, err := soft_wallet.NewSoftWallet()
+		assert.NoError(t, err)
+		walletPath := filepath.Join(dir, name)
+		_, err = wallet.Establish(walletPath, name, "12345678", "")
+		assert.NoError(t, err)
+		walletAccounts, err := wallet.Accounts()
+		assert.NoError(t, err)
+		walletPaths = append(walletPaths, walletPath)
+		signers = append(signers, walletAccounts[0].Address)
+	}
+	assert.NoError(t, ioutil.WriteFile(passwordFile, []byte("12345678"), 0600))
+
+	info, err := model.NewMultiSigInfo(2, signers)
+	assert.NoError(t, err)
+	tx := model.NewMultiSigTransaction(3, info, common.HexToAddress(to), big.NewInt(10), big.NewInt(1), 21000, nil)
+	txRlp, err := rlp.EncodeToBytes(tx)
+	assert.NoError(t, err)
+	content, err := json.Marshal(&OfflineTx{From: info.Address(), ChainId: (*hexutil.Big)(big.NewInt(1600)), Tx: txRlp})
+	assert.NoError(t, err)
+	in := filepath.Join(dir, "unsigned.json")
+	assert.NoError(t, ioutil.WriteFile(in, content, 0600))
+
+	app := getOfflineTestApp()
+	signed := []string{filepath.Join(dir, "signed1.json"), filepath.Join(dir, "signed2.json")}
+	// the signer isn't in the wallet
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "sign", "--in", in, "--wallet", walletPaths[0], "--password-file", passwordFile, "--signer", signers[1].Hex(), "--out", signed[0]}))
+	_, err = os.Stat(signed[0])
+	assert.True(t, os.IsNotExist(err))
+
+	for i := range signers {
+		assert.NoError(t, app.Run([]string{os.Args[0], "offline", "sign", "--in", in, "--wallet", walletPaths[i], "--password-file", passwordFile, "--signer", signers[i].Hex(), "--out", signed[i]}))
+		offlineTx, signedTx := readOfflineTestTx(t, signed[i])
+		assert.Equal(t, info.Address(), offlineTx.From)
+		assert.Len(t, signedTx.MultiSig().Sigs, 1)
+	}
+
+	// one signature isn't enough
+	merged := filepath.Join(dir, "merged.json")
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "merge", "--in", signed[0], "--out", merged}))
+	_, err = os.Stat(merged)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "merge", "--in", signed[0], "--in", signed[1], "--out", merged}))
+	_, mergedTx := readOfflineTestTx(t, merged)
+	assert.Len(t, mergedTx.MultiSig().Sigs, 2)
+	sender, err := mergedTx.Sender(model.NewSigner(big.NewInt(1600)))
+	assert.NoError(t, err)
+	assert.Equal(t, info.Address(), sender)
+
+	// the merged json is sent as it is
+	mergedRlp, err := rlp.EncodeToBytes(mergedTx)
+	assert.NoError(t, err)
+	mockClient := mockOfflineDial(ctrl)
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("NewTransaction"), mergedRlp).Return(nil)
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "send", "--rpc", "http://127.0.0.1:3035", "--in", merged}))
+}
+
+func decodeOfflineSignedTx(t *testing.T, path string) *model.Transaction {
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	txRlp, err := hexutil.Decode(string(content[:len(content)-1]))
+	assert.NoError(t, err)
+	var tx model.Transaction
+	assert.NoError(t, rlp.DecodeBytes(txRlp, &tx))
+	return &tx
+}
+
+func TestOfflineSend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer func() {
+		dialRpc = func(url string) (RpcClient, error) {
+			return rpc.DialHTTP(url)
+		}
+	}()
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	tx := model.NewTransaction(0, common.HexToAddress(to), big.NewInt(10), big.NewInt(1), 21000, nil)
+	signedTx, err := tx.SignTx(key, model.NewSigner(big.NewInt(1600)))
+	assert.NoError(t, err)
+	txRlp, err := rlp.EncodeToBytes(signedTx)
+	assert.NoError(t, err)
+
+	app := getOfflineTestApp()
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "send", "--rpc", "http://127.0.0.1:3035"}))
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "send", "--rpc", "http://127.0.0.1:3035", "-p", "0x1234"}))
+
+	mockClient := mockOfflineDial(ctrl)
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("NewTransaction"), txRlp).Return(testErr)
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "send", "--rpc", "http://127.0.0.1:3035", "-p", hexutil.Encode(txRlp)}))
+
+	// the console client is used without the rpc url
+	client = mockClient
+	defer func() {
+		client = nil
+	}()
+	mockClient.EXPECT().Call(gomock.Any(), getDipperinRpcMethodByName("NewTransaction"), txRlp).Return(nil)
+	assert.NoError(t, app.Run([]string{os.Args[0], "offline", "send", "-p", hexutil.Encode(txRlp)}))
+}
@@ -533,6 +533,46 @@ func (caller *rpcCaller) SetMineGasConfig(c *cli.Context) {
 	l.Info("setting MinerGasConfig success")
 }
 
+// GetWorkerBalance prints the pool balance of the worker, or the balances of all the workers without the address
+func (caller *rpcCaller) GetWorkerBalance(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	var resp []*rpc_interface.WorkerBalanceResp
+	if len(cParams) == 0 || cParams[0] == "" {
+		if err = client.Call(&resp, getDipperinRpcMethodByName("GetWorkerBalances")); err != nil {
+			l.Error("call worker balances error", "err", err)
+			return
+		}
+	} else {
+		address, err := CheckAndChangeHexToAddress(cParams[0])
+		if err != nil {
+			l.Error("the input address is invalid", "err", err)
+			return
+		}
+		var balance rpc_interface.WorkerBalanceResp
+		if err = client.Call(&balance, getDipperinRpcMethodByName(mName), address); err != nil {
+			l.Error("call worker balance error", "err", err)
+			return
+		}
+		resp = append(resp, &balance)
+	}
+
+	for _, balance := range resp {
+		credited, err1 := CSCoinToMoneyValue(balance.Balance)
+		immature, err2 := CSCoinToMoneyValue(balance.Immature)
+		paid, err3 := CSCoinToMoneyValue(balance.Paid)
+		if err1 != nil || err2 != nil || err3 != nil {
+			l.Error("the worker balance is invalid", "address", balance.Address.Hex())
+			continue
+		}
+		l.Info("worker pool balance is:", "address", balance.Address.Hex(), "balance", credited, "immature", immature, "paid", paid)
+	}
+}
+
 func (caller *rpcCaller) SendTx(c *cli.Context) {
 	if checkSync() {
 		return
@@ -693,6 +733,58 @@ func (caller *rpcCaller) Transaction(c *cli.Context) {
 	printTransactionInfo(resp)
 }
 
+// getAddressAndPage parses the params of the paged queries: address [offset] [limit]
+func getAddressAndPage(cParams []string) (address common.Address, offset, limit uint64, err error) {
+	if address, err = CheckAndChangeHexToAddress(cParams[0]); err != nil {
+		return
+	}
+
+	var page [2]uint64
+	for i, param := range cParams[1:] {
+		if page[i], err = strconv.ParseUint(param, 10, 64); err != nil {
+			return
+		}
+	}
+	return address, page[0], page[1], nil
+}
+
+// GetTransactionsByAddress prints a page of the txs sent or received by the address from the latest one
+func (caller *rpcCaller) GetTransactionsByAddress(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) < 1 || len(cParams) > 3 {
+		l.Error("GetTransactionsByAddress need：address [offset] [limit]")
+		return
+	}
+
+	address, offset, limit, err := getAddressAndPage(cParams)
+	if err != nil {
+		l.Error("the input address, offset or limit is invalid", "err", err)
+		return
+	}
+
+	var resp []*rpc_interface.AddressTxResp
+	if err = client.Call(&resp, getDipperinRpcMethodByName(mName), address, offset, limit); err != nil {
+		l.Error("call GetTransactionsByAddress error", "err", err)
+		return
+	}
+
+	l.Info("the txs of the address", "address", address.Hex(), "count", len(resp))
+	for _, tx := range resp {
+		printTransactionInfo(rpc_interface.TransactionResp{
+			Transaction: tx.Transaction,
+			BlockHash:   tx.BlockHash,
+			BlockNumber: tx.BlockNumber,
+			TxIndex:     tx.TxIndex,
+		})
+		fmt.Printf("the tx is sent:%v, received:%v\r\n", tx.Sent, tx.Received)
+	}
+}
+
 func (caller *rpcCaller) GetReceiptByTxHash(c *cli.Context) {
 	_, cParams, err := getRpcMethodAndParam(c)
 	if err != nil {
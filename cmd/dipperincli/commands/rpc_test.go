@@ -560,6 +560,50 @@ func TestRpcCaller_SetMineGasConfig(t *testing.T) {
 	client = nil
 }
 
+func TestRpcCaller_GetWorkerBalance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		caller.GetWorkerBalance(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		c.Set("p", "")
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_getWorkerBalances").Return(testErr)
+		caller.GetWorkerBalance(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_getWorkerBalances").DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*[]*rpc_interface.WorkerBalanceResp) = []*rpc_interface.WorkerBalanceResp{{
+				Balance:  (*hexutil.Big)(big.NewInt(1)),
+				Immature: (*hexutil.Big)(big.NewInt(2)),
+				Paid:     (*hexutil.Big)(big.NewInt(3)),
+			}}
+			return nil
+		})
+		caller.GetWorkerBalance(c)
+
+		c.Set("p", "address")
+		caller.GetWorkerBalance(c)
+
+		c.Set("p", from)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_getWorkerBalance", gomock.Any()).Return(testErr)
+		caller.GetWorkerBalance(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_getWorkerBalance", gomock.Any()).Return(nil)
+		caller.GetWorkerBalance(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "GetWorkerBalance"}))
+	client = nil
+}
+
 func TestRpcCaller_SendTx(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -718,6 +762,50 @@ func TestRpcCaller_Transaction(t *testing.T) {
 	client = nil
 }
 
+func TestRpcCaller_GetTransactionsByAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		caller := &rpcCaller{}
+		caller.GetTransactionsByAddress(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		c.Set("p", "")
+		caller.GetTransactionsByAddress(c)
+
+		c.Set("p", "address")
+		caller.GetTransactionsByAddress(c)
+
+		c.Set("p", from+",offset")
+		caller.GetTransactionsByAddress(c)
+
+		c.Set("p", from+",1,10,1")
+		caller.GetTransactionsByAddress(c)
+
+		c.Set("p", from+",1,10")
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_getTransactionsByAddress", gomock.Any(), uint64(1), uint64(10)).Return(testErr)
+		caller.GetTransactionsByAddress(c)
+
+		c.Set("p", from)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "dipperin_getTransactionsByAddress", gomock.Any(), uint64(0), uint64(0)).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			tx, _ := factory.CreateTestTx()
+			*result.(*[]*rpc_interface.AddressTxResp) = []*rpc_interface.AddressTxResp{{Transaction: tx, Sent: true}}
+			return nil
+		})
+		caller.GetTransactionsByAddress(c)
+	}
+
+	assert.NoError(t, app.Run([]string{os.Args[0], "GetTransactionsByAddress"}))
+	client = nil
+}
+
 func TestRpcCaller_GetReceiptByTxHash(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,156 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/urfave/cli"
+	"strings"
+)
+
+// getTxPoolRpcMethodByName get the rpc method name based on the method name
+func getTxPoolRpcMethodByName(mName string) string {
+	lm := strings.ToLower(string(mName[0])) + mName[1:]
+	return "txpool_" + lm
+}
+
+// decodeTxHashes decodes the tx hashes of the parameters
+func decodeTxHashes(cParams []string) ([]common.Hash, error) {
+	hashes := make([]common.Hash, len(cParams))
+	for i := range cParams {
+		tmpHash, err := hexutil.Decode(strings.TrimSpace(cParams[i]))
+		if err != nil {
+			return nil, err
+		}
+		copy(hashes[i][:], tmpHash)
+	}
+	return hashes, nil
+}
+
+func (caller *rpcCaller) TxPoolContent(c *cli.Context) {
+	var resp rpc_interface.TxPoolContentResp
+	if err := client.Call(&resp, getTxPoolRpcMethodByName("Content")); err != nil {
+		l.Error("call tx pool content error", "err", err)
+		return
+	}
+	fmt.Println(util.StringifyJson(resp))
+}
+
+func (caller *rpcCaller) TxPoolStatus(c *cli.Context) {
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) < 1 {
+		l.Error("TxPoolStatus need：txHash1,txHash2...")
+		return
+	}
+
+	hashes, err := decodeTxHashes(cParams)
+	if err != nil {
+		l.Error("TxPoolStatus decode error", "err", err)
+		return
+	}
+
+	var resp []string
+	if err = client.Call(&resp, getTxPoolRpcMethodByName("Status"), hashes); err != nil {
+		l.Error("call tx pool status error", "err", err)
+		return
+	}
+	for i := range resp {
+		l.Info("tx status", "txId", hashes[i].Hex(), "status", resp[i])
+	}
+}
+
+func (caller *rpcCaller) TxPoolStats(c *cli.Context) {
+	var resp rpc_interface.TxPoolStatsResp
+	if err := client.Call(&resp, getTxPoolRpcMethodByName("Stats")); err != nil {
+		l.Error("call tx pool stats error", "err", err)
+		return
+	}
+	l.Info("tx pool stats", "pending", resp.Pending, "queued", resp.Queued)
+}
+
+func (caller *rpcCaller) DropTransaction(c *cli.Context) {
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 1 {
+		l.Error("DropTransaction need：txHash")
+		return
+	}
+
+	hashes, err := decodeTxHashes(cParams)
+	if err != nil {
+		l.Error("DropTransaction decode error", "err", err)
+		return
+	}
+
+	if err = client.Call(nil, getTxPoolRpcMethodByName("DropTransaction"), hashes[0]); err != nil {
+		l.Error("call drop transaction error", "err", err)
+		return
+	}
+	l.Info("drop transaction success", "txId", hashes[0].Hex())
+}
+
+func (caller *rpcCaller) ReplaceTransaction(c *cli.Context) {
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 2 {
+		l.Error("ReplaceTransaction need：txHash,gasPrice")
+		return
+	}
+
+	hashes, err := decodeTxHashes(cParams[:1])
+	if err != nil {
+		l.Error("ReplaceTransaction decode error", "err", err)
+		return
+	}
+	gasPrice, err := MoneyValueToCSCoin(cParams[1])
+	if err != nil {
+		l.Error("the parameter gasPrice invalid", "err", err)
+		return
+	}
+
+	var resp common.Hash
+	if err = client.Call(&resp, getTxPoolRpcMethodByName("ReplaceTransaction"), hashes[0], gasPrice); err != nil {
+		l.Error("call replace transaction error", "err", err)
+		return
+	}
+	l.Info("replace transaction success", "old txId", hashes[0].Hex(), "new txId", resp.Hex())
+}
+
+func (caller *rpcCaller) FlushTxJournal(c *cli.Context) {
+	if err := client.Call(nil, getTxPoolRpcMethodByName("FlushJournal")); err != nil {
+		l.Error("call flush tx journal error", "err", err)
+		return
+	}
+	l.Info("flush tx journal success")
+}
@@ -0,0 +1,178 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"os"
+	"testing"
+)
+
+func Test_getTxPoolRpcMethodByName(t *testing.T) {
+	assert.Equal(t, "txpool_content", getTxPoolRpcMethodByName("Content"))
+}
+
+func Test_decodeTxHashes(t *testing.T) {
+	hash := common.HexToHash("0x1234")
+	hashes, err := decodeTxHashes([]string{hash.Hex(), " " + hash.Hex()})
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Hash{hash, hash}, hashes)
+
+	_, err = decodeTxHashes([]string{"1234"})
+	assert.Error(t, err)
+}
+
+func Test_rpcCaller_TxPoolContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_content").Return(testErr)
+		caller.TxPoolContent(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_content").Return(nil)
+		caller.TxPoolContent(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_stats").Return(testErr)
+		caller.TxPoolStats(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_stats").DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*rpc_interface.TxPoolStatsResp) = rpc_interface.TxPoolStatsResp{Pending: 1}
+			return nil
+		})
+		caller.TxPoolStats(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_flushJournal").Return(testErr)
+		caller.FlushTxJournal(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_flushJournal").Return(nil)
+		caller.FlushTxJournal(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0], "TxPoolContent"}))
+	client = nil
+}
+
+func Test_rpcCaller_TxPoolStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(context *cli.Context) {
+		c := &rpcCaller{}
+		c.TxPoolStatus(context)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		c.Set("p", "")
+		caller.TxPoolStatus(c)
+
+		c.Set("p", "test")
+		caller.TxPoolStatus(c)
+
+		c.Set("p", common.HexToHash("0x1234").Hex())
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_status", gomock.Any()).Return(testErr)
+		caller.TxPoolStatus(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_status", gomock.Any()).DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*[]string) = []string{"pending"}
+			return nil
+		})
+		caller.TxPoolStatus(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0], "TxPoolStatus"}))
+	client = nil
+}
+
+func Test_rpcCaller_DropTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(context *cli.Context) {
+		c := &rpcCaller{}
+		c.DropTransaction(context)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		c.Set("p", "")
+		caller.DropTransaction(c)
+
+		c.Set("p", "test")
+		caller.DropTransaction(c)
+
+		c.Set("p", common.HexToHash("0x1234").Hex())
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_dropTransaction", gomock.Any()).Return(testErr)
+		caller.DropTransaction(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_dropTransaction", gomock.Any()).Return(nil)
+		caller.DropTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0], "DropTransaction"}))
+	client = nil
+}
+
+func Test_rpcCaller_ReplaceTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := getRpcTestApp()
+	app.Action = func(context *cli.Context) {
+		c := &rpcCaller{}
+		c.ReplaceTransaction(context)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0]}))
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+		hash := common.HexToHash("0x1234").Hex()
+
+		c.Set("p", hash)
+		caller.ReplaceTransaction(c)
+
+		c.Set("p", "test,2wu")
+		caller.ReplaceTransaction(c)
+
+		c.Set("p", hash+",test")
+		caller.ReplaceTransaction(c)
+
+		c.Set("p", hash+",2wu")
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_replaceTransaction", gomock.Any(), gomock.Any()).Return(testErr)
+		caller.ReplaceTransaction(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "txpool_replaceTransaction", gomock.Any(), gomock.Any()).Return(nil)
+		caller.ReplaceTransaction(c)
+	}
+	assert.NoError(t, app.Run([]string{os.Args[0], "ReplaceTransaction"}))
+	client = nil
+}
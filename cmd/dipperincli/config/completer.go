@@ -29,6 +29,8 @@ var Commands = []prompt.Suggest{
 	{Text: "tx", Description: "tx method"},
 	{Text: "chain", Description: "chain method"},
 	{Text: "personal", Description: "personal method"},
+	{Text: "txpool", Description: "txpool method"},
+	{Text: "offline", Description: "offline tx method"},
 	{Text: "exit", Description: "exit"},
 }
 
@@ -118,6 +120,10 @@ func getSuggestFromModuleName(moduleName string) []prompt.Suggest {
 		suggest = personalMethods
 	case "miner":
 		suggest = minerMethods
+	case "txpool":
+		suggest = txPoolMethods
+	case "offline":
+		suggest = offlineMethods
 	}
 	return suggest
 }
@@ -133,7 +139,7 @@ func argumentsCompleterNew(args []string) []prompt.Suggest {
 	//fmt.Println("argumentsCompleterNew", "args", args)
 
 	switch first {
-	case "miner", "m", "verifier", "chain", "tx", "personal":
+	case "miner", "m", "verifier", "chain", "tx", "personal", "txpool", "offline":
 		if l == 2 {
 			second := strings.TrimSpace(args[1])
 			var subCommands []prompt.Suggest
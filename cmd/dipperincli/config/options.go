@@ -68,8 +68,10 @@ func optionCompleterNew(args []string, long bool) []prompt.Suggest {
 		switch commandArgs[0] {
 		case "tx":
 			suggests = txPromptFlags
-		case "chain", "verifier", "personal", "miner":
+		case "chain", "verifier", "personal", "miner", "txpool":
 			suggests = commonFlags
+		case "offline":
+			suggests = offlinePromptFlags
 		}
 	}
 
@@ -108,6 +110,29 @@ var txPromptFlags = []prompt.Suggest{
 	{Text: "--func-name", Description: "the function to call"},
 }
 
+var offlinePromptFlags = []prompt.Suggest{
+	{Text: "-p", Description: "parameters"},
+	{Text: "--rpc", Description: "http url of the node"},
+	{Text: "--type", Description: "tx type to build"},
+	{Text: "--gas-price", Description: "gas price, suggested by the node by default"},
+	{Text: "--gas-limit", Description: "gas limit, estimated by default"},
+	{Text: "--nonce", Description: "nonce, fetched from the node by default"},
+	{Text: "--abi", Description: "abi path"},
+	{Text: "--wasm", Description: "wasm path"},
+	{Text: "--input", Description: "contract parameters"},
+	{Text: "--func-name", Description: "the function to call"},
+	{Text: "--format", Description: "json or rlp"},
+	{Text: "--wallet", Description: "soft wallet file path"},
+	{Text: "--vote-a", Description: "json file of the first vote of the evidence"},
+	{Text: "--vote-b", Description: "json file of the conflicting vote of the evidence"},
+	{Text: "--password-file", Description: "file of the wallet password"},
+	{Text: "--from", Description: "signer address"},
+	{Text: "--signer", Description: "signer address of the multisig tx"},
+	{Text: "--chain-id", Description: "chain id to sign with"},
+	{Text: "--in", Description: "input file"},
+	{Text: "--out", Description: "output file"},
+}
+
 /*func callMethod(args []string, long bool) []prompt.Suggest {
 	l := len(args)
 	if l <= 2 {
@@ -154,6 +179,7 @@ var minerMethods = []prompt.Suggest{
 	{Text: "SetMineCoinBase", Description: ""},
 	{Text: "StartMine", Description: ""},
 	{Text: "StopMine", Description: ""},
+	{Text: "GetWorkerBalance", Description: "get the pool balances of the workers"},
 }
 
 var txMethods = []prompt.Suggest{
@@ -162,12 +188,14 @@ var txMethods = []prompt.Suggest{
 	{Text: "ERC20Approve", Description: ""},
 	{Text: "ERC20Balance", Description: ""},
 	{Text: "ERC20GetInfo", Description: ""},
+	{Text: "ERC20Holders", Description: "get the holders of the token from the largest balance"},
 	//{Text: "ERC20TokenDecimals", Description: ""},
 	//{Text: "ERC20TokenName", Description: ""},
 	//{Text: "ERC20TokenSymbol", Description: ""},
 	//{Text: "ERC20TotalSupply", Description: ""},
 	{Text: "ERC20Transfer", Description: ""},
 	{Text: "ERC20TransferFrom", Description: ""},
+	{Text: "ERC20TransfersByAddress", Description: "get the token transfers of the address from the latest one"},
 	{Text: "SendCancelTransaction", Description: ""},
 	{Text: "SendCancelTx", Description: ""},
 	{Text: "SendUnStakeTransaction", Description: ""},
@@ -177,11 +205,22 @@ var txMethods = []prompt.Suggest{
 	{Text: "SendTransaction", Description: ""},
 	{Text: "SendTransactionContract", Description: ""},
 	{Text: "SendTx", Description: ""},
+	{Text: "SendLockTransaction", Description: ""},
+	{Text: "SendClaimTransaction", Description: ""},
+	{Text: "SendRefundTransaction", Description: ""},
+	{Text: "GetLockInfo", Description: ""},
+	{Text: "CreateMultiSigAccount", Description: ""},
+	{Text: "GetMultiSigAccount", Description: ""},
+	{Text: "NewMultiSigTransaction", Description: ""},
+	{Text: "SignMultiSigTransaction", Description: ""},
+	{Text: "CombineMultiSigTransaction", Description: ""},
+	{Text: "SendMultiSigTransaction", Description: ""},
 	{Text: "TransferEDIPToDIP", Description: ""},
 	{Text: "GetContractAddressByTxHash", Description: ""},
 	{Text: "CallContract", Description: ""},
 	{Text: "EstimateGas", Description: ""},
 	{Text: "Transaction", Description: ""},
+	{Text: "GetTransactionsByAddress", Description: "get the txs of the address from the latest one"},
 }
 
 var chainMethods = []prompt.Suggest{
@@ -200,6 +239,22 @@ var chainMethods = []prompt.Suggest{
 	{Text: "SuggestGasPrice", Description: ""},
 }
 
+var txPoolMethods = []prompt.Suggest{
+	{Text: "TxPoolContent", Description: ""},
+	{Text: "TxPoolStatus", Description: ""},
+	{Text: "TxPoolStats", Description: ""},
+	{Text: "DropTransaction", Description: ""},
+	{Text: "ReplaceTransaction", Description: ""},
+	{Text: "FlushTxJournal", Description: ""},
+}
+
+var offlineMethods = []prompt.Suggest{
+	{Text: "build", Description: "build an unsigned tx"},
+	{Text: "sign", Description: "sign a tx with a wallet file"},
+	{Text: "merge", Description: "merge the signatures of a multisig tx"},
+	{Text: "send", Description: "send a signed tx"},
+}
+
 var verifierMethods = []prompt.Suggest{
 	// verifier
 	{Text: "GetCurVerifiers", Description: ""},
@@ -232,6 +287,7 @@ var verifierMethods = []prompt.Suggest{
 	{Text: "SetMineCoinBase", Description: ""},
 	{Text: "StartMine", Description: ""},
 	{Text: "StopMine", Description: ""},
+	{Text: "GetWorkerBalance", Description: "get the pool balances of the workers"},
 
 	// chain
 	{Text: "AddPeer", Description: ""},
@@ -247,12 +303,14 @@ var verifierMethods = []prompt.Suggest{
 	{Text: "ERC20Approve", Description: ""},
 	{Text: "ERC20Balance", Description: ""},
 	{Text: "ERC20GetInfo", Description: ""},
+	{Text: "ERC20Holders", Description: "get the holders of the token from the largest balance"},
 	//{Text: "ERC20TokenDecimals", Description: ""},
 	//{Text: "ERC20TokenName", Description: ""},
 	//{Text: "ERC20TokenSymbol", Description: ""},
 	//{Text: "ERC20TotalSupply", Description: ""},
 	{Text: "ERC20Transfer", Description: ""},
 	{Text: "ERC20TransferFrom", Description: ""},
+	{Text: "ERC20TransfersByAddress", Description: "get the token transfers of the address from the latest one"},
 	{Text: "SendCancelTransaction", Description: ""},
 	{Text: "SendCancelTx", Description: ""},
 	{Text: "SendUnStakeTransaction", Description: ""},
@@ -263,6 +321,7 @@ var verifierMethods = []prompt.Suggest{
 	{Text: "SendTransactionContract", Description: ""},
 	{Text: "SendTx", Description: ""},
 	{Text: "Transaction", Description: ""},
+	{Text: "GetTransactionsByAddress", Description: "get the txs of the address from the latest one"},
 	{Text: "GetContractAddressByTxHash", Description: ""},
 	{Text: "GetConvertReceiptByTxHash", Description: ""},
 	{Text: "GetReceiptByTxHash", Description: ""},
@@ -0,0 +1,139 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// signer runs the signer daemon that keeps the keys of a verifier away from its node. The node signs through it with
+// the remote_signer flag, the daemon refuses to sign two different blocks at the same height, round and vote type.
+package main
+
+import (
+	"github.com/dipperin/dipperin-core/cmd/base"
+	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/core/accounts/remote-signer"
+	"github.com/dipperin/dipperin-core/core/accounts/soft-wallet"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/urfave/cli"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	walletPathFlagName = "soft_wallet_path"
+	walletPwdFlagName  = "soft_wallet_pwd"
+	ipcPathFlagName    = "ipc_path"
+	httpsAddrFlagName  = "https_addr"
+	tlsCertFlagName    = "tls_cert"
+	tlsKeyFlagName     = "tls_key"
+	tlsCAFlagName      = "tls_ca"
+	slashingDBFlagName = "slashing_db"
+)
+
+func main() {
+	dataDir := filepath.Join(util.HomeDir(), ".dipperin_signer")
+	app := base.NewApp("dipperin signer", "the remote signer of the dipperin verifiers")
+	app.Action = action
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  walletPathFlagName,
+			Usage: "the soft wallet file holding the keys",
+		},
+		cli.StringFlag{
+			Name:  walletPwdFlagName,
+			Usage: "the password of the soft wallet",
+		},
+		cli.StringFlag{
+			Name:  ipcPathFlagName,
+			Usage: "serve the node on this ipc path",
+			Value: filepath.Join(dataDir, "signer.ipc"),
+		},
+		cli.StringFlag{
+			Name:  httpsAddrFlagName,
+			Usage: "also serve the node over https on this address, the node must present a certificate issued by tls_ca",
+		},
+		cli.StringFlag{
+			Name:  tlsCertFlagName,
+			Usage: "the certificate of the https endpoint",
+		},
+		cli.StringFlag{
+			Name:  tlsKeyFlagName,
+			Usage: "the key of the certificate of the https endpoint",
+		},
+		cli.StringFlag{
+			Name:  tlsCAFlagName,
+			Usage: "the CA which issues the certificates of the nodes",
+		},
+		cli.StringFlag{
+			Name:  slashingDBFlagName,
+			Usage: "the db of the signed votes",
+			Value: filepath.Join(dataDir, "slashing_db"),
+		},
+	}
+	if err := app.Run(os.Args); err != nil {
+		log.Error("signer run failed", "err", err)
+	}
+}
+
+func action(c *cli.Context) {
+	wallet, err := soft_wallet.NewSoftWallet()
+	if err != nil {
+		panic("new soft wallet failed: " + err.Error())
+	}
+	walletPath := c.String(walletPathFlagName)
+	if err = wallet.Open(walletPath, filepath.Base(walletPath), c.String(walletPwdFlagName)); err != nil {
+		panic("open soft wallet failed: " + err.Error())
+	}
+	defer wallet.Close()
+
+	slashingDB, err := remote_signer.OpenSlashingDB(c.String(slashingDBFlagName))
+	if err != nil {
+		panic("open slashing db failed: " + err.Error())
+	}
+	defer slashingDB.Close()
+	api := remote_signer.NewSignerAPI(wallet, slashingDB)
+
+	var listeners []net.Listener
+	if ipcPath := c.String(ipcPathFlagName); ipcPath != "" {
+		listener, _, err := remote_signer.StartIPC(ipcPath, api)
+		if err != nil {
+			panic("start ipc endpoint failed: " + err.Error())
+		}
+		listeners = append(listeners, listener)
+		log.Info("signer ipc endpoint opened", "path", ipcPath)
+	}
+	if httpsAddr := c.String(httpsAddrFlagName); httpsAddr != "" {
+		listener, _, err := remote_signer.StartHTTPS(httpsAddr, &remote_signer.TLSConfig{
+			CertFile: c.String(tlsCertFlagName),
+			KeyFile:  c.String(tlsKeyFlagName),
+			CAFile:   c.String(tlsCAFlagName),
+		}, api)
+		if err != nil {
+			panic("start https endpoint failed: " + err.Error())
+		}
+		listeners = append(listeners, listener)
+		log.Info("signer https endpoint opened", "address", httpsAddr)
+	}
+
+	// the endpoints are closed before the slashing db
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	s := <-sig
+	log.Info("got system signal", "signal", s)
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
@@ -17,6 +17,7 @@
 package utils
 
 import (
+	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/core/chain"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/chain/registerdb"
@@ -26,30 +27,36 @@ import (
 )
 
 func SetupGenesis(dataDir string, cConfig *chain_config.ChainConfig) {
+	if _, err := InitGenesis(dataDir, cConfig); err != nil {
+		panic("setup genesis block failed: " + err.Error())
+	}
+}
+
+// InitGenesis writes the genesis block to the data dir, it fails if the data dir has a different genesis block
+func InitGenesis(dataDir string, cConfig *chain_config.ChainConfig) (common.Hash, error) {
 	cs := chain_state.NewChainState(&chain_state.ChainStateConfig{
 		ChainConfig:   cConfig,
 		DataDir:       dataDir,
 		WriterFactory: chain_writer.NewChainWriterFactory(),
 	})
-	setupGenesis(cs)
-	cs.ChainDB.DB().Close()
+	defer cs.ChainDB.DB().Close()
+	return setupGenesis(cs)
 }
 
-func setupGenesis(cs *chain_state.ChainState) {
+func setupGenesis(cs *chain_state.ChainState) (common.Hash, error) {
 	genesisAccountStateProcessor, err := state_processor.MakeGenesisAccountStateProcessor(cs.StateStorage)
 	if err != nil {
-		panic("open account state processor for genesis failed: " + err.Error())
+		return common.Hash{}, err
 	}
 
 	genesisRegisterProcessor, err := registerdb.MakeGenesisRegisterProcessor(cs.StateStorage)
 	if err != nil {
-		panic("make registerDB processor for genesis failed: " + err.Error())
+		return common.Hash{}, err
 	}
 	// setup genesis block
 	defaultGenesis := chain.DefaultGenesisBlock(cs.ChainDB, genesisAccountStateProcessor, genesisRegisterProcessor,
 		cs.ChainConfig)
 
-	if _, _, err = chain.SetupGenesisBlock(defaultGenesis); err != nil {
-		panic("setup genesis block failed: " + err.Error())
-	}
+	_, hash, err := chain.SetupGenesisBlock(defaultGenesis)
+	return hash, err
 }
@@ -24,4 +24,8 @@ var (
 	ErrIsChangePointDoNotFind   = errors.New("is change point, do not find")
 	ErrNotCurrentOrNextVerifier = errors.New("not current or next verifier")
 	ErrAlreadyStarted           = errors.New("already started")
+
+	/*Fork id errors*/
+	ErrForkIdRemoteStale  = errors.New("the remote peer doesn't schedule the next fork")
+	ErrForkIdIncompatible = errors.New("the fork config of the remote peer is incompatible or the local node is stale")
 )
@@ -29,6 +29,12 @@ var (
 	ErrVerificationRootNotMatch       = errors.New("verification root not match")
 	ErrInvalidBlockHashInVotes        = errors.New("invalid block hash in votes")
 	ErrRegisterRootNotMatch           = errors.New("register root not match")
+	ErrAggregateCommitRequired        = errors.New("block must carry the aggregate commit of the previous block")
+	ErrUnexpectedAggregateCommit      = errors.New("block shouldn't carry an aggregate commit")
+	ErrInvalidAggregateCommit         = errors.New("aggregate commit doesn't match the previous block")
+	ErrInvalidSignerBitmap            = errors.New("invalid signer bitmap in aggregate commit")
+	ErrInvalidAggregateSignature      = errors.New("invalid aggregate signature")
+	ErrVerifierBlsKeyNotExist         = errors.New("verifier hasn't registered a bls key")
 
 	/*Validate block errors*/
 	ErrChainOrBlockIsNil       = errors.New("chain or block is nil")
@@ -50,20 +56,25 @@ var (
 	ErrHeaderGasLimitNotEnough = errors.New("header gas limit not enough compare parent block")
 
 	/*Validate tx errors*/
-	ErrTxRootNotMatch           = errors.New("transaction root not match")
-	ErrTxInSpecialBlock         = errors.New("special block have transactions")
-	ErrTxGasLimitNotEnough      = errors.New("tx gas limit not enough")
-	ErrTxSenderBalanceNotEnough = errors.New("tx sender balance not enough")
-	ErrTxSenderStakeNotEnough   = errors.New("tx sender stake not enough")
-	ErrTxTargetStakeNotEnough   = errors.New("tx target stake not enough")
-	ErrInvalidTxType            = errors.New("invalid tx type, no validator for tx")
-	ErrTxDelegatesNotEnough     = errors.New("register tx delegate is not enough")
-	ValidateSendRegisterTxFirst = errors.New("validate: need to send register tx first")
-	ValidateSendCancelTxFirst   = errors.New("validate: need to send cancel tx first")
-	ErrInvalidEvidenceTime      = errors.New("invalid evidence time")
-	ErrEvidenceVoteNotConflict  = errors.New("evidence vote not conflict")
-	ErrTxTargetAddressNotMatch  = errors.New("tx target address not match")
-	ErrInvalidUnStakeTime       = errors.New("invalid unStake time")
+	ErrTxRootNotMatch            = errors.New("transaction root not match")
+	ErrTxInSpecialBlock          = errors.New("special block have transactions")
+	ErrTxGasLimitNotEnough       = errors.New("tx gas limit not enough")
+	ErrTxSenderBalanceNotEnough  = errors.New("tx sender balance not enough")
+	ErrTxSenderStakeNotEnough    = errors.New("tx sender stake not enough")
+	ErrTxTargetStakeNotEnough    = errors.New("tx target stake not enough")
+	ErrInvalidTxType             = errors.New("invalid tx type, no validator for tx")
+	ErrTxDelegatesNotEnough      = errors.New("register tx delegate is not enough")
+	ValidateSendRegisterTxFirst  = errors.New("validate: need to send register tx first")
+	ValidateSendCancelTxFirst    = errors.New("validate: need to send cancel tx first")
+	ErrInvalidEvidenceTime       = errors.New("invalid evidence time")
+	ErrEvidenceVoteNotConflict   = errors.New("evidence vote not conflict")
+	ErrInvalidEvidenceHeight     = errors.New("invalid evidence height")
+	ErrEvidenceAlreadyProcessed  = errors.New("evidence already processed")
+	ErrEvidenceTargetNotVerifier = errors.New("evidence target isn't verifier at the vote height")
+	ErrTxTargetAddressNotMatch   = errors.New("tx target address not match")
+	ErrInvalidUnStakeTime        = errors.New("invalid unStake time")
+	ErrInvalidBlsRegistration    = errors.New("invalid bls key in register tx")
+	ErrRegisterTxWithoutBlsKey   = errors.New("register tx must carry a bls key")
 
 	/*Insert receipts errors*/
 	ErrReceiptHashNotMatch      = errors.New("receipt hash not match")
@@ -30,4 +30,42 @@ var (
 
 	/*Chain state errors*/
 	ErrBlockNotFound = errors.New("block not found")
+
+	/*Interlink proof errors*/
+	ErrChainTooShortForProof = errors.New("blockchain is too short to get enough proof")
+	ErrInvalidProofSuffix    = errors.New("the suffix length of the proof is invalid")
+	ErrProofGenesisNotMatch  = errors.New("the proof doesn't start with the genesis block")
+	ErrInvalidInterLinkRoot  = errors.New("the interlinks don't match the interlink root")
+	ErrInvalidProofPow       = errors.New("the proof header doesn't meet its difficulty")
+	ErrProofNotConnected     = errors.New("the proof headers aren't linked")
+	ErrSpecialProofNotSigned = errors.New("the special block of the proof isn't signed by a verifier boot node")
+	ErrNoValidProof          = errors.New("no valid proof found")
+
+	/*Fast sync errors*/
+	ErrFastSyncNoBlocks     = errors.New("the peer returns no blocks for the fast sync")
+	ErrStateNodeNotFound    = errors.New("the peer doesn't have the requested state trie nodes")
+	ErrFetchNodeDataTimeout = errors.New("fetch state trie nodes timeout")
+	ErrNoStateServed        = errors.New("the node doesn't serve the state trie nodes")
+	ErrFastSyncSlotNotFound = errors.New("can't get the slot of the fast sync block")
+
+	/*State pruning errors*/
+	ErrStateRetentionTooSmall = errors.New("the state retention is less than the blocks used by the verifier election")
+	ErrPruneUnsupportedDB     = errors.New("the database doesn't support the state pruning")
+	ErrPruneEmptyChain        = errors.New("no block found in the chain to prune")
+
+	/*Tx history errors*/
+	ErrTxHistoryUnsupportedDB  = errors.New("the database doesn't support the iteration of the tx history")
+	ErrTxHistoryDisabled       = errors.New("the tx history index isn't enabled on the node")
+	ErrTxHistoryOffsetTooLarge = errors.New("the offset of the tx history is too large")
+
+	/*Genesis spec errors*/
+	ErrGenesisSpecPathEmpty     = errors.New("the path of the genesis spec file is needed")
+	ErrGenesisSpecMismatch      = errors.New("the data dir has been initialized with a different genesis spec")
+	ErrGenesisInvalidChainId    = errors.New("the chain id and the network id of the genesis spec can't be 0")
+	ErrGenesisVerifierNumber    = errors.New("the number of the genesis verifiers doesn't match the verifier number")
+	ErrGenesisInvalidAddress    = errors.New("invalid address in the genesis spec")
+	ErrGenesisAllocConflict     = errors.New("the genesis alloc conflicts with the pre-mining addresses of the economy model")
+	ErrGenesisInvalidProportion = errors.New("the base number of the economy proportion can't be 0")
+	ErrGenesisInvalidContract   = errors.New("the genesis contract has no code or invalid abi")
+	ErrGenesisDiffAlgorithm     = errors.New("the difficulty algorithm of the genesis spec must be period or lwma")
 )
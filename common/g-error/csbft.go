@@ -21,4 +21,6 @@ import "errors"
 var (
 	ErrIsNotCurVerifierCannotStartBft = errors.New("is not current verifier, can't start bft")
 	ErrCannotLoadSeenCommit           = errors.New("can't load seen commit")
+	ErrBftConflictSign                = errors.New("already signed another block at this height and round")
+	ErrBftSignerWithoutBlsKey         = errors.New("the bft signer can't sign with a bls key")
 )
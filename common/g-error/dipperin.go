@@ -3,5 +3,12 @@ package g_error
 import "errors"
 
 var (
-	NodeConfWalletError = errors.New("the wallet config info error")
+	NodeConfWalletError    = errors.New("the wallet config info error")
+	NodeConfGCModeError    = errors.New("the gc mode must be full or archive")
+	NodeConfRetentionError = errors.New("the state retention is less than the blocks used by the verifier election")
+	NodeConfPoolError      = errors.New("the pool accounting is only run by the mine master")
+	NodeConfRpcAccessError = errors.New("the rpc access config is invalid")
+	NodeConfSignerError    = errors.New("the remote signer replaces the soft wallet and needs the tls files for https")
+	NodeConfTxHistoryError = errors.New("the tx history index needs the txs of all the blocks")
+	NodeConfVmAOTError     = errors.New("the native code of the contracts needs linux amd64 with cgo")
 )
@@ -32,4 +32,7 @@ var (
 	ErrBloombitsNotFound         = errors.New("can't find the bloombits")
 	ErrReceiptIsNil              = errors.New("the transaction receipt is nil")
 	ErrReceiptNotFound           = errors.New("the transaction receipt not found")
+	ErrTransactionNotFound       = errors.New("the transaction is not found")
+	ErrTraceGenesisBlock         = errors.New("the genesis block can't be traced")
+	ErrNotERC20Address           = errors.New("the address isn't a built-in erc20 token")
 )
@@ -14,4 +14,13 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with this program.  If not, see <https://www.gnu.org/licenses/>.
 
-package chain_communication
+package g_error
+
+import "errors"
+
+var (
+	ErrPoolNotEnabled    = errors.New("the pool accounting of the mine master isn't enabled")
+	ErrPoolUnknownScheme = errors.New("the pool scheme must be pplns or prop")
+	ErrPoolEmptyBalance  = errors.New("the worker has no pool balance to pay")
+	ErrPoolNoPayout      = errors.New("the payout sender of the pool isn't set")
+)
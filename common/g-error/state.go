@@ -42,6 +42,17 @@ var (
 	StateSendRegisterTxFirst = errors.New("processor: need to send register tx first")
 	StateSendCancelTxFirst   = errors.New("processor: need to send cancel tx first")
 
+	/*Cross chain processor errors*/
+	ErrLockAddressNotMatch  = errors.New("lock address not match with sender and receiver")
+	ErrLockAlreadyExist     = errors.New("lock address already holds locked money")
+	ErrLockNotExist         = errors.New("lock address holds no locked money")
+	ErrInvalidHashLock      = errors.New("hash lock can not be empty")
+	ErrInvalidTimeLock      = errors.New("time lock should be larger than current block number")
+	ErrHashKeyNotMatch      = errors.New("hash key not match with hash lock")
+	ErrTimeLockExpired      = errors.New("time lock expired, can only refund")
+	ErrTimeLockNotExpired   = errors.New("time lock not expired, can't refund")
+	ErrInvalidCrossTxAmount = errors.New("lock amount should be positive")
+
 	/*Block processor errors*/
 	NotHavePreBlockErr        = errors.New("not have pre block")
 	InvalidCoinBaseAddressErr = errors.New("invalid coinBase address")
@@ -1 +1,30 @@
 package g_error
+
+import "errors"
+
+/*Tx pool errors*/
+var (
+	ErrTxNotInPool        = errors.New("the transaction isn't in the tx pool")
+	ErrReplaceTxNotMatch  = errors.New("the replacement transaction has a different sender or nonce")
+	ErrReplaceUnderpriced = errors.New("the gas price of the replacement transaction isn't bumped enough")
+	ErrTxPoolNoJournal    = errors.New("the local transaction journal isn't enabled")
+	ErrTxNegativeValue    = errors.New("tx value can not be negtive")
+	ErrTxInvalidSender    = errors.New("invalid sender")
+)
+
+/*Multisig errors*/
+var (
+	ErrInvalidMultiSigThreshold = errors.New("multisig threshold should be between 1 and the number of the signers")
+	ErrInvalidMultiSigSigners   = errors.New("multisig signers should be sorted different normal addresses")
+	ErrTooManyMultiSigSigners   = errors.New("too many multisig signers")
+	ErrNotMultiSigTx            = errors.New("the transaction isn't sent from a multisig account")
+	ErrInvalidMultiSigWitness   = errors.New("invalid multisig witness")
+	ErrNotMultiSigSigner        = errors.New("the address isn't a signer of the multisig account")
+	ErrMultiSigNotEnoughSigs    = errors.New("the multisig transaction isn't signed by enough signers")
+	ErrMultiSigTxNotMatch       = errors.New("the multisig transactions to combine are different")
+	ErrMultiSigAddressNotMatch  = errors.New("multisig address not match with the signers and the threshold")
+	ErrMultiSigAccountNotExist  = errors.New("the multisig account doesn't exist")
+	ErrMultiSigInfoNotMatch     = errors.New("the signers of the multisig transaction not match with the account")
+	ErrMultiSigNoPublicKey      = errors.New("the multisig transaction has no single sender public key")
+	ErrMultiSigNotActivated     = errors.New("the multisig transaction isn't valid before the Eris fork")
+)
@@ -1,6 +1,9 @@
 package g_error
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrOutOfGas                  = errors.New("out of gas")
@@ -12,4 +15,17 @@ var (
 	ErrInsufficientBalanceForGas = errors.New("insufficient balance to pay for gas")
 	ErrExecutionReverted         = errors.New("vm: execution reverted")
 	ErrMaxCodeSizeExceeded       = errors.New("vm: max code size exceeded")
+	ErrSelfDestructed            = errors.New("vm: contract self destructed")
 )
+
+// RevertError is the reverted contract execution with the reason given by the contract
+type RevertError struct {
+	Reason string
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return ErrExecutionReverted.Error()
+	}
+	return fmt.Sprintf("%v: %s", ErrExecutionReverted, e.Reason)
+}
@@ -23,6 +23,8 @@ import (
 
 const (
 	NewBlockInsertEvent = "on_new_block"
+	// the transactions ([]model.AbstractTransaction) entering the pending list of the tx pool
+	NewTxPoolEvent = "on_new_pending_txs"
 )
 
 type Subscription interface {
@@ -58,6 +58,9 @@ const (
 
 	CurChainHeight         = "cur_height"
 	FailedInsertBlockCount = "failed_insert_block_count"
+
+	// rpc calls rejected by the access control, labeled by the reason
+	RpcRejectedCount = "rpc_rejected_count"
 )
 
 // call this after NewPrometheusMetricsServer
@@ -87,4 +90,5 @@ func InitCSMetrics() {
 	CreateGauge(QueuedTxCountInPool, "trace tx count", nil)
 	CreateGauge(CurChainHeight, "chain height", nil)
 	CreateCounter(FailedInsertBlockCount, "trace failed insert block", nil)
+	CreateCounter(RpcRejectedCount, "trace rejected rpc calls", []string{"reason"})
 }
@@ -0,0 +1,172 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/dipperin/dipperin-core/core/chain-communication"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// the rpc namespace of the signer daemon
+const signerNamespace = "signer"
+
+type EvaluateResult struct {
+	Index hexutil.Bytes `json:"index"`
+	Proof hexutil.Bytes `json:"proof"`
+}
+
+// SignerAPI is served by the signer daemon, it signs with the accounts of the wallet it's given. There is no
+// endpoint signing a raw hash, every msg is rebuilt and hashed by the daemon, so the node can't get a vote signed
+// without SignVote, which checks it against the slashing db first. The proposals are checked the same way.
+type SignerAPI struct {
+	wallet   accounts.Wallet
+	slashing *SlashingDB
+}
+
+func NewSignerAPI(wallet accounts.Wallet, slashing *SlashingDB) *SignerAPI {
+	return &SignerAPI{wallet: wallet, slashing: slashing}
+}
+
+func (api *SignerAPI) Accounts() ([]common.Address, error) {
+	accs, err := api.wallet.Accounts()
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]common.Address, 0, len(accs))
+	for _, acc := range accs {
+		addresses = append(addresses, acc.Address)
+	}
+	return addresses, nil
+}
+
+func (api *SignerAPI) PublicKey(address common.Address) (hexutil.Bytes, error) {
+	pk, err := api.wallet.GetPKFromAddress(accounts.Account{Address: address})
+	if err != nil {
+		return nil, err
+	}
+	return crypto.FromECDSAPub(pk), nil
+}
+
+// signHash signs the hash of a msg rebuilt by the daemon
+func (api *SignerAPI) signHash(address common.Address, hash common.Hash) (hexutil.Bytes, error) {
+	return api.wallet.SignHash(accounts.Account{Address: address}, hash.Bytes())
+}
+
+// SignProposal signs the proposal after it's recorded in the slashing db
+func (api *SignerAPI) SignProposal(address common.Address, proposal *model2.Proposal) (hexutil.Bytes, error) {
+	if err := api.slashing.CheckAndRecordProposal(address, proposal); err != nil {
+		log.Warn("refuse to sign proposal", "address", address.Hex(), "height", proposal.Height, "round", proposal.Round, "block", proposal.BlockID.Hex(), "err", err)
+		return nil, err
+	}
+	return api.signHash(address, proposal.Hash())
+}
+
+// SignNewRound signs the new round msg, they can't conflict
+func (api *SignerAPI) SignNewRound(address common.Address, msg *model2.NewRoundMsg) (hexutil.Bytes, error) {
+	return api.signHash(address, msg.Hash())
+}
+
+// SignHandshake signs the handshake data of the p2p status msg
+func (api *SignerAPI) SignHandshake(address common.Address, data chain_communication.HandShakeData) (hexutil.Bytes, error) {
+	status := &chain_communication.StatusData{HandShakeData: data}
+	return api.signHash(address, status.DataHash())
+}
+
+// SignTx takes and returns the rlp of the tx
+func (api *SignerAPI) SignTx(address common.Address, tx hexutil.Bytes, chainID *hexutil.Big) (hexutil.Bytes, error) {
+	var unsigned model.Transaction
+	if err := rlp.DecodeBytes(tx, &unsigned); err != nil {
+		return nil, err
+	}
+	signed, err := api.wallet.SignTx(accounts.Account{Address: address}, &unsigned, chainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+func (api *SignerAPI) Evaluate(address common.Address, seed hexutil.Bytes) (*EvaluateResult, error) {
+	index, proof, err := api.wallet.Evaluate(accounts.Account{Address: address}, seed)
+	if err != nil {
+		return nil, err
+	}
+	return &EvaluateResult{Index: index[:], Proof: proof}, nil
+}
+
+// SignVote signs the vote after it's recorded in the slashing db, the bls signature of the commit is added if
+// withBls is set. The hash is computed here, the daemon never signs a vote it hasn't checked.
+func (api *SignerAPI) SignVote(address common.Address, vote *model.VoteMsg, withBls bool) (*model.WitMsg, error) {
+	// the bls signature has no vote type, signing other votes with it would make a commit
+	if withBls && vote.VoteType != model.VoteMessage {
+		return nil, ErrBlsSignNotCommit
+	}
+
+	account := accounts.Account{Address: address}
+	if ok, err := api.wallet.Contains(account); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, accounts.ErrInvalidAddress
+	}
+	var blsKey *bls.SecretKey
+	if withBls {
+		var err error
+		if blsKey, err = api.blsKey(address); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := api.slashing.CheckAndRecord(address, vote); err != nil {
+		log.Warn("refuse to sign vote", "address", address.Hex(), "height", vote.Height, "round", vote.Round, "type", vote.VoteType, "block", vote.BlockID.Hex(), "err", err)
+		return nil, err
+	}
+	sign, err := api.wallet.SignHash(account, vote.Hash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	witness := &model.WitMsg{Address: address, Sign: sign}
+	if blsKey != nil {
+		witness.BlsSign = blsKey.Sign(model.BlsSignHash(vote.Height, vote.Round, vote.BlockID).Bytes()).Marshal()
+	}
+	return witness, nil
+}
+
+// BlsRegistration is the bls public key of the account and its proof of possession, the node puts it in the
+// register tx of the verifier
+func (api *SignerAPI) BlsRegistration(address common.Address) (*model.BlsRegistration, error) {
+	key, err := api.blsKey(address)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewBlsRegistration(key, address), nil
+}
+
+// blsKey loads the bls key of the account, the wallet of the daemon keeps it apart from the account key
+func (api *SignerAPI) blsKey(address common.Address) (*bls.SecretKey, error) {
+	store, ok := api.wallet.(accounts.BlsKeyStore)
+	if !ok {
+		return nil, accounts.ErrNotSupported
+	}
+	return store.BlsSecretKey(accounts.Account{Address: address})
+}
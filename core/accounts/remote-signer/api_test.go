@@ -0,0 +1,245 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"crypto/ecdsa"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/dipperin/dipperin-core/core/chain-communication"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testWallet holds a single key and its bls key, the methods the api doesn't use are left to the nil interface
+type testWallet struct {
+	accounts.Wallet
+	sk      *ecdsa.PrivateKey
+	blsKey  *bls.SecretKey
+	address common.Address
+}
+
+func newTestWallet(t *testing.T) *testWallet {
+	sk, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	blsKey, err := bls.GenerateKey(nil)
+	assert.NoError(t, err)
+	return &testWallet{sk: sk, blsKey: blsKey, address: cs_crypto.GetNormalAddress(sk.PublicKey)}
+}
+
+func (w *testWallet) Accounts() ([]accounts.Account, error) {
+	return []accounts.Account{{Address: w.address}}, nil
+}
+
+func (w *testWallet) Contains(account accounts.Account) (bool, error) {
+	return account.Address.IsEqual(w.address), nil
+}
+
+func (w *testWallet) key(account accounts.Account) (*ecdsa.PrivateKey, error) {
+	if !account.Address.IsEqual(w.address) {
+		return nil, accounts.ErrInvalidAddress
+	}
+	return w.sk, nil
+}
+
+func (w *testWallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	sk, err := w.key(account)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(hash, sk)
+}
+
+func (w *testWallet) BlsSecretKey(account accounts.Account) (*bls.SecretKey, error) {
+	if _, err := w.key(account); err != nil {
+		return nil, err
+	}
+	return w.blsKey, nil
+}
+
+func (w *testWallet) GetPKFromAddress(account accounts.Account) (*ecdsa.PublicKey, error) {
+	sk, err := w.key(account)
+	if err != nil {
+		return nil, err
+	}
+	return &sk.PublicKey, nil
+}
+
+func (w *testWallet) SignTx(account accounts.Account, tx *model.Transaction, chainID *big.Int) (*model.Transaction, error) {
+	sk, err := w.key(account)
+	if err != nil {
+		return nil, err
+	}
+	return tx.SignTx(sk, model.NewSigner(chainID))
+}
+
+func (w *testWallet) Evaluate(account accounts.Account, seed []byte) (index [32]byte, proof []byte, err error) {
+	sk, err := w.key(account)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	index, proof = crypto.Evaluate(sk, seed)
+	return index, proof, nil
+}
+
+func newTestSignerAPI(t *testing.T, dir string) (*SignerAPI, *testWallet) {
+	wallet := newTestWallet(t)
+	db, err := OpenSlashingDB(filepath.Join(dir, "slashing"))
+	assert.NoError(t, err)
+	return NewSignerAPI(wallet, db), wallet
+}
+
+func TestSignerAPI_SignVote(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	api, wallet := newTestSignerAPI(t, dir)
+	defer api.slashing.Close()
+
+	block := common.HexToHash("0xa")
+	vote := model.NewVoteMsg(1, 0, block, model.VoteMessage)
+	witness, err := api.SignVote(wallet.address, vote, true)
+	assert.NoError(t, err)
+	vote.Witness = witness
+	assert.NoError(t, vote.Valid())
+
+	reg, err := api.BlsRegistration(wallet.address)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.blsKey.PublicKey().Marshal(), reg.PubKey)
+	pk, err := bls.UnmarshalPublicKey(reg.PubKey)
+	assert.NoError(t, err)
+	sig, err := bls.UnmarshalSignature(witness.BlsSign)
+	assert.NoError(t, err)
+	assert.True(t, bls.Verify(pk, model.BlsSignHash(1, 0, block).Bytes(), sig))
+
+	// the conflicting vote is refused, the same one is signed again
+	_, err = api.SignVote(wallet.address, model.NewVoteMsg(1, 0, common.HexToHash("0xb"), model.VoteMessage), false)
+	assert.Equal(t, ErrDoubleSign, err)
+	_, err = api.SignVote(wallet.address, model.NewVoteMsg(1, 0, block, model.VoteMessage), false)
+	assert.NoError(t, err)
+
+	_, err = api.SignVote(common.HexToAddress("0x1"), vote, false)
+	assert.Equal(t, accounts.ErrInvalidAddress, err)
+
+	// only the commit vote gets the bls signature, the refused vote isn't recorded
+	preVote := model.NewVoteMsg(2, 0, block, model.PreVoteMessage)
+	_, err = api.SignVote(wallet.address, preVote, true)
+	assert.Equal(t, ErrBlsSignNotCommit, err)
+	witness, err = api.SignVote(wallet.address, preVote, false)
+	assert.NoError(t, err)
+	assert.Len(t, witness.BlsSign, 0)
+}
+
+func TestSignerAPI_SignMsgs(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	api, wallet := newTestSignerAPI(t, dir)
+	defer api.slashing.Close()
+	pk := crypto.FromECDSAPub(&wallet.sk.PublicKey)
+	verify := func(hash common.Hash, sign []byte) bool {
+		return crypto.VerifySignature(pk, hash.Bytes(), sign[:len(sign)-1])
+	}
+
+	proposal := &model2.Proposal{Height: 1, Round: 0, BlockID: common.HexToHash("0xa"), Timestamp: time.Now()}
+	sign, err := api.SignProposal(wallet.address, proposal)
+	assert.NoError(t, err)
+	assert.True(t, verify(proposal.Hash(), sign))
+	_, err = api.SignProposal(wallet.address, &model2.Proposal{Height: 1, Round: 0, BlockID: common.HexToHash("0xb"), Timestamp: time.Now()})
+	assert.Equal(t, ErrDoubleSign, err)
+
+	newRound := &model2.NewRoundMsg{Height: 1, Round: 2}
+	sign, err = api.SignNewRound(wallet.address, newRound)
+	assert.NoError(t, err)
+	assert.True(t, verify(newRound.Hash(), sign))
+
+	status := &chain_communication.StatusData{HandShakeData: chain_communication.HandShakeData{ChainID: big.NewInt(1), NodeName: "verifier", CurrentBlockHeight: 3}}
+	sign, err = api.SignHandshake(wallet.address, status.HandShakeData)
+	assert.NoError(t, err)
+	assert.True(t, verify(status.DataHash(), sign))
+}
+
+// the daemon has no endpoint that signs a hash given by the node, so a vote conflicting with a signed one can't
+// be signed through any of them
+func TestSignerAPI_NoConflictingVote(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	api, wallet := newTestSignerAPI(t, dir)
+	defer api.slashing.Close()
+
+	var methods []string
+	apiType := reflect.TypeOf(api)
+	for i := 0; i < apiType.NumMethod(); i++ {
+		methods = append(methods, apiType.Method(i).Name)
+	}
+	assert.Equal(t, []string{"Accounts", "BlsRegistration", "Evaluate", "PublicKey", "SignHandshake", "SignNewRound", "SignProposal", "SignTx", "SignVote"}, methods)
+
+	vote := model.NewVoteMsg(1, 0, common.HexToHash("0xa"), model.VoteMessage)
+	_, err := api.SignVote(wallet.address, vote, false)
+	assert.NoError(t, err)
+	conflict := model.NewVoteMsg(1, 0, common.HexToHash("0xb"), model.VoteMessage)
+	conflict.Witness = nil
+	hash := conflict.Hash()
+	signsConflict := func(sign []byte) bool {
+		if len(sign) == 0 {
+			return false
+		}
+		address, err := cs_crypto.RecoverAddressFromSig(hash, sign)
+		return err == nil && address.IsEqual(wallet.address)
+	}
+
+	_, err = api.SignVote(wallet.address, conflict, false)
+	assert.Equal(t, ErrDoubleSign, err)
+
+	// the msgs made of the conflicting vote are hashed by the daemon
+	sign, err := api.SignProposal(wallet.address, &model2.Proposal{Height: conflict.Height, Round: conflict.Round, BlockID: conflict.BlockID, Timestamp: conflict.Timestamp})
+	assert.NoError(t, err)
+	assert.False(t, signsConflict(sign))
+	sign, err = api.SignNewRound(wallet.address, &model2.NewRoundMsg{Height: conflict.Height, Round: conflict.Round})
+	assert.NoError(t, err)
+	assert.False(t, signsConflict(sign))
+	sign, err = api.SignHandshake(wallet.address, chain_communication.HandShakeData{ChainID: big.NewInt(1), CurrentBlock: hash, GenesisBlock: conflict.BlockID})
+	assert.NoError(t, err)
+	assert.False(t, signsConflict(sign))
+
+	tx, err := rlp.EncodeToBytes(model.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), big.NewInt(1), 21000, hash.Bytes()))
+	assert.NoError(t, err)
+	signedTx, err := api.SignTx(wallet.address, tx, (*hexutil.Big)(big.NewInt(1)))
+	assert.NoError(t, err)
+	var decoded model.Transaction
+	assert.NoError(t, rlp.DecodeBytes(signedTx, &decoded))
+	txHash, err := model.NewSigner(big.NewInt(1)).GetSignHash(&decoded)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, txHash)
+
+	result, err := api.Evaluate(wallet.address, hash.Bytes())
+	assert.NoError(t, err)
+	assert.False(t, signsConflict(result.Proof))
+	reg, err := api.BlsRegistration(wallet.address)
+	assert.NoError(t, err)
+	assert.False(t, signsConflict(reg.Pop))
+}
@@ -0,0 +1,161 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/chain-communication"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/rpc"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const callTimeout = 10 * time.Second
+
+// Client calls the signer daemon
+type Client struct {
+	endpoint string
+	rpc      *rpc.Client
+}
+
+// Dial connects to the signer daemon. An https:// endpoint is called with the mutual tls in tlsConf, plain http is
+// refused, anything else is taken as the path of the ipc endpoint.
+func Dial(endpoint string, tlsConf *TLSConfig) (*Client, error) {
+	var (
+		client *rpc.Client
+		err    error
+	)
+	switch {
+	case strings.HasPrefix(endpoint, "https://"):
+		conf, err := tlsConf.ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		client, err = rpc.DialHTTPWithClient(endpoint, &http.Client{
+			Transport: &http.Transport{TLSClientConfig: conf},
+			Timeout:   callTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+	case strings.HasPrefix(endpoint, "http://"):
+		return nil, ErrInsecureEndpoint
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+		defer cancel()
+		if client, err = rpc.DialIPC(ctx, endpoint); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{endpoint: endpoint, rpc: client}, nil
+}
+
+func (c *Client) Endpoint() string {
+	return c.endpoint
+}
+
+func (c *Client) Close() {
+	c.rpc.Close()
+}
+
+func (c *Client) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	return c.rpc.CallContext(ctx, result, signerNamespace+"_"+method, args...)
+}
+
+func (c *Client) Accounts() ([]common.Address, error) {
+	var addresses []common.Address
+	err := c.call(&addresses, "accounts")
+	return addresses, err
+}
+
+func (c *Client) PublicKey(address common.Address) (*ecdsa.PublicKey, error) {
+	var pk hexutil.Bytes
+	if err := c.call(&pk, "publicKey", address); err != nil {
+		return nil, err
+	}
+	return crypto.UnmarshalPubkey(pk)
+}
+
+func (c *Client) SignProposal(address common.Address, proposal *model2.Proposal) ([]byte, error) {
+	var sign hexutil.Bytes
+	err := c.call(&sign, "signProposal", address, proposal)
+	return sign, err
+}
+
+func (c *Client) SignNewRound(address common.Address, msg *model2.NewRoundMsg) ([]byte, error) {
+	var sign hexutil.Bytes
+	err := c.call(&sign, "signNewRound", address, msg)
+	return sign, err
+}
+
+func (c *Client) SignHandshake(address common.Address, data chain_communication.HandShakeData) ([]byte, error) {
+	var sign hexutil.Bytes
+	err := c.call(&sign, "signHandshake", address, data)
+	return sign, err
+}
+
+func (c *Client) SignTx(address common.Address, tx *model.Transaction, chainID *big.Int) (*model.Transaction, error) {
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	var signed hexutil.Bytes
+	if err = c.call(&signed, "signTx", address, hexutil.Bytes(data), (*hexutil.Big)(chainID)); err != nil {
+		return nil, err
+	}
+	var signedTx model.Transaction
+	if err = rlp.DecodeBytes(signed, &signedTx); err != nil {
+		return nil, err
+	}
+	return &signedTx, nil
+}
+
+func (c *Client) Evaluate(address common.Address, seed []byte) (index [32]byte, proof []byte, err error) {
+	var result EvaluateResult
+	if err = c.call(&result, "evaluate", address, hexutil.Bytes(seed)); err != nil {
+		return [32]byte{}, nil, err
+	}
+	copy(index[:], result.Index)
+	return index, result.Proof, nil
+}
+
+func (c *Client) SignVote(address common.Address, vote *model.VoteMsg, withBls bool) (*model.WitMsg, error) {
+	var witness model.WitMsg
+	if err := c.call(&witness, "signVote", address, vote, withBls); err != nil {
+		return nil, err
+	}
+	return &witness, nil
+}
+
+func (c *Client) BlsRegistration(address common.Address) (*model.BlsRegistration, error) {
+	var reg model.BlsRegistration
+	if err := c.call(&reg, "blsRegistration", address); err != nil {
+		return nil, err
+	}
+	return &reg, nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/dipperin/dipperin-core/core/chain-communication"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClient_IPC(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	api, testWallet := newTestSignerAPI(t, dir)
+	defer api.slashing.Close()
+
+	endpoint := filepath.Join(dir, "signer.ipc")
+	listener, _, err := StartIPC(endpoint, api)
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	client, err := Dial(endpoint, nil)
+	assert.NoError(t, err)
+	wallet := NewWallet(client)
+	defer wallet.Close()
+	account := accounts.Account{Address: testWallet.address}
+
+	identifier, err := wallet.GetWalletIdentifier()
+	assert.NoError(t, err)
+	assert.Equal(t, accounts.RemoteWallet, identifier.WalletType)
+	contains, err := wallet.Contains(account)
+	assert.NoError(t, err)
+	assert.True(t, contains)
+	pk, err := wallet.GetPKFromAddress(account)
+	assert.NoError(t, err)
+	assert.Equal(t, testWallet.sk.PublicKey, *pk)
+	_, err = wallet.GetSKFromAddress(account.Address)
+	assert.Equal(t, accounts.ErrNotSupported, err)
+
+	tx := model.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(100), big.NewInt(1), 21000, nil)
+	signedTx, err := wallet.SignTx(account, tx, big.NewInt(1600))
+	assert.NoError(t, err)
+	sender, err := signedTx.Sender(model.NewSigner(big.NewInt(1600)))
+	assert.NoError(t, err)
+	assert.Equal(t, account.Address, sender)
+
+	index, proof, err := wallet.Evaluate(account, []byte("seed"))
+	assert.NoError(t, err)
+	proofIndex, err := crypto.ProofToHash(&testWallet.sk.PublicKey, []byte("seed"), proof)
+	assert.NoError(t, err)
+	assert.Equal(t, proofIndex, index)
+
+	reg, err := wallet.BlsRegistration(account)
+	assert.NoError(t, err)
+	regTx, err := model.NewBlsRegisterTransaction(1, big.NewInt(100), big.NewInt(1), 21000, reg)
+	assert.NoError(t, err)
+	_, err = model.DecodeBlsRegistration(regTx.ExtraData(), account.Address)
+	assert.NoError(t, err)
+
+	signer := NewSigner(client, account.Address)
+	assert.Equal(t, crypto.FromECDSAPub(&testWallet.sk.PublicKey), crypto.FromECDSAPub(signer.PublicKey()))
+	assert.Equal(t, testWallet.blsKey.PublicKey().Marshal(), reg.PubKey)
+	_, err = wallet.SignHash(account, common.HexToHash("0x1").Bytes())
+	assert.Equal(t, accounts.ErrNotSupported, err)
+
+	pk = &testWallet.sk.PublicKey
+	proposal := &model2.Proposal{Height: 1, Round: 0, BlockID: common.HexToHash("0xa"), Timestamp: time.Now()}
+	sign, err := signer.SignProposal(proposal)
+	assert.NoError(t, err)
+	assert.NoError(t, signer.ValidSign(proposal.Hash().Bytes(), crypto.FromECDSAPub(pk), sign))
+	newRound := &model2.NewRoundMsg{Height: 1, Round: 1}
+	sign, err = signer.SignNewRound(newRound)
+	assert.NoError(t, err)
+	assert.NoError(t, signer.ValidSign(newRound.Hash().Bytes(), crypto.FromECDSAPub(pk), sign))
+	status := &chain_communication.StatusData{HandShakeData: chain_communication.HandShakeData{ChainID: big.NewInt(1), NodeName: "verifier"}}
+	sign, err = signer.SignHandshake(status.HandShakeData)
+	assert.NoError(t, err)
+	assert.NoError(t, signer.ValidSign(status.DataHash().Bytes(), crypto.FromECDSAPub(pk), sign))
+
+	vote := model.NewVoteMsg(1, 0, common.HexToHash("0xa"), model.VoteMessage)
+	vote.Witness = nil
+	witness, err := signer.SignVote(vote, true)
+	assert.NoError(t, err)
+	assert.Len(t, witness.BlsSign, 64)
+	vote.Witness = witness
+	assert.NoError(t, vote.Valid())
+	_, err = signer.SignVote(model.NewVoteMsg(1, 0, common.HexToHash("0xb"), model.VoteMessage), false)
+	assert.EqualError(t, err, ErrDoubleSign.Error())
+}
+
+func TestDial_Insecure(t *testing.T) {
+	_, err := Dial("http://127.0.0.1:10000", nil)
+	assert.Equal(t, ErrInsecureEndpoint, err)
+	_, err = Dial("https://127.0.0.1:10000", nil)
+	assert.Equal(t, ErrNoTLSConfig, err)
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// writeTestCert issues a certificate by parent, a nil parent makes a self signed CA
+func writeTestCert(t *testing.T, dir, name string, parent *testCert) (*testCert, *TLSConfig) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer := &testCert{template, key}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent = signer
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent.cert, &key.PublicKey, parent.key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	conf := &TLSConfig{
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+	assert.NoError(t, ioutil.WriteFile(conf.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, ioutil.WriteFile(conf.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return &testCert{cert, key}, conf
+}
+
+func TestClient_HTTPS(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	api, testWallet := newTestSignerAPI(t, dir)
+	defer api.slashing.Close()
+
+	ca, caConf := writeTestCert(t, dir, "ca", nil)
+	_, serverConf := writeTestCert(t, dir, "server", ca)
+	_, clientConf := writeTestCert(t, dir, "client", ca)
+	serverConf.CAFile, clientConf.CAFile = caConf.CertFile, caConf.CertFile
+
+	listener, _, err := StartHTTPS("127.0.0.1:0", serverConf, api)
+	assert.NoError(t, err)
+	defer listener.Close()
+	endpoint := "https://" + listener.Addr().String()
+
+	client, err := Dial(endpoint, clientConf)
+	assert.NoError(t, err)
+	defer client.Close()
+	addresses, err := client.Accounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{testWallet.address}, addresses)
+
+	// a client with a certificate of another CA is refused
+	otherCA, otherCAConf := writeTestCert(t, dir, "other_ca", nil)
+	_, otherConf := writeTestCert(t, dir, "other", otherCA)
+	otherConf.CAFile = caConf.CertFile
+	client, err = Dial(endpoint, otherConf)
+	assert.NoError(t, err)
+	defer client.Close()
+	_, err = client.Accounts()
+	assert.Error(t, err)
+
+	// so is a daemon with a certificate of another CA
+	clientConf.CAFile = otherCAConf.CertFile
+	client, err = Dial(endpoint, clientConf)
+	assert.NoError(t, err)
+	defer client.Close()
+	_, err = client.Accounts()
+	assert.Error(t, err)
+}
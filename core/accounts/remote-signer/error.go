@@ -0,0 +1,33 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"errors"
+)
+
+var (
+	ErrDoubleSign = errors.New("refuse to sign another block at the same height, round and vote type")
+
+	ErrInsecureEndpoint = errors.New("the remote signer must be reached by ipc or https")
+
+	ErrNoTLSConfig = errors.New("the https remote signer needs the tls cert, key and CA")
+
+	ErrInvalidCA = errors.New("no certificate found in the CA file")
+
+	ErrBlsSignNotCommit = errors.New("only the commit vote is signed by the bls key")
+)
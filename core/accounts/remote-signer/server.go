@@ -0,0 +1,55 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"crypto/tls"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/rpc"
+	"net"
+)
+
+func (api *SignerAPI) apis() []rpc.API {
+	return []rpc.API{{Namespace: signerNamespace, Version: "1.0", Service: api, Public: false}}
+}
+
+// StartIPC serves the api on the ipc endpoint, only the local users allowed to open the file can call it
+func StartIPC(path string, api *SignerAPI) (net.Listener, *rpc.Server, error) {
+	return rpc.StartIPCEndpoint(path, api.apis())
+}
+
+// StartHTTPS serves the api over https, the callers must present a certificate issued by the CA in tlsConf
+func StartHTTPS(address string, tlsConf *TLSConfig, api *SignerAPI) (net.Listener, *rpc.Server, error) {
+	conf, err := tlsConf.ServerConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	handler := rpc.NewServer()
+	if err := handler.RegisterName(signerNamespace, api); err != nil {
+		return nil, nil, err
+	}
+	listener, err := tls.Listen("tcp", address, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	go func() {
+		if err := rpc.NewHTTPServer(nil, []string{"*"}, rpc.DefaultHTTPTimeouts, handler).Serve(listener); err != nil {
+			log.Info("remote signer https endpoint stopped", "err", err)
+		}
+	}()
+	return listener, handler, nil
+}
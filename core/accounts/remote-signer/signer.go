@@ -0,0 +1,106 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"crypto/ecdsa"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/dipperin/dipperin-core/core/chain-communication"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/dipperin/dipperin-core/core/model"
+	crypto2 "github.com/dipperin/dipperin-core/third-party/crypto"
+	"sync"
+)
+
+// Signer signs the msgs of the verifier with the signer daemon. The msgs are sent to the daemon as a whole, the
+// votes and proposals by SignVote and SignProposal pass its slashing protection.
+type Signer struct {
+	client *Client
+
+	lock    sync.RWMutex
+	address common.Address
+	pk      *ecdsa.PublicKey
+}
+
+func NewSigner(client *Client, address common.Address) *Signer {
+	return &Signer{client: client, address: address}
+}
+
+func (signer *Signer) GetAddress() common.Address {
+	signer.lock.RLock()
+	defer signer.lock.RUnlock()
+	return signer.address
+}
+
+func (signer *Signer) SetBaseAddress(address common.Address) {
+	signer.lock.Lock()
+	defer signer.lock.Unlock()
+	if !signer.address.IsEqual(address) {
+		signer.address = address
+		signer.pk = nil
+	}
+}
+
+// SignHash isn't supported, the msgs are signed by the typed methods below
+func (signer *Signer) SignHash(hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+func (signer *Signer) SignProposal(proposal *model2.Proposal) ([]byte, error) {
+	return signer.client.SignProposal(signer.GetAddress(), proposal)
+}
+
+func (signer *Signer) SignNewRound(msg *model2.NewRoundMsg) ([]byte, error) {
+	return signer.client.SignNewRound(signer.GetAddress(), msg)
+}
+
+func (signer *Signer) SignHandshake(data chain_communication.HandShakeData) ([]byte, error) {
+	return signer.client.SignHandshake(signer.GetAddress(), data)
+}
+
+func (signer *Signer) PublicKey() *ecdsa.PublicKey {
+	signer.lock.Lock()
+	defer signer.lock.Unlock()
+	if signer.pk == nil {
+		pk, err := signer.client.PublicKey(signer.address)
+		if err != nil {
+			return nil
+		}
+		signer.pk = pk
+	}
+	return signer.pk
+}
+
+func (signer *Signer) ValidSign(hash []byte, pubKey []byte, sign []byte) error {
+	if len(sign) == 0 {
+		return accounts.ErrEmptySign
+	}
+	if crypto2.VerifySignature(pubKey, hash, sign[:len(sign)-1]) {
+		return nil
+	}
+	return accounts.ErrSignatureInvalid
+}
+
+func (signer *Signer) Evaluate(account accounts.Account, seed []byte) (index [32]byte, proof []byte, err error) {
+	return signer.client.Evaluate(account.Address, seed)
+}
+
+// SignVote lets the daemon sign the vote, it refuses the votes conflicting with the ones it has signed
+func (signer *Signer) SignVote(vote *model.VoteMsg, withBls bool) (*model.WitMsg, error) {
+	return signer.client.SignVote(signer.GetAddress(), vote, withBls)
+}
@@ -0,0 +1,100 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/dipperin/dipperin-core/common"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"sync"
+)
+
+var (
+	slashingVotePrefix     = []byte("v")
+	slashingProposalPrefix = []byte("p")
+)
+
+// SlashingDB remembers the block of every vote the daemon signed, a verifier signing two different blocks at the
+// same height, round and vote type can be slashed with the conflicting votes. The record is synced to disk before
+// the vote is signed, so a crash of the daemon can't make it forget a signed vote.
+type SlashingDB struct {
+	lock sync.Mutex
+	db   *leveldb.DB
+}
+
+func OpenSlashingDB(path string) (*SlashingDB, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &SlashingDB{db: db}, nil
+}
+
+// prefix + address + height + round
+func slashingKey(prefix []byte, address common.Address, height, round uint64) []byte {
+	key := make([]byte, 0, len(prefix)+common.AddressLength+17)
+	key = append(key, prefix...)
+	key = append(key, address.Bytes()...)
+	var num [8]byte
+	binary.BigEndian.PutUint64(num[:], height)
+	key = append(key, num[:]...)
+	binary.BigEndian.PutUint64(num[:], round)
+	return append(key, num[:]...)
+}
+
+// prefix + address + height + round + vote type
+func slashingVoteKey(address common.Address, vote *model.VoteMsg) []byte {
+	return append(slashingKey(slashingVotePrefix, address, vote.Height, vote.Round), byte(vote.VoteType))
+}
+
+// CheckAndRecord records the block of the vote, it returns ErrDoubleSign if another block has been signed at the
+// same height, round and vote type. Signing the same block again is allowed, a restarted node may ask for it.
+func (s *SlashingDB) CheckAndRecord(address common.Address, vote *model.VoteMsg) error {
+	return s.checkAndRecord(slashingVoteKey(address, vote), vote.BlockID)
+}
+
+// CheckAndRecordProposal records the block of the proposal the same way, a proposer can only propose one block
+// at a height and round
+func (s *SlashingDB) CheckAndRecordProposal(address common.Address, proposal *model2.Proposal) error {
+	return s.checkAndRecord(slashingKey(slashingProposalPrefix, address, proposal.Height, proposal.Round), proposal.BlockID)
+}
+
+func (s *SlashingDB) checkAndRecord(key []byte, block common.Hash) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	signed, err := s.db.Get(key, nil)
+	switch err {
+	case nil:
+		if !bytes.Equal(signed, block.Bytes()) {
+			return ErrDoubleSign
+		}
+		return nil
+	case leveldb.ErrNotFound:
+		return s.db.Put(key, block.Bytes(), &opt.WriteOptions{Sync: true})
+	default:
+		return err
+	}
+}
+
+func (s *SlashingDB) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,68 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "remote_signer_test")
+	assert.NoError(t, err)
+	return dir
+}
+
+func TestSlashingDB_CheckAndRecord(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "slashing")
+
+	db, err := OpenSlashingDB(path)
+	assert.NoError(t, err)
+	address := common.HexToAddress("0x000062be10f46b5d01Ecd9b502c4bA3d6131f6fc2e41")
+	blockA, blockB := common.HexToHash("0xa"), common.HexToHash("0xb")
+
+	assert.NoError(t, db.CheckAndRecord(address, model.NewVoteMsg(1, 0, blockA, model.VoteMessage)))
+	assert.NoError(t, db.CheckAndRecord(address, model.NewVoteMsg(1, 0, blockA, model.VoteMessage)))
+	assert.Equal(t, ErrDoubleSign, db.CheckAndRecord(address, model.NewVoteMsg(1, 0, blockB, model.VoteMessage)))
+
+	// another round, vote type or address isn't a conflict
+	assert.NoError(t, db.CheckAndRecord(address, model.NewVoteMsg(1, 1, blockB, model.VoteMessage)))
+	assert.NoError(t, db.CheckAndRecord(address, model.NewVoteMsg(1, 0, blockB, model.PreVoteMessage)))
+	assert.NoError(t, db.CheckAndRecord(common.HexToAddress("0x1"), model.NewVoteMsg(1, 0, blockB, model.VoteMessage)))
+	assert.NoError(t, db.Close())
+
+	// the records survive a restart
+	db, err = OpenSlashingDB(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.Equal(t, ErrDoubleSign, db.CheckAndRecord(address, model.NewVoteMsg(1, 0, blockB, model.VoteMessage)))
+	assert.NoError(t, db.CheckAndRecord(address, model.NewVoteMsg(1, 0, blockA, model.VoteMessage)))
+
+	// the proposals are recorded apart from the votes
+	assert.NoError(t, db.CheckAndRecordProposal(address, &model2.Proposal{Height: 1, Round: 0, BlockID: blockB}))
+	assert.NoError(t, db.CheckAndRecordProposal(address, &model2.Proposal{Height: 1, Round: 0, BlockID: blockB}))
+	assert.Equal(t, ErrDoubleSign, db.CheckAndRecordProposal(address, &model2.Proposal{Height: 1, Round: 0, BlockID: blockA}))
+	assert.NoError(t, db.CheckAndRecordProposal(address, &model2.Proposal{Height: 1, Round: 1, BlockID: blockA}))
+}
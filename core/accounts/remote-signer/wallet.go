@@ -0,0 +1,161 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package remote_signer
+
+import (
+	"crypto/ecdsa"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"math/big"
+	"sync"
+)
+
+// Wallet is the accounts of the signer daemon, the node signs with them but never holds the keys. The wallet can't
+// be established, restored or derived from the node, that's done where the daemon runs. Raw hashes aren't signed,
+// so the multisig txs can't be signed with it either.
+type Wallet struct {
+	client *Client
+
+	lock   sync.RWMutex
+	nonces map[common.Address]uint64
+}
+
+func NewWallet(client *Client) *Wallet {
+	return &Wallet{client: client, nonces: make(map[common.Address]uint64)}
+}
+
+func (w *Wallet) GetWalletIdentifier() (accounts.WalletIdentifier, error) {
+	return accounts.WalletIdentifier{
+		WalletType: accounts.RemoteWallet,
+		Path:       w.client.Endpoint(),
+		WalletName: "remote signer",
+	}, nil
+}
+
+func (w *Wallet) Status() (string, error) {
+	return accounts.Opened, nil
+}
+
+func (w *Wallet) Establish(path, name, password, passPhrase string) (string, error) {
+	return "", accounts.ErrNotSupported
+}
+
+func (w *Wallet) RestoreWallet(path, name, password, passPhrase, mnemonic string, GetAddressRelatedInfo accounts.AddressInfoReader) (err error) {
+	return accounts.ErrNotSupported
+}
+
+func (w *Wallet) Open(path, name, password string) error {
+	return accounts.ErrNotSupported
+}
+
+func (w *Wallet) Close() error {
+	w.client.Close()
+	return nil
+}
+
+func (w *Wallet) PaddingAddressNonce(GetAddressRelatedInfo accounts.AddressInfoReader) (err error) {
+	accs, err := w.Accounts()
+	if err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, account := range accs {
+		currentNonce, err := GetAddressRelatedInfo.GetTransactionNonce(account.Address)
+		if err != nil {
+			log.Warn("padding address nonce failed", "address", account.Address.Hex(), "err", err)
+		}
+		w.nonces[account.Address] = currentNonce
+	}
+	return nil
+}
+
+func (w *Wallet) GetAddressNonce(address common.Address) (nonce uint64, err error) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.nonces[address], nil
+}
+
+func (w *Wallet) SetAddressNonce(address common.Address, nonce uint64) (err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.nonces[address] = nonce
+	return nil
+}
+
+func (w *Wallet) Accounts() ([]accounts.Account, error) {
+	addresses, err := w.client.Accounts()
+	if err != nil {
+		return nil, err
+	}
+	accs := make([]accounts.Account, 0, len(addresses))
+	for _, address := range addresses {
+		accs = append(accs, accounts.Account{Address: address})
+	}
+	return accs, nil
+}
+
+func (w *Wallet) Contains(account accounts.Account) (bool, error) {
+	accs, err := w.Accounts()
+	if err != nil {
+		return false, err
+	}
+	for _, acc := range accs {
+		if acc.Address.IsEqual(account.Address) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+func (w *Wallet) SelfDerive(base accounts.DerivationPath) error {
+	return accounts.ErrNotSupported
+}
+
+// SignHash isn't supported, the daemon only signs the msgs it can rebuild itself
+func (w *Wallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+func (w *Wallet) GetPKFromAddress(account accounts.Account) (*ecdsa.PublicKey, error) {
+	return w.client.PublicKey(account.Address)
+}
+
+// GetSKFromAddress isn't supported, the keys never leave the signer daemon
+func (w *Wallet) GetSKFromAddress(address common.Address) (*ecdsa.PrivateKey, error) {
+	return nil, accounts.ErrNotSupported
+}
+
+func (w *Wallet) SignTx(account accounts.Account, tx *model.Transaction, chainID *big.Int) (*model.Transaction, error) {
+	return w.client.SignTx(account.Address, tx, chainID)
+}
+
+func (w *Wallet) Evaluate(account accounts.Account, seed []byte) (index [32]byte, proof []byte, err error) {
+	return w.client.Evaluate(account.Address, seed)
+}
+
+// BlsRegistration implements accounts.BlsRegistrar, the bls key stays in the daemon
+func (w *Wallet) BlsRegistration(account accounts.Account) (*model.BlsRegistration, error) {
+	return w.client.BlsRegistration(account.Address)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	crypto2 "github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"github.com/dipperin/dipperin-core/third-party/go-bip39"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/tidwall/gjson"
@@ -589,6 +590,33 @@ func (w *SoftWallet) SignHash(account accounts.Account, hash []byte) ([]byte, er
 	return signData, nil
 }
 
+//Get the bls key of the account, it's generated and written to the wallet file when it's first asked for
+func (w *SoftWallet) BlsSecretKey(account accounts.Account) (*bls.SecretKey, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.status != accounts.Opened {
+		return nil, accounts.ErrWalletNotOpen
+	}
+	if _, ok := w.walletInfo.ExtendKeys[account.Address]; !ok {
+		return nil, accounts.ErrInvalidAddress
+	}
+	if blsKey, ok := w.walletInfo.BlsKeys[account.Address]; ok {
+		return bls.UnmarshalSecretKey(blsKey)
+	}
+
+	key, err := bls.GenerateKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	w.walletInfo.BlsKeys[account.Address] = key.Marshal()
+	if err = w.encryptWalletAndWriteFile(CloseWallet); err != nil {
+		delete(w.walletInfo.BlsKeys, account.Address)
+		return nil, err
+	}
+	return key, nil
+}
+
 //Sign the transaction with its corresponding private key based on the incoming account
 func (w *SoftWallet) SignTx(account accounts.Account, tx *model.Transaction, chainID *big.Int) (*model.Transaction, error) {
 	// Transaction signature operation with the private key based on the account
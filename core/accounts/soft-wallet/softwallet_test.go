@@ -257,6 +257,40 @@ func TestSoftWallet_SignHash(t *testing.T) {
 	os.Remove(path)
 }
 
+func TestSoftWallet_BlsSecretKey(t *testing.T) {
+	testWallet, err := GetTestWallet()
+	assert.NoError(t, err)
+	account := testWallet.walletInfo.Accounts[0]
+
+	testWallet.Close()
+	_, err = testWallet.BlsSecretKey(account)
+	assert.Equal(t, accounts.ErrWalletNotOpen, err)
+
+	err = testWallet.Open(path, walletName, password)
+	assert.NoError(t, err)
+
+	_, err = testWallet.BlsSecretKey(errAccount)
+	assert.Equal(t, accounts.ErrInvalidAddress, err)
+
+	key, err := testWallet.BlsSecretKey(account)
+	assert.NoError(t, err)
+	same, err := testWallet.BlsSecretKey(account)
+	assert.NoError(t, err)
+	assert.Equal(t, key.PublicKey().Marshal(), same.PublicKey().Marshal())
+
+	//the bls key is written to the wallet file once it's generated
+	reopened, err := NewSoftWallet()
+	assert.NoError(t, err)
+	err = reopened.Open(path, walletName, password)
+	assert.NoError(t, err)
+	stored, err := reopened.BlsSecretKey(account)
+	assert.NoError(t, err)
+	assert.Equal(t, key.PublicKey().Marshal(), stored.PublicKey().Marshal())
+
+	testWallet.Close()
+	os.Remove(path)
+}
+
 func TestSoftWallet_SignTx(t *testing.T) {
 	testWallet, err := GetTestWallet()
 	assert.NoError(t, err)
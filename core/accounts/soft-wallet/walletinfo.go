@@ -59,6 +59,8 @@ type WalletInfo struct {
 	//the used largest index in wallet derivation path, the key is the changeValue to identify the derived path, and the value is the largest index used.
 	DerivedPathIndex map[uint32]uint32
 	Seed             []byte //Wallet seed
+	//The bls keys of the verifier accounts, they're generated apart from the seed, so they can't be restored from the mnemonic
+	BlsKeys map[common.Address][]byte
 
 	//Get the balance and nonce value corresponding to the address
 	lock sync.RWMutex
@@ -74,6 +76,7 @@ func NewHdWalletInfo() (info *WalletInfo) {
 		Nonce:            make(map[common.Address]uint64, 0),
 		DerivedPathIndex: make(map[uint32]uint32, 0),
 		Seed:             make([]byte, 0),
+		BlsKeys:          make(map[common.Address][]byte),
 	}
 
 	return &walletInfo
@@ -97,7 +100,8 @@ type WalletInfoJson struct {
 	Balances         map[string]*big.Int                `json:"balances"`
 	Nonce            map[string]uint64
 	DerivedPathIndex map[uint32]uint32
-	Seed             []byte `json:"seed"`
+	Seed             []byte            `json:"seed"`
+	BlsKeys          map[string][]byte `json:"bls_keys,omitempty"`
 }
 
 func NewHdWalletInfoJson() (jsonInfo *WalletInfoJson) {
@@ -109,6 +113,7 @@ func NewHdWalletInfoJson() (jsonInfo *WalletInfoJson) {
 		Nonce:            make(map[string]uint64, 0),
 		DerivedPathIndex: make(map[uint32]uint32, 0),
 		Seed:             make([]byte, 0),
+		BlsKeys:          make(map[string][]byte),
 	}
 	return w
 }
@@ -139,6 +144,9 @@ func (w WalletInfo) HdWalletInfoEncodeJson() (encodeData []byte, err error) {
 		tmpData.Balances[account.Address.Hex()] = w.Balances[account.Address]
 		tmpData.Nonce[account.Address.Hex()] = w.Nonce[account.Address]
 		tmpData.DerivedPathIndex = w.DerivedPathIndex
+		if blsKey, ok := w.BlsKeys[account.Address]; ok {
+			tmpData.BlsKeys[account.Address.Hex()] = blsKey
+		}
 	}
 
 	return json.Marshal(tmpData)
@@ -177,6 +185,9 @@ func (w *WalletInfo) HdWalletInfoDecodeJson(decodeData []byte) (err error) {
 		w.Balances[account.Address] = tmpData.Balances[string(account.Address[:])]
 		w.Nonce[account.Address] = tmpData.Nonce[string(account.Address[:])]
 		w.DerivedPathIndex = tmpData.DerivedPathIndex
+		if blsKey, ok := tmpData.BlsKeys[account.Address.Hex()]; ok {
+			w.BlsKeys[account.Address] = blsKey
+		}
 	}
 
 	return nil
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"math/big"
 )
 
@@ -32,6 +33,9 @@ const (
 	LedgerWallet
 
 	TrezorWallet
+
+	// the keys are kept by a remote signer daemon
+	RemoteWallet
 )
 
 //wallet status
@@ -127,3 +131,15 @@ type Wallet interface {
 	//generate vrf proof
 	Evaluate(account Account, seed []byte) (index [32]byte, proof []byte, err error)
 }
+
+// BlsKeyStore is implemented by the wallets keeping the bls keys of their accounts. The bls key isn't derived from
+// the account key, it's generated when it's first asked for and stored encrypted along with the account.
+type BlsKeyStore interface {
+	BlsSecretKey(account Account) (*bls.SecretKey, error)
+}
+
+// BlsRegistrar is implemented by the wallets that don't let the bls key of a verifier out, the node asks them for
+// the registration of the key instead of loading the key itself
+type BlsRegistrar interface {
+	BlsRegistration(account Account) (*model.BlsRegistration, error)
+}
@@ -75,7 +75,7 @@ func NewWalletManager(getAddressInfo AddressInfoReader, wallets ...Wallet) (*Wal
 		if err != nil {
 			return nil, err
 		}
-		if walletIdentifier.WalletType != SoftWallet {
+		if walletIdentifier.WalletType != SoftWallet && walletIdentifier.WalletType != RemoteWallet {
 			return nil, ErrNotSupportUsbWallet
 		} else {
 			tmpWallets = append(tmpWallets, tmpWallet)
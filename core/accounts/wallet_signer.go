@@ -20,6 +20,7 @@ import (
 	"crypto/ecdsa"
 	"github.com/dipperin/dipperin-core/common"
 	crypto2 "github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"sync"
 )
 
@@ -90,3 +91,16 @@ func (signer *WalletSigner) Evaluate(account Account, seed []byte) (index [32]by
 	index, proof, err = wallet.Evaluate(account, seed)
 	return index, proof, nil
 }
+
+// BlsSecretKey loads the bls key of the signer from its wallet, the wallet must keep the bls keys
+func (signer *WalletSigner) BlsSecretKey() (*bls.SecretKey, error) {
+	wallet, err := signer.walletManager.FindWalletFromAddress(signer.account.Address)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := wallet.(BlsKeyStore)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+	return store.BlsSecretKey(signer.account)
+}
@@ -81,6 +81,22 @@ func TestWalletSigner_SetBaseAddress(t *testing.T) {
 	os.Remove(wallet.Path)
 }
 
+func TestWalletSigner_BlsSecretKey(t *testing.T) {
+	testSigner, err := wallet.GetTestWalletSigner()
+	assert.NoError(t, err)
+
+	key, err := testSigner.BlsSecretKey()
+	assert.NoError(t, err)
+	same, err := testSigner.BlsSecretKey()
+	assert.NoError(t, err)
+	assert.Equal(t, key.PublicKey().Marshal(), same.PublicKey().Marshal())
+
+	testSigner.SetBaseAddress(wallet.TestAddress)
+	_, err = testSigner.BlsSecretKey()
+	assert.Equal(t, accounts.ErrNotFindWallet, err)
+	os.Remove(wallet.Path)
+}
+
 func TestWalletSigner_SignHash(t *testing.T) {
 	testSigner, err := wallet.GetTestWalletSigner()
 	assert.NoError(t, err)
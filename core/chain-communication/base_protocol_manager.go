@@ -137,6 +137,10 @@ type StatusData struct {
 
 	PubKey []byte
 	Sign   []byte
+
+	// ForkIDs holds the fork id of the peer. It's a tail field, so the status of the old peers without it
+	// can still be decoded.
+	ForkIDs []ForkID `rlp:"tail"`
 }
 
 func (status *StatusData) Sender() (result common.Address) {
@@ -134,14 +134,18 @@ func MakeCsProtocolManager(pmConfig *CsProtocolManagerConfig, txBConf *NewTxBroa
 
 	// have diff downloader
 	downloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{
-		Chain:    pmConfig.Chain,
-		Pm:       pm,
-		PbftNode: pmConfig.PbftNode,
-		fetcher:  blockFetcher,
+		Chain:      pmConfig.Chain,
+		StateChain: pmConfig.StateChain,
+		Pm:         pm,
+		PbftNode:   pmConfig.PbftNode,
+		FastSync:   pmConfig.FastSync,
+		fetcher:    blockFetcher,
 	})
 
 	//downloader.SetFetcher(bftOuterFetcher)
 	pm.registerCommunicationService(downloader, downloader)
+	lightProofServer := MakeLightProofServer(&LightProofServerConfig{Chain: pmConfig.Chain})
+	pm.registerCommunicationService(lightProofServer, nil)
 
 	broadcastDelegate := &BroadcastDelegate{
 		newTxBroadcaster: newTxBroadcaster,
@@ -165,6 +169,7 @@ func MakeCsProtocolManager(pmConfig *CsProtocolManagerConfig, txBConf *NewTxBroa
 		debug.Memsize.Add("bftOut", bftOut)
 		debug.Memsize.Add("blockFetcher", blockFetcher)
 		debug.Memsize.Add("downloader", downloader)
+		debug.Memsize.Add("lightProofServer", lightProofServer)
 	}
 
 	return pm, broadcastDelegate
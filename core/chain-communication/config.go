@@ -31,6 +31,14 @@ const (
 	NewBlockMsg        = 0x07
 	NewBlockByBloomMsg = 0x08
 
+	// interlink proof for light clients
+	GetInterLinkProofMsg = 0x05
+	InterLinkProofMsg    = 0x06
+
+	// state trie nodes for fast sync
+	GetNodeDataMsg = 0x09
+	NodeDataMsg    = 0x0a
+
 	// finder verifier
 	GetVerifiersConnFromBootNode = 0x60
 	BootNodeVerifiersConn        = 0x61
@@ -70,6 +78,16 @@ var (
 
 const (
 	MaxBlockFetch = 16
+	MaxStateFetch = 384
+)
+
+// the security parameters of the interlink suffix proof,
+// m is the minimal superchain length and k is the suffix length
+const (
+	LightProofM = 15
+	LightProofK = 6
+
+	maxLightProofParam = 100
 )
 
 var totalVerifierBootNode int
@@ -51,6 +51,9 @@ type CsProtocolManagerConfig struct {
 	VerifiersReader VerifiersReader
 	PbftNode        PbftNode
 	MsgSigner       PbftSigner
+	// download the state tries of a recent pivot block instead of processing all blocks
+	FastSync   bool
+	StateChain StateSyncChain
 }
 
 /*
@@ -84,6 +87,9 @@ type CsProtocolManager struct {
 
 	peerSetManager *CsPmPeerSetManager
 
+	// scores of the peers, misbehaving peers get banned
+	scorer *peerScorer
+
 	stop chan struct{}
 }
 
@@ -108,6 +114,43 @@ func (pm *CsProtocolManager) RemovePeer(id string) {
 	pm.peerSetManager.RemovePeer(id)
 }
 
+// tempBans is how many times the peer has been banned temporarily, the p2p server counts them along with the bans
+func (pm *CsProtocolManager) tempBans(id string) int {
+	var nodeID enode.ID
+	if err := nodeID.UnmarshalText([]byte(id)); err != nil {
+		return 0
+	}
+	return pm.P2PServer.TempBans(nodeID)
+}
+
+func (pm *CsProtocolManager) ReportPeerFault(id string, fault PeerFault) {
+	pm.penalisePeer(id, fault)
+}
+
+// penalisePeer lowers the score of the peer, returns whether the peer got banned
+func (pm *CsProtocolManager) penalisePeer(id string, fault PeerFault) bool {
+	if pm.scorer == nil {
+		return false
+	}
+	log.Info("penalise peer", "id", id, "fault", fault)
+	return pm.scorer.penalise(id, fault)
+}
+
+// banPeer refuses connections with the peer for the duration, a non-positive duration bans it permanently
+func (pm *CsProtocolManager) banPeer(id string, d time.Duration) {
+	var nodeID enode.ID
+	if err := nodeID.UnmarshalText([]byte(id)); err != nil {
+		log.Warn("can't ban peer with invalid id", "id", id, "err", err)
+		return
+	}
+
+	log.Warn("ban misbehaving peer", "id", id, "duration", d)
+	if err := pm.P2PServer.BanPeer(nodeID, d); err != nil {
+		log.Warn("ban peer failed", "id", id, "err", err)
+	}
+	pm.peerSetManager.RemovePeer(id)
+}
+
 func (pm *CsProtocolManager) GetPeer(id string) PmAbstractPeer {
 	if p := pm.peerSetManager.basePeers.Peer(id); p != nil {
 		return p
@@ -211,6 +254,7 @@ func newCsProtocolManager(config *CsProtocolManagerConfig) *CsProtocolManager {
 		pm.isCurrentVerifierNode, pm.isNextVerifierNode, pm.isVerifierBootNode)
 
 	pm.peerSetManager = psManager
+	pm.scorer = newPeerScorer(pm.banPeer, pm.tempBans)
 
 	return pm
 }
@@ -705,6 +749,11 @@ func (pm *CsProtocolManager) handle(p PmAbstractPeer) error {
 		if err := pm.handleMsg(p); err != nil {
 			log.Error("handle peer msg failed", "err", err, "p name", p.NodeName())
 
+			if fault, ok := faultOfErr(err); ok && pm.penalisePeer(p.ID(), fault) {
+				p.SetNotRunning()
+				return err
+			}
+
 			if InPmBrokenError(err) {
 				p.SetNotRunning()
 				return err
@@ -822,12 +871,13 @@ func (pm *CsProtocolManager) HandShake(p PmAbstractPeer) error {
 				GenesisBlock:       genesisBlock.Hash(),
 				RawUrl:             pm.P2PServer.Self().String(),
 			},
+			ForkIDs: []ForkID{NewForkID(&chainConf, genesisBlock.Hash(), curB.Number())},
 			//NodeType:
 		}
 		log.Debug("before sign hand shake msg", "data hash", sData.DataHash().Hex())
 		if nodeConf.GetNodeType() != chain_config.NodeTypeOfNormal {
 			// sign
-			if signB, err := pbftSigner.SignHash(sData.DataHash().Bytes()); err != nil {
+			if signB, err := signHandshake(pbftSigner, &sData); err != nil {
 				// send even if there is an error
 				log.Error("sign status data hash failed", "err", err)
 			} else {
@@ -878,6 +928,12 @@ func (pm *CsProtocolManager) HandShake(p PmAbstractPeer) error {
 			log.Error(fmt.Sprintf("genesis block not match, local: %v remote: %v", genesisBlock.Hash(), remoteStatus.GenesisBlock))
 			return errors.New("genesis block not match")
 		}
+		if len(remoteStatus.ForkIDs) > 0 {
+			if err := validForkID(&chainConf, genesisBlock.Hash(), chainReader.CurrentBlock().Number(), remoteStatus.ForkIDs[0]); err != nil {
+				log.Error("fork id not match", "remote", remoteStatus.ForkIDs[0], "err", err)
+				return err
+			}
+		}
 		if remoteStatus.ProtocolVersion == 0 {
 			return errors.New("can't read hand shake msg")
 		}
@@ -903,6 +959,14 @@ func (pm *CsProtocolManager) HandShake(p PmAbstractPeer) error {
 
 }
 
+// signHandshake signs the data hash of the status msg
+func signHandshake(signer PbftSigner, sData *StatusData) ([]byte, error) {
+	if s, ok := signer.(HandshakeSigner); ok {
+		return s.SignHandshake(sData.HandShakeData)
+	}
+	return signer.SignHash(sData.DataHash().Bytes())
+}
+
 //check and connect verifier boot nodes
 //If it is the current or next round verifier, then connect the unconnected verifier boot nodes
 //If it is a verifier boot node, connect to other unconnected verifier boot nodes
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/tests"
@@ -2002,6 +2003,95 @@ func TestCsProtocolManager_handShake1(t *testing.T) {
 	time.Sleep(600 * time.Millisecond)
 }
 
+func TestCsProtocolManager_handShakeForkID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mChain := NewMockChain(ctrl)
+	mNodeConf := NewMockNodeConf(ctrl)
+	mSigner := NewMockPbftSigner(ctrl)
+	mP2PServer := NewMockP2PServer(ctrl)
+
+	mPeer := NewMockPmAbstractPeer(ctrl)
+
+	pm := &CsProtocolManager{
+		CsProtocolManagerConfig: &CsProtocolManagerConfig{
+			ChainConfig: *forkIDTestConfig(),
+			Chain:       mChain,
+			NodeConf:    mNodeConf,
+			MsgSigner:   mSigner,
+			P2PServer:   mP2PServer,
+		},
+	}
+
+	var pyRecord, _ = hex.DecodeString("f884b8407098ad865b00a582051940cb9cf36836572411a47278783077011599ed5cd16b76f2635f4e234738f30813a89eb9137e3e3df5266e3a1f11df72ecf1145ccb9c01826964827634826970847f00000189736563703235366b31a103ca634cae0d49acb401d8a4c6b6fe8c55b70d115bf400769cc1400f3258cd31388375647082765f")
+
+	var r enr.Record
+	if err := rlp.DecodeBytes(pyRecord, &r); err != nil {
+		t.Fatalf("can't decode: %v", err)
+	}
+	n, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatalf("can't verify record: %v", err)
+	}
+
+	block := model.NewBlock(model.NewHeader(11, 101, common.HexToHash("ss"), common.HexToHash("fdfs"), common.StringToDiff("0x22"), big.NewInt(111), common.StringToAddress("fdsfds"), common.EncodeNonce(33)), nil, nil)
+
+	// case 2
+	mChain.EXPECT().GetBlockByNumber(gomock.Eq(uint64(0))).Return(block)
+
+	//  send and check the fork id
+	mChain.EXPECT().CurrentBlock().Return(block).Times(2)
+	mNodeConf.EXPECT().GetNodeType().Return(chain_config.NodeTypeOfNormal).Times(2)
+	mNodeConf.EXPECT().GetNodeName().Return("dsadsad")
+	mP2PServer.EXPECT().Self().Return(n)
+	mPeer.EXPECT().SendMsg(gomock.Any(), gomock.Any()).Return(nil)
+
+	hsData := HandShakeData{
+		ProtocolVersion:    1,
+		ChainID:            big.NewInt(2),
+		NetworkId:          pm.ChainConfig.NetworkID,
+		CurrentBlock:       common.HexToHash("aaa"),
+		CurrentBlockHeight: 64,
+		GenesisBlock:       block.Hash(),
+		NodeType:           2,
+		NodeName:           "test",
+		RawUrl:             n.String(),
+	}
+
+	// the remote peer schedules a fork which is passed by the local chain
+	forkID := NewForkID(&pm.ChainConfig, block.Hash(), block.Number())
+	forkID.Next = block.Number()
+	statusData := &StatusData{HandShakeData: hsData, ForkIDs: []ForkID{forkID}}
+
+	hash := statusData.DataHash()
+
+	assert.Equal(t, true, !hash.IsEmpty())
+
+	account := tests.AccFactory.GenAccount()
+
+	sign, err := account.SignHash(statusData.DataHash().Bytes())
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, true, len(sign) > 0)
+
+	statusData.Sign = sign
+
+	statusData.PubKey = crypto.CompressPubkey(&account.Pk.PublicKey)
+
+	size, r1, err := rlp.EncodeToReader(statusData)
+	assert.NoError(t, err)
+
+	msg := p2p.Msg{Code: StatusMsg, Size: uint32(size), Payload: r1}
+
+	// read
+	mPeer.EXPECT().ReadMsg().Return(msg, nil)
+	assert.Equal(t, g_error.ErrForkIdIncompatible, pm.HandShake(mPeer))
+
+	time.Sleep(600 * time.Millisecond)
+}
+
 func TestCsProtocolManager_handShake2(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
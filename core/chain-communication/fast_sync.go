@@ -0,0 +1,402 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"github.com/dipperin/dipperin-core/third-party/trie"
+	"time"
+)
+
+var (
+	// the pivot is far enough from the head to not be rolled back by the special blocks
+	fastSyncPivotGap     = uint64(64)
+	fetchNodeDataTimeout = 10 * time.Second
+)
+
+// the max size of the node data in one response
+const nodeDataSoftLimit = 2 * 1024 * 1024
+
+type nodeDataPack struct {
+	peerID string
+	data   [][]byte
+}
+
+func (fd *NewPbftDownloader) onGetNodeData(msg p2p.Msg, p PmAbstractPeer) error {
+	var hashes []common.Hash
+	if err := msg.Decode(&hashes); err != nil {
+		return errors.New("decode error, invalid message")
+	}
+	if fd.StateChain == nil {
+		return g_error.ErrNoStateServed
+	}
+
+	// the missing nodes are skipped, the requester checks the hashes of the returned nodes
+	trieDB := fd.StateChain.GetStateStorage().TrieDB()
+	data := make([][]byte, 0, len(hashes))
+	size := 0
+	for _, hash := range hashes {
+		if len(data) >= MaxStateFetch || size >= nodeDataSoftLimit {
+			break
+		}
+		if node, err := trieDB.Node(hash); err == nil {
+			data = append(data, node)
+			size += len(node)
+		}
+	}
+
+	log.Info("downloader send node data to remote", "remote node", p.NodeName(), "request", len(hashes), "node len", len(data))
+	return p.SendMsg(NodeDataMsg, data)
+}
+
+func (fd *NewPbftDownloader) onNodeData(msg p2p.Msg, p PmAbstractPeer) error {
+	var data [][]byte
+	if err := msg.Decode(&data); err != nil {
+		log.Error("downloader decode node data failed", "err", err)
+		return err
+	}
+
+	select {
+	case <-fd.quitCh:
+		return quitErr
+	default:
+	}
+
+	// the data is dropped if no sync is waiting for it, so the unrequested data can't block the peer
+	select {
+	case fd.nodeDataC <- &nodeDataPack{peerID: p.ID(), data: data}:
+	default:
+		log.Debug("downloader drop the unrequested node data", "remote node", p.NodeName())
+	}
+	return nil
+}
+
+// fastSyncPivot returns the block number whose state is downloaded. The fast sync runs when
+// the node starts from the genesis, or the state of the current block is incomplete because
+// the last fast sync is interrupted.
+func (fd *NewPbftDownloader) fastSyncPivot(bestPeer PmAbstractPeer) (uint64, bool) {
+	if !fd.FastSync || fd.StateChain == nil {
+		return 0, false
+	}
+
+	current := fd.Chain.CurrentBlock()
+	if current.Number() > 0 && fd.hasTrie(current.StateRoot()) {
+		return 0, false
+	}
+
+	_, height := bestPeer.GetHead()
+	if height > fastSyncPivotGap && height-fastSyncPivotGap > current.Number() {
+		return height - fastSyncPivotGap, true
+	}
+
+	// the chain is too short for the fast sync
+	if current.Number() == 0 {
+		return 0, false
+	}
+	return current.Number(), true
+}
+
+func (fd *NewPbftDownloader) fastSync(bestPeer PmAbstractPeer, pivotNumber uint64) error {
+	log.Info("downloader start fast sync", "pivot", pivotNumber, "remote node", bestPeer.NodeName())
+	if err := fd.fetchFastBlocks(bestPeer, pivotNumber); err != nil {
+		return err
+	}
+
+	pivot := fd.Chain.GetBlockByNumber(pivotNumber)
+	if pivot == nil {
+		return g_error.ErrBlockNotFound
+	}
+	if err := fd.syncState(bestPeer, pivot); err != nil {
+		return err
+	}
+
+	log.Info("downloader fast sync finished", "pivot", pivotNumber, "state root", pivot.StateRoot().Hex())
+	return nil
+}
+
+// fetchFastBlocks saves the blocks until the pivot without processing their state
+func (fd *NewPbftDownloader) fetchFastBlocks(bestPeer PmAbstractPeer, pivot uint64) error {
+	nextNumber := fd.Chain.CurrentBlock().Number() + 1
+	if nextNumber > pivot {
+		return nil
+	}
+	fd.requestFastBlocks(bestPeer, nextNumber, pivot)
+
+	timeoutTimer := time.NewTimer(fetchBlockTimeout)
+	defer timeoutTimer.Stop()
+	for {
+		select {
+		case packet := <-fd.blockC:
+			if packet.peerID != bestPeer.ID() {
+				log.Warn("Received skeleton from incorrect peer", "peer", packet.peerID)
+				break
+			}
+			if len(packet.blocks) == 0 {
+				return g_error.ErrFastSyncNoBlocks
+			}
+
+			if err := fd.importFastBlocks(bestPeer, packet.blocks, pivot); err != nil {
+				log.Error("downloader save fast block failed", "err", err, "remote node", bestPeer.NodeName())
+				fd.Pm.ReportPeerFault(bestPeer.ID(), FaultBadBlock)
+				return err
+			}
+
+			nextNumber = fd.Chain.CurrentBlock().Number() + 1
+			if nextNumber > pivot {
+				return nil
+			}
+
+			timeoutTimer.Reset(fetchBlockTimeout)
+			fd.requestFastBlocks(bestPeer, nextNumber, pivot)
+		case <-timeoutTimer.C:
+			log.Warn("Waiting for fast sync blocks timed out", "node name", bestPeer.NodeName())
+			fd.Pm.ReportPeerFault(bestPeer.ID(), FaultTimeout)
+			return g_error.ErrFastSyncNoBlocks
+
+		case <-fd.quitCh:
+			return quitErr
+		}
+	}
+}
+
+func (fd *NewPbftDownloader) requestFastBlocks(bestPeer PmAbstractPeer, from, pivot uint64) {
+	amount := pivot - from + 1
+	if amount > MaxBlockFetch {
+		amount = MaxBlockFetch
+	}
+	go func() {
+		if err := bestPeer.SendMsg(GetBlocksMsg, &getBlockHeaders{OriginHeight: from, Amount: amount}); err != nil {
+			log.Warn("send get blocks msg failed", "err", err)
+		}
+	}()
+}
+
+func (fd *NewPbftDownloader) importFastBlocks(p PmAbstractPeer, list []*catchupRlp, pivot uint64) error {
+	for _, b := range list {
+		if b.Block.Number() > pivot {
+			break
+		}
+		if b.Block.Number() <= fd.Chain.CurrentBlock().Number() {
+			continue
+		}
+
+		if err := fd.syncVerifierState(p, b.Block); err != nil {
+			return err
+		}
+		commits := make([]model.AbstractVerification, len(b.SeenCommit))
+		util.InterfaceSliceCopy(commits, b.SeenCommit)
+		if err := fd.StateChain.SaveFastBlock(b.Block, commits); err != nil {
+			if err == g_error.ErrNormalBlockHeightTooLow {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// syncVerifierState downloads the state used to calculate the verifiers of the block, so its seen commits
+// are checked against them before it's saved. The register trie of the previous block gives the slot of
+// the block, and the verifiers of the slot are elected with the account state of the change point before
+// the last one. Both tries are verified by the roots in the headers which are already saved with valid
+// commits, so the verifiers are derived from the genesis verifiers instead of the peer.
+func (fd *NewPbftDownloader) syncVerifierState(p PmAbstractPeer, block model.AbstractBlock) error {
+	pre := fd.Chain.GetBlockByNumber(block.Number() - 1)
+	if pre == nil {
+		return g_error.ErrBlockNotFound
+	}
+	if err := fd.syncTrie(p, pre.GetRegisterRoot(), false); err != nil {
+		return err
+	}
+
+	slot := fd.Chain.GetSlot(block)
+	if slot == nil {
+		return g_error.ErrFastSyncSlotNotFound
+	}
+	// the verifiers of the first slots are configured
+	if *slot < chain_config.GetChainConfig().SlotMargin {
+		return nil
+	}
+
+	num := fd.StateChain.NumBeforeLastBySlot(*slot)
+	if num == nil {
+		return g_error.ErrLastNumIsNil
+	}
+	point := fd.Chain.GetBlockByNumber(*num)
+	if point == nil {
+		return g_error.ErrBlockNotFound
+	}
+	return fd.syncTrie(p, point.StateRoot(), false)
+}
+
+// syncState downloads the register tries used to find the slots and the change points,
+// the account state tries of the last change points used to calculate the verifiers,
+// and at last the account state trie of the pivot.
+func (fd *NewPbftDownloader) syncState(bestPeer PmAbstractPeer, pivot model.AbstractBlock) error {
+	var points []model.AbstractBlock
+	block := pivot
+	for i := uint64(0); i <= chain_config.GetChainConfig().SlotMargin && block.Number() > 0; i++ {
+		pre := fd.Chain.GetBlockByNumber(block.Number() - 1)
+		if err := fd.syncTrie(bestPeer, pre.GetRegisterRoot(), false); err != nil {
+			return err
+		}
+
+		num := fd.StateChain.GetLastChangePoint(block)
+		if num == nil {
+			return g_error.ErrLastNumIsNil
+		}
+		if block = fd.Chain.GetBlockByNumber(*num); block == nil {
+			return g_error.ErrBlockNotFound
+		}
+		points = append(points, block)
+	}
+
+	from := uint64(0)
+	if len(points) > 0 && points[len(points)-1].Number() > 0 {
+		from = points[len(points)-1].Number() - 1
+	}
+	for num := from; num <= pivot.Number(); num++ {
+		if err := fd.syncTrie(bestPeer, fd.Chain.GetBlockByNumber(num).GetRegisterRoot(), false); err != nil {
+			return err
+		}
+	}
+
+	for _, point := range points {
+		if err := fd.syncTrie(bestPeer, point.StateRoot(), true); err != nil {
+			return err
+		}
+	}
+
+	// the pivot state is the last one, so the existing state root means the fast sync is finished
+	return fd.syncTrie(bestPeer, pivot.StateRoot(), true)
+}
+
+// syncTrie downloads the trie from the peer, the contract data tries are downloaded with the
+// account trie if withData is true. The nodes are verified by their hashes, and a node is
+// written only after all its children, so the existing root means the whole trie exists.
+func (fd *NewPbftDownloader) syncTrie(p PmAbstractPeer, root common.Hash, withData bool) error {
+	db := fd.StateChain.GetStateStorage().DiskDB()
+
+	var sched *trie.Sync
+	var callback trie.LeafCallback
+	if withData {
+		callback = func(leaf []byte, parent common.Hash) error {
+			// the contract data root is saved as the raw hash or rlp encoded hash
+			if len(leaf) != common.HashLength && len(leaf) != common.HashLength+1 {
+				return nil
+			}
+			dataRoot := common.BytesToHash(leaf)
+			if dataRoot.IsEmpty() {
+				return nil
+			}
+			if has, _ := db.Has(dataRoot.Bytes()); has {
+				return nil
+			}
+
+			// other hash values in the account trie aren't trie roots, the peer doesn't have them
+			data, err := fd.fetchNodeData(p, []common.Hash{dataRoot})
+			if err != nil {
+				return err
+			}
+			for _, node := range data {
+				if cs_crypto.Keccak256Hash(node).IsEqual(dataRoot) {
+					sched.AddSubTrie(dataRoot, 64, parent, nil)
+					break
+				}
+			}
+			return nil
+		}
+	}
+	sched = trie.NewSync(root, db, callback)
+
+	for sched.Pending() > 0 {
+		hashes := sched.Missing(MaxStateFetch)
+		if len(hashes) == 0 {
+			return g_error.ErrStateNodeNotFound
+		}
+		data, err := fd.fetchNodeData(p, hashes)
+		if err != nil {
+			return err
+		}
+
+		requested := make(map[common.Hash]bool, len(hashes))
+		for _, hash := range hashes {
+			requested[hash] = true
+		}
+		results := make([]trie.SyncResult, 0, len(data))
+		for _, node := range data {
+			hash := cs_crypto.Keccak256Hash(node)
+			if requested[hash] {
+				delete(requested, hash)
+				results = append(results, trie.SyncResult{Hash: hash, Data: node})
+			}
+		}
+
+		if _, index, err := sched.Process(results); err != nil {
+			log.Warn("process state trie node failed", "hash", results[index].Hash.Hex(), "err", err)
+			return err
+		}
+		if _, err := sched.Commit(db); err != nil {
+			return err
+		}
+
+		// the missing nodes aren't scheduled again, the sync restarts from the committed nodes
+		if len(requested) > 0 {
+			return g_error.ErrStateNodeNotFound
+		}
+	}
+	return nil
+}
+
+func (fd *NewPbftDownloader) fetchNodeData(p PmAbstractPeer, hashes []common.Hash) ([][]byte, error) {
+	go func() {
+		if err := p.SendMsg(GetNodeDataMsg, hashes); err != nil {
+			log.Warn("send get node data msg failed", "err", err)
+		}
+	}()
+
+	timeoutTimer := time.NewTimer(fetchNodeDataTimeout)
+	defer timeoutTimer.Stop()
+	for {
+		select {
+		case pack := <-fd.nodeDataC:
+			if pack.peerID != p.ID() {
+				log.Warn("Received node data from incorrect peer", "peer", pack.peerID)
+				break
+			}
+			return pack.data, nil
+		case <-timeoutTimer.C:
+			fd.Pm.ReportPeerFault(p.ID(), FaultTimeout)
+			return nil, g_error.ErrFetchNodeDataTimeout
+		case <-fd.quitCh:
+			return nil, quitErr
+		}
+	}
+}
+
+func (fd *NewPbftDownloader) hasTrie(root common.Hash) bool {
+	_, err := fd.StateChain.GetStateStorage().TrieDB().Node(root)
+	return err == nil
+}
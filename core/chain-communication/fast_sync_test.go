@@ -0,0 +1,388 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"github.com/dipperin/dipperin-core/third-party/trie"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStateSyncChain struct {
+	*fakeLightChain
+	storage state_processor.StateStorage
+}
+
+func newFakeStateSyncChain(blocks []model.AbstractBlock) *fakeStateSyncChain {
+	return &fakeStateSyncChain{
+		fakeLightChain: &fakeLightChain{blocks: blocks},
+		storage:        state_processor.NewStateStorageWithCache(ethdb.NewMemDatabase()),
+	}
+}
+
+func (f *fakeStateSyncChain) GetStateStorage() state_processor.StateStorage {
+	return f.storage
+}
+
+// the change points are at every 10 blocks
+func (f *fakeStateSyncChain) GetLastChangePoint(block model.AbstractBlock) *uint64 {
+	num := (block.Number() - 1) / 10 * 10
+	return &num
+}
+
+// the slots have 10 blocks, and the block 10 is the last one of the slot 0
+func (f *fakeStateSyncChain) GetSlot(block model.AbstractBlock) *uint64 {
+	slot := uint64(0)
+	if block.Number() > 0 {
+		slot = (block.Number() - 1) / 10
+	}
+	return &slot
+}
+
+func (f *fakeStateSyncChain) NumBeforeLastBySlot(slot uint64) *uint64 {
+	num := uint64(0)
+	if margin := chain_config.GetChainConfig().SlotMargin; slot >= margin {
+		num = (slot - margin + 1) * 10
+	}
+	return &num
+}
+
+func (f *fakeStateSyncChain) SaveFastBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	current := f.CurrentBlock()
+	if block.Number() <= current.Number() {
+		return g_error.ErrNormalBlockHeightTooLow
+	}
+	if block.Number() != current.Number()+1 || !block.PreHash().IsEqual(current.Hash()) {
+		return g_error.ErrInvalidBlockNum
+	}
+	f.blocks = append(f.blocks, block)
+	return nil
+}
+
+func commitTestTrie(t *testing.T, storage state_processor.StateStorage, kv map[string][]byte) common.Hash {
+	tr, err := trie.New(common.Hash{}, storage.TrieDB())
+	assert.NoError(t, err)
+	for k, v := range kv {
+		tr.Update([]byte(k), v)
+	}
+	root, err := tr.Commit(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.TrieDB().Commit(root, false))
+	return root
+}
+
+func checkTestTrie(t *testing.T, storage state_processor.StateStorage, root common.Hash, kv map[string][]byte) {
+	tr, err := trie.New(root, storage.TrieDB())
+	assert.NoError(t, err)
+	for k, v := range kv {
+		value, err := tr.TryGet([]byte(k))
+		assert.NoError(t, err)
+		assert.Equal(t, v, value)
+	}
+}
+
+// newFakeStateServer makes a chain whose blocks have different state roots and register roots
+func newFakeStateServer(t *testing.T, count int) *fakeStateSyncChain {
+	server := newFakeStateSyncChain(nil)
+	var pre model.AbstractBlock
+	for i := 0; i <= count; i++ {
+		header := model.NewHeader(1, uint64(i), common.Hash{}, common.HexToHash("1111"), common.HexToDiff("0x20ffffff"), big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
+		header.StateRoot = commitTestTrie(t, server.storage, map[string][]byte{"number": []byte(fmt.Sprint(i)), "balance": []byte("100")})
+		header.RegisterRoot = commitTestTrie(t, server.storage, map[string][]byte{"slot": []byte(fmt.Sprint(i / 10))})
+
+		var block model.AbstractBlock
+		if pre == nil {
+			block = model.NewBlock(header, nil, nil)
+		} else {
+			header.PreHash = pre.Hash()
+			block = model.NewBlockWithLink(header, nil, nil, pre.GetInterlinks())
+		}
+		server.blocks = append(server.blocks, block)
+		pre = block
+	}
+	return server
+}
+
+func nodeDataMsg(t *testing.T, data interface{}) p2p.Msg {
+	payload, err := rlp.EncodeToBytes(data)
+	assert.NoError(t, err)
+	return p2p.Msg{Payload: bytes.NewReader(payload)}
+}
+
+// connectStateServer makes the peer serve the blocks and the node data of the server
+func connectStateServer(t *testing.T, ctrl *gomock.Controller, fd *NewPbftDownloader, server *fakeStateSyncChain, height uint64) *MockPmAbstractPeer {
+	serverFd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: server, StateChain: server})
+
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().NodeName().Return("server").AnyTimes()
+	mockPeer.EXPECT().ID().Return("1").AnyTimes()
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, height).AnyTimes()
+
+	// the reply of the server is delivered to the downloader
+	serverPeer := NewMockPmAbstractPeer(ctrl)
+	serverPeer.EXPECT().NodeName().Return("client").AnyTimes()
+	serverPeer.EXPECT().SendMsg(uint64(NodeDataMsg), gomock.Any()).DoAndReturn(func(code uint64, msg interface{}) error {
+		return fd.onNodeData(nodeDataMsg(t, msg), mockPeer)
+	}).AnyTimes()
+
+	mockPeer.EXPECT().SendMsg(uint64(GetNodeDataMsg), gomock.Any()).DoAndReturn(func(code uint64, msg interface{}) error {
+		return serverFd.onGetNodeData(nodeDataMsg(t, msg), serverPeer)
+	}).AnyTimes()
+	mockPeer.EXPECT().SendMsg(uint64(GetBlocksMsg), gomock.Any()).DoAndReturn(func(code uint64, msg interface{}) error {
+		query := msg.(*getBlockHeaders)
+		var blocks []*catchupRlp
+		for i := query.OriginHeight; i < query.OriginHeight+query.Amount; i++ {
+			if block := server.GetBlockByNumber(i); block != nil {
+				blocks = append(blocks, &catchupRlp{Block: block.(*model.Block)})
+			}
+		}
+		fd.blockC <- &npbPack{peerID: "1", blocks: blocks}
+		return nil
+	}).AnyTimes()
+	return mockPeer
+}
+
+func TestNewPbftDownloader_onGetNodeData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+
+	fd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{})
+	assert.NotNil(t, fd.MsgHandlers()[GetNodeDataMsg])
+	assert.NotNil(t, fd.MsgHandlers()[NodeDataMsg])
+	assert.Error(t, fd.onGetNodeData(p2p.Msg{Payload: bytes.NewReader([]byte{})}, mockPeer))
+	assert.Equal(t, g_error.ErrNoStateServed, fd.onGetNodeData(nodeDataMsg(t, []common.Hash{}), mockPeer))
+
+	server := newFakeStateSyncChain(nil)
+	root := commitTestTrie(t, server.storage, map[string][]byte{"a": []byte("1")})
+	fd = MakeNewPbftDownloader(&NewPbftDownloaderConfig{StateChain: server})
+
+	// the missing node is skipped
+	mockPeer.EXPECT().SendMsg(uint64(NodeDataMsg), gomock.Any()).DoAndReturn(func(code uint64, msg interface{}) error {
+		data := msg.([][]byte)
+		assert.Len(t, data, 1)
+		node, err := server.storage.TrieDB().Node(root)
+		assert.NoError(t, err)
+		assert.Equal(t, node, data[0])
+		return nil
+	})
+	assert.NoError(t, fd.onGetNodeData(nodeDataMsg(t, []common.Hash{common.HexToHash("123"), root}), mockPeer))
+}
+
+func TestNewPbftDownloader_onNodeData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().ID().Return("1").AnyTimes()
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+
+	fd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{})
+	assert.Error(t, fd.onNodeData(p2p.Msg{Payload: bytes.NewReader([]byte{})}, mockPeer))
+
+	// the data isn't waited for by any sync, the later ones are dropped without blocking
+	assert.NoError(t, fd.onNodeData(nodeDataMsg(t, [][]byte{{1, 2}}), mockPeer))
+	assert.NoError(t, fd.onNodeData(nodeDataMsg(t, [][]byte{{3, 4}}), mockPeer))
+	pack := <-fd.nodeDataC
+	assert.Equal(t, "1", pack.peerID)
+	assert.Equal(t, [][]byte{{1, 2}}, pack.data)
+	assert.Len(t, fd.nodeDataC, 0)
+
+	fd.Stop()
+	assert.Equal(t, quitErr, fd.onNodeData(nodeDataMsg(t, [][]byte{{1, 2}}), mockPeer))
+}
+
+func TestNewPbftDownloader_syncTrie(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newFakeStateSyncChain(nil)
+	rawData := map[string][]byte{"key1": []byte("value1")}
+	rlpData := map[string][]byte{"key2": []byte("value2")}
+	rawRoot := commitTestTrie(t, server.storage, rawData)
+	rlpRoot := commitTestTrie(t, server.storage, rlpData)
+	encRoot, err := rlp.EncodeToBytes(rlpRoot)
+	assert.NoError(t, err)
+
+	account := map[string][]byte{
+		"a_data_root": rawRoot.Bytes(),
+		"b_data_root": encRoot,
+		"c_data_root": common.Hash{}.Bytes(),
+		// the hash value which isn't a trie root
+		"d_hash_lock": common.HexToHash("123").Bytes(),
+	}
+	for i := 0; i < 500; i++ {
+		account[fmt.Sprintf("balance%v", i)] = []byte(fmt.Sprint(i))
+	}
+	root := commitTestTrie(t, server.storage, account)
+
+	client := newFakeStateSyncChain(nil)
+	fd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{StateChain: client})
+	mockPeer := connectStateServer(t, ctrl, fd, server, 0)
+
+	assert.NoError(t, fd.syncTrie(mockPeer, root, true))
+	assert.True(t, fd.hasTrie(root))
+	checkTestTrie(t, client.storage, root, account)
+	checkTestTrie(t, client.storage, rawRoot, rawData)
+	checkTestTrie(t, client.storage, rlpRoot, rlpData)
+
+	// the data tries aren't downloaded without data
+	client = newFakeStateSyncChain(nil)
+	fd = MakeNewPbftDownloader(&NewPbftDownloaderConfig{StateChain: client})
+	mockPeer = connectStateServer(t, ctrl, fd, server, 0)
+	assert.NoError(t, fd.syncTrie(mockPeer, root, false))
+	assert.True(t, fd.hasTrie(root))
+	assert.False(t, fd.hasTrie(rawRoot))
+
+	// the peer doesn't have the trie
+	assert.Equal(t, g_error.ErrStateNodeNotFound, fd.syncTrie(mockPeer, common.HexToHash("123"), true))
+	assert.False(t, fd.hasTrie(common.HexToHash("123")))
+}
+
+func TestNewPbftDownloader_fetchNodeDataTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fetchNodeDataTimeout = 10 * time.Millisecond
+	defer func() {
+		fetchNodeDataTimeout = 10 * time.Second
+	}()
+
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().SendMsg(uint64(GetNodeDataMsg), gomock.Any()).Return(nil).AnyTimes()
+	mockPeer.EXPECT().ID().Return("1").AnyTimes()
+	mockPM := NewMockPeerManager(ctrl)
+	mockPM.EXPECT().ReportPeerFault("1", FaultTimeout).Times(1)
+
+	fd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Pm: mockPM})
+	_, err := fd.fetchNodeData(mockPeer, []common.Hash{common.HexToHash("123")})
+	assert.Equal(t, g_error.ErrFetchNodeDataTimeout, err)
+}
+
+func TestNewPbftDownloader_fastSyncPivot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newFakeStateServer(t, 100)
+	client := newFakeStateSyncChain(server.blocks[:1])
+	fd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: client, StateChain: client})
+
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, uint64(100)).AnyTimes()
+
+	_, ok := fd.fastSyncPivot(mockPeer)
+	assert.False(t, ok)
+
+	fd.FastSync = true
+	pivot, ok := fd.fastSyncPivot(mockPeer)
+	assert.True(t, ok)
+	assert.Equal(t, 100-fastSyncPivotGap, pivot)
+
+	// the chain is too short
+	shortPeer := NewMockPmAbstractPeer(ctrl)
+	shortPeer.EXPECT().GetHead().Return(common.Hash{}, fastSyncPivotGap).AnyTimes()
+	_, ok = fd.fastSyncPivot(shortPeer)
+	assert.False(t, ok)
+
+	// the interrupted fast sync goes on
+	client.blocks = server.blocks[:80]
+	pivot, ok = fd.fastSyncPivot(shortPeer)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(79), pivot)
+
+	// the state of the current block exists
+	commitTestTrie(t, client.storage, map[string][]byte{"number": []byte("79"), "balance": []byte("100")})
+	_, ok = fd.fastSyncPivot(mockPeer)
+	assert.False(t, ok)
+}
+
+func TestNewPbftDownloader_fastSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newFakeStateServer(t, 100)
+	client := newFakeStateSyncChain(append([]model.AbstractBlock{}, server.blocks[0]))
+	fd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: client, StateChain: client, FastSync: true})
+	mockPeer := connectStateServer(t, ctrl, fd, server, 100)
+
+	pivot, ok := fd.fastSyncPivot(mockPeer)
+	assert.True(t, ok)
+	assert.NoError(t, fd.fastSync(mockPeer, pivot))
+
+	assert.Equal(t, pivot, client.CurrentBlock().Number())
+	assert.Equal(t, server.GetBlockByNumber(pivot).Hash(), client.CurrentBlock().Hash())
+
+	// the state of the pivot and the last change points, and the register tries after the oldest change point
+	assert.True(t, fd.hasTrie(client.CurrentBlock().StateRoot()))
+	for _, num := range []uint64{30, 20, 10} {
+		assert.True(t, fd.hasTrie(client.GetBlockByNumber(num).StateRoot()))
+	}
+	assert.False(t, fd.hasTrie(client.GetBlockByNumber(25).StateRoot()))
+	for num := uint64(9); num <= pivot; num++ {
+		assert.True(t, fd.hasTrie(client.GetBlockByNumber(num).GetRegisterRoot()))
+	}
+
+	// the full sync goes on after the fast sync
+	_, ok = fd.fastSyncPivot(mockPeer)
+	assert.False(t, ok)
+}
+
+func TestNewPbftDownloader_fetchFastBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newFakeStateServer(t, 50)
+	client := newFakeStateSyncChain(append([]model.AbstractBlock{}, server.blocks[:20]...))
+	fd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: client, StateChain: client})
+	mockPeer := connectStateServer(t, ctrl, fd, server, 50)
+
+	assert.NoError(t, fd.fetchFastBlocks(mockPeer, 10))
+	assert.Equal(t, uint64(19), client.CurrentBlock().Number())
+
+	assert.NoError(t, fd.fetchFastBlocks(mockPeer, 45))
+	assert.Equal(t, server.GetBlockByNumber(45).Hash(), client.CurrentBlock().Hash())
+
+	// the state electing the verifiers of the saved blocks is downloaded before them
+	for num := uint64(19); num < 45; num++ {
+		assert.True(t, fd.hasTrie(client.GetBlockByNumber(num).GetRegisterRoot()))
+	}
+	for _, num := range []uint64{10, 20, 30} {
+		assert.True(t, fd.hasTrie(client.GetBlockByNumber(num).StateRoot()))
+	}
+	assert.False(t, fd.hasTrie(client.GetBlockByNumber(40).StateRoot()))
+	assert.False(t, fd.hasTrie(client.GetBlockByNumber(45).StateRoot()))
+
+	// the peer has no more blocks
+	assert.Equal(t, g_error.ErrFastSyncNoBlocks, fd.fetchFastBlocks(mockPeer, 60))
+	assert.Equal(t, uint64(50), client.CurrentBlock().Number())
+}
@@ -0,0 +1,105 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"encoding/binary"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"hash/crc32"
+	"math"
+)
+
+// ForkID identifies the fork config of a chain as EIP-2124. The hash is the checksum of the genesis hash and
+// the passed fork heights, next is the height of the next scheduled fork or 0.
+type ForkID struct {
+	Hash [4]byte
+	Next uint64
+}
+
+// NewForkID returns the fork id of the chain at the head height
+func NewForkID(conf *chain_config.ChainConfig, genesis common.Hash, head uint64) ForkID {
+	hash := crc32.ChecksumIEEE(genesis[:])
+	for _, fork := range conf.Forks() {
+		if fork > head {
+			return ForkID{Hash: checksumToBytes(hash), Next: fork}
+		}
+		hash = checksumUpdate(hash, fork)
+	}
+	return ForkID{Hash: checksumToBytes(hash)}
+}
+
+// validForkID checks whether the remote fork id is compatible with the local chain at the head height. The peers
+// that are syncing the passed forks are compatible, while the peers that miss a fork passed by the local chain,
+// or schedule a fork the local chain has passed without it, are rejected.
+func validForkID(conf *chain_config.ChainConfig, genesis common.Hash, head uint64, remote ForkID) error {
+	// the checksums of the passed forks, sums[i] is the checksum before the fork i
+	forks := conf.Forks()
+	sums := make([][4]byte, len(forks)+1)
+	hash := crc32.ChecksumIEEE(genesis[:])
+	sums[0] = checksumToBytes(hash)
+	for i, fork := range forks {
+		hash = checksumUpdate(hash, fork)
+		sums[i+1] = checksumToBytes(hash)
+	}
+
+	// the last fork is never passed
+	forks = append(forks, math.MaxUint64)
+	for i, fork := range forks {
+		if head >= fork {
+			continue
+		}
+
+		// the same forks are passed, the remote next fork must not be passed by the local chain
+		if sums[i] == remote.Hash {
+			if remote.Next > 0 && head >= remote.Next {
+				return g_error.ErrForkIdIncompatible
+			}
+			return nil
+		}
+		// the remote peer is syncing, its next fork must be the next local fork
+		for j, fork := range forks[:i] {
+			if sums[j] == remote.Hash {
+				if fork != remote.Next {
+					return g_error.ErrForkIdRemoteStale
+				}
+				return nil
+			}
+		}
+		// the local node is syncing
+		for j := i + 1; j < len(sums); j++ {
+			if sums[j] == remote.Hash {
+				return nil
+			}
+		}
+		return g_error.ErrForkIdIncompatible
+	}
+	return nil
+}
+
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
@@ -0,0 +1,92 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func forkIDTestConfig() *chain_config.ChainConfig {
+	conf := chain_config.DefaultChainConfig()
+	conf.EarthBlock = big.NewInt(10)
+	conf.MarsBlock = big.NewInt(20)
+	return conf
+}
+
+func TestNewForkID(t *testing.T) {
+	conf := forkIDTestConfig()
+	genesis := common.HexToHash("0x1234")
+
+	id0 := NewForkID(conf, genesis, 0)
+	assert.Equal(t, uint64(10), id0.Next)
+	assert.Equal(t, id0, NewForkID(conf, genesis, 9))
+
+	id1 := NewForkID(conf, genesis, 10)
+	assert.Equal(t, uint64(20), id1.Next)
+	assert.NotEqual(t, id0.Hash, id1.Hash)
+
+	id2 := NewForkID(conf, genesis, 100)
+	assert.Equal(t, uint64(0), id2.Next)
+	assert.NotEqual(t, id1.Hash, id2.Hash)
+
+	// the chain without forks only has the genesis checksum
+	assert.Equal(t, id0.Hash, NewForkID(chain_config.DefaultChainConfig(), genesis, 100).Hash)
+	assert.NotEqual(t, id0.Hash, NewForkID(conf, common.HexToHash("0x5678"), 0).Hash)
+}
+
+func Test_validForkID(t *testing.T) {
+	conf := forkIDTestConfig()
+	genesis := common.HexToHash("0x1234")
+	id := func(head uint64) ForkID {
+		return NewForkID(conf, genesis, head)
+	}
+	otherConf := forkIDTestConfig()
+	otherConf.EarthBlock = big.NewInt(12)
+	withNext := func(id ForkID, next uint64) ForkID {
+		id.Next = next
+		return id
+	}
+
+	testCases := []struct {
+		name   string
+		head   uint64
+		remote ForkID
+		err    error
+	}{
+		{"same forks", 5, id(5), nil},
+		{"remote passed the next fork", 5, id(15), nil},
+		{"remote passed all forks", 5, id(25), nil},
+		{"local passed the remote next fork", 15, id(5), nil},
+		{"remote doesn't schedule the next fork", 15, withNext(id(5), 0), g_error.ErrForkIdRemoteStale},
+		{"remote schedules another fork", 15, withNext(id(5), 12), g_error.ErrForkIdRemoteStale},
+		{"remote schedules a passed fork", 25, withNext(id(25), 22), g_error.ErrForkIdIncompatible},
+		{"remote schedules a future fork", 25, withNext(id(25), 30), nil},
+		{"different genesis", 5, NewForkID(conf, common.HexToHash("0x5678"), 5), g_error.ErrForkIdIncompatible},
+		{"remote without forks", 15, NewForkID(chain_config.DefaultChainConfig(), genesis, 15), g_error.ErrForkIdRemoteStale},
+		{"different fork", 15, NewForkID(otherConf, genesis, 15), g_error.ErrForkIdIncompatible},
+		{"max head", math.MaxUint64 - 1, id(25), nil},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.err, validForkID(conf, genesis, tc.head, tc.remote), tc.name)
+	}
+}
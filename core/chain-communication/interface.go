@@ -21,10 +21,12 @@ import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/core/accounts"
 	"github.com/dipperin/dipperin-core/core/bloom"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/p2p"
 	"github.com/dipperin/dipperin-core/third-party/p2p/enode"
 	"net"
+	"time"
 )
 
 //go:generate mockgen -destination=./peer_mock_test.go -package=chain_communication -self_package=github.com/dipperin/dipperin-core/core/chain-communication github.com/dipperin/dipperin-core/core/chain-communication PmAbstractPeer
@@ -113,7 +115,7 @@ type TxPool interface {
 
 //go:generate mockgen -destination=./pbft_node_mock_test.go -package=chain_communication  -self_package=github.com/dipperin/dipperin-core/core/chain-communication github.com/dipperin/dipperin-core/core/chain-communication PbftNode
 type PbftNode interface {
-	OnNewWaitVerifyBlock(block model.AbstractBlock, id string)
+	OnNewWaitVerifyBlock(block model.AbstractBlock, id string) error
 	OnNewMsg(msg interface{}) error
 	ChangePrimary(primary string)
 
@@ -135,6 +137,8 @@ type P2PServer interface {
 	AddPeer(node *enode.Node)
 	RemovePeer(node *enode.Node)
 	Self() *enode.Node
+	BanPeer(id enode.ID, d time.Duration) error
+	TempBans(id enode.ID) int
 }
 
 //go:generate mockgen -destination=./chain_mock_test.go -package=chain_communication github.com/dipperin/dipperin-core/core/chain-communication Chain
@@ -148,6 +152,14 @@ type Chain interface {
 	SaveBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error
 }
 
+// StateSyncChain serves the state trie nodes and saves the blocks downloaded by the fast sync
+type StateSyncChain interface {
+	GetStateStorage() state_processor.StateStorage
+	GetLastChangePoint(block model.AbstractBlock) *uint64
+	NumBeforeLastBySlot(slot uint64) *uint64
+	SaveFastBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error
+}
+
 //go:generate mockgen -destination=./pbft_signer_mock_test.go -package=chain_communication github.com/dipperin/dipperin-core/core/chain-communication PbftSigner
 type PbftSigner interface {
 	GetAddress() common.Address
@@ -158,6 +170,11 @@ type PbftSigner interface {
 	Evaluate(account accounts.Account, seed []byte) (index [32]byte, proof []byte, err error)
 }
 
+// HandshakeSigner is implemented by the pbft signers that don't sign raw hashes, like a remote signer
+type HandshakeSigner interface {
+	SignHandshake(data HandShakeData) ([]byte, error)
+}
+
 //go:generate mockgen -destination=./verifiers_reader_mock_test.go -package=chain_communication github.com/dipperin/dipperin-core/core/chain-communication VerifiersReader
 type VerifiersReader interface {
 	CurrentVerifiers() []common.Address
@@ -172,6 +189,8 @@ type PeerManager interface {
 	IsSync() bool
 	GetPeer(id string) PmAbstractPeer
 	RemovePeer(id string)
+	// lower the score of a misbehaving peer, which is banned once its score is too low
+	ReportPeerFault(id string, fault PeerFault)
 }
 
 type AbstractPbftProtocolManager interface {
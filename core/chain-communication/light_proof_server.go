@@ -0,0 +1,124 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"sync"
+	"time"
+)
+
+// the least interval between two proof requests served for a peer
+var lightProofServeInterval = 10 * time.Second
+
+// MakeLightProofServer every node serves the interlink proofs of its chain for the light clients
+func MakeLightProofServer(config *LightProofServerConfig) *LightProofServer {
+	service := &LightProofServer{
+		LightProofServerConfig: config,
+		handlers:               map[uint64]func(msg p2p.Msg, p PmAbstractPeer) error{},
+		served:                 map[string]time.Time{},
+	}
+	service.handlers[GetInterLinkProofMsg] = service.onGetInterLinkProof
+	return service
+}
+
+type LightProofServerConfig struct {
+	Chain Chain
+}
+
+type LightProofServer struct {
+	*LightProofServerConfig
+	handlers map[uint64]func(msg p2p.Msg, p PmAbstractPeer) error
+
+	lock sync.Mutex
+	// the last serve time of each peer
+	served map[string]time.Time
+	// the last generated proof, it's reused until the chain head changes
+	cache *lightProofCache
+}
+
+type lightProofCache struct {
+	head  common.Hash
+	m     uint64
+	k     uint64
+	proof *chain.Proof
+}
+
+func (ls *LightProofServer) MsgHandlers() map[uint64]func(msg p2p.Msg, p PmAbstractPeer) error {
+	return ls.handlers
+}
+
+func (ls *LightProofServer) onGetInterLinkProof(msg p2p.Msg, p PmAbstractPeer) error {
+	var query getInterLinkProof
+	if err := msg.Decode(&query); err != nil {
+		return errors.New("decode error, invalid message")
+	}
+	if query.M == 0 || query.M > maxLightProofParam || query.K == 0 || query.K > maxLightProofParam {
+		return errors.New("invalid interlink proof params")
+	}
+	if !ls.allowServe(p.ID(), time.Now()) {
+		log.Debug("drop the too frequent interlink proof request", "remote node", p.NodeName())
+		return nil
+	}
+	log.Info("receive get interlink proof msg", "m", query.M, "k", query.K, "remote node", p.NodeName())
+
+	// send an empty proof if nothing to serve
+	proof, err := ls.suffixProof(query.M, query.K)
+	if err != nil {
+		log.Warn("get interlink suffix proof failed", "err", err)
+		proof = &chain.Proof{}
+	}
+	return p.SendMsg(InterLinkProofMsg, proof)
+}
+
+// allowServe limits the proof requests of each peer, and removes the expired serve records
+func (ls *LightProofServer) allowServe(peerID string, now time.Time) bool {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	if last, ok := ls.served[peerID]; ok && now.Sub(last) < lightProofServeInterval {
+		return false
+	}
+	for id, last := range ls.served {
+		if now.Sub(last) >= lightProofServeInterval {
+			delete(ls.served, id)
+		}
+	}
+	ls.served[peerID] = now
+	return true
+}
+
+// suffixProof walks the chain only once for each head, the requests are served one by one
+func (ls *LightProofServer) suffixProof(m, k uint64) (*chain.Proof, error) {
+	ls.lock.Lock()
+	defer ls.lock.Unlock()
+
+	head := ls.Chain.CurrentBlock().Hash()
+	if c := ls.cache; c != nil && c.head.IsEqual(head) && c.m == m && c.k == k {
+		return c.proof, nil
+	}
+	proof, err := chain.GetSuffixProof(ls.Chain, int(m), k)
+	if err != nil {
+		return nil, err
+	}
+	ls.cache = &lightProofCache{head: head, m: m, k: k, proof: proof}
+	return proof, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLightChain struct {
+	Chain
+	blocks []model.AbstractBlock
+}
+
+func (f *fakeLightChain) CurrentBlock() model.AbstractBlock {
+	return f.blocks[len(f.blocks)-1]
+}
+
+func (f *fakeLightChain) GetBlockByNumber(number uint64) model.AbstractBlock {
+	if number >= uint64(len(f.blocks)) {
+		return nil
+	}
+	return f.blocks[number]
+}
+
+func (f *fakeLightChain) GetBlockByHash(hash common.Hash) model.AbstractBlock {
+	for _, b := range f.blocks {
+		if b.Hash().IsEqual(hash) {
+			return b
+		}
+	}
+	return nil
+}
+
+func newFakeLightChain(count int) *fakeLightChain {
+	diff := common.HexToDiff("0x20ffffff")
+	header := model.NewHeader(1, 0, common.Hash{}, common.HexToHash("1111"), diff, big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
+	f := &fakeLightChain{blocks: []model.AbstractBlock{model.NewBlock(header, nil, nil)}}
+	for i := 0; i < count; i++ {
+		pre := f.CurrentBlock()
+		header = model.NewHeader(1, pre.Number()+1, pre.Hash(), common.HexToHash("1111"), diff, big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
+		f.blocks = append(f.blocks, model.NewBlockWithLink(header, nil, nil, pre.GetInterlinks()))
+	}
+	return f
+}
+
+func getInterLinkProofMsg(t *testing.T, m, k uint64) p2p.Msg {
+	data, err := rlp.EncodeToBytes(&getInterLinkProof{M: m, K: k})
+	assert.NoError(t, err)
+	return p2p.Msg{Payload: bytes.NewReader(data)}
+}
+
+func TestLightProofServer_MsgHandlers(t *testing.T) {
+	ls := MakeLightProofServer(&LightProofServerConfig{})
+
+	handlers := ls.MsgHandlers()
+	assert.NotNil(t, handlers[GetInterLinkProofMsg])
+	assert.Nil(t, handlers[InterLinkProofMsg])
+}
+
+func TestLightProofServer_onGetInterLinkProof(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeChain := newFakeLightChain(200)
+	ls := MakeLightProofServer(&LightProofServerConfig{Chain: fakeChain})
+
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+	mockPeer.EXPECT().ID().Return("1").AnyTimes()
+
+	assert.Error(t, ls.onGetInterLinkProof(p2p.Msg{Payload: bytes.NewReader([]byte{})}, mockPeer))
+	assert.Error(t, ls.onGetInterLinkProof(getInterLinkProofMsg(t, 0, LightProofK), mockPeer))
+	assert.Error(t, ls.onGetInterLinkProof(getInterLinkProofMsg(t, LightProofM, maxLightProofParam+1), mockPeer))
+
+	var served *chain.Proof
+	mockPeer.EXPECT().SendMsg(uint64(InterLinkProofMsg), gomock.Any()).DoAndReturn(func(code uint64, msg interface{}) error {
+		served = msg.(*chain.Proof)
+		return nil
+	}).Times(2)
+
+	assert.NoError(t, ls.onGetInterLinkProof(getInterLinkProofMsg(t, LightProofM, LightProofK), mockPeer))
+	assert.NoError(t, served.Valid(fakeChain.GetBlockByNumber(0).Hash(), LightProofK))
+	assert.Equal(t, uint64(200), served.Tip().Number)
+
+	// the second request of the peer is dropped before the serve interval
+	assert.NoError(t, ls.onGetInterLinkProof(getInterLinkProofMsg(t, LightProofM, LightProofK), mockPeer))
+
+	// the chain is too short for the suffix, serve an empty proof
+	short := MakeLightProofServer(&LightProofServerConfig{Chain: newFakeLightChain(LightProofK - 1)})
+	assert.NoError(t, short.onGetInterLinkProof(getInterLinkProofMsg(t, LightProofM, LightProofK), mockPeer))
+	assert.Len(t, served.Suffix, 0)
+}
+
+func TestLightProofServer_allowServe(t *testing.T) {
+	ls := MakeLightProofServer(&LightProofServerConfig{})
+
+	now := time.Now()
+	assert.True(t, ls.allowServe("1", now))
+	assert.False(t, ls.allowServe("1", now.Add(lightProofServeInterval/2)))
+	assert.True(t, ls.allowServe("2", now.Add(lightProofServeInterval/2)))
+
+	// the expired record of peer 1 is removed
+	assert.True(t, ls.allowServe("1", now.Add(lightProofServeInterval)))
+	assert.True(t, ls.allowServe("3", now.Add(2*lightProofServeInterval)))
+	assert.Len(t, ls.served, 1)
+}
+
+func TestLightProofServer_suffixProof(t *testing.T) {
+	fakeChain := newFakeLightChain(100)
+	ls := MakeLightProofServer(&LightProofServerConfig{Chain: fakeChain})
+
+	proof, err := ls.suffixProof(LightProofM, LightProofK)
+	assert.NoError(t, err)
+
+	// the proof is reused until the head changes
+	cached, err := ls.suffixProof(LightProofM, LightProofK)
+	assert.NoError(t, err)
+	assert.True(t, proof == cached)
+
+	other, err := ls.suffixProof(LightProofM, LightProofK+1)
+	assert.NoError(t, err)
+	assert.Len(t, other.Suffix, LightProofK+1)
+
+	pre := fakeChain.CurrentBlock()
+	header := model.NewHeader(1, pre.Number()+1, pre.Hash(), common.HexToHash("1111"), common.HexToDiff("0x20ffffff"), big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
+	fakeChain.blocks = append(fakeChain.blocks, model.NewBlockWithLink(header, nil, nil, pre.GetInterlinks()))
+	latest, err := ls.suffixProof(LightProofM, LightProofK+1)
+	assert.NoError(t, err)
+	assert.Equal(t, fakeChain.CurrentBlock().Hash(), latest.Tip().Hash())
+}
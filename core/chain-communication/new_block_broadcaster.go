@@ -157,7 +157,7 @@ func (broadcaster *NewBlockBroadcaster) onNewBlock(msg p2p.Msg, p PmAbstractPeer
 	pbftNode := broadcaster.PbftNode
 	log.Info("Get new block", "from", p.NodeName(), "Is pbft", !reflect.ValueOf(pbftNode).IsNil())
 	if !reflect.ValueOf(pbftNode).IsNil() {
-		pbftNode.OnNewWaitVerifyBlock(&block, p.ID())
+		return pbftNode.OnNewWaitVerifyBlock(&block, p.ID())
 	}
 	return nil
 
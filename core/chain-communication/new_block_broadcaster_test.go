@@ -278,11 +278,23 @@ func TestNewBlockBroadcaster_onNewBlock(t *testing.T) {
 		Payload: bytes.NewReader(payload),
 	}
 
-	mockPbftNode.EXPECT().OnNewWaitVerifyBlock(gomock.Any(), gomock.Any()).Times(1)
+	mockPbftNode.EXPECT().OnNewWaitVerifyBlock(gomock.Any(), gomock.Any()).Return(nil)
 
 	err = bb.onNewBlock(msg, mockPeer)
 
 	assert.NoError(t, err)
+
+	// the invalid blocks are reported back to the peer
+	msg = p2p.Msg{
+		Payload: bytes.NewReader(payload),
+	}
+	mockPbftNode.EXPECT().OnNewWaitVerifyBlock(gomock.Any(), "1").Return(NewPeerFaultError(FaultBadBlock, errors.New("test")))
+
+	err = bb.onNewBlock(msg, mockPeer)
+
+	fault, ok := faultOfErr(err)
+	assert.True(t, ok)
+	assert.Equal(t, FaultBadBlock, fault)
 }
 
 func TestNewBlockBroadcaster_newBlockReceiver(t *testing.T) {
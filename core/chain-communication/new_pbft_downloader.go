@@ -40,17 +40,23 @@ func MakeNewPbftDownloader(config *NewPbftDownloaderConfig) *NewPbftDownloader {
 		NewPbftDownloaderConfig: config,
 		handlers:                map[uint64]func(msg p2p.Msg, p PmAbstractPeer) error{},
 		blockC:                  make(chan *npbPack),
+		nodeDataC:               make(chan *nodeDataPack, 1),
 		quitCh:                  make(chan struct{}),
 	}
 	service.handlers[GetBlocksMsg] = service.onGetBlocks
 	service.handlers[BlocksMsg] = service.onBlocks
+	service.handlers[GetNodeDataMsg] = service.onGetNodeData
+	service.handlers[NodeDataMsg] = service.onNodeData
 	return service
 }
 
 type NewPbftDownloaderConfig struct {
-	Chain    Chain
-	Pm       PeerManager
-	PbftNode PbftNode
+	Chain      Chain
+	StateChain StateSyncChain
+	Pm         PeerManager
+	PbftNode   PbftNode
+	// download the state tries of a recent pivot block instead of processing all blocks
+	FastSync bool
 	//fetcher  *EiBlockFetcher
 	fetcher *BlockFetcher
 }
@@ -59,6 +65,7 @@ type NewPbftDownloader struct {
 	*NewPbftDownloaderConfig
 	handlers      map[uint64]func(msg p2p.Msg, p PmAbstractPeer) error
 	blockC        chan *npbPack
+	nodeDataC     chan *nodeDataPack
 	quitCh        chan struct{}
 	synchronising int32
 }
@@ -214,10 +221,11 @@ func (fd *NewPbftDownloader) runSync() {
 	}
 	defer atomic.StoreInt32(&fd.synchronising, 0)
 
-	// clear old blocks in chan
+	// clear old blocks and node data in chan
 	for empty := false; !empty; {
 		select {
 		case <-fd.blockC:
+		case <-fd.nodeDataC:
 		default:
 			empty = true
 		}
@@ -228,6 +236,14 @@ func (fd *NewPbftDownloader) runSync() {
 		return
 	}
 
+	// the full sync goes on from the pivot in the next round
+	if pivot, ok := fd.fastSyncPivot(bestPeer); ok {
+		if err := fd.fastSync(bestPeer, pivot); err != nil {
+			log.Warn("fast sync failed", "err", err, "remote node", bestPeer.NodeName())
+		}
+		return
+	}
+
 	fd.fetchBlocks(bestPeer)
 }
 
@@ -272,6 +288,7 @@ func (fd *NewPbftDownloader) fetchBlocks(bestPeer PmAbstractPeer) {
 			if size > 0 {
 				if err := fd.importBlockResults(blocks); err != nil {
 					log.Error("downloader save block failed", "err", err, "remote node", bestPeer.NodeName())
+					fd.Pm.ReportPeerFault(bestPeer.ID(), FaultBadBlock)
 					return
 				}
 				nextNumber += uint64(len(blocks))
@@ -288,6 +305,7 @@ func (fd *NewPbftDownloader) fetchBlocks(bestPeer PmAbstractPeer) {
 			go bestPeer.SendMsg(GetBlocksMsg, &getBlockHeaders{OriginHeight: nextNumber, Amount: MaxBlockFetch})
 		case <-timeoutTimer.C:
 			log.Warn("Waiting for fetchHeaders headers timed out", "node name", bestPeer.NodeName())
+			fd.Pm.ReportPeerFault(bestPeer.ID(), FaultTimeout)
 			return
 
 		case <-fd.quitCh:
@@ -369,6 +369,7 @@ func TestNewPbftDownloader_fetchBlocks(t *testing.T) {
 	pbftDownloader.fetchBlocks(mockPeer)
 
 	mockChain.EXPECT().SaveBlock(gomock.Any(), gomock.Any()).Return(errors.New("test")).Times(1)
+	mockPM.EXPECT().ReportPeerFault("1", FaultBadBlock).Times(1)
 
 	mockNpbPack3 := &npbPack{
 		peerID: "1",
@@ -416,12 +417,12 @@ func TestNewPbftDownloader_fetchBlocks(t *testing.T) {
 	mockPeer.EXPECT().GetHead().Return(common.HexToHash("0x123"), uint64(2)).Times(1)
 
 	fetchBlockTimeout = 1 * time.Millisecond
-
-	go func() {
-		pbftDownloader2.fetchBlocks(mockPeer)
+	defer func() {
+		fetchBlockTimeout = 60 * time.Second
 	}()
+	mockPM.EXPECT().ReportPeerFault("1", FaultTimeout).Times(1)
 
-	time.Sleep(10 * time.Millisecond)
+	pbftDownloader2.fetchBlocks(mockPeer)
 }
 
 func TestNewPbftDownloader_importBlockResults(t *testing.T) {
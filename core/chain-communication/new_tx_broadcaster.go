@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
@@ -135,7 +136,11 @@ func (broadcaster *NewTxBroadcaster) onNewTx(msg p2p.Msg, p PmAbstractPeer) erro
 	for i := range errs {
 		if errs[i] != nil {
 			log.Debug("tx pool AddRemotes error", "index", i, "err", errs[i])
-			//You cannot return err here, otherwise the peer will be disconnected.
+			// txs that can never be valid are the peer's fault, other errors like known or underpriced txs
+			// cannot be returned here, otherwise the peer will be disconnected.
+			if errs[i] == g_error.ErrTxInvalidSender || errs[i] == g_error.ErrTxNegativeValue {
+				return NewPeerFaultError(FaultBadTx, errs[i])
+			}
 			return nil
 		}
 	}
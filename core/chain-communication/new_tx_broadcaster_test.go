@@ -19,6 +19,7 @@ package chain_communication
 import (
 	"errors"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/p2p"
@@ -127,6 +128,15 @@ func Test_onNewTx(t *testing.T) {
 
 	assert.NoError(t, err)
 
+	mockDecoder.EXPECT().DecodeTxsMsg(gomock.Any()).Return(txs, nil)
+	mockTxPool.EXPECT().AddRemotes(gomock.Any()).Return([]error{g_error.ErrTxInvalidSender})
+
+	err = ntb.onNewTx(p2p.Msg{}, mockPeer)
+
+	fault, ok := faultOfErr(err)
+	assert.True(t, ok)
+	assert.Equal(t, FaultBadTx, fault)
+
 	mockDecoder.EXPECT().DecodeTxsMsg(gomock.Any()).Return(txs, nil)
 	mockTxPool.EXPECT().AddRemotes(gomock.Any()).Return([]error{})
 
@@ -24,6 +24,7 @@ import (
 	enode "github.com/dipperin/dipperin-core/third-party/p2p/enode"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockP2PServer is a mock of P2PServer interface
@@ -61,6 +62,20 @@ func (mr *MockP2PServerMockRecorder) AddPeer(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPeer", reflect.TypeOf((*MockP2PServer)(nil).AddPeer), arg0)
 }
 
+// BanPeer mocks base method
+func (m *MockP2PServer) BanPeer(arg0 enode.ID, arg1 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanPeer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BanPeer indicates an expected call of BanPeer
+func (mr *MockP2PServerMockRecorder) BanPeer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanPeer", reflect.TypeOf((*MockP2PServer)(nil).BanPeer), arg0, arg1)
+}
+
 // RemovePeer mocks base method
 func (m *MockP2PServer) RemovePeer(arg0 *enode.Node) {
 	m.ctrl.T.Helper()
@@ -86,3 +101,17 @@ func (mr *MockP2PServerMockRecorder) Self() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Self", reflect.TypeOf((*MockP2PServer)(nil).Self))
 }
+
+// TempBans mocks base method
+func (m *MockP2PServer) TempBans(arg0 enode.ID) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TempBans", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// TempBans indicates an expected call of TempBans
+func (mr *MockP2PServerMockRecorder) TempBans(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TempBans", reflect.TypeOf((*MockP2PServer)(nil).TempBans), arg0)
+}
@@ -118,9 +118,11 @@ func (mr *MockPbftNodeMockRecorder) OnNewP2PMsg(arg0, arg1 interface{}) *gomock.
 }
 
 // OnNewWaitVerifyBlock mocks base method
-func (m *MockPbftNode) OnNewWaitVerifyBlock(arg0 model.AbstractBlock, arg1 string) {
+func (m *MockPbftNode) OnNewWaitVerifyBlock(arg0 model.AbstractBlock, arg1 string) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "OnNewWaitVerifyBlock", arg0, arg1)
+	ret := m.ctrl.Call(m, "OnNewWaitVerifyBlock", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // OnNewWaitVerifyBlock indicates an expected call of OnNewWaitVerifyBlock
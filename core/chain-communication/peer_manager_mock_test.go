@@ -116,3 +116,15 @@ func (mr *MockPeerManagerMockRecorder) RemovePeer(arg0 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePeer", reflect.TypeOf((*MockPeerManager)(nil).RemovePeer), arg0)
 }
+
+// ReportPeerFault mocks base method
+func (m *MockPeerManager) ReportPeerFault(arg0 string, arg1 PeerFault) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReportPeerFault", arg0, arg1)
+}
+
+// ReportPeerFault indicates an expected call of ReportPeerFault
+func (mr *MockPeerManagerMockRecorder) ReportPeerFault(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportPeerFault", reflect.TypeOf((*MockPeerManager)(nil).ReportPeerFault), arg0, arg1)
+}
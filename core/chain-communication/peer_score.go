@@ -0,0 +1,184 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"fmt"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"sync"
+	"time"
+)
+
+// PeerFault is a kind of misbehaviour of a remote peer
+type PeerFault int
+
+const (
+	// message payload can't be decoded
+	FaultBadMsg PeerFault = iota
+	// block failed verification
+	FaultBadBlock
+	// vote carries an invalid signature
+	FaultBadVote
+	// tx can never be valid, like one with an invalid signature
+	FaultBadTx
+	// request not answered in time
+	FaultTimeout
+)
+
+var faultNames = map[PeerFault]string{
+	FaultBadMsg:   "bad message",
+	FaultBadBlock: "bad block",
+	FaultBadVote:  "bad vote signature",
+	FaultBadTx:    "bad transaction",
+	FaultTimeout:  "timeout",
+}
+
+func (f PeerFault) String() string {
+	if name, ok := faultNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown fault %d", int(f))
+}
+
+var (
+	// score lost for each fault, a peer starts with zero
+	faultPenalties = map[PeerFault]int64{
+		FaultBadMsg:   20,
+		FaultBadBlock: 50,
+		FaultBadVote:  50,
+		FaultBadTx:    20,
+		FaultTimeout:  10,
+	}
+	// a peer whose score drops to this value is banned
+	peerBanThreshold int64 = -100
+	// score regained per minute without faults, until back at zero
+	peerScoreRecovery int64 = 5
+	// how long a ban lasts, the ban after maxTempBans temporary ones is permanent
+	peerTempBanDuration = time.Hour
+	peerMaxTempBans     = 3
+)
+
+// PeerFaultError is returned by msg handlers when a message proves the remote
+// peer misbehaving, so the protocol manager can score the peer.
+type PeerFaultError struct {
+	Fault PeerFault
+	Err   error
+}
+
+func NewPeerFaultError(fault PeerFault, err error) error {
+	return &PeerFaultError{Fault: fault, Err: err}
+}
+
+func (e *PeerFaultError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Fault, e.Err)
+}
+
+// faultOfErr finds out whether a msg handling error is the remote peer's fault
+func faultOfErr(err error) (PeerFault, bool) {
+	if fe, ok := err.(*PeerFaultError); ok {
+		return fe.Fault, true
+	}
+	if p2p.IsMsgDecodeError(err) {
+		return FaultBadMsg, true
+	}
+	return 0, false
+}
+
+type peerScore struct {
+	score   int64
+	updated time.Time
+}
+
+// catch up on the recovery since the last update
+func (ps *peerScore) recover(now time.Time) {
+	if ps.score >= 0 {
+		ps.updated = now
+		return
+	}
+	// only whole minutes count, the rest is carried over to the next update
+	minutes := now.Sub(ps.updated) / time.Minute
+	ps.updated = ps.updated.Add(minutes * time.Minute)
+	ps.score += int64(minutes) * peerScoreRecovery
+	if ps.score > 0 {
+		ps.score = 0
+	}
+}
+
+// peerScorer keeps the scores of peers by node id, the scores outlive
+// the connections so reconnecting doesn't wipe out a bad record. The
+// temporary bans are counted where the bans are stored, so they outlive
+// restarts as well.
+type peerScorer struct {
+	lock     sync.Mutex
+	scores   map[string]*peerScore
+	ban      func(id string, d time.Duration)
+	tempBans func(id string) int
+}
+
+func newPeerScorer(ban func(id string, d time.Duration), tempBans func(id string) int) *peerScorer {
+	return &peerScorer{
+		scores:   map[string]*peerScore{},
+		ban:      ban,
+		tempBans: tempBans,
+	}
+}
+
+// penalise lowers the score of the peer for the fault and bans the peer once
+// its score reaches the threshold. Returns whether the peer was banned.
+func (s *peerScorer) penalise(id string, fault PeerFault) bool {
+	now := time.Now()
+
+	s.lock.Lock()
+	// forget peers that behaved long enough
+	for k, ps := range s.scores {
+		ps.recover(now)
+		if ps.score == 0 {
+			delete(s.scores, k)
+		}
+	}
+	ps := s.scores[id]
+	if ps == nil {
+		ps = &peerScore{updated: now}
+		s.scores[id] = ps
+	}
+	ps.score -= faultPenalties[fault]
+	if ps.score > peerBanThreshold {
+		s.lock.Unlock()
+		return false
+	}
+
+	ps.score = 0
+	s.lock.Unlock()
+
+	d := peerTempBanDuration
+	if s.tempBans(id) >= peerMaxTempBans {
+		d = 0
+	}
+	s.ban(id, d)
+	return true
+}
+
+func (s *peerScorer) score(id string) int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ps := s.scores[id]
+	if ps == nil {
+		return 0
+	}
+	ps.recover(time.Now())
+	return ps.score
+}
@@ -0,0 +1,147 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"github.com/dipperin/dipperin-core/third-party/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFaultOfErr(t *testing.T) {
+	fault, ok := faultOfErr(NewPeerFaultError(FaultBadVote, errors.New("invalid signature")))
+	assert.True(t, ok)
+	assert.Equal(t, FaultBadVote, fault)
+
+	size, r, err := rlp.EncodeToReader([]uint64{1, 2})
+	assert.NoError(t, err)
+	var s string
+	fault, ok = faultOfErr(p2p.Msg{Code: 1, Size: uint32(size), Payload: r}.Decode(&s))
+	assert.True(t, ok)
+	assert.Equal(t, FaultBadMsg, fault)
+
+	_, ok = faultOfErr(errors.New("test"))
+	assert.False(t, ok)
+	_, ok = faultOfErr(msgHandleFuncNotFoundErr)
+	assert.False(t, ok)
+
+	assert.Equal(t, "bad vote signature: test", NewPeerFaultError(FaultBadVote, errors.New("test")).Error())
+	assert.Equal(t, "unknown fault 100", PeerFault(100).String())
+}
+
+func TestPeerScorer_penalise(t *testing.T) {
+	var bans []time.Duration
+	tempBans := 0
+	ban := func(id string, d time.Duration) {
+		assert.Equal(t, "p1", id)
+		bans = append(bans, d)
+		if d > 0 {
+			tempBans++
+		}
+	}
+	countBans := func(id string) int {
+		return tempBans
+	}
+	s := newPeerScorer(ban, countBans)
+
+	assert.False(t, s.penalise("p1", FaultBadBlock))
+	assert.Equal(t, int64(-50), s.score("p1"))
+	assert.Equal(t, int64(0), s.score("p2"))
+
+	// the temporary bans end in a permanent one
+	assert.True(t, s.penalise("p1", FaultBadBlock))
+	assert.Equal(t, int64(0), s.score("p1"))
+	for i := 1; i <= peerMaxTempBans; i++ {
+		for !s.penalise("p1", FaultTimeout) {
+		}
+	}
+	assert.Equal(t, []time.Duration{peerTempBanDuration, peerTempBanDuration, peerTempBanDuration, 0}, bans)
+
+	// the count of the temporary bans outlives the scores
+	bans = nil
+	s = newPeerScorer(ban, countBans)
+	for !s.penalise("p1", FaultBadBlock) {
+	}
+	assert.Equal(t, []time.Duration{0}, bans)
+}
+
+func TestPeerScore_recover(t *testing.T) {
+	now := time.Now()
+	ps := &peerScore{score: -20, updated: now}
+
+	// partial minutes are carried over
+	ps.recover(now.Add(90 * time.Second))
+	assert.Equal(t, -20+peerScoreRecovery, ps.score)
+	ps.recover(now.Add(120 * time.Second))
+	assert.Equal(t, -20+2*peerScoreRecovery, ps.score)
+
+	ps.recover(now.Add(time.Hour))
+	assert.Equal(t, int64(0), ps.score)
+	assert.Equal(t, now.Add(time.Hour), ps.updated)
+}
+
+func TestPeerScorer_forget(t *testing.T) {
+	s := newPeerScorer(func(id string, d time.Duration) {}, func(id string) int { return 0 })
+	s.penalise("p1", FaultTimeout)
+	s.scores["p1"].updated = time.Now().Add(-time.Hour)
+
+	s.penalise("p2", FaultTimeout)
+	assert.Len(t, s.scores, 1)
+	assert.NotNil(t, s.scores["p2"])
+}
+
+func TestCsProtocolManager_ReportPeerFault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := enode.ID{0x01}
+	mockP2PServer := NewMockP2PServer(ctrl)
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().ID().Return(id.String()).AnyTimes()
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+	mockPeer.EXPECT().DisconnectPeer().Times(1)
+
+	pm := &CsProtocolManager{
+		CsProtocolManagerConfig: &CsProtocolManagerConfig{P2PServer: mockP2PServer},
+		peerSetManager:          newCsPmPeerSetManager(base, P2PMaxPeerCount, nil, nil, nil, nil, nil),
+	}
+	pm.scorer = newPeerScorer(pm.banPeer, pm.tempBans)
+	assert.NoError(t, pm.peerSetManager.basePeers.AddPeer(mockPeer))
+
+	pm.ReportPeerFault(id.String(), FaultBadVote)
+	assert.NotNil(t, pm.GetPeer(id.String()))
+
+	mockP2PServer.EXPECT().TempBans(id).Return(0)
+	mockP2PServer.EXPECT().BanPeer(id, peerTempBanDuration).Return(nil)
+	pm.ReportPeerFault(id.String(), FaultBadVote)
+	assert.Nil(t, pm.GetPeer(id.String()))
+
+	// the temporary bans counted by the p2p server end in a permanent one
+	mockP2PServer.EXPECT().TempBans(id).Return(peerMaxTempBans)
+	mockP2PServer.EXPECT().BanPeer(id, time.Duration(0)).Return(nil)
+	pm.ReportPeerFault(id.String(), FaultBadVote)
+	pm.ReportPeerFault(id.String(), FaultBadVote)
+
+	// invalid ids can't be banned
+	pm.banPeer("invalid", 0)
+	assert.Equal(t, 0, pm.tempBans("invalid"))
+}
@@ -20,3 +20,8 @@ type getBlockHeaders struct {
 	OriginHeight uint64
 	Amount       uint64
 }
+
+type getInterLinkProof struct {
+	M uint64
+	K uint64
+}
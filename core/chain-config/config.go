@@ -57,6 +57,14 @@ const (
 	CallCreateDepth uint64 = 1024
 )
 
+// the difficulty adjustment algorithms
+const (
+	// retarget every BlockCountOfPeriod blocks by the time cost of the period
+	DiffAlgorithmPeriod = "period"
+	// retarget every block by the linearly weighted moving average of the latest LWMAWindow solve times
+	DiffAlgorithmLWMA = "lwma"
+)
+
 const (
 	NodeTypeOfNormal = iota
 	NodeTypeOfMineMaster
@@ -71,6 +79,11 @@ var (
 
 var config = defaultChainConfig()
 
+// DefaultChainConfig returns a new chain config of the boot env
+func DefaultChainConfig() *ChainConfig {
+	return defaultChainConfig()
+}
+
 func defaultChainConfig() *ChainConfig {
 	c := &ChainConfig{
 		//DeriveShaType:         DeriveShaTypeByHash,
@@ -96,6 +109,10 @@ func defaultChainConfig() *ChainConfig {
 		BlockGenerate: uint64(13),
 		//the block number in a difficulty adjust cycle
 		BlockCountOfPeriod: uint64(4096),
+		//the difficulty adjustment algorithm after the Neptune fork
+		DiffAlgorithm: DiffAlgorithmLWMA,
+		//the solve times averaged by the lwma algorithm
+		LWMAWindow: uint64(60),
 
 		//verifier boot node number
 		VerifierBootNodeNumber: 4,
@@ -165,6 +182,10 @@ type ChainConfig struct {
 	BlockGenerate uint64
 	//the block number in a difficulty adjust cycle
 	BlockCountOfPeriod uint64
+	//the difficulty adjustment algorithm used after the Neptune fork, the period algorithm is used if it's empty
+	DiffAlgorithm string
+	//the number of the latest normal blocks averaged by the lwma algorithm
+	LWMAWindow uint64
 
 	//verifier boot node number
 	VerifierBootNodeNumber int
@@ -174,12 +195,43 @@ type ChainConfig struct {
 
 	//number of block that special block can roll back
 	RollBackNum uint64
+
+	// hard fork heights, nil means the fork isn't scheduled
+	// EarthBlock reprices the non-zero bytes of the transaction data
+	EarthBlock *big.Int
+	// MarsBlock prices the memory growth of the wasm contracts by pages
+	MarsBlock *big.Int
+	// JupiterBlock passes the address, bytes, big integer, array and struct params to the wasm contracts
+	JupiterBlock *big.Int
+	// SaturnBlock keeps the failed contract transactions in the block with the failure reason in the receipt
+	SaturnBlock *big.Int
+	// UranusBlock lets the wasm contracts deploy contracts and destroy themselves
+	UranusBlock *big.Int
+	// NeptuneBlock switches the difficulty adjustment to the DiffAlgorithm
+	NeptuneBlock *big.Int
+	// PlutoBlock makes the verifiers sign the commits with their bls keys, the blocks after it carry one aggregate
+	// signature of the commits of the previous block instead of the signed votes
+	PlutoBlock *big.Int
+	// CeresBlock makes the built-in erc20 tokens record their transfers and approvals as logs in the receipts
+	CeresBlock *big.Int
+	// ErisBlock enables the multisig accounts and the multisig witness, the signature recoveries are charged in the intrinsic gas
+	ErisBlock *big.Int
+	// HaumeaBlock keeps the vote height of the processed evidence in the evidence account and records the slash as a log
+	HaumeaBlock *big.Int
+	// MakemakeBlock enables the hash time locked cross chain transfers
+	MakemakeBlock *big.Int
 }
 
 func GetChainConfig() *ChainConfig {
 	return config
 }
 
+// SetChainConfig replaces the chain config in place, so the components which have got the config see the new values.
+// It's used by the genesis spec before the node starts.
+func SetChainConfig(conf *ChainConfig) {
+	*config = *conf
+}
+
 // Get the operating environment：test mercury
 func GetCurBootsEnv() string {
 	return os.Getenv("boots_env")
@@ -221,9 +273,24 @@ var (
 var (
 	VerifierBootNodes []*enode.Node
 	KBucketNodes      []*enode.Node
+
+	// the boot nodes are set by the genesis spec
+	customBootNodes bool
 )
 
+// SetBootNodes replaces the boot nodes of the boot env, InitBootNodes keeps them
+func SetBootNodes(vBoots, kBoots []*enode.Node) {
+	VerifierBootNodes = vBoots
+	KBucketNodes = kBoots
+	customBootNodes = true
+}
+
 func InitBootNodes(dataDir string) {
+	if customBootNodes {
+		log.Info("use the boot nodes of the genesis spec", "verifier boot nodes", len(VerifierBootNodes), "boot nodes", len(KBucketNodes))
+		return
+	}
+
 	log.Info("the boot env is:", "env", os.Getenv(BootEnvTagName))
 	// If the environment variable is set during deploy use, these environment variables are automatically taken when the startup command is used.
 	switch os.Getenv(BootEnvTagName) {
@@ -0,0 +1,105 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_config
+
+import (
+	"math/big"
+	"sort"
+)
+
+// IsEarth returns whether the block number is at or after the Earth fork
+func (c *ChainConfig) IsEarth(number uint64) bool {
+	return isForked(c.EarthBlock, number)
+}
+
+// IsMars returns whether the block number is at or after the Mars fork
+func (c *ChainConfig) IsMars(number uint64) bool {
+	return isForked(c.MarsBlock, number)
+}
+
+// IsJupiter returns whether the block number is at or after the Jupiter fork
+func (c *ChainConfig) IsJupiter(number uint64) bool {
+	return isForked(c.JupiterBlock, number)
+}
+
+// IsSaturn returns whether the block number is at or after the Saturn fork
+func (c *ChainConfig) IsSaturn(number uint64) bool {
+	return isForked(c.SaturnBlock, number)
+}
+
+// IsUranus returns whether the block number is at or after the Uranus fork
+func (c *ChainConfig) IsUranus(number uint64) bool {
+	return isForked(c.UranusBlock, number)
+}
+
+// IsNeptune returns whether the block number is at or after the Neptune fork
+func (c *ChainConfig) IsNeptune(number uint64) bool {
+	return isForked(c.NeptuneBlock, number)
+}
+
+// IsPluto returns whether the block number is at or after the Pluto fork
+func (c *ChainConfig) IsPluto(number uint64) bool {
+	return isForked(c.PlutoBlock, number)
+}
+
+// IsCeres returns whether the block number is at or after the Ceres fork
+func (c *ChainConfig) IsCeres(number uint64) bool {
+	return isForked(c.CeresBlock, number)
+}
+
+// IsEris returns whether the block number is at or after the Eris fork
+func (c *ChainConfig) IsEris(number uint64) bool {
+	return isForked(c.ErisBlock, number)
+}
+
+// IsHaumea returns whether the block number is at or after the Haumea fork
+func (c *ChainConfig) IsHaumea(number uint64) bool {
+	return isForked(c.HaumeaBlock, number)
+}
+
+// IsMakemake returns whether the block number is at or after the Makemake fork
+func (c *ChainConfig) IsMakemake(number uint64) bool {
+	return isForked(c.MakemakeBlock, number)
+}
+
+// Forks returns the scheduled fork heights in ascending order without duplicates, the forks at the genesis
+// aren't included as they don't change the rules of any block.
+func (c *ChainConfig) Forks() []uint64 {
+	var forks []uint64
+	for _, fork := range []*big.Int{c.EarthBlock, c.MarsBlock, c.JupiterBlock, c.SaturnBlock, c.UranusBlock, c.NeptuneBlock, c.PlutoBlock, c.CeresBlock, c.ErisBlock, c.HaumeaBlock, c.MakemakeBlock} {
+		if fork == nil || fork.Sign() == 0 {
+			continue
+		}
+		forks = append(forks, fork.Uint64())
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i] < forks[j] })
+
+	result := forks[:0]
+	for i, fork := range forks {
+		if i == 0 || fork != forks[i-1] {
+			result = append(result, fork)
+		}
+	}
+	return result
+}
+
+func isForked(fork *big.Int, number uint64) bool {
+	if fork == nil {
+		return false
+	}
+	return fork.Cmp(new(big.Int).SetUint64(number)) <= 0
+}
@@ -0,0 +1,124 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestChainConfig_IsForks(t *testing.T) {
+	conf := DefaultChainConfig()
+	assert.False(t, conf.IsEarth(0))
+	assert.False(t, conf.IsMars(1000000))
+	assert.Empty(t, conf.Forks())
+
+	conf.EarthBlock = big.NewInt(0)
+	conf.MarsBlock = big.NewInt(100)
+	assert.True(t, conf.IsEarth(0))
+	assert.False(t, conf.IsMars(99))
+	assert.True(t, conf.IsMars(100))
+	assert.True(t, conf.IsMars(101))
+
+	assert.False(t, conf.IsJupiter(0))
+	conf.JupiterBlock = big.NewInt(200)
+	assert.False(t, conf.IsJupiter(199))
+	assert.True(t, conf.IsJupiter(200))
+
+	assert.False(t, conf.IsSaturn(0))
+	conf.SaturnBlock = big.NewInt(300)
+	assert.False(t, conf.IsSaturn(299))
+	assert.True(t, conf.IsSaturn(300))
+
+	assert.False(t, conf.IsUranus(0))
+	conf.UranusBlock = big.NewInt(400)
+	assert.False(t, conf.IsUranus(399))
+	assert.True(t, conf.IsUranus(400))
+
+	assert.False(t, conf.IsNeptune(0))
+	conf.NeptuneBlock = big.NewInt(500)
+	assert.False(t, conf.IsNeptune(499))
+	assert.True(t, conf.IsNeptune(500))
+
+	assert.False(t, conf.IsPluto(0))
+	conf.PlutoBlock = big.NewInt(600)
+	assert.False(t, conf.IsPluto(599))
+	assert.True(t, conf.IsPluto(600))
+
+	assert.False(t, conf.IsCeres(0))
+	conf.CeresBlock = big.NewInt(700)
+	assert.False(t, conf.IsCeres(699))
+	assert.True(t, conf.IsCeres(700))
+
+	assert.False(t, conf.IsEris(0))
+	conf.ErisBlock = big.NewInt(800)
+	assert.False(t, conf.IsEris(799))
+	assert.True(t, conf.IsEris(800))
+
+	assert.False(t, conf.IsHaumea(0))
+	conf.HaumeaBlock = big.NewInt(900)
+	assert.False(t, conf.IsHaumea(899))
+	assert.True(t, conf.IsHaumea(900))
+
+	assert.False(t, conf.IsMakemake(0))
+	conf.MakemakeBlock = big.NewInt(1000)
+	assert.False(t, conf.IsMakemake(999))
+	assert.True(t, conf.IsMakemake(1000))
+}
+
+func TestChainConfig_Forks(t *testing.T) {
+	conf := DefaultChainConfig()
+
+	// the forks at the genesis are skipped
+	conf.EarthBlock = big.NewInt(0)
+	conf.MarsBlock = big.NewInt(100)
+	assert.Equal(t, []uint64{100}, conf.Forks())
+
+	conf.EarthBlock = big.NewInt(200)
+	assert.Equal(t, []uint64{100, 200}, conf.Forks())
+
+	conf.EarthBlock = big.NewInt(100)
+	assert.Equal(t, []uint64{100}, conf.Forks())
+
+	conf.JupiterBlock = big.NewInt(50)
+	assert.Equal(t, []uint64{50, 100}, conf.Forks())
+
+	conf.SaturnBlock = big.NewInt(50)
+	assert.Equal(t, []uint64{50, 100}, conf.Forks())
+
+	conf.UranusBlock = big.NewInt(150)
+	assert.Equal(t, []uint64{50, 100, 150}, conf.Forks())
+
+	conf.NeptuneBlock = big.NewInt(120)
+	assert.Equal(t, []uint64{50, 100, 120, 150}, conf.Forks())
+
+	conf.PlutoBlock = big.NewInt(180)
+	assert.Equal(t, []uint64{50, 100, 120, 150, 180}, conf.Forks())
+
+	conf.CeresBlock = big.NewInt(100)
+	assert.Equal(t, []uint64{50, 100, 120, 150, 180}, conf.Forks())
+
+	conf.ErisBlock = big.NewInt(200)
+	assert.Equal(t, []uint64{50, 100, 120, 150, 180, 200}, conf.Forks())
+
+	conf.HaumeaBlock = big.NewInt(0)
+	assert.Equal(t, []uint64{50, 100, 120, 150, 180, 200}, conf.Forks())
+
+	conf.MakemakeBlock = big.NewInt(250)
+	assert.Equal(t, []uint64{50, 100, 120, 150, 180, 200, 250}, conf.Forks())
+}
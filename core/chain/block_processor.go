@@ -71,6 +71,11 @@ func (state *BlockProcessor) GetBlockHashByNumber(number uint64) common.Hash {
 	return state.fullChain.GetBlockByNumber(number).Hash()
 }
 
+// GetVerifiersByNumber returns the verifiers who vote for the block at the number
+func (state *BlockProcessor) GetVerifiersByNumber(number uint64) []common.Address {
+	return state_processor.VerifiersByNumber(state.fullChain)(number)
+}
+
 func (state *BlockProcessor) Process(block model.AbstractBlock, economyModel economy_model.EconomyModel) (err error) {
 	log.Mpt.Debug("AccountStateDB Process begin~~~~~~~~~~~~~~", "pre state", state.PreStateRoot().Hex(), "blockId", block.Hash().Hex())
 
@@ -82,11 +87,12 @@ func (state *BlockProcessor) Process(block model.AbstractBlock, economyModel eco
 	if !block.IsSpecial() {
 		if err = block.TxIterator(func(i int, tx model.AbstractTransaction) error {
 			conf := state_processor.TxProcessConfig{
-				Tx:       tx,
-				Header:   blockHeader,
-				GetHash:  state.GetBlockHashByNumber,
-				GasUsed:  &gasUsed,
-				GasLimit: &gasLimit,
+				Tx:           tx,
+				Header:       blockHeader,
+				GetHash:      state.GetBlockHashByNumber,
+				GetVerifiers: state.GetVerifiersByNumber,
+				GasUsed:      &gasUsed,
+				GasLimit:     &gasLimit,
 			}
 			innerError := state.ProcessTxNew(&conf)
 			/*// unrecognized tx means no processing of the tx
@@ -151,7 +157,10 @@ func (state *BlockProcessor) processCommitList(block model.AbstractBlock, isProc
 		}
 
 		// boot node verifier does't process verification
-		verifications := block.GetVerifications()
+		verifications, err := model.CommitVerifications(block, verifiers)
+		if err != nil {
+			return err
+		}
 		if preBlock.IsSpecial() {
 			verifications = verifications[1:]
 		}
@@ -41,6 +41,17 @@ func TestNewBlockProcessor(t *testing.T) {
 	assert.Nil(t, processor)
 }
 
+func TestBlockProcessor_GetVerifiersByNumber(t *testing.T) {
+	processor, err := NewBlockProcessor(fakeAccountDBChain{}, common.Hash{}, fakeStateStorage{})
+	assert.NoError(t, err)
+
+	assert.Nil(t, processor.GetVerifiersByNumber(0))
+	assert.Nil(t, processor.GetVerifiersByNumber(1))
+	assert.Nil(t, processor.GetVerifiersByNumber(12))
+	assert.Equal(t, VerifierAddress, processor.GetVerifiersByNumber(10))
+	assert.Equal(t, VerifierAddress, processor.GetVerifiersByNumber(11))
+}
+
 func TestBlockProcessor_Process(t *testing.T) {
 	db, root := createTestStateDB(t)
 	tdb := state_processor.NewStateStorageWithCache(db)
@@ -17,10 +17,13 @@
 package chaindb
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/contract"
 	"github.com/dipperin/dipperin-core/core/model"
 	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
@@ -28,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
+	"sort"
 )
 
 type ChainDB struct {
@@ -454,6 +458,250 @@ func (chainDB *ChainDB) DeleteTxLookupEntry(block model.AbstractBlock) {
 	}
 }
 
+// SaveAddressTxEntries saves the txs of the block to the tx history of their senders and receivers
+func (chainDB *ChainDB) SaveAddressTxEntries(block model.AbstractBlock) error {
+	if block.TxCount() == 0 {
+		return nil
+	}
+
+	batch := chainDB.db.NewBatch()
+	if err := block.TxIterator(func(index int, tx model.AbstractTransaction) error {
+		addresses, err := addressTxFlags(tx)
+		if err != nil {
+			return err
+		}
+
+		for address, flags := range addresses {
+			data, err := rlp.EncodeToBytes(addressTxStorage{TxHash: tx.CalTxId(), Flags: flags})
+			if err != nil {
+				return err
+			}
+			if err := batch.Put(addressTxKey(address, block.Number(), uint64(index)), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Error("save address tx entries failed", "num", block.Number(), "err", err)
+		return err
+	}
+	return batch.Write()
+}
+
+// DeleteAddressTxEntries deletes the txs of the block from the tx history, it's used when the block is rolled back
+func (chainDB *ChainDB) DeleteAddressTxEntries(block model.AbstractBlock) error {
+	if block.TxCount() == 0 {
+		return nil
+	}
+
+	batch := chainDB.db.NewBatch()
+	if err := block.TxIterator(func(index int, tx model.AbstractTransaction) error {
+		addresses, err := addressTxFlags(tx)
+		if err != nil {
+			return err
+		}
+		for address := range addresses {
+			if err := batch.Delete(addressTxKey(address, block.Number(), uint64(index))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Error("delete address tx entries failed", "num", block.Number(), "err", err)
+		return err
+	}
+	return batch.Write()
+}
+
+// GetAddressTxEntries returns the tx history of the address from the latest tx, the first offset
+// entries are skipped and at most limit entries are returned.
+func (chainDB *ChainDB) GetAddressTxEntries(address common.Address, offset, limit uint64) ([]AddressTxEntry, error) {
+	if limit == 0 {
+		return nil, nil
+	}
+
+	prefix := addressTxKey(address, 0, 0)[:len(addressTxPrefix)+common.AddressLength]
+	var entries []AddressTxEntry
+	err := iterateKeys(chainDB.db, prefix, func(key, value []byte) (bool, error) {
+		// the trie nodes with the same leading bytes are skipped
+		if len(key) != len(prefix)+16 {
+			return true, nil
+		}
+		if offset > 0 {
+			offset--
+			return true, nil
+		}
+
+		var storage addressTxStorage
+		if err := rlp.DecodeBytes(value, &storage); err != nil {
+			return false, err
+		}
+		entries = append(entries, AddressTxEntry{
+			TxHash:      storage.TxHash,
+			BlockNumber: ^binary.BigEndian.Uint64(key[len(prefix):]),
+			Index:       ^binary.BigEndian.Uint64(key[len(prefix)+8:]),
+			Sent:        storage.Flags&addressTxSent != 0,
+			Received:    storage.Flags&addressTxReceived != 0,
+		})
+		return uint64(len(entries)) < limit, nil
+	})
+	return entries, err
+}
+
+// addressTxFlags returns the addresses whose tx history contains the tx. The created contract is
+// the receiver of the contract creation, and the evidence is received by the address it reports.
+func addressTxFlags(tx model.AbstractTransaction) (map[common.Address]uint8, error) {
+	sender, err := tx.Sender(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := map[common.Address]uint8{sender: addressTxSent}
+	switch {
+	case tx.GetType() == common.AddressTypeContractCreate:
+		addresses[cs_crypto.CreateContractAddress(sender, tx.Nonce())] |= addressTxReceived
+	case tx.To() == nil:
+	case tx.GetType() == common.AddressTypeEvidence:
+		addresses[cs_crypto.GetNormalAddressFromEvidence(*tx.To())] |= addressTxReceived
+	default:
+		addresses[*tx.To()] |= addressTxReceived
+	}
+	return addresses, nil
+}
+
+// SaveERC20Transfers saves the transfers of the built-in erc20 tokens logged in the receipts of the block
+// to the transfer history of their senders and receivers
+func (chainDB *ChainDB) SaveERC20Transfers(block model.AbstractBlock, receipts model2.Receipts) error {
+	batch := chainDB.db.NewBatch()
+	if err := iterateERC20Transfers(receipts, func(address common.Address, txIndex, logIndex uint64, transfer *erc20TransferStorage) error {
+		data, err := rlp.EncodeToBytes(transfer)
+		if err != nil {
+			return err
+		}
+		return batch.Put(erc20TransferKey(address, block.Number(), txIndex, logIndex), data)
+	}); err != nil {
+		log.Error("save erc20 transfers failed", "num", block.Number(), "err", err)
+		return err
+	}
+	return batch.Write()
+}
+
+// DeleteERC20Transfers deletes the transfers logged in the receipts of the block from the transfer history,
+// it's used when the block is rolled back
+func (chainDB *ChainDB) DeleteERC20Transfers(block model.AbstractBlock, receipts model2.Receipts) error {
+	batch := chainDB.db.NewBatch()
+	if err := iterateERC20Transfers(receipts, func(address common.Address, txIndex, logIndex uint64, transfer *erc20TransferStorage) error {
+		return batch.Delete(erc20TransferKey(address, block.Number(), txIndex, logIndex))
+	}); err != nil {
+		log.Error("delete erc20 transfers failed", "num", block.Number(), "err", err)
+		return err
+	}
+	return batch.Write()
+}
+
+// GetERC20Transfers returns the erc20 transfer history of the address from the latest transfer, the first
+// offset entries are skipped and at most limit entries are returned.
+func (chainDB *ChainDB) GetERC20Transfers(address common.Address, offset, limit uint64) ([]ERC20TransferEntry, error) {
+	if limit == 0 {
+		return nil, nil
+	}
+
+	prefix := erc20TransferKey(address, 0, 0, 0)[:len(erc20TransferPrefix)+common.AddressLength]
+	var entries []ERC20TransferEntry
+	err := iterateKeys(chainDB.db, prefix, func(key, value []byte) (bool, error) {
+		// the trie nodes with the same leading bytes are skipped
+		if len(key) != len(prefix)+24 {
+			return true, nil
+		}
+		if offset > 0 {
+			offset--
+			return true, nil
+		}
+
+		var storage erc20TransferStorage
+		if err := rlp.DecodeBytes(value, &storage); err != nil {
+			return false, err
+		}
+		entries = append(entries, ERC20TransferEntry{
+			Token:       storage.Token,
+			TxHash:      storage.TxHash,
+			BlockNumber: ^binary.BigEndian.Uint64(key[len(prefix):]),
+			TxIndex:     ^binary.BigEndian.Uint64(key[len(prefix)+8:]),
+			LogIndex:    ^binary.BigEndian.Uint64(key[len(prefix)+16:]),
+			From:        storage.From,
+			To:          storage.To,
+			Value:       storage.Value,
+		})
+		return uint64(len(entries)) < limit, nil
+	})
+	return entries, err
+}
+
+// iterateERC20Transfers calls fn with the transfers in the receipts for both the sender and the receiver,
+// the empty sender of the token creation is skipped
+func iterateERC20Transfers(receipts model2.Receipts, fn func(address common.Address, txIndex, logIndex uint64, transfer *erc20TransferStorage) error) error {
+	for txIndex, receipt := range receipts {
+		for logIndex, l := range receipt.Logs {
+			from, to, value, ok := contract.ParseERC20TransferLog(l)
+			if !ok {
+				continue
+			}
+
+			transfer := &erc20TransferStorage{Token: l.Address, TxHash: l.TxHash, From: from, To: to, Value: value}
+			if !from.IsEmpty() {
+				if err := fn(from, uint64(txIndex), uint64(logIndex), transfer); err != nil {
+					return err
+				}
+			}
+			if to != from {
+				if err := fn(to, uint64(txIndex), uint64(logIndex), transfer); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// iterateKeys calls fn with the keys of the prefix in order until fn returns false or an error
+func iterateKeys(db ethdb.Database, prefix []byte, fn func(key, value []byte) (bool, error)) error {
+	switch db := db.(type) {
+	case *ethdb.LDBDatabase:
+		it := db.NewIteratorWithPrefix(prefix)
+		defer it.Release()
+		for it.Next() {
+			if next, err := fn(it.Key(), it.Value()); err != nil || !next {
+				return err
+			}
+		}
+		return it.Error()
+
+	case *ethdb.MemDatabase:
+		var keys [][]byte
+		for _, key := range db.Keys() {
+			if bytes.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+		for _, key := range keys {
+			value, err := db.Get(key)
+			if err != nil {
+				return err
+			}
+			if next, err := fn(key, value); err != nil || !next {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return g_error.ErrTxHistoryUnsupportedDB
+	}
+}
+
 func (chainDB *ChainDB) GetTransaction(txHash common.Hash) (model.AbstractTransaction, common.Hash, uint64, uint64) {
 	blockHash, blockNumber, txIndex := chainDB.GetTxLookupEntry(txHash)
 	if blockHash == (common.Hash{}) {
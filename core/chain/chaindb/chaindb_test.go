@@ -18,12 +18,18 @@ package chaindb
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/contract"
+	model2 "github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/tests/factory"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"testing"
 )
 
@@ -227,6 +233,172 @@ func TestWriteTxLookupEntries_Error(t *testing.T) {
 	db.GetTxLookupEntry(common.HexToHash("123"))
 }
 
+func TestChainDB_AddressTxEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "address_tx")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ldb, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	assert.NoError(t, err)
+	defer ldb.Close()
+
+	for _, db := range []*ChainDB{newChainDB(), NewChainDB(ldb, newDecoder())} {
+		b1 := createBlock(1)
+		b2 := createBlock(2)
+		assert.NoError(t, db.SaveAddressTxEntries(b1))
+		assert.NoError(t, db.SaveAddressTxEntries(b2))
+
+		// bob receives a tx in each block, the latest one comes first
+		entries, err := db.GetAddressTxEntries(factory.BobAddrV, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []AddressTxEntry{
+			{TxHash: b2.GetTransactions()[1].CalTxId(), BlockNumber: 2, Index: 1, Received: true},
+			{TxHash: b1.GetTransactions()[1].CalTxId(), BlockNumber: 1, Index: 1, Received: true},
+		}, entries)
+
+		entries, err = db.GetAddressTxEntries(factory.BobAddrV, 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []AddressTxEntry{{TxHash: b1.GetTransactions()[1].CalTxId(), BlockNumber: 1, Index: 1, Received: true}}, entries)
+
+		entries, err = db.GetAddressTxEntries(factory.BobAddrV, 0, 0)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 0)
+
+		// all the txs are sent by the same test key
+		tx := b1.GetTransactions()[0]
+		sender, err := tx.Sender(nil)
+		assert.NoError(t, err)
+		entries, err = db.GetAddressTxEntries(sender, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 4)
+		assert.Equal(t, AddressTxEntry{TxHash: tx.CalTxId(), BlockNumber: 1, Index: 0, Sent: true}, entries[3])
+
+		// the contract creation is received by the created contract
+		entries, err = db.GetAddressTxEntries(cs_crypto.CreateContractAddress(sender, tx.Nonce()), 0, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []AddressTxEntry{{TxHash: tx.CalTxId(), BlockNumber: 2, Index: 0, Received: true}}, entries)
+
+		// roll back the second block
+		assert.NoError(t, db.DeleteAddressTxEntries(b2))
+		entries, err = db.GetAddressTxEntries(factory.BobAddrV, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []AddressTxEntry{{TxHash: b1.GetTransactions()[1].CalTxId(), BlockNumber: 1, Index: 1, Received: true}}, entries)
+	}
+}
+
+func TestChainDB_AddressTxEntries_Error(t *testing.T) {
+	fakeDB := NewChainDB(fakeDataBase{err: BatchErr}, newDecoder())
+	b := createBlock(22)
+	assert.Equal(t, BatchErr, fakeDB.SaveAddressTxEntries(b))
+	assert.Equal(t, BatchErr, fakeDB.DeleteAddressTxEntries(b))
+
+	_, err := fakeDB.GetAddressTxEntries(factory.BobAddrV, 0, 10)
+	assert.Equal(t, g_error.ErrTxHistoryUnsupportedDB, err)
+
+	db := newChainDB()
+	assert.NoError(t, db.db.Put(addressTxKey(factory.BobAddrV, 1, 0), []byte{1}))
+	_, err = db.GetAddressTxEntries(factory.BobAddrV, 0, 10)
+	assert.Error(t, err)
+
+	// the tx without the signature has no sender
+	tx := model2.NewTransaction(0, factory.BobAddrV, g_testData.TestValue, g_testData.TestGasPrice, g_testData.TestGasLimit, []byte{})
+	header := model2.NewHeader(1, 1, common.Hash{}, common.Hash{}, common.HexToDiff("1fffffff"), big.NewInt(1), factory.AliceAddrV, common.BlockNonce{})
+	b = model2.NewBlock(header, []*model2.Transaction{tx}, nil)
+	assert.Error(t, db.SaveAddressTxEntries(b))
+	assert.Error(t, db.DeleteAddressTxEntries(b))
+	assert.NoError(t, db.SaveAddressTxEntries(model2.NewBlock(header, nil, nil)))
+	assert.NoError(t, db.DeleteAddressTxEntries(model2.NewBlock(header, nil, nil)))
+}
+
+func erc20TransferLog(token, from, to common.Address, value int64, txHash common.Hash) *model.Log {
+	return &model.Log{
+		Address: token,
+		Topics:  []common.Hash{contract.ERC20TransferTopic, from.Hash(), to.Hash()},
+		Data:    common.LeftPadBytes(big.NewInt(value).Bytes(), common.HashLength),
+		TxHash:  txHash,
+	}
+}
+
+func TestChainDB_ERC20Transfers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "erc20_transfer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ldb, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	assert.NoError(t, err)
+	defer ldb.Close()
+
+	token := common.HexToAddress("0x00100000FA42f7315cD04D6774E58B54e92603e96d84")
+	alice, bob := factory.AliceAddrV, factory.BobAddrV
+	txHash1, txHash2, txHash3 := common.HexToHash("1"), common.HexToHash("2"), common.HexToHash("3")
+	receipts1 := model.Receipts{
+		{Logs: []*model.Log{erc20TransferLog(token, common.Address{}, alice, 100, txHash1)}},
+		{Logs: []*model.Log{}},
+		{Logs: []*model.Log{
+			{Address: token, Topics: []common.Hash{contract.ERC20ApprovalTopic, alice.Hash(), bob.Hash()}, TxHash: txHash2},
+			erc20TransferLog(token, alice, bob, 3, txHash2),
+		}},
+	}
+	receipts2 := model.Receipts{
+		{Logs: []*model.Log{erc20TransferLog(token, bob, bob, 1, txHash3), erc20TransferLog(alice, bob, alice, 1, txHash3)}},
+	}
+
+	for _, db := range []*ChainDB{newChainDB(), NewChainDB(ldb, newDecoder())} {
+		b1 := createBlock(1)
+		b2 := createBlock(2)
+		assert.NoError(t, db.SaveERC20Transfers(b1, receipts1))
+		assert.NoError(t, db.SaveERC20Transfers(b2, receipts2))
+
+		// the latest transfer comes first, the transfer to oneself is saved once
+		entries, err := db.GetERC20Transfers(bob, 0, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []ERC20TransferEntry{
+			{Token: token, TxHash: txHash3, BlockNumber: 2, TxIndex: 0, LogIndex: 0, From: bob, To: bob, Value: big.NewInt(1)},
+			{Token: token, TxHash: txHash2, BlockNumber: 1, TxIndex: 2, LogIndex: 1, From: alice, To: bob, Value: big.NewInt(3)},
+		}, entries)
+
+		entries, err = db.GetERC20Transfers(alice, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, ERC20TransferEntry{Token: token, TxHash: txHash1, BlockNumber: 1, From: common.Address{}, To: alice, Value: big.NewInt(100)}, entries[1])
+
+		entries, err = db.GetERC20Transfers(alice, 1, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, txHash1, entries[0].TxHash)
+
+		entries, err = db.GetERC20Transfers(alice, 0, 0)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 0)
+
+		// the empty sender of the token creation isn't indexed
+		entries, err = db.GetERC20Transfers(common.Address{}, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 0)
+
+		// roll back the second block
+		assert.NoError(t, db.DeleteERC20Transfers(b2, receipts2))
+		entries, err = db.GetERC20Transfers(bob, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, txHash2, entries[0].TxHash)
+	}
+}
+
+func TestChainDB_ERC20Transfers_Error(t *testing.T) {
+	token := common.HexToAddress("0x00100000FA42f7315cD04D6774E58B54e92603e96d84")
+	receipts := model.Receipts{{Logs: []*model.Log{erc20TransferLog(token, factory.AliceAddrV, factory.BobAddrV, 1, common.HexToHash("1"))}}}
+	fakeDB := NewChainDB(fakeDataBase{err: BatchErr}, newDecoder())
+	b := createBlock(22)
+	assert.Equal(t, BatchErr, fakeDB.SaveERC20Transfers(b, receipts))
+	assert.Equal(t, BatchErr, fakeDB.DeleteERC20Transfers(b, receipts))
+
+	_, err := fakeDB.GetERC20Transfers(factory.BobAddrV, 0, 10)
+	assert.Equal(t, g_error.ErrTxHistoryUnsupportedDB, err)
+
+	db := newChainDB()
+	assert.NoError(t, db.db.Put(erc20TransferKey(factory.BobAddrV, 1, 0, 0), []byte{1}))
+	_, err = db.GetERC20Transfers(factory.BobAddrV, 0, 10)
+	assert.Error(t, err)
+}
+
 func TestReadTransaction(t *testing.T) {
 	db := newChainDB()
 	b := createBlock(22)
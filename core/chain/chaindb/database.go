@@ -62,6 +62,14 @@ type Database interface {
 	SaveTxLookupEntries(block model.AbstractBlock)
 	DeleteTxLookupEntry(block model.AbstractBlock)
 
+	SaveAddressTxEntries(block model.AbstractBlock) error
+	DeleteAddressTxEntries(block model.AbstractBlock) error
+	GetAddressTxEntries(address common.Address, offset, limit uint64) ([]AddressTxEntry, error)
+
+	SaveERC20Transfers(block model.AbstractBlock, receipts model2.Receipts) error
+	DeleteERC20Transfers(block model.AbstractBlock, receipts model2.Receipts) error
+	GetERC20Transfers(address common.Address, offset, limit uint64) ([]ERC20TransferEntry, error)
+
 	GetTransaction(txHash common.Hash) (model.AbstractTransaction, common.Hash, uint64, uint64)
 
 	InsertBlock(block model.AbstractBlock) error
@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/ethereum/go-ethereum/metrics"
+	"math/big"
 )
 
 // The fields below define the low level database schema prefixing.
@@ -51,6 +52,9 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	addressTxPrefix     = []byte("a") // addressTxPrefix + address + ^num (uint64 big endian) + ^index (uint64 big endian) -> address tx entry
+	erc20TransferPrefix = []byte("T") // erc20TransferPrefix + address + ^num + ^tx index + ^log index (uint64 big endian) -> erc20 transfer entry
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
@@ -71,6 +75,50 @@ type TxLookupEntry struct {
 	Index      uint64
 }
 
+// AddressTxEntry is a transaction sent or received by an address, the entries of an address
+// are ordered from the latest one.
+type AddressTxEntry struct {
+	TxHash      common.Hash
+	BlockNumber uint64
+	Index       uint64
+	Sent        bool
+	Received    bool
+}
+
+// the directions of the tx saved with the address tx entry
+const (
+	addressTxSent     uint8 = 1
+	addressTxReceived uint8 = 2
+)
+
+// addressTxStorage is the value of the address tx entry, the position of the tx is kept by the key
+type addressTxStorage struct {
+	TxHash common.Hash
+	Flags  uint8
+}
+
+// ERC20TransferEntry is a transfer of a built-in erc20 token sent or received by an address, the entries
+// of an address are ordered from the latest one. The token creation is a transfer from the empty address.
+type ERC20TransferEntry struct {
+	Token       common.Address
+	TxHash      common.Hash
+	BlockNumber uint64
+	TxIndex     uint64
+	LogIndex    uint64
+	From        common.Address
+	To          common.Address
+	Value       *big.Int
+}
+
+// erc20TransferStorage is the value of the erc20 transfer entry, the position of the log is kept by the key
+type erc20TransferStorage struct {
+	Token  common.Address
+	TxHash common.Hash
+	From   common.Address
+	To     common.Address
+	Value  *big.Int
+}
+
 // encodeBlockNumber encodes a block number as big endian uint64
 func encodeBlockNumber(number uint64) []byte {
 	enc := make([]byte, 8)
@@ -108,6 +156,18 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// erc20TransferKey = erc20TransferPrefix + address + ^num + ^tx index + ^log index (uint64 big endian),
+// the position is inverted to iterate the transfers of the address from the latest one
+func erc20TransferKey(address common.Address, number, txIndex, logIndex uint64) []byte {
+	key := make([]byte, len(erc20TransferPrefix)+common.AddressLength+24)
+	copy(key, erc20TransferPrefix)
+	copy(key[len(erc20TransferPrefix):], address.Bytes())
+	binary.BigEndian.PutUint64(key[len(key)-24:], ^number)
+	binary.BigEndian.PutUint64(key[len(key)-16:], ^txIndex)
+	binary.BigEndian.PutUint64(key[len(key)-8:], ^logIndex)
+	return key
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
@@ -123,6 +183,17 @@ func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
 }
 
+// addressTxKey = addressTxPrefix + address + ^num (uint64 big endian) + ^index (uint64 big endian),
+// the position is inverted to iterate the txs of the address from the latest one
+func addressTxKey(address common.Address, number, index uint64) []byte {
+	key := make([]byte, len(addressTxPrefix)+common.AddressLength+16)
+	copy(key, addressTxPrefix)
+	copy(key[len(addressTxPrefix):], address.Bytes())
+	binary.BigEndian.PutUint64(key[len(key)-16:], ^number)
+	binary.BigEndian.PutUint64(key[len(key)-8:], ^index)
+	return key
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 /*func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
@@ -137,7 +137,7 @@ func (batch fakeBatch) Put(key []byte, value []byte) error {
 }
 
 func (batch fakeBatch) Delete(key []byte) error {
-	panic("implement me")
+	return batch.err
 }
 
 func (batch fakeBatch) ValueSize() int {
@@ -302,6 +302,10 @@ func (body fakeBody) GetVerifications() []model.AbstractVerification {
 	panic("implement me")
 }
 
+func (body fakeBody) GetAggregateCommit() *model.AggregateCommit {
+	panic("implement me")
+}
+
 func (body fakeBody) SetReceiptHash(receiptHash common.Hash) {
 	panic("implement me")
 }
@@ -0,0 +1,420 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain
+
+import (
+	"encoding/json"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/consts"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/chain/registerdb"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/contract"
+	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/p2p/enode"
+	"github.com/pelletier/go-toml"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GenesisSpecFileName is the genesis spec written to the data dir by the init command
+const GenesisSpecFileName = "genesis.json"
+
+const (
+	genesisTimeLayout       = "2006-01-02 15:04:05"
+	defaultGenesisTimestamp = "2018-08-08 08:08:08"
+	defaultGenesisExtraData = "dipperin Genesis"
+)
+
+// the applied genesis spec, DefaultGenesisBlock returns its genesis instead of the genesis of the boot env
+var genesisSpec *GenesisSpec
+
+// GenesisSpec is the genesis file to run a private network. The zero fields of the chain config and the bft
+// timeouts keep the values of the boot env, so does the pre-mining proportion of the economy model if it isn't set.
+type GenesisSpec struct {
+	Config GenesisChainConfig `json:"config"`
+	Bft    GenesisBftConfig   `json:"bft"`
+
+	Nonce uint64 `json:"nonce"`
+	// the time of the genesis block, e.g. "2018-08-08 08:08:08"
+	Timestamp  string `json:"timestamp"`
+	ExtraData  string `json:"extraData"`
+	GasLimit   uint64 `json:"gasLimit"`
+	Difficulty string `json:"difficulty"`
+
+	// the verifiers of the first slots
+	Verifiers []common.Address `json:"verifiers"`
+	// the addresses and the enode urls of the verifier boot nodes
+	VerifierBootAddresses []common.Address `json:"verifierBootAddresses"`
+	VerifierBootNodes     []string         `json:"verifierBootNodes"`
+	// the enode urls of the k bucket boot nodes
+	BootNodes []string `json:"bootNodes"`
+
+	// the initial balances of the addresses in DIP
+	Alloc     map[string]uint64                   `json:"alloc"`
+	Economy   *economy_model.AddressDIPProportion `json:"economy"`
+	Contracts []GenesisContract                   `json:"contracts"`
+}
+
+// GenesisChainConfig overrides the chain config of the boot env, the verifier number is the number of the
+// genesis verifiers if it's 0.
+type GenesisChainConfig struct {
+	ChainId              uint64          `json:"chainId"`
+	NetworkID            uint64          `json:"networkId"`
+	SlotSize             uint64          `json:"slotSize"`
+	StakeLockSlot        uint64          `json:"stakeLockSlot"`
+	SlotMargin           uint64          `json:"slotMargin"`
+	VerifierNumber       int             `json:"verifierNumber"`
+	BlockGenerate        uint64          `json:"blockGenerate"`
+	BlockCountOfPeriod   uint64          `json:"blockCountOfPeriod"`
+	DiffAlgorithm        string          `json:"diffAlgorithm,omitempty"`
+	LWMAWindow           uint64          `json:"lwmaWindow,omitempty"`
+	BlockTimeRestriction GenesisDuration `json:"blockTimeRestriction"`
+	RollBackNum          uint64          `json:"rollBackNum"`
+
+	// the hard fork heights, the forks aren't scheduled if they are absent
+	EarthBlock    *uint64 `json:"earthBlock,omitempty"`
+	MarsBlock     *uint64 `json:"marsBlock,omitempty"`
+	JupiterBlock  *uint64 `json:"jupiterBlock,omitempty"`
+	SaturnBlock   *uint64 `json:"saturnBlock,omitempty"`
+	UranusBlock   *uint64 `json:"uranusBlock,omitempty"`
+	NeptuneBlock  *uint64 `json:"neptuneBlock,omitempty"`
+	PlutoBlock    *uint64 `json:"plutoBlock,omitempty"`
+	CeresBlock    *uint64 `json:"ceresBlock,omitempty"`
+	ErisBlock     *uint64 `json:"erisBlock,omitempty"`
+	HaumeaBlock   *uint64 `json:"haumeaBlock,omitempty"`
+	MakemakeBlock *uint64 `json:"makemakeBlock,omitempty"`
+}
+
+// GenesisBftConfig overrides the timeouts of the bft state machine
+type GenesisBftConfig struct {
+	WaitNewRound       GenesisDuration `json:"waitNewRound"`
+	WaitProposeTimeout GenesisDuration `json:"waitProposeTimeout"`
+	ProposalTimeout    GenesisDuration `json:"proposalTimeout"`
+	PreVoteTimeout     GenesisDuration `json:"preVoteTimeout"`
+	PreCommitTimeout   GenesisDuration `json:"preCommitTimeout"`
+}
+
+// GenesisContract is a WASM contract deployed by the genesis, the storage is the initial data of the contract
+type GenesisContract struct {
+	Address common.Address           `json:"address"`
+	Code    hexutil.Bytes            `json:"code"`
+	Abi     json.RawMessage          `json:"abi"`
+	Storage map[string]hexutil.Bytes `json:"storage"`
+}
+
+// GenesisDuration is a duration written as a string in the genesis spec, e.g. "8s"
+type GenesisDuration time.Duration
+
+func (d GenesisDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *GenesisDuration) UnmarshalJSON(input []byte) error {
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = GenesisDuration(v)
+	return nil
+}
+
+// LoadGenesisSpec reads the genesis spec file, which is parsed as toml if the extension is .toml, or as json.
+func LoadGenesisSpec(path string) (*GenesisSpec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// the toml file is converted to json, so both formats are decoded by the json tags
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		tree, err := toml.LoadBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = json.Marshal(tree.ToMap()); err != nil {
+			return nil, err
+		}
+	}
+
+	spec := &GenesisSpec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+	if err := spec.Valid(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// ReadGenesisSpec reads the genesis spec written by the init command, it returns nil if the data dir hasn't the spec
+func ReadGenesisSpec(dataDir string) (*GenesisSpec, error) {
+	path := filepath.Join(dataDir, GenesisSpecFileName)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return LoadGenesisSpec(path)
+}
+
+// Write saves the spec to the data dir as json
+func (s *GenesisSpec) Write(dataDir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dataDir, GenesisSpecFileName), data, 0644)
+}
+
+// Hash is the hash of the json encoding of the spec, it's used to compare the specs
+func (s *GenesisSpec) Hash() common.Hash {
+	data, err := json.Marshal(s)
+	if err != nil {
+		panic("encode genesis spec failed: " + err.Error())
+	}
+	return cs_crypto.Keccak256Hash(data)
+}
+
+func (s *GenesisSpec) Valid() error {
+	if s.Config.ChainId == 0 || s.Config.NetworkID == 0 {
+		return g_error.ErrGenesisInvalidChainId
+	}
+	if len(s.Verifiers) == 0 || (s.Config.VerifierNumber != 0 && s.Config.VerifierNumber != len(s.Verifiers)) {
+		return g_error.ErrGenesisVerifierNumber
+	}
+	if _, err := s.timestamp(); err != nil {
+		return err
+	}
+	if s.Config.DiffAlgorithm != "" && s.Config.DiffAlgorithm != chain_config.DiffAlgorithmPeriod && s.Config.DiffAlgorithm != chain_config.DiffAlgorithmLWMA {
+		return g_error.ErrGenesisDiffAlgorithm
+	}
+	if _, err := parseGenesisNodes(s.VerifierBootNodes); err != nil {
+		return err
+	}
+	if _, err := parseGenesisNodes(s.BootNodes); err != nil {
+		return err
+	}
+
+	alloc, err := s.alloc()
+	if err != nil {
+		return err
+	}
+	// the pre-mining balances of the economy model are merged into the alloc
+	proportion := economy_model.DIPProportion
+	if s.Economy != nil {
+		if s.Economy.BaseNumber == 0 {
+			return g_error.ErrGenesisInvalidProportion
+		}
+		proportion = *s.Economy
+	}
+	groups := []map[string]int{proportion.InvestorProportion, proportion.DeveloperProportion, proportion.MaintenanceProportion,
+		proportion.EarlyTokenProportion, proportion.ReMainRewardProportion}
+	for _, group := range groups {
+		for address := range group {
+			if _, ok := alloc[common.HexToAddress(address)]; ok {
+				return g_error.ErrGenesisAllocConflict
+			}
+		}
+	}
+
+	for _, c := range s.Contracts {
+		if len(c.Code) == 0 || !json.Valid(c.Abi) {
+			return g_error.ErrGenesisInvalidContract
+		}
+	}
+	return nil
+}
+
+// ChainConfig returns the chain config of the boot env overridden by the spec
+func (s *GenesisSpec) ChainConfig() *chain_config.ChainConfig {
+	conf := chain_config.DefaultChainConfig()
+	conf.ChainId = new(big.Int).SetUint64(s.Config.ChainId)
+	conf.NetworkID = s.Config.NetworkID
+	conf.VerifierNumber = len(s.Verifiers)
+	if len(s.VerifierBootAddresses) > 0 {
+		conf.VerifierBootNodeNumber = len(s.VerifierBootAddresses)
+	}
+
+	if s.Config.SlotSize != 0 {
+		conf.SlotSize = s.Config.SlotSize
+	}
+	if s.Config.StakeLockSlot != 0 {
+		conf.StakeLockSlot = s.Config.StakeLockSlot
+	}
+	if s.Config.SlotMargin != 0 {
+		conf.SlotMargin = s.Config.SlotMargin
+	}
+	if s.Config.BlockGenerate != 0 {
+		conf.BlockGenerate = s.Config.BlockGenerate
+	}
+	if s.Config.BlockCountOfPeriod != 0 {
+		conf.BlockCountOfPeriod = s.Config.BlockCountOfPeriod
+	}
+	if s.Config.DiffAlgorithm != "" {
+		conf.DiffAlgorithm = s.Config.DiffAlgorithm
+	}
+	if s.Config.LWMAWindow != 0 {
+		conf.LWMAWindow = s.Config.LWMAWindow
+	}
+	if s.Config.BlockTimeRestriction != 0 {
+		conf.BlockTimeRestriction = time.Duration(s.Config.BlockTimeRestriction)
+	}
+	if s.Config.RollBackNum != 0 {
+		conf.RollBackNum = s.Config.RollBackNum
+	}
+	if s.Config.EarthBlock != nil {
+		conf.EarthBlock = new(big.Int).SetUint64(*s.Config.EarthBlock)
+	}
+	if s.Config.MarsBlock != nil {
+		conf.MarsBlock = new(big.Int).SetUint64(*s.Config.MarsBlock)
+	}
+	if s.Config.JupiterBlock != nil {
+		conf.JupiterBlock = new(big.Int).SetUint64(*s.Config.JupiterBlock)
+	}
+	if s.Config.SaturnBlock != nil {
+		conf.SaturnBlock = new(big.Int).SetUint64(*s.Config.SaturnBlock)
+	}
+	if s.Config.UranusBlock != nil {
+		conf.UranusBlock = new(big.Int).SetUint64(*s.Config.UranusBlock)
+	}
+	if s.Config.NeptuneBlock != nil {
+		conf.NeptuneBlock = new(big.Int).SetUint64(*s.Config.NeptuneBlock)
+	}
+	if s.Config.PlutoBlock != nil {
+		conf.PlutoBlock = new(big.Int).SetUint64(*s.Config.PlutoBlock)
+	}
+	if s.Config.CeresBlock != nil {
+		conf.CeresBlock = new(big.Int).SetUint64(*s.Config.CeresBlock)
+	}
+	if s.Config.ErisBlock != nil {
+		conf.ErisBlock = new(big.Int).SetUint64(*s.Config.ErisBlock)
+	}
+	if s.Config.HaumeaBlock != nil {
+		conf.HaumeaBlock = new(big.Int).SetUint64(*s.Config.HaumeaBlock)
+	}
+	if s.Config.MakemakeBlock != nil {
+		conf.MakemakeBlock = new(big.Int).SetUint64(*s.Config.MakemakeBlock)
+	}
+	return conf
+}
+
+// Apply replaces the chain config, the default verifiers, the boot nodes and the pre-mining proportion of the
+// boot env by the spec. It must be called before the chain is opened.
+func (s *GenesisSpec) Apply() error {
+	if err := s.Valid(); err != nil {
+		return err
+	}
+	vBoots, _ := parseGenesisNodes(s.VerifierBootNodes)
+	kBoots, _ := parseGenesisNodes(s.BootNodes)
+
+	conf := s.ChainConfig()
+	chain_config.SetChainConfig(conf)
+	VerifierAddress = s.Verifiers
+	if len(s.VerifierBootAddresses) > 0 {
+		chain_config.VerBootNodeAddress = s.VerifierBootAddresses
+	}
+	if len(vBoots) > 0 || len(kBoots) > 0 {
+		chain_config.SetBootNodes(vBoots, kBoots)
+	}
+
+	// the owner of the early reward contract is changed with the proportion
+	if s.Economy != nil {
+		economy_model.SetDIPProportion(*s.Economy)
+		contract.InitEarlyRewardContract()
+	}
+
+	genesisSpec = s
+	log.Info("apply the genesis spec", "chainId", conf.ChainId, "networkId", conf.NetworkID, "verifiers", conf.VerifierNumber)
+	return nil
+}
+
+// ToGenesis makes the genesis of the spec which is applied
+func (s *GenesisSpec) ToGenesis(chainDB chaindb.Database, accountStateProcessor state_processor.AccountStateProcessor, registerProcessor registerdb.RegisterProcessor) *Genesis {
+	gTime, _ := s.timestamp()
+	alloc, _ := s.alloc()
+
+	genesis := &Genesis{
+		ChainDB:               chainDB,
+		AccountStateProcessor: accountStateProcessor,
+		RegisterProcessor:     registerProcessor,
+		Config:                chain_config.GetChainConfig(),
+		Nonce:                 s.Nonce,
+		Timestamp:             big.NewInt(gTime.UnixNano()),
+		ExtraData:             []byte(s.ExtraData),
+		GasLimit:              s.GasLimit,
+		Alloc:                 alloc,
+		Verifiers:             s.Verifiers,
+		Contracts:             s.Contracts,
+	}
+	if s.ExtraData == "" {
+		genesis.ExtraData = []byte(defaultGenesisExtraData)
+	}
+	if s.GasLimit == 0 {
+		genesis.GasLimit = chain_config.BlockGasLimit
+	}
+	if s.Difficulty != "" {
+		genesis.Difficulty = common.HexToDiff(s.Difficulty)
+	}
+	return genesis
+}
+
+func (s *GenesisSpec) timestamp() (time.Time, error) {
+	if s.Timestamp == "" {
+		return time.Parse(genesisTimeLayout, defaultGenesisTimestamp)
+	}
+	return time.Parse(genesisTimeLayout, s.Timestamp)
+}
+
+// alloc converts the DIP balances to the balances of the addresses
+func (s *GenesisSpec) alloc() (GenesisAlloc, error) {
+	alloc := make(GenesisAlloc, len(s.Alloc))
+	for address, balance := range s.Alloc {
+		b, err := hexutil.Decode(address)
+		if err != nil || len(b) != common.AddressLength {
+			return nil, g_error.ErrGenesisInvalidAddress
+		}
+		alloc[common.BytesToAddress(b)] = new(big.Int).Mul(new(big.Int).SetUint64(balance), big.NewInt(consts.DIP))
+	}
+	return alloc, nil
+}
+
+func parseGenesisNodes(urls []string) ([]*enode.Node, error) {
+	nodes := make([]*enode.Node, 0, len(urls))
+	for _, url := range urls {
+		n, err := enode.ParseV4(url)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
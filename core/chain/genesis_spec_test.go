@@ -0,0 +1,268 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain
+
+import (
+	"encoding/json"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/consts"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/chain/registerdb"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	specAllocAddress    = common.HexToAddress("0x00001111111111111111111111111111111111111111")
+	specContractAddress = common.HexToAddress("0x00122222222222222222222222222222222222222222")
+)
+
+const testGenesisSpecToml = `
+timestamp = "2019-09-09 09:09:09"
+verifiers = ["0x0000970e8128aB834E8EAC17aB8E3812f010678CF791"]
+
+[config]
+chainId = 1600
+networkId = 1601
+slotSize = 20
+blockTimeRestriction = "5s"
+
+[bft]
+proposalTimeout = "3s"
+
+[alloc]
+"0x00001111111111111111111111111111111111111111" = 100
+`
+
+func createGenesisSpec() *GenesisSpec {
+	return &GenesisSpec{
+		Config: GenesisChainConfig{
+			ChainId:   1600,
+			NetworkID: 1601,
+			SlotSize:  20,
+		},
+		Verifiers: []common.Address{common.HexToAddress("0x0000970e8128aB834E8EAC17aB8E3812f010678CF791")},
+		Alloc:     map[string]uint64{specAllocAddress.Hex(): 100},
+		Contracts: []GenesisContract{{
+			Address: specContractAddress,
+			Code:    []byte{0, 97, 115, 109},
+			Abi:     json.RawMessage(`[{"name": "init", "type": "function"}]`),
+			Storage: map[string]hexutil.Bytes{"owner": specAllocAddress.Bytes()},
+		}},
+	}
+}
+
+// resetGenesisSpec restores the globals changed by Apply
+func resetGenesisSpec() func() {
+	conf := *chain_config.GetChainConfig()
+	verifiers := VerifierAddress
+	return func() {
+		chain_config.SetChainConfig(&conf)
+		VerifierAddress = verifiers
+		genesisSpec = nil
+	}
+}
+
+func TestLoadGenesisSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis_spec")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tomlPath := filepath.Join(dir, "genesis.toml")
+	assert.NoError(t, ioutil.WriteFile(tomlPath, []byte(testGenesisSpecToml), 0644))
+	spec, err := LoadGenesisSpec(tomlPath)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1600), spec.Config.ChainId)
+	assert.Equal(t, GenesisDuration(5*time.Second), spec.Config.BlockTimeRestriction)
+	assert.Equal(t, GenesisDuration(3*time.Second), spec.Bft.ProposalTimeout)
+	assert.Equal(t, uint64(100), spec.Alloc[specAllocAddress.Hex()])
+
+	// the spec written as json is read back
+	stored, err := ReadGenesisSpec(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+	assert.NoError(t, spec.Write(dir))
+	stored, err = ReadGenesisSpec(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, spec.Hash(), stored.Hash())
+
+	_, err = LoadGenesisSpec(filepath.Join(dir, "none.json"))
+	assert.Error(t, err)
+}
+
+func TestGenesisSpec_Valid(t *testing.T) {
+	assert.NoError(t, createGenesisSpec().Valid())
+
+	testCases := []struct {
+		name   string
+		change func(spec *GenesisSpec)
+		err    error
+	}{
+		{"no chain id", func(spec *GenesisSpec) { spec.Config.ChainId = 0 }, g_error.ErrGenesisInvalidChainId},
+		{"no verifiers", func(spec *GenesisSpec) { spec.Verifiers = nil }, g_error.ErrGenesisVerifierNumber},
+		{"verifier number", func(spec *GenesisSpec) { spec.Config.VerifierNumber = 2 }, g_error.ErrGenesisVerifierNumber},
+		{"diff algorithm", func(spec *GenesisSpec) { spec.Config.DiffAlgorithm = "asert" }, g_error.ErrGenesisDiffAlgorithm},
+		{"alloc address", func(spec *GenesisSpec) { spec.Alloc["0x1234"] = 1 }, g_error.ErrGenesisInvalidAddress},
+		{"alloc conflict", func(spec *GenesisSpec) {
+			for address := range economy_model.DIPProportion.InvestorProportion {
+				spec.Alloc[common.HexToAddress(address).Hex()] = 1
+			}
+		}, g_error.ErrGenesisAllocConflict},
+		{"contract code", func(spec *GenesisSpec) { spec.Contracts[0].Code = nil }, g_error.ErrGenesisInvalidContract},
+		{"contract abi", func(spec *GenesisSpec) { spec.Contracts[0].Abi = json.RawMessage("[") }, g_error.ErrGenesisInvalidContract},
+	}
+	for _, tc := range testCases {
+		spec := createGenesisSpec()
+		tc.change(spec)
+		assert.Equal(t, tc.err, spec.Valid(), tc.name)
+	}
+
+	spec := createGenesisSpec()
+	spec.Timestamp = "2019-09-09"
+	assert.Error(t, spec.Valid())
+	spec = createGenesisSpec()
+	spec.BootNodes = []string{"enode://invalid"}
+	assert.Error(t, spec.Valid())
+}
+
+func TestGenesisSpec_ChainConfig(t *testing.T) {
+	spec := createGenesisSpec()
+	spec.Config.RollBackNum = 3
+	conf := spec.ChainConfig()
+	assert.Equal(t, big.NewInt(1600), conf.ChainId)
+	assert.Equal(t, uint64(1601), conf.NetworkID)
+	assert.Equal(t, uint64(20), conf.SlotSize)
+	assert.Equal(t, uint64(3), conf.RollBackNum)
+	assert.Equal(t, 1, conf.VerifierNumber)
+
+	// the zero fields keep the boot env
+	assert.Equal(t, chain_config.GetChainConfig().StakeLockSlot, conf.StakeLockSlot)
+	assert.Equal(t, chain_config.GetChainConfig().BlockTimeRestriction, conf.BlockTimeRestriction)
+	assert.Nil(t, conf.EarthBlock)
+
+	earth := uint64(0)
+	spec.Config.EarthBlock = &earth
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsEarth(0))
+	assert.False(t, conf.IsMars(10000))
+	assert.False(t, conf.IsJupiter(10000))
+
+	jupiter := uint64(100)
+	spec.Config.JupiterBlock = &jupiter
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsJupiter(100))
+	assert.False(t, conf.IsSaturn(100))
+
+	saturn := uint64(200)
+	spec.Config.SaturnBlock = &saturn
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsSaturn(200))
+	assert.False(t, conf.IsUranus(200))
+
+	uranus := uint64(300)
+	spec.Config.UranusBlock = &uranus
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsUranus(300))
+	assert.False(t, conf.IsNeptune(300))
+	assert.Equal(t, chain_config.DiffAlgorithmLWMA, conf.DiffAlgorithm)
+
+	neptune := uint64(400)
+	spec.Config.NeptuneBlock = &neptune
+	spec.Config.DiffAlgorithm = chain_config.DiffAlgorithmPeriod
+	spec.Config.LWMAWindow = 90
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsNeptune(400))
+	assert.Equal(t, chain_config.DiffAlgorithmPeriod, conf.DiffAlgorithm)
+	assert.Equal(t, uint64(90), conf.LWMAWindow)
+	assert.False(t, conf.IsPluto(400))
+
+	pluto := uint64(500)
+	spec.Config.PlutoBlock = &pluto
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsPluto(500))
+	assert.False(t, conf.IsCeres(500))
+
+	ceres := uint64(600)
+	spec.Config.CeresBlock = &ceres
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsCeres(600))
+	assert.False(t, conf.IsEris(600))
+
+	eris := uint64(700)
+	spec.Config.ErisBlock = &eris
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsEris(700))
+	assert.False(t, conf.IsHaumea(700))
+
+	haumea := uint64(800)
+	spec.Config.HaumeaBlock = &haumea
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsHaumea(800))
+	assert.False(t, conf.IsMakemake(800))
+
+	makemake := uint64(900)
+	spec.Config.MakemakeBlock = &makemake
+	conf = spec.ChainConfig()
+	assert.True(t, conf.IsMakemake(900))
+}
+
+func TestGenesisSpec_Apply(t *testing.T) {
+	defer resetGenesisSpec()()
+
+	spec := createGenesisSpec()
+	assert.NoError(t, spec.Apply())
+	assert.Equal(t, big.NewInt(1600), chain_config.GetChainConfig().ChainId)
+	assert.Equal(t, spec.Verifiers, VerifierAddress)
+
+	db := ethdb.NewMemDatabase()
+	storage := state_processor.NewStateStorageWithCache(db)
+	stateProcessor, _ := state_processor.MakeGenesisAccountStateProcessor(storage)
+	registerProcessor, _ := registerdb.MakeGenesisRegisterProcessor(storage)
+	chainDB := chaindb.NewChainDB(db, model.MakeDefaultBlockDecoder())
+
+	genesis := DefaultGenesisBlock(chainDB, stateProcessor, registerProcessor, chain_config.GetChainConfig())
+	assert.Equal(t, []byte(defaultGenesisExtraData), genesis.ExtraData)
+	assert.Equal(t, big.NewInt(0).Mul(big.NewInt(100), big.NewInt(consts.DIP)), genesis.Alloc[specAllocAddress])
+
+	block, err := genesis.Prepare()
+	assert.NoError(t, err)
+	assert.NotNil(t, block)
+	state := genesis.AccountStateProcessor.(*state_processor.AccountStateDB)
+	code, err := state.GetCode(specContractAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(spec.Contracts[0].Code), code)
+	abi, err := state.GetAbi(specContractAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"name":"init","type":"function"}]`, string(abi))
+	assert.Equal(t, specAllocAddress.Bytes(), state.GetData(specContractAddress, "owner"))
+
+	// the invalid spec isn't applied
+	spec.Verifiers = nil
+	assert.Equal(t, g_error.ErrGenesisVerifierNumber, spec.Apply())
+}
@@ -16,867 +16,360 @@
 
 package chain
 
-//import (
-//	"errors"
-//	"fmt"
-//	"github.com/dipperin/dipperin-core/common"
-//	"github.com/dipperin/dipperin-core/core/chain/state-processor"
-//	"github.com/dipperin/dipperin-core/core/model"
-//	"github.com/dipperin/dipperin-core/third-party/log"
-//	"github.com/ethereum/go-ethereum/rlp"
-//	"math/big"
-//	"sort"
-//)
-//
-//type PredicateFunc func(LightProofs) bool
-//type Proofs []Proof
-//
-//type LightProof struct {
-//	Header model.AbstractHeader
-//	Link   model.InterLink
-//}
-//
-//type LightProofs []*LightProof
-//type Headers []model.AbstractHeader
-//
-//// TODO: modified when deployed
-//var reader = NewFakeFullChain()
-//var TestGenesis = reader.Genesis()
-//var minDiff = common.HexToDiff("0x20ffffff")
-//
-//type Proof struct {
-//	Prefix LightProofs
-//	Suffix LightProofs
-//	PreMap HeadersMap
-//	SufMap HeadersMap
-//}
-//
-//func NewLightProof(h model.AbstractHeader, l model.InterLink) *LightProof {
-//	return &LightProof{
-//		Header: h,
-//		Link:   l,
-//	}
-//}
-//
-//// validlightproof tries to verify the given header, interlinks are indeed valid
-//// by checking whether the derived root is equal to the header root
-//func (l LightProof) ValidLightProof() bool {
-//	if root := model.DeriveSha(l.Link); !root.IsEqual(l.Header.GetInterLinkRoot()) {
-//		return false
-//	}
-//	return true
-//}
-//
-//func (l LightProof) Hash() common.Hash {
-//	return l.Header.Hash()
-//}
-//
-//func (l LightProof) GetNumber() uint64 {
-//	return l.Header.GetNumber()
-//}
-//
-//func (p *Proof) InsertPrefix(h model.AbstractHeader, l model.InterLink) {
-//	if !p.PreMap.Insert(h) {
-//		p.Prefix = append(p.Prefix, NewLightProof(h, l))
-//	}
-//}
-//
-//func (p *Proof) InsertBatchPrefix(headers LightProofs) {
-//	for _, h := range headers {
-//		p.InsertPrefix(h.Header, h.Link)
-//	}
-//}
-//
-//func (p *Proof) InsertSuffix(h model.AbstractHeader, l model.InterLink) {
-//	if !p.SufMap.Insert(h) {
-//		p.Suffix = append(p.Suffix, NewLightProof(h, l))
-//	}
-//}
-//
-//func (p *Proof) InsertBatchSuffix(headers LightProofs) {
-//	for _, h := range headers {
-//		p.InsertSuffix(h.Header, h.Link)
-//	}
-//}
-//
-//func (p *Proof) Sort() {
-//	sort.Sort(p.Suffix)
-//	sort.Sort(p.Prefix)
-//}
-//
-//func validProof(headers LightProofs) bool {
-//	for i, header := range headers {
-//		if i == 0 {
-//			continue
-//		}
-//
-//		//linkRoot := header.GetInterLinkRoot()
-//		links := header.Link
-//
-//		found := false
-//
-//		preHeader := headers[i-1]
-//
-//		for _, h := range links {
-//			if !found {
-//				found = h.IsEqual(preHeader.Hash())
-//			}
-//		}
-//
-//		if !found {
-//			return false
-//		}
-//	}
-//
-//	return true
-//}
-//
-////todo How to ensure that this verification can be passed when proof is generated normally?
-//func (p *Proof) Valid(fullchain Chain) (err error) {
-//	if !validProof(p.Prefix) {
-//		for _, h := range p.Prefix {
-//			fmt.Println(h.Hash(), h.GetNumber())
-//			fmt.Println(h.Link)
-//		}
-//		log.Error("interlink prefix invalid")
-//		err = errors.New("interlink prefix invalid")
-//		return
-//	}
-//
-//	if !validProof(p.Suffix) {
-//		log.Error("interlink suffix invalid")
-//		err = errors.New("interlink suffix invalid")
-//		return
-//	}
-//
-//	if !validProof(append(p.Prefix, p.Suffix...)) {
-//		log.Error("interlink proof invalid")
-//		err = errors.New("interlink proof invalid")
-//		return
-//	}
-//
-//	return nil
-//}
-//
-//func NewProofs() *Proof {
-//	return &Proof{
-//		Prefix: make(LightProofs, 0),
-//		Suffix: make(LightProofs, 0),
-//		PreMap: *NewHeadersMap(),
-//		SufMap: *NewHeadersMap(),
-//	}
-//}
-//
-//type FakeFullChainInterlink struct {
-//	blocks []model.AbstractBlock
-//}
-//
-//func (f FakeFullChainInterlink) GetLastChangePoint(block model.AbstractBlock) *uint64 {
-//	panic("implement me")
-//}
-//
-//func (f FakeFullChainInterlink) GetSlot(block model.AbstractBlock) *uint64 {
-//	panic("implement me")
-//}
-//
-//func (f FakeFullChainInterlink) IsChangePoint(block model.AbstractBlock, isProcessPackageBlock bool) bool {
-//	panic("implement me")
-//}
-//
-//func (f FakeFullChainInterlink) GetSlotByNum(num uint64) *uint64 {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) InsertBlocks(blocks []model.AbstractBlock) error {
-//	panic("implement me")
-//}
-//
-//func (f FakeFullChainInterlink) Genesis() model.AbstractBlock {
-//	return f.blocks[0]
-//}
-//
-//func (f FakeFullChainInterlink) CurrentBlock() model.AbstractBlock {
-//	return f.blocks[len(f.blocks)-1]
-//}
-//
-//func (FakeFullChainInterlink) CurrentHeader() model.AbstractHeader {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetCurrVerifiers() []common.Address {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetVerifiers(round uint64) []common.Address {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetNextVerifiers() []common.Address {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) CurrentSeed() (common.Hash, uint64) {
-//	panic("implement me")
-//}
-//
-//func (f FakeFullChainInterlink) GetBlock(hash common.Hash, number uint64) model.AbstractBlock {
-//	n := f.GetBlockNumber(hash)
-//	if n != nil {
-//		return f.GetBlockByNumber(*n)
-//	}
-//	return nil
-//}
-//
-//func (f FakeFullChainInterlink) GetBlockByHash(hash common.Hash) model.AbstractBlock {
-//	for _, b := range f.blocks {
-//		if hash.IsEqual(b.Hash()) {
-//			return b
-//		}
-//	}
-//	return nil
-//}
-//
-//func (f FakeFullChainInterlink) GetLatestNormalBlock() model.AbstractBlock {
-//	return nil
-//}
-//
-//func (f FakeFullChainInterlink) GetBlockByNumber(number uint64) model.AbstractBlock {
-//	return f.blocks[number]
-//}
-//
-//func (FakeFullChainInterlink) HasBlock(hash common.Hash, number uint64) bool {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetBody(hash common.Hash) model.AbstractBody {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetBodyRLP(hash common.Hash) rlp.RawValue {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetHeader(hash common.Hash, number uint64) model.AbstractHeader {
-//	panic("implement me")
-//}
-//
-//func (f FakeFullChainInterlink) GetHeaderByHash(hash common.Hash) model.AbstractHeader {
-//	return f.GetBlockByHash(hash).Header()
-//}
-//
-//func (f FakeFullChainInterlink) GetHeaderByNumber(number uint64) model.AbstractHeader {
-//	if number > uint64(len(f.blocks)) {
-//		return nil
-//	}
-//	return f.blocks[number].Header()
-//}
-//
-//func (FakeFullChainInterlink) GetHeaderRLP(hash common.Hash) rlp.RawValue {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) HasHeader(hash common.Hash, number uint64) bool {
-//	panic("implement me")
-//}
-//
-//func (f FakeFullChainInterlink) GetBlockNumber(hash common.Hash) *uint64 {
-//	number := uint64(0)
-//	for _, h := range f.blocks {
-//		if hash.IsEqual(h.Hash()) {
-//			number = h.Number()
-//			return &number
-//		}
-//	}
-//
-//	return nil
-//}
-//
-//func (FakeFullChainInterlink) GetTransaction(txHash common.Hash) (model.AbstractTransaction, common.Hash, uint64, uint64) {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetStateStorage() state_processor.StateStorage {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) CurrentState() (*state_processor.AccountStateDB, error) {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) StateAtByBlockNumber(num uint64) (*state_processor.AccountStateDB, error) {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) StateAtByStateRoot(root common.Hash) (*state_processor.AccountStateDB, error) {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) TxMaxSize(block model.AbstractBlock) common.StorageSize {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) TxMinSize(block model.AbstractBlock) common.StorageSize {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) TxMaxAmount(block model.AbstractBlock) *big.Int {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) TxMinAmount(block model.AbstractBlock) *big.Int {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) ValidTx(tx model.AbstractTransaction) error {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) ValidateBlock(block model.AbstractBlock) error {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetSeenCommit(height uint64) []model.AbstractVerification {
-//	panic("implement me")
-//}
-//
-//func (FakeFullChainInterlink) GetVerifiersByBlock(height uint64) []common.Address {
-//	panic("implement me")
-//}
-//
-//func (f *FakeFullChainInterlink) SaveBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
-//	f.blocks = append(f.blocks, block)
-//	return nil
-//}
-//
-//func (FakeFullChainInterlink) LastNumberBySlot() uint64 {
-//	panic("implement me")
-//}
-//
-//func NewFakeFullChain() *FakeFullChainInterlink {
-//	header1 := model.NewHeader(1, 0, common.HexToHash("1111"), common.HexToHash("1111"), minDiff, big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
-//	block1 := model.NewBlock(header1, nil, nil)
-//	inter := common.HexToHash("")
-//	block1.SetInterLinks([]common.Hash{inter})
-//	block1.SetInterLinkRoot(model.DeriveSha(block1.GetInterlinks()))
-//
-//	f := &FakeFullChainInterlink{
-//		blocks: make([]model.AbstractBlock, 0),
-//	}
-//
-//	f.blocks = append(f.blocks, block1)
-//	return f
-//}
-//
-//func (bs LightProofs) Len() int {
-//	return len(bs)
-//}
-//
-//func (bs LightProofs) Less(i, j int) bool {
-//	return bs[i].GetNumber() < bs[j].GetNumber()
-//}
-//
-//func (bs LightProofs) Swap(i, j int) {
-//	bs[i], bs[j] = bs[j], bs[i]
-//}
-//
-//func NewHeadersMap() *HeadersMap {
-//	return &HeadersMap{
-//		headers: make(map[common.Hash]bool),
-//	}
-//}
-//
-//type HeadersMap struct {
-//	headers map[common.Hash]bool
-//}
-//
-//func (h *HeadersMap) Insert(header model.AbstractHeader) bool {
-//	if !h.In(header) {
-//		h.headers[header.Hash()] = true
-//		return false
-//	}
-//	return true
-//}
-//
-//func (h HeadersMap) In(header model.AbstractHeader) bool {
-//	_, ok := h.headers[header.Hash()]
-//	return ok
-//}
-//
-//// getHeaders implements the [A:Z], returns the sub-chain of the fullChain from
-//// A (inclusive) to Z (exclusive)
-//func getHeaders(fullchain Chain, start uint64, end uint64) (headers LightProofs) {
-//	for start < end {
-//		var block model.AbstractBlock
-//		block = fullchain.GetBlockByNumber(start)
-//		proof := NewLightProof(block.Header(), block.GetInterlinks())
-//
-//		if block == nil {
-//			return nil
-//		}
-//		headers = append(headers, proof)
-//		start++
-//	}
-//	return headers
-//}
-//
-//// AfterTarget implements [b:] operator that returns all the headers
-//// after and including b. Noted that, the parameter headers are assumed
-//// sorted, and the b is not necessarily in headers, but is in the underlying
-//// support block chain of headers.
-//func AfterTarget(headers LightProofs, b model.AbstractHeader) (res LightProofs) {
-//	left := 0
-//	right := headers.Len() - 1
-//	tar := b.GetNumber()
-//
-//	if len(headers) == 0 {
-//		return
-//	}
-//
-//	//sort.Sort(headers)
-//	var mid int
-//	for left <= right {
-//		mid = (left + right) / 2
-//		midNumber := headers[mid].GetNumber()
-//
-//		if midNumber < tar {
-//			left = mid + 1
-//		} else if midNumber > tar {
-//			right = mid - 1
-//		} else {
-//			res = make(LightProofs, len(headers[mid:]))
-//			copy(res, headers[mid:])
-//			return
-//		}
-//	}
-//
-//	res = make(LightProofs, len(headers[left:]))
-//	copy(res, headers[left:])
-//	return
-//}
-//
-//func GetSuffixProof(fullchain Chain, end uint64, m int, k uint64) (p *Proof, err error) {
-//	if end > fullchain.CurrentBlock().Number() {
-//		err = errors.New("blockchain is too short to get enough proof")
-//		return
-//	}
-//
-//	mu := len(fullchain.GetBlockByNumber(end - k).GetInterlinks())
-//	mu--
-//	//fmt.Println("end", end)
-//	//fmt.Println("k", k)
-//	//fmt.Println("end - k", end-k)
-//	//fmt.Println(fullchain.GetBlockByNumber(end - k).GetInterlinks())
-//	//fmt.Println("mu", mu)
-//
-//	genesis := fullchain.Genesis()
-//	header := NewLightProof(genesis.Header(), genesis.GetInterlinks())
-//	//todo: Why didn't the genesis block be added to the suffix proof here?
-//
-//	//todo: Whether it should be end+1-k, end+1 here (input end is the block number of the last block)
-//	suf := getHeaders(fullchain, end-k, end)
-//
-//	// using map to ensure the items are unique
-//	//p := NewHeadersMap()
-//	p = NewProofs()
-//	p.InsertBatchSuffix(suf)
-//	for index := mu; index >= 0; index-- {
-//		subChain := getHeaders(fullchain, header.GetNumber(), end-k)
-//		alpha := UpChain(subChain, index)
-//		p.InsertBatchPrefix(alpha)
-//
-//		if len(alpha) < m {
-//			continue
-//		}
-//		header = alpha[len(alpha)-m]
-//	}
-//	p.Sort()
-//	return p, nil
-//}
-//
-//func followDown(fullchain Chain, lo model.AbstractHeader, hi model.AbstractHeader) (auxiliary LightProofs) {
-//	header := hi
-//	mu := model.HashLevel(header.Hash(), header.GetDifficulty().DiffToTarget())
-//	//fmt.Println(lo.Hash())
-//	for !header.Hash().IsEqual(lo.Hash()) {
-//		link := fullchain.GetBlockByNumber(header.GetNumber()).GetInterlinks()
-//		//fmt.Println("mu", mu)
-//		//fmt.Println("len(link)", len(link))
-//		//fmt.Println("number", header.GetNumber(), header.Hash())
-//		//fmt.Println(link)
-//		if mu >= len(link) {
-//			mu = len(link) - 1
-//		}
-//		prime := fullchain.GetHeaderByHash(link[mu])
-//
-//		if prime.GetNumber() < lo.GetNumber() {
-//			mu--
-//		} else {
-//			auxiliary = append(auxiliary, NewLightProof(header, link))
-//			header = prime
-//		}
-//	}
-//	sort.Sort(LightProofs(auxiliary))
-//	return
-//}
-//
-//func getLevel(b model.AbstractHeader) int {
-//	return model.HashLevel(b.Hash(), b.GetDifficulty().DiffToTarget())
-//}
-//
-//// UpChain select the blocks that have more links than
-//// the given level, and forms a super chain
-//func UpChain(headers LightProofs, level int) (superChain LightProofs) {
-//	for _, block := range headers {
-//		if getLevel(block.Header) >= level {
-//			superChain = append(superChain, block)
-//		}
-//	}
-//	return superChain
-//}
-//
-//func BestArg(pre LightProofs, b model.AbstractHeader, m int) (arg int) {
-//	length := m
-//	subChain := AfterTarget(pre, b)
-//	//fmt.Println(pre)
-//	//fmt.Println(b)
-//	//fmt.Println(len(subChain))
-//	for mu := 0; length >= m; mu ++ {
-//		//fmt.Println("m:", m, "|upchain|:", length, "mu:", mu, "2^mu:", 1<<uint(mu))
-//		//fmt.Println("2^mu * length", length*(1<<uint(mu)))
-//		if length*(1<<uint(mu)) > arg {
-//			arg = length * (1 << uint(mu))
-//		}
-//
-//		subChain = UpChain(subChain, mu)
-//		length = len(subChain)
-//	}
-//	//fmt.Println("---------------------------------------------")
-//	//fmt.Println("best arg", arg)
-//	return
-//}
-//
-//// BlockGreater implements the $ >=_m $ operator defined in
-//// the NIPPOW paper Algorithm 4.
-//// We assume all the input LightProofs are sorted.
-//func BlockGreater(A LightProofs, B LightProofs, threshold int) bool {
-//	La := len(A) - 1
-//	Lb := len(B) - 1
-//
-//	var b model.AbstractHeader
-//
-//	for La >= 0 && Lb >= 0 {
-//		if A[La].GetNumber() == B[Lb].GetNumber() {
-//			if A[La].Hash().IsEqual(B[Lb].Hash()) {
-//				// Found greatest common ancestor
-//				b = A[La].Header
-//				break
-//			} else {
-//				// Equal number but hash is different, diverged here
-//				// continue to find common ancestor
-//				La--
-//				Lb--
-//			}
-//		} else if A[La].GetNumber() > B[Lb].GetNumber() {
-//			La--
-//		} else {
-//			Lb--
-//		}
-//	}
-//
-//	if b == nil {
-//		b = TestGenesis.Header()
-//	}
-//	//fmt.Println(commonBlocks[len(commonBlocks)-1])
-//	//fmt.Println(A)
-//	//fmt.Println(B)
-//	//fmt.Println(A[La])
-//	//fmt.Println(B[Lb])
-//	bestA := BestArg(A, b, threshold)
-//	bestB := BestArg(B, b, threshold)
-//	return bestA > bestB
-//}
-//
-//func GetInfixProof(fullchain Chain, endPoint uint64, threshold int, suffix uint64, target uint64) (p *Proof, err error) {
-//	p, err = GetSuffixProof(fullchain, endPoint, threshold, suffix)
-//	targetHeader := fullchain.GetHeaderByNumber(target)
-//	if err != nil {
-//		return
-//	}
-//	for _, header := range p.Prefix {
-//		if header.GetNumber() >= target {
-//			auxiliary := followDown(fullchain, targetHeader, header.Header)
-//			p.InsertBatchPrefix(auxiliary)
-//			break
-//		}
-//	}
-//
-//	p.Sort()
-//	return
-//}
-//
-//func GetGoodInfixProof(fullchain Chain, endPoint uint64, threshold int, suffix uint64, target uint64, delta float64) (p *Proof, err error) {
-//	p, err = GetGoodSuffixProof(fullchain, endPoint, threshold, suffix, delta)
-//	if err != nil {
-//		return
-//	}
-//	targetHeader := fullchain.GetHeaderByNumber(target)
-//	for _, header := range p.Prefix {
-//		if header.GetNumber() >= target {
-//			auxiliary := followDown(fullchain, targetHeader, header.Header)
-//			p.InsertBatchPrefix(auxiliary)
-//			break
-//		}
-//	}
-//
-//	p.Sort()
-//	return
-//}
-//
-//func GetGoodSuffixProof(fullchain Chain, end uint64, m int, k uint64, delta float64) (p *Proof, err error) {
-//	if end > fullchain.CurrentBlock().Number() {
-//		err = errors.New("blockchain is too short to get enough proof")
-//		return
-//	}
-//
-//	mu := len(fullchain.GetBlockByNumber(end - k).GetInterlinks())
-//	mu--
-//	//fmt.Println("end", end)
-//	//fmt.Println("k", k)
-//	//fmt.Println("end - k", end-k)
-//	//fmt.Println(fullchain.GetBlockByNumber(end - k).GetInterlinks())
-//	//fmt.Println("mu", mu)
-//	genesis := fullchain.Genesis()
-//	header := NewLightProof(genesis.Header(), genesis.GetInterlinks())
-//	suf := getHeaders(fullchain, end-k, end)
-//
-//	// using map to ensure the items are unique
-//	//p := NewHeadersMap()
-//	p = NewProofs()
-//	p.InsertBatchSuffix(suf)
-//	for index := mu; index >= 0; index-- {
-//		subChain := getHeaders(fullchain, header.GetNumber(), end-k)
-//		alpha := UpChain(subChain, index)
-//		p.InsertBatchPrefix(alpha)
-//
-//		if len(alpha) < m {
-//			continue
-//		}
-//
-//		under := GetUnderlyingHeaders(fullchain, alpha)
-//		if Good(alpha, under, m, index, delta) {
-//			header = alpha[len(alpha)-m]
-//		} else {
-//			log.Info("header hash is not good, using lower level")
-//		}
-//	}
-//	p.Sort()
-//	return p, nil
-//}
-//
-//// super is assumed sorted
-//func GetUnderlyingHeaders(reader Chain, super LightProofs) (under LightProofs) {
-//	if len(super) < 1 {
-//		return nil
-//	}
-//	start := super[0].GetNumber()
-//	end := super[len(super)-1].GetNumber()
-//
-//	under = make(LightProofs, end-start+1)
-//	for i := start; i <= end; i ++ {
-//		b := reader.GetBlockByNumber(i)
-//		under[i-start] = NewLightProof(b.Header(), b.GetInterlinks())
-//	}
-//
-//	return
-//}
-//
-//// we assume under contains all the elements in super
-//func DownChain(under, super LightProofs) (down LightProofs) {
-//	if len(under) < len(super) {
-//		return nil
-//	}
-//
-//	tail := super[len(super)-1].GetNumber()
-//	after := AfterTarget(under, super[0].Header)
-//	for _, h := range after {
-//		down = append(down, h)
-//		if h.GetNumber() > tail {
-//			break
-//		}
-//	}
-//
-//	return
-//}
-//
-//func LocalGood(super, under LightProofs, mu int, delta float64) bool {
-//	// TODO: we only need to compare the length
-//	// input argument be modified to use integer
-//	return len(super)*(1<<uint(mu)) > int((1-delta)*float64(len(under)))
-//}
-//
-//// chain is assumed to be the 0-chain
-//func SuperchainQuality(chain LightProofs, m int, mu int, delta float64) bool {
-//	mP := m
-//	superchain := UpChain(chain, mu)
-//	length := len(superchain)
-//
-//	for mP < length {
-//		super := superchain[(length - mP):]
-//		//Since the chain is ordered by blockNumber, use AfterTarget directly here.，
-//		// You don't have to perform underlying on the chain as written by paper, and you can't get chainReader here.
-//		under := AfterTarget(chain, super[0].Header)
-//		if !LocalGood(super, under, mu, delta) {
-//			return false
-//		}
-//		mP++
-//	}
-//
-//	return true
-//}
-//
-//func MultilevelQuality(super, under LightProofs, k1 int, mu int, delta float64) bool {
-//	muP := 0
-//	for muP < mu {
-//		//Here is not verified by any original set of under the original paper, directly use the under
-//		//The author believes that this problem can only be solved by special attacks described in paper, which can be restricted by chain consensus conditions.
-//		star := DownChain(under, super)
-//		star = UpChain(star, muP)
-//		length := len(UpChain(star, muP))
-//		if length >= k1 {
-//			//fmt.Println("|C* mu-up|*2^mu", len(UpChain(star, mu))*(1<<uint(mu)), "(1-delta)", delta, "mu'", muP)
-//			//fmt.Println("length*2^mu'", (1<<uint(muP))*length, "length", length)
-//			if len(UpChain(star, mu))*(1<<uint(mu)) < int((1-delta)*float64((1<<uint(muP))*length)) {
-//				return false
-//			}
-//		} else {
-//			// higher level assumed to has less block
-//			break
-//		}
-//		muP++
-//		//fmt.Println("---------------------------")
-//	}
-//	return true
-//}
-//
-//func Good(super, under LightProofs, m int, mu int, delta float64) bool {
-//	if !SuperchainQuality(under, m, mu, delta) {
-//		return false
-//	}
-//
-//	if !MultilevelQuality(super, under, m, mu, delta) {
-//		return false
-//	}
-//
-//	return true
-//}
-//
-//// VerifySuffix implements algorithm 2
-//func VerifySuffix(proofs Proofs, k uint64, m int, Q PredicateFunc) bool {
-//	prePrime := LightProofs{NewLightProof(TestGenesis.Header(), TestGenesis.GetInterlinks())}
-//	var sufPrime LightProofs
-//
-//	for _, p := range proofs {
-//		// if validChain && |suf| = k && pre >=_m prePrime
-//		if len(p.Suffix) == int(k) && BlockGreater(p.Prefix, prePrime, m) {
-//			prePrime = p.Prefix
-//			sufPrime = p.Suffix
-//		}
-//	}
-//
-//	return Q(sufPrime)
-//}
-//
-//type BlockByHash struct {
-//	M    map[common.Hash]*LightProof
-//	Flag map[common.Hash]bool
-//}
-//
-//func NewBlockByHash() *BlockByHash {
-//	return &BlockByHash{
-//		M:    make(map[common.Hash]*LightProof),
-//		Flag: make(map[common.Hash]bool),
-//	}
-//}
-//
-//func (b *BlockByHash) Insert(h common.Hash, proof *LightProof) {
-//	b.M[h] = proof
-//}
-//
-//func (b *BlockByHash) Traverse(h common.Hash) {
-//	b.Flag[h] = true
-//}
-//
-////determine if the block hash has been recorded
-//func (b BlockByHash) Traversed(h common.Hash) bool {
-//	return b.Flag[h]
-//}
-//
-//func (b BlockByHash) Get(h common.Hash) (*LightProof, bool) {
-//	p, ok := b.M[h]
-//	return p, ok
-//}
-//
-//func Ancestors(b *LightProof, header *BlockByHash) LightProofs {
-//	if b.Hash().IsEqual(TestGenesis.Hash()) {
-//		return LightProofs{b}
-//	}
-//
-//	var headers LightProofs
-//	headersMap := NewHeadersMap()
-//	//fmt.Println("finding ancestor at")
-//	//fmt.Println(b.Hash(), b.GetNumber())
-//	//fmt.Println("link")
-//	//fmt.Println(b.Link)
-//	//fmt.Println("iterating interlink")
-//	for i := len(b.Link) - 1; i > 0; i-- {
-//		hash := b.Link[i]
-//		//fmt.Println("hash", hash)
-//		if h, ok := header.Get(hash); ok {
-//			// To ensure every edge in the DAG is traversed only once,
-//			// otherwise it would cost so much time if we follow the algorithm
-//			// in the original paper
-//			if !header.Traversed(hash) {
-//				//fmt.Println(h.Hash(), h.GetNumber())
-//				//fmt.Println("entering recursive")
-//				header.Traverse(hash)
-//				aux := Ancestors(h, header)
-//				for _, h := range aux {
-//					if !headersMap.Insert(h.Header) {
-//						headers = append(headers, h)
-//					}
-//				}
-//			}
-//		}
-//	}
-//
-//	if !headersMap.Insert(b.Header) {
-//		headers = append(headers, b)
-//	}
-//	return headers
-//}
-//
-//// VerifyInfix implements algorithm 7
-//func VerifyInfix(proofs Proofs, k uint64, m int, D PredicateFunc) bool {
-//	headers := NewBlockByHash()
-//	headers.Insert(TestGenesis.Hash(), NewLightProof(TestGenesis.Header(), TestGenesis.GetInterlinks()))
-//	//fmt.Println("Setup blockById")
-//	for _, p := range proofs {
-//		for _, B := range p.Prefix {
-//			headers.Insert(B.Hash(), B)
-//			//fmt.Println(B.Hash(), B.GetNumber())
-//		}
-//	}
-//
-//	prePrime := LightProofs{NewLightProof(TestGenesis.Header(), TestGenesis.GetInterlinks())}
-//
-//	for _, p := range proofs {
-//		// if validChain && |suf| = k && pre >=_m prePrime
-//		if len(p.Suffix) == int(k) && BlockGreater(p.Prefix, prePrime, m) {
-//			prePrime = p.Prefix
-//		}
-//	}
-//
-//	an := Ancestors(prePrime[len(prePrime)-1], headers)
-//
-//	return D(an)
-//}
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"sort"
+)
+
+// ProofChainReader is the part of the full chain used to generate interlink proofs
+type ProofChainReader interface {
+	CurrentBlock() model.AbstractBlock
+	GetBlockByNumber(number uint64) model.AbstractBlock
+	GetBlockByHash(hash common.Hash) model.AbstractBlock
+}
+
+// LightProof is a header with its interlinks, which are checked by the interlink root of the header.
+// Special blocks have no pow, they carry the votes of the verifier boot nodes on them instead.
+type LightProof struct {
+	Header *model.Header
+	Link   model.InterLink
+	Votes  []*model.VoteMsg
+}
+
+type LightProofs []*LightProof
+
+func NewLightProof(block model.AbstractBlock) *LightProof {
+	return &LightProof{
+		Header: block.Header().(*model.Header),
+		Link:   block.GetInterlinks(),
+	}
+}
+
+func (l *LightProof) Hash() common.Hash {
+	return l.Header.Hash()
+}
+
+func (l *LightProof) GetNumber() uint64 {
+	return l.Header.Number
+}
+
+// special blocks are made by the verifiers without pow
+func (l *LightProof) IsSpecial() bool {
+	return l.Header.Diff.Equal(common.Difficulty{0}) && l.Header.Nonce.IsEqual(common.BlockNonce{0})
+}
+
+// Level returns the highest superchain level of the block, special blocks are only on level 0
+func (l *LightProof) Level() int {
+	if l.IsSpecial() {
+		return 0
+	}
+	return model.HashLevel(l.Hash(), l.Header.Diff.DiffToTarget())
+}
+
+// Valid checks the interlinks with the interlink root and the pow of the header,
+// special blocks must be signed by a verifier boot node
+func (l *LightProof) Valid() error {
+	if !model.DeriveSha(l.Link).IsEqual(l.Header.InterlinkRoot) {
+		return g_error.ErrInvalidInterLinkRoot
+	}
+	if l.IsSpecial() {
+		if l.bootNodeVote() == nil {
+			return g_error.ErrSpecialProofNotSigned
+		}
+		return nil
+	}
+	if !l.Hash().ValidHashForDifficulty(l.Header.Diff) {
+		return g_error.ErrInvalidProofPow
+	}
+	return nil
+}
+
+// bootNodeVote returns the valid vote of a verifier boot node on the header
+func (l *LightProof) bootNodeVote() *model.VoteMsg {
+	hash := l.Hash()
+	for _, v := range l.Votes {
+		if v == nil || v.Witness == nil || v.VoteType != model.VerBootNodeVoteMessage {
+			continue
+		}
+		if v.Height != l.GetNumber() || !v.BlockID.IsEqual(hash) {
+			continue
+		}
+		if err := v.HaltedVoteValid(nil); err == nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// newProofHeader returns the light proof of the block, the votes on a special
+// block are taken from the verifications of the next block
+func newProofHeader(reader ProofChainReader, block model.AbstractBlock) (*LightProof, error) {
+	l := NewLightProof(block)
+	if !l.IsSpecial() {
+		return l, nil
+	}
+
+	next := reader.GetBlockByNumber(block.Number() + 1)
+	if next == nil || !next.PreHash().IsEqual(l.Hash()) {
+		return nil, g_error.ErrSpecialProofNotSigned
+	}
+	for _, v := range next.GetVerifications() {
+		if vote, ok := v.(*model.VoteMsg); ok && vote.VoteType == model.VerBootNodeVoteMessage {
+			l.Votes = append(l.Votes, vote)
+		}
+	}
+	if l.bootNodeVote() == nil {
+		return nil, g_error.ErrSpecialProofNotSigned
+	}
+	return l, nil
+}
+
+// linked checks whether the pre block is referenced by the pre hash or the interlinks
+func (l *LightProof) linked(pre *LightProof) bool {
+	preHash := pre.Hash()
+	if l.Header.PreHash.IsEqual(preHash) {
+		return true
+	}
+	for _, h := range l.Link {
+		if h.IsEqual(preHash) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bs LightProofs) Len() int {
+	return len(bs)
+}
+
+func (bs LightProofs) Less(i, j int) bool {
+	return bs[i].GetNumber() < bs[j].GetNumber()
+}
+
+func (bs LightProofs) Swap(i, j int) {
+	bs[i], bs[j] = bs[j], bs[i]
+}
+
+// UpChain returns the superchain of the given level
+func (bs LightProofs) UpChain(level int) (superChain LightProofs) {
+	for _, b := range bs {
+		if b.Level() >= level {
+			superChain = append(superChain, b)
+		}
+	}
+	return
+}
+
+// AfterTarget implements the [b:] operator, which returns the sorted headers from the number b (inclusive)
+func (bs LightProofs) AfterTarget(number uint64) LightProofs {
+	index := sort.Search(len(bs), func(i int) bool {
+		return bs[i].GetNumber() >= number
+	})
+	res := make(LightProofs, len(bs[index:]))
+	copy(res, bs[index:])
+	return res
+}
+
+// remove the duplicate headers and sort the rest by number
+func (bs LightProofs) uniqueSorted() LightProofs {
+	seen := make(map[common.Hash]bool, len(bs))
+	res := make(LightProofs, 0, len(bs))
+	for _, b := range bs {
+		hash := b.Hash()
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		res = append(res, b)
+	}
+	sort.Sort(res)
+	return res
+}
+
+// Proof is a NIPoPoW suffix proof. The prefix is made of the superchains from the genesis,
+// which get sparser towards the genesis, and the suffix is the latest k blocks.
+type Proof struct {
+	Prefix LightProofs
+	Suffix LightProofs
+}
+
+// Tip returns the latest header of the proof
+func (p *Proof) Tip() *model.Header {
+	if len(p.Suffix) == 0 {
+		return nil
+	}
+	return p.Suffix[len(p.Suffix)-1].Header
+}
+
+// Valid checks that the proof starts from the genesis, every header is linked to the
+// one before and the suffix is made of k consecutive blocks
+func (p *Proof) Valid(genesis common.Hash, k uint64) error {
+	if k == 0 || uint64(len(p.Suffix)) != k {
+		return g_error.ErrInvalidProofSuffix
+	}
+	if len(p.Prefix) == 0 || !p.Prefix[0].Hash().IsEqual(genesis) {
+		return g_error.ErrProofGenesisNotMatch
+	}
+
+	headers := append(append(LightProofs{}, p.Prefix...), p.Suffix...)
+	for i := 1; i < len(headers); i++ {
+		if err := headers[i].Valid(); err != nil {
+			return err
+		}
+		if headers[i].GetNumber() <= headers[i-1].GetNumber() || !headers[i].linked(headers[i-1]) {
+			return g_error.ErrProofNotConnected
+		}
+	}
+
+	for i := 1; i < len(p.Suffix); i++ {
+		if !p.Suffix[i].Header.PreHash.IsEqual(p.Suffix[i-1].Hash()) {
+			return g_error.ErrProofNotConnected
+		}
+	}
+	return nil
+}
+
+// GetSuffixProof implements the suffix prover of the NIPoPoW paper with the current block as the chain tip.
+// m is the security parameter of the superchain length and k is the length of the suffix.
+// The special blocks at the tip aren't signed in the chain yet, so they are left out of the proof.
+func GetSuffixProof(reader ProofChainReader, m int, k uint64) (*Proof, error) {
+	end := reader.CurrentBlock().Number()
+	for end > 0 && reader.GetBlockByNumber(end).IsSpecial() {
+		end--
+	}
+	if k == 0 || end < k {
+		return nil, g_error.ErrChainTooShortForProof
+	}
+
+	p := &Proof{}
+	for number := end - k + 1; number <= end; number++ {
+		block := reader.GetBlockByNumber(number)
+		if block == nil {
+			return nil, g_error.ErrBlockNotFound
+		}
+		l, err := newProofHeader(reader, block)
+		if err != nil {
+			return nil, err
+		}
+		p.Suffix = append(p.Suffix, l)
+	}
+
+	// the interlinks of the first suffix block point into the prefix
+	first := p.Suffix[0]
+	prefix := LightProofs{NewLightProof(reader.GetBlockByNumber(0))}
+	start := uint64(0)
+	for level := len(first.Link) - 1; level >= 0; level-- {
+		alpha, err := superChain(reader, first, level, start)
+		if err != nil {
+			return nil, err
+		}
+		prefix = append(prefix, alpha...)
+
+		if len(alpha) >= m {
+			start = alpha[len(alpha)-m].GetNumber()
+		}
+	}
+	p.Prefix = prefix.uniqueSorted()
+	return p, nil
+}
+
+// superChain follows the interlink of the level (the pre hash for level 0) back from the tip,
+// and returns the blocks from the number start (inclusive) in ascending order without the genesis
+func superChain(reader ProofChainReader, tip *LightProof, level int, start uint64) (LightProofs, error) {
+	var chain LightProofs
+	cur := tip
+	for {
+		var next common.Hash
+		if level == 0 {
+			next = cur.Header.PreHash
+		} else if level < len(cur.Link) {
+			next = cur.Link[level]
+		} else {
+			break
+		}
+
+		block := reader.GetBlockByHash(next)
+		if block == nil {
+			return nil, g_error.ErrBlockNotFound
+		}
+		if block.Number() == 0 || block.Number() < start {
+			break
+		}
+
+		l, err := newProofHeader(reader, block)
+		if err != nil {
+			return nil, err
+		}
+		cur = l
+		chain = append(chain, cur)
+	}
+
+	// reverse to ascending order
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// bestArg scores the proof after the block number b by the best 2^level * |superchain|
+// among level 0 and the levels that have at least m blocks
+func bestArg(proof LightProofs, b uint64, m int) int {
+	chain := proof.AfterTarget(b)
+	best := len(chain)
+	for level := 1; ; level++ {
+		chain = chain.UpChain(level)
+		if len(chain) < m {
+			break
+		}
+		if score := len(chain) << uint(level); score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// BlockGreater implements the >=_m operator of the NIPoPoW paper, it compares the scores of
+// the two sorted proofs after their last common block. It returns false if the scores are equal.
+func BlockGreater(a LightProofs, b LightProofs, m int) bool {
+	inB := make(map[common.Hash]bool, len(b))
+	for _, h := range b {
+		inB[h.Hash()] = true
+	}
+
+	var lca uint64
+	for i := len(a) - 1; i >= 0; i-- {
+		if inB[a[i].Hash()] {
+			lca = a[i].GetNumber()
+			break
+		}
+	}
+	return bestArg(a, lca, m) > bestArg(b, lca, m)
+}
+
+// VerifySuffix implements the suffix verifier of the NIPoPoW paper, it returns the best valid proof.
+// The proof with the higher tip wins if the prefixes have the same score.
+func VerifySuffix(proofs []*Proof, genesis common.Hash, m int, k uint64) (*Proof, error) {
+	var best *Proof
+	for _, p := range proofs {
+		if p == nil {
+			continue
+		}
+		if err := p.Valid(genesis, k); err != nil {
+			log.Warn("invalid interlink proof", "err", err)
+			continue
+		}
+
+		if best == nil || BlockGreater(p.Prefix, best.Prefix, m) ||
+			(!BlockGreater(best.Prefix, p.Prefix, m) && p.Tip().Number > best.Tip().Number) {
+			best = p
+		}
+	}
+
+	if best == nil {
+		return nil, g_error.ErrNoValidProof
+	}
+	return best, nil
+}
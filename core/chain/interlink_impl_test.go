@@ -16,279 +16,246 @@
 
 package chain
 
-//import (
-//	"fmt"
-//	"github.com/dipperin/dipperin-core/common"
-//	"github.com/dipperin/dipperin-core/core/model"
-//	"github.com/dipperin/dipperin-core/third-party/log"
-//	"github.com/stretchr/testify/assert"
-//	"math/big"
-//	"testing"
-//)
-//
-//type testSetup struct {
-//	reader  *FakeFullChainInterlink
-//	genesis model.AbstractBlock
-//}
-//
-//func setUpInterlinkTest(t *testing.T) *testSetup {
-//	genesis := reader.Genesis()
-//	preHash := genesis.Hash()
-//	log.Info("the genesis hash is:","hash",preHash.Hex())
-//	preBlock := genesis
-//	//tReader := NewFakeFullChain()
-//
-//	for i := 1; i < 1000; i++ {
-//		header := model.NewHeader(1, uint64(i), preHash, common.HexToHash("1111"), minDiff, big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
-//		block := model.NewBlockWithLink(header, nil, nil, preBlock.GetInterlinks())
-//
-//		err := reader.SaveBlock(block, nil)
-//		assert.NoError(t, err)
-//
-//		preBlock = block
-//		preHash = block.Hash()
-//	}
-//
-//	return &testSetup{
-//		reader:  reader,
-//		genesis: genesis,
-//	}
-//}
-//
-//func TestProof(t *testing.T) {
-//	setUpInterlinkTest(t)
-//	p, err := GetSuffixProof(reader, 800, 2, 6)
-//
-//	assert.NoError(t, err)
-//	assert.NoError(t, p.Valid(reader))
-//
-//	// for inspection
-//	for _, h := range p.Prefix {
-//		fmt.Println(h.Hash(), h.GetNumber())
-//		b := reader.GetBlockByNumber(h.GetNumber())
-//		for _, inter := range b.GetInterlinks() {
-//			fmt.Println(inter)
-//		}
-//		fmt.Println()
-//	}
-//}
-//
-//func TestVerify(t *testing.T) {
-//	setUpInterlinkTest(t)
-//	reader1 := NewFakeFullChain()
-//
-//	preHash := TestGenesis.Hash()
-//	preBlock := TestGenesis
-//
-//	for i := uint64(0); i < reader.CurrentBlock().Number(); i++ {
-//		if i > 300 {
-//			header := model.NewHeader(1, uint64(i), preHash, common.HexToHash("2222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//			block := model.NewBlockWithLink(header, nil, nil, preBlock.GetInterlinks())
-//
-//			err := reader1.SaveBlock(block, nil)
-//			assert.NoError(t, err)
-//
-//			preHash = block.Hash()
-//			preBlock = block
-//		} else {
-//			b := reader.GetBlockByNumber(i)
-//			err := reader1.SaveBlock(b, b.GetVerifications())
-//			assert.NoError(t, err)
-//
-//			preHash = b.Hash()
-//			preBlock = b
-//		}
-//	}
-//
-//	m := 7
-//	k := uint64(2)
-//
-//	p, err := GetSuffixProof(reader, 500, m, k)
-//	//fmt.Println("prefix", len(p.Prefix))
-//	assert.NoError(t, err)
-//	assert.NoError(t, p.Valid(reader))
-//
-//	p1, err := GetSuffixProof(reader1, 490, m, k)
-//	//p1, storageErr := GetGoodSuffixProof(reader1, 4900, m, k, 0.4)
-//	//fmt.Println("prefix", len(p1.Prefix))
-//
-//	assert.NoError(t, err)
-//	assert.NoError(t, p1.Valid(reader1))
-//
-//	assert.True(t, uint64(len(p.Suffix)) == k)
-//	assert.True(t, uint64(len(p1.Suffix)) == k)
-//
-//	//assert.True(t, BlockGreater(p.Prefix, LightProofs{TestGenesis.Header()}, m))
-//	//fmt.Println(BlockGreater(p.Prefix, p1.Prefix, m))
-//	//fmt.Println(BlockGreater(headers, headers1, m))
-//	//for inspection
-//	//for _, h := range p.Prefix {
-//	//	fmt.Println("Number", h.GetNumber(), h.Hash())
-//	//}
-//	//fmt.Println("-----------------------------")
-//	//for _, h := range p1.Prefix {
-//	//	fmt.Println("Number", h.GetNumber(), h.Hash())
-//	//}
-//}
-//
-//func TestGood(t *testing.T) {
-//	setUpInterlinkTest(t)
-//
-//	m := 5
-//	k := uint64(2)
-//
-//	p, err := GetSuffixProof(reader, 500, m, k)
-//	//fmt.Println("prefix", len(p.Prefix))
-//	assert.NoError(t, err)
-//	assert.NoError(t, p.Valid(reader))
-//
-//	p1, err := GetGoodSuffixProof(reader, 500, m, k, 0.7)
-//	//fmt.Println("prefix", len(p.Prefix))
-//	assert.NoError(t, err)
-//	assert.NoError(t, p.Valid(reader))
-//
-//	assert.True(t, uint64(len(p.Suffix)) == k)
-//	assert.True(t, uint64(len(p1.Suffix)) == k)
-//
-//	//assert.True(t, BlockGreater(p.Prefix, LightProofs{TestGenesis.Header()}, m))
-//	fmt.Println(BlockGreater(p.Prefix, p1.Prefix, m))
-//
-//	//for inspection
-//	//for _, h := range p.Prefix {
-//	//	fmt.Println("Number", h.GetNumber(), h.Hash())
-//	//}
-//	//fmt.Println("-----------------------------")
-//	//for _, h := range p1.Prefix {
-//	//	fmt.Println("Number", h.GetNumber(), h.Hash())
-//	//}
-//}
-//
-//func TestGoodVerify(t *testing.T) {
-//	setUpInterlinkTest(t)
-//	reader1 := NewFakeFullChain()
-//
-//	preHash := TestGenesis.Hash()
-//	preBlock := TestGenesis
-//
-//	for i := uint64(1); i < reader.CurrentBlock().Number(); i++ {
-//		if i > 300 {
-//			header := model.NewHeader(1, uint64(i), preHash, common.HexToHash("2222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//			block := model.NewBlockWithLink(header, nil, nil, preBlock.GetInterlinks())
-//
-//			err := reader1.SaveBlock(block, nil)
-//			assert.NoError(t, err)
-//
-//			preHash = block.Hash()
-//			preBlock = block
-//		} else {
-//			b := reader.GetBlockByNumber(i)
-//			preHash = b.Hash()
-//			preBlock = b
-//			err := reader1.SaveBlock(b, b.GetVerifications())
-//			assert.NoError(t, err)
-//		}
-//	}
-//
-//	m := 7
-//	k := uint64(2)
-//	delta := float64(0.7)
-//	p, err := GetGoodSuffixProof(reader, 500, m, k, delta)
-//	//fmt.Println("prefix", len(p.Prefix))
-//	assert.NoError(t, err)
-//	assert.NoError(t, p.Valid(reader))
-//
-//	p1, err := GetGoodSuffixProof(reader1, 400, m, k, delta)
-//	//p1, storageErr := GetGoodSuffixProof(reader1, 4900, m, k, 0.4)
-//	//fmt.Println("prefix", len(p1.Prefix))
-//
-//	assert.NoError(t, err)
-//	assert.NoError(t, p1.Valid(reader1))
-//
-//	assert.True(t, uint64(len(p.Suffix)) == k)
-//	assert.True(t, uint64(len(p1.Suffix)) == k)
-//
-//	//assert.True(t, BlockGreater(p.Prefix, LightProofs{TestGenesis.Header()}, m))
-//	//fmt.Println(BlockGreater(p.Prefix, p1.Prefix, m))
-//	//fmt.Println(BlockGreater(headers, headers1, m))
-//	//for inspection
-//	//for _, h := range p.Prefix {
-//	//	fmt.Println("Number", h.GetNumber(), h.Hash())
-//	//}
-//	//fmt.Println("-----------------------------")
-//	//for _, h := range p1.Prefix {
-//	//	fmt.Println("Number", h.GetNumber(), h.Hash())
-//	//}
-//}
-//
-//func TestAfterTarget(t *testing.T) {
-//	//header1 := model.NewHeader(1, uint64(1), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//	header2 := model.NewHeader(1, uint64(2), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//	header3 := model.NewHeader(1, uint64(3), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//	//header4 := model.NewHeader(1, uint64(4), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//	header5 := model.NewHeader(1, uint64(5), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//	header6 := model.NewHeader(1, uint64(6), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//	header7 := model.NewHeader(1, uint64(7), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//	//header8 := model.NewHeader(1, uint64(8), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//	//header9 := model.NewHeader(1, uint64(9), TestGenesis.Hash(), common.HexToHash("22222"), minDiff, big.NewInt(1232131), common.HexToAddress("00ff1acd"), common.BlockNonceFromInt(23322))
-//
-//	headers := LightProofs{NewLightProof(header2, nil), NewLightProof(header3, nil), NewLightProof(header6, nil), NewLightProof(header7, nil)}
-//
-//	fmt.Println(AfterTarget(headers, header5))
-//}
-//
-//func TestGetInfixProof(t *testing.T) {
-//	setUpInterlinkTest(t)
-//
-//	m := 2
-//	k := uint64(2)
-//	tar := uint64(300)
-//
-//	p, err := GetInfixProof(reader, 800, m, k, tar)
-//	assert.NoError(t, err)
-//	//for _, a := range p.Prefix {
-//	//	fmt.Println(a.Hash(), a.GetNumber())
-//	//}
-//	assert.NoError(t, p.Valid(reader))
-//}
-//
-//func TestVerifyInfix(t *testing.T) {
-//	setUpInterlinkTest(t)
-//
-//	m := 2
-//	k := uint64(2)
-//	tar := uint64(300)
-//
-//	p, err := GetInfixProof(reader, 800, m, k, tar)
-//	assert.NoError(t, err)
-//	//fmt.Println(p.Prefix)
-//	assert.NoError(t, p.Valid(reader))
-//	D := func(proofs LightProofs) bool {
-//		//for _, a := range proofs {
-//		//	fmt.Println(a.Hash(), a.GetNumber())
-//		//}
-//
-//		return true
-//	}
-//
-//	VerifyInfix(Proofs{*p}, k, m, D)
-//}
-//
-//func TestVerifySuffix(t *testing.T) {
-//	setUpInterlinkTest(t)
-//	m := 4
-//	k := uint64(6)
-//	tar := uint64(300)
-//
-//	p, err := GetInfixProof(reader, 800, m, k, tar)
-//	assert.NoError(t, err)
-//
-//	Q := func(proofs LightProofs) bool {
-//		//for _, a := range proofs {
-//		//	fmt.Println(a.Hash(), a.GetNumber())
-//		//}
-//
-//		return true
-//	}
-//
-//	VerifySuffix(Proofs{*p}, k, m, Q)
-//}
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+type fakeProofChain struct {
+	blocks []model.AbstractBlock
+	// the votes put into the next block
+	votes []model.AbstractVerification
+}
+
+func (f *fakeProofChain) CurrentBlock() model.AbstractBlock {
+	return f.blocks[len(f.blocks)-1]
+}
+
+func (f *fakeProofChain) GetBlockByNumber(number uint64) model.AbstractBlock {
+	if number >= uint64(len(f.blocks)) {
+		return nil
+	}
+	return f.blocks[number]
+}
+
+func (f *fakeProofChain) GetBlockByHash(hash common.Hash) model.AbstractBlock {
+	for _, b := range f.blocks {
+		if b.Hash().IsEqual(hash) {
+			return b
+		}
+	}
+	return nil
+}
+
+func newFakeProofChain() *fakeProofChain {
+	header := model.NewHeader(1, 0, common.Hash{}, common.HexToHash("1111"), minDiff, big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
+	genesis := model.NewBlock(header, nil, nil)
+	return &fakeProofChain{blocks: []model.AbstractBlock{genesis}}
+}
+
+// fork returns a chain sharing the blocks until the number
+func (f *fakeProofChain) fork(number uint64) *fakeProofChain {
+	blocks := make([]model.AbstractBlock, number+1)
+	copy(blocks, f.blocks[:number+1])
+	return &fakeProofChain{blocks: blocks}
+}
+
+func (f *fakeProofChain) grow(t *testing.T, count int, seed common.Hash) {
+	for i := 0; i < count; i++ {
+		pre := f.CurrentBlock()
+		header := model.NewHeader(1, pre.Number()+1, pre.Hash(), seed, minDiff, big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
+		block := model.NewBlockWithLink(header, nil, f.votes, pre.GetInterlinks())
+		assert.True(t, block.Hash().ValidHashForDifficulty(minDiff))
+		f.blocks = append(f.blocks, block)
+		f.votes = nil
+	}
+}
+
+// growSpecial appends a special block, the vote of the boot node on it is put into the next block if signed
+func (f *fakeProofChain) growSpecial(signed bool) {
+	pre := f.CurrentBlock()
+	header := model.NewHeader(1, pre.Number()+1, pre.Hash(), common.HexToHash("1111"), common.Difficulty{0}, big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonce{0})
+	block := model.NewBlockWithLink(header, nil, f.votes, pre.GetInterlinks())
+	f.blocks = append(f.blocks, block)
+	f.votes = nil
+	if signed {
+		f.votes = []model.AbstractVerification{model.CreateSignedVote(block.Number(), 0, block.Hash(), model.VerBootNodeVoteMessage)}
+	}
+}
+
+// setBootNode makes the signer of the test votes a verifier boot node, and returns the func restoring the boot nodes
+func setBootNode() func() {
+	bootNodes := chain_config.VerBootNodeAddress
+	vote := model.CreateSignedVote(1, 0, common.Hash{}, model.VerBootNodeVoteMessage)
+	chain_config.VerBootNodeAddress = []common.Address{vote.GetAddress()}
+	return func() {
+		chain_config.VerBootNodeAddress = bootNodes
+	}
+}
+
+func TestGetSuffixProof(t *testing.T) {
+	reader := newFakeProofChain()
+	_, err := GetSuffixProof(reader, 5, 6)
+	assert.Equal(t, g_error.ErrChainTooShortForProof, err)
+
+	reader.grow(t, 1000, common.HexToHash("1111"))
+	genesis := reader.GetBlockByNumber(0).Hash()
+
+	p, err := GetSuffixProof(reader, 5, 6)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Valid(genesis, 6))
+	assert.Len(t, p.Suffix, 6)
+	assert.True(t, p.Prefix[0].Hash().IsEqual(genesis))
+	assert.True(t, len(p.Prefix) < 500)
+	assert.Equal(t, uint64(1000), p.Tip().Number)
+	assert.Equal(t, uint64(994), p.Prefix[len(p.Prefix)-1].GetNumber())
+
+	assert.Equal(t, g_error.ErrInvalidProofSuffix, p.Valid(genesis, 5))
+	assert.Equal(t, g_error.ErrProofGenesisNotMatch, p.Valid(common.HexToHash("123"), 6))
+}
+
+func TestProof_Valid(t *testing.T) {
+	reader := newFakeProofChain()
+	reader.grow(t, 300, common.HexToHash("1111"))
+	genesis := reader.GetBlockByNumber(0).Hash()
+
+	getProof := func() *Proof {
+		p, err := GetSuffixProof(reader, 5, 6)
+		assert.NoError(t, err)
+		return p
+	}
+
+	p := getProof()
+	p.Prefix[1] = &LightProof{Header: p.Prefix[1].Header, Link: model.InterLink{genesis}}
+	assert.Equal(t, g_error.ErrInvalidInterLinkRoot, p.Valid(genesis, 6))
+
+	p = getProof()
+	p.Suffix[3] = p.Suffix[2]
+	assert.Equal(t, g_error.ErrProofNotConnected, p.Valid(genesis, 6))
+
+	p = getProof()
+	other := newFakeProofChain()
+	other.grow(t, 10, common.HexToHash("2222"))
+	p.Prefix = append(LightProofs{p.Prefix[0], NewLightProof(other.GetBlockByNumber(5))}, p.Prefix[1:]...)
+	assert.Equal(t, g_error.ErrProofNotConnected, p.Valid(genesis, 6))
+
+	header := model.NewHeader(1, 1, genesis, common.HexToHash("1111"), common.HexToDiff("0x1effffff"), big.NewInt(324234), common.HexToAddress("032f14"), common.BlockNonceFromInt(432423))
+	block := model.NewBlockWithLink(header, nil, nil, reader.GetBlockByNumber(0).GetInterlinks())
+	assert.Equal(t, g_error.ErrInvalidProofPow, NewLightProof(block).Valid())
+}
+
+func TestBlockGreater(t *testing.T) {
+	honest := newFakeProofChain()
+	honest.grow(t, 1000, common.HexToHash("1111"))
+	genesis := honest.GetBlockByNumber(0).Hash()
+
+	adversary := honest.fork(300)
+	adversary.grow(t, 400, common.HexToHash("2222"))
+
+	m, k := 5, uint64(6)
+	p1, err := GetSuffixProof(honest, m, k)
+	assert.NoError(t, err)
+	p2, err := GetSuffixProof(adversary, m, k)
+	assert.NoError(t, err)
+
+	assert.True(t, BlockGreater(p1.Prefix, p2.Prefix, m))
+	assert.False(t, BlockGreater(p2.Prefix, p1.Prefix, m))
+	assert.False(t, BlockGreater(p1.Prefix, p1.Prefix, m))
+
+	best, err := VerifySuffix([]*Proof{p2, nil, p1}, genesis, m, k)
+	assert.NoError(t, err)
+	assert.Equal(t, p1, best)
+
+	best, err = VerifySuffix([]*Proof{p1, p2}, genesis, m, k)
+	assert.NoError(t, err)
+	assert.Equal(t, p1, best)
+
+	_, err = VerifySuffix([]*Proof{p1, p2}, common.HexToHash("123"), m, k)
+	assert.Equal(t, g_error.ErrNoValidProof, err)
+}
+
+func TestVerifySuffix_HigherTip(t *testing.T) {
+	reader := newFakeProofChain()
+	reader.grow(t, 200, common.HexToHash("1111"))
+	genesis := reader.GetBlockByNumber(0).Hash()
+
+	p1, err := GetSuffixProof(reader, 5, 6)
+	assert.NoError(t, err)
+	reader.grow(t, 1, common.HexToHash("1111"))
+	p2, err := GetSuffixProof(reader, 5, 6)
+	assert.NoError(t, err)
+
+	best, err := VerifySuffix([]*Proof{p1, p2}, genesis, 5, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(201), best.Tip().Number)
+}
+
+func TestGetSuffixProof_SpecialBlocks(t *testing.T) {
+	defer setBootNode()()
+	reader := newFakeProofChain()
+	reader.grow(t, 297, common.HexToHash("1111"))
+	reader.growSpecial(true)
+	reader.grow(t, 2, common.HexToHash("1111"))
+	reader.growSpecial(true)
+	genesis := reader.GetBlockByNumber(0).Hash()
+
+	// the special tip isn't signed yet
+	p, err := GetSuffixProof(reader, 5, 6)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Valid(genesis, 6))
+	assert.Equal(t, uint64(300), p.Tip().Number)
+	assert.True(t, p.Suffix[3].IsSpecial())
+	assert.Len(t, p.Suffix[3].Votes, 1)
+
+	p.Suffix[3].Votes = nil
+	assert.Equal(t, g_error.ErrSpecialProofNotSigned, p.Valid(genesis, 6))
+
+	unsigned := newFakeProofChain()
+	unsigned.grow(t, 297, common.HexToHash("1111"))
+	unsigned.growSpecial(false)
+	unsigned.grow(t, 2, common.HexToHash("1111"))
+	_, err = GetSuffixProof(unsigned, 5, 6)
+	assert.Equal(t, g_error.ErrSpecialProofNotSigned, err)
+}
+
+func TestVerifySuffix_ForgedSpecialBlocks(t *testing.T) {
+	defer setBootNode()()
+	honest := newFakeProofChain()
+	honest.grow(t, 300, common.HexToHash("1111"))
+	genesis := honest.GetBlockByNumber(0).Hash()
+
+	m, k := 5, uint64(6)
+	p1, err := GetSuffixProof(honest, m, k)
+	assert.NoError(t, err)
+
+	// the adversary appends special blocks without pow to the honest chain, its prefix
+	// then holds the whole honest proof and scores higher
+	adversary := honest.fork(300)
+	for i := 0; i < 100; i++ {
+		adversary.growSpecial(false)
+	}
+	var blocks LightProofs
+	for _, b := range adversary.blocks[301:] {
+		blocks = append(blocks, NewLightProof(b))
+	}
+	prefix := append(append(append(LightProofs{}, p1.Prefix...), p1.Suffix...), blocks[:len(blocks)-int(k)]...)
+	p2 := &Proof{Prefix: prefix, Suffix: blocks[len(blocks)-int(k):]}
+	assert.True(t, BlockGreater(p2.Prefix, p1.Prefix, m))
+	assert.Equal(t, g_error.ErrSpecialProofNotSigned, p2.Valid(genesis, k))
+
+	best, err := VerifySuffix([]*Proof{p2, p1}, genesis, m, k)
+	assert.NoError(t, err)
+	assert.Equal(t, p1, best)
+
+	// the votes of other blocks don't sign the special blocks
+	vote := model.CreateSignedVote(p1.Tip().Number, 0, p1.Tip().Hash(), model.VerBootNodeVoteMessage)
+	for _, b := range blocks {
+		b.Votes = []*model.VoteMsg{vote}
+	}
+	assert.Equal(t, g_error.ErrSpecialProofNotSigned, p2.Valid(genesis, k))
+}
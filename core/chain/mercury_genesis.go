@@ -17,6 +17,7 @@
 package chain
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -86,6 +87,9 @@ type Genesis struct {
 	//add verifiers
 	Verifiers []common.Address
 
+	// the contracts deployed by the genesis spec
+	Contracts []GenesisContract
+
 	// These fields are used for consensus tests. Please don't use them
 	// in actual genesis blocks.
 	Number uint64 `json:"number"`
@@ -373,6 +377,40 @@ func (g *Genesis) SetEarlyTokenContract() error {
 	return nil
 }
 
+// deployContracts writes the code, the abi and the storage of the genesis contracts
+func (g *Genesis) deployContracts() error {
+	if len(g.Contracts) == 0 {
+		return nil
+	}
+
+	state := g.AccountStateProcessor.(*state_processor.AccountStateDB)
+	for _, c := range g.Contracts {
+		if _, ok := g.Alloc[c.Address]; !ok {
+			if err := state.NewAccountState(c.Address); err != nil {
+				return err
+			}
+		}
+
+		// the abi is saved compactly, so it doesn't change with the format of the spec file
+		abi := new(bytes.Buffer)
+		if err := json.Compact(abi, c.Abi); err != nil {
+			return err
+		}
+		if err := state.SetCode(c.Address, c.Code); err != nil {
+			return err
+		}
+		if err := state.SetAbi(c.Address, abi.Bytes()); err != nil {
+			return err
+		}
+		for key, value := range c.Storage {
+			if err := state.SetData(c.Address, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Prepare() (model.AbstractBlock, error) {
@@ -400,6 +438,10 @@ func (g *Genesis) Prepare() (model.AbstractBlock, error) {
 		}
 	}
 
+	if err := g.deployContracts(); err != nil {
+		return nil, err
+	}
+
 	err := g.SetEarlyTokenContract()
 	if err != nil {
 		return nil, err
@@ -454,6 +496,11 @@ func (g *Genesis) Prepare() (model.AbstractBlock, error) {
 func DefaultGenesisBlock(chainDB chaindb.Database, accountStateProcessor state_processor.AccountStateProcessor, registerProcessor registerdb.RegisterProcessor, chainConf *chain_config.ChainConfig) *Genesis {
 	log.Debug("call DefaultGenesisBlock")
 
+	// the applied genesis spec replaces the genesis of the boot env
+	if genesisSpec != nil {
+		return genesisSpec.ToGenesis(chainDB, accountStateProcessor, registerProcessor)
+	}
+
 	//read config file first
 	if mGenesis := GenesisBlockFromFile(chainDB, accountStateProcessor); mGenesis != nil {
 		return mGenesis
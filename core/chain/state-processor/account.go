@@ -40,6 +40,8 @@ const (
 	performanceSuffix  = "_performance"
 	abiSuffix          = "_abi"
 	codeSuffix         = "_code"
+	multiSigSuffix     = "_multisig"
+	blsKeySuffix       = "_bls_key"
 )
 
 func GetContractFieldKey(address common.Address, key string) []byte {
@@ -103,6 +105,14 @@ func GetCodeKey(address common.Address) []byte {
 	return append(address[:], []byte(codeSuffix)...)
 }
 
+func GetMultiSigKey(address common.Address) []byte {
+	return append(address[:], []byte(multiSigSuffix)...)
+}
+
+func GetBlsKeyKey(address common.Address) []byte {
+	return append(address[:], []byte(blsKeySuffix)...)
+}
+
 func (a *account) getNonce() uint64 {
 	return a.Nonce
 }
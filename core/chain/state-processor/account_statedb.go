@@ -22,6 +22,7 @@ import (
 	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/common/util"
 	"github.com/dipperin/dipperin-core/common/util/json-kv"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/contract"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/core/vm"
@@ -177,7 +178,7 @@ func NewAccountStateDB(preStateRoot common.Hash, db StateStorage) (*AccountState
 		blockStateTrie: tr,
 		storage:        db,
 
-		contractTrieCache:     NewStateStorageWithCache(db.DiskDB()),
+		contractTrieCache:     newContractStorage(db),
 		contractData:          map[common.Address]reflect.Value{},
 		smartContractData:     make(map[common.Address]map[string][]byte),
 		finalisedContractRoot: map[common.Address]common.Hash{},
@@ -201,9 +202,10 @@ func (state *AccountStateDB) Copy() *AccountStateDB {
 		storage:         state.storage,
 		stateChangeList: newStateChangeList(),
 
-		contractTrieCache:     NewStateStorageWithCache(state.storage.DiskDB()),
+		contractTrieCache:     newContractStorage(state.storage),
 		contractData:          map[common.Address]reflect.Value{},
 		finalisedContractRoot: map[common.Address]common.Hash{},
+		logs:                  map[common.Hash][]*model2.Log{},
 		//todo: if there is a question because not copy early contract in here
 	}
 	return statedb
@@ -439,6 +441,35 @@ func (state *AccountStateDB) GetCode(addr common.Address) ([]byte, error) {
 	return res, nil
 }
 
+// GetMultiSig returns the signer set and the threshold of the multisig account
+func (state *AccountStateDB) GetMultiSig(addr common.Address) (*model.MultiSigInfo, error) {
+	empty := state.IsEmptyAccount(addr)
+	if empty {
+		return nil, g_error.ErrAccountNotExist
+	}
+	enc, err := state.blockStateTrie.TryGet(GetMultiSigKey(addr))
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		return nil, g_error.ErrMultiSigAccountNotExist
+	}
+	var info model.MultiSigInfo
+	if err = rlp.DecodeBytes(enc, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetBlsKey returns the bls public key registered by the verifier, it's empty if the verifier hasn't registered one
+func (state *AccountStateDB) GetBlsKey(addr common.Address) ([]byte, error) {
+	empty := state.IsEmptyAccount(addr)
+	if empty {
+		return nil, g_error.ErrAccountNotExist
+	}
+	return state.blockStateTrie.TryGet(GetBlsKeyKey(addr))
+}
+
 func (state *AccountStateDB) SetBalance(addr common.Address, amount *big.Int) error {
 	old, _ := state.GetBalance(addr)
 	err := state.setBalance(addr, amount)
@@ -640,6 +671,56 @@ func (state *AccountStateDB) setCode(addr common.Address, code []byte) error {
 	return nil
 }
 
+func (state *AccountStateDB) SetMultiSig(addr common.Address, info *model.MultiSigInfo) error {
+	old, _ := state.blockStateTrie.TryGet(GetMultiSigKey(addr))
+	enc, err := rlp.EncodeToBytes(info)
+	if err != nil {
+		return err
+	}
+	err = state.setMultiSig(addr, enc)
+	if err != nil {
+		return err
+	}
+	state.stateChangeList.append(multiSigChange{Account: &addr, Prev: old, Current: enc, ChangeType: MultiSigChange})
+	return nil
+}
+
+// setMultiSig saves the encoded multisig info, it's removed if enc is empty
+func (state *AccountStateDB) setMultiSig(addr common.Address, enc []byte) error {
+	empty := state.IsEmptyAccount(addr)
+	if empty {
+		return g_error.ErrAccountNotExist
+	}
+	log.Mpt.Debug("setMultiSig", "addr", addr.Hex())
+	if len(enc) == 0 {
+		return state.blockStateTrie.TryDelete(GetMultiSigKey(addr))
+	}
+	return state.blockStateTrie.TryUpdate(GetMultiSigKey(addr), enc)
+}
+
+func (state *AccountStateDB) SetBlsKey(addr common.Address, key []byte) error {
+	old, _ := state.blockStateTrie.TryGet(GetBlsKeyKey(addr))
+	err := state.setBlsKey(addr, key)
+	if err != nil {
+		return err
+	}
+	state.stateChangeList.append(blsKeyChange{Account: &addr, Prev: old, Current: key, ChangeType: BlsKeyChange})
+	return nil
+}
+
+// setBlsKey saves the bls public key, it's removed if key is empty
+func (state *AccountStateDB) setBlsKey(addr common.Address, key []byte) error {
+	empty := state.IsEmptyAccount(addr)
+	if empty {
+		return g_error.ErrAccountNotExist
+	}
+	log.Mpt.Debug("setBlsKey", "addr", addr.Hex())
+	if len(key) == 0 {
+		return state.blockStateTrie.TryDelete(GetBlsKeyKey(addr))
+	}
+	return state.blockStateTrie.TryUpdate(GetBlsKeyKey(addr), key)
+}
+
 func (state *AccountStateDB) SetDataRoot(addr common.Address, dataRoot common.Hash) error {
 	old, _ := state.GetDataRoot(addr)
 	err := state.setDataRoot(addr, dataRoot)
@@ -666,6 +747,34 @@ func (state *AccountStateDB) setDataRoot(addr common.Address, dataRoot common.Ha
 	return nil
 }
 
+// ClearContract removes the code, the abi and the data of the contract, the changes are journaled
+// so a reverted call restores them
+func (state *AccountStateDB) ClearContract(addr common.Address) error {
+	if state.IsEmptyAccount(addr) {
+		return g_error.ErrAccountNotExist
+	}
+
+	// drop the data written in this block, sorted to keep the change list deterministic
+	var keys []string
+	for key := range state.smartContractData[addr] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := state.SetData(addr, key, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := state.SetCode(addr, []byte{}); err != nil {
+		return err
+	}
+	if err := state.SetAbi(addr, []byte{}); err != nil {
+		return err
+	}
+	return state.SetDataRoot(addr, common.Hash{})
+}
+
 func (state *AccountStateDB) SetStake(addr common.Address, amount *big.Int) error {
 	old, _ := state.GetStake(addr)
 	err := state.setStake(addr, amount)
@@ -947,6 +1056,14 @@ func (state *AccountStateDB) deleteAccountState(addr common.Address) (err error)
 	if err != nil {
 		return err
 	}
+	err = state.blockStateTrie.TryDelete(GetMultiSigKey(addr))
+	if err != nil {
+		return err
+	}
+	err = state.blockStateTrie.TryDelete(GetBlsKeyKey(addr))
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -1208,7 +1325,8 @@ func (state *AccountStateDB) IntermediateRoot() (result common.Hash, err error)
 	return
 }*/
 
-func (state *AccountStateDB) setTxReceiptPar(tx model.AbstractTransaction, par *model.ReceiptPara, blockGasUsed *uint64) error {
+func (state *AccountStateDB) setTxReceiptPar(conf *TxProcessConfig, par *model.ReceiptPara) error {
+	tx := conf.Tx
 	if tx.GetType() == common.AddressTypeContractCreate || tx.GetType() == common.AddressTypeContractCall {
 		return nil
 	}
@@ -1218,31 +1336,103 @@ func (state *AccountStateDB) setTxReceiptPar(tx model.AbstractTransaction, par *
 		return err
 	}
 
-	gasUsed, err := model.IntrinsicGas(tx.ExtraData(), false, false)
+	gasUsed, err := conf.intrinsicGas()
 	if err != nil {
 		return err
 	}
 
 	//add block gasUsed
-	*blockGasUsed += gasUsed
+	*conf.GasUsed += gasUsed
 
-	par.CumulativeGasUsed = *blockGasUsed
+	par.CumulativeGasUsed = *conf.GasUsed
 	par.HandlerResult = false
 	par.Root = root[:]
-	par.Logs = []*model2.Log{}
+	par.Logs = state.GetLogs(tx.CalTxId())
+	if par.Logs == nil {
+		par.Logs = []*model2.Log{}
+	}
 	return nil
 }
 
+// GetVerifiersFunc returns the verifiers who vote for the block at the number
+type GetVerifiersFunc func(number uint64) []common.Address
+
+// VerifierChainReader is the chain read by VerifiersByNumber
+type VerifierChainReader interface {
+	CurrentBlock() model.AbstractBlock
+	GetBlockByNumber(number uint64) model.AbstractBlock
+	GetSlot(block model.AbstractBlock) *uint64
+	IsChangePoint(block model.AbstractBlock, isProcessPackageBlock bool) bool
+	GetVerifiers(slot uint64) []common.Address
+}
+
+// VerifiersByNumber reads the verifiers of the block at the number from the chain, the evidence txs are
+// validated and processed with it so that both agree on the verifiers of the votes
+func VerifiersByNumber(chain VerifierChainReader) GetVerifiersFunc {
+	return func(number uint64) []common.Address {
+		if number == 0 || number > chain.CurrentBlock().Number()+1 {
+			log.Info("GetVerifiersByNumber failed, can't get future verifiers", "number", number)
+			return nil
+		}
+		preBlock := chain.GetBlockByNumber(number - 1)
+		if preBlock == nil {
+			return nil
+		}
+		slot := chain.GetSlot(preBlock)
+		if slot == nil {
+			return nil
+		}
+		if chain.IsChangePoint(preBlock, false) {
+			return chain.GetVerifiers(*slot + 1)
+		}
+		return chain.GetVerifiers(*slot)
+	}
+}
+
 type TxProcessConfig struct {
-	Tx       model.AbstractTransaction
-	Header   model.AbstractHeader
-	GetHash  vm.GetHashFunc
-	GasLimit *uint64
-	GasUsed  *uint64
-	TxFee    *big.Int
+	Tx           model.AbstractTransaction
+	Header       model.AbstractHeader
+	GetHash      vm.GetHashFunc
+	GetVerifiers GetVerifiersFunc
+	GasLimit     *uint64
+	GasUsed      *uint64
+	TxFee        *big.Int
+	// Tracer captures the contract calls of the transaction if it isn't nil
+	Tracer vm.Tracer
+}
+
+// isEarth returns whether the transaction is processed after the Earth fork
+func (conf *TxProcessConfig) isEarth() bool {
+	return conf.Header != nil && chain_config.GetChainConfig().IsEarth(conf.Header.GetNumber())
+}
+
+func (conf *TxProcessConfig) isSaturn() bool {
+	return conf.Header != nil && chain_config.GetChainConfig().IsSaturn(conf.Header.GetNumber())
+}
+
+func (conf *TxProcessConfig) isEris() bool {
+	return conf.Header != nil && chain_config.GetChainConfig().IsEris(conf.Header.GetNumber())
+}
+
+// intrinsicGas is the gas used by the transaction which isn't a contract transaction,
+// the signature recoveries of the multisig witness are charged after the Eris fork
+func (conf *TxProcessConfig) intrinsicGas() (uint64, error) {
+	gas, err := model.IntrinsicGas(conf.Tx.ExtraData(), false, false, conf.isEarth())
+	if err != nil {
+		return 0, err
+	}
+	if conf.isEris() {
+		gas += model.MultiSigRecoverGas(conf.Tx)
+	}
+	return gas, nil
 }
 
 func (state *AccountStateDB) ProcessTxNew(conf *TxProcessConfig) (err error) {
+	// the multisig accounts and the multisig witness are valid after the Eris fork
+	if !conf.isEris() && (conf.Tx.GetType() == common.AddressTypeMultiSig || conf.Tx.MultiSig() != nil) {
+		return g_error.ErrMultiSigNotActivated
+	}
+
 	// All transactions must be done with processBasicTx, and transactionBasicTx only deducts transaction fees. Amount is selectively handled in each type of transaction
 	if conf.Tx.GetType() != common.AddressTypeContractCall && conf.Tx.GetType() != common.AddressTypeContractCreate {
 		err = state.processBasicTx(conf)
@@ -1261,19 +1451,21 @@ func (state *AccountStateDB) ProcessTxNew(conf *TxProcessConfig) (err error) {
 	case common.AddressTypeNormal:
 		err = state.processNormalTx(conf.Tx)
 	case common.AddressTypeCross:
-		err = state.processCrossTx(conf.Tx)
+		err = state.processCrossTx(conf.Tx, conf.Header.GetNumber())
 	case common.AddressTypeERC20:
 		err = state.processERC20Tx(conf.Tx, conf.Header.GetNumber())
 	case common.AddressTypeStake:
-		err = state.processStakeTx(conf.Tx)
+		err = state.processStakeTx(conf.Tx, conf.Header.GetNumber())
 	case common.AddressTypeCancel:
 		err = state.processCancelTx(conf.Tx, conf.Header.GetNumber())
 	case common.AddressTypeUnStake:
 		err = state.processUnStakeTx(conf.Tx)
 	case common.AddressTypeEvidence:
-		err = state.processEvidenceTx(conf.Tx)
+		err = state.processEvidenceTx(conf.Tx, conf.Header.GetNumber(), conf.GetVerifiers)
 	case common.AddressTypeEarlyReward:
 		err = state.processEarlyTokenTx(conf.Tx, conf.Header.GetNumber())
+	case common.AddressTypeMultiSig:
+		err = state.processMultiSigTx(conf.Tx)
 	default:
 		err = g_error.ErrUnknownTxType
 	}
@@ -1281,7 +1473,7 @@ func (state *AccountStateDB) ProcessTxNew(conf *TxProcessConfig) (err error) {
 		return
 	}
 
-	err = state.setTxReceiptPar(conf.Tx, &par, conf.GasUsed)
+	err = state.setTxReceiptPar(conf, &par)
 	if err != nil {
 		return
 	}
@@ -1306,6 +1498,11 @@ func (state *AccountStateDB) processBasicTx(conf *TxProcessConfig) (err error) {
 	if empty := state.IsEmptyAccount(sender); empty {
 		return g_error.ErrSenderNotExist
 	}
+	if sender.GetAddressType() == common.AddressTypeMultiSig {
+		if err = state.ValidMultiSigSender(conf.Tx); err != nil {
+			return
+		}
+	}
 
 	curNonce, _ := state.GetNonce(sender)
 	if conf.Tx.Nonce() != curNonce {
@@ -1316,7 +1513,7 @@ func (state *AccountStateDB) processBasicTx(conf *TxProcessConfig) (err error) {
 		return g_error.ErrReceiverNotExist
 	}*/
 	//calculated gasUsed and sub the fee
-	gasUsed, err := model.IntrinsicGas(conf.Tx.ExtraData(), false, false)
+	gasUsed, err := conf.intrinsicGas()
 	if err != nil {
 		return err
 	}
@@ -1355,17 +1552,24 @@ func (state *AccountStateDB) processNormalTx(tx model.AbstractTransaction) (err
 	return
 }
 
-func (state *AccountStateDB) processCrossTx(tx model.AbstractTransaction) (err error) {
-	// TODO:
-	return g_error.ErrTxNotSupported
-}
-
 func (state *AccountStateDB) processERC20Tx(tx model.AbstractTransaction, blockHeight uint64) (err error) {
 	cProcessor := contract.NewProcessor(state, blockHeight)
 	err = cProcessor.Process(tx)
 	if err != nil {
 		return
 	}
+
+	// the transfers and approvals of the built-in tokens are recorded in the receipt after the Ceres fork
+	if !chain_config.GetChainConfig().IsCeres(blockHeight) {
+		return
+	}
+	for _, l := range cProcessor.Logs() {
+		l.TxHash = tx.CalTxId()
+		l.BlockNumber = blockHeight
+		if err = state.AddLog(l); err != nil {
+			return
+		}
+	}
 	return
 }
 
@@ -19,7 +19,12 @@ package state_processor
 import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/contract"
+	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/stretchr/testify/assert"
 	"math/big"
@@ -185,6 +190,44 @@ func TestAccountStateDB_Snapshot(t *testing.T) {
 	assert.False(t, processor.IsEmptyAccount(aliceAddr))
 }
 
+func TestAccountStateDB_ClearContract(t *testing.T) {
+	processor := createStateProcessor(t)
+	assert.Equal(t, g_error.ErrAccountNotExist, processor.ClearContract(charlieAddr))
+
+	_, err := processor.newContractAccount(charlieAddr)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.SetCode(charlieAddr, []byte("code")))
+	assert.NoError(t, processor.SetAbi(charlieAddr, []byte("abi")))
+	assert.NoError(t, processor.SetData(charlieAddr, "committed", []byte("1")))
+	_, err = processor.IntermediateRoot()
+	assert.NoError(t, err)
+	dataRoot, err := processor.GetDataRoot(charlieAddr)
+	assert.NoError(t, err)
+	assert.NotEqual(t, common.Hash{}, dataRoot)
+	assert.NoError(t, processor.SetData(charlieAddr, "pending", []byte("2")))
+
+	id := processor.Snapshot()
+	assert.NoError(t, processor.ClearContract(charlieAddr))
+	code, err := processor.GetCode(charlieAddr)
+	assert.NoError(t, err)
+	assert.Empty(t, code)
+	abi, err := processor.GetAbi(charlieAddr)
+	assert.NoError(t, err)
+	assert.Empty(t, abi)
+	assert.Empty(t, processor.GetData(charlieAddr, "committed"))
+	assert.Empty(t, processor.GetData(charlieAddr, "pending"))
+
+	processor.RevertToSnapshot(id)
+	code, err = processor.GetCode(charlieAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("code"), code)
+	abi, err = processor.GetAbi(charlieAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("abi"), abi)
+	assert.Equal(t, []byte("1"), processor.GetData(charlieAddr, "committed"))
+	assert.Equal(t, []byte("2"), processor.GetData(charlieAddr, "pending"))
+}
+
 func TestMakeGenesisAccountStateProcessor(t *testing.T) {
 	db := ethdb.NewMemDatabase()
 	storage := NewStateStorageWithCache(db)
@@ -255,7 +298,7 @@ func TestAccountStateDB_ProcessTxNew(t *testing.T) {
 	txType = common.TxType(common.AddressTypeEvidence)
 	nonce = uint64(6)
 	err = processor.ProcessTxNew(config)
-	assert.NoError(t, err)
+	assert.Error(t, err)
 
 	txType = common.TxType(common.AddressTypeEarlyReward)
 	nonce = uint64(7)
@@ -463,3 +506,47 @@ func TestGetContractAddrAndKey(t *testing.T) {
 	assert.Equal(t, common.Address{}, address)
 	assert.Nil(t, key)
 }
+
+func TestAccountStateDB_processERC20TxLogs(t *testing.T) {
+	db, root := CreateTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+
+	conf := chain_config.GetChainConfig()
+	ceres := conf.CeresBlock
+	conf.CeresBlock = big.NewInt(10)
+	defer func() { conf.CeresBlock = ceres }()
+
+	key1, _ := createKey()
+	owner := cs_crypto.GetNormalAddress(key1.PublicKey)
+	tokenAddr := common.HexToAddress("0x00100000FA42f7315cD04D6774E58B54e92603e96d84")
+	erc20Tx := func(nonce uint64, action, params string) model.AbstractTransaction {
+		extraData := util.StringifyJsonToBytes(contract.ExtraDataForContract{Action: action, Params: params})
+		tx := model.NewTransaction(nonce, tokenAddr, big.NewInt(0), g_testData.TestGasPrice, g_testData.TestGasLimit, extraData)
+		signedTx, _ := tx.SignTx(key1, model.NewSigner(big.NewInt(1)))
+		return signedTx
+	}
+
+	// no logs before the fork
+	createTx := erc20Tx(0, "create", `{"owner":"`+owner.Hex()+`","token_name":"EOS","token_decimals":18,"token_symbol":"EOS","token_total_supply":"0x64"}`)
+	assert.NoError(t, processor.processERC20Tx(createTx, 9))
+	assert.Len(t, processor.GetLogs(createTx.CalTxId()), 0)
+
+	transferTx := erc20Tx(1, "Transfer", `["`+bobAddr.Hex()+`","0x3"]`)
+	assert.NoError(t, processor.processERC20Tx(transferTx, 10))
+	logs := processor.GetLogs(transferTx.CalTxId())
+	assert.Len(t, logs, 1)
+	assert.Equal(t, tokenAddr, logs[0].Address)
+	assert.Equal(t, transferTx.CalTxId(), logs[0].TxHash)
+	assert.Equal(t, uint64(10), logs[0].BlockNumber)
+	from, to, value, ok := contract.ParseERC20TransferLog(logs[0])
+	assert.True(t, ok)
+	assert.Equal(t, owner, from)
+	assert.Equal(t, bobAddr, to)
+	assert.Equal(t, big.NewInt(3), value)
+
+	// the failed call records nothing
+	failedTx := erc20Tx(2, "Transfer", `["`+bobAddr.Hex()+`","0x100"]`)
+	assert.Error(t, processor.processERC20Tx(failedTx, 10))
+	assert.Len(t, processor.GetLogs(failedTx.CalTxId()), 0)
+}
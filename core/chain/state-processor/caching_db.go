@@ -46,6 +46,25 @@ func NewStateStorageWithCache(db ethdb.Database) StateStorage {
 	}
 }
 
+// NewStateStorageWithRefCount creates the state storage of the pruning mode, the committed trie
+// nodes are reference counted so the old states can be released.
+func NewStateStorageWithRefCount(db ethdb.Database) StateStorage {
+	csc, _ := lru.New(codeSizeCacheSize)
+	return &cachingDB{
+		db:            trie.NewDatabaseWithRefCount(db),
+		codeSizeCache: csc,
+	}
+}
+
+// newContractStorage creates the storage of the contract data tries, the references are counted
+// as the state storage, or the contract data of a released state is never deleted.
+func newContractStorage(storage StateStorage) StateStorage {
+	if c, ok := storage.(*cachingDB); ok && c.db.RefCounted() {
+		return NewStateStorageWithRefCount(storage.DiskDB())
+	}
+	return NewStateStorageWithCache(storage.DiskDB())
+}
+
 type cachingDB struct {
 	db            *trie.Database
 	mu            sync.Mutex
@@ -21,6 +21,7 @@ import (
 	trie2 "github.com/dipperin/dipperin-core/third-party/trie"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
 )
 
@@ -108,3 +109,41 @@ func TestCachedTrie_Commit(t *testing.T) {
 	}
 	assert.Len(t, cache.pastTries, maxPastTries)
 }
+
+func TestNewStateStorageWithRefCount(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	storage := NewStateStorageWithRefCount(db)
+	assert.True(t, storage.TrieDB().RefCounted())
+	assert.False(t, NewStateStorageWithCache(db).TrieDB().RefCounted())
+
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+	assert.True(t, processor.contractTrieCache.TrieDB().RefCounted())
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.SetData(aliceAddr, "tkey", []byte("value")))
+	root1, err := processor.Commit()
+	assert.NoError(t, err)
+	assert.NoError(t, storage.TrieDB().RetainRoot(root1))
+
+	processor, err = NewAccountStateDB(root1, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(2000)))
+	root2, err := processor.Commit()
+	assert.NoError(t, err)
+	assert.NoError(t, storage.TrieDB().RetainRoot(root2))
+
+	// the contract data is shared by the two states
+	assert.NoError(t, storage.TrieDB().ReleaseRoot(root1))
+	processor, err = NewAccountStateDB(root2, storage)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), processor.GetData(aliceAddr, "tkey"))
+	dataRoot, err := processor.GetDataRoot(aliceAddr)
+	assert.NoError(t, err)
+
+	// the past tries are cached in the storage
+	assert.NoError(t, storage.TrieDB().ReleaseRoot(root2))
+	_, err = NewAccountStateDB(root2, NewStateStorageWithRefCount(db))
+	assert.Error(t, err)
+	_, err = storage.TrieDB().Node(dataRoot)
+	assert.Error(t, err)
+}
@@ -22,7 +22,14 @@ func (state *AccountStateDB) ProcessContract(conf *TxProcessConfig, create bool)
 		return model.ReceiptPara{}, err
 	}
 	dvm := vm.NewVM(context, fullState, vm.DEFAULT_VM_CONFIG)
-	_, usedGas, failed, fee, err := ApplyMessage(dvm, &msg, conf.GasLimit)
+	dvm.SetTracer(conf.Tracer)
+	ret, usedGas, failed, fee, err := ApplyMessage(dvm, &msg, conf.GasLimit)
+	// the failed contract transactions are kept in the block after the Saturn fork
+	var vmErr error
+	if err != nil && failed && conf.isSaturn() {
+		log.Info("AccountStateDB#ProcessContract contract failed", "err", err)
+		vmErr, err = err, nil
+	}
 	if err != nil {
 		log.Error("AccountStateDB#ProcessContract", "ApplyMessage err", err)
 		return model.ReceiptPara{}, err
@@ -43,5 +50,7 @@ func (state *AccountStateDB) ProcessContract(conf *TxProcessConfig, create bool)
 		HandlerResult:     failed,
 		CumulativeGasUsed: *conf.GasUsed,
 		Logs:              fullState.GetLogs(conf.Tx.CalTxId()),
+		VmErr:             vmErr,
+		Ret:               ret,
 	}, nil
 }
@@ -4,9 +4,12 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/core/vm/common/utils"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
@@ -556,3 +559,59 @@ func getSignedTx(t *testing.T, priKey *ecdsa.PrivateKey, tx *model.Transaction,
 	assert.NoError(t, err)
 	return signCreateTx
 }
+
+func TestAccountStateDB_ProcessContract_Revert(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	defer func(saturn *big.Int) { conf.SaturnBlock = saturn }(conf.SaturnBlock)
+
+	contractAddr := cs_crypto.CreateContractAddress(bobAddr, 0)
+	code, abi := g_testData.GetCodeAbi(g_testData.GetWASMPath("revert", g_testData.CoreVmTestData), g_testData.GetAbiPath("revert", g_testData.CoreVmTestData))
+	process := func() (*AccountStateDB, *model.Transaction, error) {
+		processor := createStateProcessor(t)
+		fullState := NewFullState(processor)
+		fullState.CreateAccount(contractAddr)
+		fullState.SetCode(contractAddr, code)
+		fullState.SetAbi(contractAddr, abi)
+
+		tx := callContractTx(&contractAddr, "fail", nil, 0)
+		block := CreateBlock(1, common.Hash{}, []*model.Transaction{tx}, 5*testGasLimit)
+		gasLimit := block.GasLimit()
+		gasUsed := block.GasUsed()
+		err := processor.ProcessTxNew(&TxProcessConfig{
+			Tx:       tx,
+			Header:   block.Header(),
+			GetHash:  getTestHashFunc(),
+			GasLimit: &gasLimit,
+			GasUsed:  &gasUsed,
+			TxFee:    big.NewInt(0),
+		})
+		return processor, tx, err
+	}
+
+	// the failed transaction is invalid before the fork
+	conf.SaturnBlock = nil
+	_, _, err := process()
+	assert.Error(t, err)
+
+	conf.SaturnBlock = big.NewInt(1)
+	processor, tx, err := process()
+	assert.NoError(t, err)
+
+	receipt := tx.GetReceipt()
+	assert.Equal(t, model2.ReceiptStatusFailed, receipt.Status)
+	assert.Equal(t, g_error.ErrExecutionReverted.Error(), receipt.Error)
+	assert.Equal(t, "bad", receipt.RevertReason)
+
+	// the value is reverted and only the used gas is paid
+	balance, err := processor.GetBalance(contractAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), balance)
+	nonce, err := processor.GetNonce(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+	assert.True(t, receipt.CumulativeGasUsed < testGasLimit)
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.CumulativeGasUsed), testGasPrice)
+	balance, err = processor.GetBalance(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, new(big.Int).Sub(big.NewInt(9e6), fee), balance)
+}
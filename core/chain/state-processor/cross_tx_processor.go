@@ -0,0 +1,275 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+)
+
+type CrossTxStep int
+
+// the three steps of a hash time lock transfer
+const (
+	CrossTxLock CrossTxStep = iota
+	CrossTxClaim
+	CrossTxRefund
+)
+
+func (step CrossTxStep) String() string {
+	switch step {
+	case CrossTxLock:
+		return "Lock"
+	case CrossTxClaim:
+		return "Claim"
+	case CrossTxRefund:
+		return "Refund"
+	default:
+		return "Unknown"
+	}
+}
+
+// CrossTxLogData is the data field of the log that each cross chain tx leaves in its receipt
+type CrossTxLogData struct {
+	Alice    common.Address
+	Bob      common.Address
+	Amount   *big.Int
+	TimeLock *big.Int
+}
+
+// GetCrossTxStep judge the step of a cross chain tx by its fields:
+// claim tx carries the hash key, lock tx carries the hash lock, otherwise it's a refund tx
+func GetCrossTxStep(tx model.AbstractTransaction) CrossTxStep {
+	if len(tx.HashKey()) != 0 {
+		return CrossTxClaim
+	}
+	if tx.HashLock() != nil && !tx.HashLock().IsEmpty() {
+		return CrossTxLock
+	}
+	return CrossTxRefund
+}
+
+// getCrossTxParties returns alice (who locks the money) and bob (who can claim it) of the tx
+func getCrossTxParties(tx model.AbstractTransaction, step CrossTxStep) (alice common.Address, bob common.Address, err error) {
+	sender, err := tx.Sender(nil)
+	if err != nil {
+		return
+	}
+	other := common.BytesToAddress(tx.ExtraData())
+	if step == CrossTxClaim {
+		alice, bob = other, sender
+	} else {
+		alice, bob = sender, other
+	}
+	if !cs_crypto.GetLockAddress(alice, bob).IsEqual(*tx.To()) {
+		return common.Address{}, common.Address{}, g_error.ErrLockAddressNotMatch
+	}
+	return
+}
+
+/*
+Check cross chain tx with the lock address state, num is processing block num
+Lock: the lock address has no locked money, hash lock isn't empty and the time lock is in the future
+Claim: the hash of the hash key matches the hash lock and the time lock hasn't expired
+Refund: the time lock has expired
+*/
+func (state *AccountStateDB) ValidCrossTx(tx model.AbstractTransaction, num uint64) error {
+	_, _, _, err := state.checkCrossTx(tx, num)
+	return err
+}
+
+func (state *AccountStateDB) checkCrossTx(tx model.AbstractTransaction, num uint64) (step CrossTxStep, alice common.Address, bob common.Address, err error) {
+	receiver := *(tx.To())
+	if receiver.GetAddressType() != common.AddressTypeCross {
+		err = g_error.ErrTxTypeNotMatch
+		return
+	}
+
+	step = GetCrossTxStep(tx)
+	if alice, bob, err = getCrossTxParties(tx, step); err != nil {
+		return
+	}
+
+	locked := big.NewInt(0)
+	if !state.IsEmptyAccount(receiver) {
+		if locked, err = state.GetBalance(receiver); err != nil {
+			return
+		}
+	}
+
+	if step == CrossTxLock {
+		if locked.Sign() > 0 {
+			err = g_error.ErrLockAlreadyExist
+			return
+		}
+		if tx.Amount().Sign() <= 0 {
+			err = g_error.ErrInvalidCrossTxAmount
+			return
+		}
+		if tx.TimeLock().Cmp(new(big.Int).SetUint64(num)) <= 0 {
+			err = g_error.ErrInvalidTimeLock
+		}
+		return
+	}
+
+	if locked.Sign() == 0 {
+		err = g_error.ErrLockNotExist
+		return
+	}
+	timeLock, err := state.GetTimeLock(receiver)
+	if err != nil {
+		return
+	}
+	expired := timeLock.Cmp(new(big.Int).SetUint64(num)) <= 0
+	if step == CrossTxRefund {
+		if !expired {
+			err = g_error.ErrTimeLockNotExpired
+		}
+		return
+	}
+
+	if expired {
+		err = g_error.ErrTimeLockExpired
+		return
+	}
+	hashLock, err := state.GetHashLock(receiver)
+	if err != nil {
+		return
+	}
+	if !model.CalHashLock(tx.HashKey()).IsEqual(hashLock) {
+		err = g_error.ErrHashKeyNotMatch
+	}
+	return
+}
+
+/*
+Process cross chain Tx, num is processing block num
+Lock: move amount from alice to the lock address and save hash lock and time lock
+Claim: move all locked money to bob
+Refund: move all locked money back to alice
+*/
+func (state *AccountStateDB) processCrossTx(tx model.AbstractTransaction, num uint64) (err error) {
+	// the cross chain txs are supported after the Makemake fork
+	if !chain_config.GetChainConfig().IsMakemake(num) {
+		return g_error.ErrTxNotSupported
+	}
+
+	//Check
+	step, alice, bob, err := state.checkCrossTx(tx, num)
+	if err != nil {
+		return
+	}
+
+	//Process
+	lockAddress := *(tx.To())
+	var amount, timeLock *big.Int
+	var hashLock common.Hash
+	if step == CrossTxLock {
+		amount, hashLock, timeLock = tx.Amount(), *tx.HashLock(), tx.TimeLock()
+		err = state.lockMoney(alice, lockAddress, amount, hashLock, timeLock)
+	} else {
+		// the locks are cleared after unlocking, keep them for the log
+		if hashLock, err = state.GetHashLock(lockAddress); err != nil {
+			return
+		}
+		if timeLock, err = state.GetTimeLock(lockAddress); err != nil {
+			return
+		}
+		to := alice
+		if step == CrossTxClaim {
+			to = bob
+		}
+		amount, err = state.unlockMoney(lockAddress, to)
+	}
+	if err != nil {
+		return
+	}
+
+	err = state.addCrossTxLog(tx, step, hashLock, CrossTxLogData{Alice: alice, Bob: bob, Amount: amount, TimeLock: timeLock}, num)
+	if err != nil {
+		return
+	}
+	log.Info("success process a cross chain transaction", "step", step.String(), "Tx hash", tx.CalTxId().Hex(), "amount", amount)
+	return
+}
+
+func (state *AccountStateDB) lockMoney(from common.Address, lockAddress common.Address, amount *big.Int, hashLock common.Hash, timeLock *big.Int) error {
+	if state.IsEmptyAccount(lockAddress) {
+		if err := state.NewAccountState(lockAddress); err != nil {
+			return err
+		}
+	}
+	if err := state.SubBalance(from, amount); err != nil {
+		return err
+	}
+	if err := state.AddBalance(lockAddress, amount); err != nil {
+		return err
+	}
+	if err := state.SetHashLock(lockAddress, hashLock); err != nil {
+		return err
+	}
+	return state.SetTimeLock(lockAddress, timeLock)
+}
+
+// unlockMoney move all locked money to the address and clear the locks, so the lock address can be used again
+func (state *AccountStateDB) unlockMoney(lockAddress common.Address, to common.Address) (*big.Int, error) {
+	amount, err := state.GetBalance(lockAddress)
+	if err != nil {
+		return nil, err
+	}
+	if state.IsEmptyAccount(to) {
+		if err = state.NewAccountState(to); err != nil {
+			return nil, err
+		}
+	}
+	if err = state.SubBalance(lockAddress, amount); err != nil {
+		return nil, err
+	}
+	if err = state.AddBalance(to, amount); err != nil {
+		return nil, err
+	}
+	if err = state.SetHashLock(lockAddress, common.Hash{}); err != nil {
+		return nil, err
+	}
+	if err = state.SetTimeLock(lockAddress, big.NewInt(0)); err != nil {
+		return nil, err
+	}
+	return amount, nil
+}
+
+// the topics of the log are the step name hash and the hash lock, so all steps of a swap can be found by GetLogs
+func (state *AccountStateDB) addCrossTxLog(tx model.AbstractTransaction, step CrossTxStep, hashLock common.Hash, logData CrossTxLogData, num uint64) error {
+	data, err := rlp.EncodeToBytes(logData)
+	if err != nil {
+		return err
+	}
+	return state.AddLog(&model2.Log{
+		Address:     *(tx.To()),
+		Topics:      []common.Hash{cs_crypto.Keccak256Hash([]byte(step.String())), hashLock},
+		TopicName:   step.String(),
+		Data:        data,
+		BlockNumber: num,
+		TxHash:      tx.CalTxId(),
+	})
+}
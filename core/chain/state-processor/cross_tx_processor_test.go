@@ -0,0 +1,197 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+var testHashKey = []byte("cross chain hash key")
+
+func createCrossTestState(t *testing.T) (*AccountStateDB, common.Address, common.Address) {
+	db, root := CreateTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+
+	key1, key2 := createKey()
+	alice := cs_crypto.GetNormalAddress(key1.PublicKey)
+	bob := cs_crypto.GetNormalAddress(key2.PublicKey)
+	if processor.IsEmptyAccount(alice) {
+		assert.NoError(t, processor.NewAccountState(alice))
+	}
+	if processor.IsEmptyAccount(bob) {
+		assert.NoError(t, processor.NewAccountState(bob))
+	}
+	assert.NoError(t, processor.AddBalance(alice, big.NewInt(1e10)))
+	return processor, alice, bob
+}
+
+func createLockTx(nonce uint64, timeLock int64, amount int64, bob common.Address) *model.Transaction {
+	key1, _ := createKey()
+	alice := cs_crypto.GetNormalAddress(key1.PublicKey)
+	tx := model.CreateRawLockTx(nonce, model.CalHashLock(testHashKey), big.NewInt(timeLock), big.NewInt(amount), testGasPrice, testGasLimit, alice, bob)
+	tx.SignTx(key1, model.NewSigner(big.NewInt(1)))
+	return tx
+}
+
+func createClaimTx(nonce uint64, hashKey []byte, alice common.Address) *model.Transaction {
+	_, key2 := createKey()
+	bob := cs_crypto.GetNormalAddress(key2.PublicKey)
+	tx := model.CreateRawClaimTx(nonce, hashKey, big.NewInt(0), testGasPrice, testGasLimit, alice, bob)
+	tx.SignTx(key2, model.NewSigner(big.NewInt(1)))
+	return tx
+}
+
+func createRefundTx(nonce uint64, bob common.Address) *model.Transaction {
+	key1, _ := createKey()
+	alice := cs_crypto.GetNormalAddress(key1.PublicKey)
+	tx := model.CreateRawRefundTx(nonce, big.NewInt(0), testGasPrice, testGasLimit, alice, bob)
+	tx.SignTx(key1, model.NewSigner(big.NewInt(1)))
+	return tx
+}
+
+func TestCrossTxStep_String(t *testing.T) {
+	assert.Equal(t, "Lock", CrossTxLock.String())
+	assert.Equal(t, "Claim", CrossTxClaim.String())
+	assert.Equal(t, "Refund", CrossTxRefund.String())
+	assert.Equal(t, "Unknown", CrossTxStep(10).String())
+}
+
+func TestGetCrossTxStep(t *testing.T) {
+	assert.Equal(t, CrossTxLock, GetCrossTxStep(createLockTx(0, 10, 100, bobAddr)))
+	assert.Equal(t, CrossTxClaim, GetCrossTxStep(createClaimTx(0, testHashKey, aliceAddr)))
+	assert.Equal(t, CrossTxRefund, GetCrossTxStep(createRefundTx(0, bobAddr)))
+}
+
+func TestAccountStateDB_processCrossTx_Claim(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	makemake := conf.MakemakeBlock
+	conf.MakemakeBlock = big.NewInt(0)
+	defer func() { conf.MakemakeBlock = makemake }()
+
+	processor, alice, bob := createCrossTestState(t)
+	lockAddress := cs_crypto.GetLockAddress(alice, bob)
+
+	lockTx := createLockTx(0, 10, 1000, bob)
+	assert.NoError(t, processor.ValidCrossTx(lockTx, 1))
+	assert.NoError(t, processor.processCrossTx(lockTx, 1))
+
+	balance, _ := processor.GetBalance(lockAddress)
+	assert.Equal(t, big.NewInt(1000), balance)
+	hashLock, _ := processor.GetHashLock(lockAddress)
+	assert.Equal(t, model.CalHashLock(testHashKey), hashLock)
+	timeLock, _ := processor.GetTimeLock(lockAddress)
+	assert.Equal(t, big.NewInt(10), timeLock)
+
+	// the lock address can't be locked twice
+	assert.Equal(t, g_error.ErrLockAlreadyExist, processor.ValidCrossTx(createLockTx(1, 10, 1000, bob), 1))
+	// alice can't refund before the time lock expired
+	assert.Equal(t, g_error.ErrTimeLockNotExpired, processor.ValidCrossTx(createRefundTx(1, bob), 5))
+	// bob can't claim with a wrong key or after the time lock expired
+	assert.Equal(t, g_error.ErrHashKeyNotMatch, processor.ValidCrossTx(createClaimTx(0, []byte("wrong key"), alice), 5))
+	assert.Equal(t, g_error.ErrTimeLockExpired, processor.ValidCrossTx(createClaimTx(0, testHashKey, alice), 10))
+
+	claimTx := createClaimTx(0, testHashKey, alice)
+	bobBalance, _ := processor.GetBalance(bob)
+	assert.NoError(t, processor.processCrossTx(claimTx, 5))
+
+	balance, _ = processor.GetBalance(bob)
+	assert.Equal(t, new(big.Int).Add(bobBalance, big.NewInt(1000)), balance)
+	balance, _ = processor.GetBalance(lockAddress)
+	assert.Equal(t, big.NewInt(0), balance)
+	hashLock, _ = processor.GetHashLock(lockAddress)
+	assert.Equal(t, common.Hash{}, hashLock)
+
+	logs := processor.GetLogs(claimTx.CalTxId())
+	assert.Len(t, logs, 1)
+	assert.Equal(t, lockAddress, logs[0].Address)
+	assert.Equal(t, []common.Hash{cs_crypto.Keccak256Hash([]byte("Claim")), model.CalHashLock(testHashKey)}, logs[0].Topics)
+	var logData CrossTxLogData
+	assert.NoError(t, rlp.DecodeBytes(logs[0].Data, &logData))
+	assert.Equal(t, CrossTxLogData{Alice: alice, Bob: bob, Amount: big.NewInt(1000), TimeLock: big.NewInt(10)}, logData)
+
+	// nothing left to claim
+	assert.Equal(t, g_error.ErrLockNotExist, processor.ValidCrossTx(createClaimTx(1, testHashKey, alice), 5))
+}
+
+func TestAccountStateDB_processCrossTx_Refund(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	makemake := conf.MakemakeBlock
+	conf.MakemakeBlock = big.NewInt(0)
+	defer func() { conf.MakemakeBlock = makemake }()
+
+	processor, alice, bob := createCrossTestState(t)
+	lockAddress := cs_crypto.GetLockAddress(alice, bob)
+
+	assert.NoError(t, processor.processCrossTx(createLockTx(0, 10, 1000, bob), 1))
+	aliceBalance, _ := processor.GetBalance(alice)
+
+	refundTx := createRefundTx(1, bob)
+	assert.NoError(t, processor.processCrossTx(refundTx, 10))
+	balance, _ := processor.GetBalance(alice)
+	assert.Equal(t, new(big.Int).Add(aliceBalance, big.NewInt(1000)), balance)
+	balance, _ = processor.GetBalance(lockAddress)
+	assert.Equal(t, big.NewInt(0), balance)
+	assert.Len(t, processor.GetLogs(refundTx.CalTxId()), 1)
+
+	// the lock address can be used again after refund
+	assert.NoError(t, processor.ValidCrossTx(createLockTx(2, 20, 1000, bob), 11))
+}
+
+func TestAccountStateDB_ValidCrossTx_Error(t *testing.T) {
+	processor, _, bob := createCrossTestState(t)
+
+	// normal tx
+	key1, _ := createKey()
+	tx := model.NewTransaction(0, bob, big.NewInt(10), testGasPrice, testGasLimit, nil)
+	tx.SignTx(key1, model.NewSigner(big.NewInt(1)))
+	assert.Equal(t, g_error.ErrTxTypeNotMatch, processor.ValidCrossTx(tx, 1))
+
+	// the lock address isn't the one of alice and bob
+	_, key2 := createKey()
+	tx = model.CreateRawLockTx(0, model.CalHashLock(testHashKey), big.NewInt(10), big.NewInt(1000), testGasPrice, testGasLimit, aliceAddr, charlieAddr)
+	tx.SignTx(key2, model.NewSigner(big.NewInt(1)))
+	assert.Equal(t, g_error.ErrLockAddressNotMatch, processor.ValidCrossTx(tx, 1))
+
+	assert.Equal(t, g_error.ErrInvalidCrossTxAmount, processor.ValidCrossTx(createLockTx(0, 10, 0, bob), 1))
+	assert.Equal(t, g_error.ErrInvalidTimeLock, processor.ValidCrossTx(createLockTx(0, 1, 1000, bob), 1))
+	assert.Equal(t, g_error.ErrLockNotExist, processor.ValidCrossTx(createRefundTx(0, bob), 1))
+}
+
+func TestAccountStateDB_processCrossTx_Fork(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	makemake := conf.MakemakeBlock
+	conf.MakemakeBlock = big.NewInt(2)
+	defer func() { conf.MakemakeBlock = makemake }()
+
+	processor, alice, bob := createCrossTestState(t)
+	lockTx := createLockTx(0, 10, 1000, bob)
+	assert.Equal(t, g_error.ErrTxNotSupported, processor.processCrossTx(lockTx, 1))
+	assert.True(t, processor.IsEmptyAccount(cs_crypto.GetLockAddress(alice, bob)))
+
+	assert.NoError(t, processor.processCrossTx(lockTx, 2))
+	balance, _ := processor.GetBalance(cs_crypto.GetLockAddress(alice, bob))
+	assert.Equal(t, big.NewInt(1000), balance)
+}
@@ -91,4 +91,6 @@ type Message interface {
 	Nonce() uint64
 	CheckNonce() bool
 	Data() []byte
+	// the gas of recovering the signers of the multisig witness
+	RecoverGas() uint64
 }
@@ -0,0 +1,108 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+)
+
+/*
+Check the tx transferring money to a multisig account
+If the account doesn't exist, the extra data should carry the signer set and the threshold of the account address,
+otherwise the account should have been created as a multisig account
+*/
+func (state *AccountStateDB) ValidMultiSigTx(tx model.AbstractTransaction) error {
+	_, err := state.checkMultiSigTx(tx)
+	return err
+}
+
+// checkMultiSigTx returns the info of the multisig account to create, it's nil if the account exists
+func (state *AccountStateDB) checkMultiSigTx(tx model.AbstractTransaction) (*model.MultiSigInfo, error) {
+	receiver := *(tx.To())
+	if receiver.GetAddressType() != common.AddressTypeMultiSig {
+		return nil, g_error.ErrTxTypeNotMatch
+	}
+
+	if !state.IsEmptyAccount(receiver) {
+		_, err := state.GetMultiSig(receiver)
+		return nil, err
+	}
+
+	info, err := model.DecodeMultiSigInfo(tx.ExtraData())
+	if err != nil {
+		return nil, err
+	}
+	if !info.Address().IsEqual(receiver) {
+		return nil, g_error.ErrMultiSigAddressNotMatch
+	}
+	return info, nil
+}
+
+/*
+Check the sender of the tx sent from a multisig account
+The signer set of the witness should be the one saved in the account, and at least threshold signers signed the tx
+*/
+func (state *AccountStateDB) ValidMultiSigSender(tx model.AbstractTransaction) error {
+	sender, err := tx.Sender(nil)
+	if err != nil {
+		return err
+	}
+	info, err := state.GetMultiSig(sender)
+	if err != nil {
+		return err
+	}
+
+	w := tx.MultiSig()
+	if w == nil {
+		return g_error.ErrNotMultiSigTx
+	}
+	if !info.Equal(&w.Info) {
+		return g_error.ErrMultiSigInfoNotMatch
+	}
+	if uint64(len(w.Sigs)) < info.Threshold {
+		return g_error.ErrMultiSigNotEnoughSigs
+	}
+	return nil
+}
+
+// Process the tx transferring money to a multisig account, the account is created with the signer set in the extra data if it doesn't exist
+func (state *AccountStateDB) processMultiSigTx(tx model.AbstractTransaction) (err error) {
+	info, err := state.checkMultiSigTx(tx)
+	if err != nil {
+		return
+	}
+
+	sender, _ := tx.Sender(nil)
+	receiver := *(tx.To())
+	if info != nil {
+		if err = state.NewAccountState(receiver); err != nil {
+			return
+		}
+		if err = state.SetMultiSig(receiver, info); err != nil {
+			return
+		}
+		log.Info("create multisig account", "address", receiver.Hex(), "threshold", info.Threshold, "signers", len(info.Signers))
+	}
+
+	if err = state.SubBalance(sender, tx.Amount()); err != nil {
+		return
+	}
+	return state.AddBalance(receiver, tx.Amount())
+}
@@ -0,0 +1,170 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"crypto/ecdsa"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/tests/g-testData"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func createMultiSigInfo(t *testing.T) *model.MultiSigInfo {
+	info, err := model.NewMultiSigInfo(2, []common.Address{aliceAddr, bobAddr, charlieAddr})
+	assert.NoError(t, err)
+	return info
+}
+
+func createMultiSigAccountTx(t *testing.T, nonce uint64, info *model.MultiSigInfo, amount int64) *model.Transaction {
+	key1, _ := createKey()
+	tx, err := model.NewMultiSigAccountTransaction(nonce, info, big.NewInt(amount), testGasPrice, testGasLimit)
+	assert.NoError(t, err)
+	tx.SignTx(key1, model.NewSigner(big.NewInt(1)))
+	return tx
+}
+
+func createMultiSigTx(nonce uint64, info *model.MultiSigInfo, amount int64, signed int) *model.Transaction {
+	key1, key2 := createKey()
+	tx := model.NewMultiSigTransaction(nonce, info, charlieAddr, big.NewInt(amount), testGasPrice, testGasLimit, nil)
+	for _, key := range []*ecdsa.PrivateKey{key1, key2}[:signed] {
+		tx.SignMultiSigTx(key, model.NewSigner(big.NewInt(1)))
+	}
+	return tx
+}
+
+func processMultiSigTestTx(processor *AccountStateDB, tx model.AbstractTransaction) error {
+	gasLimit := g_testData.TestGasLimit * 100
+	gasUsed := uint64(0)
+	block := CreateBlock(1, common.Hash{}, nil, gasLimit)
+	return processor.ProcessTxNew(&TxProcessConfig{
+		Tx:       tx,
+		Header:   block.Header(),
+		GetHash:  getTestHashFunc(),
+		GasLimit: &gasLimit,
+		GasUsed:  &gasUsed,
+	})
+}
+
+func TestAccountStateDB_processMultiSigTx(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	eris := conf.ErisBlock
+	conf.ErisBlock = big.NewInt(0)
+	defer func() { conf.ErisBlock = eris }()
+
+	processor, _, _ := createCrossTestState(t)
+	info := createMultiSigInfo(t)
+	multiSigAddr := info.Address()
+
+	// the extra data should match the multisig address
+	other, err := model.NewMultiSigInfo(1, info.Signers)
+	assert.NoError(t, err)
+	badTx := model.NewTransaction(0, multiSigAddr, big.NewInt(100), testGasPrice, testGasLimit, createMultiSigAccountTx(t, 0, other, 100).ExtraData())
+	assert.Equal(t, g_error.ErrMultiSigAddressNotMatch, processor.ValidMultiSigTx(badTx))
+
+	snapshot := processor.Snapshot()
+	createTx := createMultiSigAccountTx(t, 0, info, 1000)
+	assert.NoError(t, processor.ValidMultiSigTx(createTx))
+	assert.NoError(t, processMultiSigTestTx(processor, createTx))
+
+	saved, err := processor.GetMultiSig(multiSigAddr)
+	assert.NoError(t, err)
+	assert.True(t, info.Equal(saved))
+	balance, _ := processor.GetBalance(multiSigAddr)
+	assert.Equal(t, big.NewInt(1000), balance)
+
+	// the existing account is funded without the extra data
+	fundTx := model.NewTransaction(1, multiSigAddr, big.NewInt(500), testGasPrice, testGasLimit, nil)
+	key1, _ := createKey()
+	fundTx.SignTx(key1, model.NewSigner(big.NewInt(1)))
+	assert.NoError(t, processMultiSigTestTx(processor, fundTx))
+	balance, _ = processor.GetBalance(multiSigAddr)
+	assert.Equal(t, big.NewInt(1500), balance)
+
+	// the created account is removed by the revert
+	processor.RevertToSnapshot(snapshot)
+	assert.True(t, processor.IsEmptyAccount(multiSigAddr))
+	_, err = processor.GetMultiSig(multiSigAddr)
+	assert.Equal(t, g_error.ErrAccountNotExist, err)
+
+	// the normal account can't be used as a multisig account
+	_, err = processor.GetMultiSig(aliceAddr)
+	assert.Equal(t, g_error.ErrMultiSigAccountNotExist, err)
+}
+
+func TestAccountStateDB_ValidMultiSigSender(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	eris := conf.ErisBlock
+	conf.ErisBlock = big.NewInt(0)
+	defer func() { conf.ErisBlock = eris }()
+
+	processor, _, _ := createCrossTestState(t)
+	info := createMultiSigInfo(t)
+	multiSigAddr := info.Address()
+
+	// the account isn't created
+	spendTx := createMultiSigTx(0, info, 100, 2)
+	assert.Equal(t, g_error.ErrSenderNotExist, processMultiSigTestTx(processor, spendTx))
+
+	assert.NoError(t, processMultiSigTestTx(processor, createMultiSigAccountTx(t, 0, info, 1e9)))
+	assert.NoError(t, processor.ValidMultiSigSender(spendTx))
+
+	// one signature isn't enough
+	notEnough := createMultiSigTx(0, info, 100, 1)
+	assert.Equal(t, g_error.ErrMultiSigNotEnoughSigs, processMultiSigTestTx(processor, notEnough))
+
+	assert.True(t, processor.IsEmptyAccount(charlieAddr))
+	assert.NoError(t, processMultiSigTestTx(processor, spendTx))
+	balance, _ := processor.GetBalance(charlieAddr)
+	assert.Equal(t, big.NewInt(100), balance)
+	nonce, _ := processor.GetNonce(multiSigAddr)
+	assert.Equal(t, uint64(1), nonce)
+
+	// the same tx can't be replayed
+	assert.Equal(t, g_error.ErrTxNonceNotMatch, processMultiSigTestTx(processor, spendTx))
+}
+
+func TestAccountStateDB_multiSigFork(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	eris := conf.ErisBlock
+	conf.ErisBlock = big.NewInt(2)
+	defer func() { conf.ErisBlock = eris }()
+
+	processor, _, _ := createCrossTestState(t)
+	info := createMultiSigInfo(t)
+	multiSigAddr := info.Address()
+
+	// the multisig txs are processed as the unknown txs before the fork
+	createTx := createMultiSigAccountTx(t, 0, info, 1e9)
+	assert.Equal(t, g_error.ErrMultiSigNotActivated, processMultiSigTestTx(processor, createTx))
+	assert.Equal(t, g_error.ErrMultiSigNotActivated, processMultiSigTestTx(processor, createMultiSigTx(0, info, 100, 2)))
+	assert.True(t, processor.IsEmptyAccount(multiSigAddr))
+
+	conf.ErisBlock = big.NewInt(1)
+	assert.NoError(t, processMultiSigTestTx(processor, createTx))
+
+	// each recovered signature is charged
+	assert.NoError(t, processMultiSigTestTx(processor, createMultiSigTx(0, info, 100, 2)))
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(model2.TxGas+2*model2.TxMultiSigRecoverGas), testGasPrice)
+	balance, _ := processor.GetBalance(multiSigAddr)
+	assert.Equal(t, new(big.Int).Sub(big.NewInt(1e9-100), fee), balance)
+}
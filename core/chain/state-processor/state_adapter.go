@@ -105,6 +105,13 @@ func (f *Fullstate) SetAbi(addr common.Address, abi []byte) {
 	}
 }
 
+func (f *Fullstate) ClearContract(addr common.Address) {
+	err := f.state.ClearContract(addr)
+	if err != nil {
+		panic(fmt.Sprintf("ClearContract failed, err=%v", err))
+	}
+}
+
 func (f *Fullstate) GetState(addr common.Address, key []byte) (data []byte) {
 	return f.state.GetData(addr, string(key))
 }
@@ -138,6 +138,14 @@ func (scl *StateChangeList) DecodeRLP(s *rlp.Stream) (err error) {
 			var change contractChange
 			rlp.DecodeBytes(state.StateChange, &change)
 			scl.append(change)
+		case MultiSigChange:
+			var change multiSigChange
+			rlp.DecodeBytes(state.StateChange, &change)
+			scl.append(change)
+		case BlsKeyChange:
+			var change blsKeyChange
+			rlp.DecodeBytes(state.StateChange, &change)
+			scl.append(change)
 		default:
 			panic("no type")
 		}
@@ -283,6 +291,8 @@ const (
 	ContractChange
 	LogsChange
 	DeleteAccountChange
+	MultiSigChange
+	BlsKeyChange
 )
 
 type (
@@ -380,6 +390,18 @@ type (
 		Current    reflect.Value
 		ChangeType uint64
 	}
+	multiSigChange struct {
+		Account    *common.Address
+		Prev       []byte
+		Current    []byte
+		ChangeType uint64
+	}
+	blsKeyChange struct {
+		Account    *common.Address
+		Prev       []byte
+		Current    []byte
+		ChangeType uint64
+	}
 	logsChange struct {
 		TxHash     *common.Hash
 		Prev       []*model.Log
@@ -700,6 +722,52 @@ func (sc abiChange) digest(change StateChange) StateChange {
 	return nil
 }
 
+func (sc multiSigChange) revert(s *AccountStateDB) {
+	s.setMultiSig(*sc.Account, sc.Prev)
+}
+
+func (sc multiSigChange) recover(s *AccountStateDB) {
+	s.setMultiSig(*sc.Account, sc.Current)
+}
+
+func (sc multiSigChange) dirtied() *common.Address {
+	return sc.Account
+}
+
+func (sc multiSigChange) getType() int {
+	return int(sc.ChangeType)
+}
+func (sc multiSigChange) digest(change StateChange) StateChange {
+	if change.getType() == MultiSigChange {
+		c := change.(multiSigChange)
+		return multiSigChange{Account: sc.Account, Prev: c.Prev, Current: sc.Current, ChangeType: MultiSigChange}
+	}
+	return nil
+}
+
+func (sc blsKeyChange) revert(s *AccountStateDB) {
+	s.setBlsKey(*sc.Account, sc.Prev)
+}
+
+func (sc blsKeyChange) recover(s *AccountStateDB) {
+	s.setBlsKey(*sc.Account, sc.Current)
+}
+
+func (sc blsKeyChange) dirtied() *common.Address {
+	return sc.Account
+}
+
+func (sc blsKeyChange) getType() int {
+	return int(sc.ChangeType)
+}
+func (sc blsKeyChange) digest(change StateChange) StateChange {
+	if change.getType() == BlsKeyChange {
+		c := change.(blsKeyChange)
+		return blsKeyChange{Account: sc.Account, Prev: c.Prev, Current: sc.Current, ChangeType: BlsKeyChange}
+	}
+	return nil
+}
+
 func (sc codeChange) revert(s *AccountStateDB) {
 	s.setCode(*sc.Account, sc.Prev)
 }
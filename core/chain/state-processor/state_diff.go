@@ -0,0 +1,112 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"math/big"
+	"sort"
+)
+
+// AccountDiff holds the changes of an account, From is the value before the changes
+type AccountDiff struct {
+	Created bool                  `json:"created,omitempty"`
+	Deleted bool                  `json:"deleted,omitempty"`
+	Balance *BalanceDiff          `json:"balance,omitempty"`
+	Nonce   *NonceDiff            `json:"nonce,omitempty"`
+	Code    *BytesDiff            `json:"code,omitempty"`
+	Storage map[string]*BytesDiff `json:"storage,omitempty"`
+}
+
+type BalanceDiff struct {
+	From *hexutil.Big `json:"from"`
+	To   *hexutil.Big `json:"to"`
+}
+
+type NonceDiff struct {
+	From hexutil.Uint64 `json:"from"`
+	To   hexutil.Uint64 `json:"to"`
+}
+
+type BytesDiff struct {
+	From hexutil.Bytes `json:"from"`
+	To   hexutil.Bytes `json:"to"`
+}
+
+// DiffSince returns the accounts changed since the snapshot, the storage keys are hex encoded
+func (state *AccountStateDB) DiffSince(revid int) map[common.Address]*AccountDiff {
+	idx := sort.Search(len(state.validRevisions), func(i int) bool {
+		return state.validRevisions[i].id >= revid
+	})
+	if idx == len(state.validRevisions) || state.validRevisions[idx].id != revid {
+		return nil
+	}
+
+	diffs := make(map[common.Address]*AccountDiff)
+	getDiff := func(addr *common.Address) *AccountDiff {
+		if diffs[*addr] == nil {
+			diffs[*addr] = &AccountDiff{}
+		}
+		return diffs[*addr]
+	}
+
+	for _, change := range state.stateChangeList.changes[state.validRevisions[idx].changeIndex:] {
+		switch ch := change.(type) {
+		case newAccountChange:
+			getDiff(ch.Account).Created = true
+		case deleteAccountChange:
+			getDiff(ch.Account).Deleted = true
+		case balanceChange:
+			diff := getDiff(ch.Account)
+			if diff.Balance == nil {
+				diff.Balance = &BalanceDiff{From: bigDiffValue(ch.Prev)}
+			}
+			diff.Balance.To = bigDiffValue(ch.Current)
+		case nonceChange:
+			diff := getDiff(ch.Account)
+			if diff.Nonce == nil {
+				diff.Nonce = &NonceDiff{From: hexutil.Uint64(ch.Prev)}
+			}
+			diff.Nonce.To = hexutil.Uint64(ch.Current)
+		case codeChange:
+			diff := getDiff(ch.Account)
+			if diff.Code == nil {
+				diff.Code = &BytesDiff{From: ch.Prev}
+			}
+			diff.Code.To = ch.Current
+		case dataChange:
+			diff := getDiff(ch.Account)
+			if diff.Storage == nil {
+				diff.Storage = make(map[string]*BytesDiff)
+			}
+			key := hexutil.Encode([]byte(ch.Key))
+			if diff.Storage[key] == nil {
+				diff.Storage[key] = &BytesDiff{From: ch.Prev}
+			}
+			diff.Storage[key].To = ch.Current
+		}
+	}
+	return diffs
+}
+
+func bigDiffValue(value *big.Int) *hexutil.Big {
+	if value == nil {
+		return (*hexutil.Big)(new(big.Int))
+	}
+	return (*hexutil.Big)(new(big.Int).Set(value))
+}
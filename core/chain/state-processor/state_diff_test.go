@@ -0,0 +1,60 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestAccountStateDB_DiffSince(t *testing.T) {
+	processor := createStateProcessor(t)
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(1)))
+
+	snapshot := processor.Snapshot()
+	assert.NoError(t, processor.SubBalance(aliceAddr, big.NewInt(100)))
+	assert.NoError(t, processor.SubBalance(aliceAddr, big.NewInt(100)))
+	assert.NoError(t, processor.AddNonce(aliceAddr, 1))
+	assert.NoError(t, processor.NewAccountState(charlieAddr))
+	assert.NoError(t, processor.SetCode(charlieAddr, []byte{1, 2}))
+	assert.NoError(t, processor.SetData(charlieAddr, "key", []byte{3}))
+	assert.NoError(t, processor.SetData(charlieAddr, "key", []byte{4}))
+
+	diffs := processor.DiffSince(snapshot)
+	assert.Len(t, diffs, 2)
+
+	alice := diffs[aliceAddr]
+	assert.False(t, alice.Created)
+	assert.Equal(t, big.NewInt(9000001), alice.Balance.From.ToInt())
+	assert.Equal(t, big.NewInt(8999801), alice.Balance.To.ToInt())
+	assert.Equal(t, hexutil.Uint64(0), alice.Nonce.From)
+	assert.Equal(t, hexutil.Uint64(1), alice.Nonce.To)
+	assert.Nil(t, alice.Code)
+
+	charlie := diffs[charlieAddr]
+	assert.True(t, charlie.Created)
+	assert.Equal(t, hexutil.Bytes{1, 2}, charlie.Code.To)
+	key := hexutil.Encode([]byte("key"))
+	assert.Len(t, charlie.Storage, 1)
+	assert.Empty(t, charlie.Storage[key].From)
+	assert.Equal(t, hexutil.Bytes{4}, charlie.Storage[key].To)
+
+	// unknown snapshot
+	assert.Nil(t, processor.DiffSince(snapshot+10))
+}
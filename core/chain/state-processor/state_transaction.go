@@ -4,6 +4,7 @@ import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/common/math"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/core/vm"
 	"github.com/dipperin/dipperin-core/third-party/log"
@@ -119,10 +120,14 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	contractCreation := msg.To().GetAddressType() == common.AddressTypeContractCreate
 
 	// Pay intrinsic gas
-	gas, err := model.IntrinsicGas(st.data, contractCreation, true)
+	earth := st.lifeVm.BlockNumber != nil && chain_config.GetChainConfig().IsEarth(st.lifeVm.BlockNumber.Uint64())
+	gas, err := model.IntrinsicGas(st.data, contractCreation, true, earth)
 	if err != nil {
 		return nil, 0, false, nil, err
 	}
+	if st.lifeVm.BlockNumber != nil && chain_config.GetChainConfig().IsEris(st.lifeVm.BlockNumber.Uint64()) {
+		gas += st.msg.RecoverGas()
+	}
 
 	if err = st.useGas(gas); err != nil {
 		log.Error("TransitionDb#IntrinsicGas", "err", err)
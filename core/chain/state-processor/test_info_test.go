@@ -28,6 +28,7 @@ import (
 	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/dipperin/dipperin-core/third-party/trie"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -264,6 +265,14 @@ func getTestUnStakeTransaction(nonce uint64, key *ecdsa.PrivateKey) *model.Trans
 	return signedTx
 }
 
+func getTestVote(t *testing.T, key *ecdsa.PrivateKey, height, round uint64, blockID common.Hash) *model.VoteMsg {
+	vote, err := model.NewVoteMsgWithSign(height, round, blockID, model.VoteMessage, func(hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	}, cs_crypto.GetNormalAddress(key.PublicKey))
+	assert.NoError(t, err)
+	return vote
+}
+
 func getTestEvidenceTransaction(nonce uint64, key *ecdsa.PrivateKey, target common.Address, voteA, voteB *model.VoteMsg) *model.Transaction {
 	trans := model.NewEvidenceTransaction(nonce, g_testData.TestGasPrice, g_testData.TestGasLimit, &target, voteA, voteB)
 	fs := model.NewSigner(big.NewInt(1))
@@ -470,3 +479,19 @@ func (tx fakeTransaction) Cost() *big.Int {
 func (tx fakeTransaction) EstimateFee() *big.Int {
 	panic("implement me")
 }
+
+func (tx fakeTransaction) HashLock() *common.Hash {
+	return nil
+}
+
+func (tx fakeTransaction) TimeLock() *big.Int {
+	return big.NewInt(0)
+}
+
+func (tx fakeTransaction) HashKey() []byte {
+	return nil
+}
+
+func (tx fakeTransaction) MultiSig() *model.MultiSigWitness {
+	return nil
+}
@@ -19,12 +19,17 @@ package state_processor
 import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
 )
 
+const evidenceLogTopic = "Slash"
+
 /*
 Basic operations
 Stake money from balance
@@ -114,7 +119,7 @@ func (state *AccountStateDB) MoveStakeToAddress(fromAdd common.Address, toAdd co
 * Process register Tx
 * Stake some money
  */
-func (state *AccountStateDB) processStakeTx(tx model.AbstractTransaction) (err error) {
+func (state *AccountStateDB) processStakeTx(tx model.AbstractTransaction, num uint64) (err error) {
 
 	//Check
 	sender, _ := tx.Sender(nil)
@@ -134,11 +139,31 @@ func (state *AccountStateDB) processStakeTx(tx model.AbstractTransaction) (err e
 		return g_error.ErrStakeNotEnough
 	}
 
+	// the bls key signs the commits after the Pluto fork, the extra data was ignored before it
+	var reg *model.BlsRegistration
+	if len(tx.ExtraData()) > 0 {
+		if reg, err = model.DecodeBlsRegistration(tx.ExtraData(), sender); err != nil {
+			if chain_config.GetChainConfig().IsPluto(num) {
+				log.Debug("process register transaction failed", "err", err)
+				return g_error.ErrInvalidBlsRegistration
+			}
+			reg, err = nil, nil
+		}
+	}
+	if reg == nil && stake.Cmp(big.NewInt(0)) == 0 && chain_config.GetChainConfig().IsPluto(num) {
+		return g_error.ErrRegisterTxWithoutBlsKey
+	}
+
 	//Process
 	err = state.Stake(sender, tx.Amount())
 	if err != nil {
 		return
 	}
+	if reg != nil {
+		if err = state.SetBlsKey(sender, reg.PubKey); err != nil {
+			return
+		}
+	}
 	log.PBft.Info("success process a register transaction", "Tx hash", tx.CalTxId().Hex())
 
 	//TODO add receipt?
@@ -227,34 +252,129 @@ func (state *AccountStateDB) processUnStakeTx(tx model.AbstractTransaction) (err
 	return
 }
 
+// EvidenceLogData is the data field of the log that a processed evidence tx leaves in its receipt
+type EvidenceLogData struct {
+	Target   common.Address
+	Reporter common.Address
+	Stake    *big.Int
+	Height   uint64
+	Round    uint64
+}
+
 /*
-Process Evidence Tx
-Punish target account
-Move all target account stake to the sender of this transaction
+Check Evidence Tx with the state, num is processing block num
+The two conflict votes must be signed by the target at a height no later than num,
+the target must be a verifier of that height, and no evidence of the target at that height or later has been processed
 */
-func (state *AccountStateDB) processEvidenceTx(tx model.AbstractTransaction) (err error) {
+func (state *AccountStateDB) ValidEvidenceTx(tx model.AbstractTransaction, num uint64, getVerifiers GetVerifiersFunc) error {
+	_, _, _, err := state.checkEvidenceTx(tx, num, getVerifiers)
+	return err
+}
 
-	//Check
-	sender, _ := tx.Sender(nil)
+func (state *AccountStateDB) checkEvidenceTx(tx model.AbstractTransaction, num uint64, getVerifiers GetVerifiersFunc) (sender common.Address, target common.Address, proofs model.Proofs, err error) {
+	sender, _ = tx.Sender(nil)
 	receiver := *(tx.To())
-	originalReceiver := common.Address{}
 	if receiver.GetAddressType() != common.AddressTypeEvidence {
-		return g_error.ErrTxTypeNotMatch
+		err = g_error.ErrTxTypeNotMatch
+		return
 	}
 	if sender.GetAddressType() != common.AddressTypeNormal {
-		return g_error.ErrAddressTypeNotMatch
+		err = g_error.ErrAddressTypeNotMatch
+		return
+	}
+	target = cs_crypto.GetNormalAddressFromEvidence(receiver)
+	if empty := state.IsEmptyAccount(target); empty {
+		err = g_error.ErrReceiverNotExist
+		return
+	}
+
+	if err = rlp.DecodeBytes(tx.ExtraData(), &proofs); err != nil {
+		return
+	}
+	if err = model.ValidConflictVotes(target, proofs.VoteA, proofs.VoteB); err != nil {
+		return
+	}
+
+	height := proofs.VoteA.GetHeight()
+	if height > num {
+		err = g_error.ErrInvalidEvidenceHeight
+		return
+	}
+
+	// the evidence address of the target keeps the vote height of the last processed evidence in its time lock
+	if !state.IsEmptyAccount(receiver) {
+		var processed *big.Int
+		if processed, err = state.GetTimeLock(receiver); err != nil {
+			return
+		}
+		if processed.Cmp(new(big.Int).SetUint64(height)) >= 0 {
+			err = g_error.ErrEvidenceAlreadyProcessed
+			return
+		}
 	}
-	originalReceiver = cs_crypto.GetNormalAddressFromEvidence(receiver)
-	if empty := state.IsEmptyAccount(originalReceiver); empty {
-		return g_error.ErrReceiverNotExist
+
+	if getVerifiers == nil || !model.CheckAddressIsCurrentVerifier(target, getVerifiers(height)) {
+		err = g_error.ErrEvidenceTargetNotVerifier
+	}
+	return
+}
+
+/*
+Process Evidence Tx, num is processing block num
+Punish target account
+Move all target account stake to the sender of this transaction
+*/
+func (state *AccountStateDB) processEvidenceTx(tx model.AbstractTransaction, num uint64, getVerifiers GetVerifiersFunc) (err error) {
+	//Check
+	sender, target, proofs, err := state.checkEvidenceTx(tx, num, getVerifiers)
+	if err != nil {
+		return
 	}
 
 	//Process
-	err = state.MoveStakeToAddress(originalReceiver, sender)
+	stake, err := state.GetStake(target)
 	if err != nil {
-		return err
+		return
+	}
+	err = state.MoveStakeToAddress(target, sender)
+	if err != nil {
+		return
 	}
 
-	//TODO add receipt return
+	// the evidence is recorded after the Haumea fork, the blocks before it only moved the stake
+	if chain_config.GetChainConfig().IsHaumea(num) {
+		if err = state.recordEvidence(tx, sender, target, stake, proofs, num); err != nil {
+			return
+		}
+	}
+	log.PBft.Info("success process an evidence transaction", "Tx hash", tx.CalTxId().Hex(), "target", target.Hex(), "stake", stake)
 	return nil
 }
+
+// recordEvidence keeps the vote height in the time lock of the evidence account so that the evidence can't be replayed,
+// and adds the slash log to the receipt
+func (state *AccountStateDB) recordEvidence(tx model.AbstractTransaction, sender, target common.Address, stake *big.Int, proofs model.Proofs, num uint64) (err error) {
+	evidenceAddress := *(tx.To())
+	if state.IsEmptyAccount(evidenceAddress) {
+		if err = state.NewAccountState(evidenceAddress); err != nil {
+			return
+		}
+	}
+	height := proofs.VoteA.GetHeight()
+	if err = state.SetTimeLock(evidenceAddress, new(big.Int).SetUint64(height)); err != nil {
+		return
+	}
+
+	data, err := rlp.EncodeToBytes(EvidenceLogData{Target: target, Reporter: sender, Stake: stake, Height: height, Round: proofs.VoteA.GetRound()})
+	if err != nil {
+		return
+	}
+	return state.AddLog(&model2.Log{
+		Address:     evidenceAddress,
+		Topics:      []common.Hash{cs_crypto.Keccak256Hash([]byte(evidenceLogTopic)), common.BytesToHash(target.Bytes())},
+		TopicName:   evidenceLogTopic,
+		Data:        data,
+		BlockNumber: num,
+		TxHash:      tx.CalTxId(),
+	})
+}
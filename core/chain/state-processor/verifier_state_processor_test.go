@@ -19,8 +19,13 @@ package state_processor
 import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"math/big"
 	"testing"
@@ -141,27 +146,76 @@ func TestAccountStateDB_processStakeTx(t *testing.T) {
 	assert.NoError(t, err)
 
 	tx := getTestCancelTransaction(0, key1)
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 0)
 	assert.Equal(t, g_error.ErrTxTypeNotMatch, err)
 
 	tx = getTestRegisterTransaction(0, key1, big.NewInt(10))
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 0)
 	assert.Equal(t, g_error.ErrAccountNotExist, err)
 
 	key1, _ = createKey()
 	tx = getTestRegisterTransaction(0, key1, big.NewInt(10))
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 0)
 	assert.Equal(t, g_error.ErrStakeNotEnough, err)
 
 	tx = getTestRegisterTransaction(0, key1, big.NewInt(1e7))
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 0)
 	assert.Equal(t, g_error.ErrBalanceNotEnough, err)
 
 	tx = getTestRegisterTransaction(0, key1, big.NewInt(100))
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 0)
 	assert.NoError(t, err)
 }
 
+func TestAccountStateDB_processStakeTxWithBlsKey(t *testing.T) {
+	db, root := CreateTestStateDB()
+	processor, _ := NewAccountStateDB(root, NewStateStorageWithCache(db))
+
+	conf := chain_config.GetChainConfig()
+	pluto := conf.PlutoBlock
+	conf.PlutoBlock = big.NewInt(10)
+	defer func() { conf.PlutoBlock = pluto }()
+
+	key1, _ := createKey()
+	blsKey, err := bls.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	// junk in the extra data is ignored before the fork
+	junkTx := model.NewTransaction(0, common.HexToAddress(common.AddressStake), big.NewInt(100), g_testData.TestGasPrice, g_testData.TestGasLimit, []byte{1, 2, 3})
+	signedTx, _ := junkTx.SignTx(key1, model.NewSigner(big.NewInt(1)))
+	snap := processor.Snapshot()
+	assert.NoError(t, processor.processStakeTx(signedTx, 9))
+	processor.RevertToSnapshot(snap)
+
+	// after the fork a new verifier must register a valid bls key
+	assert.Equal(t, g_error.ErrInvalidBlsRegistration, processor.processStakeTx(signedTx, 10))
+	tx := getTestRegisterTransaction(0, key1, big.NewInt(100))
+	assert.Equal(t, g_error.ErrRegisterTxWithoutBlsKey, processor.processStakeTx(tx, 10))
+
+	blsTx, err := model.NewBlsRegisterTransaction(0, big.NewInt(100), g_testData.TestGasPrice, g_testData.TestGasLimit, model.NewBlsRegistration(blsKey, cs_crypto.GetNormalAddress(key1.PublicKey)))
+	assert.NoError(t, err)
+	signedTx, _ = blsTx.SignTx(key1, model.NewSigner(big.NewInt(1)))
+	snap = processor.Snapshot()
+	assert.NoError(t, processor.processStakeTx(signedTx, 10))
+	key, err := processor.GetBlsKey(cs_crypto.GetNormalAddress(key1.PublicKey))
+	assert.NoError(t, err)
+	assert.Equal(t, blsKey.PublicKey().Marshal(), key)
+
+	// the key is reverted with the snapshot
+	processor.RevertToSnapshot(snap)
+	key, err = processor.GetBlsKey(cs_crypto.GetNormalAddress(key1.PublicKey))
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+
+	// a staked verifier can add its key before the fork, and needn't register one again after it
+	assert.NoError(t, processor.processStakeTx(tx, 9))
+	assert.NoError(t, processor.processStakeTx(tx, 10))
+	assert.NoError(t, processor.processStakeTx(signedTx, 10))
+	key, err = processor.GetBlsKey(cs_crypto.GetNormalAddress(key1.PublicKey))
+	assert.NoError(t, err)
+	assert.Equal(t, blsKey.PublicKey().Marshal(), key)
+}
+
 func TestAccountStateDB_processCancelTx(t *testing.T) {
 	db, root := CreateTestStateDB()
 	processor, _ := NewAccountStateDB(root, NewStateStorageWithCache(db))
@@ -258,17 +312,103 @@ func TestAccountStateDB_processEvidenceTx_Error(t *testing.T) {
 	assert.NoError(t, err)
 
 	tx := getTestRegisterTransaction(0, key1, big.NewInt(10))
-	err = processor.processEvidenceTx(tx)
+	err = processor.processEvidenceTx(tx, 1, nil)
 	assert.Equal(t, g_error.ErrTxTypeNotMatch, err)
 
 	tx = getTestEvidenceTransaction(0, key1, common.HexToAddress("123"), &model.VoteMsg{}, &model.VoteMsg{})
-	err = processor.processEvidenceTx(tx)
+	err = processor.processEvidenceTx(tx, 1, nil)
 	assert.Equal(t, g_error.ErrReceiverNotExist, err)
 
 	key1, _ = createKey()
 	err = processor.SetStake(bobAddr, big.NewInt(100))
 	assert.NoError(t, err)
 	tx = getTestEvidenceTransaction(0, key1, bobAddr, &model.VoteMsg{}, &model.VoteMsg{})
-	err = processor.processEvidenceTx(tx)
-	assert.NoError(t, err)
+	err = processor.processEvidenceTx(tx, 1, nil)
+	assert.Error(t, err)
+}
+
+func TestAccountStateDB_processEvidenceTx(t *testing.T) {
+	db, root := CreateTestStateDB()
+	processor, _ := NewAccountStateDB(root, NewStateStorageWithCache(db))
+
+	reporterKey, targetKey := createKey()
+	reporter := cs_crypto.GetNormalAddress(reporterKey.PublicKey)
+	target := cs_crypto.GetNormalAddress(targetKey.PublicKey)
+	if processor.IsEmptyAccount(target) {
+		assert.NoError(t, processor.NewAccountState(target))
+	}
+	assert.NoError(t, processor.SetStake(target, big.NewInt(100)))
+	getVerifiers := func(number uint64) []common.Address {
+		return []common.Address{target}
+	}
+
+	// votes signed by others
+	voteA := getTestVote(t, reporterKey, 5, 1, common.HexToHash("1"))
+	voteB := getTestVote(t, reporterKey, 5, 1, common.HexToHash("2"))
+	tx := getTestEvidenceTransaction(0, reporterKey, target, voteA, voteB)
+	assert.Equal(t, g_error.ErrTxTargetAddressNotMatch, processor.ValidEvidenceTx(tx, 10, getVerifiers))
+
+	// votes for the same block
+	voteA = getTestVote(t, targetKey, 5, 1, common.HexToHash("1"))
+	voteB = getTestVote(t, targetKey, 5, 1, common.HexToHash("1"))
+	tx = getTestEvidenceTransaction(0, reporterKey, target, voteA, voteB)
+	assert.Equal(t, g_error.ErrEvidenceVoteNotConflict, processor.ValidEvidenceTx(tx, 10, getVerifiers))
+
+	// votes of different rounds
+	voteB = getTestVote(t, targetKey, 5, 2, common.HexToHash("2"))
+	tx = getTestEvidenceTransaction(0, reporterKey, target, voteA, voteB)
+	assert.Equal(t, g_error.ErrEvidenceVoteNotConflict, processor.ValidEvidenceTx(tx, 10, getVerifiers))
+
+	// forged signature
+	voteB = getTestVote(t, targetKey, 5, 1, common.HexToHash("2"))
+	voteB.Witness.Sign = voteA.Witness.Sign
+	tx = getTestEvidenceTransaction(0, reporterKey, target, voteA, voteB)
+	assert.Error(t, processor.ValidEvidenceTx(tx, 10, getVerifiers))
+
+	voteB = getTestVote(t, targetKey, 5, 1, common.HexToHash("2"))
+	tx = getTestEvidenceTransaction(0, reporterKey, target, voteA, voteB)
+	assert.Equal(t, g_error.ErrInvalidEvidenceHeight, processor.ValidEvidenceTx(tx, 4, getVerifiers))
+	assert.Equal(t, g_error.ErrEvidenceTargetNotVerifier, processor.ValidEvidenceTx(tx, 10, nil))
+	assert.Equal(t, g_error.ErrEvidenceTargetNotVerifier, processor.ValidEvidenceTx(tx, 10, func(number uint64) []common.Address {
+		return []common.Address{reporter}
+	}))
+
+	conf := chain_config.GetChainConfig()
+	haumea := conf.HaumeaBlock
+	conf.HaumeaBlock = big.NewInt(11)
+	defer func() { conf.HaumeaBlock = haumea }()
+
+	// only the stake is moved before the Haumea fork
+	snapshot := processor.Snapshot()
+	assert.NoError(t, processor.processEvidenceTx(tx, 10, getVerifiers))
+	stake, _ := processor.GetStake(target)
+	assert.Equal(t, big.NewInt(0), stake)
+	assert.Len(t, processor.GetLogs(tx.CalTxId()), 0)
+	assert.True(t, processor.IsEmptyAccount(*tx.To()))
+	processor.RevertToSnapshot(snapshot)
+
+	conf.HaumeaBlock = big.NewInt(10)
+	reporterBalance, _ := processor.GetBalance(reporter)
+	assert.NoError(t, processor.processEvidenceTx(tx, 10, getVerifiers))
+	stake, _ = processor.GetStake(target)
+	assert.Equal(t, big.NewInt(0), stake)
+	balance, _ := processor.GetBalance(reporter)
+	assert.Equal(t, new(big.Int).Add(reporterBalance, big.NewInt(100)), balance)
+
+	logs := processor.GetLogs(tx.CalTxId())
+	assert.Len(t, logs, 1)
+	var logData EvidenceLogData
+	assert.NoError(t, rlp.DecodeBytes(logs[0].Data, &logData))
+	assert.Equal(t, EvidenceLogData{Target: target, Reporter: reporter, Stake: big.NewInt(100), Height: 5, Round: 1}, logData)
+
+	// the same evidence can't be replayed after the target stakes again
+	assert.NoError(t, processor.SetStake(target, big.NewInt(100)))
+	tx = getTestEvidenceTransaction(1, reporterKey, target, voteA, voteB)
+	assert.Equal(t, g_error.ErrEvidenceAlreadyProcessed, processor.ValidEvidenceTx(tx, 10, getVerifiers))
+
+	// later evidence can still be processed
+	voteA = getTestVote(t, targetKey, 6, 0, common.HexToHash("1"))
+	voteB = getTestVote(t, targetKey, 6, 0, common.HexToHash("2"))
+	tx = getTestEvidenceTransaction(1, reporterKey, target, voteA, voteB)
+	assert.NoError(t, processor.ValidEvidenceTx(tx, 10, getVerifiers))
 }
@@ -163,7 +163,7 @@ type fakeAccountDBChain struct {
 }
 
 func (dbChain fakeAccountDBChain) CurrentBlock() model.AbstractBlock {
-	panic("implement me")
+	return createBlock(10)
 }
 
 func (dbChain fakeAccountDBChain) GetBlockByNumber(number uint64) model.AbstractBlock {
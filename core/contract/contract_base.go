@@ -18,6 +18,7 @@ package contract
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"math/big"
 )
 
@@ -33,4 +34,16 @@ type ContractBase struct {
 	CurContractAddr common.Address `json:"-"`
 	// amount
 	TxAmount *big.Int `json:"-"`
+	// logs recorded by the current call
+	Logs []*model2.Log `json:"-"`
+}
+
+// addLog records a log of the contract, the processor adds the logs to the receipt of the tx
+func (base *ContractBase) addLog(topicName string, topics []common.Hash, data []byte) {
+	base.Logs = append(base.Logs, &model2.Log{
+		Address:   base.CurContractAddr,
+		Topics:    topics,
+		TopicName: topicName,
+		Data:      data,
+	})
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/util"
 	"github.com/dipperin/dipperin-core/core/model"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"reflect"
 )
@@ -45,6 +46,8 @@ type Processor struct {
 	contractDB  ContractDB
 	accountDB   AccountDB
 	blockHeight uint64
+	// the logs recorded by the contract of the processed tx
+	logs []*model2.Log
 }
 
 func (p *Processor) SetAccountDB(db AccountDB) {
@@ -78,10 +81,21 @@ func (p *Processor) Process(tx model.AbstractTransaction) (err error) {
 
 		err = p.contractDB.PutContract(eData.ContractAddress, result)
 	}
+	if err == nil {
+		if logsF := result.Elem().FieldByName("Logs"); logsF.IsValid() {
+			p.logs, _ = logsF.Interface().([]*model2.Log)
+		}
+	}
 
 	return
 }
 
+// Logs returns the logs recorded by the contract of the last processed tx, the tx hash
+// and the block number of the logs aren't set
+func (p *Processor) Logs() []*model2.Log {
+	return p.logs
+}
+
 // create contract
 func (p *Processor) DoCreate(eData *ExtraDataForContract) (reflect.Value, error) {
 	if eData.ContractAddress.IsEmpty() {
@@ -122,6 +136,10 @@ func (p *Processor) DoCreate(eData *ExtraDataForContract) (reflect.Value, error)
 	// todo if not ERC20 and EARLY TOKEN？
 	amount := nContract.Elem().FieldByName("TokenTotalSupply")
 	nContract.Elem().FieldByName("Balances").SetMapIndex(reflect.ValueOf(owner), amount)
+	if token, ok := nContract.Interface().(*BuiltInERC20Token); ok {
+		token.CurContractAddr = eData.ContractAddress
+		token.logTransfer(common.Address{}, token.Owner, token.TokenTotalSupply)
+	}
 	return nContract, nil
 }
 
@@ -147,6 +165,15 @@ func (p *Processor) Run(executorAddress common.Address, eData *ExtraDataForContr
 	if tmpF.CanSet() {
 		tmpF.Set(reflect.ValueOf(executorAddress))
 	}
+	tmpF = nContract.Elem().FieldByName("CurContractAddr")
+	if tmpF.CanSet() {
+		tmpF.Set(reflect.ValueOf(eData.ContractAddress))
+	}
+	// the contract is cached by the state db, drop the logs of the previous calls
+	tmpF = nContract.Elem().FieldByName("Logs")
+	if tmpF.CanSet() {
+		tmpF.Set(reflect.Zero(tmpF.Type()))
+	}
 	// block height must be saved in state db, or meet hash collision
 	nContract.Elem().FieldByName("CurBlockHeight").Set(reflect.ValueOf(p.blockHeight))
 
@@ -120,6 +120,55 @@ func TestProcessor_Process(t *testing.T) {
 	tx = CreateSignedTx(erc20Addr, extraData)
 	err = processor.Process(tx)
 	assert.NoError(t, err)
+	assert.Len(t, processor.Logs(), 0)
+}
+
+func TestProcessor_Logs(t *testing.T) {
+	mockCtl := gomock.NewController(t)
+	mockContactDB := NewMockContractDB(mockCtl)
+	processor := NewProcessor(mockContactDB, uint64(1))
+
+	var created reflect.Value
+	mockContactDB.EXPECT().ContractExist(erc20Addr).Return(false)
+	mockContactDB.EXPECT().PutContract(erc20Addr, gomock.Any()).DoAndReturn(func(addr common.Address, v reflect.Value) error {
+		created = v
+		return nil
+	}).Times(3)
+
+	// the creation is logged as a transfer from the empty address
+	m := ExtraDataForContract{Action: "create", Params: createERC20JsonWithOwner}
+	assert.NoError(t, processor.Process(CreateSignedTx(erc20Addr, util.StringifyJsonToBytes(m))))
+	assert.Len(t, processor.Logs(), 1)
+	from, to, value, ok := ParseERC20TransferLog(processor.Logs()[0])
+	assert.True(t, ok)
+	assert.Equal(t, common.Address{}, from)
+	assert.Equal(t, created.Interface().(*BuiltInERC20Token).Owner, to)
+	assert.Equal(t, created.Interface().(*BuiltInERC20Token).TokenTotalSupply, value)
+
+	// the logs of the previous call on the cached contract are dropped
+	mockContactDB.EXPECT().GetContract(erc20Addr, gomock.Any()).DoAndReturn(func(addr common.Address, vType reflect.Type) (reflect.Value, error) {
+		return created, nil
+	}).Times(2)
+	token := created.Interface().(*BuiltInERC20Token)
+	tx := CreateSignedTx(erc20Addr, nil)
+	sender, err := tx.Sender(nil)
+	assert.NoError(t, err)
+	token.Balances[sender.Hex()] = big.NewInt(10)
+
+	m = ExtraDataForContract{Action: "Approve", Params: fmt.Sprintf("[\"%s\",\"0x2\"]", bobAddr.Hex())}
+	assert.NoError(t, processor.Process(CreateSignedTx(erc20Addr, util.StringifyJsonToBytes(m))))
+	assert.Len(t, processor.Logs(), 1)
+	assert.Equal(t, erc20Addr, processor.Logs()[0].Address)
+	assert.Equal(t, []common.Hash{ERC20ApprovalTopic, sender.Hash(), bobAddr.Hash()}, processor.Logs()[0].Topics)
+
+	m = ExtraDataForContract{Action: "Transfer", Params: fmt.Sprintf("[\"%s\",\"0x3\"]", bobAddr.Hex())}
+	assert.NoError(t, processor.Process(CreateSignedTx(erc20Addr, util.StringifyJsonToBytes(m))))
+	assert.Len(t, processor.Logs(), 1)
+	from, to, value, ok = ParseERC20TransferLog(processor.Logs()[0])
+	assert.True(t, ok)
+	assert.Equal(t, sender, from)
+	assert.Equal(t, bobAddr, to)
+	assert.Equal(t, big.NewInt(3), value)
 }
 
 func TestProcessor_DoCreate(t *testing.T) {
@@ -17,13 +17,17 @@
 package contract
 
 import (
+	"bytes"
 	"errors"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/hexutil"
 	"github.com/dipperin/dipperin-core/common/number"
 	"github.com/dipperin/dipperin-core/common/util"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"math/big"
+	"sort"
 )
 
 type BaseERC20 struct {
@@ -65,6 +69,55 @@ var (
 	ContractSupplyLess0Err = errors.New("contract TokenTotalSupply must more than 0")
 )
 
+// the logs of the built-in erc20 token have the standard topics, the first one is the event signature hash
+// followed by the padded from (owner) and to (spender) addresses, the data is the 32 bytes big endian value.
+// The creation is logged as a transfer from the empty address to the owner.
+const (
+	ERC20TransferEvent = "Transfer"
+	ERC20ApprovalEvent = "Approval"
+)
+
+var (
+	ERC20TransferTopic = cs_crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	ERC20ApprovalTopic = cs_crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+)
+
+// ERC20Holder is an address holding the built-in erc20 token
+type ERC20Holder struct {
+	Address common.Address
+	Balance *big.Int
+}
+
+// GetERC20Holders returns the holders of the token from the largest balance, the holders with the
+// same balance are ordered by the address. The addresses without balance aren't included.
+func GetERC20Holders(token *BuiltInERC20Token) []ERC20Holder {
+	holders := make([]ERC20Holder, 0, len(token.Balances))
+	for addr, balance := range token.Balances {
+		if balance == nil || balance.Sign() <= 0 {
+			continue
+		}
+		holders = append(holders, ERC20Holder{Address: common.HexToAddress(addr), Balance: new(big.Int).Set(balance)})
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if cmp := holders[i].Balance.Cmp(holders[j].Balance); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(holders[i].Address.Bytes(), holders[j].Address.Bytes()) < 0
+	})
+	return holders
+}
+
+// ParseERC20TransferLog returns the transfer recorded by the log, ok is false if the log
+// isn't a transfer of a built-in erc20 token
+func ParseERC20TransferLog(l *model2.Log) (from, to common.Address, value *big.Int, ok bool) {
+	if l == nil || l.Address.GetAddressType() != common.AddressTypeERC20 || len(l.Topics) != 3 || l.Topics[0] != ERC20TransferTopic || len(l.Data) != common.HashLength {
+		return
+	}
+	from = common.BytesToAddress(l.Topics[1].Bytes())
+	to = common.BytesToAddress(l.Topics[2].Bytes())
+	return from, to, new(big.Int).SetBytes(l.Data), true
+}
+
 func (token BuiltInERC20Token) MarshalJSON() ([]byte, error) {
 	bm := &builtInERC20TokenForMarshaling{
 		Owner:            token.Owner,
@@ -291,7 +344,7 @@ func (token *BuiltInERC20Token) Transfer(toAddress common.Address, hValue *hexut
 	token.Balances[senderAddress.Hex()] = sBalance.Sub(sBalance, value)
 	token.Balances[toAddress.Hex()] = tBalance.Add(tBalance, value)
 	log.Debug("ERC20 transfer", "from address", senderAddress.Hex(), "to address", toAddress.Hex())
-	// TODO record operation
+	token.logTransfer(senderAddress, toAddress, value)
 
 	return nil
 
@@ -306,6 +359,14 @@ func (token *BuiltInERC20Token) getBalanceForAddress(addr common.Address) *big.I
 	return balance
 }
 
+func (token *BuiltInERC20Token) logTransfer(from, to common.Address, value *big.Int) {
+	token.addLog(ERC20TransferEvent, []common.Hash{ERC20TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())}, common.LeftPadBytes(value.Bytes(), common.HashLength))
+}
+
+func (token *BuiltInERC20Token) logApproval(owner, spender common.Address, value *big.Int) {
+	token.addLog(ERC20ApprovalEvent, []common.Hash{ERC20ApprovalTopic, common.BytesToHash(owner.Bytes()), common.BytesToHash(spender.Bytes())}, common.LeftPadBytes(value.Bytes(), common.HashLength))
+}
+
 //  transfer token from third party
 //func (token *BuiltInERC20Token) TransferFrom(fromAddress, toAddress common.Address, value *big.Int) bool {
 func (token *BuiltInERC20Token) TransferFrom(fromAddress, toAddress common.Address, hValue *hexutil.Big) bool {
@@ -334,8 +395,7 @@ func (token *BuiltInERC20Token) TransferFrom(fromAddress, toAddress common.Addre
 			token.Allowed[fromAddress.Hex()][senderAddress.Hex()] = allowance.Sub(allowance, value)
 		}
 	}
-
-	// TODO record operation
+	token.logTransfer(fromAddress, toAddress, value)
 
 	return true
 
@@ -352,8 +412,7 @@ func (token *BuiltInERC20Token) Approve(spenderAddress common.Address, hValue *h
 
 	value := (*big.Int)(hValue)
 	token.Allowed[senderAddress.Hex()][spenderAddress.Hex()] = value
-
-	// TODO record operation
+	token.logApproval(senderAddress, spenderAddress, value)
 
 	return true
 
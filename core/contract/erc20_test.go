@@ -103,6 +103,72 @@ func TestBuiltInERC20Token_TransferFrom(t *testing.T) {
 	assert.Equal(t, token.Allowed, resp.Allowed)
 }
 
+func TestBuiltInERC20Token_Logs(t *testing.T) {
+	token := newTestToken()
+	token.CurContractAddr = erc20Addr
+	token.Balances[aliceAddr.Hex()] = big.NewInt(10)
+	token.CurSender = aliceAddr
+
+	assert.NoError(t, token.Transfer(bobAddr, (*hexutil.Big)(big.NewInt(3))))
+	assert.True(t, token.Approve(bobAddr, (*hexutil.Big)(big.NewInt(2))))
+	token.CurSender = bobAddr
+	assert.True(t, token.TransferFrom(aliceAddr, charlieAddr, (*hexutil.Big)(big.NewInt(2))))
+	assert.False(t, token.TransferFrom(aliceAddr, charlieAddr, (*hexutil.Big)(big.NewInt(2))))
+	assert.Error(t, token.Transfer(aliceAddr, (*hexutil.Big)(big.NewInt(4))))
+	assert.Len(t, token.Logs, 3)
+
+	assert.Equal(t, erc20Addr, token.Logs[1].Address)
+	assert.Equal(t, ERC20ApprovalEvent, token.Logs[1].TopicName)
+	assert.Equal(t, []common.Hash{ERC20ApprovalTopic, aliceAddr.Hash(), bobAddr.Hash()}, token.Logs[1].Topics)
+	assert.Equal(t, common.LeftPadBytes([]byte{2}, common.HashLength), token.Logs[1].Data)
+
+	from, to, value, ok := ParseERC20TransferLog(token.Logs[0])
+	assert.True(t, ok)
+	assert.Equal(t, aliceAddr, from)
+	assert.Equal(t, bobAddr, to)
+	assert.Equal(t, big.NewInt(3), value)
+
+	from, to, value, ok = ParseERC20TransferLog(token.Logs[2])
+	assert.True(t, ok)
+	assert.Equal(t, aliceAddr, from)
+	assert.Equal(t, charlieAddr, to)
+	assert.Equal(t, big.NewInt(2), value)
+
+	_, _, _, ok = ParseERC20TransferLog(token.Logs[1])
+	assert.False(t, ok)
+	token.Logs[0].Address = aliceAddr
+	_, _, _, ok = ParseERC20TransferLog(token.Logs[0])
+	assert.False(t, ok)
+	_, _, _, ok = ParseERC20TransferLog(nil)
+	assert.False(t, ok)
+
+	// the logs aren't saved with the token
+	data := util.StringifyJsonToBytes(token)
+	var resp *BuiltInERC20Token
+	assert.NoError(t, util.ParseJsonFromBytes(data, &resp))
+	assert.Nil(t, resp.Logs)
+}
+
+func TestGetERC20Holders(t *testing.T) {
+	token := newTestToken()
+	assert.Len(t, GetERC20Holders(token), 0)
+
+	token.Balances[aliceAddr.Hex()] = big.NewInt(5)
+	token.Balances[bobAddr.Hex()] = big.NewInt(0)
+	token.Balances[charlieAddr.Hex()] = big.NewInt(5)
+	token.Balances[erc20Addr.Hex()] = big.NewInt(7)
+	holders := GetERC20Holders(token)
+	assert.Equal(t, []ERC20Holder{
+		{Address: erc20Addr, Balance: big.NewInt(7)},
+		{Address: aliceAddr, Balance: big.NewInt(5)},
+		{Address: charlieAddr, Balance: big.NewInt(5)},
+	}, holders)
+
+	// the balances of the token aren't shared
+	holders[0].Balance.SetInt64(1)
+	assert.Equal(t, big.NewInt(7), token.Balances[erc20Addr.Hex()])
+}
+
 func TestBuiltInERC20Token_IsValid(t *testing.T) {
 	token := &BuiltInERC20Token{}
 	assert.Error(t, token.IsValid(), ContractOwnerNilErr)
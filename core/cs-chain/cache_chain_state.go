@@ -104,6 +104,24 @@ func (chain *CacheChainState) SaveBftBlock(block model.AbstractBlock, seenCommit
 	chain.currentBlock.Store(block)
 	chain.currentHeader.Store(block.Header())
 
+	// the block is saved, the failed pruning only leaves the old state on the disk
+	if err := chain.RetainState(block); err != nil {
+		log.Error("retain block state failed", "num", block.Number(), "err", err)
+	}
+	return nil
+}
+
+// SaveFastBlock saves the block downloaded by the fast sync without processing its state
+func (chain *CacheChainState) SaveFastBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	err := chain.WriterFactory.NewWriter(middleware.NewFastSyncBlockContext(block, seenCommits, chain)).SaveBlock()
+	if err != nil {
+		log.Error("SaveFastBlock err", "err", err)
+		return err
+	}
+
+	chain.currentBlock.Store(block)
+	chain.currentHeader.Store(block.Header())
+
 	return nil
 }
 
@@ -37,6 +37,10 @@ type ChainStateConfig struct {
 	ChainConfig   *chain_config.ChainConfig
 	DataDir       string
 	WriterFactory chain_writer.AbstractChainWriterFactory
+	// the number of the latest block states kept by the pruning mode, 0 for the archive mode
+	StateRetention uint64
+	// keep the tx history of the addresses
+	TxHistoryIndex bool
 }
 
 // the struct of ChainState
@@ -102,7 +106,7 @@ func (cs *ChainState) initConfigAndDB(dataDir string) {
 	// init chainDB
 	cs.ChainDB = chaindb.NewChainDB(ethDB, blockDecoder)
 
-	cs.StateStorage = state_processor.NewStateStorageWithCache(ethDB)
+	cs.StateStorage = cs.newStateStorage(ethDB)
 
 	// init economy model
 	cs.EconomyModel = economy_model.MakeDipperinEconomyModel(cs, economy_model.DIPProportion)
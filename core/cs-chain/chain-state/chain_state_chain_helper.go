@@ -36,3 +36,8 @@ func (cs *ChainState) GetEconomyModel() economy_model.EconomyModel {
 func (cs *ChainState) GetChainDB() chaindb.Database {
 	return cs.ChainDB
 }
+
+// whether the tx history of the addresses is kept by the chain
+func (cs *ChainState) TxHistoryIndexEnabled() bool {
+	return cs.TxHistoryIndex
+}
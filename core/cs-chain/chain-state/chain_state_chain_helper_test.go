@@ -60,3 +60,7 @@ func (suite *chainHelperSuite) Test_GetChainDB(c *check.C) {
 
 	c.Check(chainDB, check.NotNil)
 }
+
+func (suite *chainHelperSuite) Test_TxHistoryIndexEnabled(c *check.C) {
+	c.Check(suite.cs.TxHistoryIndexEnabled(), check.Equals, false)
+}
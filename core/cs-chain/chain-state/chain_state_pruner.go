@@ -0,0 +1,308 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/trie"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	// statePrunedKey marks the datadir whose trie nodes are reference counted
+	statePrunedKey = []byte("state-pruned")
+
+	// retainedStatePrefix + num (uint64 big endian) -> the state roots retained for the block
+	retainedStatePrefix = []byte("state-retained-")
+)
+
+// the number of the unreachable nodes deleted in one batch by the offline pruning
+const pruneBatchNodes = 100000
+
+// MinStateRetention returns the least number of the latest block states kept by the pruning mode,
+// the verifiers are elected by the states of the last change points.
+func MinStateRetention(conf *chain_config.ChainConfig) uint64 {
+	return (conf.SlotMargin+2)*conf.SlotSize + conf.RollBackNum
+}
+
+func retainedStateKey(num uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, num)
+	return append(append([]byte{}, retainedStatePrefix...), enc...)
+}
+
+// newStateStorage counts the references of the trie nodes in the pruning mode. The datadir which has
+// been pruned keeps counting in the archive mode, so the nodes written later can be pruned again.
+func (cs *ChainState) newStateStorage(db ethdb.Database) state_processor.StateStorage {
+	pruned, _ := db.Has(statePrunedKey)
+	if cs.StateRetention == 0 && !pruned {
+		return state_processor.NewStateStorageWithCache(db)
+	}
+
+	if cs.StateRetention == 0 {
+		log.Warn("the state of the datadir has been pruned, the archive mode only keeps the new states")
+	} else if !pruned {
+		if err := db.Put(statePrunedKey, []byte{1}); err != nil {
+			panic(err)
+		}
+	}
+	return state_processor.NewStateStorageWithRefCount(db)
+}
+
+// RetainState keeps the state of the inserted block, and releases the state of the block which is out of
+// the retention. The genesis state is never released.
+func (cs *ChainState) RetainState(block model.AbstractBlock) error {
+	if cs.StateRetention == 0 || !cs.StateStorage.TrieDB().RefCounted() {
+		return nil
+	}
+
+	if has, err := cs.ethDB.Has(retainedStateKey(0)); err != nil {
+		return err
+	} else if !has {
+		if genesis := cs.Genesis(); genesis != nil {
+			if err := cs.retainBlockState(genesis); err != nil {
+				return err
+			}
+		}
+	}
+
+	if block.Number() == 0 {
+		return nil
+	}
+	if err := cs.retainBlockState(block); err != nil {
+		return err
+	}
+	if block.Number() > cs.StateRetention {
+		return cs.releaseBlockState(block.Number() - cs.StateRetention)
+	}
+	return nil
+}
+
+// retainBlockState retains the account state root and the register root of the block. The state of the
+// block with the same number is released after, which is rolled back by a special block.
+func (cs *ChainState) retainBlockState(block model.AbstractBlock) error {
+	roots := []common.Hash{block.StateRoot(), block.GetRegisterRoot()}
+	for _, root := range roots {
+		if err := cs.StateStorage.TrieDB().RetainRoot(root); err != nil {
+			return err
+		}
+	}
+	if err := cs.releaseBlockState(block.Number()); err != nil {
+		return err
+	}
+
+	enc, err := rlp.EncodeToBytes(roots)
+	if err != nil {
+		return err
+	}
+	return cs.ethDB.Put(retainedStateKey(block.Number()), enc)
+}
+
+// releaseBlockState releases the state roots retained for the block number
+func (cs *ChainState) releaseBlockState(num uint64) error {
+	key := retainedStateKey(num)
+	if has, err := cs.ethDB.Has(key); err != nil || !has {
+		return err
+	}
+	enc, err := cs.ethDB.Get(key)
+	if err != nil {
+		return err
+	}
+	var roots []common.Hash
+	if err := rlp.DecodeBytes(enc, &roots); err != nil {
+		return err
+	}
+
+	// the roots are released after the record is deleted, an interruption only leaves the nodes on the disk
+	if err := cs.ethDB.Delete(key); err != nil {
+		return err
+	}
+	for _, root := range roots {
+		if err := cs.StateStorage.TrieDB().ReleaseRoot(root); err != nil {
+			return err
+		}
+	}
+	log.Debug("release block state", "num", num)
+	return nil
+}
+
+// PruneHistoryState deletes the trie nodes which aren't reachable from the genesis state or the states of the
+// latest retention blocks. It's used by the offline prune command to compact an existing datadir, and returns
+// the number of the deleted nodes.
+func (cs *ChainState) PruneHistoryState(retention uint64) (uint64, error) {
+	if retention < MinStateRetention(cs.ChainConfig) {
+		return 0, g_error.ErrStateRetentionTooSmall
+	}
+	current := cs.CurrentBlock()
+	if current == nil {
+		return 0, g_error.ErrPruneEmptyChain
+	}
+	first := uint64(1)
+	if current.Number() > retention {
+		first = current.Number() - retention + 1
+	}
+
+	// release the states retained by the pruning mode first, which deletes the counted nodes
+	var released []uint64
+	err := forEachKey(cs.ethDB, retainedStatePrefix, func(key, value []byte) error {
+		if num := binary.BigEndian.Uint64(key[len(retainedStatePrefix):]); num > 0 && num < first {
+			released = append(released, num)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, num := range released {
+		if err := cs.releaseBlockState(num); err != nil {
+			return 0, err
+		}
+	}
+
+	reachable := make(map[common.Hash]bool)
+	blocks := []model.AbstractBlock{cs.Genesis()}
+	for num := first; num <= current.Number(); num++ {
+		blocks = append(blocks, cs.GetBlockByNumber(num))
+	}
+	for _, block := range blocks {
+		if block == nil {
+			return 0, g_error.ErrBlockNotFound
+		}
+		for _, root := range []common.Hash{block.StateRoot(), block.GetRegisterRoot()} {
+			if err := cs.markTrie(root, reachable); err != nil {
+				return 0, err
+			}
+		}
+	}
+	log.Info("mark the reachable trie nodes", "from", first, "to", current.Number(), "nodes", len(reachable))
+
+	// the trie nodes are saved with their hashes as the keys
+	var deleted uint64
+	var unreachable []common.Hash
+	err = forEachKey(cs.ethDB, nil, func(key, value []byte) error {
+		if len(key) != common.HashLength {
+			return nil
+		}
+		hash := common.BytesToHash(key)
+		if reachable[hash] || !cs_crypto.Keccak256Hash(value).IsEqual(hash) {
+			return nil
+		}
+
+		unreachable = append(unreachable, hash)
+		if len(unreachable) < pruneBatchNodes {
+			return nil
+		}
+		deleted += uint64(len(unreachable))
+		err := cs.StateStorage.TrieDB().DeleteNodes(unreachable)
+		unreachable = unreachable[:0]
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	deleted += uint64(len(unreachable))
+	if err := cs.StateStorage.TrieDB().DeleteNodes(unreachable); err != nil {
+		return 0, err
+	}
+
+	if ldb, ok := cs.ethDB.(*ethdb.LDBDatabase); ok {
+		log.Info("compact the chain database", "deleted nodes", deleted)
+		if err := ldb.LDB().CompactRange(util.Range{}); err != nil {
+			return 0, err
+		}
+	}
+	return deleted, nil
+}
+
+// markTrie marks the nodes of the trie, and the contract data tries referenced by the leaves
+func (cs *ChainState) markTrie(root common.Hash, reachable map[common.Hash]bool) error {
+	if reachable[root] || root.IsEmpty() {
+		return nil
+	}
+	// the states before the pivot of the fast sync don't exist
+	if has, err := cs.ethDB.Has(root.Bytes()); err != nil || !has {
+		return err
+	}
+
+	t, err := trie.New(root, trie.NewDatabase(cs.ethDB))
+	if err != nil {
+		return err
+	}
+	it := t.NodeIterator(nil)
+	for it.Next(true) {
+		if hash := it.Hash(); !hash.IsEmpty() {
+			reachable[hash] = true
+		}
+		if !it.Leaf() {
+			continue
+		}
+
+		// the contract data root is saved as the raw hash or rlp encoded hash
+		leaf := it.LeafBlob()
+		if len(leaf) != common.HashLength && len(leaf) != common.HashLength+1 {
+			continue
+		}
+		if err := cs.markTrie(common.BytesToHash(leaf), reachable); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// forEachKey calls fn with the entries of the database which have the prefix
+func forEachKey(db ethdb.Database, prefix []byte, fn func(key, value []byte) error) error {
+	switch db := db.(type) {
+	case *ethdb.LDBDatabase:
+		it := db.NewIteratorWithPrefix(prefix)
+		defer it.Release()
+		for it.Next() {
+			if err := fn(it.Key(), it.Value()); err != nil {
+				return err
+			}
+		}
+		return it.Error()
+
+	case *ethdb.MemDatabase:
+		for _, key := range db.Keys() {
+			if !bytes.HasPrefix(key, prefix) {
+				continue
+			}
+			value, err := db.Get(key)
+			if err != nil {
+				return err
+			}
+			if err := fn(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return g_error.ErrPruneUnsupportedDB
+	}
+}
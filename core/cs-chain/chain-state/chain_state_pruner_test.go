@@ -0,0 +1,103 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-writer"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/check.v1"
+)
+
+// hasBlockState opens the state of the block with a new storage, which doesn't cache the past tries
+func (suite *chainWriterSuite) hasBlockState(num uint64) bool {
+	block := suite.chainState.GetBlockByNumber(num)
+	_, err := state_processor.NewAccountStateDB(block.StateRoot(), state_processor.NewStateStorageWithCache(suite.chainState.GetDB()))
+	return err == nil
+}
+
+func (suite *chainWriterSuite) TestChainState_RetainState(c *check.C) {
+	retention := MinStateRetention(suite.chainState.ChainConfig)
+	suite.chainState.StateRetention = retention
+	suite.chainState.StateStorage = state_processor.NewStateStorageWithRefCount(suite.chainState.GetDB())
+
+	count := retention + 5
+	for i := uint64(0); i < count; i++ {
+		suite.InsertBlock(c, 1)
+		assert.NoError(c, suite.chainState.RetainState(suite.chainState.CurrentBlock()))
+	}
+
+	assert.True(c, suite.hasBlockState(0))
+	for num := uint64(1); num <= count; num++ {
+		assert.Equal(c, num > count-retention, suite.hasBlockState(num), "block %d", num)
+
+		has, err := suite.chainState.GetDB().Has(retainedStateKey(num))
+		assert.NoError(c, err)
+		assert.Equal(c, num > count-retention, has)
+	}
+
+	// the retained state of the rolled back block is released
+	block := suite.chainState.CurrentBlock()
+	assert.NoError(c, suite.chainState.RetainState(block))
+	assert.True(c, suite.hasBlockState(block.Number()))
+}
+
+func (suite *chainWriterSuite) TestChainState_PruneHistoryState(c *check.C) {
+	retention := MinStateRetention(suite.chainState.ChainConfig)
+	count := retention + 5
+	suite.InsertBlock(c, int(count))
+
+	_, err := suite.chainState.PruneHistoryState(retention - 1)
+	assert.Equal(c, g_error.ErrStateRetentionTooSmall, err)
+
+	deleted, err := suite.chainState.PruneHistoryState(retention)
+	assert.NoError(c, err)
+	assert.True(c, deleted > 0)
+
+	assert.True(c, suite.hasBlockState(0))
+	for num := uint64(1); num <= count; num++ {
+		assert.Equal(c, num > count-retention, suite.hasBlockState(num), "block %d", num)
+	}
+
+	// the chain goes on with the kept states
+	suite.InsertBlock(c, 1)
+}
+
+func TestChainState_newStateStorage(t *testing.T) {
+	conf := &ChainStateConfig{
+		WriterFactory: chain_writer.NewChainWriterFactory(),
+		ChainConfig:   chain_config.GetChainConfig(),
+	}
+	db := ethdb.NewMemDatabase()
+	cs := &ChainState{ChainStateConfig: conf}
+	assert.False(t, cs.newStateStorage(db).TrieDB().RefCounted())
+	assert.NoError(t, cs.RetainState(nil))
+
+	// the pruned datadir keeps counting the references in the archive mode
+	conf.StateRetention = MinStateRetention(conf.ChainConfig)
+	assert.True(t, cs.newStateStorage(db).TrieDB().RefCounted())
+	conf.StateRetention = 0
+	assert.True(t, cs.newStateStorage(db).TrieDB().RefCounted())
+
+	_, err := NewChainState(conf).PruneHistoryState(MinStateRetention(conf.ChainConfig))
+	assert.Equal(t, g_error.ErrPruneEmptyChain, err)
+}
@@ -53,6 +53,7 @@ func (cw *BftChainWriter) SaveBlock() error {
 	c.Use(middleware.UpdateStateRoot(&c.BlockContext))
 	c.Use(middleware.UpdateBlockVerifier(&c.BlockContext))
 	c.Use(middleware.ValidGasUsedAndReceipts(&c.BlockContext))
+	c.Use(middleware.InsertAddressTxs(&c.BlockContext))
 	c.Use(middleware.InsertBlock(&c.BlockContext))
 	c.Use(middleware.NextRoundVerifier(&c.BlockContext))
 	//Call BlockProcessor.Process
@@ -94,6 +95,7 @@ func (cw *BftChainWriterWithoutVotes) SaveBlock() error {
 	c.Use(middleware.UpdateStateRoot(&c.BlockContext))
 
 	c.Use(middleware.UpdateBlockVerifier(&c.BlockContext))
+	c.Use(middleware.InsertAddressTxs(&c.BlockContext))
 	c.Use(middleware.InsertBlock(&c.BlockContext))
 
 	// after insert block, update verifier
@@ -107,3 +109,39 @@ func (cw *BftChainWriterWithoutVotes) SaveBlock() error {
 
 	return err
 }
+
+// FastSyncChainWriter saves the blocks before the fast sync pivot, only the headers and the seen commits are
+// validated because the txs need the state which is downloaded after the blocks.
+type FastSyncChainWriter struct {
+	context *middleware.FastSyncBlockContext
+	chain   middleware.ChainInterface
+}
+
+func NewFastSyncChainWriter(context *middleware.FastSyncBlockContext, chain middleware.ChainInterface) *FastSyncChainWriter {
+	return &FastSyncChainWriter{context: context, chain: chain}
+}
+
+func (cw *FastSyncChainWriter) SaveBlock() error {
+	log.Info("fastSyncChainWriter save block")
+	c := cw.context
+
+	c.Use(middleware.ValidateBlockNumber(&c.BlockContext))
+	if !model.IsIgnoreDifficultyValidation() {
+		c.Use(middleware.ValidateBlockDifficulty(&c.BlockContext))
+	}
+	c.Use(middleware.ValidateBlockVersion(&c.BlockContext))
+	c.Use(middleware.ValidateBlockHash(&c.BlockContext))
+	c.Use(middleware.ValidateBlockCoinBase(&c.BlockContext))
+	c.Use(middleware.ValidateSeed(&c.BlockContext))
+	c.Use(middleware.ValidateBlockTime(&c.BlockContext))
+	c.Use(middleware.ValidateBlockTxRoot(&c.BlockContext))
+	c.Use(middleware.ValidateFastSyncVotes(c))
+	c.Use(middleware.InsertBlockWithoutState(&c.BlockContext))
+
+	err := c.Process()
+	if err != nil {
+		log.Error("fast sync save block failed", "err", err)
+	}
+
+	return err
+}
@@ -53,3 +53,17 @@ func TestBftChainWriter_SaveBlock(t *testing.T) {
 		BlockContext: middleware.BlockContext{Block: mb, Chain: mc},
 	}, mc).SaveBlock())
 }
+
+func TestFastSyncChainWriter_SaveBlock(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mc := g_mockFile.NewMockChainInterface(controller)
+	mb := NewMockAbstractBlock(controller)
+	mb.EXPECT().IsSpecial().Return(true)
+	mb.EXPECT().Version().Return(uint64(100))
+	mc.EXPECT().GetChainConfig().Return(chain_config.GetChainConfig()).AnyTimes()
+
+	assert.Error(t, NewFastSyncChainWriter(&middleware.FastSyncBlockContext{
+		BlockContext: middleware.BlockContext{Block: mb, Chain: mc},
+	}, mc).SaveBlock())
+}
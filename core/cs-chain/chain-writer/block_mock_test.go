@@ -276,6 +276,20 @@ func (mr *MockAbstractBlockMockRecorder) GetTransactions() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactions", reflect.TypeOf((*MockAbstractBlock)(nil).GetTransactions))
 }
 
+// GetAggregateCommit mocks base method
+func (m *MockAbstractBlock) GetAggregateCommit() *model.AggregateCommit {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAggregateCommit")
+	ret0, _ := ret[0].(*model.AggregateCommit)
+	return ret0
+}
+
+// GetAggregateCommit indicates an expected call of GetAggregateCommit
+func (mr *MockAbstractBlockMockRecorder) GetAggregateCommit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAggregateCommit", reflect.TypeOf((*MockAbstractBlock)(nil).GetAggregateCommit))
+}
+
 // GetVerifications mocks base method
 func (m *MockAbstractBlock) GetVerifications() []model.AbstractVerification {
 	m.ctrl.T.Helper()
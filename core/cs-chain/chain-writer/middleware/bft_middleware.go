@@ -49,6 +49,24 @@ func NewBftBlockContextWithoutVotes(b model.AbstractBlock, chain ChainInterface)
 	return bc
 }
 
+// FastSyncBlockContext is used to save the blocks before the pivot of the fast sync,
+// the state of these blocks is downloaded instead of processed.
+type FastSyncBlockContext struct {
+	BlockContext
+
+	Votes []model.AbstractVerification
+}
+
+func NewFastSyncBlockContext(b model.AbstractBlock, votes []model.AbstractVerification, chain ChainInterface) *FastSyncBlockContext {
+	bc := &FastSyncBlockContext{}
+	bc.index = -1
+
+	bc.Block = b
+	bc.Votes = votes
+	bc.Chain = chain
+	return bc
+}
+
 func NewBftBlockValidator(chain ChainInterface) *BftBlockValidator {
 	return &BftBlockValidator{Chain: chain}
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
 )
@@ -29,14 +30,7 @@ func ValidateVotes(c *BftBlockContext) Middleware {
 	return func() error {
 		log.Middleware.Info("ValidateVotes start")
 		// verify the number of seen commits meets the need
-		block := c.Block
-		slot := c.Chain.GetSlot(block)
-		verifiers := c.Chain.GetVerifiers(*slot)
-		if err := validVotesForBlock(c.Votes, block, verifiers); err != nil {
-			return err
-		}
-
-		if err := validBlockHash(c.Votes, block); err != nil {
+		if err := ValidateSeenCommits(c.Block, c.Votes, c.Chain); err != nil {
 			return err
 		}
 
@@ -49,6 +43,28 @@ func ValidateVotes(c *BftBlockContext) Middleware {
 	}
 }
 
+// ValidateFastSyncVotes checks the seen commits of the blocks saved by the fast sync like ValidateVotes,
+// the fast sync downloads the state used by the verifier election of the block before saving it
+func ValidateFastSyncVotes(c *FastSyncBlockContext) Middleware {
+	return func() error {
+		log.Middleware.Info("ValidateFastSyncVotes start")
+		if err := ValidateSeenCommits(c.Block, c.Votes, c.Chain); err != nil {
+			return err
+		}
+		log.Middleware.Info("ValidateFastSyncVotes success")
+		return c.Next()
+	}
+}
+
+// ValidateSeenCommits checks the signatures and the quorum of the seen commits on the block
+func ValidateSeenCommits(block model.AbstractBlock, votes []model.AbstractVerification, chain ChainInterface) error {
+	slot := chain.GetSlot(block)
+	if err := validVotesForBlock(votes, block, chain.GetVerifiers(*slot)); err != nil {
+		return err
+	}
+	return validBlockHash(votes, block)
+}
+
 // BFT use
 func ValidateVotesForBFT(c *BlockContext) Middleware {
 	return func() error {
@@ -64,20 +80,28 @@ func validateVotes(block model.AbstractBlock, chain ChainInterface) error {
 
 	// The first block has no votes
 	if block.Number() == 1 {
-		if !block.VerificationRoot().IsEqual(model.EmptyVerfRoot) || len(block.GetVerifications()) != 0 {
+		if !block.VerificationRoot().IsEqual(model.EmptyVerfRoot) || len(block.GetVerifications()) != 0 || block.GetAggregateCommit() != nil {
 			return g_error.ErrFirstBlockHaveVerifications
 		}
 		return nil
 	}
 
+	// the commits of the normal blocks are aggregated after the Pluto fork, the special blocks keep the boot node votes
+	preBlockHeight := block.Number() - 1
+	preBlock := chain.GetBlockByNumber(preBlockHeight)
+	if chain_config.GetChainConfig().IsPluto(preBlockHeight) && !preBlock.IsSpecial() {
+		return validAggregateCommit(block, preBlock, chain)
+	}
+	if block.GetAggregateCommit() != nil {
+		return g_error.ErrUnexpectedAggregateCommit
+	}
+
 	// the v root of the current block in PBFT is the merkle root of the previous block's verifications, and the body's verifications are also that of the previous block
 	if err := validVerificationRoot(block.GetVerifications(), block.VerificationRoot()); err != nil {
 		return err
 	}
 
 	// 2.1 2.2 Verify votes is on previous block
-	preBlockHeight := block.Number() - 1
-	preBlock := chain.GetBlockByNumber(preBlockHeight)
 	preBlockSlot := chain.GetSlot(preBlock)
 	preBlockVerifiers := chain.GetVerifiers(*preBlockSlot)
 	if err := validVotesForBlock(block.GetVerifications(), preBlock, preBlockVerifiers); err != nil {
@@ -90,6 +114,51 @@ func validateVotes(block model.AbstractBlock, chain ChainInterface) error {
 	return nil
 }
 
+func validAggregateCommit(block, preBlock model.AbstractBlock, chain ChainInterface) error {
+	if len(block.GetVerifications()) != 0 {
+		return g_error.ErrUnexpectedAggregateCommit
+	}
+	commit := block.GetAggregateCommit()
+	if commit == nil {
+		return g_error.ErrAggregateCommitRequired
+	}
+	if !commit.Hash().IsEqual(block.VerificationRoot()) {
+		return g_error.ErrVerificationRootNotMatch
+	}
+	if commit.Height != preBlock.Number() || !commit.BlockID.IsEqual(preBlock.Hash()) {
+		return g_error.ErrInvalidAggregateCommit
+	}
+
+	preBlockSlot := chain.GetSlot(preBlock)
+	preBlockVerifiers := chain.GetVerifiers(*preBlockSlot)
+	signers, err := commit.SignerAddresses(preBlockVerifiers)
+	if err != nil {
+		return g_error.ErrInvalidSignerBitmap
+	}
+	if len(signers) < len(preBlockVerifiers)*2/3+1 {
+		return g_error.ErrBlockVotesNotEnough
+	}
+
+	// the keys registered until the previous block
+	state, err := chain.StateAtByBlockNumber(preBlock.Number())
+	if err != nil {
+		return err
+	}
+	pubKeys := make([][]byte, 0, len(signers))
+	for _, signer := range signers {
+		key, err := state.GetBlsKey(signer)
+		if err != nil || len(key) == 0 {
+			return g_error.ErrVerifierBlsKeyNotExist
+		}
+		pubKeys = append(pubKeys, key)
+	}
+	if err := commit.VerifySignature(pubKeys); err != nil {
+		log.Error("invalid aggregate commit", "height", commit.Height, "err", err)
+		return g_error.ErrInvalidAggregateSignature
+	}
+	return nil
+}
+
 func validVotesForBlock(votes []model.AbstractVerification, block model.AbstractBlock, verifiers []common.Address) error {
 	if len(votes) == 0 {
 		return g_error.ErrEmptyVoteList
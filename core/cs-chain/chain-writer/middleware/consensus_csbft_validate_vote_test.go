@@ -18,10 +18,14 @@ package middleware
 
 import (
 	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
 
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 )
 
@@ -51,6 +55,38 @@ func TestValidateVotes(t *testing.T) {
 	})())
 }
 
+func TestValidateFastSyncVotes(t *testing.T) {
+	_, _, _, passChain := getTxTestEnv(t)
+	a := NewAccount()
+	b := NewAccount()
+	c := NewAccount()
+	fb := &fakeBlock{hash: common.Hash{0x12}}
+	va := a.getVoteMsg(1, 1, fb.hash, model.VoteMessage)
+	vb := b.getVoteMsg(1, 1, fb.hash, model.VoteMessage)
+	vc := c.getVoteMsg(1, 1, fb.hash, model.VoteMessage)
+	passChain.verifiers = []common.Address{a.Address(), b.Address()}
+	validate := func(votes ...model.AbstractVerification) error {
+		return ValidateFastSyncVotes(NewFastSyncBlockContext(fb, votes, passChain))()
+	}
+
+	assert.Equal(t, g_error.ErrEmptyVoteList, validate())
+	assert.Equal(t, g_error.ErrNotCurrentVerifier, validate(va, vc))
+	assert.NoError(t, validate(va, vb))
+
+	// the verifiers of the later slots are calculated from the downloaded state, the other signers are rejected
+	passChain.slot = chain_config.GetChainConfig().SlotMargin
+	passChain.verifiers = []common.Address{b.Address(), c.Address()}
+	assert.Equal(t, g_error.ErrNotCurrentVerifier, validate(va, vb))
+	assert.NoError(t, validate(vb, vc))
+	assert.Equal(t, g_error.ErrBlockVotesNotEnough, validate(vc))
+	assert.Equal(t, g_error.ErrInvalidBlockHashInVotes, validate(vb, c.getVoteMsg(1, 1, common.Hash{0x34}, model.VoteMessage)))
+
+	// the forged signature
+	forged := *vb
+	forged.Witness = &model.WitMsg{Address: b.Address(), Sign: vc.Witness.Sign}
+	assert.Error(t, validate(&forged, vc))
+}
+
 func TestValidateVotesForBFT(t *testing.T) {
 	assert.Error(t, ValidateVotesForBFT(&BlockContext{
 		Chain: &fakeChainInterface{},
@@ -98,3 +134,74 @@ func Test_validVotesForBlock(t *testing.T) {
 
 	assert.Error(t, validVotesForBlock([]model.AbstractVerification{v}, &fakeBlock{}, []common.Address{{}}))
 }
+
+func Test_validateVotesWithAggregateCommit(t *testing.T) {
+	_, adb, _, passChain := getTxTestEnv(t)
+	passChain.slot = 1
+	passChain.block.hash = common.Hash{0x12}
+	preBlock := passChain.block
+
+	conf := chain_config.GetChainConfig()
+	pluto := conf.PlutoBlock
+	conf.PlutoBlock = big.NewInt(int64(testBlockNum))
+	defer func() { conf.PlutoBlock = pluto }()
+
+	var votes []model.AbstractVerification
+	passChain.verifiers = nil
+	for i := 0; i < 4; i++ {
+		a := NewAccount()
+		key, err := bls.GenerateKey(nil)
+		assert.NoError(t, err)
+		assert.NoError(t, adb.NewAccountState(a.Address()))
+		if i < 3 {
+			assert.NoError(t, adb.SetBlsKey(a.Address(), key.PublicKey().Marshal()))
+		}
+		passChain.verifiers = append(passChain.verifiers, a.Address())
+		votes = append(votes, &model.VoteMsg{
+			Height:   preBlock.num,
+			Round:    1,
+			BlockID:  preBlock.hash,
+			VoteType: model.VoteMessage,
+			Witness:  &model.WitMsg{Address: a.Address(), BlsSign: key.Sign(model.BlsSignHash(preBlock.num, 1, preBlock.hash).Bytes()).Marshal()},
+		})
+	}
+	newBlock := func(votes []model.AbstractVerification) *fakeBlock {
+		commit, err := model.NewAggregateCommit(votes, passChain.verifiers)
+		assert.NoError(t, err)
+		return &fakeBlock{num: testBlockNum + 1, commit: commit, vRoot: commit.Hash()}
+	}
+
+	assert.Equal(t, g_error.ErrAggregateCommitRequired, validateVotes(&fakeBlock{num: testBlockNum + 1}, passChain))
+	assert.Equal(t, g_error.ErrUnexpectedAggregateCommit, validateVotes(&fakeBlock{num: testBlockNum + 1, vs: votes}, passChain))
+
+	block := newBlock(votes[:3])
+	assert.NoError(t, validateVotes(block, passChain))
+
+	block.vRoot = common.Hash{}
+	assert.Equal(t, g_error.ErrVerificationRootNotMatch, validateVotes(block, passChain))
+
+	block = newBlock(votes[:2])
+	assert.Equal(t, g_error.ErrBlockVotesNotEnough, validateVotes(block, passChain))
+
+	// the last verifier has no bls key
+	block = newBlock(votes[1:])
+	assert.Equal(t, g_error.ErrVerifierBlsKeyNotExist, validateVotes(block, passChain))
+
+	block = newBlock(votes[:3])
+	block.commit.Signature = votes[0].(*model.VoteMsg).Witness.BlsSign
+	block.vRoot = block.commit.Hash()
+	assert.Equal(t, g_error.ErrInvalidAggregateSignature, validateVotes(block, passChain))
+
+	block = newBlock(votes[:3])
+	block.commit.Signers = []byte{0x07, 0x00}
+	block.vRoot = block.commit.Hash()
+	assert.Equal(t, g_error.ErrInvalidSignerBitmap, validateVotes(block, passChain))
+
+	passChain.block.hash = common.Hash{0x13}
+	block = newBlock(votes[:3])
+	assert.Equal(t, g_error.ErrInvalidAggregateCommit, validateVotes(block, passChain))
+
+	// no aggregate commit before the fork
+	conf.PlutoBlock = big.NewInt(int64(testBlockNum) + 1)
+	assert.Equal(t, g_error.ErrUnexpectedAggregateCommit, validateVotes(block, passChain))
+}
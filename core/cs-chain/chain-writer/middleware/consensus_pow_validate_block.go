@@ -99,21 +99,8 @@ func ValidateBlockDifficulty(c *BlockContext) Middleware {
 
 		}
 
-		preBlockHeight := c.Block.Number() - 1
-		preSpanH := model.LastPeriodBlockNum(preBlockHeight)
-		if preSpanH == 0 {
-			preSpanH = 1
-		}
-
-		//get the first block in the preBlock period
-		preSpanBlock := c.Chain.GetBlockByNumber(preSpanH)
-		//lastBlock := c.Chain.GetBlockByNumber(preBlockHeight)
-
-		//find the neighbor normal block
-		findBlock := c.Chain.GetLatestNormalBlock()
-
-		targetDiff := model.NewCalNewWorkDiff(preSpanBlock, findBlock, c.Block.Number()-1)
-		//targetDiff := model.CalNewWorkDiff(preSpanBlock, lastBlock)
+		// the same difficulty as the mine master's work
+		targetDiff := model.NextDifficulty(c.Chain, c.Block.Number()-1)
 
 		if !targetDiff.Equal(c.Block.Difficulty()) {
 			log.Error("the c.Block number is:","number",c.Block.Number())
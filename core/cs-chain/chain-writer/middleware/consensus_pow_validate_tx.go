@@ -28,7 +28,6 @@ import (
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
-	"strings"
 )
 
 // special tx validators
@@ -37,6 +36,7 @@ var txValidators = map[common.TxType]func(tx model.AbstractTransaction, chain Ch
 	common.TxType(common.AddressTypeNormal): func(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
 		return nil
 	},
+	common.TxType(common.AddressTypeCross):          validCrossTx,
 	common.TxType(common.AddressTypeStake):          validRegisterTx,
 	common.TxType(common.AddressTypeCancel):         validCancelTx,
 	common.TxType(common.AddressTypeUnStake):        validUnStakeTx,
@@ -45,6 +45,7 @@ var txValidators = map[common.TxType]func(tx model.AbstractTransaction, chain Ch
 	common.TxType(common.AddressTypeEarlyReward):    validEarlyTokenTx,
 	common.TxType(common.AddressTypeContractCall):   validContractCallTx,
 	common.TxType(common.AddressTypeContractCreate): validContractCreateTx,
+	common.TxType(common.AddressTypeMultiSig):       validMultiSigTx,
 }
 
 //type TxContext struct {
@@ -72,13 +73,11 @@ func (v *TxValidatorForRpcService) Valid(tx model.AbstractTransaction) error {
 func ValidateBlockTxs(c *BlockContext) Middleware {
 	return func() error {
 		log.Middleware.Info("ValidateBlockTxs start")
-		txs := c.Block.GetAbsTransactions()
-		targetRoot := model.DeriveSha(model.AbsTransactions(txs))
-		if !targetRoot.IsEqual(c.Block.TxRoot()) {
-			log.Error("tx root not match", "targetRoot", targetRoot.Hex(), "blockRoot", c.Block.TxRoot().Hex())
-			return g_error.ErrTxRootNotMatch
+		if err := validTxRoot(c.Block); err != nil {
+			return err
 		}
 
+		txs := c.Block.GetAbsTransactions()
 		if c.Block.IsSpecial() {
 			if txs != nil {
 				return g_error.ErrTxInSpecialBlock
@@ -98,8 +97,34 @@ func ValidateBlockTxs(c *BlockContext) Middleware {
 	}
 }
 
+// ValidateBlockTxRoot only checks the tx root, it's used by the blocks saved without the state
+func ValidateBlockTxRoot(c *BlockContext) Middleware {
+	return func() error {
+		log.Middleware.Info("ValidateBlockTxRoot start")
+		if err := validTxRoot(c.Block); err != nil {
+			return err
+		}
+		log.Middleware.Info("ValidateBlockTxRoot success")
+		return c.Next()
+	}
+}
+
+func validTxRoot(block model.AbstractBlock) error {
+	targetRoot := model.DeriveSha(model.AbsTransactions(block.GetAbsTransactions()))
+	if !targetRoot.IsEqual(block.TxRoot()) {
+		log.Error("tx root not match", "targetRoot", targetRoot.Hex(), "blockRoot", block.TxRoot().Hex())
+		return g_error.ErrTxRootNotMatch
+	}
+	return nil
+}
+
 // valid sender and amount
 func ValidTxSender(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	// the multisig witness is valid after the Eris fork
+	if tx.MultiSig() != nil && !isMultiSigActivated(chain, blockHeight) {
+		return g_error.ErrMultiSigNotActivated
+	}
+
 	economy := chain.GetEconomyModel()
 	singer := tx.GetSigner()
 	sender, err := tx.Sender(singer)
@@ -108,10 +133,12 @@ func ValidTxSender(tx model.AbstractTransaction, chain ChainInterface, blockHeig
 	}
 
 	//check minimal gasUsed
-	gas, err := model.IntrinsicGas(tx.ExtraData(), tx.GetType() == common.AddressTypeContractCreate, true)
+	earth := chain.GetChainConfig().IsEarth(blockHeight)
+	gas, err := model.IntrinsicGas(tx.ExtraData(), tx.GetType() == common.AddressTypeContractCreate, true, earth)
 	if err != nil {
 		return err
 	}
+	gas += model.MultiSigRecoverGas(tx)
 
 	if gas > tx.GetGasLimit() {
 		log.Error("tx gas limit is too low", "need", gas, "got", tx.GetGasLimit())
@@ -123,6 +150,11 @@ func ValidTxSender(tx model.AbstractTransaction, chain ChainInterface, blockHeig
 	if err != nil {
 		return err
 	}
+	if sender.GetAddressType() == common.AddressTypeMultiSig {
+		if err = state.ValidMultiSigSender(tx); err != nil {
+			return err
+		}
+	}
 	credit, err := state.GetBalance(sender)
 	log.Info("ValidTxSender#credit", "credit", credit)
 	if err != nil {
@@ -189,9 +221,80 @@ func validRegisterTx(tx model.AbstractTransaction, chain ChainInterface, blockHe
 	if tx.Amount().Cmp(economy_model.MiniPledgeValue) == -1 {
 		return g_error.ErrTxDelegatesNotEnough
 	}
+
+	// the commits are signed by the bls keys after the Pluto fork, the extra data is ignored before it
+	config := chain_config.GetChainConfig()
+	if config.PlutoBlock == nil {
+		return nil
+	}
+	if blockHeight == 0 {
+		blockHeight = chain.CurrentBlock().Number() + 1
+	}
+	if !config.IsPluto(blockHeight) {
+		return nil
+	}
+	sender, err := tx.Sender(tx.GetSigner())
+	if err != nil {
+		return err
+	}
+	if len(tx.ExtraData()) > 0 {
+		if _, err := model.DecodeBlsRegistration(tx.ExtraData(), sender); err != nil {
+			return g_error.ErrInvalidBlsRegistration
+		}
+		return nil
+	}
+
+	// the verifiers staked before need no key in the following register txs
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+	stake, err := state.GetStake(sender)
+	if err != nil {
+		return err
+	}
+	if stake.Sign() == 0 {
+		return g_error.ErrRegisterTxWithoutBlsKey
+	}
 	return nil
 }
 
+func validCrossTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+
+	// tx from rpc will be packaged into the next block
+	if blockHeight == 0 {
+		blockHeight = chain.CurrentBlock().Number() + 1
+	}
+	// the cross chain txs are supported after the Makemake fork
+	if !chain.GetChainConfig().IsMakemake(blockHeight) {
+		return g_error.ErrTxNotSupported
+	}
+	return state.ValidCrossTx(tx, blockHeight)
+}
+
+func validMultiSigTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	if !isMultiSigActivated(chain, blockHeight) {
+		return g_error.ErrMultiSigNotActivated
+	}
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+	return state.ValidMultiSigTx(tx)
+}
+
+// isMultiSigActivated returns whether the block at the height is after the Eris fork, the tx from rpc will be packaged into the next block
+func isMultiSigActivated(chain ChainInterface, blockHeight uint64) bool {
+	if blockHeight == 0 {
+		blockHeight = chain.CurrentBlock().Number() + 1
+	}
+	return chain.GetChainConfig().IsEris(blockHeight)
+}
+
 func validUnStakeTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
 	if err := haveStack(tx, chain, blockHeight); err != nil {
 		return err
@@ -264,6 +367,9 @@ func validEvidenceTx(tx model.AbstractTransaction, chain ChainInterface, blockHe
 	if err := validTargetStake(tx, chain, blockHeight); err != nil {
 		return err
 	}
+	if err := validEvidenceState(tx, chain, blockHeight); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -314,29 +420,21 @@ func conflictVote(tx model.AbstractTransaction, chain ChainInterface, blockHeigh
 	if err := rlp.DecodeBytes(extraData, &proofData); err != nil {
 		return err
 	}
-	voteA := proofData.VoteA
-	voteB := proofData.VoteB
+	return model.ValidConflictVotes(cs_crypto.GetNormalAddressFromEvidence(*tx.To()), proofData.VoteA, proofData.VoteB)
+}
 
-	// valid vote
-	err := voteA.Valid()
-	if err != nil {
-		return err
-	}
-	err = voteB.Valid()
+// check the vote height, the verifier set of that height and the replay of the evidence with the state
+func validEvidenceState(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	state, err := getPreStateForHeight(blockHeight, chain)
 	if err != nil {
 		return err
 	}
 
-	// Two vote conflict check
-	if voteA.GetType() != voteB.GetType() || voteA.GetViewID() != voteB.GetViewID() || voteA.GetHeight() != voteB.GetHeight() || strings.Compare(voteA.GetBlockHash(), voteB.GetBlockHash()) == 0 || !voteA.GetAddress().IsEqual(voteB.GetAddress()) {
-		return g_error.ErrEvidenceVoteNotConflict
-	}
-
-	// Test target match voter
-	if !voteA.GetAddress().IsEqual(cs_crypto.GetNormalAddressFromEvidence(*tx.To())) {
-		return g_error.ErrTxTargetAddressNotMatch
+	// tx from rpc will be packaged into the next block
+	if blockHeight == 0 {
+		blockHeight = chain.CurrentBlock().Number() + 1
 	}
-	return nil
+	return state.ValidEvidenceTx(tx, blockHeight, state_processor.VerifiersByNumber(chain))
 }
 
 func validUnStakeTime(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
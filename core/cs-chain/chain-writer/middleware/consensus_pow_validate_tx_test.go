@@ -19,10 +19,12 @@ package middleware
 import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/economy-model"
 	"github.com/dipperin/dipperin-core/core/model"
 	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
@@ -50,6 +52,17 @@ func TestValidateBlockTxs(t *testing.T) {
 	assert.Equal(t, "invalid sender", ValidateBlockTxs(blockContext)().Error())
 }
 
+func TestValidateBlockTxRoot(t *testing.T) {
+	block := &fakeBlock{}
+	blockContext := &BlockContext{Block: block, Chain: &fakeChainInterface{}}
+	assert.Equal(t, g_error.ErrTxRootNotMatch, ValidateBlockTxRoot(blockContext)())
+
+	// the txs aren't validated without the state
+	block.txs = []model.AbstractTransaction{&fakeTx{Receipt: &model2.Receipt{}}}
+	block.txRoot = common.HexToHash("0xdcc044ba24b5184502aef321c170b8a52d570190d7b85a9ccfbd5d7b0754d2f8")
+	assert.NoError(t, ValidateBlockTxRoot(blockContext)())
+}
+
 func TestTxValidatorForRpcService_Valid(t *testing.T) {
 
 	assert.Error(t, ValidTxSender(&fakeTx{
@@ -99,6 +112,24 @@ func TestTxValidatorForRpcService_Valid(t *testing.T) {
 		block: &fakeBlock{},
 		em:    &fakeEconomyModel{lockM: big.NewInt(0)},
 	}, 1))
+
+	// the multisig witness is valid after the Eris fork, and each signature recovery is charged
+	witness := &model.MultiSigWitness{Sigs: make([]model.MultiSigSignature, 2)}
+	assert.Equal(t, g_error.ErrMultiSigNotActivated, ValidTxSender(&fakeTx{
+		sender:   sender,
+		GasLimit: model2.TxGas,
+		multiSig: witness,
+	}, &fakeChainInterface{state: adb, block: &fakeBlock{}}, 1))
+
+	conf := chain_config.GetChainConfig()
+	eris := conf.ErisBlock
+	conf.ErisBlock = big.NewInt(0)
+	defer func() { conf.ErisBlock = eris }()
+	assert.Equal(t, g_error.ErrTxGasLimitNotEnough, ValidTxSender(&fakeTx{
+		sender:   sender,
+		GasLimit: model2.TxGas,
+		multiSig: witness,
+	}, &fakeChainInterface{state: adb, block: &fakeBlock{}}, 1))
 }
 
 func TestValidTxByType(t *testing.T) {
@@ -134,6 +165,39 @@ func Test_validRegisterTx(t *testing.T) {
 	assert.NoError(t, validRegisterTx(tx, nil, 0))
 }
 
+func Test_validRegisterTxBlsKey(t *testing.T) {
+	s, adb, passTx, passChain := getTxTestEnv(t)
+	passTx.amount = economy_model.MiniPledgeValue
+
+	conf := chain_config.GetChainConfig()
+	pluto := conf.PlutoBlock
+	conf.PlutoBlock = big.NewInt(int64(testBlockNum) + 2)
+	defer func() { conf.PlutoBlock = pluto }()
+
+	// before the fork
+	assert.NoError(t, validRegisterTx(passTx, passChain, 0))
+
+	conf.PlutoBlock = big.NewInt(int64(testBlockNum) + 1)
+	assert.Equal(t, g_error.ErrRegisterTxWithoutBlsKey, validRegisterTx(passTx, passChain, 0))
+	passTx.extraData = []byte{1, 2, 3}
+	assert.Equal(t, g_error.ErrInvalidBlsRegistration, validRegisterTx(passTx, passChain, 0))
+
+	key, err := bls.GenerateKey(nil)
+	assert.NoError(t, err)
+	// the key registered by another account
+	passTx.extraData, err = rlp.EncodeToBytes(model.NewBlsRegistration(key, common.Address{0x12}))
+	assert.NoError(t, err)
+	assert.Equal(t, g_error.ErrInvalidBlsRegistration, validRegisterTx(passTx, passChain, 0))
+	passTx.extraData, err = rlp.EncodeToBytes(model.NewBlsRegistration(key, s))
+	assert.NoError(t, err)
+	assert.NoError(t, validRegisterTx(passTx, passChain, 0))
+
+	// a staked verifier may leave the key out
+	passTx.extraData = nil
+	assert.NoError(t, adb.AddStake(s, big.NewInt(100)))
+	assert.NoError(t, validRegisterTx(passTx, passChain, 0))
+}
+
 func Test_validUnStakeTx(t *testing.T) {
 	s, adb, passTx, passChain := getTxTestEnv(t)
 	assert.Equal(t, g_error.ErrTxSenderStakeNotEnough, validUnStakeTx(passTx, passChain, 0))
@@ -170,21 +234,71 @@ func Test_validEarlyTokenTx(t *testing.T) {
 	assert.Nil(t, validEarlyTokenTx(nil, nil, 0))
 }
 
+func Test_validCrossTx(t *testing.T) {
+	assert.Error(t, validCrossTx(&fakeTx{}, &fakeChainInterface{}, 0))
+
+	s, _, passTx, passChain := getTxTestEnv(t)
+	bob := common.Address{0x22}
+	lockAddr := cs_crypto.GetLockAddress(s, bob)
+	hashLock := model.CalHashLock([]byte("123"))
+	passTx.to = &lockAddr
+	passTx.extraData = bob.Bytes()
+	passTx.hashLock = &hashLock
+	passTx.timeLock = big.NewInt(int64(testBlockNum) + 1)
+	assert.Equal(t, g_error.ErrTxNotSupported, validCrossTx(passTx, passChain, 0))
+
+	conf := chain_config.GetChainConfig()
+	makemake := conf.MakemakeBlock
+	conf.MakemakeBlock = big.NewInt(0)
+	defer func() { conf.MakemakeBlock = makemake }()
+
+	assert.Equal(t, g_error.ErrInvalidTimeLock, validCrossTx(passTx, passChain, 0))
+
+	passTx.timeLock = big.NewInt(int64(testBlockNum) + 2)
+	assert.NoError(t, validCrossTx(passTx, passChain, 0))
+}
+
+func Test_validMultiSigTx(t *testing.T) {
+	_, _, passTx, passChain := getTxTestEnv(t)
+	info, err := model.NewMultiSigInfo(2, []common.Address{NewAccount().Address(), NewAccount().Address(), NewAccount().Address()})
+	assert.NoError(t, err)
+	multiSigAddr := info.Address()
+	passTx.to = &multiSigAddr
+	assert.Equal(t, g_error.ErrMultiSigNotActivated, validMultiSigTx(passTx, passChain, 0))
+
+	conf := chain_config.GetChainConfig()
+	eris := conf.ErisBlock
+	conf.ErisBlock = big.NewInt(0)
+	defer func() { conf.ErisBlock = eris }()
+	assert.Error(t, validMultiSigTx(passTx, passChain, 0))
+
+	passTx.extraData, err = rlp.EncodeToBytes(info)
+	assert.NoError(t, err)
+	assert.NoError(t, validMultiSigTx(passTx, passChain, 0))
+
+	otherAddr := cs_crypto.GetMultiSigAddress(1, info.Signers)
+	passTx.to = &otherAddr
+	assert.Equal(t, g_error.ErrMultiSigAddressNotMatch, validMultiSigTx(passTx, passChain, 0))
+}
+
 func Test_validEvidenceTx(t *testing.T) {
 	assert.Error(t, validEvidenceTx(&fakeTx{extraData: []byte{}}, &fakeChainInterface{}, 0))
 
 	a, p := getPassConflictVote()
 	pb, err := rlp.EncodeToBytes(p)
 	assert.NoError(t, err)
-	tmpAddr := a.Address()
+	tmpAddr := cs_crypto.GetEvidenceAddress(a.Address())
 	assert.Error(t, validEvidenceTx(&fakeTx{extraData: pb, to: &tmpAddr}, &fakeChainInterface{}, 0))
 
 	_, adb, passTx, passChain := getTxTestEnv(t)
+	passTx.sender = NewAccount().Address()
 	passTx.extraData = pb
 	passTx.to = &tmpAddr
-	assert.NoError(t, adb.NewAccountState(tmpAddr))
+	assert.NoError(t, adb.NewAccountState(a.Address()))
 	assert.Error(t, validEvidenceTx(passTx, passChain, 0))
-	assert.NoError(t, adb.AddStake(tmpAddr, big.NewInt(100)))
+	assert.NoError(t, adb.AddStake(a.Address(), big.NewInt(100)))
+	assert.Equal(t, g_error.ErrEvidenceTargetNotVerifier, validEvidenceTx(passTx, passChain, 0))
+	passChain.verifiers = []common.Address{a.Address()}
 	assert.NoError(t, validEvidenceTx(passTx, passChain, 0))
 }
 
@@ -195,17 +309,24 @@ func Test_conflictVote(t *testing.T) {
 	tmpAddr := common.Address{0x12}
 	assert.Error(t, conflictVote(&fakeTx{extraData: pb, to: &tmpAddr}, &fakeChainInterface{}, 0))
 
+	target := cs_crypto.GetEvidenceAddress(a.Address())
+	assert.NoError(t, conflictVote(&fakeTx{extraData: pb, to: &target}, &fakeChainInterface{}, 0))
+
 	p.VoteB = a.getVoteMsg(0, 1, common.Hash{}, model.VoteMessage)
 	pb, err = rlp.EncodeToBytes(p)
-	assert.Error(t, conflictVote(&fakeTx{extraData: pb}, &fakeChainInterface{}, 0))
+	assert.Error(t, conflictVote(&fakeTx{extraData: pb, to: &target}, &fakeChainInterface{}, 0))
+
+	p.VoteB = a.getVoteMsg(0, 2, common.Hash{0x12}, model.VoteMessage)
+	pb, err = rlp.EncodeToBytes(p)
+	assert.Equal(t, g_error.ErrEvidenceVoteNotConflict, conflictVote(&fakeTx{extraData: pb, to: &target}, &fakeChainInterface{}, 0))
 
 	p.VoteB.Height = 3
 	pb, err = rlp.EncodeToBytes(p)
-	assert.Error(t, conflictVote(&fakeTx{extraData: pb}, &fakeChainInterface{}, 0))
+	assert.Error(t, conflictVote(&fakeTx{extraData: pb, to: &target}, &fakeChainInterface{}, 0))
 
 	p.VoteA.Height = 2
 	pb, err = rlp.EncodeToBytes(p)
-	assert.Error(t, conflictVote(&fakeTx{extraData: pb}, &fakeChainInterface{}, 0))
+	assert.Error(t, conflictVote(&fakeTx{extraData: pb, to: &target}, &fakeChainInterface{}, 0))
 
 	assert.Error(t, conflictVote(&fakeTx{extraData: []byte{}}, &fakeChainInterface{}, 0))
 }
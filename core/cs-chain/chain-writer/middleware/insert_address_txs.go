@@ -0,0 +1,77 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package middleware
+
+import (
+	"github.com/dipperin/dipperin-core/core/model"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+)
+
+// TxHistoryIndexer is implemented by the chain which keeps the tx history of the addresses
+type TxHistoryIndexer interface {
+	TxHistoryIndexEnabled() bool
+}
+
+// InsertAddressTxs saves the txs of the block to the tx history of their senders and receivers after
+// the block is inserted, and the erc20 transfers in the receipts to the transfer history. The history
+// of the blocks rolled back by a special block is deleted. It must be used before InsertBlock.
+func InsertAddressTxs(c *BlockContext) Middleware {
+	return func() error {
+		if indexer, ok := c.Chain.(TxHistoryIndexer); !ok || !indexer.TxHistoryIndexEnabled() {
+			return c.Next()
+		}
+		log.Middleware.Info("InsertAddressTxs start", "blockNum", c.Block.Number())
+
+		// the blocks are rolled back by InsertBlock, find them with their receipts before
+		var rolledBack []model.AbstractBlock
+		var rolledBackReceipts []model2.Receipts
+		if c.Block.IsSpecial() {
+			curNum := c.Chain.CurrentBlock().Number()
+			for num := c.Block.Number(); num <= curNum; num++ {
+				if block := c.Chain.GetBlockByNumber(num); block != nil {
+					rolledBack = append(rolledBack, block)
+					rolledBackReceipts = append(rolledBackReceipts, c.Chain.GetReceipts(block.Hash(), num))
+				}
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		// the block is saved, the failed indexing only leaves the tx history incomplete
+		chainDB := c.Chain.GetChainDB()
+		for i, block := range rolledBack {
+			if err := chainDB.DeleteAddressTxEntries(block); err != nil {
+				log.Error("delete the tx history of the rolled back block failed", "num", block.Number(), "err", err)
+			}
+			if err := chainDB.DeleteERC20Transfers(block, rolledBackReceipts[i]); err != nil {
+				log.Error("delete the erc20 transfers of the rolled back block failed", "num", block.Number(), "err", err)
+			}
+		}
+		if err := chainDB.SaveAddressTxEntries(c.Block); err != nil {
+			log.Error("save the tx history of the block failed", "num", c.Block.Number(), "err", err)
+		}
+		if err := chainDB.SaveERC20Transfers(c.Block, c.receipts); err != nil {
+			log.Error("save the erc20 transfers of the block failed", "num", c.Block.Number(), "err", err)
+		}
+
+		log.Middleware.Info("InsertAddressTxs success")
+		return nil
+	}
+}
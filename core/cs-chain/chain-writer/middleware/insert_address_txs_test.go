@@ -0,0 +1,119 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package middleware
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/contract"
+	"github.com/dipperin/dipperin-core/core/model"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/tests/g-testData"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+type txHistoryChain struct {
+	*MockChainInterface
+	enabled bool
+}
+
+func (chain txHistoryChain) TxHistoryIndexEnabled() bool {
+	return chain.enabled
+}
+
+func createAddressTxBlock(t *testing.T, num uint64, sender *Account, to common.Address) *model.Block {
+	var txs []*model.Transaction
+	diff, nonce := common.Difficulty{0}, common.BlockNonce{0}
+	if sender != nil {
+		tx := model.NewTransaction(num, to, big.NewInt(1), g_testData.TestGasPrice, g_testData.TestGasLimit, nil)
+		signedTx, err := tx.SignTx(sender.Pk, model.NewSigner(big.NewInt(1)))
+		assert.NoError(t, err)
+		txs = append(txs, signedTx)
+		diff, nonce = minDiff, common.BlockNonceFromInt(1)
+	}
+	header := model.NewHeader(1, num, common.Hash{}, common.Hash{}, diff, big.NewInt(1), common.Address{}, nonce)
+	return model.NewBlock(header, txs, nil)
+}
+
+func TestInsertAddressTxs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sender := NewAccount()
+	receiver := NewAccount().Address()
+	db := chaindb.NewChainDB(ethdb.NewMemDatabase(), model.MakeDefaultBlockDecoder())
+	block1 := createAddressTxBlock(t, 1, sender, receiver)
+	block2 := createAddressTxBlock(t, 2, sender, receiver)
+	token := common.HexToAddress("0x00100000FA42f7315cD04D6774E58B54e92603e96d84")
+	receipts := model2.Receipts{{Logs: []*model2.Log{{
+		Address: token,
+		Topics:  []common.Hash{contract.ERC20TransferTopic, sender.Address().Hash(), receiver.Hash()},
+		Data:    common.LeftPadBytes([]byte{1}, common.HashLength),
+		TxHash:  block1.GetTransactions()[0].CalTxId(),
+	}}}}
+
+	// the chain without the index
+	c := NewBlockContext(block1, txHistoryChain{MockChainInterface: NewMockChainInterface(ctrl)})
+	assert.NoError(t, c.Process(InsertAddressTxs(c)))
+	entries, err := db.GetAddressTxEntries(receiver, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+
+	chain := txHistoryChain{MockChainInterface: NewMockChainInterface(ctrl), enabled: true}
+	chain.EXPECT().GetChainDB().Return(db).AnyTimes()
+	c = NewBlockContext(block1, chain)
+	c.receipts = receipts
+	assert.NoError(t, c.Process(InsertAddressTxs(c)))
+	transfers, err := db.GetERC20Transfers(receiver, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []chaindb.ERC20TransferEntry{{Token: token, TxHash: block1.GetTransactions()[0].CalTxId(), BlockNumber: 1, From: sender.Address(), To: receiver, Value: big.NewInt(1)}}, transfers)
+	entries, err = db.GetAddressTxEntries(sender.Address(), 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []chaindb.AddressTxEntry{{TxHash: block1.GetTransactions()[0].CalTxId(), BlockNumber: 1, Sent: true}}, entries)
+	entries, err = db.GetAddressTxEntries(receiver, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []chaindb.AddressTxEntry{{TxHash: block1.GetTransactions()[0].CalTxId(), BlockNumber: 1, Received: true}}, entries)
+
+	// the block isn't inserted
+	insertErr := errors.New("insert block failed")
+	c = NewBlockContext(block2, chain)
+	assert.Equal(t, insertErr, c.Process(InsertAddressTxs(c), func() error { return insertErr }))
+	entries, err = db.GetAddressTxEntries(receiver, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// the special block rolls back the first block
+	chain.EXPECT().CurrentBlock().Return(block1)
+	chain.EXPECT().GetBlockByNumber(uint64(1)).Return(block1)
+	chain.EXPECT().GetReceipts(block1.Hash(), uint64(1)).Return(receipts)
+	c = NewBlockContext(createAddressTxBlock(t, 1, nil, receiver), chain)
+	assert.NoError(t, c.Process(InsertAddressTxs(c)))
+	entries, err = db.GetAddressTxEntries(receiver, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+	transfers, err = db.GetERC20Transfers(receiver, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, transfers, 0)
+	entries, err = db.GetAddressTxEntries(sender.Address(), 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
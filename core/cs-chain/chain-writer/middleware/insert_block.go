@@ -58,3 +58,21 @@ func InsertBlock(c *BlockContext) Middleware {
 		return c.Next()
 	}
 }
+
+// InsertBlockWithoutState inserts the block downloaded by the fast sync, the state roots of the block
+// aren't processed and no receipts are saved.
+func InsertBlockWithoutState(c *BlockContext) Middleware {
+	return func() error {
+		log.Middleware.Info("InsertBlockWithoutState start", "blockNum", c.Block.Number())
+		if c.Chain.CurrentBlock().Number()+1 != c.Block.Number() {
+			return g_error.ErrInvalidBlockNum
+		}
+
+		if err := c.Chain.GetChainDB().InsertBlock(c.Block); err != nil {
+			return err
+		}
+
+		log.Middleware.Info("InsertBlockWithoutState success")
+		return c.Next()
+	}
+}
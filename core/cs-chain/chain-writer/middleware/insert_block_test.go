@@ -34,3 +34,16 @@ func TestInsertBlock(t *testing.T) {
 		Chain: passChain,
 	})())
 }
+
+func TestInsertBlockWithoutState(t *testing.T) {
+	_, _, _, passChain := getTxTestEnv(t)
+	assert.Equal(t, InsertBlockWithoutState(&BlockContext{
+		Block: &fakeBlock{num: testBlockNum + 2},
+		Chain: passChain,
+	})(), g_error.ErrInvalidBlockNum)
+
+	assert.NoError(t, InsertBlockWithoutState(&BlockContext{
+		Block: &fakeBlock{num: testBlockNum + 1},
+		Chain: passChain,
+	})())
+}
@@ -27,8 +27,8 @@ var testBlockNum = uint64(10)
 
 func getPassConflictVote() (*Account, model.Proofs) {
 	a := NewAccount()
-	va := a.getVoteMsg(0, 1, common.Hash{}, model.VoteMessage)
-	vb := a.getVoteMsg(0, 1, common.Hash{0x12}, model.VoteMessage)
+	va := a.getVoteMsg(1, 1, common.Hash{}, model.VoteMessage)
+	vb := a.getVoteMsg(1, 1, common.Hash{0x12}, model.VoteMessage)
 	p := model.Proofs{
 		VoteA:    va,
 		VoteB:    vb,
@@ -218,7 +218,7 @@ func (ci *fakeChainInterface) GetLastChangePoint(block model.AbstractBlock) *uin
 }
 
 func (ci *fakeChainInterface) GetSlotByNum(num uint64) *uint64 {
-	panic("implement me")
+	return &ci.slot
 }
 
 func (ci *fakeChainInterface) GetSlot(block model.AbstractBlock) *uint64 {
@@ -364,6 +364,10 @@ type fakeTx struct {
 	Price     *big.Int
 	GasLimit  uint64
 	Receipt   *model2.Receipt
+	hashLock  *common.Hash
+	timeLock  *big.Int
+	hashKey   []byte
+	multiSig  *model.MultiSigWitness
 }
 
 func (ft *fakeTx) PaddingReceipt(parameters model.ReceiptPara) {
@@ -448,6 +452,25 @@ func (ft *fakeTx) EstimateFee() *big.Int {
 	panic("implement me")
 }
 
+func (ft *fakeTx) HashLock() *common.Hash {
+	return ft.hashLock
+}
+
+func (ft *fakeTx) TimeLock() *big.Int {
+	if ft.timeLock == nil {
+		return big.NewInt(0)
+	}
+	return ft.timeLock
+}
+
+func (ft *fakeTx) HashKey() []byte {
+	return ft.hashKey
+}
+
+func (ft *fakeTx) MultiSig() *model.MultiSigWitness {
+	return ft.multiSig
+}
+
 type fakeBlock struct {
 	txRoot       common.Hash
 	isSpecial    bool
@@ -459,6 +482,7 @@ type fakeBlock struct {
 	preHash      common.Hash
 	registerRoot common.Hash
 	vs           []model.AbstractVerification
+	commit       *model.AggregateCommit
 	vRoot        common.Hash
 	diff         common.Difficulty
 	cb           common.Address
@@ -657,6 +681,10 @@ func (fb *fakeBlock) GetVerifications() []model.AbstractVerification {
 	return fb.vs
 }
 
+func (fb *fakeBlock) GetAggregateCommit() *model.AggregateCommit {
+	return fb.commit
+}
+
 func NewEmptyAccountDB() (*state_processor.AccountStateDB, state_processor.StateStorage) {
 	storage := state_processor.NewStateStorageWithCache(ethdb.NewMemDatabase())
 	db, err := state_processor.NewAccountStateDB(common.Hash{}, storage)
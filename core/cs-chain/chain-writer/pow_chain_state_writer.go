@@ -40,6 +40,7 @@ func (cw *PowChainWriter) SaveBlock() error {
 	c.Use(middleware.ValidateBlockHash(c))
 	c.Use(middleware.ValidateBlockTxs(c))
 	c.Use(middleware.UpdateStateRoot(c))
+	c.Use(middleware.InsertAddressTxs(c))
 	c.Use(middleware.InsertBlock(c))
 
 	//Call BlockProcessor.Process
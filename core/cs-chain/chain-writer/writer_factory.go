@@ -44,6 +44,8 @@ func (f *ChainWriterFactory) NewWriter(context interface{}) ChainWriter {
 	case *middleware.BftBlockContextWithoutVotes:
 		// just for test
 		return NewBftChainWriterWithoutVotes(c, f.chain)
+	case *middleware.FastSyncBlockContext:
+		return NewFastSyncChainWriter(c, f.chain)
 	}
 
 	panic(fmt.Sprintf("block context type error, got: %v", reflect.TypeOf(context)))
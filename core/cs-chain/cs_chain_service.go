@@ -209,6 +209,29 @@ func (cs *CsChainService) SaveBlock(block model.AbstractBlock, seenCommits []mod
 	return nil
 }
 
+// SaveFastBlock saves the block before the fast sync pivot, the state used by the verifier
+// election of its slot must be downloaded before, so its seen commits are fully checked.
+func (cs *CsChainService) SaveFastBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	cs.wg.Add(1)
+	defer cs.wg.Done()
+
+	cs.saveBlockLock.Lock()
+	defer cs.saveBlockLock.Unlock()
+
+	if err := cs.CacheChainState.SaveFastBlock(block, seenCommits); err != nil {
+		g_metrics.Add(g_metrics.FailedInsertBlockCount, "", 1)
+		return err
+	}
+
+	if err := cs.CacheDB.SaveSeenCommits(block.Number(), common.Hash{}, seenCommits); err != nil {
+		log.PBft.Error("save seenCommits failed", "err", err)
+		return err
+	}
+
+	g_metrics.Set(g_metrics.CurChainHeight, "", float64(block.Number()))
+	return nil
+}
+
 func (cs *CsChainService) checkBftBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
 	// todo this can be optimized in middleware
 	if block.Number() <= cs.CurrentBlock().Number() {
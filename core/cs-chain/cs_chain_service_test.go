@@ -56,6 +56,25 @@ func (c *fakeCacheDB) SaveSeenCommits(blockHeight uint64, blockHash common.Hash,
 	return nil
 }
 
+// memCacheDB keeps the seen commits in memory
+type memCacheDB struct {
+	commits map[uint64][]model.AbstractVerification
+}
+
+func (c *memCacheDB) DeleteSeenCommits(blockHeight uint64, blockHash common.Hash) error {
+	delete(c.commits, blockHeight)
+	return nil
+}
+
+func (c *memCacheDB) GetSeenCommits(blockHeight uint64, blockHash common.Hash) (result []model.AbstractVerification, err error) {
+	return c.commits[blockHeight], nil
+}
+
+func (c *memCacheDB) SaveSeenCommits(blockHeight uint64, blockHash common.Hash, commits []model.AbstractVerification) error {
+	c.commits[blockHeight] = commits
+	return nil
+}
+
 type fakeTxPool struct{}
 
 func (t *fakeTxPool) AddRemotes(txs []model.AbstractTransaction) []error {
@@ -201,6 +220,24 @@ func TestCsChainService_SaveBlock(t *testing.T) {
 	assert.Equal(t, g_error.ErrAlreadyHaveThisBlock, ccs.checkBftBlock(block, nil))
 }
 
+func TestCsChainService_SaveFastBlock(t *testing.T) {
+	cMock := &memCacheDB{commits: map[uint64][]model.AbstractVerification{}}
+	ccs, gEnv, txB, bB := getTestChainEnv(cMock)
+	bB.SetMinerPk(gEnv.DefaultBootNodeVerifiers()[0].Pk)
+	bB.Txs = []*model.Transaction{txB.Build()}
+
+	// the block is saved without the state, the seen commits are checked like the normal blocks
+	b1 := bB.Build()
+	assert.Equal(t, g_error.ErrEmptyVoteList, ccs.SaveFastBlock(b1, nil))
+	assert.Equal(t, g_error.ErrBlockVotesNotEnough, ccs.SaveFastBlock(b1, gEnv.VoteBlock(1, 1, b1)))
+	v := gEnv.VoteBlock(3, 1, b1)
+	assert.NoError(t, ccs.SaveFastBlock(b1, v))
+	assert.Equal(t, b1.Hash(), ccs.CurrentBlock().Hash())
+	assert.Equal(t, b1.Hash(), ccs.GetBlockByNumber(1).Hash())
+	assert.Equal(t, g_error.ErrNormalBlockHeightTooLow, ccs.SaveFastBlock(b1, v))
+	assert.Len(t, ccs.GetSeenCommit(1), len(v))
+}
+
 func TestCsChainService_checkGenesis(t *testing.T) {
 	ccs := &CsChainService{CacheChainState: &CacheChainState{ChainState: &chain_state.ChainState{}}}
 	assert.Panics(t, func() {
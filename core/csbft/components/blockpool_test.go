@@ -313,6 +313,10 @@ func (fb *FakeBlock) GetVerifications() []model.AbstractVerification {
 	panic("implement me")
 }
 
+func (fb *FakeBlock) GetAggregateCommit() *model.AggregateCommit {
+	panic("implement me")
+}
+
 func (fb *FakeBlock) GetTransactionFees() *big.Int {
 	panic("implement me")
 }
@@ -61,11 +61,17 @@ func (bft *CsBft) SetFetcher(fetcher *components.CsBftFetcher) {
 	bft.stateHandler.SetFetcher(fetcher)
 }
 
+// SetWAL sets the consensus wal of the state handler, it must be called before Start
+func (bft *CsBft) SetWAL(wal *state_machine.WAL) {
+	bft.stateHandler.SetWAL(wal)
+}
+
 /*func (bft *CsBft) SendFetchBlockMsg(msgCode uint64, from common.Address, msg *model2.FetchBlockReqDecodeMsg) error {
     //return bft.nodeContext.FetcherConnAdaptCsBft().SendFetchBlockMsg(msgCode, from, msg)
     return bft.FetcherConnAdaptCsBft.SendFetchBlockMsg(msgCode, from, msg)
 }*/
 
+// Start replays the consensus wal if there is one before the state handler runs
 func (bft *CsBft) Start() error {
 	log.PBft.Info("start CsBft", "cur height", bft.ChainReader.CurrentBlock().Number())
 	if !bft.canStart() {
@@ -78,6 +84,10 @@ func (bft *CsBft) Start() error {
 	}
 	err := bft.stateHandler.Start()
 	log.PBft.Debug("start git", "is running", bft.stateHandler.IsRunning(), "err", err)
+	// don't take part in the consensus without knowing what has been signed
+	if err != nil && !bft.stateHandler.IsRunning() {
+		return err
+	}
 	err = bft.blockPool.Start()
 	log.PBft.Debug("start pool", "is running", bft.blockPool.IsRunning(), "err", err)
 	err = bft.fetcher.Start()
@@ -101,22 +111,39 @@ func (bft *CsBft) Stop() {
 	bft.fetcher.Reset()
 }
 
-func (bft *CsBft) OnNewWaitVerifyBlock(block model.AbstractBlock, id string) {
+func (bft *CsBft) OnNewWaitVerifyBlock(block model.AbstractBlock, id string) error {
 	//log.PBft.Debug("cs onNewWatVerifyBlock")
 	//check the node is or isn't current verifier node
 	if !bft.stateHandler.IsRunning() || !bft.blockPool.IsRunning() {
 		log.PBft.Debug("cs onNewWatVerifyBlock, bft not running")
-		return
+		return nil
 	}
 	log.PBft.Info("cs bft OnNewWaitVerifyBlock", "block num", block.Number())
 
-	// todo check block valid here?
+	if err := bft.validWaitVerifyBlock(block); err != nil {
+		log.PBft.Info("invalid wait verify block", "from", id, "err", err)
+		return chain_communication.NewPeerFaultError(chain_communication.FaultBadBlock, err)
+	}
 	if err := bft.blockPool.AddBlock(block); err != nil {
 		log.PBft.Info("pool add block failed", "err", err)
-		return
+		return nil
 	}
 	// wait and sync block to other verifiers
 	go bft.broadcastFetchBlockMsg(block.Hash())
+	return nil
+}
+
+// validWaitVerifyBlock checks the block of the height being agreed on, blocks of other heights are left to the pool.
+// The chain may move on during the check, which isn't the block's fault
+func (bft *CsBft) validWaitVerifyBlock(block model.AbstractBlock) error {
+	height := bft.ChainReader.CurrentBlock().Number() + 1
+	if block.Number() != height {
+		return nil
+	}
+	if err := bft.Validator.FullValid(block); err != nil && bft.ChainReader.CurrentBlock().Number()+1 == height {
+		return err
+	}
+	return nil
 }
 
 func (bft *CsBft) broadcastFetchBlockMsg(blockHash common.Hash) {
@@ -206,6 +233,10 @@ func (bft *CsBft) OnNewP2PMsg(msg p2p.Msg, p chain_communication.PmAbstractPeer)
 			return err
 		}
 		log.PBft.Info("[Node-OnNewMsg]receive prevote msg", "node", p.NodeName(), "height", m.Height, "round", m.Round, "block", m.BlockID.Hex())
+		// votes come straight from their signers, so a bad signature is the sender's fault
+		if err := m.Valid(); err != nil {
+			return chain_communication.NewPeerFaultError(chain_communication.FaultBadVote, err)
+		}
 		bft.stateHandler.PreVote(&m)
 
 	case model2.TypeOfVoteMsg:
@@ -214,6 +245,10 @@ func (bft *CsBft) OnNewP2PMsg(msg p2p.Msg, p chain_communication.PmAbstractPeer)
 			return err
 		}
 		log.PBft.Info("[Node-OnNewMsg]receive vote msg", "node", p.NodeName(), "height", m.Height, "round", m.Round, "block", m.BlockID.Hex())
+		// votes come straight from their signers, so a bad signature is the sender's fault
+		if err := m.Valid(); err != nil {
+			return chain_communication.NewPeerFaultError(chain_communication.FaultBadVote, err)
+		}
 		bft.stateHandler.Vote(&m)
 
 	case model2.TypeOfFetchBlockReqMsg:
@@ -17,7 +17,9 @@
 package csbftnode
 
 import (
+	"errors"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-communication"
 	"github.com/dipperin/dipperin-core/core/csbft/components"
 	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
 	"github.com/dipperin/dipperin-core/core/csbft/state-machine"
@@ -87,6 +89,21 @@ func TestCsBft_OnNewWaitVerifyBlock(t *testing.T) {
 	assert.Equal(t, false, node1.blockPool.IsEmpty())
 }
 
+func TestCsBft_OnNewWaitVerifyBlock_Invalid(t *testing.T) {
+	node1 := NewTestNode()
+	node1.Validator = &FakeValidtor{err: errors.New("invalid block")}
+	node1.Start()
+
+	block := &FakeBlock{uint64(1), common.HexToHash("0x123"), nil}
+	err := node1.OnNewWaitVerifyBlock(block, "")
+	assert.Equal(t, chain_communication.FaultBadBlock, err.(*chain_communication.PeerFaultError).Fault)
+	assert.Equal(t, true, node1.blockPool.IsEmpty())
+
+	// blocks of other heights are left to the pool
+	block = &FakeBlock{uint64(2), common.HexToHash("0x123"), nil}
+	assert.NoError(t, node1.OnNewWaitVerifyBlock(block, ""))
+}
+
 func TestCsBft_isNextVerifier(t *testing.T) {
 	node1 := NewTestNode()
 	assert.Equal(t, true, node1.isNextVerifier())
@@ -303,6 +320,25 @@ func TestCsBft_OnNewP2PMsg8(t *testing.T) {
 	node1.OnNewP2PMsg(p2pMsg4, &tPeer{0, "", "", address})
 }
 
+// Test VoteMsg with a bad signature
+func TestCsBft_OnNewP2PMsg_BadVote(t *testing.T) {
+	node1 := NewTestNode()
+	node1.Start()
+	address := common.HexToAddress("0x54bbe8ffddc")
+
+	vote := MakeNewVote(1, 1, &FakeBlock{}, 1)
+	size, r, _ := rlp.EncodeToReader(vote)
+	err := node1.OnNewP2PMsg(p2p.Msg{Code: uint64(model2.TypeOfVoteMsg), Size: uint32(size), Payload: r}, &tPeer{0, "", "", address})
+	assert.NoError(t, err)
+
+	// the signature doesn't cover the changed round
+	vote.Round = 2
+	size, r, _ = rlp.EncodeToReader(vote)
+	err = node1.OnNewP2PMsg(p2p.Msg{Code: uint64(model2.TypeOfPreVoteMsg), Size: uint32(size), Payload: r}, &tPeer{0, "", "", address})
+	assert.IsType(t, &chain_communication.PeerFaultError{}, err)
+	assert.Equal(t, chain_communication.FaultBadVote, err.(*chain_communication.PeerFaultError).Fault)
+}
+
 // Test FetchBlockResponse
 func TestCsBft_OnNewP2PMsg9(t *testing.T) {
 	node1 := NewTestNode()
@@ -53,10 +53,12 @@ func (fms *FackMsgSender) BroadcastEiBlock(block model.AbstractBlock) {
 }
 
 // New FakeValidtor
-type FakeValidtor struct{}
+type FakeValidtor struct {
+	err error
+}
 
-func (FakeValidtor) FullValid(block model.AbstractBlock) error {
-	return nil
+func (v FakeValidtor) FullValid(block model.AbstractBlock) error {
+	return v.err
 }
 
 func (FakeValidtor) Valid(block model.AbstractBlock) error {
@@ -325,6 +327,10 @@ func (fb *FakeBlock) GetVerifications() []model.AbstractVerification {
 	panic("implement me")
 }
 
+func (fb *FakeBlock) GetAggregateCommit() *model.AggregateCommit {
+	panic("implement me")
+}
+
 func (fb *FakeBlock) GetTransactionFees() *big.Int {
 	panic("implement me")
 }
@@ -18,7 +18,9 @@ package state_machine
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 )
 
 type ChainReader interface {
@@ -35,6 +37,25 @@ type MsgSigner interface {
 	GetAddress() common.Address
 }
 
+// VoteSigner is implemented by the signers that sign the whole vote themselves, like a remote signer that keeps
+// its own slashing protection. The bls signature is added to the witness if withBls is set.
+type VoteSigner interface {
+	SignVote(vote *model.VoteMsg, withBls bool) (*model.WitMsg, error)
+}
+
+// BlsSigner is implemented by the signers that load the bls key of the verifier from its wallet, the commits are
+// signed with it after the Pluto fork
+type BlsSigner interface {
+	BlsSecretKey() (*bls.SecretKey, error)
+}
+
+// ProposalSigner is implemented by the signers that don't sign raw hashes, like a remote signer. They hash the
+// proposals and the new round msgs themselves.
+type ProposalSigner interface {
+	SignProposal(proposal *model2.Proposal) ([]byte, error)
+	SignNewRound(msg *model2.NewRoundMsg) ([]byte, error)
+}
+
 type MsgSender interface {
 	BroadcastMsg(msgCode uint64, msg interface{})
 	SendReqRoundMsg(msgCode uint64, from []common.Address, msg interface{})
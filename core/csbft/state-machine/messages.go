@@ -19,8 +19,10 @@ package state_machine
 import (
 	"errors"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"sync"
 )
@@ -290,6 +292,10 @@ func (vs *VoteSet) validVote(v *model.VoteMsg) error {
 	if err := v.Witness.Valid(v.Hash().Bytes()); err != nil {
 		return err
 	}
+	// the commits are aggregated after the Pluto fork, the bls sign is checked by the builder with the registered key
+	if v.VoteType == model.VoteMessage && chain_config.GetChainConfig().IsPluto(v.Height) && len(v.Witness.BlsSign) != bls.SignatureLength {
+		return errors.New("vote without bls sign, addr: " + v.Witness.Address.Hex())
+	}
 	// check is already have
 	votes := vs.roundVotes(v.Round)
 	if votes[v.Witness.Address] != nil {
@@ -321,6 +327,10 @@ func (vs *VoteSet) AddVote(v *model.VoteMsg) error {
 		log.PBft.Debug("[AddVote] vote not valid", "error", err)
 		return err
 	}
+	// a verifier restarted from the wal sends its votes again, they are only counted once
+	if pre, ok := vs.roundVotes(v.Round)[v.Witness.Address]; ok && pre.BlockID.IsEqual(v.BlockID) {
+		return nil
+	}
 	//vs.Votes[v.Round] = make(map[common.Address]*VoteMsg)
 	vs.roundVotes(v.Round)[v.Witness.Address] = v
 	if vs.roundBlockVotes(v.Round)[v.BlockID] == 0 {
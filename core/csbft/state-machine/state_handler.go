@@ -22,10 +22,13 @@ import (
 	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/common/g-metrics"
 	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/csbft/components"
 	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/rlp"
 	"time"
 )
 
@@ -38,6 +41,14 @@ type StateHandler struct {
 	blockPool *components.BlockPool
 	ticker    components.TimeoutTicker
 
+	// wal keeps the signed msgs over restarts, signed is the block signed for each height, round and msg type
+	wal    *WAL
+	signed map[signedKey]common.Hash
+
+	// blsKey is loaded from the wallet of the signer when the first commit is signed after the Pluto fork
+	blsKey     *bls.SecretKey
+	blsKeyAddr common.Address
+
 	newHeightChan        chan uint64
 	newRoundChan         chan *model2.NewRoundMsg
 	poolNotEmptyChan     chan struct{}
@@ -61,6 +72,12 @@ type ReqRoundMsg struct {
 	Round  uint64
 }
 
+type signedKey struct {
+	height  uint64
+	round   uint64
+	msgType walRecordType
+}
+
 type getProposalBlockMsg struct {
 	hash       common.Hash
 	resultChan chan model.AbstractBlock
@@ -79,6 +96,7 @@ func NewStateHandler(bftConfig *BftConfig, timeConfig Config, blockPool *compone
 		voteChan:             make(chan *model.VoteMsg, 5),
 		getProposalBlockChan: make(chan getProposalBlockMsg),
 		ticker:               components.NewTimeoutTicker(),
+		signed:               make(map[signedKey]common.Hash),
 	}
 	h.BftConfig = bftConfig
 
@@ -94,6 +112,13 @@ func NewStateHandler(bftConfig *BftConfig, timeConfig Config, blockPool *compone
 
 func (h *StateHandler) OnStart() error {
 	log.PBft.Info("StateHandler OnStart~~~~~~~~~~~~~~~~~")
+	// restore what has been signed before the restart, so that it won't sign conflicting msgs
+	if h.wal != nil {
+		if err := h.wal.Open(); err != nil {
+			return err
+		}
+		h.replayWAL()
+	}
 	h.ticker = components.NewTimeoutTicker()
 	h.ticker.Start()
 	go h.loop()
@@ -102,6 +127,11 @@ func (h *StateHandler) OnStart() error {
 
 func (h *StateHandler) OnStop() {
 	h.ticker.Stop()
+	if h.wal != nil {
+		if err := h.wal.Close(); err != nil {
+			log.PBft.Warn("close consensus wal failed", "err", err)
+		}
+	}
 }
 
 func (h *StateHandler) OnReset() error { return nil }
@@ -162,10 +192,12 @@ func (h *StateHandler) OnNewHeight(height uint64) {
 	if h.ChainReader.IsChangePoint(Block, false) {
 		verifiers := h.ChainReader.GetNextVerifiers()
 		h.bs.OnNewHeight(height, 0, verifiers)
+		h.replayWAL()
 		return
 	}
 
 	h.bs.OnNewHeight(height, round+1, h.ChainReader.GetCurrVerifiers())
+	h.replayWAL()
 	log.PBft.Debug(fmt.Sprintf("EnterNewHeight (H: %v, R: %v, S: %v)", h.bs.Height, h.bs.Round, h.bs.Step))
 }
 
@@ -291,10 +323,20 @@ func (h *StateHandler) OnNewProposal(proposal *model2.Proposal) {
 func (h *StateHandler) OnPreVote(pv *model.VoteMsg) {
 	log.PBft.Info("[StateHandler-OnPreVote]")
 	_, preRound, preStep := h.RecordCurState()
+	preLocked, preLockedRound := h.bs.LockedBlock, h.bs.LockedRound
 	h.bs.OnPreVote(pv)
 	_, curRound, curStep := h.RecordCurState()
 	log.PBft.Info(fmt.Sprintf("Prevote, state change from (R:%v, S:%s) to (R:%v, S:%s)", preRound, preStep, curRound, curStep))
 
+	// the lock must be on disk before the vote on it is sent out, the node doesn't precommit without it
+	// and the round times out
+	if preLocked != h.bs.LockedBlock || preLockedRound != h.bs.LockedRound {
+		if err := h.writeLock(); err != nil {
+			log.PBft.Error("write lock to consensus wal failed", "err", err)
+			return
+		}
+	}
+
 	switch {
 	case preRound == curRound && preStep == model2.RoundStepPreVote && curStep == model2.RoundStepPreCommit:
 		h.onEnterPrecommit()
@@ -360,7 +402,7 @@ func (h *StateHandler) broadcastNewRoundMsg() {
 		Round:  h.bs.Round,
 	}
 
-	sign, err := h.BftConfig.Signer.SignHash(msg.Hash().Bytes())
+	sign, err := h.signNewRound(msg)
 	if err != nil {
 		log.Warn("sign new round msg failed", "err", err)
 		return
@@ -369,6 +411,10 @@ func (h *StateHandler) broadcastNewRoundMsg() {
 		Address: h.BftConfig.Signer.GetAddress(),
 		Sign:    sign,
 	}
+	// new round msgs can't conflict, failing to record one only loses the round after a restart
+	if err = h.writeWAL(walNewRound, msg.Height, msg.Round, msg); err != nil {
+		log.PBft.Error("write new round msg to consensus wal failed", "err", err)
+	}
 
 	log.PBft.Info("StateHandler#broadcastNewRoundMsg")
 	h.Sender.BroadcastMsg(uint64(model2.TypeOfNewRoundMsg), msg)
@@ -456,15 +502,23 @@ func (h *StateHandler) onEnterPropose() {
 		BlockID:   block.Hash(),
 		Timestamp: time.Now(),
 	}
-	sign, err := h.BftConfig.Signer.SignHash(msg.Hash().Bytes())
+	if err := h.checkSign(walProposal, msg.Height, msg.Round, msg.BlockID); err != nil {
+		log.PBft.Error("refuse to sign proposal", "height", msg.Height, "round", msg.Round, "block", msg.BlockID.Hex(), "err", err)
+		return
+	}
+	sign, err := h.signProposal(&msg)
 	if err != nil {
-		log.PBft.Warn("sign new round msg failed", "err", err)
+		log.PBft.Warn("sign proposal failed", "err", err)
 		return
 	}
 	msg.Witness = &model.WitMsg{
 		Address: h.BftConfig.Signer.GetAddress(),
 		Sign:    sign,
 	}
+	if err = h.recordSigned(walProposal, msg.Height, msg.Round, msg.BlockID, &msg); err != nil {
+		log.PBft.Error("write proposal to consensus wal failed", "err", err)
+		return
+	}
 
 	//Send proposal to other verifiers
 	h.Sender.BroadcastMsg(uint64(model2.TypeOfProposalMsg), msg)
@@ -587,15 +641,20 @@ func (h *StateHandler) onPreCommitTimeout() {
 }
 
 func (h *StateHandler) signAndPrevote(msg *model.VoteMsg) {
+	if err := h.checkSign(walPreVote, msg.Height, msg.Round, msg.BlockID); err != nil {
+		log.PBft.Error("refuse to sign prevote", "height", msg.Height, "round", msg.Round, "block", msg.BlockID.Hex(), "err", err)
+		return
+	}
 	// sign msg
-	sign, err := h.BftConfig.Signer.SignHash(msg.Hash().Bytes())
+	witness, err := h.signVote(msg, false)
 	if err != nil {
 		log.Warn("sign vote msg failed", "err", err)
 		return
 	}
-	msg.Witness = &model.WitMsg{
-		Address: h.BftConfig.Signer.GetAddress(),
-		Sign:    sign,
+	msg.Witness = witness
+	if err = h.recordSigned(walPreVote, msg.Height, msg.Round, msg.BlockID, msg); err != nil {
+		log.PBft.Error("write prevote to consensus wal failed", "err", err)
+		return
 	}
 
 	h.Sender.BroadcastMsg(uint64(model2.TypeOfPreVoteMsg), msg)
@@ -604,15 +663,20 @@ func (h *StateHandler) signAndPrevote(msg *model.VoteMsg) {
 }
 
 func (h *StateHandler) signAndVote(msg *model.VoteMsg) {
+	if err := h.checkSign(walVote, msg.Height, msg.Round, msg.BlockID); err != nil {
+		log.PBft.Error("refuse to sign vote", "height", msg.Height, "round", msg.Round, "block", msg.BlockID.Hex(), "err", err)
+		return
+	}
 	// sign msg
-	sign, err := h.BftConfig.Signer.SignHash(msg.Hash().Bytes())
+	witness, err := h.signVote(msg, chain_config.GetChainConfig().IsPluto(msg.Height))
 	if err != nil {
 		log.Warn("sign vote msg failed", "err", err)
 		return
 	}
-	msg.Witness = &model.WitMsg{
-		Address: h.BftConfig.Signer.GetAddress(),
-		Sign:    sign,
+	msg.Witness = witness
+	if err = h.recordSigned(walVote, msg.Height, msg.Round, msg.BlockID, msg); err != nil {
+		log.PBft.Error("write vote to consensus wal failed", "err", err)
+		return
 	}
 
 	h.Sender.BroadcastMsg(uint64(model2.TypeOfVoteMsg), msg)
@@ -620,6 +684,60 @@ func (h *StateHandler) signAndVote(msg *model.VoteMsg) {
 	h.OnVote(msg)
 }
 
+// signVote signs the vote and adds the bls signature of the commit if withBls is set
+func (h *StateHandler) signVote(msg *model.VoteMsg, withBls bool) (*model.WitMsg, error) {
+	if signer, ok := h.BftConfig.Signer.(VoteSigner); ok {
+		return signer.SignVote(msg, withBls)
+	}
+	sign, err := h.BftConfig.Signer.SignHash(msg.Hash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	witness := &model.WitMsg{
+		Address: h.BftConfig.Signer.GetAddress(),
+		Sign:    sign,
+	}
+	if withBls {
+		key, err := h.getBlsKey()
+		if err != nil {
+			return nil, err
+		}
+		witness.BlsSign = key.Sign(model.BlsSignHash(msg.Height, msg.Round, msg.BlockID).Bytes()).Marshal()
+	}
+	return witness, nil
+}
+
+func (h *StateHandler) signProposal(msg *model2.Proposal) ([]byte, error) {
+	if signer, ok := h.BftConfig.Signer.(ProposalSigner); ok {
+		return signer.SignProposal(msg)
+	}
+	return h.BftConfig.Signer.SignHash(msg.Hash().Bytes())
+}
+
+func (h *StateHandler) signNewRound(msg *model2.NewRoundMsg) ([]byte, error) {
+	if signer, ok := h.BftConfig.Signer.(ProposalSigner); ok {
+		return signer.SignNewRound(msg)
+	}
+	return h.BftConfig.Signer.SignHash(msg.Hash().Bytes())
+}
+
+func (h *StateHandler) getBlsKey() (*bls.SecretKey, error) {
+	address := h.BftConfig.Signer.GetAddress()
+	if h.blsKey != nil && h.blsKeyAddr.IsEqual(address) {
+		return h.blsKey, nil
+	}
+	signer, ok := h.BftConfig.Signer.(BlsSigner)
+	if !ok {
+		return nil, g_error.ErrBftSignerWithoutBlsKey
+	}
+	key, err := signer.BlsSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	h.blsKey, h.blsKeyAddr = key, address
+	return key, nil
+}
+
 func (h *StateHandler) addTimeoutCount(label string) {
 	g_metrics.Add(g_metrics.BftTimeoutCount, label, 1)
 }
@@ -684,7 +802,7 @@ func (h *StateHandler) GetRoundMsg(height, round uint64) *model2.NewRoundMsg {
 		Height: h.bs.Height,
 		Round:  round,
 	}
-	sign, err := h.Signer.SignHash(msg.Hash().Bytes())
+	sign, err := h.signNewRound(msg)
 	if err != nil {
 		log.Warn("sign new round msg failed", "err", err)
 		return nil
@@ -700,6 +818,114 @@ func (h *StateHandler) SetFetcher(fetcher components.Fetcher) {
 	h.Fetcher = fetcher
 }
 
+// SetWAL must be called before the handler starts
+func (h *StateHandler) SetWAL(wal *WAL) {
+	h.wal = wal
+}
+
+// checkSign refuses a msg for another block at a height and round already signed, which is what the evidence punishes
+func (h *StateHandler) checkSign(msgType walRecordType, height, round uint64, blockId common.Hash) error {
+	if signed, ok := h.signed[signedKey{height: height, round: round, msgType: msgType}]; ok && !signed.IsEqual(blockId) {
+		return g_error.ErrBftConflictSign
+	}
+	return nil
+}
+
+// recordSigned writes the signed msg to the wal and remembers its block, the msg mustn't be sent out if it fails
+func (h *StateHandler) recordSigned(msgType walRecordType, height, round uint64, blockId common.Hash, msg interface{}) error {
+	if err := h.writeWAL(msgType, height, round, msg); err != nil {
+		return err
+	}
+	h.signed[signedKey{height: height, round: round, msgType: msgType}] = blockId
+	return nil
+}
+
+func (h *StateHandler) writeWAL(recordType walRecordType, height, round uint64, msg interface{}) error {
+	if h.wal == nil {
+		return nil
+	}
+	data, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	return h.wal.Write(walRecord{Type: recordType, Height: height, Round: round, Data: data})
+}
+
+func (h *StateHandler) writeLock() error {
+	if h.wal == nil {
+		return nil
+	}
+	var data []byte
+	if h.bs.LockedBlock != nil {
+		var err error
+		if data, err = h.bs.LockedBlock.EncodeRlpToBytes(); err != nil {
+			return err
+		}
+	}
+	return h.wal.Write(walRecord{Type: walLock, Height: h.bs.Height, Round: h.bs.LockedRound, Data: data})
+}
+
+// replayWAL restores the round, the lock and the msgs signed at the current height from the wal
+func (h *StateHandler) replayWAL() {
+	for key := range h.signed {
+		if key.height < h.bs.Height {
+			delete(h.signed, key)
+		}
+	}
+	if h.wal == nil {
+		return
+	}
+
+	records := h.wal.Records(h.bs.Height)
+	for _, record := range records {
+		switch record.Type {
+		case walNewRound:
+			var msg model2.NewRoundMsg
+			if err := rlp.DecodeBytes(record.Data, &msg); err != nil {
+				log.PBft.Warn("decode new round msg in consensus wal failed", "err", err)
+				continue
+			}
+			h.bs.restoreRound(msg.Round)
+			h.bs.NewRound.Add(&msg)
+		case walProposal:
+			var msg model2.Proposal
+			if err := rlp.DecodeBytes(record.Data, &msg); err != nil {
+				log.PBft.Warn("decode proposal in consensus wal failed", "err", err)
+				continue
+			}
+			h.signed[signedKey{height: msg.Height, round: msg.Round, msgType: walProposal}] = msg.BlockID
+		case walPreVote, walVote:
+			var msg model.VoteMsg
+			if err := rlp.DecodeBytes(record.Data, &msg); err != nil {
+				log.PBft.Warn("decode vote in consensus wal failed", "err", err)
+				continue
+			}
+			h.signed[signedKey{height: msg.Height, round: msg.Round, msgType: record.Type}] = msg.BlockID
+			if record.Type == walPreVote {
+				h.bs.PreVotes.AddVote(&msg)
+			} else {
+				h.bs.Votes.AddVote(&msg)
+			}
+		case walLock:
+			if len(record.Data) == 0 {
+				h.bs.LockedBlock = nil
+				continue
+			}
+			var block model.Block
+			if err := rlp.DecodeBytes(record.Data, &block); err != nil {
+				log.PBft.Warn("decode locked block in consensus wal failed", "err", err)
+				continue
+			}
+			h.bs.LockedBlock = &block
+			h.bs.LockedRound = record.Round
+			h.bs.ProposalBlock.AddBlock(&block, record.Round)
+		}
+	}
+	if len(records) > 0 {
+		log.PBft.Info("replay consensus wal", "height", h.bs.Height, "records", len(records), "round", h.bs.Round, "lockedRound", h.bs.LockedRound)
+	}
+}
+
 func (h *StateHandler) RecordCurState() (uint64, uint64, model2.RoundStepType) {
 	return h.bs.Height, h.bs.Round, h.bs.Step
 }
@@ -18,10 +18,15 @@ package state_machine
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/csbft/components"
 	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"github.com/stretchr/testify/assert"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"testing"
@@ -417,3 +422,88 @@ func TestStateHandler_OnNewRound(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 	assert.Equal(t, uint64(2), sh0.bs.Round)
 }
+
+func TestStateHandler_SignVoteWithBls(t *testing.T) {
+	dir := newWALTestDir(t)
+	defer os.RemoveAll(dir)
+	sh := newWALStateHandler(t, filepath.Join(dir, "wal"))
+	defer sh.wal.Close()
+
+	conf := chain_config.GetChainConfig()
+	pluto := conf.PlutoBlock
+	conf.PlutoBlock = big.NewInt(1)
+	defer func() { conf.PlutoBlock = pluto }()
+
+	block := &FakeBlock{1, common.HexToHash("0xa"), nil}
+	msg := &model.VoteMsg{Height: 1, Round: 0, BlockID: block.Hash(), VoteType: model.VoteMessage, Timestamp: time.Now()}
+	sh.signAndVote(msg)
+	assert.Equal(t, 1, sh.bs.Votes.roundBlockVotes(0)[block.Hash()])
+
+	key, err := sh.Signer.(BlsSigner).BlsSecretKey()
+	assert.NoError(t, err)
+	sig, err := bls.UnmarshalSignature(msg.Witness.BlsSign)
+	assert.NoError(t, err)
+	assert.True(t, bls.Verify(key.PublicKey(), model.BlsSignHash(1, 0, block.Hash()).Bytes(), sig))
+
+	// the commits without bls sign are refused after the fork
+	assert.Error(t, sh.bs.Votes.AddVote(MakeNewVote(1, 0, block, 1)))
+	conf.PlutoBlock = big.NewInt(2)
+	assert.NoError(t, sh.bs.Votes.AddVote(MakeNewVote(1, 0, block, 1)))
+}
+
+type fakeVoteSigner struct {
+	*fakeSigner
+	votes     []*model.VoteMsg
+	withBls   []bool
+	proposals int
+	newRounds int
+}
+
+func (signer *fakeVoteSigner) SignVote(vote *model.VoteMsg, withBls bool) (*model.WitMsg, error) {
+	signer.votes = append(signer.votes, vote)
+	signer.withBls = append(signer.withBls, withBls)
+	sign, err := signer.SignHash(vote.Hash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &model.WitMsg{Address: signer.GetAddress(), Sign: sign}, nil
+}
+
+func (signer *fakeVoteSigner) SignProposal(proposal *model2.Proposal) ([]byte, error) {
+	signer.proposals++
+	return signer.SignHash(proposal.Hash().Bytes())
+}
+
+func (signer *fakeVoteSigner) SignNewRound(msg *model2.NewRoundMsg) ([]byte, error) {
+	signer.newRounds++
+	return signer.SignHash(msg.Hash().Bytes())
+}
+
+func TestStateHandler_SignWithVoteSigner(t *testing.T) {
+	dir := newWALTestDir(t)
+	defer os.RemoveAll(dir)
+	sh := newWALStateHandler(t, filepath.Join(dir, "wal"))
+	defer sh.wal.Close()
+	signer := &fakeVoteSigner{fakeSigner: sh.Signer.(*fakeSigner)}
+	sh.Signer = signer
+
+	conf := chain_config.GetChainConfig()
+	pluto := conf.PlutoBlock
+	conf.PlutoBlock = big.NewInt(1)
+	defer func() { conf.PlutoBlock = pluto }()
+
+	block := &FakeBlock{1, common.HexToHash("0xa"), nil}
+	sh.signAndPrevote(&model.VoteMsg{Height: 1, Round: 0, BlockID: block.Hash(), VoteType: model.PreVoteMessage, Timestamp: time.Now()})
+	sh.signAndVote(&model.VoteMsg{Height: 1, Round: 0, BlockID: block.Hash(), VoteType: model.VoteMessage, Timestamp: time.Now()})
+	assert.Len(t, signer.votes, 2)
+	assert.Equal(t, []bool{false, true}, signer.withBls)
+	assert.Equal(t, signer.GetAddress(), signer.votes[0].Witness.Address)
+
+	// the proposals and new round msgs are given to the signer as a whole too
+	_, err := sh.signProposal(&model2.Proposal{Height: 1, Round: 0, BlockID: block.Hash(), Timestamp: time.Now()})
+	assert.NoError(t, err)
+	_, err = sh.signNewRound(&model2.NewRoundMsg{Height: 1, Round: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, signer.proposals)
+	assert.Equal(t, 1, signer.newRounds)
+}
@@ -288,6 +288,13 @@ func (bs *BftState) enterPreCommit(round uint64) {
 	log.PBft.Info(fmt.Sprintf("[EnterPrecommit], (H: %v, R: %v, S: %v)", bs.Height, bs.Round, bs.Step))
 }
 
+// restoreRound moves a restarted verifier back to the round it had reached at this height, so it won't go back to the rounds it has left
+func (bs *BftState) restoreRound(round uint64) {
+	if bs.Step == model2.RoundStepNewHeight && round > bs.Round {
+		bs.Round = round
+	}
+}
+
 // Timeout actions
 
 /*
@@ -26,6 +26,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/model"
 	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"math/big"
 	"time"
@@ -290,6 +291,10 @@ func (fb *FakeBlock) GetVerifications() []model.AbstractVerification {
 	panic("implement me")
 }
 
+func (fb *FakeBlock) GetAggregateCommit() *model.AggregateCommit {
+	panic("implement me")
+}
+
 func (fb *FakeBlock) GetTransactionFees() *big.Int {
 	panic("implement me")
 }
@@ -358,6 +363,7 @@ func (fms *FackMsgSender) BroadcastEiBlock(block model.AbstractBlock) {
 type fakeSigner struct {
 	baseAddr   common.Address
 	privateKey *ecdsa.PrivateKey
+	blsKey     *bls.SecretKey
 }
 
 func newFackSigner(sk *ecdsa.PrivateKey) *fakeSigner {
@@ -374,6 +380,18 @@ func (signer *fakeSigner) SignHash(hash []byte) ([]byte, error) {
 	return crypto.Sign(hash, signer.privateKey)
 }
 
+// the bls key is generated on the first use like the wallets do
+func (signer *fakeSigner) BlsSecretKey() (*bls.SecretKey, error) {
+	if signer.blsKey == nil {
+		key, err := bls.GenerateKey(nil)
+		if err != nil {
+			return nil, err
+		}
+		signer.blsKey = key
+	}
+	return signer.blsKey, nil
+}
+
 //----------------------------
 // FakeFetcher
 type FakeFetcher struct {
@@ -0,0 +1,204 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_machine
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// walRecordType is the kind of a record in the consensus write-ahead log
+type walRecordType uint8
+
+const (
+	walNewRound walRecordType = iota + 1 // own new round msg, written on each round transition
+	walProposal                          // own proposal
+	walPreVote                           // own prevote
+	walVote                              // own vote
+	walLock                              // the locked block, an empty Data means unlocked
+)
+
+// walFrameHeader is the length and the crc32 of the rlp of the record
+const walFrameHeader = 8
+
+var errWALNotOpen = errors.New("consensus wal isn't open")
+
+// walRecord is a record in the consensus write-ahead log, Data is the rlp of the signed msg or the locked block
+type walRecord struct {
+	Type   walRecordType
+	Height uint64
+	Round  uint64
+	Data   []byte
+}
+
+// WAL is the write-ahead log of the msgs signed by this verifier, so that a restarted verifier
+// remembers what it has signed and locked on. Only the records of the latest height are kept,
+// the file is truncated when the first record of a new height is written.
+type WAL struct {
+	path string
+
+	lock    sync.Mutex
+	file    *os.File
+	height  uint64
+	records []walRecord
+}
+
+func NewWAL(path string) *WAL {
+	return &WAL{path: path}
+}
+
+// Open loads the records in the file and opens it for appending. A torn or corrupted tail
+// left by a crash is cut off, the records before it are kept.
+func (w *WAL) Open() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(w.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	w.height, w.records = 0, nil
+	offset := 0
+	for offset < len(content) {
+		record, size, err := decodeWALFrame(content[offset:])
+		if err != nil {
+			log.PBft.Warn("cut off the broken tail of the consensus wal", "path", w.path, "offset", offset, "err", err)
+			break
+		}
+		if record.Height > w.height {
+			w.height, w.records = record.Height, nil
+		}
+		if record.Height == w.height {
+			w.records = append(w.records, record)
+		}
+		offset += size
+	}
+
+	file, err := os.OpenFile(w.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if offset < len(content) {
+		if err = file.Truncate(int64(offset)); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	w.file = file
+	log.PBft.Info("open consensus wal", "path", w.path, "height", w.height, "records", len(w.records))
+	return nil
+}
+
+// Write appends the record and syncs it to the disk, it must be called before the msg is sent out
+func (w *WAL) Write(record walRecord) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file == nil {
+		return errWALNotOpen
+	}
+	frame, err := encodeWALFrame(record)
+	if err != nil {
+		return err
+	}
+
+	// the records of the past heights are no longer needed
+	if record.Height > w.height {
+		if err = w.file.Truncate(0); err != nil {
+			return err
+		}
+		w.height, w.records = record.Height, nil
+	}
+	if _, err = w.file.Write(frame); err != nil {
+		return err
+	}
+	if err = w.file.Sync(); err != nil {
+		return err
+	}
+	if record.Height == w.height {
+		w.records = append(w.records, record)
+	}
+	return nil
+}
+
+// Records returns the records written at the height
+func (w *WAL) Records(height uint64) []walRecord {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if height != w.height {
+		return nil
+	}
+	return append([]walRecord{}, w.records...)
+}
+
+func (w *WAL) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func encodeWALFrame(record walRecord) ([]byte, error) {
+	data, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return nil, err
+	}
+	frame := make([]byte, walFrameHeader+len(data))
+	binary.BigEndian.PutUint32(frame[:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(frame[4:walFrameHeader], crc32.ChecksumIEEE(data))
+	copy(frame[walFrameHeader:], data)
+	return frame, nil
+}
+
+func decodeWALFrame(content []byte) (record walRecord, size int, err error) {
+	if len(content) < walFrameHeader {
+		return record, 0, io.ErrUnexpectedEOF
+	}
+	length := int(binary.BigEndian.Uint32(content[:4]))
+	if len(content)-walFrameHeader < length {
+		return record, 0, io.ErrUnexpectedEOF
+	}
+	data := content[walFrameHeader : walFrameHeader+length]
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(content[4:walFrameHeader]) {
+		return record, 0, errors.New("consensus wal record checksum mismatch")
+	}
+	if err = rlp.DecodeBytes(data, &record); err != nil {
+		return record, 0, err
+	}
+	return record, walFrameHeader + length, nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_machine
+
+import (
+	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/csbft/components"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newWALTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "csbft_wal")
+	assert.NoError(t, err)
+	return dir
+}
+
+// newWALStateHandler builds a handler without starting its loop, so the test drives it step by step
+func newWALStateHandler(t *testing.T, path string) *StateHandler {
+	fc := NewFakeFullChain()
+	fc.SetNewHeightNotifier(func(height uint64) {})
+	sks, _ := CreateKey()
+	config := &BftConfig{fc, &FakeFetcher{}, newFackSigner(sks[0]), &FackMsgSender{}, &FakeValidtor{}}
+	sh := NewStateHandler(config, TestConfig, components.NewBlockPool(fc.Height+1, nil))
+	sh.SetWAL(NewWAL(path))
+	assert.NoError(t, sh.wal.Open())
+	sh.replayWAL()
+	return sh
+}
+
+func TestWAL_WriteAndOpen(t *testing.T) {
+	dir := newWALTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wal")
+
+	wal := NewWAL(path)
+	assert.Equal(t, errWALNotOpen, wal.Write(walRecord{Type: walNewRound, Height: 1}))
+	assert.NoError(t, wal.Open())
+	assert.NoError(t, wal.Write(walRecord{Type: walNewRound, Height: 1, Round: 0, Data: []byte{1}}))
+	assert.NoError(t, wal.Write(walRecord{Type: walPreVote, Height: 1, Round: 0, Data: []byte{2}}))
+	assert.Len(t, wal.Records(1), 2)
+	assert.Nil(t, wal.Records(2))
+	assert.NoError(t, wal.Close())
+
+	// the records of a lower height are dropped once a new height is written
+	wal = NewWAL(path)
+	assert.NoError(t, wal.Open())
+	assert.Len(t, wal.Records(1), 2)
+	assert.NoError(t, wal.Write(walRecord{Type: walNewRound, Height: 2, Round: 0, Data: []byte{3}}))
+	assert.Nil(t, wal.Records(1))
+	assert.NoError(t, wal.Close())
+
+	wal = NewWAL(path)
+	assert.NoError(t, wal.Open())
+	records := wal.Records(2)
+	assert.Len(t, records, 1)
+	assert.Equal(t, []byte{3}, records[0].Data)
+	assert.NoError(t, wal.Close())
+}
+
+func TestWAL_OpenTornTail(t *testing.T) {
+	dir := newWALTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wal")
+
+	wal := NewWAL(path)
+	assert.NoError(t, wal.Open())
+	assert.NoError(t, wal.Write(walRecord{Type: walNewRound, Height: 1, Data: []byte{1}}))
+	assert.NoError(t, wal.Write(walRecord{Type: walPreVote, Height: 1, Data: []byte{2}}))
+	assert.NoError(t, wal.Close())
+
+	// a crash in the middle of the last write
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(path, info.Size()-1))
+
+	wal = NewWAL(path)
+	assert.NoError(t, wal.Open())
+	assert.Len(t, wal.Records(1), 1)
+	assert.NoError(t, wal.Write(walRecord{Type: walVote, Height: 1, Data: []byte{3}}))
+	assert.NoError(t, wal.Close())
+
+	wal = NewWAL(path)
+	assert.NoError(t, wal.Open())
+	records := wal.Records(1)
+	assert.Len(t, records, 2)
+	assert.Equal(t, walVote, records[1].Type)
+	assert.NoError(t, wal.Close())
+}
+
+func TestStateHandler_RefuseConflictSign(t *testing.T) {
+	dir := newWALTestDir(t)
+	defer os.RemoveAll(dir)
+
+	sh := newWALStateHandler(t, filepath.Join(dir, "wal"))
+	defer sh.wal.Close()
+
+	blockA, blockB := common.HexToHash("0xa"), common.HexToHash("0xb")
+	sh.signAndPrevote(&model.VoteMsg{Height: 1, Round: 0, BlockID: blockA, VoteType: model.PreVoteMessage, Timestamp: time.Now()})
+	assert.Len(t, sh.wal.Records(1), 1)
+
+	assert.NoError(t, sh.checkSign(walPreVote, 1, 0, blockA))
+	assert.Equal(t, g_error.ErrBftConflictSign, sh.checkSign(walPreVote, 1, 0, blockB))
+	assert.NoError(t, sh.checkSign(walPreVote, 1, 1, blockB))
+	assert.NoError(t, sh.checkSign(walVote, 1, 0, blockB))
+
+	// the conflicting prevote is neither recorded nor sent
+	sh.signAndPrevote(&model.VoteMsg{Height: 1, Round: 0, BlockID: blockB, VoteType: model.PreVoteMessage, Timestamp: time.Now()})
+	assert.Len(t, sh.wal.Records(1), 1)
+	assert.Equal(t, 1, sh.bs.PreVotes.roundBlockVotes(0)[blockA])
+	assert.Equal(t, 0, sh.bs.PreVotes.roundBlockVotes(0)[blockB])
+}
+
+func TestStateHandler_ReplayWAL(t *testing.T) {
+	dir := newWALTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wal")
+
+	header := model.NewHeader(1, 1, common.HexToHash("0x232"), common.HexToHash("0x1"), common.HexToDiff("1fffffff"), big.NewInt(time.Now().UnixNano()), common.HexToAddress("0x032f14"), common.BlockNonceFromInt(1))
+	block := model.NewBlock(header, nil, []model.AbstractVerification{})
+
+	sh := newWALStateHandler(t, path)
+	sh.bs.enterNewRound(1, 2)
+	sh.broadcastNewRoundMsg()
+	sh.signAndPrevote(&model.VoteMsg{Height: 1, Round: 2, BlockID: block.Hash(), VoteType: model.PreVoteMessage, Timestamp: time.Now()})
+	sh.bs.LockedBlock, sh.bs.LockedRound = block, 2
+	assert.NoError(t, sh.writeLock())
+	sh.signAndVote(&model.VoteMsg{Height: 1, Round: 2, BlockID: block.Hash(), VoteType: model.VoteMessage, Timestamp: time.Now()})
+	sh.OnStop()
+
+	// restart at the same height
+	sh = newWALStateHandler(t, path)
+	defer sh.wal.Close()
+	assert.Equal(t, uint64(1), sh.bs.Height)
+	assert.Equal(t, uint64(2), sh.bs.Round)
+	assert.Equal(t, model2.RoundStepNewHeight, sh.bs.Step)
+	assert.Equal(t, block.Hash(), sh.bs.LockedBlock.Hash())
+	assert.Equal(t, uint64(2), sh.bs.LockedRound)
+	assert.Equal(t, block.Hash(), sh.bs.ProposalBlock.GetBlock(2).Hash())
+	assert.Equal(t, 1, sh.bs.PreVotes.roundBlockVotes(2)[block.Hash()])
+	assert.Equal(t, 1, sh.bs.Votes.roundBlockVotes(2)[block.Hash()])
+	assert.NotNil(t, sh.bs.NewRound.RoundMessages[2][sh.Signer.GetAddress()])
+	assert.Equal(t, g_error.ErrBftConflictSign, sh.checkSign(walPreVote, 1, 2, common.HexToHash("0xb")))
+	assert.Equal(t, g_error.ErrBftConflictSign, sh.checkSign(walVote, 1, 2, common.HexToHash("0xb")))
+
+	// signing the same vote again is allowed and only counted once
+	sh.signAndVote(&model.VoteMsg{Height: 1, Round: 2, BlockID: block.Hash(), VoteType: model.VoteMessage, Timestamp: time.Now()})
+	assert.Equal(t, 1, sh.bs.Votes.roundBlockVotes(2)[block.Hash()])
+}
+
+func TestStateHandler_OnPreVoteWriteLockFailed(t *testing.T) {
+	dir := newWALTestDir(t)
+	defer os.RemoveAll(dir)
+
+	header := model.NewHeader(1, 1, common.HexToHash("0x232"), common.HexToHash("0x1"), common.HexToDiff("1fffffff"), big.NewInt(time.Now().UnixNano()), common.HexToAddress("0x032f14"), common.BlockNonceFromInt(1))
+	block := model.NewBlock(header, nil, []model.AbstractVerification{})
+	prevote := func(walFailed bool) (*StateHandler, *fakeVoteSigner) {
+		sh := newWALStateHandler(t, filepath.Join(dir, fmt.Sprintf("wal_%v", walFailed)))
+		signer := &fakeVoteSigner{fakeSigner: sh.Signer.(*fakeSigner)}
+		sh.Signer = signer
+		if walFailed {
+			assert.NoError(t, sh.wal.Close())
+		}
+		sh.bs.enterNewRound(1, 0)
+		sh.bs.ProposalBlock.AddBlock(block, 0)
+		sh.bs.Step = model2.RoundStepPreVote
+		for i := 1; i <= 3; i++ {
+			sh.OnPreVote(MakeNewProVote(1, 0, block, i))
+		}
+		assert.Equal(t, block.Hash(), sh.bs.LockedBlock.Hash())
+		return sh, signer
+	}
+
+	sh, signer := prevote(false)
+	assert.Len(t, signer.votes, 1)
+	assert.Equal(t, 1, sh.bs.Votes.roundBlockVotes(0)[block.Hash()])
+	assert.NoError(t, sh.wal.Close())
+
+	// the lock isn't on disk, so the node doesn't precommit on it
+	sh, signer = prevote(true)
+	assert.Empty(t, signer.votes)
+	assert.Equal(t, 0, sh.bs.Votes.roundBlockVotes(0)[block.Hash()])
+}
+
+func TestStateHandler_OnStartWALFailed(t *testing.T) {
+	dir := newWALTestDir(t)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, ioutil.WriteFile(file, []byte{}, 0600))
+
+	fc := NewFakeFullChain()
+	sks, _ := CreateKey()
+	config := &BftConfig{fc, &FakeFetcher{}, newFackSigner(sks[0]), &FackMsgSender{}, &FakeValidtor{}}
+	sh := NewStateHandler(config, TestConfig, components.NewBlockPool(fc.Height+1, nil))
+	sh.SetWAL(NewWAL(filepath.Join(file, "wal")))
+	assert.Error(t, sh.Start())
+	assert.False(t, sh.IsRunning())
+}
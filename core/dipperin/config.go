@@ -19,9 +19,15 @@ package dipperin
 import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-state"
 	"github.com/dipperin/dipperin-core/core/dipperin/service"
+	"github.com/dipperin/dipperin-core/core/mine/minemaster"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/dipperin/dipperin-core/third-party/life/aot"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/dipperin/dipperin-core/third-party/rpc"
+	"math/big"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -29,6 +35,14 @@ import (
 	"strings"
 )
 
+// the garbage collection modes of the state tries
+const (
+	// keep the states of the latest blocks only
+	GCModeFull = "full"
+	// keep the states of all blocks
+	GCModeArchive = "archive"
+)
+
 type ExtraServiceFunc func(c ExtraServiceFuncConfig) (apis []rpc.API, services []NodeService)
 type ExtraServiceFuncConfig struct {
 	service.DipperinConfig
@@ -74,7 +88,31 @@ type NodeConfig struct {
 	SoftWalletPassword   string
 	SoftWalletPassPhrase string
 	SoftWalletPath       string
-	IsStartMine          bool
+	// the ipc path or https url of the signer daemon holding the keys, it replaces the soft wallet
+	RemoteSigner string
+	// the client certificate and key of the node and the CA of the daemon certificate, for the https signer
+	RemoteSignerCert string
+	RemoteSignerKey  string
+	RemoteSignerCA   string
+	IsStartMine      bool
+	// download the state of a recent block instead of processing all blocks from the genesis
+	FastSync bool
+	// full or archive, the empty mode is the archive mode
+	GCMode string
+	// the number of the latest block states kept by the full gc mode, 0 for the least number
+	StateRetention uint64
+	// keep the tx history of the addresses for the GetTransactionsByAddress rpc
+	TxHistoryIndex bool
+	// run the hot contracts with the native code compiled by the C compiler, only linux amd64 with cgo
+	VmAOT bool
+	// the share scheme of the mine master pool, pplns or prop, the pool accounting is disabled if it's empty
+	PoolScheme string
+	// the number of the latest shares counted by the pplns scheme
+	PoolPPLNSWindow uint64
+	// the share target is PoolShareMultiple times of the block target
+	PoolShareMultiple uint64
+	// the least worker balance in WU paid by a payout transaction, 0 disables the automatic payouts
+	PoolPayoutThreshold string
 
 	//used to set the default account of pbft
 	DefaultAccountKey string
@@ -93,6 +131,8 @@ type NodeConfig struct {
 	Nat         string
 
 	AllowHosts []string
+	// the json file of the access control of the http and websocket rpc endpoints, no control if it's empty
+	RpcAccessConf string
 
 	PMetricsPort int
 
@@ -100,7 +140,57 @@ type NodeConfig struct {
 }
 
 func (conf NodeConfig) NodeConfigCheck() error {
-	if conf.NoWalletStart {
+	if conf.GCMode != "" && conf.GCMode != GCModeFull && conf.GCMode != GCModeArchive {
+		log.Error("unknown gc mode", "gcMode", conf.GCMode)
+		return g_error.NodeConfGCModeError
+	}
+	if conf.StateRetention != 0 && conf.StateRetention < chain_state.MinStateRetention(chain_config.GetChainConfig()) {
+		log.Error("the state retention is too small", "retention", conf.StateRetention)
+		return g_error.NodeConfRetentionError
+	}
+	if conf.TxHistoryIndex && conf.FastSync {
+		log.Error("the blocks synced by the fast sync aren't indexed")
+		return g_error.NodeConfTxHistoryError
+	}
+	if conf.VmAOT && !aot.Supported {
+		log.Error("the native code of the contracts isn't supported by the platform")
+		return g_error.NodeConfVmAOTError
+	}
+	if conf.PoolScheme != "" {
+		if conf.NodeType != chain_config.NodeTypeOfMineMaster {
+			log.Error("the pool scheme is set but the node isn't a mine master", "nodeType", conf.NodeType)
+			return g_error.NodeConfPoolError
+		}
+		if conf.PoolScheme != minemaster.SchemePPLNS && conf.PoolScheme != minemaster.SchemeProportional {
+			log.Error("unknown pool scheme", "scheme", conf.PoolScheme)
+			return g_error.ErrPoolUnknownScheme
+		}
+		if _, ok := new(big.Int).SetString(conf.PoolPayoutThreshold, 10); conf.PoolPayoutThreshold != "" && !ok {
+			log.Error("invalid pool payout threshold", "threshold", conf.PoolPayoutThreshold)
+			return g_error.NodeConfPoolError
+		}
+	}
+	if conf.RpcAccessConf != "" {
+		if _, err := rpc_interface.LoadAccessConfig(conf.RpcAccessConf); err != nil {
+			log.Error("can't load the rpc access config", "file", conf.RpcAccessConf, "err", err)
+			return g_error.NodeConfRpcAccessError
+		}
+	}
+	if conf.RemoteSigner != "" {
+		if conf.NoWalletStart || conf.SoftWalletPath != "" || conf.SoftWalletPassword != "" || conf.SoftWalletPassPhrase != "" {
+			log.Error("the remote signer is set but there are entered some wallet conf")
+			return g_error.NodeConfSignerError
+		}
+		if strings.HasPrefix(conf.RemoteSigner, "http://") {
+			log.Error("the remote signer must be reached by ipc or https", "signer", conf.RemoteSigner)
+			return g_error.NodeConfSignerError
+		}
+		if strings.HasPrefix(conf.RemoteSigner, "https://") && (conf.RemoteSignerCert == "" || conf.RemoteSignerKey == "" || conf.RemoteSignerCA == "") {
+			log.Error("the https remote signer needs the tls cert, key and CA", "signer", conf.RemoteSigner)
+			return g_error.NodeConfSignerError
+		}
+		log.Info("the nodeConf RemoteSigner is:", "RemoteSigner", conf.RemoteSigner)
+	} else if conf.NoWalletStart {
 		if conf.SoftWalletPath != "" || conf.SoftWalletPassword != "" || conf.SoftWalletPassPhrase != "" {
 			log.Error("the NoWalletStart is true but there are entered some wallet conf")
 			return g_error.NodeConfWalletError
@@ -116,6 +206,35 @@ func (conf NodeConfig) NodeConfigCheck() error {
 	return nil
 }
 
+// GetStateRetention returns the number of the latest block states kept by the chain, 0 for the archive mode
+func (conf NodeConfig) GetStateRetention() uint64 {
+	if conf.GCMode != GCModeFull {
+		return 0
+	}
+	if min := chain_state.MinStateRetention(chain_config.GetChainConfig()); conf.StateRetention < min {
+		return min
+	}
+	return conf.StateRetention
+}
+
+// GetPoolConfig returns the pool accounting config of the mine master, nil if it isn't enabled
+func (conf NodeConfig) GetPoolConfig() *minemaster.PoolConfig {
+	if conf.PoolScheme == "" || conf.NodeType != chain_config.NodeTypeOfMineMaster {
+		return nil
+	}
+	pool := &minemaster.PoolConfig{
+		Scheme:        conf.PoolScheme,
+		PPLNSWindow:   conf.PoolPPLNSWindow,
+		ShareMultiple: conf.PoolShareMultiple,
+		Confirmations: chain_config.GetChainConfig().RollBackNum,
+		DataDir:       conf.DataDir,
+	}
+	if threshold, ok := new(big.Int).SetString(conf.PoolPayoutThreshold, 10); ok && threshold.Sign() > 0 {
+		pool.PayoutThreshold = threshold
+	}
+	return pool
+}
+
 func (conf NodeConfig) GetIsStartMine() bool {
 	return conf.IsStartMine
 }
@@ -128,6 +247,14 @@ func (conf NodeConfig) GetAllowHosts() []string {
 	return conf.AllowHosts
 }
 
+// GetRpcAccessConfig returns the access config of the http and websocket rpc endpoints, nil if there isn't one
+func (conf NodeConfig) GetRpcAccessConfig() (*rpc_interface.AccessConfig, error) {
+	if conf.RpcAccessConf == "" {
+		return nil, nil
+	}
+	return rpc_interface.LoadAccessConfig(conf.RpcAccessConf)
+}
+
 func (conf NodeConfig) GetIsUploadNodeData() int {
 	return conf.IsUploadNodeData
 }
@@ -17,7 +17,13 @@
 package dipperin
 
 import (
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-state"
+	"github.com/dipperin/dipperin-core/core/mine/minemaster"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -30,6 +36,112 @@ func TestNodeConfig_FullChainDBDir(t *testing.T) {
 	assert.Equal(t, "full_chain_data", result)
 }
 
+func TestNodeConfig_NodeConfigCheck(t *testing.T) {
+	nodeConfig := NodeConfig{NoWalletStart: true, FastSync: true}
+	assert.NoError(t, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.GCMode = "none"
+	assert.Equal(t, g_error.NodeConfGCModeError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.GCMode = GCModeFull
+	nodeConfig.StateRetention = 1
+	assert.Equal(t, g_error.NodeConfRetentionError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.StateRetention = 0
+	nodeConfig.TxHistoryIndex = true
+	assert.Equal(t, g_error.NodeConfTxHistoryError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.FastSync = false
+	nodeConfig.PoolScheme = minemaster.SchemePPLNS
+	assert.Equal(t, g_error.NodeConfPoolError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.NodeType = chain_config.NodeTypeOfMineMaster
+	assert.NoError(t, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.PoolPayoutThreshold = "1DIP"
+	assert.Equal(t, g_error.NodeConfPoolError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.PoolScheme = "pps"
+	assert.Equal(t, g_error.ErrPoolUnknownScheme, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.PoolScheme = ""
+	nodeConfig.RpcAccessConf = filepath.Join(os.TempDir(), "not_exist_rpc_access.json")
+	assert.Equal(t, g_error.NodeConfRpcAccessError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.RpcAccessConf = ""
+	nodeConfig.RemoteSigner = "/tmp/signer.ipc"
+	assert.Equal(t, g_error.NodeConfSignerError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.NoWalletStart = false
+	assert.NoError(t, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.SoftWalletPassword = "12345678"
+	assert.Equal(t, g_error.NodeConfSignerError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.SoftWalletPassword = ""
+	nodeConfig.RemoteSigner = "http://127.0.0.1:10000"
+	assert.Equal(t, g_error.NodeConfSignerError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.RemoteSigner = "https://127.0.0.1:10000"
+	assert.Equal(t, g_error.NodeConfSignerError, nodeConfig.NodeConfigCheck())
+
+	nodeConfig.RemoteSignerCert, nodeConfig.RemoteSignerKey, nodeConfig.RemoteSignerCA = "node.crt", "node.key", "ca.crt"
+	assert.NoError(t, nodeConfig.NodeConfigCheck())
+}
+
+func TestNodeConfig_GetRpcAccessConfig(t *testing.T) {
+	nodeConfig := NodeConfig{}
+	access, err := nodeConfig.GetRpcAccessConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, access)
+
+	dir, err := ioutil.TempDir("", "rpc_access")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	nodeConfig.RpcAccessConf = filepath.Join(dir, "access.json")
+	assert.NoError(t, ioutil.WriteFile(nodeConfig.RpcAccessConf, []byte(`{"modules":["dipperin"],"max_batch_size":10}`), 0644))
+	access, err = nodeConfig.GetRpcAccessConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dipperin"}, access.Modules)
+	assert.Equal(t, 10, access.MaxBatchSize)
+}
+
+func TestNodeConfig_GetPoolConfig(t *testing.T) {
+	nodeConfig := NodeConfig{NodeType: chain_config.NodeTypeOfMineMaster, DataDir: "/tmp/pool"}
+	assert.Nil(t, nodeConfig.GetPoolConfig())
+
+	nodeConfig.PoolScheme = minemaster.SchemeProportional
+	nodeConfig.PoolShareMultiple = 100
+	nodeConfig.PoolPayoutThreshold = "0"
+	assert.Equal(t, &minemaster.PoolConfig{
+		Scheme:        minemaster.SchemeProportional,
+		ShareMultiple: 100,
+		Confirmations: chain_config.GetChainConfig().RollBackNum,
+		DataDir:       "/tmp/pool",
+	}, nodeConfig.GetPoolConfig())
+
+	nodeConfig.PoolPayoutThreshold = "1000"
+	assert.Equal(t, big.NewInt(1000), nodeConfig.GetPoolConfig().PayoutThreshold)
+
+	nodeConfig.NodeType = chain_config.NodeTypeOfNormal
+	assert.Nil(t, nodeConfig.GetPoolConfig())
+}
+
+func TestNodeConfig_GetStateRetention(t *testing.T) {
+	nodeConfig := NodeConfig{}
+	assert.Equal(t, uint64(0), nodeConfig.GetStateRetention())
+
+	min := chain_state.MinStateRetention(chain_config.GetChainConfig())
+	nodeConfig.GCMode = GCModeFull
+	assert.Equal(t, min, nodeConfig.GetStateRetention())
+
+	nodeConfig.StateRetention = min + 1
+	assert.Equal(t, min+1, nodeConfig.GetStateRetention())
+
+	nodeConfig.GCMode = GCModeArchive
+	assert.Equal(t, uint64(0), nodeConfig.GetStateRetention())
+}
+
 func TestNodeConfig_GetAllowHosts(t *testing.T) {
 	nodeConfig := NodeConfig{AllowHosts: []string{}}
 	result := nodeConfig.GetAllowHosts()
@@ -0,0 +1,53 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dipperin
+
+import (
+	"github.com/dipperin/dipperin-core/core/chain"
+	"github.com/dipperin/dipperin-core/core/csbft/state-machine"
+	"time"
+)
+
+// LoadGenesisSpec applies the genesis spec which is written to the data dir by the init command, the node runs
+// the chain of the boot env if the data dir hasn't the spec. It must be called before the node is built.
+func LoadGenesisSpec(dataDir string) error {
+	spec, err := chain.ReadGenesisSpec(dataDir)
+	if err != nil || spec == nil {
+		return err
+	}
+	if err = spec.Apply(); err != nil {
+		return err
+	}
+
+	state_machine.DefaultConfig = bftConfig(spec.Bft, state_machine.DefaultConfig)
+	return nil
+}
+
+// bftConfig overrides the bft timeouts by the non-zero timeouts of the spec
+func bftConfig(spec chain.GenesisBftConfig, conf state_machine.Config) state_machine.Config {
+	override := func(d chain.GenesisDuration, timeout *time.Duration) {
+		if d != 0 {
+			*timeout = time.Duration(d)
+		}
+	}
+	override(spec.WaitNewRound, &conf.WaitNewRound)
+	override(spec.WaitProposeTimeout, &conf.WaitProposeTimeout)
+	override(spec.ProposalTimeout, &conf.ProposalTimeout)
+	override(spec.PreVoteTimeout, &conf.PreVoteTimeout)
+	override(spec.PreCommitTimeout, &conf.PreCommitTimeout)
+	return conf
+}
@@ -0,0 +1,48 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package dipperin
+
+import (
+	"github.com/dipperin/dipperin-core/core/chain"
+	"github.com/dipperin/dipperin-core/core/csbft/state-machine"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoadGenesisSpec(t *testing.T) {
+	dataDir := "/tmp/dipperin_genesis_spec_test"
+	os.RemoveAll(dataDir)
+
+	// the data dir without the spec runs the boot env
+	conf := state_machine.DefaultConfig
+	assert.NoError(t, LoadGenesisSpec(dataDir))
+	assert.Equal(t, conf, state_machine.DefaultConfig)
+}
+
+func Test_bftConfig(t *testing.T) {
+	conf := bftConfig(chain.GenesisBftConfig{
+		ProposalTimeout:  chain.GenesisDuration(3 * time.Second),
+		PreCommitTimeout: chain.GenesisDuration(time.Second),
+	}, state_machine.DefaultConfig)
+
+	assert.Equal(t, 3*time.Second, conf.ProposalTimeout)
+	assert.Equal(t, time.Second, conf.PreCommitTimeout)
+	assert.Equal(t, state_machine.DefaultConfig.WaitNewRound, conf.WaitNewRound)
+	assert.Equal(t, state_machine.DefaultConfig.PreVoteTimeout, conf.PreVoteTimeout)
+}
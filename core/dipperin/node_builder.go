@@ -22,6 +22,7 @@ import (
 	"github.com/dipperin/dipperin-core/common/g-metrics"
 	"github.com/dipperin/dipperin-core/common/util"
 	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/dipperin/dipperin-core/core/accounts/remote-signer"
 	"github.com/dipperin/dipperin-core/core/accounts/soft-wallet"
 	"github.com/dipperin/dipperin-core/core/chain"
 	"github.com/dipperin/dipperin-core/core/chain-communication"
@@ -41,6 +42,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/rpc-interface"
 	"github.com/dipperin/dipperin-core/core/tx-pool"
 	"github.com/dipperin/dipperin-core/core/verifiers-halt-check"
+	"github.com/dipperin/dipperin-core/core/vm"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/dipperin/dipperin-core/third-party/p2p"
 	"github.com/dipperin/dipperin-core/third-party/p2p/nat"
@@ -82,7 +84,8 @@ type BaseComponent struct {
 	verifiersReader             VerifiersReader
 	chainService                *service.VenusFullChainService
 	walletManager               *accounts.WalletManager
-	msgSigner                   *accounts.WalletSigner
+	msgSigner                   chain_communication.PbftSigner
+	remoteSigner                *remote_signer.Client
 	bftNode                     *csbftnode.CsBft
 	p2pServer                   *p2p.Server
 	broadcastDelegate           *chain_communication.BroadcastDelegate
@@ -186,7 +189,7 @@ func (b *BaseComponent) setNodeSignerInfo() error {
 
 	b.coinbaseAddr.Store(account.Address)
 	b.defaultAccountAddress = account.Address
-	b.msgSigner = accounts.MakeWalletSigner(b.defaultAccountAddress, b.walletManager)
+	b.msgSigner = b.makeMsgSigner()
 
 	b.DipperinConfig.DefaultAccount = b.defaultAccountAddress
 	b.DipperinConfig.MsgSigner = b.msgSigner
@@ -263,6 +266,8 @@ func (b *BaseComponent) buildCommunicationConfig() {
 		VerifiersReader: b.verifiersReader,
 		PbftNode:        b.bftNode,
 		MsgSigner:       b.msgSigner,
+		FastSync:        b.nodeConfig.FastSync,
+		StateChain:      b.fullChain,
 	}
 	b.txBConf = &chain_communication.NewTxBroadcasterConfig{
 		P2PMsgDecoder: b.defaultMsgDecoder,
@@ -278,6 +283,10 @@ func (b *BaseComponent) buildMineConfig(modelConfig builder.ModelConfig) minemas
 		CoinbaseAddress:  b.coinbaseAddr,
 		BlockBuilder:     builder.MakeBftBlockBuilder(modelConfig),
 		BlockBroadcaster: b.broadcastDelegate,
+		Pool:             b.nodeConfig.GetPoolConfig(),
+		RewardCalculator: b.fullChain.EconomyModel,
+		ChainReader:      b.fullChain,
+		PayoutSender:     b.chainService,
 	}
 }
 
@@ -294,11 +303,16 @@ func (b *BaseComponent) builderModelConfig() builder.ModelConfig {
 func (b *BaseComponent) initFullChain() {
 	// init full chain
 	b.fullChain = cs_chain.NewCsChainService(b.csChainServiceConfig, chain_state.NewChainState(&chain_state.ChainStateConfig{
-		ChainConfig:   b.chainConfig,
-		DataDir:       b.nodeConfig.DataDir,
-		WriterFactory: chain_writer.NewChainWriterFactory(),
+		ChainConfig:    b.chainConfig,
+		DataDir:        b.nodeConfig.DataDir,
+		WriterFactory:  chain_writer.NewChainWriterFactory(),
+		StateRetention: b.nodeConfig.GetStateRetention(),
+		TxHistoryIndex: b.nodeConfig.TxHistoryIndex,
 	}))
 	b.csChainServiceConfig.CacheDB = cachedb.NewCacheDB(b.fullChain.GetDB())
+	if b.nodeConfig.VmAOT {
+		vm.EnableAOT(filepath.Join(b.nodeConfig.DataDir, "aot"))
+	}
 	cachedb.SetCacheDataDecoder(&cachedb.BFTCacheDataDecoder{})
 
 	b.verifiersReader = chain.MakeVerifiersReader(b.fullChain)
@@ -334,8 +348,20 @@ func (b *BaseComponent) initChainService() {
 	b.chainService = service.MakeFullChainService(b.DipperinConfig)
 }
 
+// the keys are kept by the signer daemon if there is one, its slashing protection sees all the votes
+func (b *BaseComponent) makeMsgSigner() chain_communication.PbftSigner {
+	if b.remoteSigner != nil {
+		return remote_signer.NewSigner(b.remoteSigner, b.defaultAccountAddress)
+	}
+	return accounts.MakeWalletSigner(b.defaultAccountAddress, b.walletManager)
+}
+
 func (b *BaseComponent) initWalletManager() {
 	var err error
+	if b.nodeConfig.RemoteSigner != "" {
+		b.initRemoteWalletManager()
+		return
+	}
 	if b.nodeConfig.NoWalletStart {
 		if b.walletManager, err = accounts.NewWalletManager(b.chainService); err != nil {
 			panic("init wallet manager failed: " + err.Error())
@@ -373,6 +399,22 @@ func (b *BaseComponent) initWalletManager() {
 	return
 }
 
+func (b *BaseComponent) initRemoteWalletManager() {
+	var err error
+	b.remoteSigner, err = remote_signer.Dial(b.nodeConfig.RemoteSigner, &remote_signer.TLSConfig{
+		CertFile: b.nodeConfig.RemoteSignerCert,
+		KeyFile:  b.nodeConfig.RemoteSignerKey,
+		CAFile:   b.nodeConfig.RemoteSignerCA,
+	})
+	if err != nil {
+		panic("dial remote signer failed: " + err.Error())
+	}
+	if b.walletManager, err = accounts.NewWalletManager(b.chainService, remote_signer.NewWallet(b.remoteSigner)); err != nil {
+		panic("init wallet manager failed: " + err.Error())
+	}
+	log.Info("use the remote signer", "signer", b.nodeConfig.RemoteSigner)
+}
+
 /*func (b *BaseComponent) initWalletManager() {
 	tmpLog := log.New()
 	tmpLog.SetHandler(log.StdoutHandler)
@@ -476,6 +518,7 @@ func (b *BaseComponent) initRpc() {
 	rpcApi := rpc_interface.MakeDipperinVenusApi(b.chainService)
 	debugApi := rpc_interface.MakeDipperinDebugApi(b.chainService)
 	p2pApi := rpc_interface.MakeDipperinP2PApi(b.chainService)
+	txPoolApi := rpc_interface.MakeDipperinTxPoolApi(b.chainService)
 	externalApi := rpc_interface.MakeDipperExternalApi(rpcApi)
 
 	b.rpcService = rpc_interface.MakeRpcService(b.nodeConfig, []rpc.API{
@@ -509,7 +552,18 @@ func (b *BaseComponent) initRpc() {
 			Service:   p2pApi,
 			Public:    false,
 		},
+		{
+			Namespace: "txpool",
+			Version:   "1.0",
+			Service:   txPoolApi,
+			Public:    false,
+		},
 	}, b.nodeConfig.GetAllowHosts())
+	if access, err := b.nodeConfig.GetRpcAccessConfig(); err != nil {
+		panic("can't load the rpc access config: " + err.Error())
+	} else if err = b.rpcService.SetAccessConfig(access); err != nil {
+		panic("invalid rpc access config: " + err.Error())
+	}
 
 	if chain_config.GetCurBootsEnv() != "mercury" {
 		debug.Memsize.Add("rpc server", b.rpcService)
@@ -543,7 +597,7 @@ func (b *BaseComponent) initMsgSigner() {
 		b.msgSigner = nil
 	} else {
 		log.Info("setup default sign address", "addr", b.defaultAccountAddress.Hex())
-		b.msgSigner = accounts.MakeWalletSigner(b.defaultAccountAddress, b.walletManager)
+		b.msgSigner = b.makeMsgSigner()
 	}
 }
 
@@ -553,6 +607,7 @@ func (b *BaseComponent) initBft() {
 	}
 	b.buildBftConfig()
 	b.bftNode = csbftnode.NewCsBft(b.bftConfig)
+	b.bftNode.SetWAL(state_machine.NewWAL(filepath.Join(b.nodeConfig.DataDir, "csbft_wal")))
 }
 
 func (b *BaseComponent) setBftAfterP2PInit() {
@@ -33,6 +33,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/accounts/soft-wallet"
 	"github.com/dipperin/dipperin-core/core/chain-communication"
 	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
 	"github.com/dipperin/dipperin-core/core/chain/state-processor"
 	"github.com/dipperin/dipperin-core/core/contract"
 	"github.com/dipperin/dipperin-core/core/cs-chain/chain-writer/middleware"
@@ -41,9 +42,11 @@ import (
 	"github.com/dipperin/dipperin-core/core/mine/minemaster"
 	"github.com/dipperin/dipperin-core/core/mine/mineworker"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/tx-pool"
 	"github.com/dipperin/dipperin-core/core/vm"
 	"github.com/dipperin/dipperin-core/core/vm/common/utils"
 	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/dipperin/dipperin-core/third-party/p2p"
 	"github.com/dipperin/dipperin-core/third-party/p2p/enode"
@@ -51,6 +54,7 @@ import (
 	vm_log_search "github.com/dipperin/dipperin-core/third-party/vm-log-search"
 	"math/big"
 	"os"
+	"sort"
 	"strings"
 	"time"
 )
@@ -95,6 +99,13 @@ type TxPool interface {
 	AddLocals(txs []model.AbstractTransaction) []error
 	AddRemote(tx model.AbstractTransaction) error
 	Stats() (int, int)
+	Pending() (map[common.Address][]model.AbstractTransaction, error)
+	Queueing() (map[common.Address][]model.AbstractTransaction, error)
+	Status(hashes []common.Hash) []tx_pool.TxStatus
+	Get(hash common.Hash) model.AbstractTransaction
+	DropTx(hash common.Hash) error
+	ReplaceTx(hash common.Hash, tx model.AbstractTransaction) error
+	FlushJournal() error
 }
 
 type Node interface {
@@ -685,6 +696,74 @@ func (service *VenusFullChainService) NewSendTransactions(txs []model.Transactio
 	return len(txs), nil
 }
 
+// TxPoolContent returns the pending and the queued transactions of the tx pool grouped by the senders
+func (service *VenusFullChainService) TxPoolContent() (pending, queued map[common.Address][]model.AbstractTransaction, err error) {
+	if pending, err = service.TxPool.Pending(); err != nil {
+		return nil, nil, err
+	}
+	if queued, err = service.TxPool.Queueing(); err != nil {
+		return nil, nil, err
+	}
+	return pending, queued, nil
+}
+
+// TxPoolStatus returns the status (unknown/queued/pending) of the transactions in the tx pool
+func (service *VenusFullChainService) TxPoolStatus(hashes []common.Hash) []tx_pool.TxStatus {
+	return service.TxPool.Status(hashes)
+}
+
+// TxPoolStats returns the number of the pending and the queued transactions
+func (service *VenusFullChainService) TxPoolStats() (int, int) {
+	return service.TxPool.Stats()
+}
+
+// DropTransaction removes the transaction from the tx pool, e.g. to unblock the stuck nonce
+func (service *VenusFullChainService) DropTransaction(txHash common.Hash) error {
+	return service.TxPool.DropTx(txHash)
+}
+
+// ReplaceTransaction signs the pooled transaction again with a higher gas price by the wallet of the
+// sender, the gas price must be bumped by TxPoolConfig.FeeBump percent at least.
+func (service *VenusFullChainService) ReplaceTransaction(txHash common.Hash, gasPrice *big.Int) (common.Hash, error) {
+	pooled := service.TxPool.Get(txHash)
+	if pooled == nil {
+		return common.Hash{}, g_error.ErrTxNotInPool
+	}
+	tx, ok := pooled.(*model.Transaction)
+	if !ok {
+		return common.Hash{}, g_error.ErrTxNotSupported
+	}
+	from, err := tx.Sender(nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tmpWallet, err := service.WalletManager.FindWalletFromAddress(from)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signedTx, err := tmpWallet.SignTx(accounts.Account{Address: from}, tx.WithGasPrice(gasPrice), service.ChainConfig.ChainId)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := service.TxValidator.Valid(signedTx); err != nil {
+		log.Error("Transaction not valid", "error", err)
+		return common.Hash{}, err
+	}
+	if err := service.TxPool.ReplaceTx(txHash, signedTx); err != nil {
+		return common.Hash{}, err
+	}
+
+	service.Broadcaster.BroadcastTx([]model.AbstractTransaction{signedTx})
+	log.Info("replace transaction", "old", txHash.Hex(), "new", signedTx.CalTxId().Hex(), "gasPrice", gasPrice)
+	return signedTx.CalTxId(), nil
+}
+
+// FlushTxJournal regenerates the local transaction journal with the transactions in the tx pool
+func (service *VenusFullChainService) FlushTxJournal() error {
+	return service.TxPool.FlushJournal()
+}
+
 //send a normal transaction
 func (service *VenusFullChainService) SendTransaction(from, to common.Address, value, gasPrice *big.Int, gasLimit uint64, data []byte, nonce *uint64) (common.Hash, error) {
 	//start:=time.Now()
@@ -727,7 +806,14 @@ func (service *VenusFullChainService) SendRegisterTransaction(from common.Addres
 		return common.Hash{}, err
 	}
 
-	tx := model.NewRegisterTransaction(usedNonce, stake, gasPrice, gasLimit)
+	reg, err := blsRegistration(tmpWallet, accounts.Account{Address: from})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := model.NewBlsRegisterTransaction(usedNonce, stake, gasPrice, gasLimit, reg)
+	if err != nil {
+		return common.Hash{}, err
+	}
 	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
 	if err != nil {
 		return common.Hash{}, err
@@ -738,6 +824,22 @@ func (service *VenusFullChainService) SendRegisterTransaction(from common.Addres
 	return txHash, nil
 }
 
+// the bls key is kept by the wallet of the account, it's the key the node signs the commits with
+func blsRegistration(wallet accounts.Wallet, account accounts.Account) (*model.BlsRegistration, error) {
+	if registrar, ok := wallet.(accounts.BlsRegistrar); ok {
+		return registrar.BlsRegistration(account)
+	}
+	store, ok := wallet.(accounts.BlsKeyStore)
+	if !ok {
+		return nil, accounts.ErrNotSupported
+	}
+	blsKey, err := store.BlsSecretKey(account)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewBlsRegistration(blsKey, account.Address), nil
+}
+
 func (service *VenusFullChainService) getLuckProof(addr common.Address) (common.Hash, []byte, uint64, error) {
 	tmpWallet, err := service.WalletManager.FindWalletFromAddress(addr)
 	if err != nil {
@@ -885,6 +987,157 @@ func (service *VenusFullChainService) SendCancelTransaction(from common.Address,
 	return txHash, nil
 }
 
+//send a lock transaction, lock value at the lock address of from and to until the block number timeLock
+func (service *VenusFullChainService) SendLockTransaction(from, to common.Address, hashLock common.Hash, timeLock, value, gasPrice *big.Int, gasLimit uint64, nonce *uint64) (common.Hash, error) {
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.CreateRawLockTx(usedNonce, hashLock, timeLock, value, gasPrice, gasLimit, from, to)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendLockTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//send a claim transaction, from takes the money locked by alice with the hash key
+func (service *VenusFullChainService) SendClaimTransaction(from, alice common.Address, hashKey []byte, gasPrice *big.Int, gasLimit uint64, nonce *uint64) (common.Hash, error) {
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.CreateRawClaimTx(usedNonce, hashKey, big.NewInt(0), gasPrice, gasLimit, alice, from)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendClaimTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//send a refund transaction, from takes back the money locked for bob after the time lock expired
+func (service *VenusFullChainService) SendRefundTransaction(from, bob common.Address, gasPrice *big.Int, gasLimit uint64, nonce *uint64) (common.Hash, error) {
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.CreateRawRefundTx(usedNonce, big.NewInt(0), gasPrice, gasLimit, from, bob)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendRefundTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//get the lock address of alice and bob, with the locked money, hash lock and time lock on it
+func (service *VenusFullChainService) GetLockInfo(alice, bob common.Address) (lockAddress common.Address, balance *big.Int, hashLock common.Hash, timeLock *big.Int, err error) {
+	lockAddress = cs_crypto.GetLockAddress(alice, bob)
+	state, err := service.ChainReader.CurrentState()
+	if err != nil {
+		return
+	}
+
+	if state.IsEmptyAccount(lockAddress) {
+		return lockAddress, big.NewInt(0), common.Hash{}, big.NewInt(0), nil
+	}
+	if balance, err = state.GetBalance(lockAddress); err != nil {
+		return
+	}
+	if hashLock, err = state.GetHashLock(lockAddress); err != nil {
+		return
+	}
+	timeLock, err = state.GetTimeLock(lockAddress)
+	return
+}
+
+//send a transaction creating the multisig account of the signers, the account is funded with value
+func (service *VenusFullChainService) CreateMultiSigAccount(from common.Address, threshold uint64, signers []common.Address, value, gasPrice *big.Int, gasLimit uint64, nonce *uint64) (common.Hash, error) {
+	info, err := model.NewMultiSigInfo(threshold, signers)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := model.NewMultiSigAccountTransaction(usedNonce, info, value, gasPrice, gasLimit)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the CreateMultiSigAccount txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//get the signer set, threshold, balance and nonce of the multisig account
+func (service *VenusFullChainService) GetMultiSigAccount(address common.Address) (info *model.MultiSigInfo, balance *big.Int, nonce uint64, err error) {
+	state, err := service.ChainReader.CurrentState()
+	if err != nil {
+		return
+	}
+	if info, err = state.GetMultiSig(address); err != nil {
+		return
+	}
+	if balance, err = state.GetBalance(address); err != nil {
+		return
+	}
+	nonce, err = state.GetNonce(address)
+	return
+}
+
+//create an unsigned transaction sent from the multisig account, the signers sign it with SignMultiSigTransaction
+func (service *VenusFullChainService) NewMultiSigTransaction(from, to common.Address, value, gasPrice *big.Int, gasLimit uint64, data []byte, nonce *uint64) (*model.Transaction, error) {
+	info, _, chainNonce, err := service.GetMultiSigAccount(from)
+	if err != nil {
+		return nil, err
+	}
+	if nonce != nil {
+		chainNonce = *nonce
+	}
+	return model.NewMultiSigTransaction(chainNonce, info, to, value, gasPrice, gasLimit, data), nil
+}
+
+//add the signature of signer to the multisig transaction, the signer should be in the wallet
+func (service *VenusFullChainService) SignMultiSigTransaction(signer common.Address, tx *model.Transaction) (*model.Transaction, error) {
+	tmpWallet, err := service.WalletManager.FindWalletFromAddress(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	s := model.NewSigner(service.ChainConfig.ChainId)
+	hash, err := s.GetSignHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := tmpWallet.SignHash(accounts.Account{Address: signer}, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.AddMultiSigSignature(sig, s); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
 //get address nonce from chain
 func (service *VenusFullChainService) GetTransactionNonce(addr common.Address) (nonce uint64, err error) {
 	state, err := service.ChainReader.CurrentState()
@@ -943,6 +1196,72 @@ func (service *VenusFullChainService) Transaction(hash common.Hash) (transaction
 	return transaction, blockHash, blockNum, txIndex, nil
 }
 
+const (
+	// the most txs returned by a page of the tx history
+	maxTxHistoryLimit = 100
+	// the most txs skipped before a page of the tx history, the skipped entries are iterated one by one
+	maxTxHistoryOffset = 10000
+)
+
+// GetTransactionsByAddress returns a page of the txs sent or received by the address from the latest one,
+// the limit is at most maxTxHistoryLimit and the offset is at most maxTxHistoryOffset
+func (service *VenusFullChainService) GetTransactionsByAddress(address common.Address, offset, limit uint64) ([]chaindb.AddressTxEntry, error) {
+	if indexer, ok := service.ChainReader.(middleware.TxHistoryIndexer); !ok || !indexer.TxHistoryIndexEnabled() {
+		return nil, g_error.ErrTxHistoryDisabled
+	}
+	if limit == 0 || limit > maxTxHistoryLimit {
+		limit = maxTxHistoryLimit
+	}
+	if offset > maxTxHistoryOffset {
+		return nil, g_error.ErrTxHistoryOffsetTooLarge
+	}
+	return service.ChainReader.GetChainDB().GetAddressTxEntries(address, offset, limit)
+}
+
+// GetERC20Transfers returns a page of the built-in erc20 token transfers sent or received by the address from
+// the latest one, the limit is at most maxTxHistoryLimit and the offset is at most maxTxHistoryOffset
+func (service *VenusFullChainService) GetERC20Transfers(address common.Address, offset, limit uint64) ([]chaindb.ERC20TransferEntry, error) {
+	if indexer, ok := service.ChainReader.(middleware.TxHistoryIndexer); !ok || !indexer.TxHistoryIndexEnabled() {
+		return nil, g_error.ErrTxHistoryDisabled
+	}
+	if limit == 0 || limit > maxTxHistoryLimit {
+		limit = maxTxHistoryLimit
+	}
+	if offset > maxTxHistoryOffset {
+		return nil, g_error.ErrTxHistoryOffsetTooLarge
+	}
+	return service.ChainReader.GetChainDB().GetERC20Transfers(address, offset, limit)
+}
+
+// GetERC20Holders returns a page of the holders of the built-in erc20 token from the largest balance,
+// the limit is at most maxTxHistoryLimit
+func (service *VenusFullChainService) GetERC20Holders(token common.Address, offset, limit uint64) ([]contract.ERC20Holder, error) {
+	if token.GetAddressType() != common.AddressTypeERC20 {
+		return nil, g_error.ErrNotERC20Address
+	}
+	c, err := service.GetContract(token)
+	if err != nil {
+		return nil, err
+	}
+	erc20, ok := c.(*contract.BuiltInERC20Token)
+	if !ok {
+		return nil, g_error.ErrNotERC20Address
+	}
+
+	holders := contract.GetERC20Holders(erc20)
+	if offset >= uint64(len(holders)) {
+		return []contract.ERC20Holder{}, nil
+	}
+	if limit == 0 || limit > maxTxHistoryLimit {
+		limit = maxTxHistoryLimit
+	}
+	holders = holders[offset:]
+	if uint64(len(holders)) > limit {
+		holders = holders[:limit]
+	}
+	return holders, nil
+}
+
 //Test get verifiers of this round
 func (service *VenusFullChainService) GetVerifiers(slotNum uint64) (addresses []common.Address) {
 	addresses = service.ChainReader.GetVerifiers(slotNum)
@@ -1080,6 +1399,31 @@ func (service *VenusFullChainService) Mining() bool {
 	return false
 }
 
+// GetWorkerBalance returns the pool balance of the worker
+func (service *VenusFullChainService) GetWorkerBalance(address common.Address) (minemaster.WorkerBalance, error) {
+	if service.MineMaster == nil {
+		return minemaster.WorkerBalance{}, errors.New("current node is not mine master")
+	}
+	return service.MineMaster.GetWorkerBalance(address), nil
+}
+
+// GetWorkerBalances returns the pool balances of all the workers
+func (service *VenusFullChainService) GetWorkerBalances() ([]minemaster.WorkerBalance, error) {
+	if service.MineMaster == nil {
+		return nil, errors.New("current node is not mine master")
+	}
+	return service.MineMaster.GetWorkerBalances(), nil
+}
+
+// SendPayout sends the pool payout of a worker with the suggested gas price
+func (service *VenusFullChainService) SendPayout(from, to common.Address, value *big.Int) (common.Hash, error) {
+	gasPrice, err := service.SuggestGasPrice()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return service.SendTransaction(from, to, value, gasPrice, model2.TxGas, nil, nil)
+}
+
 // debug
 func (service *VenusFullChainService) Metrics(raw bool) (map[string]interface{}, error) {
 	/*// Create a rate formatter
@@ -1246,6 +1590,69 @@ func (service *VenusFullChainService) RemovePeer(url string) error {
 	return nil
 }
 
+// BanPeer disconnects the node and refuses it for the given seconds, 0 bans it permanently.
+// The node is given by its enode url or its hex id.
+func (service *VenusFullChainService) BanPeer(id string, seconds uint64) error {
+	server := service.P2PServer
+	if server == nil {
+		return errors.New("no p2p server running")
+	}
+
+	nodeID, err := parseNodeID(id)
+	if err != nil {
+		return fmt.Errorf("invalid node id: %v", err)
+	}
+	return server.BanPeer(nodeID, time.Duration(seconds)*time.Second)
+}
+
+// UnbanPeer lifts the ban of the node
+func (service *VenusFullChainService) UnbanPeer(id string) error {
+	server := service.P2PServer
+	if server == nil {
+		return errors.New("no p2p server running")
+	}
+
+	nodeID, err := parseNodeID(id)
+	if err != nil {
+		return fmt.Errorf("invalid node id: %v", err)
+	}
+	return server.UnbanPeer(nodeID)
+}
+
+func (service *VenusFullChainService) BannedPeers() ([]*p2p.BannedPeerInfo, error) {
+	server := service.P2PServer
+	if server == nil {
+		return nil, errors.New("no p2p server running")
+	}
+
+	result := make([]*p2p.BannedPeerInfo, 0)
+	for id, until := range server.BannedPeers() {
+		info := &p2p.BannedPeerInfo{ID: id.String()}
+		if !until.IsZero() {
+			info.Until = until.Unix()
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func parseNodeID(id string) (enode.ID, error) {
+	if strings.HasPrefix(id, "enode://") {
+		node, err := enode.ParseV4(id)
+		if err != nil {
+			return enode.ID{}, err
+		}
+		return node.ID(), nil
+	}
+
+	var nodeID enode.ID
+	err := nodeID.UnmarshalText([]byte(id))
+	return nodeID, err
+}
+
 func (service *VenusFullChainService) CsPmInfo() (*p2p.CsPmPeerInfo, error) {
 	pm := service.NormalPm.(*chain_communication.CsProtocolManager)
 	return pm.ShowPmInfo(), nil
@@ -1487,6 +1894,19 @@ type SubBlockTxResp struct {
 	//HashKey []byte `json:"hashKey"    gencodec:"required"`
 }
 
+func newSubBlockTxResp(transaction model.AbstractTransaction) *SubBlockTxResp {
+	from, _ := transaction.Sender(nil)
+	return &SubBlockTxResp{
+		TxID:         transaction.CalTxId(),
+		From:         from,
+		AccountNonce: transaction.Nonce(),
+		Recipient:    transaction.To(),
+		Amount:       transaction.Amount(),
+		ExtraData:    transaction.ExtraData(),
+		ExtraDataStr: hexutil.Encode(transaction.ExtraData()),
+	}
+}
+
 // notify wallet
 func (service *VenusFullChainService) SubscribeBlock(ctx context.Context) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -1506,16 +1926,7 @@ func (service *VenusFullChainService) SubscribeBlock(ctx context.Context) (*rpc.
 			case b := <-blockCh:
 				var respTxs []*SubBlockTxResp
 				_ = b.TxIterator(func(i int, transaction model.AbstractTransaction) error {
-					from, _ := transaction.Sender(nil)
-					respTxs = append(respTxs, &SubBlockTxResp{
-						TxID:         transaction.CalTxId(),
-						From:         from,
-						AccountNonce: transaction.Nonce(),
-						Recipient:    transaction.To(),
-						Amount:       transaction.Amount(),
-						ExtraData:    transaction.ExtraData(),
-						ExtraDataStr: hexutil.Encode(transaction.ExtraData()),
-					})
+					respTxs = append(respTxs, newSubBlockTxResp(transaction))
 					return nil
 				})
 
@@ -1542,6 +1953,137 @@ func (service *VenusFullChainService) SubscribeBlock(ctx context.Context) (*rpc.
 	return rpcSub, nil
 }
 
+// notify the transactions entering the pending list of the tx pool
+func (service *VenusFullChainService) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	txsCh := make(chan []model.AbstractTransaction)
+	txsSub := g_event.Subscribe(g_event.NewTxPoolEvent, txsCh)
+
+	go func() {
+		for {
+			select {
+			case txs := <-txsCh:
+				for _, tx := range txs {
+					if err := notifier.Notify(rpcSub.ID, newSubBlockTxResp(tx)); err != nil {
+						log.Error("can't notify pending transaction", "err", err)
+					}
+				}
+
+			case <-rpcSub.Err():
+				txsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				txsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// notify the contract logs of the inserted blocks which match the addresses and the topics,
+// the filter rules are the same as GetLogs
+func (service *VenusFullChainService) SubscribeLogs(ctx context.Context, addresses []common.Address, topics [][]common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	blockCh := make(chan model.Block)
+	blockSub := g_event.Subscribe(g_event.NewBlockInsertEvent, blockCh)
+
+	go func() {
+		for {
+			select {
+			case b := <-blockCh:
+				var unfiltered []*model2.Log
+				for _, receipt := range service.ChainReader.GetReceipts(b.Hash(), b.Number()) {
+					unfiltered = append(unfiltered, receipt.Logs...)
+				}
+				logs := vm_log_search.FilterLogs(unfiltered, addresses, topics)
+				if len(logs) == 0 {
+					continue
+				}
+
+				logs, err := service.convertLogs(logs)
+				if err != nil {
+					log.Error("can't convert subscribed logs", "block", b.Number(), "err", err)
+					continue
+				}
+				for _, l := range logs {
+					if err := notifier.Notify(rpcSub.ID, l); err != nil {
+						log.Error("can't notify logs", "err", err)
+					}
+				}
+
+			case <-rpcSub.Err():
+				blockSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				blockSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// notify the receipt of the transaction once it's included in a block, it's notified at once
+// if the transaction has been included
+func (service *VenusFullChainService) SubscribeReceipt(ctx context.Context, txHash common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	// subscribe the blocks before looking up the receipt, so the inclusion can't be missed
+	blockCh := make(chan model.Block)
+	blockSub := g_event.Subscribe(g_event.NewBlockInsertEvent, blockCh)
+	if receipt, err := service.GetReceiptByTxHash(txHash); err == nil {
+		blockSub.Unsubscribe()
+		if err := notifier.Notify(rpcSub.ID, receipt); err != nil {
+			log.Error("can't notify receipt", "err", err)
+		}
+		return rpcSub, nil
+	}
+
+	go func() {
+		defer blockSub.Unsubscribe()
+
+		for {
+			select {
+			case b := <-blockCh:
+				if b.Transaction(txHash) == nil {
+					continue
+				}
+				receipt, err := service.receiptInBlock(b.Hash(), b.Number(), txHash)
+				if err != nil {
+					log.Error("can't get the receipt of the included transaction", "txHash", txHash.Hex(), "err", err)
+					return
+				}
+				if err := notifier.Notify(rpcSub.ID, receipt); err != nil {
+					log.Error("can't notify receipt", "err", err)
+				}
+				return
+
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // stop this node service
 func (service *VenusFullChainService) StopDipperin() {
 	service.Node.Stop()
@@ -1649,8 +2191,11 @@ func (service *VenusFullChainService) convertLogs(logs []*model2.Log) ([]*model2
 		return []*model2.Log{}, nil
 	}
 
-	// convert logs data
+	// convert logs data, only the logs of the wasm contracts are described by an abi
 	for i := 0; i < len(logs); i++ {
+		if logs[i].Address.GetAddressType() != common.AddressTypeContractCall {
+			continue
+		}
 		abi, err := service.GetABI(logs[i].Address)
 		if err != nil {
 			return nil, err
@@ -1719,7 +2264,11 @@ func (service *VenusFullChainService) GetReceiptByTxHash(txHash common.Hash) (*m
 	if err != nil {
 		return nil, err
 	}
+	return service.receiptInBlock(blockHash, blockNumber, txHash)
+}
 
+// receiptInBlock returns the receipt of the transaction in the block, the logs are converted by the contract abi
+func (service *VenusFullChainService) receiptInBlock(blockHash common.Hash, blockNumber uint64, txHash common.Hash) (*model2.Receipt, error) {
 	receipts := service.ChainReader.GetReceipts(blockHash, blockNumber)
 	if receipts == nil {
 		return nil, g_error.ErrReceiptIsNil
@@ -1876,7 +2425,15 @@ func (service *VenusFullChainService) Call(signedTx model.AbstractTransaction, b
 	for _, v := range abi.AbiArr {
 		if strings.EqualFold(v.Name, funcName) && strings.EqualFold(v.Type, "function") {
 			if len(v.Outputs) != 0 {
-				convertResult := utils.Align32BytesConverter(result, v.Outputs[0].Type)
+				var convertResult interface{}
+				if outputType, innerErr := v.Outputs[0].AbiType(); innerErr == nil && !outputType.IsLegacy() {
+					convertResult, err = utils.DecodeAbiOutput(result, outputType)
+					if err != nil {
+						return "", err
+					}
+				} else {
+					convertResult = utils.Align32BytesConverter(result, v.Outputs[0].Type)
+				}
 				resp = fmt.Sprintf("%v", convertResult)
 			} else {
 				resp = "void"
@@ -1894,10 +2451,13 @@ func (service *VenusFullChainService) Call(signedTx model.AbstractTransaction, b
 func (service *VenusFullChainService) EstimateGas(signedTx model.AbstractTransaction, blockNum uint64) (hexutil.Uint64, error) {
 	log.Info("Service#EstimateGas Start")
 	if signedTx.To().GetAddressType() != common.AddressTypeContractCreate && signedTx.To().GetAddressType() != common.AddressTypeContractCall {
-		gasUsed, err := model.IntrinsicGas(signedTx.ExtraData(), false, false)
+		gasUsed, err := model.IntrinsicGas(signedTx.ExtraData(), false, false, service.ChainConfig.IsEarth(blockNum))
 		if err != nil {
 			return hexutil.Uint64(0), err
 		}
+		if service.ChainConfig.IsEris(blockNum) {
+			gasUsed += model.MultiSigRecoverGas(signedTx)
+		}
 		return hexutil.Uint64(gasUsed), nil
 	}
 
@@ -1927,10 +2487,12 @@ func (service *VenusFullChainService) EstimateGas(signedTx model.AbstractTransac
 	}
 
 	// Create a helper to check if a gas allowance results in an executable transaction
+	var callErr error
 	executable := func(gas uint64) bool {
 		msg.SetGas(gas)
 		_, pass, innerErr := service.doCall(&msg, txHash, block.Number(), 0)
 		log.Info("executable#doCall", "pass", pass)
+		callErr = innerErr
 		if innerErr != nil || pass {
 			return false
 		}
@@ -1953,6 +2515,10 @@ func (service *VenusFullChainService) EstimateGas(signedTx model.AbstractTransac
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if high == capacity {
 		if !executable(high) {
+			// the reverted transaction fails with any gas limit
+			if revertErr, ok := callErr.(*g_error.RevertError); ok {
+				return 0, revertErr
+			}
 			return 0, fmt.Errorf("gas required exceeds allowance or always failing transaction")
 		}
 	}
@@ -2019,15 +2585,8 @@ func (service *VenusFullChainService) MakeTmpSignedTx(args CallArgs, blockNum ui
 func (service *VenusFullChainService) doCall(msg state_processor.Message, txHash common.Hash, blockNum uint64, timeout time.Duration) ([]byte, bool, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
-	// GetBlock and GetState
-	block, err := service.GetBlockByNumber(blockNum)
+	dvm, _, err := service.newCallVM(msg, txHash, blockNum)
 	if err != nil {
-		log.Error("doCall#GetBlockByNumber failed", "err", err, "blockNum", blockNum)
-		return nil, false, err
-	}
-	state, err := service.ChainReader.StateAtByBlockNumber(blockNum)
-	if err != nil {
-		log.Error("doCall#StateAtByBlockNumber failed", "err", err, "blockNum", blockNum)
 		return nil, false, err
 	}
 
@@ -2044,23 +2603,6 @@ func (service *VenusFullChainService) doCall(msg state_processor.Message, txHash
 	// this makes sure resources are cleaned up.
 	defer cancel()
 
-	// Create NewVM
-	log.Info("doCall#gasLimit", "gasLimit", msg.Gas())
-	conText := vm.Context{
-		Origin:      msg.From(),
-		GasPrice:    msg.GasPrice(),
-		GasLimit:    msg.Gas(),
-		BlockNumber: new(big.Int).SetUint64(blockNum),
-		TxHash:      txHash,
-		CanTransfer: vm.CanTransfer,
-		Transfer:    vm.Transfer,
-		Coinbase:    block.Header().CoinBaseAddress(),
-		Time:        block.Header().GetTimeStamp(),
-		GetHash:     service.GetBlockHashByNumber,
-	}
-	fullState := state_processor.NewFullState(state)
-	dvm := vm.NewVM(conText, fullState, vm.DEFAULT_VM_CONFIG)
-
 	/*	// Wait for the context to be done and cancel the evm. Even if the
 		// EVM has finished, cancelling may be done (repeatedly)
 		go func() {
@@ -2074,6 +2616,9 @@ func (service *VenusFullChainService) doCall(msg state_processor.Message, txHash
 	result, _, failed, _, err := state_processor.ApplyMessage(dvm, msg, &gp)
 	if err != nil {
 		log.Error("doCall#ApplyMessage failed", "err", err)
+		if err == g_error.ErrExecutionReverted {
+			err = &g_error.RevertError{Reason: string(result)}
+		}
 		return result, failed, err
 	}
 	if failed {
@@ -2083,6 +2628,173 @@ func (service *VenusFullChainService) doCall(msg state_processor.Message, txHash
 	return result, failed, nil
 }
 
+// newCallVM creates a vm to execute the message on the state of the block
+func (service *VenusFullChainService) newCallVM(msg state_processor.Message, txHash common.Hash, blockNum uint64) (*vm.VM, *state_processor.AccountStateDB, error) {
+	// GetBlock and GetState
+	block, err := service.GetBlockByNumber(blockNum)
+	if err != nil {
+		log.Error("doCall#GetBlockByNumber failed", "err", err, "blockNum", blockNum)
+		return nil, nil, err
+	}
+	state, err := service.ChainReader.StateAtByBlockNumber(blockNum)
+	if err != nil {
+		log.Error("doCall#StateAtByBlockNumber failed", "err", err, "blockNum", blockNum)
+		return nil, nil, err
+	}
+
+	// Create NewVM
+	log.Info("doCall#gasLimit", "gasLimit", msg.Gas())
+	conText := vm.Context{
+		Origin:      msg.From(),
+		GasPrice:    msg.GasPrice(),
+		GasLimit:    msg.Gas(),
+		BlockNumber: new(big.Int).SetUint64(blockNum),
+		TxHash:      txHash,
+		CanTransfer: vm.CanTransfer,
+		Transfer:    vm.Transfer,
+		Coinbase:    block.Header().CoinBaseAddress(),
+		Time:        block.Header().GetTimeStamp(),
+		GetHash:     service.GetBlockHashByNumber,
+	}
+	fullState := state_processor.NewFullState(state)
+	return vm.NewVM(conText, fullState, vm.DEFAULT_VM_CONFIG), state, nil
+}
+
+// TxTrace is the trace of a transaction executed by the debug api
+type TxTrace struct {
+	TxHash    common.Hash                             `json:"txHash"`
+	GasUsed   hexutil.Uint64                          `json:"gasUsed"`
+	Failed    bool                                    `json:"failed"`
+	Error     string                                  `json:"error,omitempty"`
+	Call      *vm.CallFrame                           `json:"call,omitempty"`
+	StateDiff map[string]*state_processor.AccountDiff `json:"stateDiff"`
+}
+
+func newTxTrace(txHash common.Hash, gasUsed uint64, failed bool, err error, tracer *vm.CallTracer, diff map[common.Address]*state_processor.AccountDiff) *TxTrace {
+	trace := &TxTrace{
+		TxHash:    txHash,
+		GasUsed:   hexutil.Uint64(gasUsed),
+		Failed:    failed,
+		Call:      tracer.Result(),
+		StateDiff: make(map[string]*state_processor.AccountDiff, len(diff)),
+	}
+	if err != nil {
+		trace.Error = err.Error()
+	} else if trace.Call != nil {
+		trace.Error = trace.Call.Error
+	}
+	for addr, accountDiff := range diff {
+		trace.StateDiff[addr.Hex()] = accountDiff
+	}
+	return trace
+}
+
+// TraceTransaction re-executes the mined transaction on the state of its parent block
+// after the previous transactions of the block, and returns the trace of it.
+func (service *VenusFullChainService) TraceTransaction(txHash common.Hash) (*TxTrace, error) {
+	tx, _, blockNumber, _, err := service.Transaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, g_error.ErrTransactionNotFound
+	}
+
+	traces, err := service.traceBlockTxs(blockNumber, &txHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(traces) == 0 {
+		return nil, g_error.ErrTransactionNotFound
+	}
+	return traces[0], nil
+}
+
+// TraceBlock re-executes all transactions of the block on the state of its parent block
+func (service *VenusFullChainService) TraceBlock(blockNum uint64) ([]*TxTrace, error) {
+	return service.traceBlockTxs(blockNum, nil)
+}
+
+// traceBlockTxs processes the transactions of the block like the block processor,
+// only the transaction of the hash is traced if it isn't nil
+func (service *VenusFullChainService) traceBlockTxs(blockNum uint64, txHash *common.Hash) ([]*TxTrace, error) {
+	if blockNum == 0 {
+		return nil, g_error.ErrTraceGenesisBlock
+	}
+	block, err := service.GetBlockByNumber(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, g_error.ErrBlockNotFound
+	}
+	processor, err := service.ChainReader.BlockProcessorByNumber(blockNum - 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		traces   []*TxTrace
+		gasUsed  = uint64(0)
+		gasLimit = block.Header().GetGasLimit()
+		found    = errors.New("the traced transaction is found")
+	)
+	err = block.TxIterator(func(i int, tx model.AbstractTransaction) error {
+		conf := state_processor.TxProcessConfig{
+			Tx:           tx,
+			Header:       block.Header(),
+			GetHash:      processor.GetBlockHashByNumber,
+			GetVerifiers: processor.GetVerifiersByNumber,
+			GasUsed:      &gasUsed,
+			GasLimit:     &gasLimit,
+		}
+		if txHash != nil && tx.CalTxId() != *txHash {
+			return processor.ProcessTxNew(&conf)
+		}
+
+		tracer := vm.NewCallTracer()
+		conf.Tracer = tracer
+		snapshot := processor.Snapshot()
+		preGasUsed := gasUsed
+		innerErr := processor.ProcessTxNew(&conf)
+
+		failed := innerErr != nil
+		if receipt := tx.GetReceipt(); innerErr == nil && receipt != nil {
+			failed = receipt.Status == model2.ReceiptStatusFailed
+		}
+		traces = append(traces, newTxTrace(tx.CalTxId(), gasUsed-preGasUsed, failed, innerErr, tracer, processor.DiffSince(snapshot)))
+		if txHash != nil {
+			return found
+		}
+		return nil
+	})
+	if err != nil && err != found {
+		return nil, err
+	}
+	return traces, nil
+}
+
+// TraceCall executes the transaction on the state of the block like Call and returns the trace,
+// the state isn't changed.
+func (service *VenusFullChainService) TraceCall(signedTx model.AbstractTransaction, blockNum uint64) (*TxTrace, error) {
+	msg, err := signedTx.AsMessage(false)
+	if err != nil {
+		return nil, err
+	}
+
+	dvm, state, err := service.newCallVM(&msg, signedTx.CalTxId(), blockNum)
+	if err != nil {
+		return nil, err
+	}
+	tracer := vm.NewCallTracer()
+	dvm.SetTracer(tracer)
+
+	snapshot := state.Snapshot()
+	gp := uint64(math.MaxUint64)
+	_, gasUsed, failed, _, err := state_processor.ApplyMessage(dvm, &msg, &gp)
+	return newTxTrace(signedTx.CalTxId(), gasUsed, failed || err != nil, err, tracer, state.DiffSince(snapshot)), nil
+}
+
 func (service *VenusFullChainService) CheckConstant(to common.Address, data []byte) (bool, string, *utils.WasmAbi, error) {
 	funcName, err := utils.ParseInputForFuncName(data)
 	if err != nil {
@@ -18,6 +18,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/consts"
 	"github.com/dipperin/dipperin-core/common/g-error"
@@ -26,16 +27,20 @@ import (
 	"github.com/dipperin/dipperin-core/core/accounts/soft-wallet"
 	"github.com/dipperin/dipperin-core/core/chain"
 	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
 	contract2 "github.com/dipperin/dipperin-core/core/contract"
 	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/core/mine/minemaster"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/tests"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"github.com/dipperin/dipperin-core/third-party/p2p/enode"
 	"github.com/dipperin/dipperin-core/third-party/vm-log-search"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/stretchr/testify/assert"
 	"math/big"
+	"net"
 	"os"
 	"testing"
 	"time"
@@ -132,6 +137,44 @@ func TestVenusFullChainService_RemovePeer(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestVenusFullChainService_BanPeer(t *testing.T) {
+	service := MakeFullChainService(&DipperinConfig{})
+	assert.Error(t, service.BanPeer("", 0))
+	assert.Error(t, service.UnbanPeer(""))
+	_, err := service.BannedPeers()
+	assert.Error(t, err)
+
+	key, _ := crypto.GenerateKey()
+	server := &p2p.Server{Config: p2p.Config{PrivateKey: key, MaxPeers: 10, NoDial: true}}
+	assert.NoError(t, server.Start())
+	defer server.Stop()
+	service = MakeFullChainService(&DipperinConfig{P2PServer: server})
+
+	assert.Error(t, service.BanPeer(url_wrong, 0))
+	assert.Error(t, service.BanPeer("123", 0))
+
+	node := enode.NewV4(&key.PublicKey, net.ParseIP("127.0.0.1"), 30303, 30303)
+	id := enode.ID{0x01}
+	assert.NoError(t, service.BanPeer(node.String(), 0))
+	assert.NoError(t, service.BanPeer(id.String(), 3600))
+	peers, err := service.BannedPeers()
+	assert.NoError(t, err)
+	assert.Len(t, peers, 2)
+	bans := map[string]int64{}
+	for _, p := range peers {
+		bans[p.ID] = p.Until
+	}
+	assert.NotZero(t, bans[id.String()])
+	assert.Contains(t, bans, node.ID().String())
+	assert.Zero(t, bans[node.ID().String()])
+
+	assert.NoError(t, service.UnbanPeer(node.String()))
+	assert.NoError(t, service.UnbanPeer(id.String()))
+	peers, err = service.BannedPeers()
+	assert.NoError(t, err)
+	assert.Len(t, peers, 0)
+}
+
 func TestVenusFullChainService_GetCurrentConnectPeers(t *testing.T) {
 	config := &DipperinConfig{}
 	service := MakeFullChainService(config)
@@ -743,6 +786,24 @@ func TestVenusFullChainService_MineTxCount(t *testing.T) {
 	assert.Equal(t, 1, service.MineTxCount())
 }
 
+func TestVenusFullChainService_GetWorkerBalance(t *testing.T) {
+	config := DipperinConfig{}
+	service := MakeFullChainService(&config)
+	_, err := service.GetWorkerBalance(aliceAddr)
+	assert.Error(t, err)
+	_, err = service.GetWorkerBalances()
+	assert.Error(t, err)
+
+	config = DipperinConfig{MineMaster: fakeMaster{}}
+	service = MakeFullChainService(&config)
+	balance, err := service.GetWorkerBalance(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), balance.Balance)
+	balances, err := service.GetWorkerBalances()
+	assert.NoError(t, err)
+	assert.Equal(t, []minemaster.WorkerBalance{balance}, balances)
+}
+
 func TestVenusFullChainService_StartMine(t *testing.T) {
 	config := DipperinConfig{}
 	service := MakeFullChainService(&config)
@@ -878,6 +939,80 @@ func TestVenusFullChainService_Transaction(t *testing.T) {
 	assert.Equal(t, uint64(0), txIndex)
 }
 
+func TestVenusFullChainService_GetTransactionsByAddress(t *testing.T) {
+	csChain := createCsChain(nil)
+	service := MakeFullChainService(&DipperinConfig{ChainReader: csChain})
+	_, err := service.GetTransactionsByAddress(aliceAddr, 0, 10)
+	assert.Equal(t, g_error.ErrTxHistoryDisabled, err)
+
+	csChain.TxHistoryIndex = true
+	tx := createSignedTx(0, aliceAddr, big.NewInt(1000), []byte{}, nil)
+	insertBlockToChain(t, csChain, 1, []*model.Transaction{tx})
+	entries, err := service.GetTransactionsByAddress(aliceAddr, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []chaindb.AddressTxEntry{{TxHash: tx.CalTxId(), BlockNumber: 1, Received: true}}, entries)
+
+	entries, err = service.GetTransactionsByAddress(aliceAddr, 1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+
+	_, err = service.GetTransactionsByAddress(aliceAddr, maxTxHistoryOffset+1, 10)
+	assert.Equal(t, g_error.ErrTxHistoryOffsetTooLarge, err)
+}
+
+func TestVenusFullChainService_ERC20TransfersAndHolders(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	ceres := conf.CeresBlock
+	conf.CeresBlock = big.NewInt(0)
+	defer func() { conf.CeresBlock = ceres }()
+
+	csChain := createCsChain(nil)
+	service := MakeFullChainService(&DipperinConfig{ChainReader: csChain})
+	_, err := service.GetERC20Transfers(aliceAddr, 0, 10)
+	assert.Equal(t, g_error.ErrTxHistoryDisabled, err)
+	_, err = service.GetERC20Holders(aliceAddr, 0, 10)
+	assert.Equal(t, g_error.ErrNotERC20Address, err)
+
+	csChain.TxHistoryIndex = true
+	createTx, token := createERC20()
+	owner, err := createTx.Sender(nil)
+	assert.NoError(t, err)
+	_, err = service.GetERC20Holders(token, 0, 10)
+	assert.Error(t, err)
+
+	// the owner gets the total supply of 1e4
+	createTx = createSignedTx(0, token, big.NewInt(0), createTx.ExtraData(), nil)
+	insertBlockToChain(t, csChain, 1, []*model.Transaction{createTx})
+	extra := contract2.ExtraDataForContract{ContractAddress: token, Action: "Transfer", Params: fmt.Sprintf("[\"%s\",\"0x64\"]", aliceAddr.Hex())}
+	transferTx := createSignedTx(1, token, big.NewInt(0), util.StringifyJsonToBytes(extra), nil)
+	insertBlockToChain(t, csChain, 1, []*model.Transaction{transferTx})
+
+	receipts, err := service.GetReceiptsByBlockNum(2)
+	assert.NoError(t, err)
+	assert.Len(t, receipts[0].Logs, 1)
+
+	transfers, err := service.GetERC20Transfers(aliceAddr, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []chaindb.ERC20TransferEntry{{Token: token, TxHash: transferTx.CalTxId(), BlockNumber: 2, From: owner, To: aliceAddr, Value: big.NewInt(100)}}, transfers)
+	transfers, err = service.GetERC20Transfers(owner, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, transfers, 2)
+	assert.Equal(t, createTx.CalTxId(), transfers[1].TxHash)
+	assert.Equal(t, common.Address{}, transfers[1].From)
+	_, err = service.GetERC20Transfers(owner, maxTxHistoryOffset+1, 10)
+	assert.Equal(t, g_error.ErrTxHistoryOffsetTooLarge, err)
+
+	holders, err := service.GetERC20Holders(token, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []contract2.ERC20Holder{{Address: owner, Balance: big.NewInt(9900)}, {Address: aliceAddr, Balance: big.NewInt(100)}}, holders)
+	holders, err = service.GetERC20Holders(token, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []contract2.ERC20Holder{{Address: aliceAddr, Balance: big.NewInt(100)}}, holders)
+	holders, err = service.GetERC20Holders(token, 2, 10)
+	assert.NoError(t, err)
+	assert.Len(t, holders, 0)
+}
+
 func TestVenusFullChainService_NewSendTransactions(t *testing.T) {
 	csChain := createCsChain(nil)
 	config := DipperinConfig{ChainReader: csChain, TxPool: fakeTxPool{}}
@@ -1,24 +1,32 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/g-event"
 	"github.com/dipperin/dipperin-core/common/util"
 	"github.com/dipperin/dipperin-core/core/accounts"
 	"github.com/dipperin/dipperin-core/core/chain-communication"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/tx-pool"
+	"github.com/dipperin/dipperin-core/core/vm"
 	"github.com/dipperin/dipperin-core/core/vm/common/utils"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/tests"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/rpc"
 	"github.com/dipperin/dipperin-core/third-party/vm-log-search"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"math/big"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestVenusFullChainService_Call(t *testing.T) {
@@ -50,6 +58,40 @@ func TestVenusFullChainService_Call(t *testing.T) {
 	assert.Equal(t, "10000", balance)
 }
 
+func TestVenusFullChainService_Call_Revert(t *testing.T) {
+	csChain := createCsChain(nil)
+	config := DipperinConfig{ChainReader: csChain}
+	service := MakeFullChainService(&config)
+
+	WASMPath := g_testData.GetWASMPath("revert", g_testData.CoreVmTestData)
+	AbiPath := g_testData.GetAbiPath("revert", g_testData.CoreVmTestData)
+	tx := createContractTx(0, WASMPath, AbiPath, "", nil)
+	block := createBlock(csChain, []*model.Transaction{tx}, nil)
+	votes := createVerifiersVotes(block, csChain.ChainConfig.VerifierNumber, nil)
+	err := csChain.SaveBftBlock(block, votes)
+	assert.NoError(t, err)
+
+	chainConfig := chain_config.GetChainConfig()
+	defer func(saturn *big.Int) { chainConfig.SaturnBlock = saturn }(chainConfig.SaturnBlock)
+	chainConfig.SaturnBlock = big.NewInt(1)
+
+	sender, err := tx.Sender(nil)
+	assert.NoError(t, err)
+	contractAddr := cs_crypto.CreateContractAddress(sender, uint64(0))
+	data, _ := rlp.EncodeToBytes([]interface{}{"fail"})
+	_, abi := g_testData.GetCodeAbi(WASMPath, AbiPath)
+	extraData, err := utils.ParseCallContractData(abi, data)
+	assert.NoError(t, err)
+	tx = createSignedTx(1, contractAddr, big.NewInt(0), extraData, nil)
+
+	_, err = service.Call(tx, 1)
+	assert.Equal(t, &g_error.RevertError{Reason: "bad"}, err)
+	assert.Equal(t, "vm: execution reverted: bad", err.Error())
+
+	_, err = service.EstimateGas(tx, 1)
+	assert.Equal(t, &g_error.RevertError{Reason: "bad"}, err)
+}
+
 func TestVenusFullChainService_ContractTransaction(t *testing.T) {
 	csChain := createCsChain(nil)
 
@@ -331,6 +373,146 @@ func TestVenusFullChainService_SendTransaction(t *testing.T) {
 	assert.Equal(t, common.Hash{}, hash)
 }
 
+func TestVenusFullChainService_SendCrossTransaction(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	makemake := conf.MakemakeBlock
+	conf.MakemakeBlock = big.NewInt(0)
+	defer func() { conf.MakemakeBlock = makemake }()
+
+	manager := createWalletManager(t)
+	defer os.Remove(util.HomeDir() + testPath)
+	account, err := manager.Wallets[0].Accounts()
+	assert.NoError(t, err)
+
+	address := account[0].Address
+	pk, err := manager.Wallets[0].GetSKFromAddress(address)
+	testAccount := tests.NewAccount(pk, address)
+	testAccounts := []tests.Account{*testAccount}
+
+	serviceChain := createCsChainService(testAccounts)
+	txPool := createTxPool(serviceChain.ChainState)
+	serviceChain.TxPool = txPool
+
+	broadcaster := chain_communication.NewBroadcastDelegate(txPool, fakeNodeConfig{}, fakePeerManager{}, serviceChain, fakePbftNode{})
+	config := &DipperinConfig{
+		NodeConf:      fakeNodeConfig{nodeType: chain_config.NodeTypeOfVerifier},
+		WalletManager: manager,
+		ChainReader:   serviceChain,
+		TxPool:        txPool,
+		ChainConfig:   *chain_config.GetChainConfig(),
+		Broadcaster:   broadcaster,
+	}
+
+	service := VenusFullChainService{
+		DipperinConfig: config,
+		TxValidator:    fakeValidator{},
+	}
+
+	nonce := uint64(0)
+	hashLock := model.CalHashLock([]byte("123"))
+	hash, err := service.SendLockTransaction(address, aliceAddr, hashLock, big.NewInt(100), g_testData.TestValue, g_testData.TestGasPrice, g_testData.TestGasLimit, &nonce)
+	assert.NoError(t, err)
+	assert.NotEqual(t, common.Hash{}, hash)
+
+	nonce = uint64(1)
+	hash, err = service.SendClaimTransaction(address, aliceAddr, []byte("123"), g_testData.TestGasPrice, g_testData.TestGasLimit, &nonce)
+	assert.NoError(t, err)
+	assert.NotEqual(t, common.Hash{}, hash)
+
+	nonce = uint64(2)
+	hash, err = service.SendRefundTransaction(address, aliceAddr, g_testData.TestGasPrice, g_testData.TestGasLimit, &nonce)
+	assert.NoError(t, err)
+	assert.NotEqual(t, common.Hash{}, hash)
+
+	lockAddress, balance, lock, timeLock, err := service.GetLockInfo(address, aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, cs_crypto.GetLockAddress(address, aliceAddr), lockAddress)
+	assert.Equal(t, big.NewInt(0), balance)
+	assert.Equal(t, common.Hash{}, lock)
+	assert.Equal(t, big.NewInt(0), timeLock)
+}
+
+func TestVenusFullChainService_MultiSigTransaction(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	eris := conf.ErisBlock
+	conf.ErisBlock = big.NewInt(0)
+	defer func() { conf.ErisBlock = eris }()
+
+	manager := createWalletManager(t)
+	defer os.Remove(util.HomeDir() + testPath)
+	account, err := manager.Wallets[0].Accounts()
+	assert.NoError(t, err)
+
+	address := account[0].Address
+	pk, err := manager.Wallets[0].GetSKFromAddress(address)
+	testAccount := tests.NewAccount(pk, address)
+	testAccounts := []tests.Account{*testAccount}
+
+	serviceChain := createCsChainService(testAccounts)
+	txPool := createTxPool(serviceChain.ChainState)
+	serviceChain.TxPool = txPool
+
+	broadcaster := chain_communication.NewBroadcastDelegate(txPool, fakeNodeConfig{}, fakePeerManager{}, serviceChain, fakePbftNode{})
+	config := &DipperinConfig{
+		NodeConf:      fakeNodeConfig{nodeType: chain_config.NodeTypeOfVerifier},
+		WalletManager: manager,
+		ChainReader:   serviceChain,
+		TxPool:        txPool,
+		ChainConfig:   *chain_config.GetChainConfig(),
+		Broadcaster:   broadcaster,
+	}
+
+	service := VenusFullChainService{
+		DipperinConfig: config,
+		TxValidator:    fakeValidator{},
+	}
+
+	info, err := model.NewMultiSigInfo(1, []common.Address{address, aliceAddr})
+	assert.NoError(t, err)
+
+	nonce := uint64(0)
+	hash, err := service.CreateMultiSigAccount(address, 3, info.Signers, g_testData.TestValue, g_testData.TestGasPrice, g_testData.TestGasLimit, &nonce)
+	assert.Equal(t, g_error.ErrInvalidMultiSigThreshold, err)
+	assert.Equal(t, common.Hash{}, hash)
+
+	hash, err = service.CreateMultiSigAccount(address, 1, info.Signers, g_testData.TestValue, g_testData.TestGasPrice, g_testData.TestGasLimit, &nonce)
+	assert.NoError(t, err)
+	assert.NotEqual(t, common.Hash{}, hash)
+
+	// the account doesn't exist before the tx is packaged
+	_, _, _, err = service.GetMultiSigAccount(info.Address())
+	assert.Equal(t, g_error.ErrAccountNotExist, err)
+	_, err = service.NewMultiSigTransaction(info.Address(), aliceAddr, big.NewInt(10), g_testData.TestGasPrice, g_testData.TestGasLimit, nil, nil)
+	assert.Equal(t, g_error.ErrAccountNotExist, err)
+
+	tx, err := model.NewMultiSigAccountTransaction(0, info, big.NewInt(1000), g_testData.TestGasPrice, g_testData.TestGasLimit)
+	assert.NoError(t, err)
+	signedTx, err := tx.SignTx(pk, model.NewSigner(service.ChainConfig.ChainId))
+	assert.NoError(t, err)
+	block := createBlock(serviceChain.ChainState, []*model.Transaction{signedTx}, nil)
+	votes := createVerifiersVotes(block, service.ChainConfig.VerifierNumber*2/3+1, testAccounts)
+	assert.NoError(t, serviceChain.SaveBftBlock(block, votes))
+
+	savedInfo, balance, accountNonce, err := service.GetMultiSigAccount(info.Address())
+	assert.NoError(t, err)
+	assert.True(t, info.Equal(savedInfo))
+	assert.Equal(t, big.NewInt(1000), balance)
+	assert.Equal(t, uint64(0), accountNonce)
+
+	multiSigTx, err := service.NewMultiSigTransaction(info.Address(), aliceAddr, big.NewInt(10), g_testData.TestGasPrice, g_testData.TestGasLimit, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), multiSigTx.Nonce())
+
+	_, err = service.SignMultiSigTransaction(aliceAddr, multiSigTx)
+	assert.Error(t, err)
+
+	multiSigTx, err = service.SignMultiSigTransaction(address, multiSigTx)
+	assert.NoError(t, err)
+	sender, err := multiSigTx.Sender(model.NewSigner(service.ChainConfig.ChainId))
+	assert.NoError(t, err)
+	assert.Equal(t, info.Address(), sender)
+}
+
 func TestVenusFullChainService_SendTransaction_Error(t *testing.T) {
 	manager := createWalletManager(t)
 	defer os.Remove(util.HomeDir() + testPath)
@@ -425,3 +607,196 @@ func TestVenusFullChainService_SendTransaction_Error(t *testing.T) {
 	assert.Equal(t, "the node isn't verifier", err.Error())
 	assert.Equal(t, common.Hash{}, hash)
 }
+
+func TestVenusFullChainService_Subscriptions(t *testing.T) {
+	csChain := createCsChain(nil)
+	config := &DipperinConfig{
+		ChainReader: csChain,
+		TxPool:      createTxPool(csChain),
+	}
+	service := MakeFullChainService(config)
+
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName("dipperin", service))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	WASMPath := g_testData.GetWASMPath("token", g_testData.CoreVmTestData)
+	AbiPath := g_testData.GetAbiPath("token", g_testData.CoreVmTestData)
+	tx := createContractTx(0, WASMPath, AbiPath, "DIPP,WU,10000", nil)
+	sender, err := tx.Sender(nil)
+	assert.NoError(t, err)
+	contractAddr := cs_crypto.CreateContractAddress(sender, uint64(0))
+	topic := []common.Hash{common.BytesToHash(crypto.Keccak256([]byte("Tranfer")))}
+
+	ctx := context.Background()
+	txCh := make(chan *SubBlockTxResp, 1)
+	txSub, err := client.Subscribe(ctx, "dipperin", txCh, "newPendingTransactions")
+	assert.NoError(t, err)
+	defer txSub.Unsubscribe()
+	logCh := make(chan *model2.Log, 1)
+	logSub, err := client.Subscribe(ctx, "dipperin", logCh, "subscribeLogs", []common.Address{contractAddr}, [][]common.Hash{topic})
+	assert.NoError(t, err)
+	defer logSub.Unsubscribe()
+	receiptCh := make(chan *model2.Receipt, 1)
+	receiptSub, err := client.Subscribe(ctx, "dipperin", receiptCh, "subscribeReceipt", tx.CalTxId())
+	assert.NoError(t, err)
+	defer receiptSub.Unsubscribe()
+
+	// the transaction enters the pending list of the tx pool
+	g_event.Send(g_event.NewTxPoolEvent, []model.AbstractTransaction{tx})
+	select {
+	case resp := <-txCh:
+		assert.Equal(t, tx.CalTxId(), resp.TxID)
+		assert.Equal(t, sender, resp.From)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the pending transaction isn't notified")
+	}
+
+	// the transaction is included by the inserted block
+	block := createBlock(csChain, []*model.Transaction{tx}, nil)
+	votes := createVerifiersVotes(block, csChain.ChainConfig.VerifierNumber, nil)
+	assert.NoError(t, csChain.SaveBftBlock(block, votes))
+	g_event.Send(g_event.NewBlockInsertEvent, *block.(*model.Block))
+
+	receipt, err := service.GetReceiptByTxHash(tx.CalTxId())
+	assert.NoError(t, err)
+	select {
+	case l := <-logCh:
+		assert.Equal(t, contractAddr, l.Address)
+		assert.Equal(t, receipt.Logs[0].TxHash, l.TxHash)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the contract log isn't notified")
+	}
+	select {
+	case r := <-receiptCh:
+		assert.Equal(t, receipt.TxHash, r.TxHash)
+		assert.Equal(t, receipt.GasUsed, r.GasUsed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the receipt isn't notified")
+	}
+
+	// the receipt of the included transaction is notified at once
+	receiptCh = make(chan *model2.Receipt, 1)
+	receiptSub, err = client.Subscribe(ctx, "dipperin", receiptCh, "subscribeReceipt", tx.CalTxId())
+	assert.NoError(t, err)
+	defer receiptSub.Unsubscribe()
+	select {
+	case r := <-receiptCh:
+		assert.Equal(t, receipt.TxHash, r.TxHash)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the receipt isn't notified")
+	}
+}
+
+func TestVenusFullChainService_TxPool(t *testing.T) {
+	manager := createWalletManager(t)
+	defer os.Remove(util.HomeDir() + testPath)
+	account, err := manager.Wallets[0].Accounts()
+	assert.NoError(t, err)
+
+	address := account[0].Address
+	pk, err := manager.Wallets[0].GetSKFromAddress(address)
+	assert.NoError(t, err)
+	testAccounts := []tests.Account{*tests.NewAccount(pk, address)}
+
+	serviceChain := createCsChainService(testAccounts)
+	txPool := createTxPool(serviceChain.ChainState)
+	config := &DipperinConfig{
+		NodeConf:      fakeNodeConfig{nodeType: chain_config.NodeTypeOfVerifier},
+		WalletManager: manager,
+		ChainReader:   serviceChain,
+		TxPool:        txPool,
+		ChainConfig:   *chain_config.GetChainConfig(),
+		Broadcaster:   chain_communication.NewBroadcastDelegate(txPool, fakeNodeConfig{}, fakePeerManager{}, serviceChain, fakePbftNode{}),
+	}
+	service := VenusFullChainService{
+		DipperinConfig: config,
+		TxValidator:    fakeValidator{},
+	}
+
+	nonce := uint64(0)
+	hash, err := service.SendTransaction(address, aliceAddr, g_testData.TestValue, g_testData.TestGasPrice, g_testData.TestGasLimit, nil, &nonce)
+	assert.NoError(t, err)
+
+	pending, queued, err := service.TxPoolContent()
+	assert.NoError(t, err)
+	assert.Len(t, pending[address], 1)
+	assert.Len(t, queued, 0)
+	assert.Equal(t, []tx_pool.TxStatus{tx_pool.TxStatusPending}, service.TxPoolStatus([]common.Hash{hash}))
+	pendingNum, _ := service.TxPoolStats()
+	assert.Equal(t, 1, pendingNum)
+
+	// the gas price isn't bumped enough
+	_, err = service.ReplaceTransaction(hash, g_testData.TestGasPrice)
+	assert.Equal(t, g_error.ErrReplaceUnderpriced, err)
+
+	newHash, err := service.ReplaceTransaction(hash, new(big.Int).Mul(g_testData.TestGasPrice, big.NewInt(2)))
+	assert.NoError(t, err)
+	assert.Equal(t, []tx_pool.TxStatus{tx_pool.TxStatusUnknown, tx_pool.TxStatusPending}, service.TxPoolStatus([]common.Hash{hash, newHash}))
+
+	assert.NoError(t, service.DropTransaction(newHash))
+	assert.Equal(t, g_error.ErrTxNotInPool, service.DropTransaction(newHash))
+	_, err = service.ReplaceTransaction(newHash, g_testData.TestGasPrice)
+	assert.Equal(t, g_error.ErrTxNotInPool, err)
+
+	assert.Equal(t, g_error.ErrTxPoolNoJournal, service.FlushTxJournal())
+}
+
+func TestVenusFullChainService_Trace(t *testing.T) {
+	csChain := createCsChain(nil)
+	config := DipperinConfig{ChainReader: csChain}
+	service := MakeFullChainService(&config)
+
+	WASMPath := g_testData.GetWASMPath("token-const", g_testData.CoreVmTestData)
+	AbiPath := g_testData.GetAbiPath("token-const", g_testData.CoreVmTestData)
+	tx := createContractTx(0, WASMPath, AbiPath, "DIPP,WU,10000", nil)
+	block := createBlock(csChain, []*model.Transaction{tx}, nil)
+	votes := createVerifiersVotes(block, csChain.ChainConfig.VerifierNumber, nil)
+	err := csChain.SaveBftBlock(block, votes)
+	assert.NoError(t, err)
+
+	sender, err := tx.Sender(nil)
+	assert.NoError(t, err)
+	contractAddr := cs_crypto.CreateContractAddress(sender, uint64(0))
+
+	// trace the mined transaction
+	trace, err := service.TraceTransaction(tx.CalTxId())
+	assert.NoError(t, err)
+	assert.Equal(t, tx.CalTxId(), trace.TxHash)
+	assert.False(t, trace.Failed)
+	assert.Equal(t, vm.CallFrameCreate, trace.Call.Type)
+	assert.Equal(t, contractAddr, trace.Call.To)
+	assert.NotEmpty(t, trace.Call.HostCalls)
+	contractDiff := trace.StateDiff[contractAddr.Hex()]
+	assert.Empty(t, contractDiff.Code.From)
+	assert.NotEmpty(t, contractDiff.Code.To)
+	assert.NotEmpty(t, contractDiff.Storage)
+	assert.NotNil(t, trace.StateDiff[sender.Hex()].Balance)
+	receipt, err := service.GetReceiptByTxHash(tx.CalTxId())
+	assert.NoError(t, err)
+	assert.Equal(t, receipt.GasUsed, uint64(trace.GasUsed))
+
+	traces, err := service.TraceBlock(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*TxTrace{trace}, traces)
+
+	_, err = service.TraceBlock(0)
+	assert.Equal(t, g_error.ErrTraceGenesisBlock, err)
+	_, err = service.TraceTransaction(common.HexToHash("0x12"))
+	assert.Equal(t, g_error.ErrTransactionNotFound, err)
+
+	// trace a call
+	data, _ := rlp.EncodeToBytes([]interface{}{"getBalance", sender.String()})
+	_, abi := g_testData.GetCodeAbi(WASMPath, AbiPath)
+	extraData, err := utils.ParseCallContractData(abi, data)
+	assert.NoError(t, err)
+	callTx := createSignedTx(1, contractAddr, g_testData.TestValue, extraData, nil)
+
+	trace, err = service.TraceCall(callTx, 1)
+	assert.NoError(t, err)
+	assert.False(t, trace.Failed)
+	assert.Equal(t, vm.CallFrameCall, trace.Call.Type)
+	assert.Equal(t, contractAddr, trace.Call.To)
+	assert.Equal(t, "getState", trace.Call.HostCalls[0].Name)
+}
@@ -298,9 +298,13 @@ func (pm fakePeerManager) RemovePeer(id string) {
 	panic("implement me")
 }
 
+func (pm fakePeerManager) ReportPeerFault(id string, fault chain_communication.PeerFault) {
+	panic("implement me")
+}
+
 type fakePbftNode struct{}
 
-func (pbft fakePbftNode) OnNewWaitVerifyBlock(block model.AbstractBlock, id string) {
+func (pbft fakePbftNode) OnNewWaitVerifyBlock(block model.AbstractBlock, id string) error {
 	panic("implement me")
 }
 
@@ -446,6 +450,14 @@ func (m fakeMaster) GetPerformance(address common.Address) uint64 {
 	panic("implement me")
 }
 
+func (m fakeMaster) GetWorkerBalance(address common.Address) minemaster.WorkerBalance {
+	return minemaster.WorkerBalance{Address: address, Balance: big.NewInt(1), Immature: big.NewInt(2), Paid: big.NewInt(3)}
+}
+
+func (m fakeMaster) GetWorkerBalances() []minemaster.WorkerBalance {
+	return []minemaster.WorkerBalance{m.GetWorkerBalance(aliceAddr)}
+}
+
 func (m fakeMaster) Mining() bool {
 	return m.isMine
 }
@@ -518,6 +530,34 @@ func (pool fakeTxPool) Stats() (int, int) {
 	panic("implement me")
 }
 
+func (pool fakeTxPool) Pending() (map[common.Address][]model.AbstractTransaction, error) {
+	panic("implement me")
+}
+
+func (pool fakeTxPool) Queueing() (map[common.Address][]model.AbstractTransaction, error) {
+	panic("implement me")
+}
+
+func (pool fakeTxPool) Status(hashes []common.Hash) []tx_pool.TxStatus {
+	panic("implement me")
+}
+
+func (pool fakeTxPool) Get(hash common.Hash) model.AbstractTransaction {
+	panic("implement me")
+}
+
+func (pool fakeTxPool) DropTx(hash common.Hash) error {
+	panic("implement me")
+}
+
+func (pool fakeTxPool) ReplaceTx(hash common.Hash, tx model.AbstractTransaction) error {
+	panic("implement me")
+}
+
+func (pool fakeTxPool) FlushJournal() error {
+	panic("implement me")
+}
+
 type fakeMsgSigner struct{ addr common.Address }
 
 func (f *fakeMsgSigner) SetBaseAddress(address common.Address) {}
@@ -176,9 +176,9 @@ var InitExchangeRate int64
 func init() {
 
 	if chain_config.GetCurBootsEnv() != "mercury" {
-		DIPProportion = NotMercuryDIPProportion
+		SetDIPProportion(NotMercuryDIPProportion)
 	} else {
-		DIPProportion = MercuryDIPProportion
+		SetDIPProportion(MercuryDIPProportion)
 	}
 
 	//log.Info("the EarlyTokenDIP is:", "EarlyTokenDIP", EarlyTokenDIP)
@@ -186,26 +186,24 @@ func init() {
 	exchangeRate.Div(exchangeRate, big.NewInt(consts.DIP))
 	exchangeRate.Div(exchangeRate, EarlyTokenAmount)
 	InitExchangeRate = exchangeRate.Int64()
+}
 
-	for address := range DIPProportion.InvestorProportion {
-		InvestorAddresses = append(InvestorAddresses, common.HexToAddress(address))
-	}
-
-	for address := range DIPProportion.DeveloperProportion {
-		DeveloperAddresses = append(DeveloperAddresses, common.HexToAddress(address))
-	}
-
-	for address := range DIPProportion.MaintenanceProportion {
-		MaintenanceAddresses = append(MaintenanceAddresses, common.HexToAddress(address))
-	}
-
-	for address := range DIPProportion.EarlyTokenProportion {
-		EarlyTokenAddresses = append(EarlyTokenAddresses, common.HexToAddress(address))
-	}
+// SetDIPProportion replaces the pre-mining proportion and the addresses of each group, it's used by the genesis spec
+func SetDIPProportion(proportion AddressDIPProportion) {
+	DIPProportion = proportion
+	InvestorAddresses = proportionAddresses(proportion.InvestorProportion)
+	DeveloperAddresses = proportionAddresses(proportion.DeveloperProportion)
+	MaintenanceAddresses = proportionAddresses(proportion.MaintenanceProportion)
+	EarlyTokenAddresses = proportionAddresses(proportion.EarlyTokenProportion)
+	RemainRewardAddresses = proportionAddresses(proportion.ReMainRewardProportion)
+}
 
-	for address := range DIPProportion.ReMainRewardProportion {
-		RemainRewardAddresses = append(RemainRewardAddresses, common.HexToAddress(address))
+func proportionAddresses(proportion map[string]int) []common.Address {
+	addresses := make([]common.Address, 0, len(proportion))
+	for address := range proportion {
+		addresses = append(addresses, common.HexToAddress(address))
 	}
+	return addresses
 }
 
 type PreMineMainType int
@@ -426,7 +424,10 @@ func (economyModel *DipperinEconomyModel) GetDiffVerifierAddress(preBlock, block
 	notCommitVerifier := make([]common.Address, len(verifiers))
 	copy(notCommitVerifier, verifiers)
 
-	verifications := block.GetVerifications()
+	verifications, err := model.CommitVerifications(block, verifiers)
+	if err != nil {
+		return map[VerifierType][]common.Address{}, err
+	}
 	//log.Info("the verifications number is:","number",len(verifications))
 	for _, verification := range verifications {
 		//log.Info("the verification address is:","address",verification.GetAddress().Hex())
@@ -310,6 +310,7 @@ func TestDipperinEconomyModel_GetDiffVerifierAddress(t *testing.T) {
 	mockVerifier.EXPECT().GetRound().Return(uint64(0))
 	mockVerifier.EXPECT().GetAddress().Return(common.HexToAddress("0x000078b33598Be2b405206F44B018557e6F851FD230C")).AnyTimes()
 
+	mockBlock.EXPECT().GetAggregateCommit().Return(nil)
 	mockBlock.EXPECT().GetVerifications().Return(model.Verifications{mockVerifier})
 
 	verAddr, err := economyModel.GetDiffVerifierAddress(mockPreBlock, mockBlock)
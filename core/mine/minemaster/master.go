@@ -79,16 +79,25 @@ func (ms *master) RetrieveReward(address common.Address) {
 
 }
 
+// sendReward pays the pool balance of the worker if the pool accounting is enabled
 func (ms *master) sendReward(address common.Address) {
-	//reward := ms.workManager.getReward(address)
-	// ms.send(reward)
-	// TODO, initiate transaction
+	if err := ms.workManager.payReward(address); err != nil {
+		log.Warn("send the worker reward failed", "address", address.Hex(), "err", err)
+	}
 }
 
 func (ms *master) GetReward(address common.Address) *big.Int {
 	return ms.workManager.getReward(address)
 }
 
+func (ms *master) GetWorkerBalance(address common.Address) WorkerBalance {
+	return ms.workManager.getBalance(address)
+}
+
+func (ms *master) GetWorkerBalances() []WorkerBalance {
+	return ms.workManager.getBalances()
+}
+
 func (ms *master) GetPerformance(address common.Address) uint64 {
 	return ms.workManager.getPerformance(address)
 }
@@ -239,6 +248,7 @@ func (ms *master) OnNewBlock(block model.AbstractBlock) {
 func (ms *master) doOnNewBlock(block model.AbstractBlock) {
 	log.Info("on new block chan", "new block", block.Number(), "cur block height", ms.curNewBlockHeight)
 
+	ms.workManager.onInsertBlock(block)
 	if block.Number() <= ms.curNewBlockHeight {
 		return
 	}
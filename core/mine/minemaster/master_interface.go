@@ -39,6 +39,9 @@ type Master interface {
 
 	GetReward(address common.Address) *big.Int
 	GetPerformance(address common.Address) uint64
+	// the pool balances of the workers, empty if the pool accounting isn't enabled
+	GetWorkerBalance(address common.Address) WorkerBalance
+	GetWorkerBalances() []WorkerBalance
 
 	// whether the mining is ongoing
 	Mining() bool
@@ -91,9 +94,16 @@ type MasterServer interface {
 // there is only one workManager to manage all worker's works
 type workManager interface {
 	submitBlock(workerAddress common.Address, block model.AbstractBlock)
+	submitShare(workerAddress common.Address, block model.AbstractBlock)
+	getShareDiff(blockDiff common.Difficulty) (common.Difficulty, bool)
 	getPerformance(address common.Address) uint64
 	getReward(address common.Address) *big.Int
 	onNewBlock(block model.AbstractBlock)
+	// credit the pool rewards of the found blocks which are final on the chain
+	onInsertBlock(block model.AbstractBlock)
+	getBalance(address common.Address) WorkerBalance
+	getBalances() []WorkerBalance
+	payReward(address common.Address) error
 	SetMsgSigner(MsgSigner chain_communication.PbftSigner)
 	spendableWorkManager
 	partialSpendableWorkManager
@@ -129,6 +139,14 @@ type rewardDistributor interface {
 	divideReward(coinbase *big.Int) map[common.Address]*big.Int
 }
 
+// shareDistributor divides the reward of a found block by the shares submitted by the workers
+type shareDistributor interface {
+	rewardDistributor
+	addShare(address common.Address)
+	getShares() []poolShare
+	setShares(shares []poolShare)
+}
+
 // calculableBlock could calculate coinbase and transaction fees from
 // the callee block
 //type calculableBlock interface {
@@ -155,12 +173,33 @@ type BlockBroadcaster interface {
 	BroadcastMinedBlock(block model.AbstractBlock)
 }
 
+// RewardCalculator calculates the coin reward of a mined block
+type RewardCalculator interface {
+	GetMineMasterDIPReward(block model.AbstractBlock) (*big.Int, error)
+}
+
+// PoolChainReader reads the inserted blocks to check the finality of the found blocks
+type PoolChainReader interface {
+	GetBlockByNumber(number uint64) model.AbstractBlock
+}
+
+// PayoutSender sends the payout transactions signed by the coinbase account
+type PayoutSender interface {
+	SendPayout(from, to common.Address, value *big.Int) (common.Hash, error)
+}
+
 type MineConfig struct {
 	GasFloor         *atomic.Value // Target gas floor for mined blocks.
 	GasCeil          *atomic.Value // Target gas ceiling for mined blocks.
 	CoinbaseAddress  *atomic.Value
 	BlockBuilder     BlockBuilder
 	BlockBroadcaster BlockBroadcaster
+
+	// the pool accounting of the worker rewards, disabled if nil
+	Pool             *PoolConfig
+	RewardCalculator RewardCalculator
+	ChainReader      PoolChainReader
+	PayoutSender     PayoutSender
 }
 
 func (conf *MineConfig) GetMsgSigner() chain_communication.PbftSigner {
@@ -59,9 +59,6 @@ func (s *server) ReceiveMsg(workerID WorkerId, code uint64, msg interface{}) {
 			return
 		}
 
-		// add timeout for wait new block event
-		s.master.startWaitTimer()
-		// TODO: verify different block difficulty
 		s.onSubmitBlock(workerID, w)
 	default:
 		log.Debug("receive wrong msg", "code", code)
@@ -80,11 +77,19 @@ func (s *server) onSubmitBlock(workerID WorkerId, work minemsg.Work) {
 	log.Info("mine master before submit block", "hash", block.RefreshHashCache())
 	// check block valid
 	if !block.RefreshHashCache().ValidHashForDifficulty(block.Difficulty()) {
+		// the share is verified by the difficulty of the master, rather than the one in the work
+		if shareDiff, ok := s.workManager.getShareDiff(block.Difficulty()); ok && block.Hash().ValidHashForDifficulty(shareDiff) {
+			s.workManager.submitShare(work.GetWorkerCoinbaseAddress(), block)
+			return
+		}
 		log.Warn("master receive invalid mined block", "do unregister worker", workerID)
 		//s.UnRegisterWorker(workerID)
 		return
 	}
 
+	// add timeout for wait new block event
+	s.master.startWaitTimer()
+
 	//receiptHash := block.GetReceiptHash()
 	//bloomLog := block.GetBloomLog()
 	//log.Info("server#onSubmitBlock", "receipts", receiptHash)
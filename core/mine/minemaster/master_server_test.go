@@ -28,6 +28,7 @@ import (
 	"testing"
 
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/bloom"
 	"github.com/dipperin/dipperin-core/core/mine/minemsg"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/tests/factory"
@@ -192,3 +193,26 @@ func Test_server_OnNewMsg(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func Test_server_submitShare(t *testing.T) {
+	config := poolTestConfig(SchemePPLNS, "", fakePoolChain{}, nil)
+	config.Pool.ShareMultiple = 1 << 62
+	m := testMasterBuilder(config)
+	m.setWorkDispatcher(&mockDispatch{})
+	wm := newDefaultWorkManager(config)
+	s := newServer(m, wm, fakeGetCurWorkBlockFunc)
+
+	// the block difficulty can't be met, the share target is capped to the half of the hashes
+	header := &model.Header{Number: 1, Diff: common.HexToDiff("0x197fffff"), Bloom: iblt.NewBloom(model.DefaultBlockBloomConfig)}
+	fakeBlock = model.NewBlock(header, nil, nil)
+	shareDiff, ok := wm.getShareDiff(fakeBlock.Difficulty())
+	assert.True(t, ok)
+	for i := uint32(0); !fakeBlock.RefreshHashCache().ValidHashForDifficulty(shareDiff); i++ {
+		fakeBlock.SetNonce(common.BlockNonceFromInt(i))
+	}
+
+	s.ReceiveMsg("123", minemsg.SubmitDefaultWorkMsg, &mockWork{})
+	s.ReceiveMsg("123", minemsg.SubmitDefaultWorkMsg, &mockWork{})
+	assert.Equal(t, []poolShare{{Count: 1}}, wm.pool.distributor.getShares())
+	assert.Empty(t, wm.pool.rounds)
+}
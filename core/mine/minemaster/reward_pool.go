@@ -0,0 +1,518 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// the share schemes of the pool
+const (
+	// pay per last N shares, the reward of a found block is divided by the latest N shares
+	SchemePPLNS = "pplns"
+	// the reward of a found block is divided by the shares of the round since the last found block
+	SchemeProportional = "prop"
+)
+
+// PoolStateFileName is the file in the data dir keeping the pool balances
+const PoolStateFileName = "mine_pool.json"
+
+// PoolConfig configures the reward accounting of the workers
+type PoolConfig struct {
+	// pplns or prop
+	Scheme string
+	// the number of the latest shares counted by the pplns scheme
+	PPLNSWindow uint64
+	// the share target is ShareMultiple times of the block target, the blocks are the only shares if it's less than 2
+	ShareMultiple uint64
+	// the number of the blocks inserted after a found block before its reward is credited,
+	// the chain can't roll back the block any more.
+	Confirmations uint64
+	// the least balance paid by a payout transaction, the balances are only paid by RetrieveReward if it's nil
+	PayoutThreshold *big.Int
+	// the dir of the state file, the state isn't persisted if it's empty
+	DataDir string
+}
+
+// the share target is capped below 2^255, which the compact difficulty can present
+var maxShareTarget = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(1))
+
+// shareDifficulty returns the share difficulty of the block difficulty
+func (conf *PoolConfig) shareDifficulty(blockDiff common.Difficulty) (common.Difficulty, bool) {
+	if conf == nil || conf.ShareMultiple < 2 || blockDiff.Equal(common.Difficulty{}) {
+		return common.Difficulty{}, false
+	}
+	target := new(big.Int).Mul(blockDiff.Big(), new(big.Int).SetUint64(conf.ShareMultiple))
+	if target.Cmp(maxShareTarget) > 0 {
+		target = maxShareTarget
+	}
+	return common.BigToDiff(target), true
+}
+
+// WorkerBalance is the pool account of a worker
+type WorkerBalance struct {
+	Address common.Address
+	// credited but not paid
+	Balance *big.Int
+	// the rewards of the found blocks which aren't final yet
+	Immature *big.Int
+	// the total paid by the payout transactions
+	Paid *big.Int
+}
+
+// poolShare is a run of the consecutive shares submitted by a worker
+type poolShare struct {
+	Address common.Address
+	Count   uint64
+}
+
+type poolCredit struct {
+	Address common.Address
+	Amount  *big.Int
+}
+
+// poolRound is a found block waiting for the finality, the credits are divided when the block is found
+type poolRound struct {
+	Number  uint64
+	Hash    common.Hash
+	Credits []poolCredit
+}
+
+type poolAccount struct {
+	Address common.Address
+	Balance *big.Int
+	Paid    *big.Int
+}
+
+// poolState is persisted in the state file
+type poolState struct {
+	Scheme   string
+	Shares   []poolShare
+	Rounds   []*poolRound
+	Accounts []*poolAccount
+}
+
+// rewardPool credits the rewards of the found blocks to the workers by their shares
+type rewardPool struct {
+	config      PoolConfig
+	distributor shareDistributor
+	reward      RewardCalculator
+	chain       PoolChainReader
+	sender      PayoutSender
+	coinbase    func() common.Address
+
+	lock sync.Mutex
+	// the submitted share hashes of the current height
+	shareHeight uint64
+	seenShares  map[common.Hash]bool
+
+	rounds   []*poolRound
+	accounts map[common.Address]*poolAccount
+}
+
+func newRewardPool(config MineConfig) (*rewardPool, error) {
+	pool := &rewardPool{
+		config:     *config.Pool,
+		reward:     config.RewardCalculator,
+		chain:      config.ChainReader,
+		sender:     config.PayoutSender,
+		coinbase:   config.GetCoinbaseAddr,
+		seenShares: make(map[common.Hash]bool),
+		accounts:   make(map[common.Address]*poolAccount),
+	}
+	switch pool.config.Scheme {
+	case SchemePPLNS:
+		pool.distributor = newPPLNSDistributor(pool.config.PPLNSWindow)
+	case SchemeProportional:
+		pool.distributor = newProportionalDistributor()
+	default:
+		return nil, g_error.ErrPoolUnknownScheme
+	}
+	if err := pool.load(); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// submitShare counts the share of the worker, the repeated share is ignored
+func (pool *rewardPool) submitShare(address common.Address, block model.AbstractBlock) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if block.Number() != pool.shareHeight {
+		pool.shareHeight = block.Number()
+		pool.seenShares = make(map[common.Hash]bool)
+	}
+	hash := block.Hash()
+	if pool.seenShares[hash] {
+		log.Warn("receive repeated share", "worker", address.Hex(), "hash", hash.Hex())
+		return false
+	}
+	pool.seenShares[hash] = true
+	pool.distributor.addShare(address)
+	return true
+}
+
+// foundBlock divides the reward of the found block, which is credited after the block is final
+func (pool *rewardPool) foundBlock(block model.AbstractBlock) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	reward := block.GetTransactionFees()
+	if pool.reward != nil {
+		coinbase, err := pool.reward.GetMineMasterDIPReward(block)
+		if err != nil {
+			log.Error("calculate the block reward failed", "num", block.Number(), "err", err)
+			return
+		}
+		reward.Add(reward, coinbase)
+	}
+
+	round := &poolRound{Number: block.Number(), Hash: block.Hash()}
+	for address, amount := range pool.distributor.divideReward(reward) {
+		round.Credits = append(round.Credits, poolCredit{Address: address, Amount: amount})
+	}
+	sort.Slice(round.Credits, func(i, j int) bool {
+		return bytes.Compare(round.Credits[i].Address[:], round.Credits[j].Address[:]) < 0
+	})
+	pool.rounds = append(pool.rounds, round)
+	log.Info("pool found block", "num", round.Number, "reward", reward, "workers", len(round.Credits))
+	pool.save()
+}
+
+// onInsertBlock credits the rounds which can't be rolled back by the inserted block, and pays the
+// balances above the threshold. The round whose block isn't on the chain is dropped.
+func (pool *rewardPool) onInsertBlock(block model.AbstractBlock) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	var rest []*poolRound
+	for _, round := range pool.rounds {
+		if round.Number+pool.config.Confirmations > block.Number() {
+			rest = append(rest, round)
+			continue
+		}
+
+		chainBlock := pool.chain.GetBlockByNumber(round.Number)
+		if chainBlock == nil || !chainBlock.Hash().IsEqual(round.Hash) {
+			log.Warn("the found block isn't on the chain, drop its rewards", "num", round.Number, "hash", round.Hash.Hex())
+			continue
+		}
+		for _, credit := range round.Credits {
+			account := pool.account(credit.Address)
+			account.Balance.Add(account.Balance, credit.Amount)
+		}
+		log.Info("credit the pool rewards", "num", round.Number, "workers", len(round.Credits))
+	}
+	if len(rest) == len(pool.rounds) {
+		return
+	}
+	pool.rounds = rest
+
+	if pool.config.PayoutThreshold != nil {
+		for _, account := range pool.sortedAccounts() {
+			if account.Balance.Sign() > 0 && account.Balance.Cmp(pool.config.PayoutThreshold) >= 0 {
+				if err := pool.pay(account); err != nil {
+					log.Warn("pay the pool balance failed", "worker", account.Address.Hex(), "err", err)
+				}
+			}
+		}
+	}
+	pool.save()
+}
+
+// payReward pays all the balance of the worker
+func (pool *rewardPool) payReward(address common.Address) error {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	account := pool.accounts[address]
+	if account == nil || account.Balance.Sign() == 0 {
+		return g_error.ErrPoolEmptyBalance
+	}
+	if err := pool.pay(account); err != nil {
+		return err
+	}
+	pool.save()
+	return nil
+}
+
+// pay sends the balance of the account to the worker, the balance is kept if the sending failed
+func (pool *rewardPool) pay(account *poolAccount) error {
+	if pool.sender == nil {
+		return g_error.ErrPoolNoPayout
+	}
+	amount := new(big.Int).Set(account.Balance)
+	txHash, err := pool.sender.SendPayout(pool.coinbase(), account.Address, amount)
+	if err != nil {
+		return err
+	}
+	account.Balance.SetInt64(0)
+	account.Paid.Add(account.Paid, amount)
+	log.Info("pay the pool balance", "worker", account.Address.Hex(), "amount", amount, "tx", txHash.Hex())
+	return nil
+}
+
+func (pool *rewardPool) balance(address common.Address) WorkerBalance {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	return pool.workerBalance(address)
+}
+
+func (pool *rewardPool) balances() (result []WorkerBalance) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	addresses := make(map[common.Address]bool)
+	for address := range pool.accounts {
+		addresses[address] = true
+	}
+	for _, round := range pool.rounds {
+		for _, credit := range round.Credits {
+			addresses[credit.Address] = true
+		}
+	}
+	for address := range addresses {
+		result = append(result, pool.workerBalance(address))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Address[:], result[j].Address[:]) < 0
+	})
+	return
+}
+
+func (pool *rewardPool) workerBalance(address common.Address) WorkerBalance {
+	result := WorkerBalance{Address: address, Balance: big.NewInt(0), Immature: big.NewInt(0), Paid: big.NewInt(0)}
+	if account := pool.accounts[address]; account != nil {
+		result.Balance.Set(account.Balance)
+		result.Paid.Set(account.Paid)
+	}
+	for _, round := range pool.rounds {
+		for _, credit := range round.Credits {
+			if credit.Address.IsEqual(address) {
+				result.Immature.Add(result.Immature, credit.Amount)
+			}
+		}
+	}
+	return result
+}
+
+func (pool *rewardPool) account(address common.Address) *poolAccount {
+	if pool.accounts[address] == nil {
+		pool.accounts[address] = &poolAccount{Address: address, Balance: big.NewInt(0), Paid: big.NewInt(0)}
+	}
+	return pool.accounts[address]
+}
+
+func (pool *rewardPool) sortedAccounts() (result []*poolAccount) {
+	for _, account := range pool.accounts {
+		result = append(result, account)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Address[:], result[j].Address[:]) < 0
+	})
+	return
+}
+
+func (pool *rewardPool) statePath() string {
+	if pool.config.DataDir == "" {
+		return ""
+	}
+	return filepath.Join(pool.config.DataDir, PoolStateFileName)
+}
+
+// load restores the state saved by the last run, the shares are dropped if the scheme is changed
+func (pool *rewardPool) load() error {
+	path := pool.statePath()
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var state poolState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Scheme == pool.config.Scheme {
+		pool.distributor.setShares(state.Shares)
+	} else {
+		log.Warn("the pool scheme is changed, drop the saved shares", "old", state.Scheme, "new", pool.config.Scheme)
+	}
+	pool.rounds = state.Rounds
+	for _, account := range state.Accounts {
+		pool.accounts[account.Address] = account
+	}
+	log.Info("load the pool state", "rounds", len(pool.rounds), "accounts", len(pool.accounts))
+	return nil
+}
+
+// save writes the state to a temporary file first, so an interruption never leaves a broken state file.
+// The shares submitted after the last saving are lost if the master crashes.
+func (pool *rewardPool) save() {
+	path := pool.statePath()
+	if path == "" {
+		return
+	}
+	state := poolState{
+		Scheme:   pool.config.Scheme,
+		Shares:   pool.distributor.getShares(),
+		Rounds:   pool.rounds,
+		Accounts: pool.sortedAccounts(),
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Error("encode the pool state failed", "err", err)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		log.Error("write the pool state failed", "err", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Error("write the pool state failed", "err", err)
+	}
+}
+
+// divideByShares divides the reward by the share counts, the remainder of the division is kept by the coinbase
+func divideByShares(reward *big.Int, counts map[common.Address]uint64) map[common.Address]*big.Int {
+	res := make(map[common.Address]*big.Int)
+	total := new(big.Int)
+	for _, count := range counts {
+		total.Add(total, new(big.Int).SetUint64(count))
+	}
+	if total.Sign() == 0 {
+		return res
+	}
+	for address, count := range counts {
+		num := new(big.Int).SetUint64(count)
+		num.Mul(num, reward)
+		res[address] = num.Quo(num, total)
+	}
+	return res
+}
+
+// pplnsDistributor divides the reward by the latest window shares
+type pplnsDistributor struct {
+	window uint64
+	count  uint64
+	shares []poolShare
+}
+
+func newPPLNSDistributor(window uint64) *pplnsDistributor {
+	return &pplnsDistributor{window: window}
+}
+
+func (d *pplnsDistributor) addShare(address common.Address) {
+	if n := len(d.shares); n > 0 && d.shares[n-1].Address.IsEqual(address) {
+		d.shares[n-1].Count++
+	} else {
+		d.shares = append(d.shares, poolShare{Address: address, Count: 1})
+	}
+	d.count++
+	d.trim()
+}
+
+// trim drops the shares out of the window
+func (d *pplnsDistributor) trim() {
+	for d.window > 0 && d.count > d.window {
+		drop := d.count - d.window
+		if drop >= d.shares[0].Count {
+			d.count -= d.shares[0].Count
+			d.shares = d.shares[1:]
+		} else {
+			d.shares[0].Count -= drop
+			d.count -= drop
+		}
+	}
+}
+
+// divideReward keeps the shares, they are counted by the next blocks until they are out of the window
+func (d *pplnsDistributor) divideReward(coinbase *big.Int) map[common.Address]*big.Int {
+	counts := make(map[common.Address]uint64)
+	for _, share := range d.shares {
+		counts[share.Address] += share.Count
+	}
+	return divideByShares(coinbase, counts)
+}
+
+func (d *pplnsDistributor) getShares() []poolShare {
+	return append([]poolShare{}, d.shares...)
+}
+
+func (d *pplnsDistributor) setShares(shares []poolShare) {
+	d.shares = append([]poolShare{}, shares...)
+	d.count = 0
+	for _, share := range d.shares {
+		d.count += share.Count
+	}
+	d.trim()
+}
+
+// proportionalDistributor divides the reward by the shares of the current round
+type proportionalDistributor struct {
+	round map[common.Address]uint64
+}
+
+func newProportionalDistributor() *proportionalDistributor {
+	return &proportionalDistributor{round: make(map[common.Address]uint64)}
+}
+
+func (d *proportionalDistributor) addShare(address common.Address) {
+	d.round[address]++
+}
+
+// divideReward ends the current round
+func (d *proportionalDistributor) divideReward(coinbase *big.Int) map[common.Address]*big.Int {
+	res := divideByShares(coinbase, d.round)
+	d.round = make(map[common.Address]uint64)
+	return res
+}
+
+func (d *proportionalDistributor) getShares() (shares []poolShare) {
+	for address, count := range d.round {
+		shares = append(shares, poolShare{Address: address, Count: count})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return bytes.Compare(shares[i].Address[:], shares[j].Address[:]) < 0
+	})
+	return
+}
+
+func (d *proportionalDistributor) setShares(shares []poolShare) {
+	d.round = make(map[common.Address]uint64)
+	for _, share := range shares {
+		d.round[share.Address] += share.Count
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/bloom"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+type fakeRewardCalculator struct{}
+
+func (fakeRewardCalculator) GetMineMasterDIPReward(block model.AbstractBlock) (*big.Int, error) {
+	return big.NewInt(100), nil
+}
+
+type fakePoolChain map[uint64]model.AbstractBlock
+
+func (c fakePoolChain) GetBlockByNumber(number uint64) model.AbstractBlock {
+	return c[number]
+}
+
+type fakePayoutSender struct {
+	err     error
+	payouts map[common.Address]*big.Int
+}
+
+func (s *fakePayoutSender) SendPayout(from, to common.Address, value *big.Int) (common.Hash, error) {
+	if s.err != nil {
+		return common.Hash{}, s.err
+	}
+	s.payouts[to] = value
+	return common.HexToHash("0x1"), nil
+}
+
+func poolTestBlock(number uint64, nonce uint32) model.AbstractBlock {
+	header := &model.Header{Number: number, Diff: common.HexToDiff("0x1effffff"), Nonce: common.BlockNonceFromInt(nonce), Bloom: iblt.NewBloom(model.DefaultBlockBloomConfig)}
+	block := model.NewBlock(header, nil, nil)
+	block.RefreshHashCache()
+	return block
+}
+
+func poolTestConfig(scheme, dataDir string, chain fakePoolChain, sender PayoutSender) MineConfig {
+	coinbase := &atomic.Value{}
+	coinbase.Store(common.HexToAddress("0x123"))
+	return MineConfig{
+		CoinbaseAddress: coinbase,
+		Pool: &PoolConfig{
+			Scheme:          scheme,
+			PPLNSWindow:     4,
+			ShareMultiple:   10,
+			Confirmations:   2,
+			PayoutThreshold: big.NewInt(50),
+			DataDir:         dataDir,
+		},
+		RewardCalculator: fakeRewardCalculator{},
+		ChainReader:      chain,
+		PayoutSender:     sender,
+	}
+}
+
+func TestPPLNSDistributor(t *testing.T) {
+	worker1 := common.HexToAddress("0x1")
+	worker2 := common.HexToAddress("0x2")
+	d := newPPLNSDistributor(4)
+	d.addShare(worker1)
+	d.addShare(worker1)
+	d.addShare(worker1)
+	d.addShare(worker2)
+	assert.Equal(t, map[common.Address]*big.Int{worker1: big.NewInt(75), worker2: big.NewInt(25)}, d.divideReward(big.NewInt(100)))
+
+	// the oldest shares are out of the window, and the shares are kept after the division
+	d.addShare(worker2)
+	d.addShare(worker2)
+	assert.Equal(t, map[common.Address]*big.Int{worker1: big.NewInt(25), worker2: big.NewInt(75)}, d.divideReward(big.NewInt(100)))
+	assert.Equal(t, []poolShare{{Address: worker1, Count: 1}, {Address: worker2, Count: 3}}, d.getShares())
+
+	d2 := newPPLNSDistributor(2)
+	d2.setShares(d.getShares())
+	assert.Equal(t, []poolShare{{Address: worker2, Count: 2}}, d2.getShares())
+}
+
+func TestProportionalDistributor(t *testing.T) {
+	worker1 := common.HexToAddress("0x1")
+	worker2 := common.HexToAddress("0x2")
+	d := newProportionalDistributor()
+	d.addShare(worker1)
+	d.addShare(worker2)
+	d.addShare(worker2)
+	d.setShares(d.getShares())
+	assert.Equal(t, map[common.Address]*big.Int{worker1: big.NewInt(33), worker2: big.NewInt(66)}, d.divideReward(big.NewInt(100)))
+
+	// the round is ended by the found block
+	assert.Empty(t, d.divideReward(big.NewInt(100)))
+	assert.Empty(t, d.getShares())
+}
+
+func TestPoolConfig_shareDifficulty(t *testing.T) {
+	diff := common.HexToDiff("0x1e7fffff")
+	_, ok := (*PoolConfig)(nil).shareDifficulty(diff)
+	assert.False(t, ok)
+	_, ok = (&PoolConfig{ShareMultiple: 1}).shareDifficulty(diff)
+	assert.False(t, ok)
+
+	shareDiff, ok := (&PoolConfig{ShareMultiple: 256}).shareDifficulty(diff)
+	assert.True(t, ok)
+	assert.Equal(t, new(big.Int).Mul(diff.Big(), big.NewInt(256)), shareDiff.Big())
+
+	// the share target is capped
+	shareDiff, ok = (&PoolConfig{ShareMultiple: 1 << 20}).shareDifficulty(common.HexToDiff("0x1f7fffff"))
+	assert.True(t, ok)
+	assert.True(t, shareDiff.Big().Cmp(maxShareTarget) <= 0)
+}
+
+func TestRewardPool(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "mine_pool")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	_, err = newRewardPool(poolTestConfig("unknown", dataDir, nil, nil))
+	assert.Equal(t, g_error.ErrPoolUnknownScheme, err)
+
+	worker1 := common.HexToAddress("0x1")
+	worker2 := common.HexToAddress("0x2")
+	chain := fakePoolChain{}
+	sender := &fakePayoutSender{payouts: make(map[common.Address]*big.Int)}
+	pool, err := newRewardPool(poolTestConfig(SchemeProportional, dataDir, chain, sender))
+	assert.NoError(t, err)
+
+	// the repeated share isn't counted
+	assert.True(t, pool.submitShare(worker1, poolTestBlock(1, 1)))
+	assert.False(t, pool.submitShare(worker1, poolTestBlock(1, 1)))
+	assert.True(t, pool.submitShare(worker2, poolTestBlock(1, 2)))
+	found := poolTestBlock(1, 3)
+	assert.True(t, pool.submitShare(worker2, found))
+	pool.foundBlock(found)
+
+	assert.Equal(t, big.NewInt(66), pool.balance(worker2).Immature)
+	assert.Equal(t, big.NewInt(0), pool.balance(worker2).Balance)
+
+	// the found block isn't final
+	chain[1] = found
+	pool.onInsertBlock(poolTestBlock(2, 0))
+	assert.Equal(t, big.NewInt(0), pool.balance(worker1).Balance)
+
+	// the found block is final, the balance above the threshold is paid
+	pool.onInsertBlock(poolTestBlock(3, 0))
+	assert.Equal(t, WorkerBalance{Address: worker1, Balance: big.NewInt(33), Immature: big.NewInt(0), Paid: big.NewInt(0)}, pool.balance(worker1))
+	assert.Equal(t, WorkerBalance{Address: worker2, Balance: big.NewInt(0), Immature: big.NewInt(0), Paid: big.NewInt(66)}, pool.balance(worker2))
+	assert.Equal(t, map[common.Address]*big.Int{worker2: big.NewInt(66)}, sender.payouts)
+
+	// the found block replaced by the chain is dropped
+	assert.True(t, pool.submitShare(worker1, poolTestBlock(4, 1)))
+	pool.foundBlock(poolTestBlock(4, 1))
+	chain[4] = poolTestBlock(4, 2)
+	pool.onInsertBlock(poolTestBlock(6, 0))
+	assert.Equal(t, big.NewInt(33), pool.balance(worker1).Balance)
+
+	// the balance is kept if the payout failed
+	sender.err = errors.New("send failed")
+	assert.Equal(t, sender.err, pool.payReward(worker1))
+	assert.Equal(t, big.NewInt(33), pool.balance(worker1).Balance)
+	sender.err = nil
+	assert.Equal(t, g_error.ErrPoolEmptyBalance, pool.payReward(common.HexToAddress("0x3")))
+
+	// the balances and the pending rounds are restored
+	assert.True(t, pool.submitShare(worker1, poolTestBlock(7, 1)))
+	pool.foundBlock(poolTestBlock(7, 1))
+	restored, err := newRewardPool(poolTestConfig(SchemeProportional, dataDir, chain, sender))
+	assert.NoError(t, err)
+	assert.Equal(t, pool.balances(), restored.balances())
+	assert.Len(t, restored.balances(), 2)
+	assert.Equal(t, big.NewInt(100), restored.balance(worker1).Immature)
+
+	assert.NoError(t, restored.payReward(worker1))
+	assert.Equal(t, big.NewInt(33), sender.payouts[worker1])
+}
+
+func TestDefaultWorkManager_pool(t *testing.T) {
+	manager := newDefaultWorkManager(fakeMineConfig())
+	assert.Equal(t, g_error.ErrPoolNotEnabled, manager.payReward(common.HexToAddress("0x1")))
+	assert.Nil(t, manager.getBalances())
+	_, ok := manager.getShareDiff(common.HexToDiff("0x1effffff"))
+	assert.False(t, ok)
+
+	chain := fakePoolChain{}
+	config := poolTestConfig(SchemePPLNS, "", chain, nil)
+	config.BlockBroadcaster = fakeBlockBroadcaster{}
+	manager = newDefaultWorkManager(config)
+	_, ok = manager.getShareDiff(common.HexToDiff("0x1effffff"))
+	assert.True(t, ok)
+
+	worker := common.HexToAddress("0x1")
+	manager.submitShare(worker, poolTestBlock(1, 1))
+	found := poolTestBlock(1, 2)
+	manager.submitBlock(worker, found)
+	chain[1] = found
+	manager.onInsertBlock(poolTestBlock(3, 0))
+
+	// the balance isn't paid without the payout sender
+	assert.Equal(t, big.NewInt(100), manager.getBalance(worker).Balance)
+	assert.Equal(t, g_error.ErrPoolNoPayout, manager.payReward(worker))
+
+	assert.Panics(t, func() {
+		newDefaultWorkManager(poolTestConfig("unknown", "", chain, nil))
+	})
+}
@@ -95,6 +95,9 @@ func (dispatcher *workDispatcher) makeNewWorks(workerLen int) (workMsgCode int,
 	gasCeil := dispatcher.GetGasCeil()
 	dispatcher.curBlock = dispatcher.BlockBuilder.BuildWaitPackBlock(coinBaseAddr, gasFloor, gasCeil)
 	mineWorkBuilder := minemsg.MakeDefaultWorkBuilder()
+	if dispatcher.curBlock != nil {
+		mineWorkBuilder.ShareDiff, _ = dispatcher.Pool.shareDifficulty(dispatcher.curBlock.Difficulty())
+	}
 	return mineWorkBuilder.BuildWorks(dispatcher.curBlock, workerLen)
 }
 
@@ -17,7 +17,9 @@
 package minemaster
 
 import (
+	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"math/big"
@@ -25,13 +27,21 @@ import (
 )
 
 func newDefaultWorkManager(config MineConfig) *defaultWorkManager {
-	return &defaultWorkManager{
+	manager := &defaultWorkManager{
 		MineConfig: config,
 
 		performance: make(map[common.Address]workerPerformance),
 		reward:      make(map[common.Address]*big.Int),
 		totalReward: new(big.Int),
 	}
+	if config.Pool != nil {
+		pool, err := newRewardPool(config)
+		if err != nil {
+			panic(fmt.Sprintf("init the mine pool failed: %v", err))
+		}
+		manager.pool = pool
+	}
+	return manager
 }
 
 type defaultWorkManager struct {
@@ -44,6 +54,9 @@ type defaultWorkManager struct {
 
 	// wallet sums up all the rewards that this minemaster had received
 	totalReward *big.Int
+
+	// the pool accounting, nil if it isn't enabled
+	pool *rewardPool
 }
 
 func (manager *defaultWorkManager) subtractPerformance(address common.Address, performance uint64) {
@@ -165,7 +178,53 @@ func (manager *defaultWorkManager) submitBlock(workerAddress common.Address, blo
 	}
 	manager.performance[workerAddress].updatePerformance()
 
+	// the found block is a share too
+	if manager.pool != nil {
+		manager.pool.submitShare(workerAddress, block)
+		manager.pool.foundBlock(block)
+	}
+
 	// broadcast block
 	//pbft_log.Debug("submitBlock broad cast block","block id",block.Number(),"block txs",block.TxCount())
 	manager.BlockBroadcaster.BroadcastMinedBlock(block)
 }
+
+// submitShare counts the share which meets the share difficulty but not the block difficulty
+func (manager *defaultWorkManager) submitShare(workerAddress common.Address, block model.AbstractBlock) {
+	if manager.pool == nil {
+		return
+	}
+	manager.pool.submitShare(workerAddress, block)
+}
+
+func (manager *defaultWorkManager) getShareDiff(blockDiff common.Difficulty) (common.Difficulty, bool) {
+	return manager.Pool.shareDifficulty(blockDiff)
+}
+
+func (manager *defaultWorkManager) onInsertBlock(block model.AbstractBlock) {
+	if manager.pool == nil {
+		return
+	}
+	manager.pool.onInsertBlock(block)
+}
+
+func (manager *defaultWorkManager) getBalance(address common.Address) WorkerBalance {
+	if manager.pool == nil {
+		return WorkerBalance{Address: address, Balance: big.NewInt(0), Immature: big.NewInt(0), Paid: big.NewInt(0)}
+	}
+	return manager.pool.balance(address)
+}
+
+func (manager *defaultWorkManager) getBalances() []WorkerBalance {
+	if manager.pool == nil {
+		return nil
+	}
+	return manager.pool.balances()
+}
+
+func (manager *defaultWorkManager) payReward(address common.Address) error {
+	if manager.pool == nil {
+		return g_error.ErrPoolNotEnabled
+	}
+	return manager.pool.payReward(address)
+}
@@ -96,6 +96,10 @@ func (fakeCalculableBlock) GetVerifications() []model.AbstractVerification {
 	panic("implement me")
 }
 
+func (fakeCalculableBlock) GetAggregateCommit() *model.AggregateCommit {
+	panic("implement me")
+}
+
 func (fakeCalculableBlock) GetInterlinks() model.InterLink {
 	panic("implement me")
 }
@@ -18,6 +18,7 @@ package minemsg
 
 import (
 	"encoding/binary"
+	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
 )
@@ -26,7 +27,10 @@ func MakeDefaultWorkBuilder() *DefaultWorkBuilder {
 	return &DefaultWorkBuilder{}
 }
 
-type DefaultWorkBuilder struct{}
+type DefaultWorkBuilder struct {
+	// the share difficulty of the built works, empty if the master doesn't count the shares
+	ShareDiff common.Difficulty
+}
 
 func (builder *DefaultWorkBuilder) BuildWorks(newBlock model.AbstractBlock, workerLen int) (workMsgCode int, works []Work) {
 	if newBlock == nil {
@@ -42,7 +46,7 @@ func (builder *DefaultWorkBuilder) BuildWorks(newBlock model.AbstractBlock, work
 		binary.BigEndian.PutUint32(newHeader.Nonce[:4], uint32(i))
 
 		log.PBft.Info("BuildWorks", "verRoot", newHeader.VerificationRoot.Hex(), "register root", newHeader.RegisterRoot)
-		works = append(works, &DefaultWork{BlockHeader: newHeader})
+		works = append(works, &DefaultWork{BlockHeader: newHeader, ShareDiff: builder.ShareDiff})
 		log.Debug("DefaultWorkBuilder#BuildWorks", "newHeader.Nonce", newHeader.Nonce)
 	}
 	workMsgCode = NewDefaultWorkMsg
@@ -45,7 +45,9 @@ type DefaultWork struct {
 	ResultNonce common.BlockNonce
 	//pre-calculate rlp
 	RlpPreCal []byte
-	// TODO: add diff baseline
+	// the hash meeting the share difficulty but not the block difficulty is submitted as a share,
+	// the empty difficulty means only the blocks are submitted
+	ShareDiff common.Difficulty
 }
 
 func (work *DefaultWork) CalHash() (common.Hash, error) {
@@ -60,6 +62,11 @@ func (work *DefaultWork) CalHash() (common.Hash, error) {
 	return cs_crypto.Keccak256Hash(raw), nil
 }
 
+// IsShareWork returns whether the worker submits the shares of the work
+func (work *DefaultWork) IsShareWork() bool {
+	return !work.ShareDiff.Equal(common.Difficulty{})
+}
+
 func (work *DefaultWork) CalBlockRlpWithoutNonce() {
 	work.RlpPreCal = work.BlockHeader.RlpBlockWithoutNonce()
 }
@@ -81,12 +81,23 @@ func (executor *defaultWorkExecutor) ChangeNonce() bool {
 			log.Health.Info("found nonce", "height", executor.curWork.BlockHeader.Number)
 			return true
 		}
+		if executor.curWork.IsShareWork() && bHash.ValidHashForDifficulty(executor.curWork.ShareDiff) {
+			executor.submitShare()
+		}
 	} else {
 		log.Info("search nonce", "error", err)
 	}
 	return false
 }
 
+// submitShare submits a copy of the work with the share nonce, the search goes on with the current work
+func (executor *defaultWorkExecutor) submitShare() {
+	share := *executor.curWork
+	share.ResultNonce = share.BlockHeader.Nonce
+	log.Debug("found share", "height", share.BlockHeader.Number)
+	executor.submitter.SubmitWork(&share)
+}
+
 func (executor *defaultWorkExecutor) Submit() {
 	executor.submitter.SubmitWork(executor.curWork)
 }
@@ -29,9 +29,11 @@ import (
 )
 
 type fakeWorkSubmitter struct {
+	works []minemsg.Work
 }
 
 func (submitter *fakeWorkSubmitter) SubmitWork(work minemsg.Work) {
+	submitter.works = append(submitter.works, work)
 }
 
 func TestDefaultWorkExecutor_ChangeNonce(t *testing.T) {
@@ -68,6 +70,30 @@ out:
 	}
 }
 
+func TestDefaultWorkExecutor_submitShare(t *testing.T) {
+	// the block difficulty can't be met, while the share difficulty is met by half of the hashes
+	block := factory.CreateBlock2(common.HexToDiff("0x03000001"), 1)
+	work := &minemsg.DefaultWork{
+		BlockHeader: *(block.Header().(*model.Header)),
+		ShareDiff:   common.HexToDiff("0x207fffff"),
+	}
+	work.CalBlockRlpWithoutNonce()
+	submitter := &fakeWorkSubmitter{}
+	executor := NewDefaultWorkExecutor(work, submitter)
+	for i := 0; i < 100; i++ {
+		assert.False(t, executor.ChangeNonce())
+	}
+	assert.NotEmpty(t, submitter.works)
+
+	// the share is a copy of the work with its nonce
+	share := submitter.works[0].(*minemsg.DefaultWork)
+	assert.Equal(t, share.BlockHeader.Nonce, share.ResultNonce)
+	assert.NotEqual(t, work, share)
+	hash, err := share.CalHash()
+	assert.NoError(t, err)
+	assert.True(t, hash.ValidHashForDifficulty(work.ShareDiff))
+}
+
 func TestBytesCopy(t *testing.T) {
 	tmpNonce := common.BlockNonce{0, 0, 0, 0, 1, 0, 0, 1, 0}
 	fmt.Println(tmpNonce[:8])
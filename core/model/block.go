@@ -263,6 +263,8 @@ type Body struct {
 	Txs    []*Transaction         `json:"transactions"`
 	Vers   []AbstractVerification `json:"commit_msg"`
 	Inters InterLink              `json:"interlinks"`
+	// the aggregate commit replacing Vers after the Pluto fork, it has at most one item and adds nothing to the rlp of the older bodies
+	Commits []*AggregateCommit `json:"aggregate_commit,omitempty" rlp:"tail"`
 }
 
 func (b *Body) GetTxsSize() int {
@@ -425,10 +427,11 @@ func (b *Block) GetEiBloomBlockData(reqEstimator *iblt.HybridEstimator) *BloomBl
 		return nil
 	}
 	return &BloomBlockData{
-		Header:          b.header,
-		BloomRLP:        invBloomRLP,
-		PreVerification: b.Verifications(),
-		Interlinks:      b.GetInterlinks(),
+		Header:             b.header,
+		BloomRLP:           invBloomRLP,
+		PreVerification:    b.Verifications(),
+		Interlinks:         b.GetInterlinks(),
+		PreAggregateCommit: b.body.Commits,
 	}
 
 }
@@ -441,6 +444,20 @@ func (b *Block) GetVerifications() (result []AbstractVerification) {
 	return b.body.Vers
 }
 
+// SetAggregateCommit replaces the verifications of the block with the aggregate commit, the verification root is its hash
+func (b *Block) SetAggregateCommit(commit *AggregateCommit) {
+	b.body.Vers = nil
+	b.body.Commits = []*AggregateCommit{commit}
+	b.header.VerificationRoot = commit.Hash()
+}
+
+func (b *Block) GetAggregateCommit() *AggregateCommit {
+	if len(b.body.Commits) == 0 {
+		return nil
+	}
+	return b.body.Commits[0]
+}
+
 func (b *Block) GetTransactions() []*Transaction {
 	return b.body.Txs
 }
@@ -255,5 +255,6 @@ func (h *PBFTBlockJsonHandler) DecodeBody(to *Body, input []byte) error {
 	to.Vers = make([]AbstractVerification, len(from.Vers))
 	util.InterfaceSliceCopy(to.Vers, from.Vers)
 	to.Inters = from.Inters
+	to.Commits = from.Commits
 	return nil
 }
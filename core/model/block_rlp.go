@@ -74,13 +74,15 @@ func (h *PBFTBlockRlpHandler) DecodeBody(to *Body, s *rlp.Stream) error {
 	to.Vers = make([]AbstractVerification, len(sBody.Vers))
 	util.InterfaceSliceCopy(to.Vers, sBody.Vers)
 	to.Inters = sBody.Inters
+	to.Commits = sBody.Commits
 	return nil
 }
 
 type PBFTBody struct {
-	Txs    []*Transaction `json:"transactions"`
-	Vers   []*VoteMsg     `json:"commit_msg"`
-	Inters InterLink      `json:"interlinks"`
+	Txs     []*Transaction     `json:"transactions"`
+	Vers    []*VoteMsg         `json:"commit_msg"`
+	Inters  InterLink          `json:"interlinks"`
+	Commits []*AggregateCommit `json:"aggregate_commit,omitempty" rlp:"tail"`
 }
 
 func (b *Body) DecodeRLP(s *rlp.Stream) error {
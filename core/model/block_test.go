@@ -342,6 +342,7 @@ func newTestBody() (b *Body) {
 		[]*Transaction{CreateSignedTx(0, big.NewInt(10000))},
 		nil,
 		[]common.Hash{common.HexToHash("123")},
+		nil,
 	}
 	return
 }
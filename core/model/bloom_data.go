@@ -33,6 +33,8 @@ type BloomBlockData struct {
 	CurVerification []AbstractVerification
 	//interlins
 	Interlinks InterLink
+	// pre height aggregate commit after the Pluto fork
+	PreAggregateCommit []*AggregateCommit `rlp:"tail"`
 }
 
 func (data *BloomBlockData) EiRecoverToBlock(txPoolMap map[common.Hash]AbstractTransaction) (block *Block, err error) {
@@ -97,6 +99,9 @@ func (data *BloomBlockData) EiRecoverToBlock(txPoolMap map[common.Hash]AbstractT
 	if block = NewBlock(data.Header, possibleTxs, data.PreVerification); block == nil {
 		return nil, errors.New("new block is nil")
 	}
+	if len(data.PreAggregateCommit) > 0 {
+		block.SetAggregateCommit(data.PreAggregateCommit[0])
+	}
 
 	return block, nil
 }
@@ -30,6 +30,7 @@ func newbloomblockdata() *BloomBlockData {
 		nil,
 		nil,
 		nil,
+		nil,
 	}
 }
 
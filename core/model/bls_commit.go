@@ -0,0 +1,211 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+)
+
+// BlsSignHash is what the verifiers sign with the bls keys, the time of the vote is left out so that
+// the signatures of all the verifiers are on the same msg and can be aggregated
+func BlsSignHash(height, round uint64, blockID common.Hash) common.Hash {
+	return common.RlpHashKeccak256([]interface{}{height, round, blockID, VoteMessage})
+}
+
+// BlsRegistration is the ExtraData of a register tx, the proof of possession keeps rogue keys out of the aggregate signatures.
+// It's bound to the registering verifier, so the key of a verifier can't be registered by another account.
+type BlsRegistration struct {
+	PubKey []byte
+	Pop    []byte
+}
+
+func NewBlsRegistration(key *bls.SecretKey, verifier common.Address) *BlsRegistration {
+	return &BlsRegistration{PubKey: key.PublicKey().Marshal(), Pop: key.ProofOfPossession(verifier.Bytes()).Marshal()}
+}
+
+// DecodeBlsRegistration decodes the ExtraData of a register tx and checks the proof of possession of the sender
+func DecodeBlsRegistration(extraData []byte, sender common.Address) (*BlsRegistration, error) {
+	var reg BlsRegistration
+	if err := rlp.DecodeBytes(extraData, &reg); err != nil {
+		return nil, err
+	}
+	pk, err := bls.UnmarshalPublicKey(reg.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	pop, err := bls.UnmarshalSignature(reg.Pop)
+	if err != nil {
+		return nil, err
+	}
+	if !bls.VerifyProofOfPossession(pk, sender.Bytes(), pop) {
+		return nil, errors.New("invalid bls proof of possession")
+	}
+	return &reg, nil
+}
+
+// NewBlsRegisterTransaction registers the bls key of the registration along with the stake
+func NewBlsRegisterTransaction(nonce uint64, amount *big.Int, gasPrice *big.Int, gasLimit uint64, reg *BlsRegistration) (*Transaction, error) {
+	data, err := rlp.EncodeToBytes(reg)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewRegisterTransaction(nonce, amount, gasPrice, gasLimit)
+	tx.data.ExtraData = data
+	return tx, nil
+}
+
+// AggregateCommit replaces the commit votes of the previous block after the Pluto fork. Signers is a bitmap over the
+// verifiers of the previous block, Signature is the sum of their bls signatures on BlsSignHash.
+type AggregateCommit struct {
+	Height    uint64      `json:"height"`
+	Round     uint64      `json:"round"`
+	BlockID   common.Hash `json:"block_id"`
+	Signers   []byte      `json:"signers"`
+	Signature []byte      `json:"signature"`
+}
+
+// NewAggregateCommit aggregates the bls signatures of the votes, the votes must have been verified
+func NewAggregateCommit(votes []AbstractVerification, verifiers []common.Address) (*AggregateCommit, error) {
+	if len(votes) == 0 {
+		return nil, errors.New("no votes to aggregate")
+	}
+	first := votes[0]
+	commit := &AggregateCommit{
+		Height:  first.GetHeight(),
+		Round:   first.GetRound(),
+		BlockID: first.GetBlockId(),
+		Signers: make([]byte, (len(verifiers)+7)/8),
+	}
+
+	var sigs []*bls.Signature
+	for _, v := range votes {
+		vote, ok := v.(*VoteMsg)
+		if !ok || vote.Witness == nil || vote.GetType() != VoteMessage {
+			return nil, errors.New("only commit votes can be aggregated")
+		}
+		if vote.Height != commit.Height || vote.Round != commit.Round || !vote.BlockID.IsEqual(commit.BlockID) {
+			return nil, errors.New("votes on different blocks can't be aggregated")
+		}
+		index := addressIndex(vote.Witness.Address, verifiers)
+		if index < 0 {
+			return nil, errors.New("vote signer isn't verifier")
+		}
+		if commit.Signers[index/8]&(1<<uint(index%8)) != 0 {
+			return nil, errors.New("same vote signer in votes")
+		}
+		sig, err := bls.UnmarshalSignature(vote.Witness.BlsSign)
+		if err != nil {
+			return nil, err
+		}
+		commit.Signers[index/8] |= 1 << uint(index%8)
+		sigs = append(sigs, sig)
+	}
+
+	sig, err := bls.AggregateSignatures(sigs)
+	if err != nil {
+		return nil, err
+	}
+	commit.Signature = sig.Marshal()
+	return commit, nil
+}
+
+func (c *AggregateCommit) Hash() common.Hash {
+	return common.RlpHashKeccak256(c)
+}
+
+// SignerAddresses picks the signers out of the verifiers by the bitmap
+func (c *AggregateCommit) SignerAddresses(verifiers []common.Address) ([]common.Address, error) {
+	if len(c.Signers) != (len(verifiers)+7)/8 {
+		return nil, errors.New("signer bitmap length doesn't match the verifiers")
+	}
+	var signers []common.Address
+	for i := 0; i < len(c.Signers)*8; i++ {
+		if c.Signers[i/8]&(1<<uint(i%8)) == 0 {
+			continue
+		}
+		if i >= len(verifiers) {
+			return nil, errors.New("signer bitmap is out of the verifiers")
+		}
+		signers = append(signers, verifiers[i])
+	}
+	return signers, nil
+}
+
+// VerifySignature checks the aggregate signature against the registered bls keys of the signers
+func (c *AggregateCommit) VerifySignature(pubKeys [][]byte) error {
+	keys := make([]*bls.PublicKey, 0, len(pubKeys))
+	for _, data := range pubKeys {
+		key, err := bls.UnmarshalPublicKey(data)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	aggKey, err := bls.AggregatePublicKeys(keys)
+	if err != nil {
+		return err
+	}
+	sig, err := bls.UnmarshalSignature(c.Signature)
+	if err != nil {
+		return err
+	}
+	if !bls.Verify(aggKey, BlsSignHash(c.Height, c.Round, c.BlockID).Bytes(), sig) {
+		return errors.New("aggregate signature not valid")
+	}
+	return nil
+}
+
+// Verifications expands the commit to the unsigned votes of its signers for the rewards, which only need the signers and the round
+func (c *AggregateCommit) Verifications(verifiers []common.Address) ([]AbstractVerification, error) {
+	signers, err := c.SignerAddresses(verifiers)
+	if err != nil {
+		return nil, err
+	}
+	vers := make([]AbstractVerification, 0, len(signers))
+	for _, signer := range signers {
+		vers = append(vers, &VoteMsg{
+			Height:   c.Height,
+			Round:    c.Round,
+			BlockID:  c.BlockID,
+			VoteType: VoteMessage,
+			Witness:  &WitMsg{Address: signer},
+		})
+	}
+	return vers, nil
+}
+
+// CommitVerifications returns the commit votes of the previous block carried by the block,
+// verifiers are the verifiers of the previous block
+func CommitVerifications(block AbstractBlock, verifiers []common.Address) ([]AbstractVerification, error) {
+	if commit := block.GetAggregateCommit(); commit != nil {
+		return commit.Verifications(verifiers)
+	}
+	return block.GetVerifications(), nil
+}
+
+func addressIndex(address common.Address, addresses []common.Address) int {
+	for i, a := range addresses {
+		if a.IsEqual(address) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,154 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func createBlsVotes(t *testing.T, n int, blockID common.Hash) ([]common.Address, []*bls.SecretKey, []AbstractVerification) {
+	var verifiers []common.Address
+	var keys []*bls.SecretKey
+	var votes []AbstractVerification
+	for i := 0; i < n; i++ {
+		key, err := bls.GenerateKey(nil)
+		assert.NoError(t, err)
+		addr := common.Address{0x00, 0x00, byte(i + 1)}
+		verifiers = append(verifiers, addr)
+		keys = append(keys, key)
+		votes = append(votes, &VoteMsg{
+			Height:   2,
+			Round:    1,
+			BlockID:  blockID,
+			VoteType: VoteMessage,
+			Witness:  &WitMsg{Address: addr, BlsSign: key.Sign(BlsSignHash(2, 1, blockID).Bytes()).Marshal()},
+		})
+	}
+	return verifiers, keys, votes
+}
+
+func TestDecodeBlsRegistration(t *testing.T) {
+	key, err := bls.GenerateKey(nil)
+	assert.NoError(t, err)
+
+	tx, err := NewBlsRegisterTransaction(1, big.NewInt(100), big.NewInt(1), 21000, NewBlsRegistration(key, aliceAddr))
+	assert.NoError(t, err)
+	assert.Equal(t, common.TxType(common.AddressTypeStake), tx.GetType())
+	reg, err := DecodeBlsRegistration(tx.ExtraData(), aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, key.PublicKey().Marshal(), reg.PubKey)
+
+	// the registration copied by another account
+	_, err = DecodeBlsRegistration(tx.ExtraData(), bobAddr)
+	assert.Error(t, err)
+
+	// the proof of possession of another key
+	other, err := bls.GenerateKey(nil)
+	assert.NoError(t, err)
+	reg.Pop = other.ProofOfPossession(aliceAddr.Bytes()).Marshal()
+	data, err := rlp.EncodeToBytes(reg)
+	assert.NoError(t, err)
+	_, err = DecodeBlsRegistration(data, aliceAddr)
+	assert.Error(t, err)
+
+	_, err = DecodeBlsRegistration([]byte{1, 2, 3}, aliceAddr)
+	assert.Error(t, err)
+}
+
+func TestAggregateCommit(t *testing.T) {
+	blockID := common.HexToHash("123")
+	verifiers, keys, votes := createBlsVotes(t, 4, blockID)
+
+	// the second verifier didn't vote
+	commit, err := NewAggregateCommit([]AbstractVerification{votes[0], votes[2], votes[3]}, verifiers)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x0d}, commit.Signers)
+
+	signers, err := commit.SignerAddresses(verifiers)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{verifiers[0], verifiers[2], verifiers[3]}, signers)
+
+	pubKeys := [][]byte{keys[0].PublicKey().Marshal(), keys[2].PublicKey().Marshal(), keys[3].PublicKey().Marshal()}
+	assert.NoError(t, commit.VerifySignature(pubKeys))
+	pubKeys[1] = keys[1].PublicKey().Marshal()
+	assert.Error(t, commit.VerifySignature(pubKeys))
+
+	vers, err := commit.Verifications(verifiers)
+	assert.NoError(t, err)
+	assert.Len(t, vers, 3)
+	assert.Equal(t, verifiers[2], vers[1].GetAddress())
+	assert.Equal(t, uint64(1), vers[1].GetRound())
+
+	_, err = commit.SignerAddresses(verifiers[:1])
+	assert.Error(t, err)
+	commit.Signers = []byte{0x10}
+	_, err = commit.SignerAddresses(verifiers)
+	assert.Error(t, err)
+}
+
+func TestNewAggregateCommit_Invalid(t *testing.T) {
+	blockID := common.HexToHash("123")
+	verifiers, _, votes := createBlsVotes(t, 4, blockID)
+
+	_, err := NewAggregateCommit(nil, verifiers)
+	assert.Error(t, err)
+	_, err = NewAggregateCommit([]AbstractVerification{votes[0], votes[0]}, verifiers)
+	assert.Error(t, err)
+	_, err = NewAggregateCommit(votes, verifiers[1:])
+	assert.Error(t, err)
+
+	votes[1].(*VoteMsg).BlockID = common.HexToHash("456")
+	_, err = NewAggregateCommit(votes, verifiers)
+	assert.Error(t, err)
+}
+
+func TestBlock_SetAggregateCommit(t *testing.T) {
+	SetBlockRlpHandler(&PBFTBlockRlpHandler{})
+	verifiers, _, votes := createBlsVotes(t, 4, common.HexToHash("123"))
+	commit, err := NewAggregateCommit(votes, verifiers)
+	assert.NoError(t, err)
+
+	block := CreateBlock(3, common.HexToHash("123"), 2)
+	block.SetAggregateCommit(commit)
+	block.RefreshHashCache()
+	assert.Len(t, block.GetVerifications(), 0)
+	assert.Equal(t, commit.Hash(), block.VerificationRoot())
+
+	vers, err := CommitVerifications(block, verifiers)
+	assert.NoError(t, err)
+	assert.Len(t, vers, 4)
+
+	data, err := rlp.EncodeToBytes(block)
+	assert.NoError(t, err)
+	var dBlock Block
+	assert.NoError(t, rlp.DecodeBytes(data, &dBlock))
+	assert.Equal(t, block.Hash(), dBlock.Hash())
+	assert.Equal(t, commit, dBlock.GetAggregateCommit())
+
+	// the bodies without the commit are encoded as before
+	body := &Body{Txs: block.body.Txs, Inters: InterLink{}}
+	data, err = rlp.EncodeToBytes(body)
+	assert.NoError(t, err)
+	old, err := rlp.EncodeToBytes([]interface{}{block.body.Txs, []AbstractVerification{}, InterLink{}})
+	assert.NoError(t, err)
+	assert.Equal(t, old, data)
+}
@@ -23,11 +23,13 @@ import (
 	"github.com/dipperin/dipperin-core/core/bloom"
 	"github.com/dipperin/dipperin-core/core/chain"
 	"github.com/dipperin/dipperin-core/core/chain-communication"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/chain/state-processor"
 
 	"github.com/dipperin/dipperin-core/core/model"
 	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bls"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"math/big"
@@ -79,11 +81,12 @@ func (builder *BftBlockBuilder) commitTransactions(txs *model.TransactionsByFeeA
 		log.Info("BftBlockBuilder#commitTransactions ", "tx hash", tx.CalTxId())
 		//from, _ := tx.Sender(builder.nodeContext.TxSigner())
 		conf := state_processor.TxProcessConfig{
-			Tx:       tx,
-			Header:   header,
-			GetHash:  state.GetBlockHashByNumber,
-			GasLimit: &gasLimit,
-			GasUsed:  &gasUsed,
+			Tx:           tx,
+			Header:       header,
+			GetHash:      state.GetBlockHashByNumber,
+			GetVerifiers: state.GetVerifiersByNumber,
+			GasLimit:     &gasLimit,
+			GasUsed:      &gasUsed,
 		}
 		err := builder.commitTransaction(&conf, state)
 		if err != nil {
@@ -176,6 +179,15 @@ func (builder *BftBlockBuilder) BuildWaitPackBlock(coinbaseAddr common.Address,
 	processor, err := builder.ChainReader.BlockProcessor(curBlock.StateRoot())
 	//processor, err := builder.BuildStateProcessor.BuildStateProcessor(curBlock.StateRoot(), builder.ChainReader, builder.StateStorage)
 
+	// the commits of the normal blocks are aggregated after the Pluto fork, the keys are read before the txs change the state
+	var commit *model.AggregateCommit
+	if curHeight > 0 && chain_config.GetChainConfig().IsPluto(curHeight) && !curBlock.IsSpecial() {
+		if commit, err = builder.aggregateCommit(curBlock, vers, processor); err != nil {
+			log.Warn("can't aggregate pre verifications", "new height", curHeight+1, "err", err)
+			return nil
+		}
+	}
+
 	log.Info("~~~~~~~~~~~~~~~the pending len is:", "number", len(pending))
 	txs := model.NewTransactionsByFeeAndNonce(builder.TxSigner, pending)
 	txBuf, receipts := builder.commitTransactions(txs, processor, header, vers)
@@ -196,7 +208,13 @@ func (builder *BftBlockBuilder) BuildWaitPackBlock(coinbaseAddr common.Address,
 	}
 	log.Info("build bft block1", "vers", len(vers), "height", curHeight)
 
-	block := model.NewBlock(header, tmpTxs, vers)
+	var block *model.Block
+	if commit != nil {
+		block = model.NewBlock(header, tmpTxs, nil)
+		block.SetAggregateCommit(commit)
+	} else {
+		block = model.NewBlock(header, tmpTxs, vers)
+	}
 	if block.Number() == 1 && !block.VerificationRoot().IsEqual(model.EmptyVerfRoot) {
 		panic(fmt.Sprintf("invalid v root: %v", block.VerificationRoot()))
 	}
@@ -244,6 +262,43 @@ func (builder *BftBlockBuilder) BuildWaitPackBlock(coinbaseAddr common.Address,
 	return block
 }
 
+// aggregateCommit aggregates the seen commits of the current block, the votes without a valid bls signature are left out
+func (builder *BftBlockBuilder) aggregateCommit(curBlock model.AbstractBlock, vers []model.AbstractVerification, processor *chain.BlockProcessor) (*model.AggregateCommit, error) {
+	if processor == nil {
+		return nil, fmt.Errorf("no state processor for block %v", curBlock.Number())
+	}
+	slot := builder.ChainReader.GetSlot(curBlock)
+	verifiers := builder.ChainReader.GetVerifiers(*slot)
+
+	var signed []model.AbstractVerification
+	for _, v := range vers {
+		vote, ok := v.(*model.VoteMsg)
+		if !ok || vote.Witness == nil {
+			continue
+		}
+		key, err := processor.GetBlsKey(vote.Witness.Address)
+		if err != nil || len(key) == 0 {
+			continue
+		}
+		pk, err := bls.UnmarshalPublicKey(key)
+		if err != nil {
+			continue
+		}
+		sig, err := bls.UnmarshalSignature(vote.Witness.BlsSign)
+		if err != nil {
+			continue
+		}
+		if bls.Verify(pk, model.BlsSignHash(vote.Height, vote.Round, vote.BlockID).Bytes(), sig) {
+			signed = append(signed, vote)
+		}
+	}
+
+	if len(signed) < len(verifiers)*2/3+1 {
+		return nil, fmt.Errorf("only %v of %v verifiers signed with bls keys", len(signed), len(verifiers))
+	}
+	return model.NewAggregateCommit(signed, verifiers)
+}
+
 //func (builder *DefaultBlockBuilder) NewBlockFromLastBlock(coinbaseAddr common.Address) model.AbstractBlock {
 //	if coinbaseAddr.IsEmpty() {
 //		panic("call NewBlockFromLastBlock, but coinbase address is empty")
@@ -302,21 +357,12 @@ func (builder *BftBlockBuilder) GetDifficulty() common.Difficulty {
 	chainReader := builder.ChainReader
 
 	curBlock := chainReader.CurrentBlock()
-	lastPNum := model.LastPeriodBlockNum(curBlock.Number())
-	if lastPNum == 0 {
-		lastPNum = 1
-	}
-
-	log.Debug("call GetDifficulty for mine, get lastPeriodBlock", "num", lastPNum)
-	lastPeriodBlock := chainReader.GetBlockByNumber(lastPNum)
 	if curBlock == nil {
 		panic("mine master get difficulty error,block is nil")
 	}
 
-	//find the neighbor normal block
-	findBlock := chainReader.GetLatestNormalBlock()
-
-	diff := model.NewCalNewWorkDiff(lastPeriodBlock, findBlock, curBlock.Number())
+	// the same difficulty as the block validation
+	diff := model.NextDifficulty(chainReader, curBlock.Number())
 
 	log.Debug("mine master difficulty", "diff", diff.Hex())
 	return diff
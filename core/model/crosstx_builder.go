@@ -17,12 +17,19 @@
 package model
 
 import (
+	"crypto/sha256"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"math/big"
 )
 
-//
+// CalHashLock returns the hash lock for the hash key. sha256 is used so that the
+// same secret can unlock HTLCs on bitcoin-like chains during an atomic swap.
+func CalHashLock(hashKey []byte) common.Hash {
+	return common.Hash(sha256.Sum256(hashKey))
+}
+
+// CreateRawLockTx alice locks amount at the lock address of alice and bob until block time
 func CreateRawLockTx(nonce uint64, lock common.Hash, time *big.Int, amount *big.Int, gasPrice *big.Int, gasLimit uint64, alice common.Address, bob common.Address) *Transaction {
 	to := cs_crypto.GetLockAddress(alice, bob)
 	data := bob.Bytes()
@@ -46,6 +53,7 @@ func CreateRawLockTx(nonce uint64, lock common.Hash, time *big.Int, amount *big.
 	return &Transaction{data: txdata, wit: wit}
 }
 
+// CreateRawRefundTx alice takes back the locked money after the time lock expired
 func CreateRawRefundTx(nonce uint64, amount *big.Int, gasPrice *big.Int, gasLimit uint64, alice common.Address, bob common.Address) *Transaction {
 	to := cs_crypto.GetLockAddress(alice, bob)
 	data := bob.Bytes()
@@ -56,6 +64,8 @@ func CreateRawRefundTx(nonce uint64, amount *big.Int, gasPrice *big.Int, gasLimi
 		TimeLock:     new(big.Int),
 		Amount:       new(big.Int).Set(amount),
 		ExtraData:    data,
+		Price:        gasPrice,
+		GasLimit:     gasLimit,
 	}
 	wit := witness{
 		R:       new(big.Int),
@@ -66,6 +76,7 @@ func CreateRawRefundTx(nonce uint64, amount *big.Int, gasPrice *big.Int, gasLimi
 	return &Transaction{data: txdata, wit: wit}
 }
 
+// CreateRawClaimTx bob takes the locked money with the hash key before the time lock expired
 func CreateRawClaimTx(nonce uint64, key []byte, amount *big.Int, gasPrice *big.Int, gasLimit uint64, alice common.Address, bob common.Address) *Transaction {
 	to := cs_crypto.GetLockAddress(alice, bob)
 	data := alice.Bytes()
@@ -77,6 +88,8 @@ func CreateRawClaimTx(nonce uint64, key []byte, amount *big.Int, gasPrice *big.I
 		TimeLock:     new(big.Int),
 		Amount:       new(big.Int).Set(amount),
 		ExtraData:    data,
+		Price:        gasPrice,
+		GasLimit:     gasLimit,
 	}
 	wit := witness{
 		R:       new(big.Int),
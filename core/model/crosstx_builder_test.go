@@ -77,3 +77,9 @@ func TestCreateRawClaimTx(t *testing.T) {
 	try, _ := rlpHash(tx.wit.HashKey)
 	assert.Equal(t, try, hashLock)
 }
+
+func TestCalHashLock(t *testing.T) {
+	hashKey := []byte("123")
+	assert.Equal(t, common.HexToHash("0xa665a45920422f9d417e4867efdc4fb8a04a1f3fff1fa07e998e86f7f7a27ae3"), CalHashLock(hashKey))
+	assert.NotEqual(t, CalHashLock(hashKey), CalHashLock([]byte("1234")))
+}
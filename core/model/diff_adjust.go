@@ -67,6 +67,34 @@ func LastPeriodBlockNum(curBlockNum uint64) uint64 {
 	return curBlockNum / BlockCountOfPeriod * BlockCountOfPeriod
 }
 
+// DiffChainReader reads the blocks used by the difficulty adjustment
+type DiffChainReader interface {
+	GetBlockByNumber(number uint64) AbstractBlock
+	GetLatestNormalBlock() AbstractBlock
+}
+
+// NextDifficulty returns the difficulty of the block after the current block, the mine master's work builder and
+// the block validation must use it to get the same difficulty. The period algorithm is used before the Neptune fork,
+// and the DiffAlgorithm of the chain config after it.
+func NextDifficulty(chain DiffChainReader, currentBlockNumber uint64) common.Difficulty {
+	if IsIgnoreDifficultyValidation() {
+		return common.HexToDiff("0x1fffffff")
+	}
+
+	config := chain_config.GetChainConfig()
+	lastNormalBlock := chain.GetLatestNormalBlock()
+	if config.IsNeptune(currentBlockNumber+1) && config.DiffAlgorithm == chain_config.DiffAlgorithmLWMA {
+		blocks := lwmaWindowBlocks(chain, lastNormalBlock, config.LWMAWindow)
+		return calNewWorkDiffByLWMA(blocks, config.BlockGenerate, config.MainPowLimit)
+	}
+
+	preSpanNum := LastPeriodBlockNum(currentBlockNumber)
+	if preSpanNum == 0 {
+		preSpanNum = 1
+	}
+	return NewCalNewWorkDiff(chain.GetBlockByNumber(preSpanNum), lastNormalBlock, currentBlockNumber)
+}
+
 //there are empty blocks in a Recent block, so we need to consider empty blocks and use a new method.
 func NewCalNewWorkDiff(preSpanBlock, lastNormalBlock AbstractBlock, currentBlockNumber uint64) common.Difficulty {
 	if IsIgnoreDifficultyValidation() {
@@ -136,6 +164,73 @@ func calNewWorkDiffByTime(preSanBlockTime *big.Int, lastBlockTime *big.Int, last
 	return common.BigToDiff(newTarget)
 }
 
+// lwmaWindowBlocks returns the latest window+1 normal blocks in ascending order, the special blocks aren't mined
+// so they are skipped. The genesis isn't included as its timestamp isn't the mining time.
+func lwmaWindowBlocks(chain DiffChainReader, lastNormalBlock AbstractBlock, window uint64) []AbstractBlock {
+	blocks := []AbstractBlock{lastNormalBlock}
+	for number := lastNormalBlock.Number(); number > 1 && uint64(len(blocks)) <= window; {
+		number--
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			break
+		}
+		if block.IsSpecial() {
+			continue
+		}
+		blocks = append(blocks, block)
+	}
+
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	return blocks
+}
+
+// calNewWorkDiffByLWMA returns the difficulty by the linearly weighted moving average of the solve times, the later
+// blocks have the larger weights so the difficulty follows the hash rate changes within a few blocks.
+// formula： target = avgTarget * sum(i * solveTime_i) / (sum(i) * expectTime)
+func calNewWorkDiffByLWMA(blocks []AbstractBlock, blockGenerate uint64, powLimit *big.Int) common.Difficulty {
+	n := int64(len(blocks) - 1)
+	if n < 1 {
+		return blocks[len(blocks)-1].Difficulty()
+	}
+
+	// here is nanosecond, the solve times are limited to [0, 6 * expectTime] against the fake timestamps
+	expectTime := new(big.Int).Mul(new(big.Int).SetUint64(blockGenerate), big.NewInt(1e9))
+	maxSolveTime := new(big.Int).Mul(expectTime, big.NewInt(6))
+
+	weightedTime := big.NewInt(0)
+	sumTarget := big.NewInt(0)
+	for i := int64(1); i <= n; i++ {
+		solveTime := new(big.Int).Sub(blocks[i].Timestamp(), blocks[i-1].Timestamp())
+		if solveTime.Sign() < 0 {
+			solveTime.SetInt64(0)
+		} else if solveTime.Cmp(maxSolveTime) > 0 {
+			solveTime.Set(maxSolveTime)
+		}
+		weightedTime.Add(weightedTime, solveTime.Mul(solveTime, big.NewInt(i)))
+		sumTarget.Add(sumTarget, blocks[i].Difficulty().DiffToTarget().Big())
+	}
+
+	// the difficulty rises at most 10 times
+	weights := big.NewInt(n * (n + 1) / 2)
+	minWeightedTime := new(big.Int).Mul(weights, expectTime)
+	minWeightedTime.Div(minWeightedTime, big.NewInt(10))
+	if weightedTime.Cmp(minWeightedTime) < 0 {
+		weightedTime.Set(minWeightedTime)
+	}
+
+	newTarget := new(big.Int).Mul(sumTarget, weightedTime)
+	newTarget.Div(newTarget, new(big.Int).Mul(big.NewInt(n), new(big.Int).Mul(weights, expectTime)))
+
+	if newTarget.Sign() == 0 {
+		newTarget.SetInt64(1)
+	} else if newTarget.Cmp(powLimit) > 0 {
+		newTarget.Set(powLimit)
+	}
+	return common.BigToDiff(newTarget)
+}
+
 // test sharding
 //var (
 //	diffIndex = 0
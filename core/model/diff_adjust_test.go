@@ -19,8 +19,10 @@ package model
 import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/stretchr/testify/assert"
 	"math/big"
+	"math/rand"
 	"testing"
 	"time"
 )
@@ -90,3 +92,169 @@ func TestNewCalNewWorkDiff(t *testing.T) {
 	result = NewCalNewWorkDiff(block1, block2, 12)
 	assert.Equal(t, common.HexToDiff("0x1fffffff"), result)
 }
+
+// fakeDiffChain is a chain of the blocks indexed by their numbers
+type fakeDiffChain []AbstractBlock
+
+func (c fakeDiffChain) GetBlockByNumber(number uint64) AbstractBlock {
+	if number >= uint64(len(c)) {
+		return nil
+	}
+	return c[number]
+}
+
+func (c fakeDiffChain) GetLatestNormalBlock() AbstractBlock {
+	for i := len(c) - 1; i > 0; i-- {
+		if !c[i].IsSpecial() {
+			return c[i]
+		}
+	}
+	return c[0]
+}
+
+func newDiffBlock(number uint64, diff common.Difficulty, timestamp int64) *Block {
+	return &Block{header: &Header{Number: number, Diff: diff, TimeStamp: big.NewInt(timestamp)}, body: &Body{}}
+}
+
+func setNeptune(algorithm string, block *big.Int) func() {
+	conf := chain_config.GetChainConfig()
+	algo, fork := conf.DiffAlgorithm, conf.NeptuneBlock
+	ignore := IgnoreDifficultyValidation
+	conf.DiffAlgorithm, conf.NeptuneBlock = algorithm, block
+	IgnoreDifficultyValidation = false
+	return func() {
+		conf.DiffAlgorithm, conf.NeptuneBlock = algo, fork
+		IgnoreDifficultyValidation = ignore
+	}
+}
+
+func TestNextDifficulty(t *testing.T) {
+	defer setNeptune(chain_config.DiffAlgorithmLWMA, big.NewInt(10))()
+
+	// the blocks are mined twice as fast as expected
+	expectTime := int64(chain_config.GetChainConfig().BlockGenerate) * 1e9
+	diff := common.HexToDiff("0x1e566611")
+	chain := fakeDiffChain{newDiffBlock(0, diff, 0)}
+	for i := uint64(1); i < 20; i++ {
+		chain = append(chain, newDiffBlock(i, diff, int64(i)*expectTime/2))
+	}
+
+	// the period algorithm keeps the difficulty before the fork
+	assert.Equal(t, diff, NextDifficulty(chain[:9], 8))
+
+	// the target is halved after the fork
+	next := NextDifficulty(chain[:10], 9)
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(diff.Big()), new(big.Float).SetInt(next.Big())).Float64()
+	assert.InDelta(t, 2, ratio, 0.01)
+
+	// the special blocks are skipped
+	special := append(fakeDiffChain{}, chain...)
+	special = append(special, &Block{header: &Header{Number: 20, TimeStamp: big.NewInt(100 * expectTime)}, body: &Body{}})
+	assert.True(t, special[20].IsSpecial())
+	assert.Equal(t, NextDifficulty(chain, 19), NextDifficulty(special, 20))
+
+	// the period algorithm is selected
+	setNeptune(chain_config.DiffAlgorithmPeriod, big.NewInt(10))
+	assert.Equal(t, diff, NextDifficulty(chain, 19))
+}
+
+func TestCalNewWorkDiffByLWMA(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	expectTime := int64(conf.BlockGenerate) * 1e9
+	diff := common.HexToDiff("0x1e566611")
+
+	// a single block keeps its difficulty
+	single := []AbstractBlock{newDiffBlock(1, diff, 0)}
+	assert.Equal(t, diff, calNewWorkDiffByLWMA(single, conf.BlockGenerate, conf.MainPowLimit))
+
+	var blocks []AbstractBlock
+	for i := int64(0); i <= 10; i++ {
+		blocks = append(blocks, newDiffBlock(uint64(i+1), diff, i*expectTime))
+	}
+	next := calNewWorkDiffByLWMA(blocks, conf.BlockGenerate, conf.MainPowLimit)
+	assert.Equal(t, diff, next)
+
+	// the fake timestamps raise the difficulty at most 10 times
+	for i := range blocks {
+		blocks[i] = newDiffBlock(uint64(i+1), diff, 0)
+	}
+	next = calNewWorkDiffByLWMA(blocks, conf.BlockGenerate, conf.MainPowLimit)
+	ratio := new(big.Int).Div(diff.Big(), next.Big())
+	assert.Equal(t, int64(10), ratio.Int64())
+
+	// the target doesn't exceed the pow limit
+	for i := range blocks {
+		blocks[i] = newDiffBlock(uint64(i+1), common.BigToDiff(conf.MainPowLimit), int64(i)*expectTime*100)
+	}
+	next = calNewWorkDiffByLWMA(blocks, conf.BlockGenerate, conf.MainPowLimit)
+	assert.Equal(t, common.BigToDiff(conf.MainPowLimit), next)
+}
+
+// simulateMining mines the blocks with the hash rates of the steps, it returns the solve times in seconds
+func simulateMining(algorithm string, hashRates []float64, stepBlocks int) []float64 {
+	defer setNeptune(algorithm, big.NewInt(0))()
+
+	conf := chain_config.GetChainConfig()
+	expectTime := float64(conf.BlockGenerate)
+	maxHash := new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 256))
+	r := rand.New(rand.NewSource(1))
+
+	// the genesis difficulty matches the first hash rate
+	target, _ := new(big.Float).Quo(maxHash, big.NewFloat(expectTime*hashRates[0])).Int(nil)
+	chain := fakeDiffChain{newDiffBlock(0, common.BigToDiff(target), 0)}
+	var solveTimes []float64
+	for _, rate := range hashRates {
+		for i := 0; i < stepBlocks; i++ {
+			cur := chain[len(chain)-1]
+			diff := NextDifficulty(chain, cur.Number())
+			if len(chain) == 1 {
+				diff = cur.Difficulty()
+			}
+
+			// the solve time is exponentially distributed with the mean of 2^256 / target / hashRate
+			mean, _ := new(big.Float).Quo(maxHash, new(big.Float).Mul(new(big.Float).SetInt(diff.Big()), big.NewFloat(rate))).Float64()
+			solveTime := mean * r.ExpFloat64()
+			solveTimes = append(solveTimes, solveTime)
+
+			timestamp := new(big.Int).Add(cur.Timestamp(), big.NewInt(int64(solveTime*1e9)))
+			chain = append(chain, newDiffBlock(cur.Number()+1, diff, timestamp.Int64()))
+		}
+	}
+	return solveTimes
+}
+
+func averageTime(solveTimes []float64) float64 {
+	sum := float64(0)
+	for _, t := range solveTimes {
+		sum += t
+	}
+	return sum / float64(len(solveTimes))
+}
+
+func TestLWMA_HashRateSteps(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	expectTime := float64(conf.BlockGenerate)
+	window := int(conf.LWMAWindow)
+	stepBlocks := 600
+
+	// the hash rate rises 10 times then drops to a fifth of the start
+	hashRates := []float64{1e6, 1e7, 2e5}
+	lwma := simulateMining(chain_config.DiffAlgorithmLWMA, hashRates, stepBlocks)
+	period := simulateMining(chain_config.DiffAlgorithmPeriod, hashRates, stepBlocks)
+
+	for step := range hashRates {
+		// the lwma algorithm settles within two windows after the step
+		settled := lwma[step*stepBlocks+2*window : (step+1)*stepBlocks]
+		avg := averageTime(settled)
+		t.Logf("step %d: hash rate %v, lwma average %.2fs, period average %.2fs", step, hashRates[step],
+			avg, averageTime(period[step*stepBlocks+2*window:(step+1)*stepBlocks]))
+		assert.InDelta(t, expectTime, avg, expectTime*0.2)
+	}
+
+	// the period algorithm doesn't react within its period
+	assert.InDelta(t, expectTime/10, averageTime(period[stepBlocks+2*window:2*stepBlocks]), expectTime/10*0.2)
+	assert.InDelta(t, expectTime*5, averageTime(period[2*stepBlocks+2*window:]), expectTime*5*0.2)
+
+	// the lwma algorithm reacts in the first window after the rise
+	assert.True(t, averageTime(lwma[stepBlocks+window:stepBlocks+2*window]) > expectTime/2)
+}
@@ -98,6 +98,7 @@ type AbstractBlock interface {
 	SetVerifications(vs []AbstractVerification)
 	VersIterator(func(int, AbstractVerification, AbstractBlock) error) error
 	GetVerifications() []AbstractVerification
+	GetAggregateCommit() *AggregateCommit
 	SetReceiptHash(receiptHash common.Hash)
 	GetReceiptHash() common.Hash
 	//GetBloomLog() model.Bloom
@@ -132,6 +133,10 @@ type AbstractTransaction interface {
 	PaddingActualTxFee(fee *big.Int)
 	GetReceipt() *model.Receipt
 	GetActualTxFee() (fee *big.Int)
+	HashLock() *common.Hash
+	TimeLock() *big.Int
+	HashKey() []byte
+	MultiSig() *MultiSigWitness
 }
 
 //go:generate mockgen -destination=./../economy-model/verification_mock_test.go -package=economy_model github.com/dipperin/dipperin-core/core/model AbstractVerification
@@ -0,0 +1,296 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"sort"
+)
+
+// MaxMultiSigSigners is the max number of the signers of a multisig account
+const MaxMultiSigSigners = 16
+
+// MultiSigInfo is the signer set and the threshold of a multisig account. The signers
+// are sorted, so that the same set always gets the same multisig address.
+type MultiSigInfo struct {
+	Threshold uint64           `json:"threshold"`
+	Signers   []common.Address `json:"signers"`
+}
+
+// NewMultiSigInfo sorts the signers and checks the threshold
+func NewMultiSigInfo(threshold uint64, signers []common.Address) (*MultiSigInfo, error) {
+	sorted := make([]common.Address, len(signers))
+	copy(sorted, signers)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+
+	info := &MultiSigInfo{Threshold: threshold, Signers: sorted}
+	if err := info.Valid(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// DecodeMultiSigInfo decodes the multisig info in the extra data of the tx creating the multisig account
+func DecodeMultiSigInfo(data []byte) (*MultiSigInfo, error) {
+	var info MultiSigInfo
+	if err := rlp.DecodeBytes(data, &info); err != nil {
+		return nil, err
+	}
+	if err := info.Valid(); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Valid checks the threshold is in [1, len(signers)] and the signers are sorted different normal addresses
+func (info *MultiSigInfo) Valid() error {
+	if len(info.Signers) > MaxMultiSigSigners {
+		return g_error.ErrTooManyMultiSigSigners
+	}
+	if info.Threshold == 0 || info.Threshold > uint64(len(info.Signers)) {
+		return g_error.ErrInvalidMultiSigThreshold
+	}
+	for i, signer := range info.Signers {
+		if signer.IsEmpty() || signer.GetAddressType() != common.AddressTypeNormal {
+			return g_error.ErrInvalidMultiSigSigners
+		}
+		if i > 0 && bytes.Compare(info.Signers[i-1][:], signer[:]) >= 0 {
+			return g_error.ErrInvalidMultiSigSigners
+		}
+	}
+	return nil
+}
+
+// Address returns the multisig address of the signer set and the threshold
+func (info *MultiSigInfo) Address() common.Address {
+	return cs_crypto.GetMultiSigAddress(info.Threshold, info.Signers)
+}
+
+func (info *MultiSigInfo) Equal(other *MultiSigInfo) bool {
+	if info.Threshold != other.Threshold || len(info.Signers) != len(other.Signers) {
+		return false
+	}
+	for i := range info.Signers {
+		if !info.Signers[i].IsEqual(other.Signers[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (info *MultiSigInfo) copy() MultiSigInfo {
+	signers := make([]common.Address, len(info.Signers))
+	copy(signers, info.Signers)
+	return MultiSigInfo{Threshold: info.Threshold, Signers: signers}
+}
+
+func (info *MultiSigInfo) signerIndex(addr common.Address) int {
+	for i := range info.Signers {
+		if info.Signers[i].IsEqual(addr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// MultiSigSignature is the signature of the signer at Index of the signer set,
+// V is in the same format as the V of the single signed tx.
+type MultiSigSignature struct {
+	Index uint64
+	R     *big.Int
+	S     *big.Int
+	V     *big.Int
+}
+
+func (sig MultiSigSignature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&multiSigSignatureJSON{
+		Index: hexutil.Uint64(sig.Index),
+		R:     (*hexutil.Big)(sig.R),
+		S:     (*hexutil.Big)(sig.S),
+		V:     (*hexutil.Big)(sig.V),
+	})
+}
+
+func (sig *MultiSigSignature) UnmarshalJSON(input []byte) error {
+	var dec multiSigSignatureJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.R == nil || dec.S == nil || dec.V == nil {
+		return errors.New("missing required field 'R', 'S' or 'V' for multisig signature")
+	}
+	sig.Index = uint64(dec.Index)
+	sig.R, sig.S, sig.V = (*big.Int)(dec.R), (*big.Int)(dec.S), (*big.Int)(dec.V)
+	return nil
+}
+
+type multiSigSignatureJSON struct {
+	Index hexutil.Uint64 `json:"index"`
+	R     *hexutil.Big   `json:"r"`
+	S     *hexutil.Big   `json:"s"`
+	V     *hexutil.Big   `json:"v"`
+}
+
+// MultiSigWitness is the witness of the tx sent from a multisig account, it carries the
+// signer set of the account and the signatures sorted by the signer index.
+type MultiSigWitness struct {
+	Info MultiSigInfo        `json:"info"`
+	Sigs []MultiSigSignature `json:"sigs"`
+}
+
+// addSignature inserts the signature by the signer index, the previous signature of the signer is replaced
+func (w *MultiSigWitness) addSignature(sig MultiSigSignature) {
+	i := sort.Search(len(w.Sigs), func(i int) bool {
+		return w.Sigs[i].Index >= sig.Index
+	})
+	if i < len(w.Sigs) && w.Sigs[i].Index == sig.Index {
+		w.Sigs[i] = sig
+		return
+	}
+	w.Sigs = append(w.Sigs, MultiSigSignature{})
+	copy(w.Sigs[i+1:], w.Sigs[i:])
+	w.Sigs[i] = sig
+}
+
+func (w *MultiSigWitness) hasSignature(index uint64) bool {
+	for _, sig := range w.Sigs {
+		if sig.Index == index {
+			return true
+		}
+	}
+	return false
+}
+
+// NewMultiSigAccountTransaction transfers amount to the multisig account of info. The account is
+// created with the signer set and the threshold in the extra data if it doesn't exist.
+func NewMultiSigAccountTransaction(nonce uint64, info *MultiSigInfo, amount, gasPrice *big.Int, gasLimit uint64) (*Transaction, error) {
+	if err := info.Valid(); err != nil {
+		return nil, err
+	}
+	data, err := rlp.EncodeToBytes(info)
+	if err != nil {
+		return nil, err
+	}
+	return NewTransaction(nonce, info.Address(), amount, gasPrice, gasLimit, data), nil
+}
+
+// NewMultiSigTransaction creates an unsigned tx sent from the multisig account of info,
+// it's sent after at least threshold signers signed it.
+func NewMultiSigTransaction(nonce uint64, info *MultiSigInfo, to common.Address, amount, gasPrice *big.Int, gasLimit uint64, data []byte) *Transaction {
+	tx := NewTransaction(nonce, to, amount, gasPrice, gasLimit, data)
+	tx.wit.MultiSig = []MultiSigWitness{{Info: info.copy()}}
+	return tx
+}
+
+// MultiSig returns the multisig witness, it's nil if the tx isn't sent from a multisig account
+func (tx *Transaction) MultiSig() *MultiSigWitness {
+	if len(tx.wit.MultiSig) == 0 {
+		return nil
+	}
+	return &tx.wit.MultiSig[0]
+}
+
+// SignMultiSigTx adds the signature of the private key to the multisig tx
+func (tx *Transaction) SignMultiSigTx(priKey *ecdsa.PrivateKey, s Signer) (*Transaction, error) {
+	h, err := s.GetSignHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(h[:], priKey)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.AddMultiSigSignature(sig, s); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// AddMultiSigSignature adds the signature in the [R || S || V] format signed by a signer of the multisig account
+func (tx *Transaction) AddMultiSigSignature(sig []byte, s Signer) error {
+	w := tx.MultiSig()
+	if w == nil {
+		return g_error.ErrNotMultiSigTx
+	}
+	if len(sig) != 65 {
+		return ErrInvalidSig
+	}
+
+	h, err := s.GetSignHash(tx)
+	if err != nil {
+		return err
+	}
+	pub, err := crypto.Ecrecover(h[:], sig)
+	if err != nil {
+		return err
+	}
+	index := w.Info.signerIndex(cs_crypto.GetNormalAddress(*cs_crypto.ToECDSAPub(pub)))
+	if index < 0 {
+		return g_error.ErrNotMultiSigSigner
+	}
+
+	r, sv, v, err := s.SignatureValues(tx, sig)
+	if err != nil {
+		return err
+	}
+	w.addSignature(MultiSigSignature{Index: uint64(index), R: r, S: sv, V: v})
+	// the chain id of the multisig tx is derived from V too
+	tx.wit.R, tx.wit.S, tx.wit.V = new(big.Int), new(big.Int), new(big.Int).Set(v)
+	return nil
+}
+
+// CombineMultiSig adds the signatures of other to tx, they should be the same tx signed by different signers
+func (tx *Transaction) CombineMultiSig(other *Transaction) error {
+	w, ow := tx.MultiSig(), other.MultiSig()
+	if w == nil || ow == nil {
+		return g_error.ErrNotMultiSigTx
+	}
+
+	hash, err := rlpHash(tx.data)
+	if err != nil {
+		return err
+	}
+	otherHash, err := rlpHash(other.data)
+	if err != nil {
+		return err
+	}
+	if !hash.IsEqual(otherHash) || !w.Info.Equal(&ow.Info) {
+		return g_error.ErrMultiSigTxNotMatch
+	}
+
+	for _, sig := range ow.Sigs {
+		if !w.hasSignature(sig.Index) {
+			w.addSignature(sig)
+		}
+	}
+	if tx.wit.V.Sign() == 0 {
+		tx.wit.V = new(big.Int).Set(other.wit.V)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"encoding/json"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/tests/g-testData"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func createMultiSigTestInfo(t *testing.T) (*MultiSigInfo, []common.Address) {
+	key3, err := crypto.HexToECDSA("289c2857d4598e37fb9647507e47a309d6133539bf21a8b9cb6df88fd5232033")
+	assert.NoError(t, err)
+	charlieAddr := cs_crypto.GetNormalAddress(key3.PublicKey)
+
+	info, err := NewMultiSigInfo(2, []common.Address{bobAddr, charlieAddr, aliceAddr})
+	assert.NoError(t, err)
+	return info, []common.Address{aliceAddr, bobAddr, charlieAddr}
+}
+
+func TestNewMultiSigInfo(t *testing.T) {
+	info, signers := createMultiSigTestInfo(t)
+	assert.Len(t, info.Signers, 3)
+	assert.Equal(t, common.TxType(common.AddressTypeMultiSig), info.Address().GetAddressType())
+
+	// the order of the signers doesn't change the address
+	other, err := NewMultiSigInfo(2, signers)
+	assert.NoError(t, err)
+	assert.True(t, info.Equal(other))
+	assert.Equal(t, info.Address(), other.Address())
+
+	// the threshold changes the address
+	other, err = NewMultiSigInfo(3, signers)
+	assert.NoError(t, err)
+	assert.False(t, info.Equal(other))
+	assert.NotEqual(t, info.Address(), other.Address())
+
+	_, err = NewMultiSigInfo(0, signers)
+	assert.Equal(t, g_error.ErrInvalidMultiSigThreshold, err)
+	_, err = NewMultiSigInfo(4, signers)
+	assert.Equal(t, g_error.ErrInvalidMultiSigThreshold, err)
+	_, err = NewMultiSigInfo(1, []common.Address{aliceAddr, aliceAddr})
+	assert.Equal(t, g_error.ErrInvalidMultiSigSigners, err)
+	_, err = NewMultiSigInfo(1, []common.Address{aliceAddr, info.Address()})
+	assert.Equal(t, g_error.ErrInvalidMultiSigSigners, err)
+	_, err = NewMultiSigInfo(1, make([]common.Address, MaxMultiSigSigners+1))
+	assert.Equal(t, g_error.ErrTooManyMultiSigSigners, err)
+
+	// unsorted signers from the extra data are invalid
+	data, err := rlp.EncodeToBytes(&MultiSigInfo{Threshold: 1, Signers: []common.Address{info.Signers[1], info.Signers[0]}})
+	assert.NoError(t, err)
+	_, err = DecodeMultiSigInfo(data)
+	assert.Equal(t, g_error.ErrInvalidMultiSigSigners, err)
+
+	data, err = rlp.EncodeToBytes(info)
+	assert.NoError(t, err)
+	decoded, err := DecodeMultiSigInfo(data)
+	assert.NoError(t, err)
+	assert.True(t, info.Equal(decoded))
+}
+
+func TestNewMultiSigAccountTransaction(t *testing.T) {
+	info, _ := createMultiSigTestInfo(t)
+	tx, err := NewMultiSigAccountTransaction(1, info, big.NewInt(100), big.NewInt(1), g_testData.TestGasLimit)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Address(), *tx.To())
+	assert.Equal(t, common.TxType(common.AddressTypeMultiSig), tx.GetType())
+	assert.Nil(t, tx.MultiSig())
+
+	decoded, err := DecodeMultiSigInfo(tx.ExtraData())
+	assert.NoError(t, err)
+	assert.True(t, info.Equal(decoded))
+
+	_, err = NewMultiSigAccountTransaction(1, &MultiSigInfo{}, big.NewInt(100), big.NewInt(1), g_testData.TestGasLimit)
+	assert.Equal(t, g_error.ErrInvalidMultiSigThreshold, err)
+}
+
+func TestTransaction_SignMultiSigTx(t *testing.T) {
+	info, _ := createMultiSigTestInfo(t)
+	aliceKey, bobKey := CreateKey()
+	signer := NewSigner(big.NewInt(1))
+
+	tx := NewMultiSigTransaction(0, info, bobAddr, big.NewInt(10), big.NewInt(1), g_testData.TestGasLimit, nil)
+	assert.NotNil(t, tx.MultiSig())
+
+	// the multisig address is signed
+	multiHash, err := signer.GetSignHash(tx)
+	assert.NoError(t, err)
+	singleHash, err := rlpHash([]interface{}{tx.data, big.NewInt(1)})
+	assert.NoError(t, err)
+	assert.NotEqual(t, singleHash, multiHash)
+
+	_, err = tx.SignMultiSigTx(aliceKey, signer)
+	assert.NoError(t, err)
+	_, err = signer.GetSender(tx)
+	assert.Equal(t, g_error.ErrMultiSigNotEnoughSigs, err)
+
+	// signing twice keeps one signature
+	_, err = tx.SignMultiSigTx(aliceKey, signer)
+	assert.NoError(t, err)
+	assert.Len(t, tx.MultiSig().Sigs, 1)
+
+	_, err = tx.SignMultiSigTx(bobKey, signer)
+	assert.NoError(t, err)
+	sender, err := tx.Sender(signer)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Address(), sender)
+	assert.Equal(t, big.NewInt(1), tx.ChainId())
+
+	_, err = tx.SenderPublicKey(signer)
+	assert.Equal(t, g_error.ErrMultiSigNoPublicKey, err)
+
+	otherKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	_, err = tx.SignMultiSigTx(otherKey, signer)
+	assert.Equal(t, g_error.ErrNotMultiSigSigner, err)
+
+	normalTx := NewTransaction(0, bobAddr, big.NewInt(10), big.NewInt(1), g_testData.TestGasLimit, nil)
+	_, err = normalTx.SignMultiSigTx(aliceKey, signer)
+	assert.Equal(t, g_error.ErrNotMultiSigTx, err)
+}
+
+func TestDipperinSigner_getMultiSigSender(t *testing.T) {
+	info, _ := createMultiSigTestInfo(t)
+	aliceKey, bobKey := CreateKey()
+	signer := NewSigner(big.NewInt(1))
+
+	newSignedTx := func() *Transaction {
+		tx := NewMultiSigTransaction(0, info, bobAddr, big.NewInt(10), big.NewInt(1), g_testData.TestGasLimit, nil)
+		_, err := tx.SignMultiSigTx(aliceKey, signer)
+		assert.NoError(t, err)
+		_, err = tx.SignMultiSigTx(bobKey, signer)
+		assert.NoError(t, err)
+		return tx
+	}
+
+	// the signatures are swapped between the signers
+	tx := newSignedTx()
+	sigs := tx.MultiSig().Sigs
+	sigs[0].Index, sigs[1].Index = sigs[1].Index, sigs[0].Index
+	sigs[0], sigs[1] = sigs[1], sigs[0]
+	_, err := signer.GetSender(tx)
+	assert.Equal(t, g_error.ErrNotMultiSigSigner, err)
+
+	// the same signature is used twice
+	tx = newSignedTx()
+	tx.MultiSig().Sigs[1] = tx.MultiSig().Sigs[0]
+	_, err = signer.GetSender(tx)
+	assert.Equal(t, g_error.ErrInvalidMultiSigWitness, err)
+
+	// the signatures can't be used by another multisig account with the same signers
+	tx = newSignedTx()
+	tx.MultiSig().Info.Threshold = 1
+	_, err = signer.GetSender(tx)
+	assert.Equal(t, g_error.ErrNotMultiSigSigner, err)
+
+	// the signatures are bound to the tx data
+	tx = newSignedTx()
+	tx.data.Amount = big.NewInt(11)
+	_, err = signer.GetSender(tx)
+	assert.Equal(t, g_error.ErrNotMultiSigSigner, err)
+
+	tx = newSignedTx()
+	tx.wit.MultiSig = append(tx.wit.MultiSig, tx.wit.MultiSig[0])
+	_, err = signer.GetSender(tx)
+	assert.Equal(t, g_error.ErrInvalidMultiSigWitness, err)
+}
+
+func TestTransaction_CombineMultiSig(t *testing.T) {
+	info, _ := createMultiSigTestInfo(t)
+	aliceKey, bobKey := CreateKey()
+	signer := NewSigner(big.NewInt(1))
+
+	unsigned := NewMultiSigTransaction(0, info, bobAddr, big.NewInt(10), big.NewInt(1), g_testData.TestGasLimit, nil)
+	enc, err := rlp.EncodeToBytes(unsigned)
+	assert.NoError(t, err)
+
+	// the signers sign their own copies of the unsigned tx
+	var aliceTx, bobTx, emptyTx Transaction
+	assert.NoError(t, rlp.DecodeBytes(enc, &aliceTx))
+	assert.NoError(t, rlp.DecodeBytes(enc, &bobTx))
+	assert.NoError(t, rlp.DecodeBytes(enc, &emptyTx))
+	_, err = aliceTx.SignMultiSigTx(aliceKey, signer)
+	assert.NoError(t, err)
+	_, err = bobTx.SignMultiSigTx(bobKey, signer)
+	assert.NoError(t, err)
+
+	assert.NoError(t, emptyTx.CombineMultiSig(&bobTx))
+	assert.NoError(t, emptyTx.CombineMultiSig(&aliceTx))
+	assert.NoError(t, emptyTx.CombineMultiSig(&aliceTx))
+	assert.Len(t, emptyTx.MultiSig().Sigs, 2)
+	assert.Equal(t, uint64(0), emptyTx.MultiSig().Sigs[0].Index)
+
+	sender, err := emptyTx.Sender(signer)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Address(), sender)
+
+	otherTx := NewMultiSigTransaction(1, info, bobAddr, big.NewInt(10), big.NewInt(1), g_testData.TestGasLimit, nil)
+	assert.Equal(t, g_error.ErrMultiSigTxNotMatch, otherTx.CombineMultiSig(&aliceTx))
+	normalTx := NewTransaction(0, bobAddr, big.NewInt(10), big.NewInt(1), g_testData.TestGasLimit, nil)
+	assert.Equal(t, g_error.ErrNotMultiSigTx, normalTx.CombineMultiSig(&aliceTx))
+}
+
+func TestMultiSigTransaction_Encode(t *testing.T) {
+	info, _ := createMultiSigTestInfo(t)
+	aliceKey, bobKey := CreateKey()
+	signer := NewSigner(big.NewInt(1))
+
+	tx := NewMultiSigTransaction(0, info, bobAddr, big.NewInt(10), big.NewInt(1), g_testData.TestGasLimit, nil)
+	_, err := tx.SignMultiSigTx(aliceKey, signer)
+	assert.NoError(t, err)
+	_, err = tx.SignMultiSigTx(bobKey, signer)
+	assert.NoError(t, err)
+
+	enc, err := rlp.EncodeToBytes(tx)
+	assert.NoError(t, err)
+	var rlpTx Transaction
+	assert.NoError(t, rlp.DecodeBytes(enc, &rlpTx))
+	sender, err := rlpTx.Sender(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Address(), sender)
+	assert.Equal(t, tx.CalTxId(), rlpTx.CalTxId())
+
+	jsonB, err := json.Marshal(tx)
+	assert.NoError(t, err)
+	var jsonTx Transaction
+	assert.NoError(t, json.Unmarshal(jsonB, &jsonTx))
+	sender, err = jsonTx.Sender(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Address(), sender)
+
+	// the encoding of the single signed tx isn't changed by the multisig witness
+	single := CreateSignedTx(0, big.NewInt(10))
+	enc, err = rlp.EncodeToBytes(single)
+	assert.NoError(t, err)
+	oldEnc, err := rlp.EncodeToBytes([]interface{}{single.data, []interface{}{single.wit.R, single.wit.S, single.wit.V, single.wit.HashKey}})
+	assert.NoError(t, err)
+	assert.Equal(t, oldEnc, enc)
+
+	// the replacement keeps the signer set without the signatures
+	replaced := tx.WithGasPrice(big.NewInt(2))
+	assert.True(t, info.Equal(&replaced.MultiSig().Info))
+	assert.Len(t, replaced.MultiSig().Sigs, 0)
+}
@@ -18,6 +18,7 @@ package model
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
@@ -31,6 +32,32 @@ type Proofs struct {
 	Priority uint64
 }
 
+// ValidConflictVotes checks that the two votes are signed by target for the same height, round and vote type but different blocks
+func ValidConflictVotes(target common.Address, voteA *VoteMsg, voteB *VoteMsg) error {
+	if voteA == nil || voteB == nil || voteA.Witness == nil || voteB.Witness == nil {
+		return g_error.ErrEvidenceVoteNotConflict
+	}
+
+	// valid vote
+	if err := voteA.Valid(); err != nil {
+		return err
+	}
+	if err := voteB.Valid(); err != nil {
+		return err
+	}
+
+	// Two vote conflict check
+	if voteA.GetType() != voteB.GetType() || voteA.GetHeight() != voteB.GetHeight() || voteA.GetRound() != voteB.GetRound() || voteA.GetBlockId().IsEqual(voteB.GetBlockId()) || !voteA.GetAddress().IsEqual(voteB.GetAddress()) {
+		return g_error.ErrEvidenceVoteNotConflict
+	}
+
+	// Test target match voter
+	if !voteA.GetAddress().IsEqual(target) {
+		return g_error.ErrTxTargetAddressNotMatch
+	}
+	return nil
+}
+
 /*
 Name
 CalledBy
@@ -18,6 +18,7 @@ package model
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/stretchr/testify/assert"
 	"math/big"
@@ -53,3 +54,25 @@ func TestNewUnNormalTransaction(t *testing.T) {
 	trans := NewUnNormalTransaction(3, big.NewInt(5), g_testData.TestGasPrice, g_testData.TestGasLimit)
 	assert.EqualValues(t, trans.GetType(), common.TxType(9))
 }
+
+func TestValidConflictVotes(t *testing.T) {
+	voteA := CreateSignedVote(1, 2, common.HexToHash("0x123456"), VoteMessage)
+	voteB := CreateSignedVote(1, 2, common.HexToHash("0x654321"), VoteMessage)
+	assert.NoError(t, ValidConflictVotes(aliceAddr, voteA, voteB))
+	assert.Equal(t, g_error.ErrTxTargetAddressNotMatch, ValidConflictVotes(bobAddr, voteA, voteB))
+	assert.Equal(t, g_error.ErrEvidenceVoteNotConflict, ValidConflictVotes(aliceAddr, voteA, nil))
+	assert.Equal(t, g_error.ErrEvidenceVoteNotConflict, ValidConflictVotes(aliceAddr, voteA, voteA))
+
+	voteB = CreateSignedVote(1, 3, common.HexToHash("0x654321"), VoteMessage)
+	assert.Equal(t, g_error.ErrEvidenceVoteNotConflict, ValidConflictVotes(aliceAddr, voteA, voteB))
+
+	voteB = CreateSignedVote(2, 2, common.HexToHash("0x654321"), VoteMessage)
+	assert.Equal(t, g_error.ErrEvidenceVoteNotConflict, ValidConflictVotes(aliceAddr, voteA, voteB))
+
+	voteB = CreateSignedVote(1, 2, common.HexToHash("0x654321"), PreVoteMessage)
+	assert.Equal(t, g_error.ErrEvidenceVoteNotConflict, ValidConflictVotes(aliceAddr, voteA, voteB))
+
+	voteB = CreateSignedVote(1, 2, common.HexToHash("0x654321"), VoteMessage)
+	voteB.Witness.Address = bobAddr
+	assert.Error(t, ValidConflictVotes(aliceAddr, voteA, voteB))
+}
@@ -64,7 +64,7 @@ func CreateSignedTxList(n int) []*Transaction {
 	var res []*Transaction
 	for i := 0; i < n; i++ {
 		tempTx := NewTransaction(uint64(i), bobAddr, big.NewInt(1000), g_testData.TestGasPrice, g_testData.TestGasLimit, []byte{})
-		gasUsed, _ := IntrinsicGas(tempTx.ExtraData(), false, false)
+		gasUsed, _ := IntrinsicGas(tempTx.ExtraData(), false, false, false)
 		tempTx.PaddingActualTxFee(big.NewInt(0).Mul(big.NewInt(int64(gasUsed)), g_testData.TestGasPrice))
 		tempTx.SignTx(keyAlice, ms)
 		res = append(res, tempTx)
@@ -56,7 +56,8 @@ type RpcTransaction struct {
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error) {
+// The non-zero bytes of the data are repriced after the Earth fork.
+func IntrinsicGas(data []byte, contractCreation, homestead, earth bool) (uint64, error) {
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if contractCreation && homestead {
@@ -73,11 +74,15 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error)
 				nz++
 			}
 		}
+		nonZeroGas := model.TxDataNonZeroGas
+		if earth {
+			nonZeroGas = model.TxDataNonZeroGasEarth
+		}
 		// Make sure we don't exceed uint64 for all data combinations
-		if (math.MaxUint64-gas)/model.TxDataNonZeroGas < nz {
+		if (math.MaxUint64-gas)/nonZeroGas < nz {
 			return 0, g_error.ErrOutOfGas
 		}
-		gas += nz * model.TxDataNonZeroGas
+		gas += nz * nonZeroGas
 
 		z := uint64(len(data)) - nz
 		if (math.MaxUint64-gas)/model.TxDataZeroGas < z {
@@ -88,6 +93,16 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error)
 	return gas, nil
 }
 
+// MultiSigRecoverGas returns the gas of recovering the signers of the multisig witness,
+// it's added to the intrinsic gas after the Eris fork.
+func MultiSigRecoverGas(tx AbstractTransaction) uint64 {
+	w := tx.MultiSig()
+	if w == nil {
+		return 0
+	}
+	return uint64(len(w.Sigs)) * model.TxMultiSigRecoverGas
+}
+
 func NewTransaction(nonce uint64, to common.Address, amount, gasPrice *big.Int, gasLimit uint64, data []byte) *Transaction {
 	return newTransaction(nonce, &to, amount, gasPrice, gasLimit, data)
 }
@@ -233,6 +248,8 @@ type witness struct {
 	V *big.Int `json:"v" gencodec:"required"`
 	// hash_key
 	HashKey []byte `json:"hashKey"    gencodec:"required"`
+	// the signer set and the signatures of the multisig sender, it's empty for the single signed tx
+	MultiSig []MultiSigWitness `json:"multisig" rlp:"tail"`
 }
 
 type TransactionRLP struct {
@@ -263,6 +280,25 @@ func (tx *Transaction) GetGasLimit() uint64 {
 	return tx.data.GasLimit
 }
 
+// WithGasPrice returns an unsigned copy of the transaction with the new gas price,
+// it's used to replace the transaction in the tx pool.
+func (tx *Transaction) WithGasPrice(gasPrice *big.Int) *Transaction {
+	cpy := &Transaction{
+		data: tx.data,
+		wit: witness{
+			R:       new(big.Int),
+			S:       new(big.Int),
+			V:       new(big.Int),
+			HashKey: tx.wit.HashKey,
+		},
+	}
+	if w := tx.MultiSig(); w != nil {
+		cpy.wit.MultiSig = []MultiSigWitness{{Info: w.Info.copy()}}
+	}
+	cpy.data.Price = new(big.Int).Set(gasPrice)
+	return cpy
+}
+
 //DecodeRLP implements rlp.Decoder
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 	var dtx TransactionRLP
@@ -413,6 +449,7 @@ func (tx *Transaction) AsMessage(checkNonce bool) (Message, error) {
 		amount:     tx.data.Amount,
 		data:       tx.data.ExtraData,
 		checkNonce: checkNonce,
+		recoverGas: MultiSigRecoverGas(tx),
 	}
 
 	var err error
@@ -425,6 +462,9 @@ type ReceiptPara struct {
 	HandlerResult     bool
 	CumulativeGasUsed uint64
 	Logs              []*model.Log
+	// VmErr is the vm error of the failed contract transaction, Ret is the data returned by the contract
+	VmErr error
+	Ret   []byte
 }
 
 func (tx *Transaction) PaddingActualTxFee(fee *big.Int) {
@@ -442,6 +482,7 @@ func (tx *Transaction) GetActualTxFee() (fee *big.Int) {
 func (tx *Transaction) PaddingReceipt(parameters ReceiptPara) {
 	log.Info("Call PaddingReceipt", "handlerResult", parameters.HandlerResult)
 	receipt := model.NewReceipt(parameters.Root, parameters.HandlerResult, parameters.CumulativeGasUsed, parameters.Logs)
+	receipt.SetFailure(parameters.VmErr, parameters.Ret)
 	tx.receipt.Store(receipt)
 }
 
@@ -614,6 +655,7 @@ type Message struct {
 	gasPrice   *big.Int
 	data       []byte
 	checkNonce bool
+	recoverGas uint64
 }
 
 /*func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *big.Int, gasLimit uint64, gasPrice *big.Int, data []byte, checkNonce bool) Message {
@@ -637,6 +679,7 @@ func (m Message) Gas() uint64          { return m.gasLimit }
 func (m Message) Nonce() uint64        { return m.nonce }
 func (m Message) Data() []byte         { return m.data }
 func (m Message) CheckNonce() bool     { return m.checkNonce }
+func (m Message) RecoverGas() uint64   { return m.recoverGas }
 func (m *Message) SetGas(gas uint64) {
 	m.gasLimit = gas
 }
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
@@ -127,6 +128,21 @@ func TestTransaction(t *testing.T) {
 	assert.Nil(t, tx.To())
 }
 
+func TestTransaction_WithGasPrice(t *testing.T) {
+	tx := CreateSignedTx(0, txAmount)
+	gasPrice := new(big.Int).Mul(tx.GetGasPrice(), big.NewInt(2))
+	replacement := tx.WithGasPrice(gasPrice)
+	assert.Equal(t, gasPrice, replacement.GetGasPrice())
+	assert.Equal(t, tx.Nonce(), replacement.Nonce())
+	assert.Equal(t, tx.To(), replacement.To())
+	assert.Equal(t, tx.Amount(), replacement.Amount())
+	assert.NotEqual(t, tx.GetGasPrice(), replacement.GetGasPrice())
+
+	// the copy is unsigned
+	_, err := replacement.Sender(NewSigner(big.NewInt(1)))
+	assert.Error(t, err)
+}
+
 func TestTransactionBy_Sort(t *testing.T) {
 	block1 := CreateBlock(0, common.HexToHash("123"), 0)
 	block2 := CreateBlock(1, common.HexToHash("123"), 0)
@@ -281,11 +297,40 @@ func TestTxDifference(t *testing.T) {
 }
 
 func TestIntrinsicGas(t *testing.T) {
-	_, err := IntrinsicGas([]byte{1, 2, 3}, true, true)
+	_, err := IntrinsicGas([]byte{1, 2, 3}, true, true, false)
+	assert.NoError(t, err)
+
+	_, err = IntrinsicGas([]byte{1, 2, 3}, false, true, false)
+	assert.NoError(t, err)
+
+	// the non-zero bytes are repriced after the Earth fork
+	gas, err := IntrinsicGas([]byte{0, 1, 2}, false, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TxGas+model.TxDataZeroGas+2*model.TxDataNonZeroGas, gas)
+	gas, err = IntrinsicGas([]byte{0, 1, 2}, false, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TxGas+model.TxDataZeroGas+2*model.TxDataNonZeroGasEarth, gas)
+}
+
+func TestMultiSigRecoverGas(t *testing.T) {
+	tx1, _ := createTestTx()
+	assert.Equal(t, uint64(0), MultiSigRecoverGas(tx1))
+
+	info, _ := createMultiSigTestInfo(t)
+	aliceKey, bobKey := CreateKey()
+	signer := NewSigner(big.NewInt(1))
+	tx := NewMultiSigTransaction(0, info, bobAddr, big.NewInt(10), big.NewInt(1), g_testData.TestGasLimit, nil)
+	assert.Equal(t, uint64(0), MultiSigRecoverGas(tx))
+
+	_, err := tx.SignMultiSigTx(aliceKey, signer)
+	assert.NoError(t, err)
+	_, err = tx.SignMultiSigTx(bobKey, signer)
 	assert.NoError(t, err)
+	assert.Equal(t, 2*model.TxMultiSigRecoverGas, MultiSigRecoverGas(tx))
 
-	_, err = IntrinsicGas([]byte{1, 2, 3}, false, true)
+	msg, err := tx.AsMessage(true)
 	assert.NoError(t, err)
+	assert.Equal(t, 2*model.TxMultiSigRecoverGas, msg.RecoverGas())
 }
 
 func TestTransaction_AsMessage(t *testing.T) {
@@ -337,6 +382,14 @@ func TestTransaction_GetReceipt(t *testing.T) {
 	assert.Equal(t, 0, len(receipt.Logs))
 	assert.Equal(t, []byte(nil), receipt.PostState)
 	assert.Equal(t, model.ReceiptStatusSuccessful, receipt.Status)
+	assert.Equal(t, "", receipt.Error)
+
+	receiptPara = ReceiptPara{HandlerResult: true, VmErr: g_error.ErrExecutionReverted, Ret: []byte("reason")}
+	tx1.PaddingReceipt(receiptPara)
+	receipt = tx1.GetReceipt()
+	assert.Equal(t, model.ReceiptStatusFailed, receipt.Status)
+	assert.Equal(t, g_error.ErrExecutionReverted.Error(), receipt.Error)
+	assert.Equal(t, "reason", receipt.RevertReason)
 }
 
 func TestTransaction_PaddingActualTxFee(t *testing.T) {
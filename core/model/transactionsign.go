@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
@@ -106,6 +107,10 @@ func (ds DipperinSigner) Equal(s2 Signer) bool {
 func (ds DipperinSigner) GetSignHash(rtx *Transaction) (common.Hash, error) {
 	//log.Debug("DipperinSigner GetSignHash","tx",rtx.data)
 	//log.Debug("DipperinSigner GetSignHash","chainId",fs.chainId)
+	// the multisig address is signed too, so the signatures can't be used by the other multisig accounts of the signer
+	if w := rtx.MultiSig(); w != nil {
+		return rlpHash([]interface{}{rtx.data, ds.chainId, w.Info.Address()})
+	}
 	res, err := rlpHash([]interface{}{rtx.data, ds.chainId})
 	return res, err
 }
@@ -134,19 +139,63 @@ func (ds DipperinSigner) GetSender(tx *Transaction) (common.Address, error) {
 		return common.Address{}, err
 	}
 
+	if len(tx.wit.MultiSig) != 0 {
+		return ds.getMultiSigSender(tx, hash)
+	}
+
 	//log.Health.Info("GetSender the tx wit r s v is:","r",tx.wit.R,"s",tx.wit.S,"v",tx.wit.V)
 	//log.Health.Info("GetSender the ds chainId is:","chainId",ds.chainId)
-	temp := big.NewInt(0).Sub(tx.wit.V, big.NewInt(0).Mul(ds.chainId, big.NewInt(2)))
+	return ds.recoverSender(hash, tx.wit.R, tx.wit.S, tx.wit.V)
+}
+
+func (ds DipperinSigner) recoverSender(hash common.Hash, R, S, V *big.Int) (common.Address, error) {
+	temp := big.NewInt(0).Sub(V, big.NewInt(0).Mul(ds.chainId, big.NewInt(2)))
 	v := big.NewInt(0).Sub(temp, big.NewInt(54))
 	//log.Health.Info("the calculated v is:","v",v)
 
-	return recoverNormalSender(hash, tx.wit.R, tx.wit.S, v)
+	return recoverNormalSender(hash, R, S, v)
+}
+
+// getMultiSigSender returns the multisig address if at least threshold signers of the account signed the tx
+func (ds DipperinSigner) getMultiSigSender(tx *Transaction, hash common.Hash) (common.Address, error) {
+	if len(tx.wit.MultiSig) != 1 {
+		return common.Address{}, g_error.ErrInvalidMultiSigWitness
+	}
+	w := tx.wit.MultiSig[0]
+	if err := w.Info.Valid(); err != nil {
+		return common.Address{}, err
+	}
+
+	for i, sig := range w.Sigs {
+		// the signatures are sorted by the signer index, one signer can only sign once
+		if sig.Index >= uint64(len(w.Info.Signers)) || (i > 0 && sig.Index <= w.Sigs[i-1].Index) {
+			return common.Address{}, g_error.ErrInvalidMultiSigWitness
+		}
+		if sig.R == nil || sig.S == nil || sig.V == nil {
+			return common.Address{}, ErrInvalidSig
+		}
+		signer, err := ds.recoverSender(hash, sig.R, sig.S, sig.V)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if !signer.IsEqual(w.Info.Signers[sig.Index]) {
+			return common.Address{}, g_error.ErrNotMultiSigSigner
+		}
+	}
+
+	if uint64(len(w.Sigs)) < w.Info.Threshold {
+		return common.Address{}, g_error.ErrMultiSigNotEnoughSigs
+	}
+	return w.Info.Address(), nil
 }
 
 func (ds DipperinSigner) GetSenderPublicKey(tx *Transaction) (*ecdsa.PublicKey, error) {
 	//different type use different address type
 
 	emptyPk := ecdsa.PublicKey{}
+	if len(tx.wit.MultiSig) != 0 {
+		return &emptyPk, g_error.ErrMultiSigNoPublicKey
+	}
 	sigHash, err := ds.GetSignHash(tx)
 	if err != nil {
 		return &emptyPk, err
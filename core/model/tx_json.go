@@ -43,6 +43,10 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 	err := json.Unmarshal(input, &tJson)
 	tx.data = tJson.TxData
 	tx.wit = tJson.Wit
+	// the signatures of the multisig tx are checked when getting the sender
+	if len(tx.wit.MultiSig) != 0 {
+		return err
+	}
 	id := deriveChainId(tx.wit.V)
 	temp := big.NewInt(0).Sub(tx.wit.V, big.NewInt(0).Mul(id, big.NewInt(2)))
 	v := big.NewInt(0).Sub(temp, big.NewInt(54))
@@ -138,12 +142,17 @@ func (t witness) MarshalJSON() ([]byte, error) {
 		S       *hexutil.Big  `json:"s" gencodec:"required"`
 		V       *hexutil.Big  `json:"v" gencodec:"required"`
 		HashKey hexutil.Bytes `json:"hashkey"    gencodec:"required"`
+		// multisig
+		MultiSig *MultiSigWitness `json:"multisig,omitempty"`
 	}
 	var enc wit
 	enc.R = (*hexutil.Big)(t.R)
 	enc.S = (*hexutil.Big)(t.S)
 	enc.V = (*hexutil.Big)(t.V)
 	enc.HashKey = t.HashKey
+	if len(t.MultiSig) != 0 {
+		enc.MultiSig = &t.MultiSig[0]
+	}
 	return json.Marshal(&enc)
 }
 
@@ -154,6 +163,8 @@ func (t *witness) UnmarshalJSON(input []byte) error {
 		V *hexutil.Big `json:"v" gencodec:"required"`
 		// hash_key
 		HashKey *hexutil.Bytes `json:"hashkey"    gencodec:"required"`
+		// multisig
+		MultiSig *MultiSigWitness `json:"multisig,omitempty"`
 	}
 	var dec wit
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -162,6 +173,9 @@ func (t *witness) UnmarshalJSON(input []byte) error {
 	if dec.HashKey != nil {
 		t.HashKey = *dec.HashKey
 	}
+	if dec.MultiSig != nil {
+		t.MultiSig = []MultiSigWitness{*dec.MultiSig}
+	}
 	if dec.R == nil {
 		return errors.New("missing required field 'R' for witness")
 	}
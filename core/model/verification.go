@@ -24,6 +24,8 @@ import (
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"io"
 	"time"
 )
 
@@ -49,6 +51,43 @@ type SignHashFunc func(hash []byte) ([]byte, error)
 type WitMsg struct {
 	Address common.Address `json:"address"`
 	Sign    []byte         `json:"sign"`
+	// BlsSign is the bls signature of the commit vote after the Pluto fork, it's aggregated into the next block
+	BlsSign []byte `json:"bls_sign,omitempty"`
+}
+
+// witMsgRlp encodes a witness without the bls signature the same as before the Pluto fork
+type witMsgRlp struct {
+	Address common.Address
+	Sign    []byte
+	BlsSign [][]byte `rlp:"tail"`
+}
+
+func (witMsg *WitMsg) EncodeRLP(w io.Writer) error {
+	// the vote hash is calculated without the witness
+	if witMsg == nil {
+		_, err := w.Write(rlp.EmptyList)
+		return err
+	}
+	enc := witMsgRlp{Address: witMsg.Address, Sign: witMsg.Sign}
+	if len(witMsg.BlsSign) > 0 {
+		enc.BlsSign = [][]byte{witMsg.BlsSign}
+	}
+	return rlp.Encode(w, &enc)
+}
+
+func (witMsg *WitMsg) DecodeRLP(s *rlp.Stream) error {
+	var dec witMsgRlp
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	if len(dec.BlsSign) > 1 {
+		return errors.New("too many bls signatures in witness")
+	}
+	witMsg.Address, witMsg.Sign, witMsg.BlsSign = dec.Address, dec.Sign, nil
+	if len(dec.BlsSign) == 1 {
+		witMsg.BlsSign = dec.BlsSign[0]
+	}
+	return nil
 }
 
 func (witMsg *WitMsg) Valid(dataHash []byte) error {
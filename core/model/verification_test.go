@@ -21,6 +21,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/third-party/crypto/secp256k1"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -34,7 +35,7 @@ func TestWitMsg_Valid(t *testing.T) {
 	sign, err := crypto.Sign(common.HexToHash("123").Bytes(), key1)
 	assert.NoError(t, err)
 
-	msg := WitMsg{aliceAddr, sign}
+	msg := WitMsg{Address: aliceAddr, Sign: sign}
 
 	err = msg.Valid(common.HexToHash("123").Bytes())
 	assert.NoError(t, err)
@@ -42,11 +43,31 @@ func TestWitMsg_Valid(t *testing.T) {
 	err = msg.Valid(common.HexToHash("456").Bytes())
 	assert.Equal(t, "signature not valid", err.Error())
 
-	msg = WitMsg{aliceAddr, []byte{123}}
+	msg = WitMsg{Address: aliceAddr, Sign: []byte{123}}
 	err = msg.Valid(common.HexToHash("123").Bytes())
 	assert.Equal(t, secp256k1.ErrInvalidSignatureLen, err)
 }
 
+func TestWitMsg_RLP(t *testing.T) {
+	// the msgs without bls sign are encoded as before
+	msg := &WitMsg{Address: aliceAddr, Sign: []byte{1, 2}}
+	data, err := rlp.EncodeToBytes(msg)
+	assert.NoError(t, err)
+	old, err := rlp.EncodeToBytes([]interface{}{aliceAddr, []byte{1, 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, old, data)
+
+	var decoded WitMsg
+	assert.NoError(t, rlp.DecodeBytes(data, &decoded))
+	assert.Equal(t, *msg, decoded)
+
+	msg.BlsSign = []byte{3, 4}
+	data, err = rlp.EncodeToBytes(msg)
+	assert.NoError(t, err)
+	assert.NoError(t, rlp.DecodeBytes(data, &decoded))
+	assert.Equal(t, *msg, decoded)
+}
+
 func TestNewVoteMsg(t *testing.T) {
 	voteMsg := NewVoteMsg(10, 1, common.HexToHash("100"), 1)
 	assert.NotNil(t, voteMsg)
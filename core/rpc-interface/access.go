@@ -0,0 +1,495 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rpc_interface
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dipperin/dipperin-core/common/g-metrics"
+	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/rpc"
+	"io/ioutil"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the access levels of the namespaces and methods
+const (
+	// anyone can call it
+	AccessPublic = "public"
+	// the caller must present an api key or a JWT
+	AccessAuth = "auth"
+	// the caller must present an api key
+	AccessApiKey = "api_key"
+	// the caller must present a JWT
+	AccessJWT = "jwt"
+	// nobody can call it on the http and websocket endpoints
+	AccessDeny = "deny"
+)
+
+// the rule matching all the namespaces and methods which aren't configured
+const accessRuleDefault = "*"
+
+// the JSON-RPC error codes of the rejected calls
+const (
+	errCodeUnauthorized  = -32001
+	errCodeForbidden     = -32003
+	errCodeLimitExceeded = -32005
+)
+
+// the least length of the JWT secret
+const jwtSecretMinLen = 32
+
+// the idle rate limit buckets are dropped at this interval
+const rateBucketsPruneInterval = time.Minute
+
+var (
+	errAccessUnknownLevel  = errors.New("unknown rpc access level")
+	errAccessRateLimit     = errors.New("the rpc rate limit needs a positive burst")
+	errAccessEmptyApiKey   = errors.New("empty rpc api key")
+	errAccessJWTSecret     = errors.New("the rpc JWT secret must be at least 32 hex bytes")
+	errJWTMalformed        = errors.New("malformed JWT")
+	errJWTUnsupportedAlg   = errors.New("unsupported JWT algorithm")
+	errJWTInvalidSignature = errors.New("invalid JWT signature")
+	errJWTExpired          = errors.New("JWT is expired")
+	errJWTNotValidYet      = errors.New("JWT is not valid yet")
+)
+
+// the default access rules, only the chain queries and the signed tx broadcasts are public.
+// The wallet, node control and tx pool methods need authentication unless the config overrides them,
+// and so does any method which isn't listed.
+var defaultAccessRules = map[string]string{
+	accessRuleDefault: AccessAuth,
+
+	"dipperin_getSyncStatus":              AccessPublic,
+	"dipperin_currentBlock":               AccessPublic,
+	"dipperin_getBlockByNumber":           AccessPublic,
+	"dipperin_getBlockByHash":             AccessPublic,
+	"dipperin_getBlockNumber":             AccessPublic,
+	"dipperin_getGenesis":                 AccessPublic,
+	"dipperin_getBlockBody":               AccessPublic,
+	"dipperin_currentBalance":             AccessPublic,
+	"dipperin_transaction":                AccessPublic,
+	"dipperin_getTransactionsByAddress":   AccessPublic,
+	"dipperin_getTransactionNonce":        AccessPublic,
+	"dipperin_newTransaction":             AccessPublic,
+	"dipperin_newSendTransactions":        AccessPublic,
+	"dipperin_newContract":                AccessPublic,
+	"dipperin_newEstimateGas":             AccessPublic,
+	"dipperin_getContractInfo":            AccessPublic,
+	"dipperin_getContract":                AccessPublic,
+	"dipperin_eRC20TotalSupply":           AccessPublic,
+	"dipperin_eRC20Balance":               AccessPublic,
+	"dipperin_eRC20Allowance":             AccessPublic,
+	"dipperin_eRC20Holders":               AccessPublic,
+	"dipperin_eRC20TransfersByAddress":    AccessPublic,
+	"dipperin_getLockInfo":                AccessPublic,
+	"dipperin_getMultiSigAccount":         AccessPublic,
+	"dipperin_getVerifiersBySlot":         AccessPublic,
+	"dipperin_getSlotByNumber":            AccessPublic,
+	"dipperin_getCurVerifiers":            AccessPublic,
+	"dipperin_getNextVerifiers":           AccessPublic,
+	"dipperin_verifierStatus":             AccessPublic,
+	"dipperin_currentStake":               AccessPublic,
+	"dipperin_currentReputation":          AccessPublic,
+	"dipperin_getChainConfig":             AccessPublic,
+	"dipperin_getBlockDiffVerifierInfo":   AccessPublic,
+	"dipperin_getVerifierDIPReward":       AccessPublic,
+	"dipperin_getMineMasterDIPReward":     AccessPublic,
+	"dipperin_getBlockYear":               AccessPublic,
+	"dipperin_getOneBlockTotalDIPReward":  AccessPublic,
+	"dipperin_getInvestorInfo":            AccessPublic,
+	"dipperin_getDeveloperInfo":           AccessPublic,
+	"dipperin_getAddressLockMoney":        AccessPublic,
+	"dipperin_getInvestorLockDIP":         AccessPublic,
+	"dipperin_getDeveloperLockDIP":        AccessPublic,
+	"dipperin_getFoundationInfo":          AccessPublic,
+	"dipperin_getMaintenanceLockDIP":      AccessPublic,
+	"dipperin_getReMainRewardLockDIP":     AccessPublic,
+	"dipperin_getEarlyTokenLockDIP":       AccessPublic,
+	"dipperin_getMineMasterEDIPReward":    AccessPublic,
+	"dipperin_getVerifierEDIPReward":      AccessPublic,
+	"dipperin_getABI":                     AccessPublic,
+	"dipperin_getCode":                    AccessPublic,
+	"dipperin_suggestGasPrice":            AccessPublic,
+	"dipperin_getContractAddressByTxHash": AccessPublic,
+	"dipperin_getLogs":                    AccessPublic,
+	"dipperin_getTxActualFee":             AccessPublic,
+	"dipperin_getReceiptByTxHash":         AccessPublic,
+	"dipperin_getReceiptsByBlockNum":      AccessPublic,
+	"dipperin_callContract":               AccessPublic,
+	"dipperin_estimateGas":                AccessPublic,
+	"dipperin_newBlock":                   AccessPublic,
+	"dipperin_subscribeBlock":             AccessPublic,
+	"dipperin_newPendingTransactions":     AccessPublic,
+	"dipperin_subscribeLogs":              AccessPublic,
+	"dipperin_subscribeReceipt":           AccessPublic,
+
+	"debug":  AccessAuth,
+	"p2p":    AccessAuth,
+	"txpool": AccessAuth,
+
+	// the wallet and node control methods stay protected if the config makes "*" public
+	"dipperin_establishWallet":           AccessAuth,
+	"dipperin_openWallet":                AccessAuth,
+	"dipperin_closeWallet":               AccessAuth,
+	"dipperin_restoreWallet":             AccessAuth,
+	"dipperin_listWallet":                AccessAuth,
+	"dipperin_listWalletAccount":         AccessAuth,
+	"dipperin_addAccount":                AccessAuth,
+	"dipperin_syncUsedAccounts":          AccessAuth,
+	"dipperin_getAddressNonceFromWallet": AccessAuth,
+	"dipperin_sendTransaction":           AccessAuth,
+	"dipperin_sendTransactionContract":   AccessAuth,
+	"dipperin_sendTransactions":          AccessAuth,
+	"dipperin_sendRegisterTransaction":   AccessAuth,
+	"dipperin_sendUnStakeTransaction":    AccessAuth,
+	"dipperin_sendEvidenceTransaction":   AccessAuth,
+	"dipperin_sendCancelTransaction":     AccessAuth,
+	"dipperin_sendLockTransaction":       AccessAuth,
+	"dipperin_sendClaimTransaction":      AccessAuth,
+	"dipperin_sendRefundTransaction":     AccessAuth,
+	"dipperin_createMultiSigAccount":     AccessAuth,
+	"dipperin_newMultiSigTransaction":    AccessAuth,
+	"dipperin_signMultiSigTransaction":   AccessAuth,
+	"dipperin_eRC20Transfer":             AccessAuth,
+	"dipperin_eRC20TransferFrom":         AccessAuth,
+	"dipperin_eRC20Approve":              AccessAuth,
+	"dipperin_createERC20":               AccessAuth,
+	"dipperin_setMineCoinBase":           AccessAuth,
+	"dipperin_setMineGasConfig":          AccessAuth,
+	"dipperin_startMine":                 AccessAuth,
+	"dipperin_stopMine":                  AccessAuth,
+	"dipperin_setBftSigner":              AccessAuth,
+	"dipperin_startRemainingService":     AccessAuth,
+	"dipperin_stopDipperin":              AccessAuth,
+}
+
+// AccessConfig is the access control of the http and websocket endpoints,
+// the ipc and in-process endpoints are trusted and never checked.
+type AccessConfig struct {
+	// the namespaces exposed on the http and websocket endpoints, only the public apis are exposed if it's empty
+	Modules []string `json:"modules"`
+	// the api keys accepted in the "Authorization: Bearer <key>" header, keyed by the client name
+	ApiKeys map[string]string `json:"api_keys"`
+	// the hex HS256 secret of the JWTs accepted in the "Authorization: Bearer <token>" header
+	JWTSecret string `json:"jwt_secret"`
+	// the access levels keyed by the namespace, "namespace_method" or "*", the most specific one is used.
+	// The namespace and method rules override the default ones, which only make the chain queries public,
+	// and "*" only applies to the methods without any rule.
+	Rules map[string]string `json:"rules"`
+	// the requests per second allowed for a client and the burst of them, no limit if it's 0.
+	// The authenticated clients are limited by their names, the others by their ip addresses.
+	RateLimit float64 `json:"rate_limit"`
+	RateBurst int     `json:"rate_burst"`
+	// the max number of the requests in a batch, no limit if it's 0
+	MaxBatchSize int `json:"max_batch_size"`
+}
+
+// LoadAccessConfig reads the access config from the json file
+func LoadAccessConfig(path string) (*AccessConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	conf := &AccessConfig{}
+	if err = util.ParseJsonFromBytes(data, conf); err != nil {
+		return nil, err
+	}
+	if _, err = newAccessGuard(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// accessError is the JSON-RPC error of a rejected call
+type accessError struct {
+	code    int
+	message string
+}
+
+func (e *accessError) ErrorCode() int { return e.code }
+
+func (e *accessError) Error() string { return e.message }
+
+// the kinds of the credentials presented by the clients
+const (
+	credentialNone = iota
+	credentialApiKey
+	credentialJWT
+)
+
+// accessGuard checks the rpc calls with the access config
+type accessGuard struct {
+	rules        map[string]string
+	apiKeys      map[string]string
+	jwtSecret    []byte
+	maxBatchSize int
+	limiter      *rateLimiter
+
+	now func() time.Time
+}
+
+func newAccessGuard(conf *AccessConfig) (*accessGuard, error) {
+	g := &accessGuard{
+		rules:        make(map[string]string, len(conf.Rules)),
+		apiKeys:      conf.ApiKeys,
+		maxBatchSize: conf.MaxBatchSize,
+		now:          time.Now,
+	}
+	for name, level := range conf.Rules {
+		switch level {
+		case AccessPublic, AccessAuth, AccessApiKey, AccessJWT, AccessDeny:
+		default:
+			return nil, fmt.Errorf("%v: %v %v", errAccessUnknownLevel, name, level)
+		}
+		g.rules[name] = level
+	}
+	for name, key := range conf.ApiKeys {
+		if key == "" {
+			return nil, fmt.Errorf("%v: %v", errAccessEmptyApiKey, name)
+		}
+	}
+	if conf.JWTSecret != "" {
+		secret, err := hex.DecodeString(strings.TrimPrefix(conf.JWTSecret, "0x"))
+		if err != nil || len(secret) < jwtSecretMinLen {
+			return nil, errAccessJWTSecret
+		}
+		g.jwtSecret = secret
+	}
+	if conf.RateLimit > 0 {
+		if conf.RateBurst <= 0 {
+			return nil, errAccessRateLimit
+		}
+		g.limiter = newRateLimiter(conf.RateLimit, conf.RateBurst)
+	}
+	return g, nil
+}
+
+// CheckBatch rejects the batches larger than the max batch size
+func (g *accessGuard) CheckBatch(ctx context.Context, size int) rpc.Error {
+	if g.maxBatchSize > 0 && size > g.maxBatchSize {
+		return g.reject(ctx, "batch_too_large", &accessError{errCodeLimitExceeded, fmt.Sprintf("batch too large, max %d requests", g.maxBatchSize)})
+	}
+	return nil
+}
+
+// CheckCall checks the credential of the client and its request rate
+func (g *accessGuard) CheckCall(ctx context.Context, service, method string) rpc.Error {
+	level := g.level(service, method)
+	if level == AccessDeny {
+		return g.reject(ctx, "forbidden", &accessError{errCodeForbidden, fmt.Sprintf("the method %s_%s is forbidden", service, method)})
+	}
+
+	client, kind, err := g.authenticate(ctx)
+	switch {
+	case level == AccessAuth && kind == credentialNone,
+		level == AccessApiKey && kind != credentialApiKey,
+		level == AccessJWT && kind != credentialJWT:
+		msg := fmt.Sprintf("the method %s_%s needs authentication", service, method)
+		if err != nil {
+			msg = fmt.Sprintf("%s: %v", msg, err)
+		}
+		return g.reject(ctx, "unauthorized", &accessError{errCodeUnauthorized, msg})
+	}
+
+	if g.limiter != nil && !g.limiter.allow(client, g.now()) {
+		return g.reject(ctx, "rate_limited", &accessError{errCodeLimitExceeded, "request rate limit exceeded"})
+	}
+	return nil
+}
+
+// level returns the access level of the method, the configured rules override the default ones
+// and the method rule overrides the namespace rule
+func (g *accessGuard) level(service, method string) string {
+	for _, rules := range []map[string]string{g.rules, defaultAccessRules} {
+		if level, ok := rules[service+"_"+method]; ok {
+			return level
+		}
+		if level, ok := rules[service]; ok {
+			return level
+		}
+	}
+	if level, ok := g.rules[accessRuleDefault]; ok {
+		return level
+	}
+	return defaultAccessRules[accessRuleDefault]
+}
+
+// authenticate returns the client name and the kind of its credential, the anonymous clients are named by their ip addresses.
+// The invalid credential error is returned with the anonymous client.
+func (g *accessGuard) authenticate(ctx context.Context) (string, int, error) {
+	anonymous := "ip:" + remoteHost(ctx)
+
+	auth, _ := ctx.Value("Authorization").(string)
+	if auth == "" {
+		return anonymous, credentialNone, nil
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+
+	for name, key := range g.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			return "key:" + name, credentialApiKey, nil
+		}
+	}
+	if g.jwtSecret == nil || strings.Count(token, ".") != 2 {
+		return anonymous, credentialNone, errors.New("unknown credential")
+	}
+	subject, err := verifyJWT(token, g.jwtSecret, g.now())
+	if err != nil {
+		return anonymous, credentialNone, err
+	}
+	return "jwt:" + subject, credentialJWT, nil
+}
+
+func (g *accessGuard) reject(ctx context.Context, reason string, err *accessError) rpc.Error {
+	log.Debug("reject rpc call", "remote", ctx.Value("remote"), "reason", reason, "err", err.message)
+	g_metrics.Add(g_metrics.RpcRejectedCount, reason, 1)
+	return err
+}
+
+// remoteHost returns the host of the remote address in the request context
+func remoteHost(ctx context.Context) string {
+	remote, _ := ctx.Value("remote").(string)
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host
+	}
+	return remote
+}
+
+// verifyJWT checks the HS256 signature and the time claims of the token, it returns the subject of the token
+func verifyJWT(token string, secret []byte, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errJWTMalformed
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return "", err
+	}
+	if header.Alg != "HS256" {
+		return "", errJWTUnsupportedAlg
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errJWTMalformed
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return "", errJWTInvalidSignature
+	}
+
+	var claims struct {
+		Subject   string `json:"sub"`
+		ExpiresAt *int64 `json:"exp"`
+		NotBefore *int64 `json:"nbf"`
+	}
+	if err = decodeJWTPart(parts[1], &claims); err != nil {
+		return "", err
+	}
+	if claims.ExpiresAt != nil && now.Unix() >= *claims.ExpiresAt {
+		return "", errJWTExpired
+	}
+	if claims.NotBefore != nil && now.Unix() < *claims.NotBefore {
+		return "", errJWTNotValidYet
+	}
+	return claims.Subject, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errJWTMalformed
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return errJWTMalformed
+	}
+	return nil
+}
+
+// rateLimiter limits the request rate of the clients with token buckets
+type rateLimiter struct {
+	lock      sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*rateBucket
+	lastPrune time.Time
+}
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*rateBucket),
+	}
+}
+
+// allow takes a token of the client, it returns false if the client has run out of tokens
+func (l *rateLimiter) allow(client string, now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.lastPrune) >= rateBucketsPruneInterval {
+		l.prune(now)
+	}
+
+	b := l.buckets[client]
+	if b == nil {
+		b = &rateBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed.Seconds()*l.rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune drops the buckets which have been refilled, they are the same as the new ones
+func (l *rateLimiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastPrune = now
+}
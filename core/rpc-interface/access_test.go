@@ -0,0 +1,244 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rpc_interface
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+var testJWTSecret = strings.Repeat("ab", 32)
+
+func makeTestJWT(t *testing.T, alg, claims string) string {
+	secret, err := hex.DecodeString(testJWTSecret)
+	assert.NoError(t, err)
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"` + alg + `","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func testAccessCtx(remote, auth string) context.Context {
+	ctx := context.WithValue(context.Background(), "remote", remote)
+	if auth != "" {
+		ctx = context.WithValue(ctx, "Authorization", auth)
+	}
+	return ctx
+}
+
+func TestNewAccessGuard(t *testing.T) {
+	_, err := newAccessGuard(&AccessConfig{Rules: map[string]string{"dipperin": "private"}})
+	assert.Error(t, err)
+	_, err = newAccessGuard(&AccessConfig{ApiKeys: map[string]string{"wallet": ""}})
+	assert.Error(t, err)
+	_, err = newAccessGuard(&AccessConfig{JWTSecret: "0x1234"})
+	assert.Equal(t, errAccessJWTSecret, err)
+	_, err = newAccessGuard(&AccessConfig{RateLimit: 1})
+	assert.Equal(t, errAccessRateLimit, err)
+
+	g, err := newAccessGuard(&AccessConfig{
+		JWTSecret: "0x" + testJWTSecret,
+		Rules:     map[string]string{"dipperin": AccessDeny, "dipperin_openWallet": AccessPublic},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, g.jwtSecret)
+	assert.Nil(t, g.limiter)
+	assert.Equal(t, AccessDeny, g.level("dipperin", "currentBlock"))
+	assert.Equal(t, AccessPublic, g.level("dipperin", "openWallet"))
+	assert.Equal(t, AccessDeny, g.level("dipperin", "sendTransaction"))
+	assert.Equal(t, AccessAuth, g.level("p2p", "addPeer"))
+	assert.Equal(t, AccessAuth, g.level("txpool", "content"))
+	assert.Equal(t, AccessAuth, g.level("unknown", "method"))
+
+	g, err = newAccessGuard(&AccessConfig{Rules: map[string]string{"*": AccessPublic}})
+	assert.NoError(t, err)
+	assert.Equal(t, AccessPublic, g.level("dipperin", "currentBlock"))
+	assert.Equal(t, AccessPublic, g.level("dipperin", "unknownMethod"))
+	assert.Equal(t, AccessAuth, g.level("dipperin", "openWallet"))
+	assert.Equal(t, AccessAuth, g.level("txpool", "content"))
+
+	g, err = newAccessGuard(&AccessConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, AccessPublic, g.level("dipperin", "getBlockByNumber"))
+	assert.Equal(t, AccessPublic, g.level("dipperin", "subscribeBlock"))
+	assert.Equal(t, AccessAuth, g.level("dipperin", "getMineCoinBase"))
+	assert.Equal(t, AccessAuth, g.level("txpool", "status"))
+}
+
+func TestDefaultAccessRules_ExternalApi(t *testing.T) {
+	g, err := newAccessGuard(&AccessConfig{})
+	assert.NoError(t, err)
+	apiType := reflect.TypeOf(&DipperExternalApi{})
+	for i := 0; i < apiType.NumMethod(); i++ {
+		name := []rune(apiType.Method(i).Name)
+		name[0] = unicode.ToLower(name[0])
+		assert.Equal(t, AccessPublic, g.level("dipperin", string(name)), string(name))
+	}
+}
+
+func TestAccessGuard_CheckCall(t *testing.T) {
+	g, err := newAccessGuard(&AccessConfig{
+		ApiKeys:   map[string]string{"wallet": "secret-key"},
+		JWTSecret: testJWTSecret,
+		Rules: map[string]string{
+			"dipperin_stopDipperin": AccessDeny,
+			"p2p":                   AccessApiKey,
+			"debug":                 AccessJWT,
+		},
+	})
+	assert.NoError(t, err)
+	now := time.Unix(1000, 0)
+	g.now = func() time.Time { return now }
+
+	anonymous := testAccessCtx("1.2.3.4:5678", "")
+	apiKey := testAccessCtx("1.2.3.4:5678", "Bearer secret-key")
+	jwt := testAccessCtx("1.2.3.4:5678", "Bearer "+makeTestJWT(t, "HS256", `{"sub":"admin","exp":2000}`))
+	wrongKey := testAccessCtx("1.2.3.4:5678", "Bearer wrong-key")
+
+	assert.Nil(t, g.CheckCall(anonymous, "dipperin", "currentBlock"))
+	assert.Nil(t, g.CheckCall(wrongKey, "dipperin", "currentBlock"))
+
+	assert.Equal(t, errCodeUnauthorized, g.CheckCall(anonymous, "dipperin", "openWallet").ErrorCode())
+	rpcErr := g.CheckCall(wrongKey, "dipperin", "openWallet")
+	assert.Equal(t, errCodeUnauthorized, rpcErr.ErrorCode())
+	assert.Contains(t, rpcErr.Error(), "unknown credential")
+	assert.Nil(t, g.CheckCall(apiKey, "dipperin", "openWallet"))
+	assert.Nil(t, g.CheckCall(jwt, "dipperin", "openWallet"))
+
+	assert.Nil(t, g.CheckCall(apiKey, "p2p", "addPeer"))
+	assert.Equal(t, errCodeUnauthorized, g.CheckCall(jwt, "p2p", "addPeer").ErrorCode())
+	assert.Nil(t, g.CheckCall(jwt, "debug", "gcStats"))
+	assert.Equal(t, errCodeUnauthorized, g.CheckCall(apiKey, "debug", "gcStats").ErrorCode())
+
+	assert.Equal(t, errCodeForbidden, g.CheckCall(apiKey, "dipperin", "stopDipperin").ErrorCode())
+
+	// the expired token
+	now = time.Unix(2000, 0)
+	rpcErr = g.CheckCall(jwt, "dipperin", "openWallet")
+	assert.Equal(t, errCodeUnauthorized, rpcErr.ErrorCode())
+	assert.Contains(t, rpcErr.Error(), errJWTExpired.Error())
+}
+
+func TestAccessGuard_CheckBatch(t *testing.T) {
+	g, err := newAccessGuard(&AccessConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, g.CheckBatch(context.Background(), 1000))
+
+	g, err = newAccessGuard(&AccessConfig{MaxBatchSize: 2})
+	assert.NoError(t, err)
+	assert.Nil(t, g.CheckBatch(context.Background(), 2))
+	assert.Equal(t, errCodeLimitExceeded, g.CheckBatch(context.Background(), 3).ErrorCode())
+}
+
+func TestAccessGuard_RateLimit(t *testing.T) {
+	g, err := newAccessGuard(&AccessConfig{
+		ApiKeys:   map[string]string{"wallet": "secret-key"},
+		RateLimit: 1,
+		RateBurst: 2,
+	})
+	assert.NoError(t, err)
+	now := time.Unix(1000, 0)
+	g.now = func() time.Time { return now }
+
+	client1 := testAccessCtx("1.2.3.4:5678", "")
+	client1OtherPort := testAccessCtx("1.2.3.4:5679", "")
+	client2 := testAccessCtx("5.6.7.8:5678", "")
+	keyClient := testAccessCtx("1.2.3.4:5678", "Bearer secret-key")
+
+	assert.Nil(t, g.CheckCall(client1, "dipperin", "currentBlock"))
+	assert.Nil(t, g.CheckCall(client1OtherPort, "dipperin", "currentBlock"))
+	assert.Equal(t, errCodeLimitExceeded, g.CheckCall(client1, "dipperin", "currentBlock").ErrorCode())
+	assert.Nil(t, g.CheckCall(client2, "dipperin", "currentBlock"))
+	assert.Nil(t, g.CheckCall(keyClient, "dipperin", "currentBlock"))
+
+	now = now.Add(time.Second)
+	assert.Nil(t, g.CheckCall(client1, "dipperin", "currentBlock"))
+	assert.Equal(t, errCodeLimitExceeded, g.CheckCall(client1, "dipperin", "currentBlock").ErrorCode())
+}
+
+func TestRateLimiter_prune(t *testing.T) {
+	l := newRateLimiter(1, 2)
+	now := time.Unix(1000, 0)
+	assert.True(t, l.allow("a", now))
+	assert.True(t, l.allow("b", now))
+	assert.True(t, l.allow("b", now))
+	assert.Len(t, l.buckets, 2)
+
+	// a is refilled after a second, b needs two
+	l.prune(now.Add(time.Second))
+	assert.Len(t, l.buckets, 1)
+	l.prune(now.Add(2 * time.Second))
+	assert.Len(t, l.buckets, 0)
+}
+
+func TestVerifyJWT(t *testing.T) {
+	secret, _ := hex.DecodeString(testJWTSecret)
+	now := time.Unix(1000, 0)
+
+	sub, err := verifyJWT(makeTestJWT(t, "HS256", `{"sub":"admin","nbf":900,"exp":1100}`), secret, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", sub)
+
+	_, err = verifyJWT(makeTestJWT(t, "HS256", `{"sub":"admin","nbf":1001}`), secret, now)
+	assert.Equal(t, errJWTNotValidYet, err)
+	_, err = verifyJWT(makeTestJWT(t, "none", `{"sub":"admin"}`), secret, now)
+	assert.Equal(t, errJWTUnsupportedAlg, err)
+	_, err = verifyJWT(makeTestJWT(t, "HS256", `{"sub":"admin"}`), secret[1:], now)
+	assert.Equal(t, errJWTInvalidSignature, err)
+	_, err = verifyJWT("a.b", secret, now)
+	assert.Equal(t, errJWTMalformed, err)
+	_, err = verifyJWT("!.b.c", secret, now)
+	assert.Equal(t, errJWTMalformed, err)
+}
+
+func TestLoadAccessConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc_access")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "access.json")
+
+	_, err = LoadAccessConfig(path)
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"modules":["dipperin"],"rules":{"dipperin":"unknown"}}`), 0644))
+	_, err = LoadAccessConfig(path)
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"modules":["dipperin"],"api_keys":{"wallet":"key"},"rate_limit":10,"rate_burst":20,"max_batch_size":5}`), 0644))
+	conf, err := LoadAccessConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, &AccessConfig{
+		Modules:      []string{"dipperin"},
+		ApiKeys:      map[string]string{"wallet": "key"},
+		RateLimit:    10,
+		RateBurst:    20,
+		MaxBatchSize: 5,
+	}, conf)
+}
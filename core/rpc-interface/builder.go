@@ -33,6 +33,10 @@ func MakeDipperinP2PApi(service P2PAPI) *DipperinP2PApi {
 	return &DipperinP2PApi{service: service}
 }
 
+func MakeDipperinTxPoolApi(service TxPoolAPI) *DipperinTxPoolApi {
+	return &DipperinTxPoolApi{service: service}
+}
+
 func MakeDipperExternalApi(api *DipperinVenusApi) *DipperExternalApi {
 	return &DipperExternalApi{allApis: api}
 }
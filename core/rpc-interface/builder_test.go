@@ -25,6 +25,7 @@ func TestMakeDipperinMercuryApi(t *testing.T) {
 	assert.NotNil(t, MakeDipperinVenusApi(nil))
 	assert.NotNil(t, MakeDipperinDebugApi(nil))
 	assert.NotNil(t, MakeDipperinP2PApi(nil))
+	assert.NotNil(t, MakeDipperinTxPoolApi(nil))
 	assert.NotNil(t, MakeRpcService(&fakeNConf{}, nil, nil))
 }
 
@@ -18,11 +18,24 @@ package rpc_interface
 
 import (
 	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/config"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/dipperin/service"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"math/big"
 	"runtime"
 )
 
 type debugAPI interface {
 	Metrics(raw bool) (map[string]interface{}, error)
+	CurrentBlock() model.AbstractBlock
+	GetExtraData(to common.Address, data []byte) ([]byte, error)
+	MakeTmpSignedTx(args service.CallArgs, blockNum uint64) (model.AbstractTransaction, error)
+	TraceTransaction(txHash common.Hash) (*service.TxTrace, error)
+	TraceBlock(blockNum uint64) ([]*service.TxTrace, error)
+	TraceCall(signedTx model.AbstractTransaction, blockNum uint64) (*service.TxTrace, error)
 }
 
 type DipperinDebugApi struct {
@@ -38,3 +51,52 @@ func (api *DipperinDebugApi) PrintGos() {
 	buf = buf[:runtime.Stack(buf, true)]
 	fmt.Println(string(buf))
 }
+
+// TraceTransaction re-executes the mined transaction and returns its call frames and state diff
+func (api *DipperinDebugApi) TraceTransaction(txHash common.Hash) (*service.TxTrace, error) {
+	return api.service.TraceTransaction(txHash)
+}
+
+// TraceBlock re-executes the transactions of the block and returns their traces
+func (api *DipperinDebugApi) TraceBlock(blockNum uint64) ([]*service.TxTrace, error) {
+	return api.service.TraceBlock(blockNum)
+}
+
+// TraceCall executes the contract call on the state of the block without changing it and returns the trace,
+// the current block is used if the block number is 0 or bigger than the current one
+func (api *DipperinDebugApi) TraceCall(from, to common.Address, value, gasPrice *big.Int, gasLimit uint64, data []byte, blockNum uint64) (*service.TxTrace, error) {
+	if value == nil {
+		value = new(big.Int).SetUint64(0)
+	}
+	if gasPrice == nil {
+		gasPrice = big.NewInt(0).SetInt64(config.DEFAULT_GAS_PRICE)
+	}
+
+	extraData, err := api.service.GetExtraData(to, data)
+	if err != nil {
+		return nil, err
+	}
+
+	curBlock := api.service.CurrentBlock()
+	if blockNum == 0 || curBlock.Number() < blockNum {
+		blockNum = curBlock.Number()
+	}
+	if gasLimit == 0 {
+		gasLimit = curBlock.Header().GetGasLimit()
+	}
+
+	args := service.CallArgs{
+		From:     from,
+		To:       &to,
+		Gas:      hexutil.Uint64(gasLimit),
+		GasPrice: hexutil.Big(*gasPrice),
+		Value:    hexutil.Big(*value),
+		Data:     hexutil.Bytes(extraData),
+	}
+	log.Info("API#TraceCall start", "from", from, "to", to, "blockNum", blockNum)
+	signedTx, err := api.service.MakeTmpSignedTx(args, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	return api.service.TraceCall(signedTx, blockNum)
+}
@@ -17,7 +17,11 @@
 package rpc_interface
 
 import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/dipperin/service"
+	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
 )
 
@@ -28,8 +32,66 @@ func TestDipperinDebugApi_Metrics(t *testing.T) {
 	api.PrintGos()
 }
 
-type fakeDS struct{}
+func TestDipperinDebugApi_Trace(t *testing.T) {
+	fds := &fakeDS{}
+	api := &DipperinDebugApi{service: fds}
+	txHash := common.HexToHash("0x12")
+
+	trace, err := api.TraceTransaction(txHash)
+	assert.NoError(t, err)
+	assert.Equal(t, txHash, trace.TxHash)
+
+	traces, err := api.TraceBlock(1)
+	assert.NoError(t, err)
+	assert.Len(t, traces, 1)
+
+	from := common.HexToAddress("0x1234")
+	to := common.HexToAddress("0x5678")
+	trace, err = api.TraceCall(from, to, nil, nil, 0, []byte{1}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), fds.callBlockNum)
+	assert.Equal(t, from, fds.callArgs.From)
+	assert.Equal(t, to, *fds.callArgs.To)
+	assert.Equal(t, fds.CurrentBlock().Header().GetGasLimit(), uint64(fds.callArgs.Gas))
+	assert.Equal(t, []byte{1}, []byte(fds.callArgs.Data))
+
+	_, err = api.TraceCall(from, to, big.NewInt(1), big.NewInt(2), 100, nil, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), uint64(fds.callArgs.Gas))
+	assert.Equal(t, big.NewInt(1), fds.callArgs.Value.ToInt())
+}
+
+type fakeDS struct {
+	callArgs     service.CallArgs
+	callBlockNum uint64
+}
 
 func (fds *fakeDS) Metrics(raw bool) (map[string]interface{}, error) {
 	return nil, nil
 }
+
+func (fds *fakeDS) CurrentBlock() model.AbstractBlock {
+	return model.CreateBlock(1, common.Hash{}, 1)
+}
+
+func (fds *fakeDS) GetExtraData(to common.Address, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (fds *fakeDS) MakeTmpSignedTx(args service.CallArgs, blockNum uint64) (model.AbstractTransaction, error) {
+	fds.callArgs = args
+	fds.callBlockNum = blockNum
+	return nil, nil
+}
+
+func (fds *fakeDS) TraceTransaction(txHash common.Hash) (*service.TxTrace, error) {
+	return &service.TxTrace{TxHash: txHash}, nil
+}
+
+func (fds *fakeDS) TraceBlock(blockNum uint64) ([]*service.TxTrace, error) {
+	return []*service.TxTrace{{}}, nil
+}
+
+func (fds *fakeDS) TraceCall(signedTx model.AbstractTransaction, blockNum uint64) (*service.TxTrace, error) {
+	return &service.TxTrace{}, nil
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/contract"
 	"github.com/dipperin/dipperin-core/core/dipperin/service"
 	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/core/mine/minemaster"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/core/vm/common/utils"
 	model2 "github.com/dipperin/dipperin-core/core/vm/model"
@@ -298,6 +299,56 @@ func (api *DipperinVenusApi) Transaction(hash common.Hash) (resp *TransactionRes
 	return &tmpResp, nil
 }
 
+// get the transactions sent or received by the address
+// swagger:operation POST /url/GetTransactionsByAddress transaction information GetTransactionsByAddress
+// ---
+// summary: get a page of the transactions sent or received by the address from the latest one
+// description: the node must keep the tx history, the limit is at most 100 and 0 for the most
+// parameters:
+// - name: address
+//   in: body
+//   description: the address of the transactions
+//   type: common.Address
+//   required: true
+// - name: offset
+//   in: body
+//   description: the number of the latest transactions skipped
+//   type: uint64
+//   required: true
+// - name: limit
+//   in: body
+//   description: the most transactions returned
+//   type: uint64
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        "$ref": "#/responses/AddressTxResp"
+func (api *DipperinVenusApi) GetTransactionsByAddress(address common.Address, offset, limit uint64) ([]*AddressTxResp, error) {
+	entries, err := api.service.GetTransactionsByAddress(address, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*AddressTxResp, 0, len(entries))
+	for _, entry := range entries {
+		tx, blockHash, _, _, err := api.service.Transaction(entry.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, &AddressTxResp{
+			Transaction: tx,
+			BlockHash:   blockHash,
+			BlockNumber: entry.BlockNumber,
+			TxIndex:     entry.Index,
+			Sent:        entry.Sent,
+			Received:    entry.Received,
+		})
+	}
+	return resp, nil
+}
+
 // get the nonce needed for the transaction:
 // swagger:operation POST /url/Transaction transaction information Transaction
 // ---
@@ -474,6 +525,61 @@ func (api *DipperinVenusApi) StopMine() error {
 	return api.service.StopMine()
 }
 
+// get the pool balance of the worker:
+// swagger:operation POST /url/GetWorkerBalance mineOperation GetWorkerBalance
+// ---
+// summary: get the pool balance of the worker
+// description: get the credited, immature and paid rewards of the worker in the mine master pool
+// parameters:
+// - name: address
+//   in: body
+//   description: the coinbase address of the worker
+//   type: common.Address
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        "$ref": "#/responses/WorkerBalanceResp"
+func (api *DipperinVenusApi) GetWorkerBalance(address common.Address) (*WorkerBalanceResp, error) {
+	balance, err := api.service.GetWorkerBalance(address)
+	if err != nil {
+		return nil, err
+	}
+	return newWorkerBalanceResp(balance), nil
+}
+
+// get the pool balances of all the workers:
+// swagger:operation POST /url/GetWorkerBalances mineOperation GetWorkerBalances
+// ---
+// summary: get the pool balances of all the workers
+// description: get the credited, immature and paid rewards of the workers in the mine master pool
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        "$ref": "#/responses/WorkerBalanceResp"
+func (api *DipperinVenusApi) GetWorkerBalances() ([]*WorkerBalanceResp, error) {
+	balances, err := api.service.GetWorkerBalances()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*WorkerBalanceResp, 0, len(balances))
+	for _, balance := range balances {
+		result = append(result, newWorkerBalanceResp(balance))
+	}
+	return result, nil
+}
+
+func newWorkerBalanceResp(balance minemaster.WorkerBalance) *WorkerBalanceResp {
+	return &WorkerBalanceResp{
+		Address:  balance.Address,
+		Balance:  (*hexutil.Big)(balance.Balance),
+		Immature: (*hexutil.Big)(balance.Immature),
+		Paid:     (*hexutil.Big)(balance.Paid),
+	}
+}
+
 // establish wallet
 // swagger:operation POST /url/EstablishWallet WalletOperation Wallet
 // ---
@@ -708,6 +814,93 @@ func (api *DipperinVenusApi) CreateERC20(from common.Address, tokenName, tokenSy
 	return resp, err
 }
 
+// get the holders of the built-in erc20 token
+// swagger:operation POST /url/ERC20Holders ERC20 ERC20Holders
+// ---
+// summary: get a page of the holders of the built-in erc20 token from the largest balance
+// description: the holders with the same balance are ordered by the address, the limit is at most 100 and 0 for the most
+// parameters:
+// - name: contractAddr
+//   in: body
+//   description: the address of the token
+//   type: common.Address
+//   required: true
+// - name: offset
+//   in: body
+//   description: the number of the largest holders skipped
+//   type: uint64
+//   required: true
+// - name: limit
+//   in: body
+//   description: the most holders returned
+//   type: uint64
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        "$ref": "#/responses/ERC20HolderResp"
+func (api *DipperinVenusApi) ERC20Holders(contractAddr common.Address, offset, limit uint64) ([]*ERC20HolderResp, error) {
+	holders, err := api.service.GetERC20Holders(contractAddr, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*ERC20HolderResp, 0, len(holders))
+	for _, holder := range holders {
+		resp = append(resp, &ERC20HolderResp{Address: holder.Address, Balance: (*hexutil.Big)(holder.Balance)})
+	}
+	return resp, nil
+}
+
+// get the built-in erc20 token transfers sent or received by the address
+// swagger:operation POST /url/ERC20TransfersByAddress ERC20 ERC20TransfersByAddress
+// ---
+// summary: get a page of the built-in erc20 token transfers sent or received by the address from the latest one
+// description: the node must keep the tx history, only the transfers after the Ceres fork are logged. The limit is at most 100 and 0 for the most
+// parameters:
+// - name: address
+//   in: body
+//   description: the address of the transfers
+//   type: common.Address
+//   required: true
+// - name: offset
+//   in: body
+//   description: the number of the latest transfers skipped
+//   type: uint64
+//   required: true
+// - name: limit
+//   in: body
+//   description: the most transfers returned
+//   type: uint64
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        "$ref": "#/responses/ERC20TransferResp"
+func (api *DipperinVenusApi) ERC20TransfersByAddress(address common.Address, offset, limit uint64) ([]*ERC20TransferResp, error) {
+	entries, err := api.service.GetERC20Transfers(address, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*ERC20TransferResp, 0, len(entries))
+	for _, entry := range entries {
+		resp = append(resp, &ERC20TransferResp{
+			Token:       entry.Token,
+			TxHash:      entry.TxHash,
+			BlockNumber: entry.BlockNumber,
+			TxIndex:     entry.TxIndex,
+			LogIndex:    entry.LogIndex,
+			From:        entry.From,
+			To:          entry.To,
+			Value:       (*hexutil.Big)(entry.Value),
+		})
+	}
+	return resp, nil
+}
+
 func (api *DipperinVenusApi) CheckBootNode() ([]string, error) {
 	nodes := make([]string, len(chain_config.KBucketNodes))
 	for i, kn := range chain_config.KBucketNodes {
@@ -972,6 +1165,267 @@ func (api *DipperinVenusApi) SendCancelTransaction(from common.Address, gasPrice
 	return api.service.SendCancelTransaction(from, gasPrice, gasLimit, nonce)
 }
 
+// send lock transaction
+// swagger:operation POST /url/SendLockTransaction transactionOperation transaction
+// ---
+// summary: send lock transaction
+// description: lock value at the lock address of from and to, to can claim it with the hash key before the time lock expired
+// parameters:
+// - name: from
+//   in: body
+//   description: the address that lock the money
+//   type: common.Address
+//   required: true
+// - name: to
+//   in: body
+//   description: the address that can claim the money
+//   type: common.Address
+//   required: true
+// - name: hashLock
+//   in: body
+//   description: sha256 hash of the hash key
+//   type: common.Hash
+//   required: true
+// - name: timeLock
+//   in: body
+//   description: the block number after which from can refund the money
+//   type: *big.Int
+//   required: true
+// - name: value
+//   in: body
+//   description: the locked value
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinVenusApi) SendLockTransaction(from, to common.Address, hashLock common.Hash, timeLock, value, gasPrice *big.Int, gasLimit uint64, nonce *uint64) (common.Hash, error) {
+	return api.service.SendLockTransaction(from, to, hashLock, timeLock, value, gasPrice, gasLimit, nonce)
+}
+
+// send claim transaction
+// swagger:operation POST /url/SendClaimTransaction transactionOperation transaction
+// ---
+// summary: send claim transaction
+// description: claim the money locked by alice with the hash key
+// parameters:
+// - name: from
+//   in: body
+//   description: the address that claim the money
+//   type: common.Address
+//   required: true
+// - name: alice
+//   in: body
+//   description: the address that lock the money
+//   type: common.Address
+//   required: true
+// - name: hashKey
+//   in: body
+//   description: the hash key of the hash lock
+//   type: hexutil.Bytes
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinVenusApi) SendClaimTransaction(from, alice common.Address, hashKey hexutil.Bytes, gasPrice *big.Int, gasLimit uint64, nonce *uint64) (common.Hash, error) {
+	return api.service.SendClaimTransaction(from, alice, hashKey, gasPrice, gasLimit, nonce)
+}
+
+// send refund transaction
+// swagger:operation POST /url/SendRefundTransaction transactionOperation transaction
+// ---
+// summary: send refund transaction
+// description: take back the money locked for bob after the time lock expired
+// parameters:
+// - name: from
+//   in: body
+//   description: the address that lock the money
+//   type: common.Address
+//   required: true
+// - name: bob
+//   in: body
+//   description: the address that can claim the money
+//   type: common.Address
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinVenusApi) SendRefundTransaction(from, bob common.Address, gasPrice *big.Int, gasLimit uint64, nonce *uint64) (common.Hash, error) {
+	return api.service.SendRefundTransaction(from, bob, gasPrice, gasLimit, nonce)
+}
+
+// get the lock info of alice and bob
+// swagger:operation POST /url/GetLockInfo transaction information LockInfoResp
+// ---
+// summary: get the lock info of alice and bob
+// description: get the lock address, the locked money, hash lock and time lock
+// parameters:
+// - name: alice
+//   in: body
+//   description: the address that lock the money
+//   type: common.Address
+//   required: true
+// - name: bob
+//   in: body
+//   description: the address that can claim the money
+//   type: common.Address
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        "$ref": "#/responses/LockInfoResp"
+func (api *DipperinVenusApi) GetLockInfo(alice, bob common.Address) (*LockInfoResp, error) {
+	lockAddress, balance, hashLock, timeLock, err := api.service.GetLockInfo(alice, bob)
+	if err != nil {
+		return nil, err
+	}
+	return &LockInfoResp{
+		LockAddress: lockAddress,
+		Balance:     (*hexutil.Big)(balance),
+		HashLock:    hashLock,
+		TimeLock:    (*hexutil.Big)(timeLock),
+	}, nil
+}
+
+// create a multisig account
+// swagger:operation POST /url/CreateMultiSigAccount transactionOperation transaction
+// ---
+// summary: create a multisig account
+// description: send the transaction creating the M-of-N multisig account of the signers
+// parameters:
+// - name: from
+//   in: body
+//   description: the address that funds the multisig account
+//   type: common.Address
+//   required: true
+// - name: threshold
+//   in: body
+//   description: the number of signatures needed to spend from the account
+//   type: uint64
+//   required: true
+// - name: signers
+//   in: body
+//   description: the signer addresses of the account
+//   type: []common.Address
+//   required: true
+// - name: value
+//   in: body
+//   description: the value transferred to the account
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinVenusApi) CreateMultiSigAccount(from common.Address, threshold uint64, signers []common.Address, value, gasPrice *big.Int, gasLimit uint64, nonce *uint64) (common.Hash, error) {
+	return api.service.CreateMultiSigAccount(from, threshold, signers, value, gasPrice, gasLimit, nonce)
+}
+
+// get the multisig account info
+// swagger:operation POST /url/GetMultiSigAccount transaction information MultiSigAccountResp
+// ---
+// summary: get the multisig account info
+// description: get the signers, threshold, balance and nonce of the multisig account
+// parameters:
+// - name: address
+//   in: body
+//   description: the multisig address
+//   type: common.Address
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        "$ref": "#/responses/MultiSigAccountResp"
+func (api *DipperinVenusApi) GetMultiSigAccount(address common.Address) (*MultiSigAccountResp, error) {
+	info, balance, nonce, err := api.service.GetMultiSigAccount(address)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiSigAccountResp{
+		Address:   address,
+		Threshold: info.Threshold,
+		Signers:   info.Signers,
+		Balance:   (*hexutil.Big)(balance),
+		Nonce:     nonce,
+	}, nil
+}
+
+// create a multisig transaction
+// swagger:operation POST /url/NewMultiSigTransaction transactionOperation transaction
+// ---
+// summary: create a multisig transaction
+// description: create the unsigned rlp of a transaction sent from the multisig account
+// parameters:
+// - name: from
+//   in: body
+//   description: the multisig address
+//   type: common.Address
+//   required: true
+// - name: to
+//   in: body
+//   description: the receiver address
+//   type: common.Address
+//   required: true
+// - name: value
+//   in: body
+//   description: the transferred value
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return the transaction rlp and the operation result
+func (api *DipperinVenusApi) NewMultiSigTransaction(from, to common.Address, value, gasPrice *big.Int, gasLimit uint64, data hexutil.Bytes, nonce *uint64) (hexutil.Bytes, error) {
+	tx, err := api.service.NewMultiSigTransaction(from, to, value, gasPrice, gasLimit, data, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(tx)
+}
+
+// sign a multisig transaction
+// swagger:operation POST /url/SignMultiSigTransaction transactionOperation transaction
+// ---
+// summary: sign a multisig transaction
+// description: add the signature of the signer in the wallet to the multisig transaction
+// parameters:
+// - name: signer
+//   in: body
+//   description: the signer address
+//   type: common.Address
+//   required: true
+// - name: transactionRlpB
+//   in: body
+//   description: the transaction rlp
+//   type: hexutil.Bytes
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return the signed transaction rlp and the operation result
+func (api *DipperinVenusApi) SignMultiSigTransaction(signer common.Address, transactionRlpB hexutil.Bytes) (hexutil.Bytes, error) {
+	var tx model.Transaction
+	if err := rlp.DecodeBytes(transactionRlpB, &tx); err != nil {
+		return nil, err
+	}
+	signedTx, err := api.service.SignMultiSigTransaction(signer, &tx)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signedTx)
+}
+
 // get verifiers info by round
 // swagger:operation POST /url/GetVerifiersBySlot verifierInfo verifierInfo
 // ---
@@ -1248,6 +1702,18 @@ func (api *DipperinVenusApi) SubscribeBlock(ctx context.Context) (*rpc.Subscript
 	return api.service.SubscribeBlock(ctx)
 }
 
+func (api *DipperinVenusApi) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	return api.service.NewPendingTransactions(ctx)
+}
+
+func (api *DipperinVenusApi) SubscribeLogs(ctx context.Context, addresses []common.Address, topics [][]common.Hash) (*rpc.Subscription, error) {
+	return api.service.SubscribeLogs(ctx, addresses, topics)
+}
+
+func (api *DipperinVenusApi) SubscribeReceipt(ctx context.Context, txHash common.Hash) (*rpc.Subscription, error) {
+	return api.service.SubscribeReceipt(ctx, txHash)
+}
+
 func (api *DipperinVenusApi) StopDipperin() {
 	api.service.StopDipperin()
 }
@@ -21,13 +21,17 @@ import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/hexutil"
 	"github.com/dipperin/dipperin-core/core/accounts"
 	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
 	"github.com/dipperin/dipperin-core/core/chain/state-processor"
 	"github.com/dipperin/dipperin-core/core/contract"
 	"github.com/dipperin/dipperin-core/core/dipperin/service"
 	"github.com/dipperin/dipperin-core/core/economy-model"
 	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/tx-pool"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/tests/g-mockFile"
 	"github.com/dipperin/dipperin-core/tests/g-testData"
 	"github.com/dipperin/dipperin-core/third-party/p2p/enode"
@@ -36,6 +40,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"math/big"
+	"reflect"
 	"testing"
 )
 
@@ -103,6 +108,8 @@ func TestDipperinMercuryApi(t *testing.T) {
 	mc.EXPECT().GetTransaction(common.Hash{}).Return(&model.Transaction{}, common.Hash{}, uint64(1), uint64(0)).AnyTimes()
 	_, err = api.Transaction(common.Hash{})
 	assert.NoError(t, err)
+	_, err = api.GetTransactionsByAddress(common.Address{}, 0, 10)
+	assert.Equal(t, g_error.ErrTxHistoryDisabled, err)
 	_, err = api.GetTransactionNonce(common.Address{})
 	assert.Error(t, err)
 	_, err = api.NewTransaction([]byte{})
@@ -113,11 +120,23 @@ func TestDipperinMercuryApi(t *testing.T) {
 	_, err = api.NewTransaction(tb)
 	assert.NoError(t, err)
 
+	// multisig transactions
+	_, err = api.GetMultiSigAccount(common.Address{})
+	assert.Error(t, err)
+	_, err = api.NewMultiSigTransaction(common.Address{}, common.Address{}, big.NewInt(1), gasPrice, gasLimit, nil, nil)
+	assert.Error(t, err)
+	_, err = api.SignMultiSigTransaction(common.Address{}, []byte{})
+	assert.Error(t, err)
+
 	// node type
 	mn.EXPECT().GetNodeType().Return(0).AnyTimes()
 	assert.Error(t, api.SetMineCoinBase(common.Address{}))
 	assert.Error(t, api.StartMine())
 	assert.Error(t, api.StopMine())
+	_, err = api.GetWorkerBalance(common.Address{})
+	assert.Error(t, err)
+	_, err = api.GetWorkerBalances()
+	assert.Error(t, err)
 
 	// soft wallet info
 	mn.EXPECT().SoftWalletName().Return("test_wa").AnyTimes()
@@ -254,6 +273,12 @@ func TestDipperinMercuryApi(t *testing.T) {
 	assert.Error(t, err)
 	_, err = api.SubscribeBlock(ctx)
 	assert.Error(t, err)
+	_, err = api.NewPendingTransactions(ctx)
+	assert.Error(t, err)
+	_, err = api.SubscribeLogs(ctx, nil, nil)
+	assert.Error(t, err)
+	_, err = api.SubscribeReceipt(ctx, common.Hash{})
+	assert.Error(t, err)
 	//api.StopDipperin()
 
 	// get abi and logs
@@ -348,6 +373,34 @@ func (p *fakeTxPool) AddRemote(tx model.AbstractTransaction) error {
 	return nil
 }
 
+func (p *fakeTxPool) Pending() (map[common.Address][]model.AbstractTransaction, error) {
+	panic("implement me")
+}
+
+func (p *fakeTxPool) Queueing() (map[common.Address][]model.AbstractTransaction, error) {
+	panic("implement me")
+}
+
+func (p *fakeTxPool) Status(hashes []common.Hash) []tx_pool.TxStatus {
+	panic("implement me")
+}
+
+func (p *fakeTxPool) Get(hash common.Hash) model.AbstractTransaction {
+	panic("implement me")
+}
+
+func (p *fakeTxPool) DropTx(hash common.Hash) error {
+	panic("implement me")
+}
+
+func (p *fakeTxPool) ReplaceTx(hash common.Hash, tx model.AbstractTransaction) error {
+	panic("implement me")
+}
+
+func (p *fakeTxPool) FlushJournal() error {
+	panic("implement me")
+}
+
 type fakeBroadcaster struct{}
 
 func (f *fakeBroadcaster) BroadcastTx(txs []model.AbstractTransaction) {}
@@ -359,3 +412,118 @@ func (f *fakeMsgSigner) SetBaseAddress(address common.Address) {}
 func (f *fakeMsgSigner) GetAddress() common.Address {
 	return common.Address{}
 }
+
+type txHistoryChain struct {
+	*g_mockFile.MockChainInterface
+}
+
+func (chain txHistoryChain) TxHistoryIndexEnabled() bool {
+	return true
+}
+
+func TestDipperinVenusApi_GetTransactionsByAddress(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	key, _ := model.CreateKey()
+	to := common.HexToAddress("0x00001234")
+	tx := model.NewTransaction(0, to, big.NewInt(1), g_testData.TestGasPrice, g_testData.TestGasLimit, nil)
+	tx, err := tx.SignTx(key, model.NewSigner(big.NewInt(1)))
+	assert.NoError(t, err)
+	header := model.NewHeader(1, 1, common.Hash{}, common.Hash{}, common.HexToDiff("1fffffff"), big.NewInt(1), common.Address{}, common.BlockNonceFromInt(1))
+	block := model.NewBlock(header, []*model.Transaction{tx}, nil)
+	db := chaindb.NewChainDB(ethdb.NewMemDatabase(), model.MakeDefaultBlockDecoder())
+	assert.NoError(t, db.SaveAddressTxEntries(block))
+
+	mc := g_mockFile.NewMockChainInterface(controller)
+	mc.EXPECT().GetChainDB().Return(db).AnyTimes()
+	api := &DipperinVenusApi{service: &service.VenusFullChainService{
+		DipperinConfig: &service.DipperinConfig{ChainReader: txHistoryChain{mc}},
+	}}
+
+	mc.EXPECT().GetTransaction(tx.CalTxId()).Return(tx, block.Hash(), uint64(1), uint64(0))
+	resp, err := api.GetTransactionsByAddress(to, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*AddressTxResp{{
+		Transaction: tx,
+		BlockHash:   block.Hash(),
+		BlockNumber: 1,
+		TxIndex:     0,
+		Received:    true,
+	}}, resp)
+
+	resp, err = api.GetTransactionsByAddress(to, 1, 0)
+	assert.NoError(t, err)
+	assert.Len(t, resp, 0)
+}
+
+func TestDipperinVenusApi_ERC20Holders(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	token := common.HexToAddress("0x00100000FA42f7315cD04D6774E58B54e92603e96d84")
+	alice, bob := common.HexToAddress("0x00001234"), common.HexToAddress("0x00005678")
+	erc20 := &contract.BuiltInERC20Token{}
+	erc20.Balances = map[string]*big.Int{alice.Hex(): big.NewInt(1), bob.Hex(): big.NewInt(2)}
+	adb, _ := NewEmptyAccountDB()
+	assert.NoError(t, adb.PutContract(token, reflect.ValueOf(erc20)))
+
+	mc := g_mockFile.NewMockChainInterface(controller)
+	mc.EXPECT().CurrentState().Return(adb, nil).AnyTimes()
+	api := &DipperinVenusApi{service: &service.VenusFullChainService{
+		DipperinConfig: &service.DipperinConfig{ChainReader: mc},
+	}}
+
+	resp, err := api.ERC20Holders(token, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ERC20HolderResp{
+		{Address: bob, Balance: (*hexutil.Big)(big.NewInt(2))},
+		{Address: alice, Balance: (*hexutil.Big)(big.NewInt(1))},
+	}, resp)
+
+	_, err = api.ERC20Holders(alice, 0, 0)
+	assert.Equal(t, g_error.ErrNotERC20Address, err)
+}
+
+func TestDipperinVenusApi_ERC20TransfersByAddress(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	token := common.HexToAddress("0x00100000FA42f7315cD04D6774E58B54e92603e96d84")
+	alice, bob := common.HexToAddress("0x00001234"), common.HexToAddress("0x00005678")
+	txHash := common.HexToHash("1")
+	header := model.NewHeader(1, 1, common.Hash{}, common.Hash{}, common.HexToDiff("1fffffff"), big.NewInt(1), common.Address{}, common.BlockNonceFromInt(1))
+	block := model.NewBlock(header, nil, nil)
+	receipts := model2.Receipts{{Logs: []*model2.Log{{
+		Address: token,
+		Topics:  []common.Hash{contract.ERC20TransferTopic, alice.Hash(), bob.Hash()},
+		Data:    common.LeftPadBytes([]byte{3}, common.HashLength),
+		TxHash:  txHash,
+	}}}}
+	db := chaindb.NewChainDB(ethdb.NewMemDatabase(), model.MakeDefaultBlockDecoder())
+	assert.NoError(t, db.SaveERC20Transfers(block, receipts))
+
+	mc := g_mockFile.NewMockChainInterface(controller)
+	mc.EXPECT().GetChainDB().Return(db).AnyTimes()
+	api := &DipperinVenusApi{service: &service.VenusFullChainService{
+		DipperinConfig: &service.DipperinConfig{ChainReader: mc},
+	}}
+	_, err := api.ERC20TransfersByAddress(bob, 0, 0)
+	assert.Equal(t, g_error.ErrTxHistoryDisabled, err)
+
+	api.service.ChainReader = txHistoryChain{mc}
+	resp, err := api.ERC20TransfersByAddress(bob, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*ERC20TransferResp{{
+		Token:       token,
+		TxHash:      txHash,
+		BlockNumber: 1,
+		From:        alice,
+		To:          bob,
+		Value:       (*hexutil.Big)(big.NewInt(3)),
+	}}, resp)
+
+	resp, err = api.ERC20TransfersByAddress(bob, 1, 0)
+	assert.NoError(t, err)
+	assert.Len(t, resp, 0)
+}
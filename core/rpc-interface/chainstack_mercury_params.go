@@ -41,6 +41,16 @@ type TransactionResp struct {
 	TxIndex     uint64             `json:"transactionIndex"`
 }
 
+// swagger:response AddressTxResp
+type AddressTxResp struct {
+	Transaction *model.Transaction `json:"transaction"`
+	BlockHash   common.Hash        `json:"blockHash"`
+	BlockNumber uint64             `json:"blockNumber"`
+	TxIndex     uint64             `json:"transactionIndex"`
+	Sent        bool               `json:"sent"`
+	Received    bool               `json:"received"`
+}
+
 // swagger:response BlockResp
 type BlockResp struct {
 	Header model.Header `json:"header"`
@@ -56,11 +66,46 @@ type SendTxReq struct {
 	Tx *model.Transaction `json:"tx"`
 }
 
+// swagger:response LockInfoResp
+type LockInfoResp struct {
+	LockAddress common.Address `json:"lockAddress"`
+	Balance     *hexutil.Big   `json:"balance"`
+	HashLock    common.Hash    `json:"hashLock"`
+	TimeLock    *hexutil.Big   `json:"timeLock"`
+}
+
+// swagger:response MultiSigAccountResp
+type MultiSigAccountResp struct {
+	Address   common.Address   `json:"address"`
+	Threshold uint64           `json:"threshold"`
+	Signers   []common.Address `json:"signers"`
+	Balance   *hexutil.Big     `json:"balance"`
+	Nonce     uint64           `json:"nonce"`
+}
+
 type ERC20Resp struct {
 	TxId common.Hash    `json:"txid"`
 	CtId common.Address `json:"ctid"`
 }
 
+// swagger:response ERC20HolderResp
+type ERC20HolderResp struct {
+	Address common.Address `json:"address"`
+	Balance *hexutil.Big   `json:"balance"`
+}
+
+// swagger:response ERC20TransferResp
+type ERC20TransferResp struct {
+	Token       common.Address `json:"token"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	BlockNumber uint64         `json:"blockNumber"`
+	TxIndex     uint64         `json:"transactionIndex"`
+	LogIndex    uint64         `json:"logIndex"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *hexutil.Big   `json:"value"`
+}
+
 //current practical verifiers resp
 type PeerInfoResp struct {
 	NodeId  string
@@ -94,3 +139,23 @@ type VerifierStatus struct {
 	Reputation        uint64
 	IsCurrentVerifier bool
 }
+
+// swagger:response TxPoolContentResp
+type TxPoolContentResp struct {
+	Pending map[common.Address][]*model.Transaction `json:"pending"`
+	Queued  map[common.Address][]*model.Transaction `json:"queued"`
+}
+
+// swagger:response WorkerBalanceResp
+type WorkerBalanceResp struct {
+	Address  common.Address `json:"address"`
+	Balance  *hexutil.Big   `json:"balance"`
+	Immature *hexutil.Big   `json:"immature"`
+	Paid     *hexutil.Big   `json:"paid"`
+}
+
+// swagger:response TxPoolStatsResp
+type TxPoolStatsResp struct {
+	Pending int `json:"pending"`
+	Queued  int `json:"queued"`
+}
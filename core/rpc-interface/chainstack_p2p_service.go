@@ -26,6 +26,9 @@ type P2PAPI interface {
 	RemoveTrustedPeer(url string) error
 	Peers() ([]*p2p.PeerInfo, error)
 	CsPmInfo() (*p2p.CsPmPeerInfo, error)
+	BanPeer(id string, seconds uint64) error
+	UnbanPeer(id string) error
+	BannedPeers() ([]*p2p.BannedPeerInfo, error)
 }
 
 type DipperinP2PApi struct {
@@ -55,3 +58,16 @@ func (api *DipperinP2PApi) Peers() ([]*p2p.PeerInfo, error) {
 func (api *DipperinP2PApi) CsPmInfo() (*p2p.CsPmPeerInfo, error) {
 	return api.service.CsPmInfo()
 }
+
+// BanPeer disconnects the node and refuses it for the given seconds, 0 bans it permanently
+func (api *DipperinP2PApi) BanPeer(id string, seconds uint64) error {
+	return api.service.BanPeer(id, seconds)
+}
+
+func (api *DipperinP2PApi) UnbanPeer(id string) error {
+	return api.service.UnbanPeer(id)
+}
+
+func (api *DipperinP2PApi) BannedPeers() ([]*p2p.BannedPeerInfo, error) {
+	return api.service.BannedPeers()
+}
@@ -32,6 +32,9 @@ func TestDipperinP2PApi_AddPeer(t *testing.T) {
 	mp.EXPECT().RemoveTrustedPeer(gomock.Any()).Return(nil).AnyTimes()
 	mp.EXPECT().Peers().Return(nil, nil).AnyTimes()
 	mp.EXPECT().CsPmInfo().Return(nil, nil).AnyTimes()
+	mp.EXPECT().BanPeer(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mp.EXPECT().UnbanPeer(gomock.Any()).Return(nil).AnyTimes()
+	mp.EXPECT().BannedPeers().Return(nil, nil).AnyTimes()
 
 	s := &DipperinP2PApi{service: mp}
 	assert.NoError(t, s.AddPeer(""))
@@ -42,4 +45,8 @@ func TestDipperinP2PApi_AddPeer(t *testing.T) {
 	assert.NoError(t, err)
 	_, err = s.CsPmInfo()
 	assert.NoError(t, err)
+	assert.NoError(t, s.BanPeer("", 0))
+	assert.NoError(t, s.UnbanPeer(""))
+	_, err = s.BannedPeers()
+	assert.NoError(t, err)
 }
@@ -0,0 +1,90 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rpc_interface
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/tx-pool"
+	"math/big"
+)
+
+type TxPoolAPI interface {
+	TxPoolContent() (pending, queued map[common.Address][]model.AbstractTransaction, err error)
+	TxPoolStatus(hashes []common.Hash) []tx_pool.TxStatus
+	TxPoolStats() (int, int)
+	DropTransaction(txHash common.Hash) error
+	ReplaceTransaction(txHash common.Hash, gasPrice *big.Int) (common.Hash, error)
+	FlushTxJournal() error
+}
+
+// DipperinTxPoolApi is the txpool namespace to inspect and manage the transactions in the tx pool
+type DipperinTxPoolApi struct {
+	service TxPoolAPI
+}
+
+// Content returns the pending and the queued transactions grouped by the senders
+func (api *DipperinTxPoolApi) Content() (*TxPoolContentResp, error) {
+	pending, queued, err := api.service.TxPoolContent()
+	if err != nil {
+		return nil, err
+	}
+	return &TxPoolContentResp{
+		Pending: convertPoolTxs(pending),
+		Queued:  convertPoolTxs(queued),
+	}, nil
+}
+
+// Status returns the status (unknown/queued/pending) of the transactions in the order of the hashes
+func (api *DipperinTxPoolApi) Status(hashes []common.Hash) []string {
+	status := api.service.TxPoolStatus(hashes)
+	result := make([]string, len(status))
+	for i, s := range status {
+		result[i] = s.String()
+	}
+	return result
+}
+
+// Stats returns the number of the pending and the queued transactions
+func (api *DipperinTxPoolApi) Stats() *TxPoolStatsResp {
+	pending, queued := api.service.TxPoolStats()
+	return &TxPoolStatsResp{Pending: pending, Queued: queued}
+}
+
+func (api *DipperinTxPoolApi) DropTransaction(txHash common.Hash) error {
+	return api.service.DropTransaction(txHash)
+}
+
+func (api *DipperinTxPoolApi) ReplaceTransaction(txHash common.Hash, gasPrice *big.Int) (common.Hash, error) {
+	return api.service.ReplaceTransaction(txHash, gasPrice)
+}
+
+func (api *DipperinTxPoolApi) FlushJournal() error {
+	return api.service.FlushTxJournal()
+}
+
+func convertPoolTxs(txs map[common.Address][]model.AbstractTransaction) map[common.Address][]*model.Transaction {
+	result := make(map[common.Address][]*model.Transaction, len(txs))
+	for from, list := range txs {
+		for _, tx := range list {
+			if t, ok := tx.(*model.Transaction); ok {
+				result[from] = append(result[from], t)
+			}
+		}
+	}
+	return result
+}
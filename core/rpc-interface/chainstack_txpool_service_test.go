@@ -0,0 +1,85 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rpc_interface
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/core/tx-pool"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+type fakeTxPoolAPI struct {
+	pending map[common.Address][]model.AbstractTransaction
+	err     error
+}
+
+func (f *fakeTxPoolAPI) TxPoolContent() (pending, queued map[common.Address][]model.AbstractTransaction, err error) {
+	return f.pending, nil, f.err
+}
+
+func (f *fakeTxPoolAPI) TxPoolStatus(hashes []common.Hash) []tx_pool.TxStatus {
+	status := make([]tx_pool.TxStatus, len(hashes))
+	for i := range hashes {
+		status[i] = tx_pool.TxStatusPending
+	}
+	return status
+}
+
+func (f *fakeTxPoolAPI) TxPoolStats() (int, int) {
+	return 1, 0
+}
+
+func (f *fakeTxPoolAPI) DropTransaction(txHash common.Hash) error {
+	return f.err
+}
+
+func (f *fakeTxPoolAPI) ReplaceTransaction(txHash common.Hash, gasPrice *big.Int) (common.Hash, error) {
+	return txHash, f.err
+}
+
+func (f *fakeTxPoolAPI) FlushTxJournal() error {
+	return f.err
+}
+
+func TestDipperinTxPoolApi(t *testing.T) {
+	tx := model.NewTransaction(0, common.HexToAddress("0x1234"), big.NewInt(1), big.NewInt(1), 21000, nil)
+	from := common.HexToAddress("0x5678")
+	f := &fakeTxPoolAPI{pending: map[common.Address][]model.AbstractTransaction{from: {tx}}}
+	api := MakeDipperinTxPoolApi(f)
+
+	content, err := api.Content()
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Transaction{tx}, content.Pending[from])
+	assert.Len(t, content.Queued, 0)
+
+	assert.Equal(t, []string{"pending"}, api.Status([]common.Hash{tx.CalTxId()}))
+	assert.Equal(t, &TxPoolStatsResp{Pending: 1, Queued: 0}, api.Stats())
+	assert.NoError(t, api.DropTransaction(tx.CalTxId()))
+	assert.NoError(t, api.FlushJournal())
+	hash, err := api.ReplaceTransaction(tx.CalTxId(), big.NewInt(2))
+	assert.NoError(t, err)
+	assert.Equal(t, tx.CalTxId(), hash)
+
+	f.err = g_error.ErrTxNotInPool
+	_, err = api.Content()
+	assert.Equal(t, g_error.ErrTxNotInPool, err)
+	assert.Equal(t, g_error.ErrTxNotInPool, api.DropTransaction(tx.CalTxId()))
+}
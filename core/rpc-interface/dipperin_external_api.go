@@ -343,3 +343,15 @@ func (api *DipperExternalApi) NewBlock(ctx context.Context) (*rpc.Subscription,
 func (api *DipperExternalApi) SubscribeBlock(ctx context.Context) (*rpc.Subscription, error) {
 	return api.allApis.SubscribeBlock(ctx)
 }
+
+func (api *DipperExternalApi) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	return api.allApis.NewPendingTransactions(ctx)
+}
+
+func (api *DipperExternalApi) SubscribeLogs(ctx context.Context, addresses []common.Address, topics [][]common.Hash) (*rpc.Subscription, error) {
+	return api.allApis.SubscribeLogs(ctx, addresses, topics)
+}
+
+func (api *DipperExternalApi) SubscribeReceipt(ctx context.Context, txHash common.Hash) (*rpc.Subscription, error) {
+	return api.allApis.SubscribeReceipt(ctx, txHash)
+}
@@ -61,6 +61,35 @@ func (mr *MockP2PAPIMockRecorder) AddTrustedPeer(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTrustedPeer", reflect.TypeOf((*MockP2PAPI)(nil).AddTrustedPeer), arg0)
 }
 
+// BanPeer mocks base method
+func (m *MockP2PAPI) BanPeer(arg0 string, arg1 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BanPeer", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BanPeer indicates an expected call of BanPeer
+func (mr *MockP2PAPIMockRecorder) BanPeer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanPeer", reflect.TypeOf((*MockP2PAPI)(nil).BanPeer), arg0, arg1)
+}
+
+// BannedPeers mocks base method
+func (m *MockP2PAPI) BannedPeers() ([]*p2p.BannedPeerInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BannedPeers")
+	ret0, _ := ret[0].([]*p2p.BannedPeerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BannedPeers indicates an expected call of BannedPeers
+func (mr *MockP2PAPIMockRecorder) BannedPeers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BannedPeers", reflect.TypeOf((*MockP2PAPI)(nil).BannedPeers))
+}
+
 // CsPmInfo mocks base method
 func (m *MockP2PAPI) CsPmInfo() (*p2p.CsPmPeerInfo, error) {
 	m.ctrl.T.Helper()
@@ -118,3 +147,17 @@ func (mr *MockP2PAPIMockRecorder) RemoveTrustedPeer(arg0 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveTrustedPeer", reflect.TypeOf((*MockP2PAPI)(nil).RemoveTrustedPeer), arg0)
 }
+
+// UnbanPeer mocks base method
+func (m *MockP2PAPI) UnbanPeer(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanPeer", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnbanPeer indicates an expected call of UnbanPeer
+func (mr *MockP2PAPIMockRecorder) UnbanPeer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanPeer", reflect.TypeOf((*MockP2PAPI)(nil).UnbanPeer), arg0)
+}
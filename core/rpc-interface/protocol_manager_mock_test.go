@@ -171,6 +171,18 @@ func (mr *MockAbstractPbftProtocolManagerMockRecorder) RemovePeer(arg0 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePeer", reflect.TypeOf((*MockAbstractPbftProtocolManager)(nil).RemovePeer), arg0)
 }
 
+// ReportPeerFault mocks base method
+func (m *MockAbstractPbftProtocolManager) ReportPeerFault(arg0 string, arg1 chain_communication.PeerFault) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReportPeerFault", arg0, arg1)
+}
+
+// ReportPeerFault indicates an expected call of ReportPeerFault
+func (mr *MockAbstractPbftProtocolManagerMockRecorder) ReportPeerFault(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportPeerFault", reflect.TypeOf((*MockAbstractPbftProtocolManager)(nil).ReportPeerFault), arg0, arg1)
+}
+
 // SelfIsBootNode mocks base method
 func (m *MockAbstractPbftProtocolManager) SelfIsBootNode() bool {
 	m.ctrl.T.Helper()
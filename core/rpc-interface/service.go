@@ -44,6 +44,10 @@ type Service struct {
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
 	allowHosts []string
+
+	// the modules exposed on the http and websocket endpoints and the guard checking their calls, nil for no check
+	modules []string
+	guard   rpc.Guard
 }
 
 func (service *Service) GetInProcHandler() *rpc.Server {
@@ -55,6 +59,20 @@ func (service *Service) AddApis(apis []rpc.API) {
 	service.apis = append(service.apis, apis...)
 }
 
+// SetAccessConfig sets the access control of the http and websocket endpoints, it must be called before Start
+func (service *Service) SetAccessConfig(conf *AccessConfig) error {
+	if conf == nil {
+		service.modules, service.guard = nil, nil
+		return nil
+	}
+	guard, err := newAccessGuard(conf)
+	if err != nil {
+		return err
+	}
+	service.modules, service.guard = conf.Modules, guard
+	return nil
+}
+
 func (service *Service) Start() error {
 	log.Info("start rpc service")
 	if err := service.startInProc(service.apis); err != nil {
@@ -67,13 +85,13 @@ func (service *Service) Start() error {
 		return err
 	}
 	log.Info("start http service")
-	if err := service.startHTTP(service.httpEndpoint, service.apis, []string{}, service.allowHosts, service.allowHosts); err != nil {
+	if err := service.startHTTP(service.httpEndpoint, service.apis, service.modules, service.allowHosts, service.allowHosts); err != nil {
 		service.stopInProc()
 		service.stopIPC()
 		return err
 	}
 	log.Info("start websocket", "allow hosts", service.allowHosts)
-	if err := service.startWS(service.wsEndpoint, service.apis, []string{}, service.allowHosts, false); err != nil {
+	if err := service.startWS(service.wsEndpoint, service.apis, service.modules, service.allowHosts, false); err != nil {
 		service.stopInProc()
 		service.stopIPC()
 		service.stopHTTP()
@@ -121,7 +139,7 @@ func (service *Service) startHTTP(endpoint string, apis []rpc.API, modules []str
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 5 * time.Second,
 		IdleTimeout:  50 * time.Second,
-	})
+	}, service.guard)
 	if err != nil {
 		return err
 	}
@@ -149,7 +167,7 @@ func (service *Service) startWS(endpoint string, apis []rpc.API, modules []strin
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, service.guard)
 	if err != nil {
 		return err
 	}
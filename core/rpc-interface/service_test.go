@@ -18,6 +18,9 @@ package rpc_interface
 
 import (
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -52,6 +55,56 @@ func TestService(t *testing.T) {
 	s.Stop()
 }
 
+func TestService_SetAccessConfig(t *testing.T) {
+	s := &Service{
+		httpEndpoint: "127.0.0.1:15216",
+		allowHosts:   []string{"*"},
+		apis: []rpc.API{
+			{Namespace: "test", Version: "0.0.1", Service: &FakeAPI{}, Public: true},
+			{Namespace: "dipperin", Version: "0.0.1", Service: &FakeWalletAPI{}, Public: false},
+		},
+	}
+	assert.Error(t, s.SetAccessConfig(&AccessConfig{Rules: map[string]string{"test": "private"}}))
+	assert.NoError(t, s.SetAccessConfig(&AccessConfig{
+		Modules:      []string{"test", "dipperin"},
+		ApiKeys:      map[string]string{"wallet": "secret-key"},
+		Rules:        map[string]string{"test": AccessPublic},
+		MaxBatchSize: 2,
+	}))
+	assert.NoError(t, s.Start())
+	defer s.Stop()
+
+	post := func(body, auth string) string {
+		req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1:15216", strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Contains(t, post(`{"jsonrpc":"2.0","id":1,"method":"test_getNum"}`, ""), `"result":1`)
+	assert.Contains(t, post(`{"jsonrpc":"2.0","id":1,"method":"dipperin_openWallet"}`, ""), `"code":-32001`)
+	assert.Contains(t, post(`{"jsonrpc":"2.0","id":1,"method":"dipperin_openWallet"}`, "Bearer secret-key"), `"result":true`)
+
+	batch := post(`[{"jsonrpc":"2.0","id":1,"method":"test_getNum"},{"jsonrpc":"2.0","id":2,"method":"dipperin_openWallet"}]`, "")
+	assert.Contains(t, batch, `"result":1`)
+	assert.Contains(t, batch, `"code":-32001`)
+	assert.Contains(t, post(`[{"jsonrpc":"2.0","id":1,"method":"test_getNum"},{"jsonrpc":"2.0","id":2,"method":"test_getNum"},{"jsonrpc":"2.0","id":3,"method":"test_getNum"}]`, ""), `"code":-32005`)
+}
+
+type FakeWalletAPI struct{}
+
+func (f *FakeWalletAPI) OpenWallet() bool {
+	return true
+}
+
 type FakeAPI struct{}
 
 func (f *FakeAPI) GetNum() uint64 {
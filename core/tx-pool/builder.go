@@ -18,6 +18,7 @@ package tx_pool
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-event"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
@@ -41,6 +42,7 @@ func NewTxPool(config TxPoolConfig, chainConfig chain_config.ChainConfig, chain
 
 		all: newTxLookup(),
 	}
+	g_event.Add(g_event.NewTxPoolEvent)
 	pool.locals = newAccountSet(pool.signer)
 	pool.feeList = newTxFeeList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header().(*model.Header))
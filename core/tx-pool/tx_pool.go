@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/g-event"
 	"github.com/dipperin/dipperin-core/common/util"
 	"github.com/dipperin/dipperin-core/core/bloom"
 	"github.com/dipperin/dipperin-core/core/chain-config"
@@ -283,6 +285,17 @@ const (
 	TxStatusPending
 )
 
+func (s TxStatus) String() string {
+	switch s {
+	case TxStatusQueued:
+		return "queued"
+	case TxStatusPending:
+		return "pending"
+	default:
+		return "unknown"
+	}
+}
+
 // Status returns the status (unknown/pending/queued) of a batch of transactions
 // identified by their hashes.
 func (pool *TxPool) Status(hashes []common.Hash) []TxStatus {
@@ -361,13 +374,13 @@ func (pool *TxPool) validateTx(tx model.AbstractTransaction, local bool) error {
 	// Transactions can't be negative. This may never happen using RLP decoded
 	// transactions but may occur if you create a transaction using the RPC.
 	if tx.Amount().Sign() < 0 {
-		return errors.New("tx value can not be negtive")
+		return g_error.ErrTxNegativeValue
 	}
 	// Make sure the transaction is signed properly
 	from, err := tx.Sender(pool.signer)
 	if err != nil {
 		log.Error("txPool validateTx the err is:", "err", err)
-		return errors.New("invalid sender")
+		return g_error.ErrTxInvalidSender
 	}
 	// Drop non-local transactions under our own minimal accepted gas price
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
@@ -375,10 +388,15 @@ func (pool *TxPool) validateTx(tx model.AbstractTransaction, local bool) error {
 	//log.Info("[validateTx] the pool.config.MinFee is: ", "mineFee", pool.config.MinFee)
 	//log.Info("[validateTx] the tx.fee is: ", "txFee", tx.Fee())
 
-	gas, err := model.IntrinsicGas(tx.ExtraData(), tx.GetType() == common.AddressTypeContractCreate, true)
+	// the transaction is packed into the next block
+	earth := pool.chainConfig.IsEarth(pool.chain.CurrentBlock().Number() + 1)
+	gas, err := model.IntrinsicGas(tx.ExtraData(), tx.GetType() == common.AddressTypeContractCreate, true, earth)
 	if err != nil {
 		return err
 	}
+	if pool.chainConfig.IsEris(pool.chain.CurrentBlock().Number() + 1) {
+		gas += model.MultiSigRecoverGas(tx)
+	}
 
 	if gas > tx.GetGasLimit() {
 		return fmt.Errorf("gas limit is to low, need:%v got:%v", gas, tx.GetGasLimit())
@@ -466,6 +484,7 @@ func (pool *TxPool) add(tx model.AbstractTransaction, local bool) (bool, error)
 		pool.journalTx(from, tx)
 
 		log.Debug("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
+		go g_event.Send(g_event.NewTxPoolEvent, []model.AbstractTransaction{tx})
 		return old != nil, nil
 	}
 
@@ -580,7 +599,7 @@ func (a addressesByHeartbeat) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 
 func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 	// Track the promoted transactions to broadcast them at once
-	//var promoted []model.AbstractTransaction
+	var promoted []model.AbstractTransaction
 
 	// if accounts is nil then go through the whole pool and generate the accounts set.
 	if accounts == nil {
@@ -623,7 +642,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				hash := tx.CalTxId()
 				if pool.promoteTx(addr, hash, tx) {
 					//log.Debug("Promoting queued transaction", "hash", hash)
-					//promoted = append(promoted, tx)
+					promoted = append(promoted, tx)
 				}
 			}
 		}
@@ -642,6 +661,10 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			delete(pool.queue, addr)
 		}
 	}
+	// Notify the subscribers of the new pending transactions
+	if len(promoted) > 0 {
+		go g_event.Send(g_event.NewTxPoolEvent, promoted)
+	}
 
 	// If the pending limit is overflown, start equalizing allowances
 	pending := uint64(0)
@@ -882,6 +905,64 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 	}
 }
 
+// DropTx removes the transaction from the pool, the following transactions of the
+// same sender are moved back to the future queue.
+func (pool *TxPool) DropTx(hash common.Hash) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.all.Get(hash) == nil {
+		return g_error.ErrTxNotInPool
+	}
+	pool.removeTx(hash, true)
+	log.Info("Dropped transaction from the pool", "hash", hash)
+	return nil
+}
+
+// ReplaceTx replaces the pooled transaction with a transaction of the same sender and nonce,
+// the gas price of the new transaction must be bumped by FeeBump percent at least.
+func (pool *TxPool) ReplaceTx(hash common.Hash, tx model.AbstractTransaction) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	old := pool.all.Get(hash)
+	if old == nil {
+		return g_error.ErrTxNotInPool
+	}
+	oldFrom, _ := old.Sender(pool.signer) // already validated
+	from, err := tx.Sender(pool.signer)
+	if err != nil {
+		return err
+	}
+	if !from.IsEqual(oldFrom) || tx.Nonce() != old.Nonce() {
+		return g_error.ErrReplaceTxNotMatch
+	}
+
+	threshold := new(big.Int).Div(new(big.Int).Mul(old.GetGasPrice(), big.NewInt(100+int64(pool.config.FeeBump))), big.NewInt(100))
+	if old.GetGasPrice().Cmp(tx.GetGasPrice()) >= 0 || threshold.Cmp(tx.GetGasPrice()) > 0 {
+		return g_error.ErrReplaceUnderpriced
+	}
+
+	// the old transaction is replaced in the pending list or the future queue, no promotion is needed
+	if _, err := pool.add(tx, !pool.config.NoLocals); err != nil {
+		return err
+	}
+	log.Info("Replaced transaction in the pool", "old", hash, "new", tx.CalTxId(), "gasPrice", tx.GetGasPrice())
+	return nil
+}
+
+// FlushJournal regenerates the local transaction journal with the local transactions in the
+// pool, so the dropped transactions aren't loaded again after a restart.
+func (pool *TxPool) FlushJournal() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.journal == nil {
+		return g_error.ErrTxPoolNoJournal
+	}
+	return pool.journal.rotate(pool.local())
+}
+
 // addTx enqueues a single transaction into the pool if it is valid.
 func (pool *TxPool) addTx(tx model.AbstractTransaction, local bool) error {
 	pool.mu.Lock()
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/consts"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/g-event"
 	"github.com/dipperin/dipperin-core/common/util"
 	"github.com/dipperin/dipperin-core/core/bloom"
 	"github.com/dipperin/dipperin-core/core/chain-config"
@@ -37,6 +39,7 @@ import (
 	"golang.org/x/crypto/sha3"
 	"hash"
 	"math/big"
+	"os"
 	"runtime"
 	"sync"
 	"testing"
@@ -512,6 +515,109 @@ func TestTxPoolAdd(t *testing.T) {
 
 }
 
+func TestTxPool_NewTxPoolEvent(t *testing.T) {
+	pool := setupTxPool()
+	_, key2, _ := createKey()
+
+	txsCh := make(chan []model.AbstractTransaction, 1)
+	sub := g_event.Subscribe(g_event.NewTxPoolEvent, txsCh)
+	defer sub.Unsubscribe()
+
+	// waitTxs skips the events sent by the pools of other tests
+	waitTxs := func(txs ...model.AbstractTransaction) bool {
+		wanted := make(map[common.Hash]bool)
+		for _, tx := range txs {
+			wanted[tx.CalTxId()] = true
+		}
+		timeout := time.After(time.Second)
+		for len(wanted) > 0 {
+			select {
+			case received := <-txsCh:
+				for _, tx := range received {
+					delete(wanted, tx.CalTxId())
+				}
+			case <-timeout:
+				return false
+			}
+		}
+		return true
+	}
+
+	// the transaction with a nonce gap stays in the queue
+	to := common.HexToAddress("0x00007e3E2D2F0a6A5eF4B2Bc1E0d47C55e1c6B0D1E25")
+	bobtx1 := transaction(30, to, big.NewInt(3000), testTxFee, g_testData.TestGasLimit, key2)
+	bobtx2 := transaction(31, to, big.NewInt(3000), testTxFee, g_testData.TestGasLimit, key2)
+	assert.NoError(t, pool.AddRemote(bobtx2))
+	assert.False(t, waitTxs(bobtx2))
+
+	// both transactions are promoted to the pending list
+	assert.NoError(t, pool.AddRemote(bobtx1))
+	assert.True(t, waitTxs(bobtx1, bobtx2))
+}
+
+func TestTxPool_DropTx(t *testing.T) {
+	pool := setupTxPool()
+	key1, key2, _ := createKey()
+	aliceAddr := cs_crypto.GetNormalAddress(key1.PublicKey)
+
+	bobtx1 := transaction(30, aliceAddr, big.NewInt(3000), testTxFee, g_testData.TestGasLimit, key2)
+	bobtx2 := transaction(31, aliceAddr, big.NewInt(3000), testTxFee, g_testData.TestGasLimit, key2)
+	assert.NoError(t, pool.AddRemote(bobtx1))
+	assert.NoError(t, pool.AddRemote(bobtx2))
+	assert.Equal(t, []TxStatus{TxStatusPending, TxStatusPending}, pool.Status([]common.Hash{bobtx1.CalTxId(), bobtx2.CalTxId()}))
+
+	assert.Equal(t, g_error.ErrTxNotInPool, pool.DropTx(common.Hash{}))
+
+	// the following transaction is moved back to the queue
+	assert.NoError(t, pool.DropTx(bobtx1.CalTxId()))
+	assert.Equal(t, []TxStatus{TxStatusUnknown, TxStatusQueued}, pool.Status([]common.Hash{bobtx1.CalTxId(), bobtx2.CalTxId()}))
+	assert.Equal(t, "unknown", TxStatusUnknown.String())
+	assert.Equal(t, "queued", TxStatusQueued.String())
+	assert.Equal(t, "pending", TxStatusPending.String())
+}
+
+func TestTxPool_ReplaceTx(t *testing.T) {
+	pool := setupTxPool()
+	key1, key2, _ := createKey()
+	aliceAddr := cs_crypto.GetNormalAddress(key1.PublicKey)
+
+	bobtx1 := transaction(30, aliceAddr, big.NewInt(3000), testTxFee, g_testData.TestGasLimit, key2)
+	bobtx2 := transaction(32, aliceAddr, big.NewInt(3000), testTxFee, g_testData.TestGasLimit, key2)
+	assert.NoError(t, pool.AddRemote(bobtx1))
+	assert.NoError(t, pool.AddRemote(bobtx2))
+
+	higherFee := new(big.Int).Mul(testTxFee, big.NewInt(2))
+	replaced := transaction(30, aliceAddr, big.NewInt(3000), higherFee, g_testData.TestGasLimit, key2)
+	assert.Equal(t, g_error.ErrTxNotInPool, pool.ReplaceTx(common.Hash{}, replaced))
+	assert.Equal(t, g_error.ErrReplaceTxNotMatch, pool.ReplaceTx(bobtx2.CalTxId(), replaced))
+	assert.Equal(t, g_error.ErrReplaceTxNotMatch, pool.ReplaceTx(bobtx1.CalTxId(), transaction(30, aliceAddr, big.NewInt(3000), higherFee, g_testData.TestGasLimit, key1)))
+
+	// the gas price must be bumped by FeeBump percent
+	underpriced := transaction(30, aliceAddr, big.NewInt(2000), testTxFee, g_testData.TestGasLimit, key2)
+	assert.Equal(t, g_error.ErrReplaceUnderpriced, pool.ReplaceTx(bobtx1.CalTxId(), underpriced))
+
+	// replace the pending transaction
+	assert.NoError(t, pool.ReplaceTx(bobtx1.CalTxId(), replaced))
+	assert.Equal(t, []TxStatus{TxStatusUnknown, TxStatusPending}, pool.Status([]common.Hash{bobtx1.CalTxId(), replaced.CalTxId()}))
+
+	// replace the queued transaction
+	replaced = transaction(32, aliceAddr, big.NewInt(3000), higherFee, g_testData.TestGasLimit, key2)
+	assert.NoError(t, pool.ReplaceTx(bobtx2.CalTxId(), replaced))
+	assert.Equal(t, []TxStatus{TxStatusUnknown, TxStatusQueued}, pool.Status([]common.Hash{bobtx2.CalTxId(), replaced.CalTxId()}))
+}
+
+func TestTxPool_FlushJournal(t *testing.T) {
+	pool := setupTxPool()
+	assert.Equal(t, g_error.ErrTxPoolNoJournal, pool.FlushJournal())
+
+	journalPath := "./transaction_flush.out"
+	defer os.Remove(journalPath)
+	pool.journal = newTxJournal(journalPath)
+	assert.NoError(t, pool.FlushJournal())
+	_, err := os.Stat(journalPath)
+	assert.NoError(t, err)
+}
+
 func TestStatus(t *testing.T) {
 	pool := setupTxPool()
 	key1, key2, _ := createKey()
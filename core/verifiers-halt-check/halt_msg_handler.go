@@ -161,6 +161,7 @@ func (handler *VBHaltHandler) MinProposalMsg() ProposalMsg {
 //alive verifier halt handler
 type AliveVerHaltHandler struct {
 	signHashFunc     SignHashFunc
+	signVoteFunc     VoteSignFunc
 	ownAddress       common.Address
 	receivedProposal ProposalMsg
 	ownVote          model.VoteMsg
@@ -178,6 +179,9 @@ func (handler *AliveVerHaltHandler) OnMinimalHashBlock(selectedProposal Proposal
 
 	handler.receivedProposal = selectedProposal
 
+	if handler.signVoteFunc != nil {
+		return GenVoteMsgWithSigner(&selectedProposal.EmptyBlock, handler.signVoteFunc, model.AliveVerifierVoteMessage)
+	}
 	return GenVoteMsg(&selectedProposal.EmptyBlock, handler.signHashFunc, handler.ownAddress, model.AliveVerifierVoteMessage)
 
 }
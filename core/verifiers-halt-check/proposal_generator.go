@@ -44,8 +44,9 @@ type ProposalGeneratorConfig struct {
 	LastVerifications []model.AbstractVerification
 	PubKey            []byte
 
-	// vote msg need
+	// vote msg need, SignVoteFunc is used instead of the SignHashFunc if it's set
 	SignHashFunc SignHashFunc
+	SignVoteFunc VoteSignFunc
 
 	//process account and register need
 	ProcessStateFunc ProcessFunc
@@ -64,7 +65,12 @@ func (g *ProposalGenerator) GenProposal() (*ProposalMsg, error) {
 	if err != nil {
 		return nil, err
 	}
-	vm, err := GenVoteMsg(emptyBlock, g.SignHashFunc, g.getAddress(), g.VoteType)
+	var vm *model.VoteMsg
+	if g.SignVoteFunc != nil {
+		vm, err = GenVoteMsgWithSigner(emptyBlock, g.SignVoteFunc, g.VoteType)
+	} else {
+		vm, err = GenVoteMsg(emptyBlock, g.SignHashFunc, g.getAddress(), g.VoteType)
+	}
 	if err != nil {
 		return nil, err
 	}
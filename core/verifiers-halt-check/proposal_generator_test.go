@@ -69,3 +69,23 @@ func TestProposalGenerator_GenProposalMsg(t *testing.T) {
 	_, err := MakeTestProposalMsg(0)
 	assert.NoError(t, err)
 }
+
+func TestProposalGenerator_SignVoteFunc(t *testing.T) {
+	config := MakeTestProposalConfig(model.VerBootNodeVoteMessage, 0)
+	config.SignHashFunc = nil
+	var signed []*model.VoteMsg
+	config.SignVoteFunc = func(vote *model.VoteMsg) (*model.WitMsg, error) {
+		signed = append(signed, vote)
+		sign, err := verBootSignForTest(vote.Hash().Bytes())
+		if err != nil {
+			return nil, err
+		}
+		return &model.WitMsg{Address: testVerBootAccounts[0].Address(), Sign: sign}, nil
+	}
+
+	proposal, err := verifiers_halt_check.GenProposalMsg(config)
+	assert.NoError(t, err)
+	assert.Len(t, signed, 1)
+	assert.Equal(t, proposal.EmptyBlock.Hash(), signed[0].BlockID)
+	assert.NoError(t, proposal.VoteMsg.HaltedVoteValid(nil))
+}
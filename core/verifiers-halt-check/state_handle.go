@@ -95,6 +95,7 @@ func (haltCheckStateHandle *StateHandler) GenProposalConfig(voteType model.VoteM
 		LastVerifications: verifications,
 		PubKey:            crypto.FromECDSAPub(haltCheckStateHandle.walletSigner.PublicKey()),
 		SignHashFunc:      haltCheckStateHandle.walletSigner.SignHash,
+		SignVoteFunc:      voteSignFunc(haltCheckStateHandle.walletSigner),
 		ProcessStateFunc:  haltCheckStateHandle.ProcessAccountAndRegisterState,
 		VoteType:          voteType,
 	}
@@ -310,6 +310,7 @@ func (systemHaltedCheck *SystemHaltedCheck) onSendMinimalHashBlock(msg p2p.Msg,
 	log.Halt.Info("received minimal hash block", "blockHash", selectedProposal.EmptyBlock.Hash().Hex(), "nodeName", p.NodeName())
 	// new AliveVerHaltHandler to valid and response the minimal hash block
 	aliveVerHandler := NewAliveVerHaltHandler(systemHaltedCheck.haltCheckStateHandle.walletSigner.SignHash, systemHaltedCheck.haltCheckStateHandle.walletSigner.GetAddress())
+	aliveVerHandler.signVoteFunc = voteSignFunc(systemHaltedCheck.haltCheckStateHandle.walletSigner)
 	vote, err := aliveVerHandler.OnMinimalHashBlock(selectedProposal)
 	if err != nil {
 		log.Halt.Warn("generateEmptyVoteMsg failed", "err", err)
@@ -24,7 +24,39 @@ import (
 	"time"
 )
 
+// VoteSignFunc signs the whole vote, it's used instead of the SignHashFunc with the signers that don't sign raw hashes
+type VoteSignFunc func(vote *model.VoteMsg) (*model.WitMsg, error)
+
+// VoteSigner is implemented by the wallet signers that sign the whole vote themselves, like a remote signer
+type VoteSigner interface {
+	SignVote(vote *model.VoteMsg, withBls bool) (*model.WitMsg, error)
+}
+
+// voteSignFunc returns the VoteSignFunc of the signer, nil if it signs the hashes
+func voteSignFunc(signer NeedWalletSigner) VoteSignFunc {
+	if s, ok := signer.(VoteSigner); ok {
+		return func(vote *model.VoteMsg) (*model.WitMsg, error) {
+			return s.SignVote(vote, false)
+		}
+	}
+	return nil
+}
+
 func GenVoteMsg(emptyBlock *model.Block, signFunc SignHashFunc, addr common.Address, voteType model.VoteMsgType) (*model.VoteMsg, error) {
+	log.Halt.Info("generate empty vote", "address", addr)
+	return GenVoteMsgWithSigner(emptyBlock, func(vote *model.VoteMsg) (*model.WitMsg, error) {
+		sign, err := signFunc(vote.Hash().Bytes())
+		if err != nil {
+			return nil, err
+		}
+		return &model.WitMsg{
+			Address: addr,
+			Sign:    sign,
+		}, nil
+	}, voteType)
+}
+
+func GenVoteMsgWithSigner(emptyBlock *model.Block, signVote VoteSignFunc, voteType model.VoteMsgType) (*model.VoteMsg, error) {
 	//generate empty block verification and send to the verification boot node
 	vote := &model.VoteMsg{
 		Height:    emptyBlock.Number(),
@@ -35,16 +67,12 @@ func GenVoteMsg(emptyBlock *model.Block, signFunc SignHashFunc, addr common.Addr
 
 	log.Halt.Info("the voteMsg blockID is", "BlockID", vote.BlockID.Hex(), "height", vote.Height)
 	// sign msg
-	log.Halt.Info("generate empty vote", "address", addr)
-	sign, err := signFunc(vote.Hash().Bytes())
+	witness, err := signVote(vote)
 	if err != nil {
 		log.Halt.Warn("sign aliveVerifierVote msg failed", "err", err)
 		return nil, err
 	}
-	vote.Witness = &model.WitMsg{
-		Address: addr,
-		Sign:    sign,
-	}
+	vote.Witness = witness
 
 	return vote, nil
 }
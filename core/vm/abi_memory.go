@@ -0,0 +1,328 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/vm/common/utils"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/core/vm/resolver"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// The abi params after the Jupiter fork are passed to the wasm functions as below:
+//
+//	int, bool, float   by value
+//	string             pointer to the NUL terminated string
+//	address            pointer to the 22 address bytes
+//	uint128, uint256   pointer to the 16/32 little endian bytes
+//	bytes              pointer to [uint32 length][data]
+//	T[]                pointer to [uint32 count][8 bytes slot]...
+//	T[N]               pointer to N slots of 8 bytes
+//	struct             pointer to the slots of the components
+//
+// A slot holds the little endian int64 param of the element, so the nested
+// strings, addresses and arrays are pointers as well.
+//
+// The returned value is read back from the memory, at most maxAbiElems
+// elements nested no deeper than maxAbiDepth are read.
+const (
+	abiSlotSize = 8
+	maxAbiDepth = 16
+	maxAbiElems = 1 << 16
+)
+
+var (
+	errInvalidAbiParam  = errors.New("interpreter_life: invalid abi param")
+	errInvalidAbiMemory = errors.New("interpreter_life: abi value out of memory")
+	errAbiValueTooLarge = errors.New("interpreter_life: abi value too large")
+)
+
+// marshalAbiParam copies the rlp value of the abi type into the vm memory and
+// returns the param passed to the wasm function
+func marshalAbiParam(vm *exec.VirtualMachine, t *utils.AbiType, value interface{}) (int64, error) {
+	if list, ok := value.([]interface{}); ok {
+		types, err := abiElemTypes(t, len(list))
+		if err != nil {
+			return 0, err
+		}
+
+		var header []byte
+		if t.Kind == utils.AbiSlice {
+			header = make([]byte, 4)
+			binary.LittleEndian.PutUint32(header, uint32(len(list)))
+		}
+
+		slots := make([]byte, len(header)+len(list)*abiSlotSize)
+		copy(slots, header)
+		for i, v := range list {
+			param, err := marshalAbiParam(vm, types[i], v)
+			if err != nil {
+				return 0, err
+			}
+			binary.LittleEndian.PutUint64(slots[len(header)+i*abiSlotSize:], uint64(param))
+		}
+		return resolver.MallocBytes(vm, slots), nil
+	}
+
+	input, ok := value.([]byte)
+	if !ok {
+		return 0, errInvalidAbiParam
+	}
+
+	switch t.Kind {
+	case utils.AbiInt:
+		if len(input) != t.Size {
+			return 0, errInvalidAbiParam
+		}
+		return int64(utils.BytesToUint64(input)), nil
+	case utils.AbiFloat:
+		if len(input) != t.Size {
+			return 0, errInvalidAbiParam
+		}
+		if t.Size == 4 {
+			return int64(binary.LittleEndian.Uint32(input)), nil
+		}
+		return int64(binary.LittleEndian.Uint64(input)), nil
+	case utils.AbiString:
+		return resolver.MallocString(vm, string(input)), nil
+	case utils.AbiAddress:
+		if len(input) != t.Size {
+			return 0, errInvalidAbiParam
+		}
+		return resolver.MallocBytes(vm, input), nil
+	case utils.AbiBigInt:
+		if len(input) > t.Size {
+			return 0, errInvalidAbiParam
+		}
+		little := make([]byte, t.Size)
+		for i, b := range input {
+			little[len(input)-1-i] = b
+		}
+		return resolver.MallocBytes(vm, little), nil
+	case utils.AbiBytes:
+		data := make([]byte, 4+len(input))
+		binary.LittleEndian.PutUint32(data, uint32(len(input)))
+		copy(data[4:], input)
+		return resolver.MallocBytes(vm, data), nil
+	}
+	return 0, errInvalidAbiParam
+}
+
+// abiReader reads the abi values from the wasm memory, the values are controlled
+// by the contract, so the elements and copied bytes are charged and limited
+type abiReader struct {
+	memory []byte
+	useGas func(uint64) bool
+	elems  int
+}
+
+func newAbiReader(vm *exec.VirtualMachine, useGas func(uint64) bool) *abiReader {
+	return &abiReader{memory: vm.Memory.Memory, useGas: useGas}
+}
+
+// readAbiValue reads the value of the abi type from the wasm param or slot,
+// the value is encoded the same as the rlp input
+func readAbiValue(vm *exec.VirtualMachine, t *utils.AbiType, param int64, useGas func(uint64) bool) (interface{}, error) {
+	return newAbiReader(vm, useGas).readValue(t, param, 0)
+}
+
+func (r *abiReader) read(offset, size int) ([]byte, error) {
+	if offset < 0 || size < 0 || offset > len(r.memory) || size > len(r.memory)-offset {
+		return nil, errInvalidAbiMemory
+	}
+	return r.memory[offset : offset+size], nil
+}
+
+// charge uses the gas of the elements and the copied bytes
+func (r *abiReader) charge(elems int, size int) error {
+	r.elems += elems
+	if r.elems > maxAbiElems {
+		return errAbiValueTooLarge
+	}
+	gas := uint64(elems)*model2.AbiElemGas + (uint64(size)+31)/32*model2.CopyGas
+	if !r.useGas(gas) {
+		return g_error.ErrOutOfGas
+	}
+	return nil
+}
+
+func (r *abiReader) copy(data []byte) ([]byte, error) {
+	if err := r.charge(1, len(data)); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, data...), nil
+}
+
+func (r *abiReader) readValue(t *utils.AbiType, param int64, depth int) (interface{}, error) {
+	if depth > maxAbiDepth {
+		return nil, errAbiValueTooLarge
+	}
+	pos := int(uint32(param))
+
+	switch t.Kind {
+	case utils.AbiInt:
+		return r.copy(utils.Uint64ToBytes(uint64(param))[8-t.Size:])
+	case utils.AbiFloat:
+		if t.Size == 4 {
+			return r.copy(utils.Float32ToBytes(math.Float32frombits(uint32(param))))
+		}
+		return r.copy(utils.Float64ToBytes(math.Float64frombits(uint64(param))))
+	case utils.AbiString:
+		if pos >= len(r.memory) {
+			return nil, errInvalidAbiMemory
+		}
+		end := pos
+		for end < len(r.memory) && r.memory[end] != 0 {
+			end++
+		}
+		return r.copy(r.memory[pos:end])
+	case utils.AbiAddress:
+		address, err := r.read(pos, t.Size)
+		if err != nil {
+			return nil, err
+		}
+		return r.copy(address)
+	case utils.AbiBigInt:
+		little, err := r.read(pos, t.Size)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.charge(1, t.Size); err != nil {
+			return nil, err
+		}
+		bigEndian := make([]byte, t.Size)
+		for i, b := range little {
+			bigEndian[t.Size-1-i] = b
+		}
+		return new(big.Int).SetBytes(bigEndian).Bytes(), nil
+	case utils.AbiBytes:
+		header, err := r.read(pos, 4)
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.read(pos+4, int(binary.LittleEndian.Uint32(header)))
+		if err != nil {
+			return nil, err
+		}
+		return r.copy(data)
+	case utils.AbiSlice, utils.AbiArray, utils.AbiTuple:
+		count := t.Size
+		if t.Kind == utils.AbiTuple {
+			count = len(t.Components)
+		}
+		if t.Kind == utils.AbiSlice {
+			header, err := r.read(pos, 4)
+			if err != nil {
+				return nil, err
+			}
+			count = int(binary.LittleEndian.Uint32(header))
+			pos += 4
+		}
+
+		// the slots must be in the memory and paid before anything is allocated for them
+		if count > maxAbiElems-r.elems {
+			return nil, errAbiValueTooLarge
+		}
+		slots, err := r.read(pos, count*abiSlotSize)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.charge(1, len(slots)); err != nil {
+			return nil, err
+		}
+		types, err := abiElemTypes(t, count)
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			slot := int64(binary.LittleEndian.Uint64(slots[i*abiSlotSize:]))
+			value, err := r.readValue(types[i], slot, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+	return nil, errInvalidAbiParam
+}
+
+// abiElemTypes returns the types of the elements of the array or struct
+func abiElemTypes(t *utils.AbiType, count int) ([]*utils.AbiType, error) {
+	switch t.Kind {
+	case utils.AbiSlice, utils.AbiArray:
+		if t.Kind == utils.AbiArray && count != t.Size {
+			return nil, errInvalidAbiParam
+		}
+		types := make([]*utils.AbiType, count)
+		for i := range types {
+			types[i] = t.Elem
+		}
+		return types, nil
+	case utils.AbiTuple:
+		if count != len(t.Components) {
+			return nil, errInvalidAbiParam
+		}
+		return t.Components, nil
+	}
+	return nil, errInvalidAbiParam
+}
+
+// abiReturn converts the return of the wasm function, address and big ints
+// are aligned to 32 bytes, bytes are returned raw, arrays and structs as rlp.
+// The read elements are paid by the gas of the contract.
+func abiReturn(vm *exec.VirtualMachine, t *utils.AbiType, res int64, contract *Contract) ([]byte, error) {
+	value, err := readAbiValue(vm, t, res, contract.UseGas)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.Kind {
+	case utils.AbiAddress, utils.AbiBigInt:
+		return utils.Align32Bytes(value.([]byte)), nil
+	case utils.AbiBytes:
+		return value.([]byte), nil
+	}
+	return rlp.EncodeToBytes(value)
+}
+
+// findOutput returns the type of the first output of the function, nil if it returns void
+func findOutput(abi []byte, funcName string) (*utils.AbiType, error) {
+	wasmAbi := new(utils.WasmAbi)
+	if err := wasmAbi.FromJson(abi); err != nil {
+		return nil, errInvalidAbi
+	}
+
+	for _, v := range wasmAbi.AbiArr {
+		if strings.EqualFold(v.Name, funcName) && strings.EqualFold(v.Type, "function") {
+			if len(v.Outputs) == 0 {
+				return nil, nil
+			}
+			return v.Outputs[0].AbiType()
+		}
+	}
+	return nil, errFuncNameNotFound
+}
@@ -0,0 +1,218 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/vm/common/utils"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+	"github.com/dipperin/dipperin-core/third-party/life/mem-manage"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const (
+	richAbi = `[{
+	"name": "transfer",
+	"inputs": [
+		{"name": "to", "type": "address"},
+		{"name": "amount", "type": "uint256"},
+		{"name": "memo", "type": "bytes"},
+		{"name": "ids", "type": "uint32[]"},
+		{"name": "order", "type": "tuple", "components": [
+			{"name": "owner", "type": "address"},
+			{"name": "prices", "type": "uint128[2]"},
+			{"name": "name", "type": "string"}
+		]}
+	],
+	"outputs": [{"name": "", "type": "uint32[]"}],
+	"constant": "false",
+	"type": "function"
+}]`
+	richAbiInput = `0x0000970e8128ab834e8eac17ab8e3812f010678cf791,1000000000000000000000,0x01ff,[1,2,3],(0x0000970e8128ab834e8eac17ab8e3812f010678cf791,[7,8],"a,b")`
+	richAbiAddr  = "0x0000970e8128ab834e8eac17ab8e3812f010678cf791"
+)
+
+func newAbiTestVm() *exec.VirtualMachine {
+	return &exec.VirtualMachine{Memory: mem_manage.NewVmMemory(1)}
+}
+
+func newAbiTestContract() *Contract {
+	return &Contract{Gas: 1000000}
+}
+
+func TestFindParams_RichAbi(t *testing.T) {
+	input, err := rlp.EncodeToBytes([]interface{}{"transfer", richAbiInput})
+	assert.NoError(t, err)
+	data, err := utils.ParseCallContractData([]byte(richAbi), input)
+	assert.NoError(t, err)
+
+	vm := newAbiTestVm()
+	funcName, params, returnType, err := ParseCallExtraDataByABI(vm, data, []byte(richAbi), true)
+	assert.NoError(t, err)
+	assert.Equal(t, "transfer", funcName)
+	assert.Equal(t, "uint32[]", returnType)
+	assert.Len(t, params, 5)
+	assert.Len(t, vm.ExternalParams, 10)
+
+	memory := vm.Memory.Memory
+	address := common.HexToAddress(richAbiAddr)
+	assert.Equal(t, address.Bytes(), memory[params[0]:params[0]+common.AddressLength])
+
+	amount, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	little := memory[params[1] : params[1]+32]
+	bigEndian := make([]byte, 32)
+	for i, b := range little {
+		bigEndian[31-i] = b
+	}
+	assert.Equal(t, amount, new(big.Int).SetBytes(bigEndian))
+
+	assert.Equal(t, uint32(2), binary.LittleEndian.Uint32(memory[params[2]:]))
+	assert.Equal(t, []byte{0x01, 0xff}, memory[params[2]+4:params[2]+6])
+
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(memory[params[3]:]))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, uint64(i+1), binary.LittleEndian.Uint64(memory[params[3]+4+int64(i)*abiSlotSize:]))
+	}
+
+	// the struct holds the pointers of its components
+	owner := int64(binary.LittleEndian.Uint64(memory[params[4]:]))
+	assert.Equal(t, address.Bytes(), memory[owner:owner+common.AddressLength])
+	prices := int64(binary.LittleEndian.Uint64(memory[params[4]+abiSlotSize:]))
+	price := int64(binary.LittleEndian.Uint64(memory[prices+abiSlotSize:]))
+	assert.Equal(t, byte(8), memory[price])
+	name := int64(binary.LittleEndian.Uint64(memory[params[4]+2*abiSlotSize:]))
+	assert.Equal(t, "a,b\x00", string(memory[name:name+4]))
+
+	// the values read back are the same as the rlp input
+	wasmAbi := new(utils.WasmAbi)
+	assert.NoError(t, wasmAbi.FromJson([]byte(richAbi)))
+	var decoded []interface{}
+	assert.NoError(t, rlp.DecodeBytes(data, &decoded))
+	for i, v := range wasmAbi.AbiArr[0].Inputs {
+		abiType, err := v.AbiType()
+		assert.NoError(t, err)
+		value, err := readAbiValue(vm, abiType, params[i], newAbiTestContract().UseGas)
+		assert.NoError(t, err)
+		assert.Equal(t, decoded[i+1], value)
+	}
+}
+
+func TestMarshalAbiParam_Invalid(t *testing.T) {
+	vm := newAbiTestVm()
+	address, err := utils.NewAbiType("address", nil)
+	assert.NoError(t, err)
+	_, err = marshalAbiParam(vm, address, []byte{1, 2})
+	assert.Equal(t, errInvalidAbiParam, err)
+	_, err = marshalAbiParam(vm, address, []interface{}{[]byte{1}})
+	assert.Equal(t, errInvalidAbiParam, err)
+
+	array, err := utils.NewAbiType("uint8[2]", nil)
+	assert.NoError(t, err)
+	_, err = marshalAbiParam(vm, array, []interface{}{[]byte{1}})
+	assert.Equal(t, errInvalidAbiParam, err)
+
+	value, err := utils.NewAbiType("uint128", nil)
+	assert.NoError(t, err)
+	_, err = marshalAbiParam(vm, value, make([]byte, 17))
+	assert.Equal(t, errInvalidAbiParam, err)
+
+	bytesType, err := utils.NewAbiType("bytes", nil)
+	assert.NoError(t, err)
+	_, err = readAbiValue(vm, bytesType, int64(len(vm.Memory.Memory)-2), newAbiTestContract().UseGas)
+	assert.Equal(t, errInvalidAbiMemory, err)
+}
+
+func TestAbiReturn(t *testing.T) {
+	vm := newAbiTestVm()
+
+	slice, err := utils.NewAbiType("uint32[]", nil)
+	assert.NoError(t, err)
+	values := []interface{}{utils.Uint32ToBytes(5), utils.Uint32ToBytes(6)}
+	pos, err := marshalAbiParam(vm, slice, values)
+	assert.NoError(t, err)
+	result, err := abiReturn(vm, slice, pos, newAbiTestContract())
+	assert.NoError(t, err)
+	expected, err := rlp.EncodeToBytes(values)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+
+	address, err := utils.NewAbiType("address", nil)
+	assert.NoError(t, err)
+	pos, err = marshalAbiParam(vm, address, common.HexToAddress(richAbiAddr).Bytes())
+	assert.NoError(t, err)
+	result, err = abiReturn(vm, address, pos, newAbiTestContract())
+	assert.NoError(t, err)
+	assert.Equal(t, utils.Align32Bytes(common.HexToAddress(richAbiAddr).Bytes()), result)
+
+	output, err := findOutput([]byte(richAbi), "transfer")
+	assert.NoError(t, err)
+	assert.Equal(t, utils.AbiSlice, output.Kind)
+	_, err = findOutput([]byte(richAbi), "unknown")
+	assert.Equal(t, errFuncNameNotFound, err)
+}
+
+func TestAbiReturn_Limits(t *testing.T) {
+	vm := newAbiTestVm()
+	memory := vm.Memory.Memory
+
+	// the count of the slice is checked before anything is allocated
+	slice, err := utils.NewAbiType("uint32[]", nil)
+	assert.NoError(t, err)
+	binary.LittleEndian.PutUint32(memory, math.MaxUint32)
+	_, err = abiReturn(vm, slice, 0, newAbiTestContract())
+	assert.Equal(t, errAbiValueTooLarge, err)
+	end := len(memory) - 8
+	binary.LittleEndian.PutUint32(memory[end:], 100)
+	_, err = abiReturn(vm, slice, int64(end), newAbiTestContract())
+	assert.Equal(t, errInvalidAbiMemory, err)
+
+	// the slots of the outer slice all point to the inner slice at 8, the nested
+	// elements are counted every time they are read
+	nested, err := utils.NewAbiType("uint32[][]", nil)
+	assert.NoError(t, err)
+	binary.LittleEndian.PutUint32(memory, 300)
+	binary.LittleEndian.PutUint64(memory[4:], 8)
+	binary.LittleEndian.PutUint32(memory[8:], 300)
+	for i := 0; i < 300; i++ {
+		binary.LittleEndian.PutUint64(memory[12+i*abiSlotSize:], 8)
+	}
+	_, err = abiReturn(vm, nested, 0, &Contract{Gas: math.MaxUint64})
+	assert.Equal(t, errAbiValueTooLarge, err)
+
+	// the read elements are paid by the contract
+	binary.LittleEndian.PutUint32(memory, 2)
+	contract := newAbiTestContract()
+	_, err = abiReturn(vm, nested, 0, contract)
+	assert.NoError(t, err)
+	assert.True(t, contract.Gas < newAbiTestContract().Gas)
+	_, err = abiReturn(vm, nested, 0, &Contract{Gas: 10})
+	assert.Equal(t, g_error.ErrOutOfGas, err)
+
+	// the array points to itself
+	deep, err := utils.NewAbiType("uint8"+strings.Repeat("[1]", maxAbiDepth+2), nil)
+	assert.NoError(t, err)
+	binary.LittleEndian.PutUint64(memory, 0)
+	_, err = abiReturn(vm, deep, 0, newAbiTestContract())
+	assert.Equal(t, errAbiValueTooLarge, err)
+}
@@ -33,13 +33,25 @@ type AbiStruct struct {
 }
 
 type InputParam struct {
-	Name string `json:"name"`
-	Type string `json:"type"`
+	Name       string       `json:"name"`
+	Type       string       `json:"type"`
+	Components []InputParam `json:"components,omitempty"`
 }
 
 type OutputsParam struct {
-	Name string `json:"name"`
-	Type string `json:"type"`
+	Name       string       `json:"name"`
+	Type       string       `json:"type"`
+	Components []InputParam `json:"components,omitempty"`
+}
+
+// AbiType parses the type of the input param
+func (p InputParam) AbiType() (*AbiType, error) {
+	return NewAbiType(p.Type, p.Components)
+}
+
+// AbiType parses the type of the output param
+func (p OutputsParam) AbiType() (*AbiType, error) {
+	return NewAbiType(p.Type, p.Components)
 }
 
 func (abi *WasmAbi) FromJson(body []byte) error {
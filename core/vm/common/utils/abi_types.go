@@ -0,0 +1,354 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/common/math"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidAbiType  = errors.New("vm_utils: invalid abi type")
+	errInvalidAbiParam = errors.New("vm_utils: invalid abi param")
+)
+
+type AbiKind uint8
+
+const (
+	// int8~int64, uint8~uint64 and bool
+	AbiInt AbiKind = iota
+	// float32 and float64
+	AbiFloat
+	AbiString
+	AbiAddress
+	AbiBytes
+	// uint128 and uint256
+	AbiBigInt
+	// dynamic array T[]
+	AbiSlice
+	// fixed array T[N]
+	AbiArray
+	// struct with the components
+	AbiTuple
+)
+
+// AbiType is the parsed type of a wasm abi param.
+//
+// The values of the types are encoded into the rlp input as below:
+// int, float, bool and string are the bytes of StringConverter, address is
+// the 22 address bytes, bytes are the raw bytes, uint128/uint256 are the
+// big endian bytes without leading zeros, arrays and structs are rlp lists.
+type AbiType struct {
+	Kind AbiKind
+	Type string
+	// byte size of the int, float and big int, length of the fixed array
+	Size       int
+	Elem       *AbiType
+	Components []*AbiType
+}
+
+// NewAbiType parses the abi type, components are only used by tuple types
+func NewAbiType(t string, components []InputParam) (*AbiType, error) {
+	t = strings.TrimSpace(t)
+	if strings.HasSuffix(t, "]") {
+		idx := strings.LastIndex(t, "[")
+		if idx <= 0 {
+			return nil, errInvalidAbiType
+		}
+
+		elem, err := NewAbiType(t[:idx], components)
+		if err != nil {
+			return nil, err
+		}
+
+		length := t[idx+1 : len(t)-1]
+		if length == "" {
+			return &AbiType{Kind: AbiSlice, Type: t, Elem: elem}, nil
+		}
+
+		size, err := strconv.Atoi(length)
+		if err != nil || size <= 0 {
+			return nil, errInvalidAbiType
+		}
+		return &AbiType{Kind: AbiArray, Type: t, Size: size, Elem: elem}, nil
+	}
+
+	switch t {
+	case "int8", "uint8", "bool":
+		return &AbiType{Kind: AbiInt, Type: t, Size: 1}, nil
+	case "int16", "uint16":
+		return &AbiType{Kind: AbiInt, Type: t, Size: 2}, nil
+	case "int32", "uint32", "int", "uint":
+		return &AbiType{Kind: AbiInt, Type: t, Size: 4}, nil
+	case "int64", "uint64":
+		return &AbiType{Kind: AbiInt, Type: t, Size: 8}, nil
+	case "float32":
+		return &AbiType{Kind: AbiFloat, Type: t, Size: 4}, nil
+	case "float64":
+		return &AbiType{Kind: AbiFloat, Type: t, Size: 8}, nil
+	case "string":
+		return &AbiType{Kind: AbiString, Type: t}, nil
+	case "address":
+		return &AbiType{Kind: AbiAddress, Type: t, Size: common.AddressLength}, nil
+	case "bytes":
+		return &AbiType{Kind: AbiBytes, Type: t}, nil
+	case "uint128":
+		return &AbiType{Kind: AbiBigInt, Type: t, Size: 16}, nil
+	case "uint256":
+		return &AbiType{Kind: AbiBigInt, Type: t, Size: 32}, nil
+	case "tuple":
+		if len(components) == 0 {
+			return nil, errInvalidAbiType
+		}
+
+		tuple := &AbiType{Kind: AbiTuple, Type: t}
+		for _, v := range components {
+			component, err := v.AbiType()
+			if err != nil {
+				return nil, err
+			}
+			tuple.Components = append(tuple.Components, component)
+		}
+		return tuple, nil
+	}
+	return nil, errInvalidAbiType
+}
+
+// IsLegacy returns whether the type is supported before the Jupiter fork
+func (t *AbiType) IsLegacy() bool {
+	switch t.Kind {
+	case AbiInt, AbiFloat, AbiString:
+		return true
+	}
+	return false
+}
+
+// HasRichParams returns whether any of the params isn't a legacy type
+func HasRichParams(params []InputParam) bool {
+	for _, v := range params {
+		t, err := v.AbiType()
+		if err != nil || !t.IsLegacy() {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitAbiParams splits "param1,[param2,param3],(param4,\"a,b\")" by the top level commas.
+// Brackets, parentheses and double quoted strings are kept in one param.
+func SplitAbiParams(source string) ([]string, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, nil
+	}
+
+	var (
+		params  []string
+		depth   int
+		quoted  bool
+		start   int
+		closing []byte
+	)
+	for i := 0; i < len(source); i++ {
+		c := source[i]
+		if quoted {
+			if c == '"' {
+				quoted = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			quoted = true
+		case '[':
+			closing = append(closing, ']')
+			depth++
+		case '(':
+			closing = append(closing, ')')
+			depth++
+		case ']', ')':
+			if depth == 0 || closing[depth-1] != c {
+				return nil, errInvalidAbiParam
+			}
+			closing = closing[:depth-1]
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(source[start:i]))
+				start = i + 1
+			}
+		}
+	}
+
+	if quoted || depth != 0 {
+		return nil, errInvalidAbiParam
+	}
+	return append(params, strings.TrimSpace(source[start:])), nil
+}
+
+// ParseAbiValue converts the string param into the rlp value of the abi type
+func ParseAbiValue(source string, t *AbiType) (interface{}, error) {
+	source = strings.TrimSpace(source)
+	switch t.Kind {
+	case AbiInt, AbiFloat:
+		return StringConverter(source, t.Type)
+	case AbiString:
+		if len(source) >= 2 && source[0] == '"' && source[len(source)-1] == '"' {
+			source = source[1 : len(source)-1]
+		}
+		return []byte(source), nil
+	case AbiAddress:
+		address, err := hexutil.Decode(source)
+		if err != nil || len(address) != common.AddressLength {
+			return nil, fmt.Errorf("%v: address %v", errInvalidAbiParam, source)
+		}
+		return address, nil
+	case AbiBytes:
+		return hexutil.Decode(source)
+	case AbiBigInt:
+		value, ok := math.ParseBig256(source)
+		if !ok || value.Sign() < 0 || value.BitLen() > t.Size*8 {
+			return nil, fmt.Errorf("%v: %v %v", errInvalidAbiParam, t.Type, source)
+		}
+		return value.Bytes(), nil
+	case AbiSlice, AbiArray, AbiTuple:
+		open, end := "[", "]"
+		if t.Kind == AbiTuple {
+			open, end = "(", ")"
+		}
+		if !strings.HasPrefix(source, open) || !strings.HasSuffix(source, end) {
+			return nil, fmt.Errorf("%v: %v %v", errInvalidAbiParam, t.Type, source)
+		}
+
+		items, err := SplitAbiParams(source[1 : len(source)-1])
+		if err != nil {
+			return nil, err
+		}
+
+		types := make([]*AbiType, len(items))
+		switch t.Kind {
+		case AbiSlice, AbiArray:
+			if t.Kind == AbiArray && len(items) != t.Size {
+				return nil, fmt.Errorf("%v: %v length %v", errInvalidAbiParam, t.Type, len(items))
+			}
+			for i := range types {
+				types[i] = t.Elem
+			}
+		case AbiTuple:
+			if len(items) != len(t.Components) {
+				return nil, fmt.Errorf("%v: tuple length %v", errInvalidAbiParam, len(items))
+			}
+			copy(types, t.Components)
+		}
+
+		values := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			value, err := ParseAbiValue(item, types[i])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+	return nil, errInvalidAbiType
+}
+
+// FormatAbiValue converts the rlp value of the abi type into a printable value
+func FormatAbiValue(value interface{}, t *AbiType) (interface{}, error) {
+	if list, ok := value.([]interface{}); ok {
+		var types []*AbiType
+		switch t.Kind {
+		case AbiSlice:
+			for range list {
+				types = append(types, t.Elem)
+			}
+		case AbiArray:
+			if len(list) != t.Size {
+				return nil, errInvalidAbiParam
+			}
+			for range list {
+				types = append(types, t.Elem)
+			}
+		case AbiTuple:
+			if len(list) != len(t.Components) {
+				return nil, errInvalidAbiParam
+			}
+			types = t.Components
+		default:
+			return nil, errInvalidAbiParam
+		}
+
+		result := make([]interface{}, 0, len(list))
+		for i, v := range list {
+			item, err := FormatAbiValue(v, types[i])
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, item)
+		}
+		return result, nil
+	}
+
+	source, ok := value.([]byte)
+	if !ok {
+		return nil, errInvalidAbiParam
+	}
+	switch t.Kind {
+	case AbiInt, AbiFloat, AbiString:
+		return Align32BytesConverter(source, t.Type), nil
+	case AbiAddress:
+		if len(source) > common.AddressLength {
+			return nil, errInvalidAbiParam
+		}
+		return common.BytesToAddress(source).Hex(), nil
+	case AbiBytes:
+		return hexutil.Encode(source), nil
+	case AbiBigInt:
+		return new(big.Int).SetBytes(source), nil
+	}
+	return nil, errInvalidAbiParam
+}
+
+// DecodeAbiOutput converts the return of a wasm function into a printable value.
+// address and big ints are returned aligned to 32 bytes, bytes are returned raw,
+// arrays and structs are returned as rlp.
+func DecodeAbiOutput(result []byte, t *AbiType) (interface{}, error) {
+	switch t.Kind {
+	case AbiAddress, AbiBigInt:
+		if len(result) < t.Size {
+			return nil, errInvalidAbiParam
+		}
+		return FormatAbiValue(result[len(result)-t.Size:], t)
+	case AbiBytes:
+		return FormatAbiValue(result, t)
+	case AbiSlice, AbiArray, AbiTuple:
+		var value interface{}
+		if err := rlp.DecodeBytes(result, &value); err != nil {
+			return nil, err
+		}
+		return FormatAbiValue(value, t)
+	}
+	return Align32BytesConverter(result, t.Type), nil
+}
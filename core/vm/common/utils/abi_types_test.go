@@ -0,0 +1,174 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestNewAbiType(t *testing.T) {
+	abiType, err := NewAbiType("uint256", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, AbiBigInt, abiType.Kind)
+	assert.Equal(t, 32, abiType.Size)
+
+	abiType, err = NewAbiType("address[3][]", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, AbiSlice, abiType.Kind)
+	assert.Equal(t, AbiArray, abiType.Elem.Kind)
+	assert.Equal(t, 3, abiType.Elem.Size)
+	assert.Equal(t, AbiAddress, abiType.Elem.Elem.Kind)
+	assert.False(t, abiType.IsLegacy())
+
+	abiType, err = NewAbiType("tuple[]", []InputParam{{Name: "a", Type: "int32"}, {Name: "b", Type: "bytes"}})
+	assert.NoError(t, err)
+	assert.Equal(t, AbiTuple, abiType.Elem.Kind)
+	assert.Len(t, abiType.Elem.Components, 2)
+
+	abiType, err = NewAbiType("float64", nil)
+	assert.NoError(t, err)
+	assert.True(t, abiType.IsLegacy())
+
+	for _, v := range []string{"int128", "uint8[0]", "uint8[a]", "[]", "tuple"} {
+		_, err = NewAbiType(v, nil)
+		assert.Equal(t, errInvalidAbiType, err, v)
+	}
+
+	assert.False(t, HasRichParams([]InputParam{{Type: "string"}, {Type: "uint64"}}))
+	assert.True(t, HasRichParams([]InputParam{{Type: "string"}, {Type: "address"}}))
+}
+
+func TestSplitAbiParams(t *testing.T) {
+	params, err := SplitAbiParams(`1, [2,3], ([4,(5)],"6,]"),7`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "[2,3]", `([4,(5)],"6,]")`, "7"}, params)
+
+	params, err = SplitAbiParams(" ")
+	assert.NoError(t, err)
+	assert.Nil(t, params)
+
+	for _, v := range []string{"[1,2", "(1,2]", "1)", `"a`} {
+		_, err = SplitAbiParams(v)
+		assert.Equal(t, errInvalidAbiParam, err, v)
+	}
+}
+
+func TestParseAbiValue(t *testing.T) {
+	address := "0x0000970e8128ab834e8eac17ab8e3812f010678cf791"
+	abiType, err := NewAbiType("tuple", []InputParam{
+		{Name: "owner", Type: "address"},
+		{Name: "amounts", Type: "uint128[]"},
+		{Name: "memo", Type: "bytes"},
+		{Name: "name", Type: "string"},
+	})
+	assert.NoError(t, err)
+
+	value, err := ParseAbiValue(`(`+address+`,[1,0x100],0x01ff,"a,b")`, abiType)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		common.HexToAddress(address).Bytes(),
+		[]interface{}{[]byte{1}, []byte{1, 0}},
+		[]byte{1, 0xff},
+		[]byte("a,b"),
+	}, value)
+
+	formatted, err := FormatAbiValue(value, abiType)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		common.HexToAddress(address).Hex(),
+		[]interface{}{big.NewInt(1), big.NewInt(256)},
+		"0x01ff",
+		"a,b",
+	}, formatted)
+
+	uint128, err := NewAbiType("uint128", nil)
+	assert.NoError(t, err)
+	_, err = ParseAbiValue("340282366920938463463374607431768211456", uint128)
+	assert.Error(t, err)
+	_, err = ParseAbiValue("-1", uint128)
+	assert.Error(t, err)
+
+	addressType, err := NewAbiType("address", nil)
+	assert.NoError(t, err)
+	_, err = ParseAbiValue("0x01", addressType)
+	assert.Error(t, err)
+
+	array, err := NewAbiType("int8[2]", nil)
+	assert.NoError(t, err)
+	_, err = ParseAbiValue("[1,2,3]", array)
+	assert.Error(t, err)
+	_, err = ParseAbiValue("1,2", array)
+	assert.Error(t, err)
+}
+
+func TestDecodeAbiOutput(t *testing.T) {
+	address := "0x0000970e8128ab834e8eac17ab8e3812f010678cf791"
+	addressType, err := NewAbiType("address", nil)
+	assert.NoError(t, err)
+	result, err := DecodeAbiOutput(Align32Bytes(common.HexToAddress(address).Bytes()), addressType)
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToAddress(address).Hex(), result)
+
+	uint256, err := NewAbiType("uint256", nil)
+	assert.NoError(t, err)
+	result, err = DecodeAbiOutput(Align32Bytes([]byte{1, 0}), uint256)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(256), result)
+
+	slice, err := NewAbiType("uint16[]", nil)
+	assert.NoError(t, err)
+	rlpResult, err := rlp.EncodeToBytes([]interface{}{Uint16ToBytes(3), Uint16ToBytes(4)})
+	assert.NoError(t, err)
+	result, err = DecodeAbiOutput(rlpResult, slice)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{uint16(3), uint16(4)}, result)
+
+	_, err = DecodeAbiOutput([]byte{1}, uint256)
+	assert.Equal(t, errInvalidAbiParam, err)
+}
+
+func TestParseCallContractData_RichAbi(t *testing.T) {
+	abi := `[{"name": "transfer", "type": "function", "constant": "false", "outputs": [],
+		"inputs": [{"name": "to", "type": "address"}, {"name": "amounts", "type": "uint256[2]"}, {"name": "memo", "type": "string"}]}]`
+	address := "0x0000970e8128ab834e8eac17ab8e3812f010678cf791"
+	input, err := rlp.EncodeToBytes([]interface{}{"transfer", address + `,[1,2],"a,b"`})
+	assert.NoError(t, err)
+
+	data, err := ParseCallContractData([]byte(abi), input)
+	assert.NoError(t, err)
+	expected, err := rlp.EncodeToBytes([]interface{}{
+		"transfer",
+		common.HexToAddress(address).Bytes(),
+		[]interface{}{[]byte{1}, []byte{2}},
+		"a,b",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	logs, err := ConvertInputs(data, []InputParam{{Type: "string"}, {Type: "address"}, {Type: "uint256[2]"}, {Type: "string"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "transfer,"+common.HexToAddress(address).Hex()+",[1 2],a,b,", string(logs))
+
+	input, err = rlp.EncodeToBytes([]interface{}{"transfer", address + ",[1,2,3],a"})
+	assert.NoError(t, err)
+	_, err = ParseCallContractData([]byte(abi), input)
+	assert.Error(t, err)
+}
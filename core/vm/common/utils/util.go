@@ -87,6 +87,7 @@ func ParseCallContractData(abi []byte, rlpInput []byte) (extraData []byte, err e
 	var (
 		paramStr string
 		params   []string
+		rich     = HasRichParams(args)
 	)
 
 	// if function has params or not
@@ -102,8 +103,9 @@ func ParseCallContractData(abi []byte, rlpInput []byte) (extraData []byte, err e
 			paramStr = string(v)
 		}
 
-		if paramStr != "" {
-			params = strings.Split(paramStr, ",")
+		params, err = splitParams(paramStr, rich)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -114,8 +116,7 @@ func ParseCallContractData(abi []byte, rlpInput []byte) (extraData []byte, err e
 
 	rlpParams := []interface{}{funcName}
 	for i, v := range args {
-		bts := params[i]
-		result, innerErr := StringConverter(bts, v.Type)
+		result, innerErr := convertParam(params[i], v, rich)
 		if innerErr != nil {
 			return nil, innerErr
 		}
@@ -180,6 +181,7 @@ func ParseCreateContractData(rlpData []byte) (extraData []byte, err error) {
 	var (
 		paramStr string
 		params   []string
+		rich     = HasRichParams(args)
 	)
 	// if function has params or not
 	if len(args) == 0 && len(iRlpList) == 2 {
@@ -194,8 +196,9 @@ func ParseCreateContractData(rlpData []byte) (extraData []byte, err error) {
 			paramStr = string(v)
 		}
 
-		if paramStr != "" {
-			params = strings.Split(paramStr, ",")
+		params, err = splitParams(paramStr, rich)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -209,16 +212,40 @@ func ParseCreateContractData(rlpData []byte) (extraData []byte, err error) {
 	}
 
 	for i, v := range args {
-		bts := params[i]
-		re, innerErr := StringConverter(bts, v.Type)
+		re, innerErr := convertParam(params[i], v, rich)
 		if innerErr != nil {
-			return re, innerErr
+			return nil, innerErr
 		}
 		rlpParams = append(rlpParams, re)
 	}
 	return rlp.EncodeToBytes(rlpParams)
 }
 
+// the params of the legacy functions are split by commas, otherwise
+// arrays "[a,b]", structs "(a,b)" and quoted strings are kept together
+func splitParams(paramStr string, rich bool) ([]string, error) {
+	if paramStr == "" {
+		return nil, nil
+	}
+
+	if rich {
+		return SplitAbiParams(paramStr)
+	}
+	return strings.Split(paramStr, ","), nil
+}
+
+func convertParam(source string, param InputParam, rich bool) (interface{}, error) {
+	if !rich {
+		return StringConverter(source, param.Type)
+	}
+
+	t, err := param.AbiType()
+	if err != nil {
+		return nil, err
+	}
+	return ParseAbiValue(source, t)
+}
+
 // input = RLP([funcName][params])
 func ParseInputForFuncName(rlpData []byte) (funcName string, err error) {
 	if rlpData == nil || len(rlpData) == 0 {
@@ -264,8 +291,20 @@ func ConvertInputs(src []byte, abiInput []InputParam) ([]byte, error) {
 
 	var data []byte
 	for i, v := range abiInput {
-		input := inputList[i].([]byte)
-		convert := Align32BytesConverter(input, v.Type)
+		var convert interface{}
+		if t, err := v.AbiType(); err == nil && !t.IsLegacy() {
+			convert, err = FormatAbiValue(inputList[i], t)
+			if err != nil {
+				log.Error("ConvertInputs failed", "type", v.Type, "err", err)
+				return nil, err
+			}
+		} else {
+			input, ok := inputList[i].([]byte)
+			if !ok {
+				return nil, errInvalidRlpFormat
+			}
+			convert = Align32BytesConverter(input, v.Type)
+		}
 		result := fmt.Sprintf("%v,", convert)
 		data = append(data, []byte(result)...)
 	}
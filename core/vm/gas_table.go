@@ -0,0 +1,35 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+	"math/big"
+)
+
+// the grown memory pages are charged after the Mars fork
+var marsGasTable = exec.NewPageGasTable(model2.GrowMemoryPageGas)
+
+// gasTable returns the gas table of the wasm instructions for the block number
+func gasTable(number *big.Int) [256]exec.Instruction {
+	if number != nil && chain_config.GetChainConfig().IsMars(number.Uint64()) {
+		return marsGasTable
+	}
+	return exec.GasTable
+}
@@ -0,0 +1,58 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	model2 "github.com/dipperin/dipperin-core/core/vm/model"
+	"github.com/dipperin/dipperin-core/third-party/life/compiler/opcodes"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func Test_gasTable(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	defer func(mars *big.Int) { conf.MarsBlock = mars }(conf.MarsBlock)
+
+	// grow 3 pages
+	frame := &exec.Frame{Code: []byte{0, 0, 0, 0}, Regs: []int64{3}}
+
+	conf.MarsBlock = nil
+	table := gasTable(big.NewInt(100))
+	cost, err := table[opcodes.GrowMemory].GasCost(nil, frame)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), cost)
+
+	conf.MarsBlock = big.NewInt(100)
+	table = gasTable(big.NewInt(99))
+	cost, _ = table[opcodes.GrowMemory].GasCost(nil, frame)
+	assert.Equal(t, uint64(1), cost)
+
+	table = gasTable(big.NewInt(100))
+	cost, err = table[opcodes.GrowMemory].GasCost(nil, frame)
+	assert.NoError(t, err)
+	assert.Equal(t, 1+3*model2.GrowMemoryPageGas, cost)
+
+	// the other instructions keep the prices
+	cost, _ = table[opcodes.I32Add].GasCost(nil, frame)
+	assert.Equal(t, uint64(1), cost)
+	table = gasTable(nil)
+	cost, _ = table[opcodes.GrowMemory].GasCost(nil, frame)
+	assert.Equal(t, uint64(1), cost)
+}
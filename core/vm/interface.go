@@ -33,6 +33,8 @@ type StateDB interface {
 	GetAbiHash(common.Address) common.Hash
 	GetAbi(common.Address) []byte
 	SetAbi(common.Address, []byte)
+	// ClearContract removes the code, the abi and the data of the contract
+	ClearContract(common.Address)
 	AddBalance(addr common.Address, amount *big.Int)
 	SubBalance(addr common.Address, amount *big.Int)
 
@@ -21,7 +21,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/common/math"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/vm/common/utils"
 	"github.com/dipperin/dipperin-core/core/vm/resolver"
 	"github.com/dipperin/dipperin-core/third-party/life/exec"
@@ -61,6 +63,7 @@ type WASMInterpreter struct {
 	context  *Context
 	config   exec.VMConfig
 	resolver exec.ImportResolver
+	modules  *ModuleCache
 }
 
 // NewWASMInterpreter returns a new instance of the Interpreter
@@ -70,12 +73,27 @@ func NewWASMInterpreter(state StateDB, context Context, vmConfig exec.VMConfig)
 		&context,
 		vmConfig,
 		&resolver.Resolver{},
+		defaultModuleCache,
 	}
 }
 
 func (in *WASMInterpreter) Run(vm *VM, contract *Contract, create bool) (ret []byte, err error) {
+	var lifeVm *exec.VirtualMachine
 	defer func() {
 		if er := recover(); er != nil {
+			// the reverted contract only pays the used gas and returns the reason after the Saturn fork
+			if revert, ok := er.(*g_error.RevertError); ok && lifeVm != nil && isSaturn(in.context.BlockNumber) {
+				log.Info("contract reverted", "reason", revert.Reason, "gasUsed", lifeVm.GasUsed)
+				contract.Gas = contract.Gas - lifeVm.GasUsed
+				ret, err = []byte(revert.Reason), g_error.ErrExecutionReverted
+				return
+			}
+			// the self destructed contract stops and only pays the used gas
+			if er == g_error.ErrSelfDestructed && lifeVm != nil {
+				contract.Gas = contract.Gas - lifeVm.GasUsed
+				ret, err = nil, nil
+				return
+			}
 			fmt.Println(stack())
 			ret, err = nil, fmt.Errorf("VM execute fail: %v", er)
 		}
@@ -94,13 +112,22 @@ func (in *WASMInterpreter) Run(vm *VM, contract *Contract, create bool) (ret []b
 	}
 
 	//　life方法注入新建虚拟机
-	solver := resolver.NewResolver(vm, contract, in.state)
-	lifeVm, err := exec.NewVirtualMachine(contract.Code, in.config, solver, nil)
+	module, err := in.modules.getOrCompile(contract.CodeHash, contract.Code, in.config)
+	if err != nil {
+		log.Info("CompileModule failed", "err", err)
+		return nil, err
+	}
+	solver := resolver.NewTracingResolver(vm, contract, in.state, vm.resolverTracer())
+	lifeVm, err = exec.NewVirtualMachineWithModule(module.compiled, in.config, solver, nil)
 	if err != nil {
 		log.Info("NewVirtualMachine failed", "err", err)
 		return nil, err
 	}
 	lifeVm.GasLimit = contract.Gas
+	lifeVm.JumpTable = gasTable(in.context.BlockNumber)
+	if native := in.modules.nativeModule(module); native != nil {
+		lifeVm.SetAOTService(native)
+	}
 	defer func() {
 		lifeVm.Stop()
 	}()
@@ -109,19 +136,20 @@ func (in *WASMInterpreter) Run(vm *VM, contract *Contract, create bool) (ret []b
 		funcName   string
 		params     []int64
 		returnType string
+		richAbi    = isJupiter(in.context.BlockNumber)
 	)
 
 	if create {
 		// init function.
 		funcName = "init"
-		params, returnType, err = ParseInitFunctionByABI(lifeVm, contract.Input, contract.ABI)
+		params, returnType, err = ParseInitFunctionByABI(lifeVm, contract.Input, contract.ABI, richAbi)
 		if err != nil {
 			log.Error("ParseInitFunctionByABI failed", "err", err)
 			return nil, err
 		}
 	} else {
 		// parse input
-		funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, contract.Input, contract.ABI)
+		funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, contract.Input, contract.ABI, richAbi)
 		if err != nil {
 			if err == errInsufficientParams { // transfer to contract address.
 				return nil, nil
@@ -150,6 +178,16 @@ func (in *WASMInterpreter) Run(vm *VM, contract *Contract, create bool) (ret []b
 		return contract.Code, nil
 	}
 
+	if richAbi {
+		outputType, err := findOutput(contract.ABI, funcName)
+		if err != nil {
+			return nil, err
+		}
+		if outputType != nil && !outputType.IsLegacy() {
+			return abiReturn(lifeVm, outputType, res, contract)
+		}
+	}
+
 	switch returnType {
 	case "void", "bool", "int8", "int16", "int32", "int64":
 		bigRes := new(big.Int)
@@ -185,9 +223,20 @@ func (in *WASMInterpreter) CanRun(code []byte) bool {
 	return true
 }
 
+// isJupiter returns whether the block number is after the Jupiter fork
+func isJupiter(number *big.Int) bool {
+	return number != nil && chain_config.GetChainConfig().IsJupiter(number.Uint64())
+}
+
+// isSaturn returns whether the block number is after the Saturn fork
+func isSaturn(number *big.Int) bool {
+	return number != nil && chain_config.GetChainConfig().IsSaturn(number.Uint64())
+}
+
 // input = RLP([params])
 // returnType must void
-func ParseInitFunctionByABI(vm *exec.VirtualMachine, input []byte, abi []byte) (params []int64, returnType string, err error) {
+// richAbi passes the address, bytes, big int, array and struct params after the Jupiter fork
+func ParseInitFunctionByABI(vm *exec.VirtualMachine, input []byte, abi []byte, richAbi bool) (params []int64, returnType string, err error) {
 	if input == nil || len(input) <= 1 {
 		log.Info("InitFunc has no input")
 		return
@@ -207,7 +256,7 @@ func ParseInitFunctionByABI(vm *exec.VirtualMachine, input []byte, abi []byte) (
 		return
 	}
 
-	params, returnType, err = findParams(vm, abi, "init", rlpList.([]interface{}))
+	params, returnType, err = findParams(vm, abi, "init", rlpList.([]interface{}), richAbi)
 	if err != nil {
 		return
 	}
@@ -221,7 +270,7 @@ func ParseInitFunctionByABI(vm *exec.VirtualMachine, input []byte, abi []byte) (
 
 // input = RLP([funcName][params])
 // get returnType[0] if more than 1 return
-func ParseCallExtraDataByABI(vm *exec.VirtualMachine, input []byte, abi []byte) (funcName string, params []int64, returnType string, err error) {
+func ParseCallExtraDataByABI(vm *exec.VirtualMachine, input []byte, abi []byte, richAbi bool) (funcName string, params []int64, returnType string, err error) {
 	if input == nil || len(input) == 0 {
 		err = errEmptyInput
 		return
@@ -255,11 +304,11 @@ func ParseCallExtraDataByABI(vm *exec.VirtualMachine, input []byte, abi []byte)
 	for _, value := range iRlpList[1:] {
 		inputList = append(inputList, value)
 	}
-	params, returnType, err = findParams(vm, abi, funcName, inputList)
+	params, returnType, err = findParams(vm, abi, funcName, inputList, richAbi)
 	return
 }
 
-func findParams(vm *exec.VirtualMachine, abi []byte, funcName string, inputList []interface{}) (params []int64, returnType string, err error) {
+func findParams(vm *exec.VirtualMachine, abi []byte, funcName string, inputList []interface{}, richAbi bool) (params []int64, returnType string, err error) {
 	wasmAbi := new(utils.WasmAbi)
 	err = wasmAbi.FromJson(abi)
 	if err != nil {
@@ -288,6 +337,26 @@ func findParams(vm *exec.VirtualMachine, abi []byte, funcName string, inputList
 		return
 	}
 
+	if richAbi {
+		for i, v := range abiParam {
+			t, innerErr := v.AbiType()
+			if innerErr != nil {
+				log.Error("findParams failed", "type", v.Type, "err", innerErr)
+				err = innerErr
+				return
+			}
+
+			param, innerErr := marshalAbiParam(vm, t, inputList[i])
+			if innerErr != nil {
+				log.Error("findParams failed", "type", v.Type, "err", innerErr)
+				err = innerErr
+				return
+			}
+			params = append(params, param)
+		}
+		return
+	}
+
 	// uint64 uint32  uint16 uint8 int64 int32  int16 int8 float32 float64 string void
 	for i, v := range abiParam {
 		input := inputList[i].([]byte)
@@ -140,73 +140,73 @@ func TestParseInitFunctionByABI(t *testing.T) {
 	num := utils.Uint64ToBytes(100)
 	input := genInput(t, "", [][]byte{num})
 
-	_, _, err := ParseInitFunctionByABI(lifeVm, nil, nil)
+	_, _, err := ParseInitFunctionByABI(lifeVm, nil, nil, false)
 	assert.NoError(t, err)
 
-	_, _, err = ParseInitFunctionByABI(lifeVm, input, nil)
+	_, _, err = ParseInitFunctionByABI(lifeVm, input, nil, false)
 	assert.Equal(t, errEmptyABI, err)
 
-	_, _, err = ParseInitFunctionByABI(lifeVm, []byte{1, 2, 3}, []byte{1, 2, 3})
+	_, _, err = ParseInitFunctionByABI(lifeVm, []byte{1, 2, 3}, []byte{1, 2, 3}, false)
 	assert.Equal(t, errInvalidRlpFormat, err)
 
-	_, _, err = ParseInitFunctionByABI(lifeVm, input, []byte(abi1))
+	_, _, err = ParseInitFunctionByABI(lifeVm, input, []byte(abi1), false)
 	assert.Equal(t, errInputAbiNotMatch, err)
 
-	_, _, err = ParseInitFunctionByABI(lifeVm, input, []byte(abi2))
+	_, _, err = ParseInitFunctionByABI(lifeVm, input, []byte(abi2), false)
 	assert.Equal(t, errInvalidReturnType, err)
 
-	_, _, err = ParseInitFunctionByABI(lifeVm, input, []byte(abi3))
+	_, _, err = ParseInitFunctionByABI(lifeVm, input, []byte(abi3), false)
 	assert.NoError(t, err)
 }
 
 func TestParseCallExtraDataByABI(t *testing.T) {
 	lifeVm := &exec.VirtualMachine{}
-	_, _, _, err := ParseCallExtraDataByABI(lifeVm, nil, nil)
+	_, _, _, err := ParseCallExtraDataByABI(lifeVm, nil, nil, false)
 	assert.Equal(t, errEmptyInput, err)
 
-	_, _, _, err = ParseCallExtraDataByABI(lifeVm, []byte{1, 2, 3}, nil)
+	_, _, _, err = ParseCallExtraDataByABI(lifeVm, []byte{1, 2, 3}, nil, false)
 	assert.Equal(t, errEmptyABI, err)
 
-	_, _, _, err = ParseCallExtraDataByABI(lifeVm, []byte{1, 2, 3}, []byte{1, 2, 3})
+	_, _, _, err = ParseCallExtraDataByABI(lifeVm, []byte{1, 2, 3}, []byte{1, 2, 3}, false)
 	assert.Equal(t, errInvalidRlpFormat, err)
 
 	input := genInput(t, "", [][]byte{})
-	_, _, _, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi2))
+	_, _, _, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi2), false)
 	assert.Equal(t, errInsufficientParams, err)
 
 	input = genInput(t, "test", [][]byte{})
-	_, _, _, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi2))
+	_, _, _, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi2), false)
 	assert.NoError(t, err)
 
-	_, _, _, err = ParseCallExtraDataByABI(lifeVm, input, []byte{123})
+	_, _, _, err = ParseCallExtraDataByABI(lifeVm, input, []byte{123}, false)
 	assert.Equal(t, errInvalidAbi, err)
 
 	input = genInput(t, "init", [][]byte{})
-	_, _, _, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi2))
+	_, _, _, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi2), false)
 	assert.Equal(t, errInputAbiNotMatch, err)
 
 	num := utils.Uint64ToBytes(100)
 	input = genInput(t, "init", [][]byte{num})
-	funcName, params, returnType, err := ParseCallExtraDataByABI(lifeVm, input, []byte(abi2))
+	funcName, params, returnType, err := ParseCallExtraDataByABI(lifeVm, input, []byte(abi2), false)
 	assert.Equal(t, "init", funcName)
 	assert.Equal(t, 1, len(params))
 	assert.Equal(t, "string", returnType)
 	assert.NoError(t, err)
 
-	funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi3))
+	funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi3), false)
 	assert.Equal(t, "init", funcName)
 	assert.Equal(t, 1, len(params))
 	assert.Equal(t, "void", returnType)
 	assert.NoError(t, err)
 
 	input = genInput(t, "init", [][]byte{})
-	funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi1))
+	funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi1), false)
 	assert.Equal(t, "init", funcName)
 	assert.Equal(t, 0, len(params))
 	assert.Equal(t, "void", returnType)
 	assert.NoError(t, err)
 
-	funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi4))
+	funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi4), false)
 	assert.Equal(t, "init", funcName)
 	assert.Equal(t, 0, len(params))
 	assert.Equal(t, "string", returnType)
@@ -218,7 +218,7 @@ func TestParseCallExtraDataByABI(t *testing.T) {
 	num4 := []byte{255}
 	num5 := []byte{1}
 	input = genInput(t, "init", [][]byte{num1, num2, num3, num4, num5})
-	funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi5))
+	funcName, params, returnType, err = ParseCallExtraDataByABI(lifeVm, input, []byte(abi5), false)
 	assert.Equal(t, "init", funcName)
 	assert.Equal(t, 5, len(params))
 	assert.Equal(t, "void", returnType)
@@ -67,8 +67,17 @@ const (
 	CreateGas        uint64 = 32000 // Once per CREATE operation & contract-creation transaction.
 	Create2Gas       uint64 = 32000 // Once per CREATE2 operation
 	SuicideRefundGas uint64 = 24000 // Refunded following a suicide operation.
+	SelfDestructGas  uint64 = 5000  // Once per SELFDESTRUCT operation.
 	MemoryGas        uint64 = 3     // Times the address of the (highest referenced byte in memory + 1). NOTE: referencing happens on read, write and in instructions such as RETURN and CALL.
 	TxDataNonZeroGas uint64 = 68    // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
+	// Per byte of data attached to a transaction that is not equal to zero after the Earth fork.
+	TxDataNonZeroGasEarth uint64 = 16
+	// Per signature recovered from the multisig witness of a transaction after the Eris fork.
+	TxMultiSigRecoverGas uint64 = 3000
+	// Per page of the wasm linear memory grown after the Mars fork.
+	GrowMemoryPageGas uint64 = 2048
+	// Per element of the abi return read from the wasm memory, the copied bytes are charged CopyGas per word.
+	AbiElemGas uint64 = 3
 
 	// todo: MAX CODE SIZE. pre value : 24576
 	MaxCodeSize = 524288 // Maximum bytecode to permit for a contract
@@ -19,6 +19,7 @@ package model
 import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/ethereum/go-ethereum/rlp"
 	"io"
 	"math/big"
@@ -54,6 +55,11 @@ type Receipt struct {
 	BlockHash        common.Hash `json:"blockHash,omitempty"`
 	BlockNumber      *big.Int    `json:"blockNumber,omitempty"`
 	TransactionIndex uint        `json:"transactionIndex"`
+
+	// Failure information: the vm error of the failed transaction and the reason given by
+	// the reverted contract, they are stored but not part of the receipt hash.
+	Error        string `json:"error,omitempty" rlp:"-"`
+	RevertReason string `json:"revertReason,omitempty" rlp:"-"`
 }
 
 /*
@@ -79,6 +85,21 @@ type receiptStorageRLP struct {
 	Status            uint64
 	CumulativeGasUsed uint64
 	Logs              []*LogForStorage
+	// Failure is [Error, RevertReason] of the failed receipts, it's empty for the receipts
+	// without failure information so that they are encoded as before
+	Failure []string `rlp:"tail"`
+}
+
+// SetFailure records the vm error of the failed transaction, the returned data of the reverted
+// execution is the reason given by the contract.
+func (r *Receipt) SetFailure(err error, ret []byte) {
+	if err == nil {
+		return
+	}
+	r.Error = err.Error()
+	if err == g_error.ErrExecutionReverted {
+		r.RevertReason = string(ret)
+	}
 }
 
 // NewReceipt creates a barebone transaction receipt, copying the init fields.
@@ -120,6 +141,8 @@ func (r *Receipt) String() string {
 	BlockHash     		%s   
 	BlockNumber     	%v 
 	TransactionIndex 	%v
+	Error				%s
+	RevertReason		%s
 `,
 		r.PostState,
 		r.GetStatusStr(),
@@ -132,6 +155,8 @@ func (r *Receipt) String() string {
 		r.BlockHash,
 		r.BlockNumber,
 		r.TransactionIndex,
+		r.Error,
+		r.RevertReason,
 	)
 }
 
@@ -181,6 +206,9 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 	for i, log := range r.Logs {
 		enc.Logs[i] = (*LogForStorage)(log)
 	}
+	if r.Error != "" {
+		enc.Failure = []string{r.Error, r.RevertReason}
+	}
 	return rlp.Encode(w, enc)
 }
 
@@ -198,6 +226,12 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	for i, log := range dec.Logs {
 		r.Logs[i] = (*Log)(log)
 	}
+	if len(dec.Failure) > 0 {
+		r.Error = dec.Failure[0]
+	}
+	if len(dec.Failure) > 1 {
+		r.RevertReason = dec.Failure[1]
+	}
 	// Assign the implementation fields
 	r.Bloom = CreateBloom(Receipts{(*Receipt)(r)})
 	return nil
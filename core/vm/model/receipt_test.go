@@ -18,6 +18,7 @@ package model
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"strconv"
@@ -53,3 +54,55 @@ func TestReceipt(t *testing.T) {
 		assert.NotNil(t, (*Receipt)(v).String())
 	}
 }
+
+func TestReceipt_SetFailure(t *testing.T) {
+	receipt := NewReceipt([]byte{}, true, uint64(100), nil)
+	receipt.SetFailure(nil, []byte("ignored"))
+	assert.Equal(t, "", receipt.Error)
+
+	receipt.SetFailure(g_error.ErrOutOfGas, []byte("ignored"))
+	assert.Equal(t, g_error.ErrOutOfGas.Error(), receipt.Error)
+	assert.Equal(t, "", receipt.RevertReason)
+
+	receipt = NewReceipt([]byte{}, true, uint64(100), nil)
+	receipt.SetFailure(g_error.ErrExecutionReverted, []byte("insufficient token"))
+	assert.Equal(t, g_error.ErrExecutionReverted.Error(), receipt.Error)
+	assert.Equal(t, "insufficient token", receipt.RevertReason)
+
+	// the failure isn't part of the receipt hash
+	hashEnc, err := rlp.EncodeToBytes(receipt)
+	assert.NoError(t, err)
+	plainEnc, err := rlp.EncodeToBytes(NewReceipt([]byte{}, true, uint64(100), nil))
+	assert.NoError(t, err)
+	assert.Equal(t, plainEnc, hashEnc)
+
+	// the failure is stored
+	enc, err := rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	assert.NoError(t, err)
+	var dec ReceiptForStorage
+	assert.NoError(t, rlp.DecodeBytes(enc, &dec))
+	assert.Equal(t, receipt.Error, dec.Error)
+	assert.Equal(t, receipt.RevertReason, dec.RevertReason)
+}
+
+func TestReceiptForStorage_Legacy(t *testing.T) {
+	// the receipts stored before the failure information
+	type legacyReceiptRLP struct {
+		PostState         []byte
+		Status            uint64
+		CumulativeGasUsed uint64
+		Logs              []*LogForStorage
+	}
+	legacy, err := rlp.EncodeToBytes(&legacyReceiptRLP{PostState: []byte{1}, Status: ReceiptStatusSuccessful, CumulativeGasUsed: 100, Logs: []*LogForStorage{}})
+	assert.NoError(t, err)
+
+	var dec ReceiptForStorage
+	assert.NoError(t, rlp.DecodeBytes(legacy, &dec))
+	assert.Equal(t, uint64(100), dec.CumulativeGasUsed)
+	assert.Equal(t, "", dec.Error)
+
+	// the receipts without failure are encoded as before
+	enc, err := rlp.EncodeToBytes(&dec)
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, enc)
+}
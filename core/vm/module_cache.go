@@ -0,0 +1,147 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"sync/atomic"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/life/aot"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/hashicorp/golang-lru"
+)
+
+// number of compiled contract modules kept in memory
+const defaultModuleCacheSize = 256
+
+// number of calls of a contract before its native code is compiled
+const aotCallThreshold = 64
+
+// the compiled modules are shared by the tx pool, the block processing and the contract calls of rpc
+var defaultModuleCache = NewModuleCache(defaultModuleCacheSize)
+
+// the native code is compiled by one C compiler at a time in the background
+var aotBuilds = make(chan struct{}, 1)
+
+// EnableAOT runs the hot contracts with the native code compiled to the directory
+func EnableAOT(dir string) {
+	defaultModuleCache.EnableAOT(dir)
+}
+
+type moduleCacheKey struct {
+	codeHash             common.Hash
+	disableFloatingPoint bool
+}
+
+type cachedModule struct {
+	compiled *exec.CompiledModule
+	calls    uint64
+	// the *aot.Module compiled from the module
+	native atomic.Value
+}
+
+// ModuleCache keeps the recently used contract modules compiled by life, so a contract isn't
+// compiled again for every transaction or nested call
+type ModuleCache struct {
+	modules *lru.Cache
+	// the *aot.Compiler of the native code, the contracts are only run by the interpreter if it isn't set
+	compiler atomic.Value
+}
+
+func NewModuleCache(size int) *ModuleCache {
+	modules, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &ModuleCache{modules: modules}
+}
+
+// EnableAOT compiles the native code of the contracts called aotCallThreshold times with the C compiler,
+// the native code has the same results and gas usage as the interpreter
+func (c *ModuleCache) EnableAOT(dir string) {
+	c.compiler.Store(aot.NewCompiler(dir))
+}
+
+// GetOrCompile returns the compiled module of the code, it's compiled and cached if it's not in the cache
+func (c *ModuleCache) GetOrCompile(codeHash common.Hash, code []byte, config exec.VMConfig) (*exec.CompiledModule, error) {
+	module, err := c.getOrCompile(codeHash, code, config)
+	if err != nil {
+		return nil, err
+	}
+	return module.compiled, nil
+}
+
+func (c *ModuleCache) getOrCompile(codeHash common.Hash, code []byte, config exec.VMConfig) (*cachedModule, error) {
+	if codeHash.IsEmpty() {
+		codeHash = cs_crypto.Keccak256Hash(code)
+	}
+	key := moduleCacheKey{codeHash: codeHash, disableFloatingPoint: config.DisableFloatingPoint}
+	if cached, ok := c.modules.Get(key); ok {
+		return cached.(*cachedModule), nil
+	}
+
+	compiled, err := exec.CompileModule(code, config, nil)
+	if err != nil {
+		return nil, err
+	}
+	module := &cachedModule{compiled: compiled}
+	c.modules.Add(key, module)
+	return module, nil
+}
+
+// nativeModule returns the native module of a cached module, or nil if the module is run by the
+// interpreter. The native code is compiled in the background when the module gets hot.
+func (c *ModuleCache) nativeModule(module *cachedModule) exec.AOTService {
+	compiler, ok := c.compiler.Load().(*aot.Compiler)
+	if !ok {
+		return nil
+	}
+	if native, ok := module.native.Load().(*aot.Module); ok {
+		return native
+	}
+	if atomic.AddUint64(&module.calls, 1) == aotCallThreshold {
+		go compileNative(compiler, module)
+	}
+	return nil
+}
+
+func compileNative(compiler *aot.Compiler, module *cachedModule) {
+	aotBuilds <- struct{}{}
+	defer func() {
+		<-aotBuilds
+	}()
+
+	native, err := compiler.Compile(module.compiled)
+	if err != nil {
+		// the module is kept on the interpreter
+		log.Warn("compile the native contract code failed", "err", err)
+		return
+	}
+	module.native.Store(native)
+}
+
+// Len returns the number of the cached modules
+func (c *ModuleCache) Len() int {
+	return c.modules.Len()
+}
+
+// Purge removes all the cached modules
+func (c *ModuleCache) Purge() {
+	c.modules.Purge()
+}
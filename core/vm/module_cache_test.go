@@ -0,0 +1,147 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	os_exec "os/exec"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/tests/g-testData"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/life/aot"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleCache_GetOrCompile(t *testing.T) {
+	WASMPath := g_testData.GetWASMPath("event", g_testData.CoreVmTestData)
+	AbiPath := g_testData.GetAbiPath("event", g_testData.CoreVmTestData)
+	code, _ := g_testData.GetCodeAbi(WASMPath, AbiPath)
+	codeHash := cs_crypto.Keccak256Hash(code)
+
+	cache := NewModuleCache(1)
+	_, err := cache.GetOrCompile(common.Hash{}, []byte{123}, exec.VMConfig{})
+	assert.Error(t, err)
+	assert.Equal(t, 0, cache.Len())
+
+	compiled, err := cache.GetOrCompile(codeHash, code, exec.VMConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cache.Len())
+
+	// the code hash is calculated if it's empty
+	cached, err := cache.GetOrCompile(common.Hash{}, code, exec.VMConfig{})
+	assert.NoError(t, err)
+	assert.True(t, compiled == cached)
+
+	// the module compiled with other config is cached separately, the old one is evicted
+	other, err := cache.GetOrCompile(codeHash, code, exec.VMConfig{DisableFloatingPoint: true})
+	assert.NoError(t, err)
+	assert.False(t, compiled == other)
+	assert.True(t, other.Module.DisableFloatingPoint)
+	assert.Equal(t, 1, cache.Len())
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestModuleCache_SharedModule(t *testing.T) {
+	WASMPath := g_testData.GetWASMPath("event", g_testData.CoreVmTestData)
+	AbiPath := g_testData.GetAbiPath("event", g_testData.CoreVmTestData)
+	name := []byte("contract")
+
+	defaultModuleCache.Purge()
+	var gasLeft []uint64
+	for i := 0; i < 3; i++ {
+		testVm := getTestVm()
+		contract := getContract(WASMPath, AbiPath, genInput(t, "returnString", [][]byte{name}))
+		_, err := testVm.Interpreter.Run(testVm, contract, false)
+		assert.NoError(t, err)
+		gasLeft = append(gasLeft, contract.Gas)
+	}
+	assert.Equal(t, 1, defaultModuleCache.Len())
+
+	// the cached module is charged the same gas as the newly compiled one
+	assert.Equal(t, gasLeft[0], gasLeft[1])
+	assert.Equal(t, gasLeft[0], gasLeft[2])
+
+	// the imported memory and table aren't set to the cached module
+	code := getContract(WASMPath, AbiPath, nil).Code
+	cached, err := defaultModuleCache.GetOrCompile(common.Hash{}, code, DEFAULT_VM_CONFIG)
+	assert.NoError(t, err)
+	compiled, err := exec.CompileModule(code, DEFAULT_VM_CONFIG, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, compiled.Module.Base.Memory, cached.Module.Base.Memory)
+	assert.Equal(t, compiled.Module.Base.Table, cached.Module.Base.Table)
+}
+
+func TestModuleCache_NativeModule(t *testing.T) {
+	if _, err := os_exec.LookPath("cc"); err != nil || !aot.Supported {
+		t.Skip("the native code isn't supported")
+	}
+	conf := chain_config.GetChainConfig()
+	defer func(saturn *big.Int) { conf.SaturnBlock = saturn }(conf.SaturnBlock)
+	conf.SaturnBlock = big.NewInt(1)
+
+	dir, err := ioutil.TempDir("", "aot-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cache := NewModuleCache(2)
+	run := func(name, funcName string, params [][]byte) ([]byte, uint64, error) {
+		testVm := getTestVm()
+		testVm.Interpreter.(*WASMInterpreter).modules = cache
+		contract := getContract(g_testData.GetWASMPath(name, g_testData.CoreVmTestData), g_testData.GetAbiPath(name, g_testData.CoreVmTestData), genInput(t, funcName, params))
+		ret, err := testVm.Interpreter.Run(testVm, contract, false)
+		return ret, contract.Gas, err
+	}
+	module := func(name string) *cachedModule {
+		code, _ := g_testData.GetCodeAbi(g_testData.GetWASMPath(name, g_testData.CoreVmTestData), g_testData.GetAbiPath(name, g_testData.CoreVmTestData))
+		module, err := cache.getOrCompile(common.Hash{}, code, DEFAULT_VM_CONFIG)
+		assert.NoError(t, err)
+		return module
+	}
+
+	expectedRet, expectedGas, err := run("event", "returnString", [][]byte{[]byte("contract")})
+	assert.NoError(t, err)
+	revertRet, revertGas, revertErr := run("revert", "fail", nil)
+	assert.Equal(t, "bad", string(revertRet))
+
+	// the modules are run by the interpreter until the native code is compiled
+	assert.Nil(t, cache.nativeModule(module("event")))
+	cache.EnableAOT(dir)
+	assert.Nil(t, cache.nativeModule(module("event")))
+	compiler := cache.compiler.Load().(*aot.Compiler)
+	compileNative(compiler, module("event"))
+	compileNative(compiler, module("revert"))
+	assert.NotNil(t, cache.nativeModule(module("event")))
+	assert.NotNil(t, cache.nativeModule(module("revert")))
+
+	// the native code has the same results and gas usage
+	ret, gas, err := run("event", "returnString", [][]byte{[]byte("contract")})
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRet, ret)
+	assert.Equal(t, expectedGas, gas)
+	ret, gas, err = run("revert", "fail", nil)
+	assert.Equal(t, revertErr, err)
+	assert.Equal(t, revertRet, ret)
+	assert.Equal(t, revertGas, gas)
+}
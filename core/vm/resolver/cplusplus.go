@@ -24,8 +24,10 @@ import (
 	"encoding/binary"
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/common/math"
 	"github.com/dipperin/dipperin-core/core/vm/common/utils"
+	"github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/third-party/life/exec"
 	"github.com/dipperin/dipperin-core/third-party/log"
@@ -300,6 +302,19 @@ func envAbortGasCost(vm *exec.VirtualMachine) (uint64, error) {
 	return 0, nil
 }
 
+// envRevert stops the contract with the reason, the interpreter reverts the state of the call
+// but only charges the used gas
+func envRevert(vm *exec.VirtualMachine) int64 {
+	msg := int(int32(vm.GetCurrentFrame().Locals[0]))
+	msgLen := int(int32(vm.GetCurrentFrame().Locals[1]))
+	panic(&g_error.RevertError{Reason: string(vm.Memory.Memory[msg : msg+msgLen])})
+}
+
+func envRevertGasCost(vm *exec.VirtualMachine) (uint64, error) {
+	msgLen := int(int32(vm.GetCurrentFrame().Locals[1]))
+	return uint64(msgLen), nil
+}
+
 // define: int64_t gasPrice();
 func (r *Resolver) envGasPrice(vm *exec.VirtualMachine) int64 {
 	gasPrice := r.Service.GetGasPrice().Int64()
@@ -621,3 +636,67 @@ func envDipperCallInt64GasCost(vm *exec.VirtualMachine) (uint64, error) {
 func envDipperCallStringGasCost(vm *exec.VirtualMachine) (uint64, error) {
 	return 1, nil
 }
+
+// define: int32_t dipcCreate(uint8_t *code, size_t codeLen, uint8_t *abi, size_t abiLen, uint8_t *input, size_t inputLen, u256 *value, char addr[22]);
+// input is the RLP list of the init params, returns 0 and writes the address of the contract if it's deployed
+func (r *Resolver) envCreate(vm *exec.VirtualMachine) int64 {
+	return r.create(vm, nil, 7)
+}
+
+// define: int32_t dipcCreate2(uint8_t *code, size_t codeLen, uint8_t *abi, size_t abiLen, uint8_t *input, size_t inputLen, u256 *value, h256 *salt, char addr[22]);
+func (r *Resolver) envCreate2(vm *exec.VirtualMachine) int64 {
+	saltPtr := int(int32(vm.GetCurrentFrame().Locals[7]))
+	salt := common.BytesToHash(vm.Memory.Memory[saltPtr : saltPtr+common.HashLength])
+	return r.create(vm, &salt, 8)
+}
+
+func (r *Resolver) create(vm *exec.VirtualMachine, salt *common.Hash, addrIndex int) int64 {
+	code := readFrameBytes(vm, 0, 1)
+	abi := readFrameBytes(vm, 2, 3)
+	input := readFrameBytes(vm, 4, 5)
+	value := readFrameU256(vm, 6)
+	addr := int(int32(vm.GetCurrentFrame().Locals[addrIndex]))
+
+	// keep 1/64 of the gas left, so the contract can go on if the creation fails and uses up its gas
+	gas := vm.GasLimit - vm.GasUsed
+	gas -= gas / 64
+	contractAddr, leftOverGas, err := r.Service.ResolverCreate(code, abi, input, value, salt, gas)
+	vm.GasUsed += gas - leftOverGas
+	log.Info("envCreate", "contractAddr", contractAddr, "GasUsed", vm.GasUsed, "leftOverGas", leftOverGas, "err", err)
+	if err != nil {
+		return 1
+	}
+	copy(vm.Memory.Memory[addr:], contractAddr.Bytes())
+	return 0
+}
+
+func envCreateGasCost(vm *exec.VirtualMachine) (uint64, error) {
+	return model.CreateGas, nil
+}
+
+// the creation data is hashed to derive the address
+func envCreate2GasCost(vm *exec.VirtualMachine) (uint64, error) {
+	locals := vm.GetCurrentFrame().Locals
+	size := uint64(int32(locals[1])) + uint64(int32(locals[3])) + uint64(int32(locals[5]))
+	return model.Create2Gas + (size+31)/32*model.Sha3WordGas, nil
+}
+
+// define: void selfDestruct(const char addr[22]);
+// the balance is transferred to the beneficiary and the contract stops, the interpreter keeps the changes
+// of the call like a normal return
+func (r *Resolver) envSelfDestruct(vm *exec.VirtualMachine) int64 {
+	addr := int(int32(vm.GetCurrentFrame().Locals[0]))
+	beneficiary := common.BytesToAddress(vm.Memory.Memory[addr : addr+common.AddressLength])
+	r.Service.SelfDestruct(r.Service.Self(), beneficiary)
+	panic(g_error.ErrSelfDestructed)
+}
+
+func envSelfDestructGasCost(vm *exec.VirtualMachine) (uint64, error) {
+	return model.SelfDestructGas, nil
+}
+
+// readFrameU256 reads the 256 bits big endian value pointed by the local of the frame
+func readFrameU256(vm *exec.VirtualMachine, index int) *big.Int {
+	ptr := int(int32(vm.GetCurrentFrame().Locals[index]))
+	return math.U256(new(big.Int).SetBytes(vm.Memory.Memory[ptr : ptr+32]))
+}
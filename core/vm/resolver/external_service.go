@@ -23,6 +23,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/vm/common/utils"
 	"github.com/dipperin/dipperin-core/core/vm/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
 	"strings"
 )
@@ -44,6 +45,9 @@ type VmContextService interface {
 	//GetCallGasTemp() uint64
 	DelegateCall(caller ContractRef, addr common.Address, input []byte, gas uint64) (ret []byte, leftOverGas uint64, err error)
 	GetTxHash() common.Hash
+	Create(caller ContractRef, data []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error)
+	Create2(caller ContractRef, data []byte, gas uint64, value *big.Int, salt common.Hash) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error)
+	SelfDestruct(contract ContractRef, beneficiary common.Address)
 }
 
 //go:generate mockgen -destination=/home/qydev/go/src/github.com/dipperin/dipperin-core/core/vm/resolver/contract_service_mock_test.go -package=resolver github.com/dipperin/dipperin-core/core/vm/resolver ContractService
@@ -119,3 +123,43 @@ func (service *resolverNeedExternalService) ResolverDelegateCall(addr, param []b
 	ret, _, err := service.DelegateCall(service.ContractService, contractAddr, param, service.GetGas())
 	return ret, err
 }
+
+// ResolverCreate deploys the contract with the code, the abi and the rlp encoded init params,
+// the address is derived from the nonce of the contract, or from the salt if it isn't nil
+func (service *resolverNeedExternalService) ResolverCreate(code, abi, init []byte, value *big.Int, salt *common.Hash, gas uint64) (common.Address, uint64, error) {
+	data, err := createData(code, abi, init)
+	if err != nil {
+		log.Error("ResolverCreate#createData failed", "err", err)
+		return common.Address{}, gas, err
+	}
+
+	log.Info("Call ResolverCreate", "caller", service.Self().Address(), "gas", gas, "value", value, "salt", salt)
+	var contractAddr common.Address
+	var leftOverGas uint64
+	if salt == nil {
+		_, contractAddr, leftOverGas, err = service.Create(service.Self(), data, gas, value)
+	} else {
+		_, contractAddr, leftOverGas, err = service.Create2(service.Self(), data, gas, value, *salt)
+	}
+	return contractAddr, leftOverGas, err
+}
+
+// createData encodes the creation data the same way as the contract creation transaction: RLP(code, abi, params...)
+func createData(code, abi, init []byte) ([]byte, error) {
+	items := []interface{}{code, abi}
+	if len(init) > 0 {
+		content, _, err := rlp.SplitList(init)
+		if err != nil {
+			return nil, err
+		}
+		for len(content) > 0 {
+			_, _, rest, err := rlp.Split(content)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, rlp.RawValue(content[:len(content)-len(rest)]))
+			content = rest
+		}
+	}
+	return rlp.EncodeToBytes(items)
+}
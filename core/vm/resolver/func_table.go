@@ -18,6 +18,7 @@ package resolver
 
 import (
 	"fmt"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/third-party/life/exec"
 )
 
@@ -31,15 +32,23 @@ const (
 
 type Resolver struct {
 	Service resolverNeedExternalService
+	// Tracer captures the host functions called by the contract if it isn't nil
+	Tracer HostTracer
 }
 
 func NewResolver(vmValue VmContextService, contract ContractService, state StateDBService) exec.ImportResolver {
+	return NewTracingResolver(vmValue, contract, state, nil)
+}
+
+// NewTracingResolver returns a resolver passing the traced host functions to the tracer
+func NewTracingResolver(vmValue VmContextService, contract ContractService, state StateDBService, tracer HostTracer) exec.ImportResolver {
 	return &Resolver{
 		Service: resolverNeedExternalService{
 			ContractService:  contract,
 			VmContextService: vmValue,
 			StateDBService:   state,
 		},
+		Tracer: tracer,
 	}
 }
 
@@ -56,7 +65,7 @@ func (r *Resolver) ResolveFunc(module, field string) *exec.FunctionImport {
 
 	if m, exist := sysFunc[module]; exist == true {
 		if f, exist := m[field]; exist == true {
-			return f
+			return r.traceFunc(field, f)
 		} else {
 			return df
 		}
@@ -91,7 +100,7 @@ func newGlobalSet() map[string]map[string]int64 {
 }
 
 func newSystemFuncSet(r *Resolver) map[string]map[string]*exec.FunctionImport {
-	return map[string]map[string]*exec.FunctionImport{
+	funcSet := map[string]map[string]*exec.FunctionImport{
 		"env": {
 			"malloc":  &exec.FunctionImport{Execute: envMalloc, GasCost: envMallocGasCost},
 			"free":    &exec.FunctionImport{Execute: envFree, GasCost: envFreeGasCost},
@@ -146,6 +155,25 @@ func newSystemFuncSet(r *Resolver) map[string]map[string]*exec.FunctionImport {
 			"dipcDelegateCall":       &exec.FunctionImport{Execute: r.envDipperDelegateCall, GasCost: envDipperCallStringGasCost},
 			"dipcDelegateCallInt64":  &exec.FunctionImport{Execute: r.envDipperDelegateCallInt64, GasCost: envDipperCallStringGasCost},
 			"dipcDelegateCallString": &exec.FunctionImport{Execute: r.envDipperDelegateCallString, GasCost: envDipperCallStringGasCost},
+			"dipcRevert":             &exec.FunctionImport{Execute: envRevert, GasCost: envRevertGasCost},
 		},
 	}
+
+	// the contracts can deploy contracts and destroy themselves after the Uranus fork
+	if r.isUranus() {
+		env := funcSet["env"]
+		env["dipcCreate"] = &exec.FunctionImport{Execute: r.envCreate, GasCost: envCreateGasCost}
+		env["dipcCreate2"] = &exec.FunctionImport{Execute: r.envCreate2, GasCost: envCreate2GasCost}
+		env["selfDestruct"] = &exec.FunctionImport{Execute: r.envSelfDestruct, GasCost: envSelfDestructGasCost}
+	}
+	return funcSet
+}
+
+// isUranus returns whether the contract runs in a block after the Uranus fork
+func (r *Resolver) isUranus() bool {
+	if r.Service.VmContextService == nil {
+		return false
+	}
+	number := r.Service.GetBlockNumber()
+	return number != nil && chain_config.GetChainConfig().IsUranus(number.Uint64())
 }
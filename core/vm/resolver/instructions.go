@@ -170,3 +170,17 @@ func MallocString(vm *exec.VirtualMachine, str string) int64 {
 	vm.ExternalParams = append(vm.ExternalParams, int64(pos))
 	return int64(pos)
 }
+
+// MallocBytes copies the bytes into the guest memory and returns the pointer
+func MallocBytes(vm *exec.VirtualMachine, b []byte) int64 {
+	mem := vm.Memory
+	size := len(b)
+	if size == 0 {
+		size = 1
+	}
+
+	pos := mem.Malloc(size)
+	copy(mem.Memory[pos:pos+size], b)
+	vm.ExternalParams = append(vm.ExternalParams, int64(pos))
+	return int64(pos)
+}
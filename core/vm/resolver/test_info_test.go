@@ -144,3 +144,14 @@ func (context *fakeVmContextService) DelegateCall(caller ContractRef, addr commo
 func (context *fakeVmContextService) GetTxHash() common.Hash {
 	return common.HexToHash("txHash")
 }
+
+func (context *fakeVmContextService) Create(caller ContractRef, data []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	return nil, common.Address{}, gas, nil
+}
+
+func (context *fakeVmContextService) Create2(caller ContractRef, data []byte, gas uint64, value *big.Int, salt common.Hash) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	return nil, common.Address{}, gas, nil
+}
+
+func (context *fakeVmContextService) SelfDestruct(contract ContractRef, beneficiary common.Address) {
+}
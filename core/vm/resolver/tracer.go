@@ -0,0 +1,131 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package resolver
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/common/math"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+	"math/big"
+)
+
+// HostTracer captures the host functions called by the contract
+type HostTracer interface {
+	CaptureHostCall(name string, args map[string]interface{})
+}
+
+// hostCallArgs reads the args of the traced host functions from the frame before they are executed
+var hostCallArgs = map[string]func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{}{
+	"setState": func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+		key := readFrameBytes(vm, 0, 1)
+		return map[string]interface{}{
+			"key":   key,
+			"prev":  hexutil.Bytes(r.Service.GetState(r.Service.Address(), key)),
+			"value": readFrameBytes(vm, 2, 3),
+		}
+	},
+	"getState": func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+		key := readFrameBytes(vm, 0, 1)
+		return map[string]interface{}{
+			"key":   key,
+			"value": hexutil.Bytes(r.Service.GetState(r.Service.Address(), key)),
+		}
+	},
+	"emitEvent": func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+		return map[string]interface{}{
+			"topic": string(readFrameBytes(vm, 0, 1)),
+			"data":  readFrameBytes(vm, 2, 3),
+		}
+	},
+	"callTransfer": func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+		locals := vm.GetCurrentFrame().Locals
+		value := int(locals[2])
+		amount := math.U256(new(big.Int).SetBytes(vm.Memory.Memory[value : value+32]))
+		return map[string]interface{}{
+			"to":    common.BytesToAddress(readFrameBytes(vm, 0, 1)),
+			"value": (*hexutil.Big)(amount),
+		}
+	},
+	"dipcRevert": func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+		return map[string]interface{}{
+			"reason": string(readFrameBytes(vm, 0, 1)),
+		}
+	},
+	"dipcCreate": func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+		return dipcCreateArgs(vm)
+	},
+	"dipcCreate2": func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+		args := dipcCreateArgs(vm)
+		salt := int(int32(vm.GetCurrentFrame().Locals[7]))
+		args["salt"] = common.BytesToHash(vm.Memory.Memory[salt : salt+common.HashLength])
+		return args
+	},
+	"selfDestruct": func(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+		addr := int(int32(vm.GetCurrentFrame().Locals[0]))
+		return map[string]interface{}{
+			"beneficiary": common.BytesToAddress(vm.Memory.Memory[addr : addr+common.AddressLength]),
+		}
+	},
+	"dipcCall":               dipcCallArgs,
+	"dipcCallInt64":          dipcCallArgs,
+	"dipcCallString":         dipcCallArgs,
+	"dipcDelegateCall":       dipcCallArgs,
+	"dipcDelegateCallInt64":  dipcCallArgs,
+	"dipcDelegateCallString": dipcCallArgs,
+}
+
+func dipcCreateArgs(vm *exec.VirtualMachine) map[string]interface{} {
+	return map[string]interface{}{
+		"codeHash": common.BytesToHash(crypto.Keccak256(readFrameBytes(vm, 0, 1))),
+		"input":    readFrameBytes(vm, 4, 5),
+		"value":    (*hexutil.Big)(readFrameU256(vm, 6)),
+	}
+}
+
+func dipcCallArgs(r *Resolver, vm *exec.VirtualMachine) map[string]interface{} {
+	addr := int(int32(vm.GetCurrentFrame().Locals[0]))
+	return map[string]interface{}{
+		"to":    common.BytesToAddress(vm.Memory.Memory[addr : addr+common.AddressLength]),
+		"input": readFrameBytes(vm, 1, 2),
+	}
+}
+
+// readFrameBytes copies the memory pointed by the ptr and length locals of the frame
+func readFrameBytes(vm *exec.VirtualMachine, ptrIndex, lenIndex int) hexutil.Bytes {
+	locals := vm.GetCurrentFrame().Locals
+	ptr := int(int32(locals[ptrIndex]))
+	length := int(int32(locals[lenIndex]))
+	return common.CopyBytes(vm.Memory.Memory[ptr : ptr+length])
+}
+
+// traceFunc wraps the host function to capture its call if the resolver is traced
+func (r *Resolver) traceFunc(name string, f *exec.FunctionImport) *exec.FunctionImport {
+	args, traced := hostCallArgs[name]
+	if r.Tracer == nil || !traced {
+		return f
+	}
+
+	return &exec.FunctionImport{
+		Execute: func(vm *exec.VirtualMachine) int64 {
+			r.Tracer.CaptureHostCall(name, args(r, vm))
+			return f.Execute(vm)
+		},
+		GasCost: f.GasCost,
+	}
+}
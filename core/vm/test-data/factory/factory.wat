@@ -0,0 +1,25 @@
+(module
+  (import "env" "dipcCreate" (func $dipcCreate (param i32 i32 i32 i32 i32 i32 i32 i32) (result i32)))
+  (import "env" "dipcCreate2" (func $dipcCreate2 (param i32 i32 i32 i32 i32 i32 i32 i32 i32) (result i32)))
+  (import "env" "selfDestruct" (func $selfDestruct (param i32)))
+  (memory (export "memory") 1)
+  ;; the code and the abi of the revert contract
+  (data (i32.const 1024) "\00\61\73\6d\01\00\00\00\01\09\02\60\02\7f\7f\00\60\00\00\02\12\01\03\65\6e\76\0a\64\69\70\63\52\65\76\65\72\74\00\00\03\03\02\01\01\05\03\01\00\01\07\18\03\06\6d\65\6d\6f\72\79\02\00\04\66\61\69\6c\00\01\04\69\6e\69\74\00\02\0a\0d\02\08\00\41\00\41\03\10\00\0b\02\00\0b\0b\09\01\00\41\00\0b\03\62\61\64")
+  (data (i32.const 2048) "\5b\0a\20\20\20\20\7b\0a\20\20\20\20\20\20\20\20\22\6e\61\6d\65\22\3a\20\22\69\6e\69\74\22\2c\0a\20\20\20\20\20\20\20\20\22\69\6e\70\75\74\73\22\3a\20\5b\5d\2c\0a\20\20\20\20\20\20\20\20\22\6f\75\74\70\75\74\73\22\3a\20\5b\5d\2c\0a\20\20\20\20\20\20\20\20\22\63\6f\6e\73\74\61\6e\74\22\3a\20\22\66\61\6c\73\65\22\2c\0a\20\20\20\20\20\20\20\20\22\74\79\70\65\22\3a\20\22\66\75\6e\63\74\69\6f\6e\22\0a\20\20\20\20\7d\2c\0a\20\20\20\20\7b\0a\20\20\20\20\20\20\20\20\22\6e\61\6d\65\22\3a\20\22\66\61\69\6c\22\2c\0a\20\20\20\20\20\20\20\20\22\69\6e\70\75\74\73\22\3a\20\5b\5d\2c\0a\20\20\20\20\20\20\20\20\22\6f\75\74\70\75\74\73\22\3a\20\5b\5d\2c\0a\20\20\20\20\20\20\20\20\22\63\6f\6e\73\74\61\6e\74\22\3a\20\22\74\72\75\65\22\2c\0a\20\20\20\20\20\20\20\20\22\74\79\70\65\22\3a\20\22\66\75\6e\63\74\69\6f\6e\22\0a\20\20\20\20\7d\0a\5d\0a")
+  ;; salt
+  (data (i32.const 3104) "\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\00\01")
+  ;; beneficiary
+  (data (i32.const 4200) "\00\00\55\86\b8\83\ec\6d\d4\f8\c2\60\63\e1\8e\b4\bd\22\8e\59\c3\e9")
+  (data (i32.const 4300) "\00\14\b5\df\12\f5\02\95\46\9f\e3\39\51\40\3b\8f\4e\63\23\1e\f4\88")
+  (func (export "init"))
+  (func (export "create")
+    (drop (call $dipcCreate (i32.const 1024) (i32.const 101) (i32.const 2048) (i32.const 278) (i32.const 0) (i32.const 0) (i32.const 3072) (i32.const 4096))))
+  (func (export "create2")
+    (drop (call $dipcCreate2 (i32.const 1024) (i32.const 101) (i32.const 2048) (i32.const 278) (i32.const 0) (i32.const 0) (i32.const 3072) (i32.const 3104) (i32.const 4096))))
+  ;; the contract stops in selfDestruct, the unreachable is never executed
+  (func (export "destroy")
+    (call $selfDestruct (i32.const 4200))
+    unreachable)
+  (func (export "destroySelf")
+    (call $selfDestruct (i32.const 4300))
+    unreachable))
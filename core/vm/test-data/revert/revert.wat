@@ -0,0 +1,7 @@
+(module
+  (import "env" "dipcRevert" (func $dipcRevert (param i32 i32)))
+  (memory (export "memory") 1)
+  (data (i32.const 0) "bad")
+  (func (export "fail")
+    (call $dipcRevert (i32.const 0) (i32.const 3)))
+  (func (export "init")))
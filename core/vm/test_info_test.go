@@ -210,6 +210,12 @@ func (state *fakeStateDB) SetAbi(addr common.Address, abi []byte) {
 	state.abiMap[addr] = abi
 }
 
+func (state *fakeStateDB) ClearContract(addr common.Address) {
+	state.codeMap[addr] = []byte{}
+	state.abiMap[addr] = []byte{}
+	state.stateMap[addr] = make(map[string][]byte)
+}
+
 func (state *fakeStateDB) AddRefund(uint64) {
 	panic("implement me")
 }
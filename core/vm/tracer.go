@@ -0,0 +1,139 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/vm/resolver"
+	"math/big"
+)
+
+const (
+	CallFrameCall         = "CALL"
+	CallFrameDelegateCall = "DELEGATECALL"
+	CallFrameCreate       = "CREATE"
+	CallFrameCreate2      = "CREATE2"
+	CallFrameSelfDestruct = "SELFDESTRUCT"
+)
+
+// Tracer captures the contract calls and the host functions called by the contracts,
+// depth is 0 for the call of the transaction
+type Tracer interface {
+	CaptureStart(depth int, typ string, from, to common.Address, input []byte, gas uint64, value *big.Int)
+	CaptureHostCall(depth int, name string, args map[string]interface{})
+	CaptureEnd(depth int, output []byte, gasUsed uint64, err error)
+}
+
+// HostCall is a host function called by the contract
+type HostCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// CallFrame is the trace of a contract call
+type CallFrame struct {
+	Type      string         `json:"type"`
+	From      common.Address `json:"from"`
+	To        common.Address `json:"to"`
+	Value     *hexutil.Big   `json:"value,omitempty"`
+	Gas       hexutil.Uint64 `json:"gas"`
+	GasUsed   hexutil.Uint64 `json:"gasUsed"`
+	Depth     int            `json:"depth"`
+	Input     hexutil.Bytes  `json:"input"`
+	Output    hexutil.Bytes  `json:"output,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	HostCalls []HostCall     `json:"hostCalls,omitempty"`
+	Calls     []*CallFrame   `json:"calls,omitempty"`
+}
+
+// CallTracer builds the tree of the call frames
+type CallTracer struct {
+	root  *CallFrame
+	stack []*CallFrame
+}
+
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+func (t *CallTracer) CaptureStart(depth int, typ string, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+	frame := &CallFrame{
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Gas:   hexutil.Uint64(gas),
+		Depth: depth,
+		Input: common.CopyBytes(input),
+	}
+	if value != nil {
+		frame.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+
+	if len(t.stack) == 0 {
+		t.root = frame
+	} else {
+		parent := t.stack[len(t.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	t.stack = append(t.stack, frame)
+}
+
+func (t *CallTracer) CaptureHostCall(depth int, name string, args map[string]interface{}) {
+	if len(t.stack) == 0 {
+		return
+	}
+	frame := t.stack[len(t.stack)-1]
+	frame.HostCalls = append(frame.HostCalls, HostCall{Name: name, Args: args})
+}
+
+func (t *CallTracer) CaptureEnd(depth int, output []byte, gasUsed uint64, err error) {
+	if len(t.stack) == 0 {
+		return
+	}
+	frame := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+
+	frame.Output = common.CopyBytes(output)
+	frame.GasUsed = hexutil.Uint64(gasUsed)
+	if err != nil {
+		frame.Error = err.Error()
+	}
+}
+
+// Result returns the frame of the transaction call, nil if no contract was called
+func (t *CallTracer) Result() *CallFrame {
+	return t.root
+}
+
+// hostTracer passes the host functions called in the vm to the tracer
+type hostTracer struct {
+	vm *VM
+}
+
+func (h *hostTracer) CaptureHostCall(name string, args map[string]interface{}) {
+	// the depth is increased by the interpreter, the host calls belong to the frame at depth-1
+	h.vm.tracer.CaptureHostCall(h.vm.depth-1, name, args)
+}
+
+// resolverTracer returns the tracer of the host functions, nil if the vm isn't traced
+func (vm *VM) resolverTracer() resolver.HostTracer {
+	if vm.tracer == nil {
+		return nil
+	}
+	return &hostTracer{vm: vm}
+}
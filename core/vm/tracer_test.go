@@ -0,0 +1,103 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the Dipperin-core library.
+//
+// The Dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The Dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/tests/g-testData"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestCallTracer(t *testing.T) {
+	tracer := NewCallTracer()
+	assert.Nil(t, tracer.Result())
+
+	tracer.CaptureHostCall(0, "getState", nil)
+	tracer.CaptureStart(0, CallFrameCall, aliceAddr, contractAddr, []byte{1}, 100, big.NewInt(10))
+	tracer.CaptureHostCall(0, "setState", map[string]interface{}{"key": "0x01"})
+	tracer.CaptureStart(1, CallFrameDelegateCall, contractAddr, common.HexToAddress("0x1234"), []byte{2}, 50, nil)
+	tracer.CaptureHostCall(1, "emitEvent", nil)
+	tracer.CaptureEnd(1, []byte{3}, 20, errors.New("revert"))
+	tracer.CaptureEnd(0, []byte{4}, 60, nil)
+
+	root := tracer.Result()
+	assert.Equal(t, CallFrameCall, root.Type)
+	assert.Equal(t, aliceAddr, root.From)
+	assert.Equal(t, big.NewInt(10), root.Value.ToInt())
+	assert.Equal(t, uint64(60), uint64(root.GasUsed))
+	assert.Equal(t, []byte{4}, []byte(root.Output))
+	assert.Equal(t, "", root.Error)
+	assert.Equal(t, []HostCall{{Name: "setState", Args: map[string]interface{}{"key": "0x01"}}}, root.HostCalls)
+
+	assert.Len(t, root.Calls, 1)
+	child := root.Calls[0]
+	assert.Equal(t, CallFrameDelegateCall, child.Type)
+	assert.Equal(t, 1, child.Depth)
+	assert.Nil(t, child.Value)
+	assert.Equal(t, "revert", child.Error)
+	assert.Equal(t, []HostCall{{Name: "emitEvent"}}, child.HostCalls)
+
+	// ends without frames are ignored
+	tracer.CaptureEnd(0, nil, 0, nil)
+	assert.Equal(t, root, tracer.Result())
+}
+
+func TestVM_Tracer(t *testing.T) {
+	vm := getTestVm()
+	tracer := NewCallTracer()
+	vm.SetTracer(tracer)
+
+	ref := AccountRef(aliceAddr)
+	gasLimit := g_testData.TestGasLimit * 100
+	WASMPath := g_testData.GetWASMPath("event", g_testData.CoreVmTestData)
+	AbiPath := g_testData.GetAbiPath("event", g_testData.CoreVmTestData)
+	data, err := g_testData.GetCreateExtraData(WASMPath, AbiPath, "")
+	assert.NoError(t, err)
+
+	vm.GetStateDB().CreateAccount(ref.Address())
+	vm.GetStateDB().AddBalance(ref.Address(), big.NewInt(10000))
+	_, addr, leftGas, err := vm.Create(ref, data, gasLimit, big.NewInt(0))
+	assert.NoError(t, err)
+
+	frame := tracer.Result()
+	assert.Equal(t, CallFrameCreate, frame.Type)
+	assert.Equal(t, addr, frame.To)
+	assert.Equal(t, gasLimit-leftGas, uint64(frame.GasUsed))
+
+	tracer = NewCallTracer()
+	vm.SetTracer(tracer)
+	data, err = g_testData.GetCallExtraData("returnString", "param")
+	assert.NoError(t, err)
+	_, leftGas, err = vm.Call(ref, addr, data, gasLimit, big.NewInt(0))
+	assert.NoError(t, err)
+
+	frame = tracer.Result()
+	assert.Equal(t, CallFrameCall, frame.Type)
+	assert.Equal(t, aliceAddr, frame.From)
+	assert.Equal(t, addr, frame.To)
+	assert.Equal(t, 0, frame.Depth)
+	assert.Equal(t, gasLimit-leftGas, uint64(frame.GasUsed))
+	assert.NotEmpty(t, frame.Output)
+	assert.Empty(t, frame.Calls)
+	assert.NotEmpty(t, frame.HostCalls)
+	for _, call := range frame.HostCalls {
+		assert.NotEmpty(t, call.Name)
+	}
+}
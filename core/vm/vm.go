@@ -48,6 +48,8 @@ type VM struct {
 	// abort is used to abort the VM calling operations
 	// NOTE: must be set atomically
 	abort int32
+	// tracer captures the calls if it isn't nil
+	tracer Tracer
 }
 
 func NewVM(context Context, state StateDB, config exec.VMConfig) *VM {
@@ -65,6 +67,28 @@ func (vm *VM) GetStateDB() StateDB {
 	return vm.state
 }
 
+// SetTracer sets the tracer to capture the contract calls, nil disables the tracing
+func (vm *VM) SetTracer(tracer Tracer) {
+	vm.tracer = tracer
+}
+
+// captureStart captures the start of a call frame, the returned function captures its end
+func (vm *VM) captureStart(typ string, from, to common.Address, input []byte, gas uint64, value *big.Int) func(ret []byte, leftOverGas uint64, err error) {
+	if vm.tracer == nil {
+		return func([]byte, uint64, error) {}
+	}
+
+	depth := vm.depth
+	vm.tracer.CaptureStart(depth, typ, from, to, input, gas, value)
+	return func(ret []byte, leftOverGas uint64, err error) {
+		gasUsed := uint64(0)
+		if gas > leftOverGas {
+			gasUsed = gas - leftOverGas
+		}
+		vm.tracer.CaptureEnd(depth, ret, gasUsed, err)
+	}
+}
+
 // Cancel cancels any running EVM operation. This may be called concurrently and
 // it's safe to be called multiple times.
 func (vm *VM) Cancel() {
@@ -76,6 +100,9 @@ func (vm *VM) Call(caller resolver.ContractRef, addr common.Address, input []byt
 		return nil, gas, nil
 	}
 
+	captureEnd := vm.captureStart(CallFrameCall, caller.Address(), addr, input, gas, value)
+	defer func() { captureEnd(ret, leftOverGas, err) }()
+
 	// Fail if we're trying to execute above the call depth limit
 	if vm.depth > int(model2.CallCreateDepth) {
 		return nil, gas, g_error.ErrDepth
@@ -146,6 +173,9 @@ func (vm *VM) DelegateCall(caller resolver.ContractRef, addr common.Address, inp
 	if vm.vmConfig.NoRecursion && vm.depth > 0 {
 		return nil, gas, nil
 	}
+
+	captureEnd := vm.captureStart(CallFrameDelegateCall, caller.Address(), addr, input, gas, nil)
+	defer func() { captureEnd(ret, leftOverGas, err) }()
 	// Fail if we're trying to execute above the call depth limit
 	if vm.depth > int(model2.CallCreateDepth) {
 		return nil, gas, g_error.ErrDepth
@@ -181,10 +211,39 @@ func (vm *VM) Create(caller resolver.ContractRef, data []byte, gas uint64, value
 		return nil, common.Address{}, 0, err
 	}
 	contractAddr = cs_crypto.CreateContractAddress(caller.Address(), nonce)
-	return vm.create(caller, data, gas, value, contractAddr)
+	return vm.create(caller, data, gas, value, contractAddr, CallFrameCreate)
+}
+
+// Create2 creates the contract at the address derived from the caller, the salt and the creation data
+// instead of the caller nonce, so the address is known before the contract is deployed
+func (vm *VM) Create2(caller resolver.ContractRef, data []byte, gas uint64, value *big.Int, salt common.Hash) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	contractAddr = cs_crypto.CreateContractAddress2(caller.Address(), salt, cs_crypto.Keccak256Hash(data))
+	return vm.create(caller, data, gas, value, contractAddr, CallFrameCreate2)
+}
+
+// SelfDestruct transfers the whole balance of the contract to the beneficiary and clears its code, abi and data,
+// the balance is burnt if the contract is its own beneficiary
+func (vm *VM) SelfDestruct(contract resolver.ContractRef, beneficiary common.Address) {
+	addr := contract.Address()
+	balance := vm.state.GetBalance(addr)
+	captureEnd := vm.captureStart(CallFrameSelfDestruct, addr, beneficiary, nil, 0, balance)
+	defer captureEnd(nil, 0, nil)
+
+	if beneficiary.IsEqual(addr) {
+		vm.state.SubBalance(addr, balance)
+	} else {
+		if !vm.state.Exist(beneficiary) {
+			vm.state.CreateAccount(beneficiary)
+		}
+		vm.Transfer(vm.state, addr, beneficiary, balance)
+	}
+	vm.state.ClearContract(addr)
 }
 
-func (vm *VM) create(caller resolver.ContractRef, data []byte, gas uint64, value *big.Int, address common.Address) (rest []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+func (vm *VM) create(caller resolver.ContractRef, data []byte, gas uint64, value *big.Int, address common.Address, typ string) (rest []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	// registered before the recover, so the end is captured after the panic is recovered
+	captureEnd := vm.captureStart(typ, caller.Address(), address, data, gas, value)
+	defer func() { captureEnd(rest, leftOverGas, err) }()
 	defer func() {
 		if er := recover(); er != nil {
 			log.Error("VM#create err  ", "err", er)
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/core/vm/common/utils"
 	model2 "github.com/dipperin/dipperin-core/core/vm/model"
@@ -478,3 +479,118 @@ func TestVM_Create_Error(t *testing.T) {
 	_, _, _, err = vm.Create(caller, nil, gasLimit, value)
 	assert.Equal(t, g_error.ErrDepth, err)
 }
+
+func TestVM_Call_Revert(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	defer func(saturn *big.Int) { conf.SaturnBlock = saturn }(conf.SaturnBlock)
+
+	call := func() ([]byte, uint64, error) {
+		vm := getTestVm()
+		ref := AccountRef(aliceAddr)
+		vm.GetStateDB().CreateAccount(ref.Address())
+		vm.GetStateDB().AddBalance(ref.Address(), big.NewInt(10000))
+		vm.GetStateDB().CreateAccount(contractAddr)
+		code, abi := g_testData.GetCodeAbi(g_testData.GetWASMPath("revert", g_testData.CoreVmTestData), g_testData.GetAbiPath("revert", g_testData.CoreVmTestData))
+		vm.GetStateDB().SetCode(contractAddr, code)
+		vm.GetStateDB().SetAbi(contractAddr, abi)
+
+		input, err := rlp.EncodeToBytes([]interface{}{"fail"})
+		assert.NoError(t, err)
+		ret, leftGas, err := vm.Call(ref, contractAddr, input, g_testData.TestGasLimit, big.NewInt(0))
+		return ret, leftGas, err
+	}
+
+	// the revert is an abort before the fork
+	conf.SaturnBlock = nil
+	_, leftGas, err := call()
+	assert.Error(t, err)
+	assert.NotEqual(t, g_error.ErrExecutionReverted, err)
+	assert.Equal(t, uint64(0), leftGas)
+
+	conf.SaturnBlock = big.NewInt(1)
+	ret, leftGas, err := call()
+	assert.Equal(t, g_error.ErrExecutionReverted, err)
+	assert.Equal(t, []byte("bad"), ret)
+	assert.True(t, leftGas > 0)
+	assert.True(t, leftGas < g_testData.TestGasLimit)
+}
+
+func TestVM_Create_FromContract(t *testing.T) {
+	conf := chain_config.GetChainConfig()
+	defer func(uranus *big.Int) { conf.UranusBlock = uranus }(conf.UranusBlock)
+
+	gasLimit := uint64(1000000)
+	childCode, childAbi := g_testData.GetCodeAbi(g_testData.GetWASMPath("revert", g_testData.CoreVmTestData), g_testData.GetAbiPath("revert", g_testData.CoreVmTestData))
+	newFactory := func() *VM {
+		vm := getTestVm()
+		vm.GetStateDB().CreateAccount(aliceAddr)
+		vm.GetStateDB().CreateAccount(contractAddr)
+		vm.GetStateDB().AddBalance(contractAddr, big.NewInt(500))
+		code, abi := g_testData.GetCodeAbi(g_testData.GetWASMPath("factory", g_testData.CoreVmTestData), g_testData.GetAbiPath("factory", g_testData.CoreVmTestData))
+		vm.GetStateDB().SetCode(contractAddr, code)
+		vm.GetStateDB().SetAbi(contractAddr, abi)
+		return vm
+	}
+	call := func(vm *VM, funcName string) (uint64, error) {
+		input, err := rlp.EncodeToBytes([]interface{}{funcName})
+		assert.NoError(t, err)
+		_, leftGas, err := vm.Call(AccountRef(aliceAddr), contractAddr, input, gasLimit, big.NewInt(0))
+		return leftGas, err
+	}
+
+	// the host functions don't exist before the fork
+	conf.UranusBlock = nil
+	_, err := call(newFactory(), "create")
+	assert.Error(t, err)
+
+	conf.UranusBlock = big.NewInt(1)
+	vm := newFactory()
+	leftGas, err := call(vm, "create")
+	assert.NoError(t, err)
+	assert.True(t, gasLimit-leftGas > model2.CreateGas)
+	childAddr := cs_crypto.CreateContractAddress(contractAddr, 0)
+	assert.Equal(t, childCode, vm.GetStateDB().GetCode(childAddr))
+	assert.Equal(t, childAbi, vm.GetStateDB().GetAbi(childAddr))
+	nonce, err := vm.GetStateDB().GetNonce(contractAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+
+	// the address of create2 only depends on the factory, the salt and the creation data
+	vm = newFactory()
+	tracer := NewCallTracer()
+	vm.SetTracer(tracer)
+	_, err = call(vm, "create2")
+	assert.NoError(t, err)
+	data, err := rlp.EncodeToBytes([]interface{}{childCode, childAbi})
+	assert.NoError(t, err)
+	childAddr = cs_crypto.CreateContractAddress2(contractAddr, common.HexToHash("0x01"), cs_crypto.Keccak256Hash(data))
+	assert.Equal(t, childCode, vm.GetStateDB().GetCode(childAddr))
+	frame := tracer.Result()
+	assert.Len(t, frame.Calls, 1)
+	assert.Equal(t, CallFrameCreate2, frame.Calls[0].Type)
+	assert.Equal(t, childAddr, frame.Calls[0].To)
+
+	// the same address can't be created twice
+	_, err = call(vm, "create2")
+	assert.NoError(t, err)
+	assert.Len(t, tracer.Result().Calls, 1)
+	assert.Equal(t, g_error.ErrContractAddressCollision.Error(), tracer.Result().Calls[0].Error)
+
+	// the balance goes to the beneficiary, the contract is cleared and stops before the unreachable
+	leftGas, err = call(vm, "destroy")
+	assert.NoError(t, err)
+	assert.True(t, leftGas > 0)
+	assert.True(t, gasLimit-leftGas > model2.SelfDestructGas)
+	assert.Equal(t, 0, vm.GetStateDB().GetBalance(contractAddr).Sign())
+	assert.Equal(t, big.NewInt(500), vm.GetStateDB().GetBalance(aliceAddr))
+	assert.Empty(t, vm.GetStateDB().GetCode(contractAddr))
+	assert.Empty(t, vm.GetStateDB().GetAbi(contractAddr))
+
+	// the balance is burnt if the contract is its own beneficiary
+	vm = newFactory()
+	_, err = call(vm, "destroySelf")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, vm.GetStateDB().GetBalance(contractAddr).Sign())
+	assert.Equal(t, 0, vm.GetStateDB().GetBalance(aliceAddr).Sign())
+	assert.Empty(t, vm.GetStateDB().GetCode(contractAddr))
+}
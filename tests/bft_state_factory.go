@@ -268,3 +268,7 @@ func (fb *FakeBlockForBft) VersIterator(func(int, model2.AbstractVerification, m
 func (fb *FakeBlockForBft) GetVerifications() []model2.AbstractVerification {
 	panic("implement me")
 }
+
+func (fb *FakeBlockForBft) GetAggregateCommit() *model2.AggregateCommit {
+	panic("implement me")
+}
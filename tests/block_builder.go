@@ -438,11 +438,12 @@ func (builder *BlockBuilder) commitTransactions(txs *model.TransactionsByFeeAndN
 		}
 		//from, _ := tx.Sender(builder.nodeContext.TxSigner())
 		conf := state_processor.TxProcessConfig{
-			Tx:       tx,
-			Header:   header,
-			GetHash:  state.GetBlockHashByNumber,
-			GasLimit: &gasLimit,
-			GasUsed:  &gasUsed,
+			Tx:           tx,
+			Header:       header,
+			GetHash:      state.GetBlockHashByNumber,
+			GetVerifiers: state.GetVerifiersByNumber,
+			GasLimit:     &gasLimit,
+			GasUsed:      &gasUsed,
 		}
 		err := builder.commitTransaction(&conf, state)
 		if err != nil {
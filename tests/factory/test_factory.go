@@ -44,12 +44,12 @@ func CreateTestTx() (*model.Transaction, *model.Transaction) {
 	fs1 := model.NewSigner(big.NewInt(1))
 	fs2 := model.NewSigner(big.NewInt(3))
 	testTx1 := model.NewTransaction(10, common.HexToAddress("0121321432423534534534"), big.NewInt(10000), g_testData.TestGasPrice, g_testData.TestGasLimit, []byte{})
-	gasUsed, _ := model.IntrinsicGas(testTx1.ExtraData(), false, false)
+	gasUsed, _ := model.IntrinsicGas(testTx1.ExtraData(), false, false, false)
 	testTx1.PaddingActualTxFee(big.NewInt(0).Mul(big.NewInt(int64(gasUsed)), testTx1.GetGasPrice()))
 	testTx1.SignTx(key1, fs1)
 
 	testTx2 := model.NewTransaction(10, common.HexToAddress("0121321432423534534535"), big.NewInt(20000), g_testData.TestGasPrice, g_testData.TestGasLimit, []byte{})
-	gasUsed, _ = model.IntrinsicGas(testTx2.ExtraData(), false, false)
+	gasUsed, _ = model.IntrinsicGas(testTx2.ExtraData(), false, false, false)
 	testTx2.PaddingActualTxFee(big.NewInt(0).Mul(big.NewInt(int64(gasUsed)), testTx2.GetGasPrice()))
 	testTx2.SignTx(key2, fs2)
 	return testTx1, testTx2
@@ -71,6 +71,14 @@ func (m *fakeMaster) GetPerformance(address common.Address) uint64 {
 	panic("implement me")
 }
 
+func (m *fakeMaster) GetWorkerBalance(address common.Address) minemaster.WorkerBalance {
+	panic("implement me")
+}
+
+func (m *fakeMaster) GetWorkerBalances() []minemaster.WorkerBalance {
+	panic("implement me")
+}
+
 func (m *fakeMaster) Mining() bool {
 	panic("implement me")
 }
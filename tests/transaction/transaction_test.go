@@ -64,7 +64,7 @@ func TestCalculateMiniTxFee(t *testing.T) {
 
 	log.Info("the txSize is:", "txSize", tempTx.Size(), "txRlpLen", len(txData))
 
-	gasUsed, err := model.IntrinsicGas(extraData, false, false)
+	gasUsed, err := model.IntrinsicGas(extraData, false, false, false)
 	assert.NoError(t, err)
 	log.Info("the gasUsed is:", "gasUsed", gasUsed)
 }
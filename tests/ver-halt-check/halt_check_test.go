@@ -73,6 +73,11 @@ func (c *fakeCacheDB) SaveSeenCommits(blockHeight uint64, blockHash common.Hash,
 	return nil
 }
 
+func (c *fakeCacheDB) DeleteSeenCommits(blockHeight uint64, blockHash common.Hash) error {
+	delete(c.commits, blockHeight)
+	return nil
+}
+
 type fakeTxPool struct{}
 
 func (t *fakeTxPool) AddRemotes(txs []model.AbstractTransaction) []error {
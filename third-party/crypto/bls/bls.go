@@ -0,0 +1,229 @@
+// Package bls implements the BLS signatures on the bn256 curve. The public keys are in G2 and the
+// signatures in G1, the signatures of the same message are aggregated into one signature which is
+// verified by the sum of the public keys. The public keys must come with a proof of possession,
+// otherwise a rogue key could forge an aggregate signature.
+package bls
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/dipperin/dipperin-core/third-party/crypto/bn256"
+	"io"
+	"math/big"
+)
+
+const (
+	SecretKeyLength = 32
+	PublicKeyLength = 128
+	SignatureLength = 64
+)
+
+var (
+	// the order of the groups and the modulus of the base field of the bn256 curve
+	curveOrder, _   = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+	fieldModulus, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
+	// p = 3 mod 4, so the square root of a is a^((p+1)/4)
+	sqrtExponent = new(big.Int).Rsh(new(big.Int).Add(fieldModulus, big.NewInt(1)), 2)
+	curveB       = big.NewInt(3)
+
+	signDomain = []byte("dipperin-bls-sign")
+	popDomain  = []byte("dipperin-bls-pop")
+)
+
+var (
+	ErrInvalidSecretKey = errors.New("bls: invalid secret key")
+	ErrInvalidPublicKey = errors.New("bls: invalid public key")
+	ErrInvalidSignature = errors.New("bls: invalid signature")
+)
+
+type SecretKey struct {
+	x *big.Int
+}
+
+type PublicKey struct {
+	p *bn256.G2
+}
+
+type Signature struct {
+	p *bn256.G1
+}
+
+// GenerateKey creates a random secret key
+func GenerateKey(r io.Reader) (*SecretKey, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+	for {
+		x, err := rand.Int(r, curveOrder)
+		if err != nil {
+			return nil, err
+		}
+		if x.Sign() > 0 {
+			return &SecretKey{x: x}, nil
+		}
+	}
+}
+
+// SecretKeyFromSeed derives the secret key from a secret seed, the same seed always gives the same key
+func SecretKeyFromSeed(seed []byte) (*SecretKey, error) {
+	if len(seed) == 0 {
+		return nil, ErrInvalidSecretKey
+	}
+	h := crypto.Keccak256(seed)
+	for counter := uint32(0); ; counter++ {
+		x := new(big.Int).SetBytes(crypto.Keccak256(h, uint32Bytes(counter)))
+		x.Mod(x, curveOrder)
+		if x.Sign() > 0 {
+			return &SecretKey{x: x}, nil
+		}
+	}
+}
+
+// Marshal encodes the secret key in big endian, it's how the wallets store the key
+func (sk *SecretKey) Marshal() []byte {
+	data := make([]byte, SecretKeyLength)
+	x := sk.x.Bytes()
+	copy(data[SecretKeyLength-len(x):], x)
+	return data
+}
+
+func UnmarshalSecretKey(data []byte) (*SecretKey, error) {
+	if len(data) != SecretKeyLength {
+		return nil, ErrInvalidSecretKey
+	}
+	x := new(big.Int).SetBytes(data)
+	if x.Sign() <= 0 || x.Cmp(curveOrder) >= 0 {
+		return nil, ErrInvalidSecretKey
+	}
+	return &SecretKey{x: x}, nil
+}
+
+func (sk *SecretKey) PublicKey() *PublicKey {
+	return &PublicKey{p: new(bn256.G2).ScalarBaseMult(sk.x)}
+}
+
+// Sign signs the msg, the msg is hashed to the curve so it can be of any length
+func (sk *SecretKey) Sign(msg []byte) *Signature {
+	return &Signature{p: new(bn256.G1).ScalarMult(hashToG1(signDomain, msg), sk.x)}
+}
+
+// ProofOfPossession signs the own public key along with the owner of the key, it proves the key isn't derived
+// from the keys of others, and the proof can't be replayed by another owner
+func (sk *SecretKey) ProofOfPossession(owner []byte) *Signature {
+	return &Signature{p: new(bn256.G1).ScalarMult(hashToG1(popDomain, popMsg(sk.PublicKey(), owner)), sk.x)}
+}
+
+func (pk *PublicKey) Marshal() []byte {
+	return pk.p.Marshal()
+}
+
+func UnmarshalPublicKey(data []byte) (*PublicKey, error) {
+	if len(data) != PublicKeyLength || isZero(data) {
+		return nil, ErrInvalidPublicKey
+	}
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return &PublicKey{p: p}, nil
+}
+
+func (s *Signature) Marshal() []byte {
+	return s.p.Marshal()
+}
+
+func UnmarshalSignature(data []byte) (*Signature, error) {
+	if len(data) != SignatureLength || isZero(data) {
+		return nil, ErrInvalidSignature
+	}
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return &Signature{p: p}, nil
+}
+
+// Verify checks e(sig, g2) == e(H(msg), pk)
+func Verify(pk *PublicKey, msg []byte, sig *Signature) bool {
+	return pairingCheck(pk, hashToG1(signDomain, msg), sig)
+}
+
+func VerifyProofOfPossession(pk *PublicKey, owner []byte, pop *Signature) bool {
+	return pairingCheck(pk, hashToG1(popDomain, popMsg(pk, owner)), pop)
+}
+
+func popMsg(pk *PublicKey, owner []byte) []byte {
+	return append(pk.Marshal(), owner...)
+}
+
+// AggregateSignatures sums the signatures, the result is verified against the sum of the public keys
+func AggregateSignatures(sigs []*Signature) (*Signature, error) {
+	if len(sigs) == 0 {
+		return nil, ErrInvalidSignature
+	}
+	sum := new(bn256.G1).Set(sigs[0].p)
+	for _, sig := range sigs[1:] {
+		sum.Add(sum, sig.p)
+	}
+	return &Signature{p: sum}, nil
+}
+
+func AggregatePublicKeys(pks []*PublicKey) (*PublicKey, error) {
+	if len(pks) == 0 {
+		return nil, ErrInvalidPublicKey
+	}
+	sum := new(bn256.G2).Set(pks[0].p)
+	for _, pk := range pks[1:] {
+		sum.Add(sum, pk.p)
+	}
+	return &PublicKey{p: sum}, nil
+}
+
+func pairingCheck(pk *PublicKey, h *bn256.G1, sig *Signature) bool {
+	g2 := new(bn256.G2).ScalarBaseMult(big.NewInt(1))
+	return bn256.PairingCheck([]*bn256.G1{sig.p, new(bn256.G1).Neg(h)}, []*bn256.G2{g2, pk.p})
+}
+
+// hashToG1 maps the msg to a point of G1 by try-and-increment, nobody knows the discrete log of the point
+func hashToG1(domain, msg []byte) *bn256.G1 {
+	h := crypto.Keccak256(domain, msg)
+	for counter := uint32(0); ; counter++ {
+		x := new(big.Int).SetBytes(crypto.Keccak256(h, uint32Bytes(counter)))
+		x.Mod(x, fieldModulus)
+
+		// y^2 = x^3 + 3
+		rhs := new(big.Int).Exp(x, big.NewInt(3), fieldModulus)
+		rhs.Add(rhs, curveB).Mod(rhs, fieldModulus)
+		y := new(big.Int).Exp(rhs, sqrtExponent, fieldModulus)
+		if new(big.Int).Exp(y, big.NewInt(2), fieldModulus).Cmp(rhs) != 0 {
+			continue
+		}
+
+		point := make([]byte, 0, SignatureLength)
+		point = append(point, common.LeftPadBytes(x.Bytes(), 32)...)
+		point = append(point, common.LeftPadBytes(y.Bytes(), 32)...)
+		p := new(bn256.G1)
+		if _, err := p.Unmarshal(point); err != nil {
+			continue
+		}
+		return p
+	}
+}
+
+func uint32Bytes(n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return b
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
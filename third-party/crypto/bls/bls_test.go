@@ -0,0 +1,99 @@
+package bls
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	sk, err := GenerateKey(nil)
+	assert.NoError(t, err)
+	pk := sk.PublicKey()
+	msg := []byte("vote")
+
+	sig := sk.Sign(msg)
+	assert.True(t, Verify(pk, msg, sig))
+	assert.False(t, Verify(pk, []byte("other vote"), sig))
+
+	other, err := GenerateKey(nil)
+	assert.NoError(t, err)
+	assert.False(t, Verify(other.PublicKey(), msg, sig))
+
+	// the proof of possession can't be used as a signature of the public key, or by another owner
+	owner := []byte("owner")
+	pop := sk.ProofOfPossession(owner)
+	assert.True(t, VerifyProofOfPossession(pk, owner, pop))
+	assert.False(t, VerifyProofOfPossession(pk, []byte("other owner"), pop))
+	assert.False(t, VerifyProofOfPossession(other.PublicKey(), owner, pop))
+	assert.False(t, Verify(pk, append(pk.Marshal(), owner...), pop))
+}
+
+func TestMarshal(t *testing.T) {
+	sk, err := SecretKeyFromSeed([]byte("seed"))
+	assert.NoError(t, err)
+	same, err := SecretKeyFromSeed([]byte("seed"))
+	assert.NoError(t, err)
+	assert.Equal(t, sk.PublicKey().Marshal(), same.PublicKey().Marshal())
+	_, err = SecretKeyFromSeed(nil)
+	assert.Equal(t, ErrInvalidSecretKey, err)
+
+	restored, err := UnmarshalSecretKey(sk.Marshal())
+	assert.NoError(t, err)
+	assert.Equal(t, sk.PublicKey().Marshal(), restored.PublicKey().Marshal())
+	_, err = UnmarshalSecretKey(make([]byte, SecretKeyLength))
+	assert.Equal(t, ErrInvalidSecretKey, err)
+	_, err = UnmarshalSecretKey(curveOrder.Bytes())
+	assert.Equal(t, ErrInvalidSecretKey, err)
+	_, err = UnmarshalSecretKey(sk.Marshal()[1:])
+	assert.Equal(t, ErrInvalidSecretKey, err)
+
+	pk, err := UnmarshalPublicKey(sk.PublicKey().Marshal())
+	assert.NoError(t, err)
+	sig, err := UnmarshalSignature(sk.Sign([]byte("vote")).Marshal())
+	assert.NoError(t, err)
+	assert.True(t, Verify(pk, []byte("vote"), sig))
+
+	_, err = UnmarshalPublicKey(make([]byte, PublicKeyLength))
+	assert.Equal(t, ErrInvalidPublicKey, err)
+	_, err = UnmarshalPublicKey(sig.Marshal())
+	assert.Equal(t, ErrInvalidPublicKey, err)
+	broken := pk.Marshal()
+	broken[10] ^= 1
+	_, err = UnmarshalPublicKey(broken)
+	assert.Equal(t, ErrInvalidPublicKey, err)
+
+	_, err = UnmarshalSignature(make([]byte, SignatureLength))
+	assert.Equal(t, ErrInvalidSignature, err)
+	broken = sig.Marshal()
+	broken[10] ^= 1
+	_, err = UnmarshalSignature(broken)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestAggregate(t *testing.T) {
+	msg := []byte("commit")
+	var sigs []*Signature
+	var pks []*PublicKey
+	for i := 0; i < 5; i++ {
+		sk, err := GenerateKey(nil)
+		assert.NoError(t, err)
+		sigs = append(sigs, sk.Sign(msg))
+		pks = append(pks, sk.PublicKey())
+	}
+
+	sig, err := AggregateSignatures(sigs)
+	assert.NoError(t, err)
+	pk, err := AggregatePublicKeys(pks)
+	assert.NoError(t, err)
+	assert.True(t, Verify(pk, msg, sig))
+
+	// a missing signer fails the aggregate
+	pk, err = AggregatePublicKeys(pks[1:])
+	assert.NoError(t, err)
+	assert.False(t, Verify(pk, msg, sig))
+
+	_, err = AggregateSignatures(nil)
+	assert.Equal(t, ErrInvalidSignature, err)
+	_, err = AggregatePublicKeys(nil)
+	assert.Equal(t, ErrInvalidPublicKey, err)
+}
@@ -97,6 +97,18 @@ func GetLockAddress(alice, bob common.Address) common.Address {
 	return common.BytesToAddress(append(tmpTypeB[:], tmpAddr...))
 }
 
+// GetMultiSigAddress returns the address of the multisig account, the signers should be sorted
+func GetMultiSigAddress(threshold uint64, signers []common.Address) common.Address {
+	res, err := rlp.EncodeToBytes([]interface{}{threshold, signers})
+	if err != nil {
+		return common.Address{}
+	}
+	var tmpTypeB [2]byte
+	binary.BigEndian.PutUint16(tmpTypeB[:], uint16(common.AddressTypeMultiSig))
+	tmpAddr := crypto.Keccak256(res[:])[12:]
+	return common.BytesToAddress(append(tmpTypeB[:], tmpAddr...))
+}
+
 func GetEvidenceAddress(target common.Address) common.Address {
 	var tmpType [2]byte
 	binary.BigEndian.PutUint16(tmpType[:], uint16(common.AddressTypeEvidence))
@@ -137,6 +149,17 @@ func CreateContractAddress(b common.Address, nonce uint64) common.Address {
 	return common.BytesToAddress(addr)
 }
 
+// CreateContractAddress2 returns the deterministic address of the contract created with the salt,
+// it only depends on the creator, the salt and the hash of the creation data
+func CreateContractAddress2(b common.Address, salt common.Hash, dataHash common.Hash) common.Address {
+	var tmpTypeB [2]byte
+	binary.BigEndian.PutUint16(tmpTypeB[:], uint16(common.AddressTypeContractCall))
+	data, _ := rlp.EncodeToBytes([]interface{}{b, salt, dataHash})
+	tempAddr := crypto.Keccak256(data)[12:]
+	addr := append(tmpTypeB[:], tempAddr...)
+	return common.BytesToAddress(addr)
+}
+
 //func zeroBytes(bytes []byte) {
 //	for i := range bytes {
 //		bytes[i] = 0
@@ -163,3 +163,25 @@ func checkhash(t *testing.T, name string, f func([]byte) []byte, msg, exp []byte
 		t.Fatalf("hash %s mismatch: want: %x have: %x", name, exp, sum)
 	}
 }
+
+func TestCreateContractAddress2(t *testing.T) {
+	addr := common.HexToAddress(testAddrHex)
+	salt := common.HexToHash("0x01")
+	dataHash := Keccak256Hash([]byte("code"))
+
+	caddr := CreateContractAddress2(addr, salt, dataHash)
+	if caddr.GetAddressType() != common.AddressTypeContractCall {
+		t.Fatalf("address type mismatch: have: %v", caddr.GetAddressType())
+	}
+	checkAddr(t, caddr, CreateContractAddress2(addr, salt, dataHash))
+
+	if CreateContractAddress2(addr, common.HexToHash("0x02"), dataHash) == caddr {
+		t.Fatal("the salt doesn't change the address")
+	}
+	if CreateContractAddress2(addr, salt, Keccak256Hash([]byte("other"))) == caddr {
+		t.Fatal("the creation data doesn't change the address")
+	}
+	if CreateContractAddress(addr, 0) == caddr {
+		t.Fatal("the address collides with the nonce address")
+	}
+}
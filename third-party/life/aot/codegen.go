@@ -0,0 +1,712 @@
+package aot
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dipperin/dipperin-core/third-party/life/compiler/opcodes"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+)
+
+var (
+	errInvalidCode     = errors.New("aot: invalid interpreter code")
+	errGasInstructions = errors.New("aot: the gas counters of a gas policy aren't supported")
+)
+
+var le = binary.LittleEndian
+
+// Program is the C source of a module. The code isn't compiled from the wasm but from the code of the
+// interpreter, every instruction of the interpreter is translated to C with the same result, so the
+// native code has the same semantics as the interpreter including its quirks.
+//
+// The gas is charged by the segments of the code, a segment is a run of instructions without branch
+// targets, branches or calls in the middle. Its gas is charged before its first instruction, which
+// fails the same calls as charging every instruction since nothing in a segment reads the gas.
+// The host functions and the growth of the memory are charged by go like the interpreter does.
+type Program struct {
+	Source string
+
+	// the instructions of every segment
+	segments [][]opcodes.Opcode
+	// the instructions in the segments
+	usedOps []opcodes.Opcode
+}
+
+// SegmentGas returns the gas of the segments for the costs of the instructions
+func (p *Program) SegmentGas(costs *[256]uint64) []uint64 {
+	gas := make([]uint64, len(p.segments))
+	for i, seg := range p.segments {
+		for _, op := range seg {
+			gas[i] += costs[op]
+		}
+	}
+	return gas
+}
+
+type instruction struct {
+	pos     int
+	valueID uint32
+	op      opcodes.Opcode
+	args    []uint32
+	imm     uint64
+}
+
+// Generate translates the interpreter code of a module to C
+func Generate(compiled *exec.CompiledModule) (*Program, error) {
+	g := &generator{module: compiled, out: &strings.Builder{}, used: map[opcodes.Opcode]bool{}}
+	if err := g.generate(); err != nil {
+		return nil, err
+	}
+	p := &Program{Source: g.out.String(), segments: g.segments}
+	for op := range g.used {
+		p.usedOps = append(p.usedOps, op)
+	}
+	return p, nil
+}
+
+type generator struct {
+	module   *exec.CompiledModule
+	out      *strings.Builder
+	segments [][]opcodes.Opcode
+	used     map[opcodes.Opcode]bool
+	maxArgs  int
+
+	// the function being generated
+	code    *funcCode
+	seg     int
+	resumes int
+	// whether the instruction uses a register out of the range
+	bad bool
+}
+
+type funcCode struct {
+	id      int
+	numRegs uint32
+	// params and locals
+	numLocals uint32
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(g.out, format, args...)
+}
+
+func (g *generator) generate() error {
+	functions := g.module.FunctionCode
+	g.printf("/* aot abi %d */\n", abiVersion)
+	g.out.WriteString(runtimeHeader)
+	for i := range functions {
+		g.printf("static int f%d(struct aot_state *s);\n", i)
+	}
+	g.printf("static const struct aot_meta aot_meta[] = {\n")
+	for _, code := range functions {
+		g.printf("\t{%d, %d, %d, %d},\n", code.NumRegs, code.NumParams, code.NumLocals, code.NumReturns)
+	}
+	g.printf("\t{0, 0, 0, 0},\n};\n")
+	g.printf("static const uint32_t aot_num_funcs = %d;\n\n", len(functions))
+
+	for i, code := range functions {
+		if err := g.function(i, code.NumRegs, code.NumParams+code.NumLocals, code.Bytes); err != nil {
+			return fmt.Errorf("function %d: %v", i, err)
+		}
+	}
+
+	g.printf("static const aot_func aot_funcs[] = {\n")
+	for i := range functions {
+		g.printf("\tf%d,\n", i)
+	}
+	g.printf("\t0,\n};\n")
+	g.printf("static const uint64_t aot_max_args = %d;\n", g.maxArgs)
+	g.printf("const uint64_t aot_abi = %d;\n", abiVersion)
+	g.out.WriteString(runtimeDriver)
+	return nil
+}
+
+// decode splits the code to instructions like the interpreter reads them
+func decode(code []byte) ([]instruction, error) {
+	var ins []instruction
+	pos := 0
+	u32 := func(at int) (uint32, bool) {
+		if at < 0 || at+4 > len(code) {
+			return 0, false
+		}
+		return le.Uint32(code[at : at+4]), true
+	}
+	for pos < len(code) {
+		if pos+5 > len(code) {
+			return nil, errInvalidCode
+		}
+		in := instruction{pos: pos, valueID: le.Uint32(code[pos : pos+4]), op: opcodes.Opcode(code[pos+4])}
+		at := pos + 5
+		n := 0
+		switch in.op {
+		case opcodes.Nop, opcodes.Unreachable, opcodes.ReturnVoid, opcodes.CurrentMemory, opcodes.Phi,
+			opcodes.FPDisabledError:
+			n = 0
+		case opcodes.I64Const, opcodes.AddGas:
+			if at+8 > len(code) {
+				return nil, errInvalidCode
+			}
+			in.imm = le.Uint64(code[at : at+8])
+			at += 8
+		case opcodes.JmpTable:
+			count, ok := u32(at)
+			if !ok || uint64(count) > uint64(len(code)) {
+				return nil, errInvalidCode
+			}
+			n = 1 + int(count) + 3
+		case opcodes.Call, opcodes.CallIndirect:
+			count, ok := u32(at + 4)
+			if !ok || uint64(count) > uint64(len(code)) {
+				return nil, errInvalidCode
+			}
+			n = 2 + int(count)
+		default:
+			var ok bool
+			if n, ok = operandCount(in.op); !ok {
+				return nil, errInvalidCode
+			}
+		}
+		for i := 0; i < n; i++ {
+			v, ok := u32(at)
+			if !ok {
+				return nil, errInvalidCode
+			}
+			in.args = append(in.args, v)
+			at += 4
+		}
+		ins = append(ins, in)
+		pos = at
+	}
+	return ins, nil
+}
+
+// operandCount returns the number of the 32 bits operands of the instructions with fixed operands
+func operandCount(op opcodes.Opcode) (int, bool) {
+	switch op {
+	case opcodes.I32Const, opcodes.ReturnValue, opcodes.GetLocal, opcodes.GetGlobal, opcodes.InvokeImport,
+		opcodes.GrowMemory:
+		return 1, true
+	case opcodes.SetLocal, opcodes.SetGlobal, opcodes.Jmp:
+		return 2, true
+	case opcodes.Select, opcodes.JmpIf:
+		return 3, true
+	case opcodes.JmpEither:
+		return 4, true
+	}
+	if _, ok := unaryOps[op]; ok {
+		return 1, true
+	}
+	if _, ok := binaryOps[op]; ok {
+		return 2, true
+	}
+	if _, ok := loadOps[op]; ok {
+		return 3, true
+	}
+	if _, ok := storeOps[op]; ok {
+		return 4, true
+	}
+	return 0, false
+}
+
+// the C expressions of the instructions of the interpreter, a and b are the operands
+var binaryOps = map[opcodes.Opcode]string{
+	opcodes.I32Add: "(int64_t)(int32_t)((uint32_t)a + (uint32_t)b)",
+	opcodes.I32Sub: "(int64_t)(int32_t)((uint32_t)a - (uint32_t)b)",
+	opcodes.I32Mul: "(int64_t)(int32_t)((uint32_t)a * (uint32_t)b)",
+	opcodes.I32And: "(int64_t)((int32_t)a & (int32_t)b)",
+	opcodes.I32Or:  "(int64_t)((int32_t)a | (int32_t)b)",
+	opcodes.I32Xor: "(int64_t)((int32_t)a ^ (int32_t)b)",
+	opcodes.I32Shl: "(int64_t)(int32_t)((uint32_t)a << ((uint32_t)b % 32))",
+	opcodes.I32ShrS: "(int64_t)((int32_t)a >> ((uint32_t)b % 32))",
+	opcodes.I32ShrU: "(int64_t)((uint32_t)a >> ((uint32_t)b % 32))",
+	opcodes.I32Rotl: "(int64_t)rotl32((uint32_t)a, (uint32_t)b)",
+	opcodes.I32Rotr: "(int64_t)rotl32((uint32_t)a, 0u - (uint32_t)b)",
+	opcodes.I32Eq:   "(int64_t)((int32_t)a == (int32_t)b)",
+	opcodes.I32Ne:   "(int64_t)((int32_t)a != (int32_t)b)",
+	opcodes.I32LtS:  "(int64_t)((int32_t)a < (int32_t)b)",
+	opcodes.I32LtU:  "(int64_t)((uint32_t)a < (uint32_t)b)",
+	opcodes.I32LeS:  "(int64_t)((int32_t)a <= (int32_t)b)",
+	opcodes.I32LeU:  "(int64_t)((uint32_t)a <= (uint32_t)b)",
+	opcodes.I32GtS:  "(int64_t)((int32_t)a > (int32_t)b)",
+	opcodes.I32GtU:  "(int64_t)((uint32_t)a > (uint32_t)b)",
+	opcodes.I32GeS:  "(int64_t)((int32_t)a >= (int32_t)b)",
+	opcodes.I32GeU:  "(int64_t)((uint32_t)a >= (uint32_t)b)",
+
+	opcodes.I64Add:  "(int64_t)((uint64_t)a + (uint64_t)b)",
+	opcodes.I64Sub:  "(int64_t)((uint64_t)a - (uint64_t)b)",
+	opcodes.I64Mul:  "(int64_t)((uint64_t)a * (uint64_t)b)",
+	opcodes.I64And:  "a & b",
+	opcodes.I64Or:   "a | b",
+	opcodes.I64Xor:  "a ^ b",
+	opcodes.I64Shl:  "(int64_t)((uint64_t)a << ((uint64_t)b % 64))",
+	opcodes.I64ShrS: "a >> ((uint64_t)b % 64)",
+	opcodes.I64ShrU: "(int64_t)((uint64_t)a >> ((uint64_t)b % 64))",
+	opcodes.I64Rotl: "(int64_t)rotl64((uint64_t)a, (uint64_t)b)",
+	opcodes.I64Rotr: "(int64_t)rotl64((uint64_t)a, 0u - (uint64_t)b)",
+	opcodes.I64Eq:   "(int64_t)(a == b)",
+	opcodes.I64Ne:   "(int64_t)(a != b)",
+	opcodes.I64LtS:  "(int64_t)(a < b)",
+	opcodes.I64LtU:  "(int64_t)((uint64_t)a < (uint64_t)b)",
+	opcodes.I64LeS:  "(int64_t)(a <= b)",
+	opcodes.I64LeU:  "(int64_t)((uint64_t)a <= (uint64_t)b)",
+	opcodes.I64GtS:  "(int64_t)(a > b)",
+	opcodes.I64GtU:  "(int64_t)((uint64_t)a > (uint64_t)b)",
+	opcodes.I64GeS:  "(int64_t)(a >= b)",
+	opcodes.I64GeU:  "(int64_t)((uint64_t)a >= (uint64_t)b)",
+
+	opcodes.F32Add:      "N32(F32(a) + F32(b))",
+	opcodes.F32Sub:      "N32(F32(a) - F32(b))",
+	opcodes.F32Mul:      "N32(F32(a) * F32(b))",
+	opcodes.F32Div:      "N32(F32(a) / F32(b))",
+	opcodes.F32Min:      "N32((float)go_min((double)F32(a), (double)F32(b)))",
+	opcodes.F32Max:      "N32((float)go_max((double)F32(a), (double)F32(b)))",
+	opcodes.F32CopySign: "N32((float)copysign((double)F32(a), (double)F32(b)))",
+	opcodes.F32Eq:       "(int64_t)(F32(a) == F32(b))",
+	opcodes.F32Ne:       "(int64_t)(F32(a) != F32(b))",
+	opcodes.F32Lt:       "(int64_t)(F32(a) < F32(b))",
+	opcodes.F32Le:       "(int64_t)(F32(a) <= F32(b))",
+	opcodes.F32Gt:       "(int64_t)(F32(a) > F32(b))",
+	opcodes.F32Ge:       "(int64_t)(F32(a) >= F32(b))",
+
+	opcodes.F64Add:      "N64(F64(a) + F64(b))",
+	opcodes.F64Sub:      "N64(F64(a) - F64(b))",
+	opcodes.F64Mul:      "N64(F64(a) * F64(b))",
+	opcodes.F64Div:      "N64(F64(a) / F64(b))",
+	opcodes.F64Min:      "N64(go_min(F64(a), F64(b)))",
+	opcodes.F64Max:      "N64(go_max(F64(a), F64(b)))",
+	opcodes.F64CopySign: "N64(copysign(F64(a), F64(b)))",
+	opcodes.F64Eq:       "(int64_t)(F64(a) == F64(b))",
+	opcodes.F64Ne:       "(int64_t)(F64(a) != F64(b))",
+	opcodes.F64Lt:       "(int64_t)(F64(a) < F64(b))",
+	opcodes.F64Le:       "(int64_t)(F64(a) <= F64(b))",
+	opcodes.F64Gt:       "(int64_t)(F64(a) > F64(b))",
+	opcodes.F64Ge:       "(int64_t)(F64(a) >= F64(b))",
+}
+
+// the divisions trap like the interpreter, the remainder of the minimum integer by -1 is 0 in go
+var divisionOps = map[opcodes.Opcode]string{
+	opcodes.I32DivS: "int32_t x = (int32_t)a, y = (int32_t)b; if (y == 0) TRAP(TRAP_DIV_ZERO); if (x == INT32_MIN && y == -1) TRAP(TRAP_OVERFLOW); v = (int64_t)(x / y);",
+	opcodes.I32DivU: "uint32_t x = (uint32_t)a, y = (uint32_t)b; if (y == 0) TRAP(TRAP_DIV_ZERO); v = (int64_t)(x / y);",
+	opcodes.I32RemS: "int32_t x = (int32_t)a, y = (int32_t)b; if (y == 0) TRAP(TRAP_DIV_ZERO); v = y == -1 ? 0 : (int64_t)(x % y);",
+	opcodes.I32RemU: "uint32_t x = (uint32_t)a, y = (uint32_t)b; if (y == 0) TRAP(TRAP_DIV_ZERO); v = (int64_t)(x % y);",
+	opcodes.I64DivS: "if (b == 0) TRAP(TRAP_DIV_ZERO); if (a == INT64_MIN && b == -1) TRAP(TRAP_OVERFLOW); v = a / b;",
+	opcodes.I64DivU: "if (b == 0) TRAP(TRAP_DIV_ZERO); v = (int64_t)((uint64_t)a / (uint64_t)b);",
+	opcodes.I64RemS: "if (b == 0) TRAP(TRAP_DIV_ZERO); v = b == -1 ? 0 : a % b;",
+	opcodes.I64RemU: "if (b == 0) TRAP(TRAP_DIV_ZERO); v = (int64_t)((uint64_t)a % (uint64_t)b);",
+}
+
+var unaryOps = map[opcodes.Opcode]string{
+	opcodes.I32Clz:    "clz32((uint32_t)a)",
+	opcodes.I32Ctz:    "ctz32((uint32_t)a)",
+	opcodes.I32PopCnt: "(int64_t)__builtin_popcount((uint32_t)a)",
+	opcodes.I32EqZ:    "(int64_t)((uint32_t)a == 0)",
+	opcodes.I64Clz:    "clz64((uint64_t)a)",
+	opcodes.I64Ctz:    "ctz64((uint64_t)a)",
+	opcodes.I64PopCnt: "(int64_t)__builtin_popcountll((uint64_t)a)",
+	opcodes.I64EqZ:    "(int64_t)((uint64_t)a == 0)",
+
+	opcodes.F32Sqrt:    "N32((float)sqrt((double)F32(a)))",
+	opcodes.F32Ceil:    "N32((float)ceil((double)F32(a)))",
+	opcodes.F32Floor:   "N32((float)floor((double)F32(a)))",
+	opcodes.F32Trunc:   "N32((float)trunc((double)F32(a)))",
+	opcodes.F32Nearest: "N32((float)nearbyint((double)F32(a)))",
+	opcodes.F32Abs:     "N32((float)fabs((double)F32(a)))",
+	opcodes.F32Neg:     "N32(-F32(a))",
+	opcodes.F64Sqrt:    "N64(sqrt(F64(a)))",
+	opcodes.F64Ceil:    "N64(ceil(F64(a)))",
+	opcodes.F64Floor:   "N64(floor(F64(a)))",
+	opcodes.F64Trunc:   "N64(trunc(F64(a)))",
+	opcodes.F64Nearest: "N64(nearbyint(F64(a)))",
+	opcodes.F64Abs:     "N64(fabs(F64(a)))",
+	opcodes.F64Neg:     "N64(-F64(a))",
+
+	opcodes.I32WrapI64:     "(int64_t)(uint32_t)a",
+	opcodes.I32TruncSF32:   "({ float c = (float)trunc((double)F32(a)); c != c ? 0x7FC00000 : trunc_f32_i32(c); })",
+	opcodes.I32TruncUF32:   "({ float c = (float)trunc((double)F32(a)); c != c ? 0x7FC00000 : trunc_f32_i32(c); })",
+	opcodes.I32TruncSF64:   "({ double c = trunc(F64(a)); c != c ? 0x7FC00000 : trunc_f64_i32(c); })",
+	opcodes.I32TruncUF64:   "({ double c = trunc(F64(a)); c != c ? 0x7FC00000 : trunc_f64_i32(c); })",
+	opcodes.I64TruncSF32:   "({ double c = trunc((double)F32(a)); c != c ? 0x7FF8000000000001LL : trunc_f64_i64(c); })",
+	opcodes.I64TruncUF32:   "({ double c = trunc((double)F32(a)); c != c ? 0x7FF8000000000001LL : trunc_f64_i64(c); })",
+	opcodes.I64TruncSF64:   "({ double c = trunc(F64(a)); c != c ? 0x7FF8000000000001LL : trunc_f64_i64(c); })",
+	opcodes.I64TruncUF64:   "({ double c = trunc(F64(a)); c != c ? 0x7FF8000000000001LL : trunc_f64_i64(c); })",
+	opcodes.F32DemoteF64:   "B32((float)F64(a))",
+	opcodes.F64PromoteF32:  "B64((double)F32(a))",
+	opcodes.F32ConvertSI32: "B32((float)(int32_t)a)",
+	opcodes.F32ConvertUI32: "B32((float)(uint32_t)a)",
+	opcodes.F32ConvertSI64: "B32((float)a)",
+	opcodes.F32ConvertUI64: "B32((float)(uint64_t)a)",
+	opcodes.F64ConvertSI32: "(int64_t)(int32_t)(uint32_t)B64((double)(int32_t)a)",
+	opcodes.F64ConvertUI32: "(int64_t)(int32_t)(uint32_t)B64((double)(uint32_t)a)",
+	opcodes.F64ConvertSI64: "B64((double)a)",
+	opcodes.F64ConvertUI64: "B64((double)(uint64_t)a)",
+	opcodes.I64ExtendUI32:  "(int64_t)(uint32_t)a",
+	opcodes.I64ExtendSI32:  "(int64_t)(int32_t)a",
+}
+
+type memOp struct {
+	size int
+	// the C expression of the loaded value or the stored value
+	expr string
+}
+
+// the accesses of more than one byte slice the memory and are checked by its capacity in the
+// interpreter, the accesses of one byte index it and are checked by its length
+var loadOps = map[opcodes.Opcode]memOp{
+	opcodes.I32Load:    {4, "(int64_t)ld32(m + ea)"},
+	opcodes.I64Load32U: {4, "(int64_t)ld32(m + ea)"},
+	opcodes.I64Load32S: {4, "(int64_t)(int32_t)ld32(m + ea)"},
+	opcodes.I64Load:    {8, "(int64_t)ld64(m + ea)"},
+	opcodes.I32Load8S:  {1, "(int64_t)(int8_t)m[ea]"},
+	opcodes.I64Load8S:  {1, "(int64_t)(int8_t)m[ea]"},
+	opcodes.I32Load8U:  {1, "(int64_t)m[ea]"},
+	opcodes.I64Load8U:  {1, "(int64_t)m[ea]"},
+	opcodes.I32Load16S: {2, "(int64_t)(int16_t)ld16(m + ea)"},
+	opcodes.I64Load16S: {2, "(int64_t)(int16_t)ld16(m + ea)"},
+	opcodes.I32Load16U: {2, "(int64_t)ld16(m + ea)"},
+	opcodes.I64Load16U: {2, "(int64_t)ld16(m + ea)"},
+}
+
+var storeOps = map[opcodes.Opcode]memOp{
+	opcodes.I32Store:   {4, "st32(m + ea, (uint32_t)x)"},
+	opcodes.I64Store32: {4, "st32(m + ea, (uint32_t)x)"},
+	opcodes.I64Store:   {8, "st64(m + ea, (uint64_t)x)"},
+	opcodes.I32Store8:  {1, "m[ea] = (uint8_t)x"},
+	opcodes.I64Store8:  {1, "m[ea] = (uint8_t)x"},
+	opcodes.I32Store16: {2, "st16(m + ea, (uint16_t)x)"},
+	opcodes.I64Store16: {2, "st16(m + ea, (uint16_t)x)"},
+}
+
+func init() {
+	for op := range divisionOps {
+		binaryOps[op] = ""
+	}
+}
+
+// reg returns the C expression of a register. The interpreter panics when an instruction reads or
+// writes a register out of the range, such an instruction is marked and replaced by a trap.
+func (g *generator) reg(i uint32) string {
+	if i >= g.code.numRegs {
+		g.bad = true
+		return "0"
+	}
+	return fmt.Sprintf("r[%d]", i)
+}
+
+// setReg returns the C statement which writes a value to a register
+func (g *generator) setReg(i uint32, value string) string {
+	if i >= g.code.numRegs {
+		g.bad = true
+	}
+	return fmt.Sprintf("r[%d] = %s;", i, value)
+}
+
+// openSegment starts a segment at an instruction
+func (g *generator) openSegment() {
+	g.seg = len(g.segments)
+	g.segments = append(g.segments, nil)
+	g.printf("\tCHARGE(%d);\n", g.seg)
+}
+
+func (g *generator) charge(op opcodes.Opcode) {
+	g.segments[g.seg] = append(g.segments[g.seg], op)
+	g.used[op] = true
+}
+
+func (g *generator) function(id int, numRegs, numLocals int, code []byte) error {
+	ins, err := decode(code)
+	if err != nil {
+		return err
+	}
+	starts := map[int]bool{}
+	for _, in := range ins {
+		starts[in.pos] = true
+	}
+
+	// the branch targets
+	targets := map[int]bool{}
+	addTarget := func(t uint32) error {
+		if !starts[int(t)] {
+			if int(t) == len(code) {
+				// the interpreter fails at the end of the code
+				targets[int(t)] = true
+				return nil
+			}
+			return errInvalidCode
+		}
+		targets[int(t)] = true
+		return nil
+	}
+	for _, in := range ins {
+		var ts []uint32
+		switch in.op {
+		case opcodes.Jmp, opcodes.JmpIf:
+			ts = in.args[:1]
+		case opcodes.JmpEither:
+			ts = in.args[:2]
+		case opcodes.JmpTable:
+			ts = in.args[1 : len(in.args)-2]
+		case opcodes.AddGas:
+			return errGasInstructions
+		}
+		for _, t := range ts {
+			if err := addTarget(t); err != nil {
+				return err
+			}
+		}
+	}
+
+	g.code = &funcCode{id: id, numRegs: uint32(numRegs), numLocals: uint32(numLocals)}
+	g.resumes = 0
+	body := &strings.Builder{}
+	out := g.out
+	g.out = body
+
+	open := false
+	for _, in := range ins {
+		if targets[in.pos] {
+			g.printf("L%d:\n", in.pos)
+			open = false
+		}
+		dynamic := in.op == opcodes.InvokeImport || in.op == opcodes.GrowMemory
+		if !open && !dynamic {
+			g.openSegment()
+			open = true
+		}
+		if !dynamic {
+			g.charge(in.op)
+		}
+		ends, err := g.translate(in)
+		if err != nil {
+			g.out = out
+			return err
+		}
+		if ends || dynamic || in.op == opcodes.Call || in.op == opcodes.CallIndirect {
+			open = false
+		}
+	}
+	if targets[len(code)] {
+		g.printf("L%d:\n", len(code))
+	}
+	// the interpreter fails when it runs out of the code
+	g.printf("\tTRAP(TRAP_INDEX);\n")
+
+	g.out = out
+	g.printf("static int f%d(struct aot_state *s) {\n", id)
+	g.printf("\tstruct aot_frame *fr = &s->frames[s->depth];\n")
+	g.printf("\tint64_t *restrict r = s->slots + fr->base;\n")
+	g.printf("\tint64_t *restrict l = r + %d;\n", numRegs)
+	g.printf("\tuint8_t *m = s->io.mem;\n")
+	g.printf("\tconst uint64_t ml = s->io.mem_len, mc = s->io.mem_cap;\n")
+	g.printf("\tconst uint64_t *sc = s->io.seg_gas;\n")
+	g.printf("\tconst uint64_t lim = s->io.gas_limit;\n")
+	g.printf("\tuint64_t g = s->io.gas_used;\n")
+	g.printf("\tint t;\n\tint64_t ts = -1;\n\tuint64_t to = 0;\n")
+	g.printf("\t(void)l; (void)m; (void)ml; (void)mc; (void)sc; (void)lim;\n")
+	g.printf("\tswitch (fr->resume) {\n\tcase 0: break;\n")
+	for i := 1; i <= g.resumes; i++ {
+		g.printf("\tcase %d: goto R%d;\n", i, i)
+	}
+	g.printf("\tdefault: TRAP(TRAP_RESUME);\n\t}\n")
+	g.out.WriteString(body.String())
+	g.printf("oog:\n\tt = TRAP_GAS;\n")
+	g.printf("trap:\n\ts->io.trap = t;\n\ts->io.trap_seg = ts;\n\ts->io.trap_op = to;\n")
+	g.printf("\ts->io.gas_used = g;\n\treturn EXIT_TRAP;\n")
+	g.printf("}\n\n")
+	return nil
+}
+
+// translate writes the C code of an instruction, or a trap when the instruction uses a register out
+// of the range
+func (g *generator) translate(in instruction) (bool, error) {
+	out, resumes := g.out, g.resumes
+	code := &strings.Builder{}
+	g.out, g.bad = code, false
+	ends, err := g.instruction(in)
+	g.out = out
+	if err != nil {
+		return false, err
+	}
+	if g.bad {
+		g.resumes = resumes
+		code.Reset()
+		if in.op == opcodes.JmpIf && in.args[1] < g.code.numRegs {
+			// the yielded register is read only when the branch is taken
+			fmt.Fprintf(code, "\tif (r[%d] != 0) TRAP(TRAP_INDEX);\n", in.args[1])
+		} else {
+			code.WriteString("\tTRAP(TRAP_INDEX);\n")
+		}
+		ends = true
+	}
+	if in.op == opcodes.InvokeImport || in.op == opcodes.GrowMemory {
+		g.out.WriteString(code.String())
+		return ends, nil
+	}
+	// the traps of the instruction keep its position in the segment
+	at := fmt.Sprintf("TRAPAT(%d, %d, ", g.seg, len(g.segments[g.seg])-1)
+	g.out.WriteString(strings.Replace(code.String(), "TRAP(", at, -1))
+	return ends, nil
+}
+
+// exit leaves the function to the driver or to go, the function continues at the resume point
+func (g *generator) exit(in instruction, code string) {
+	g.resumes++
+	g.printf("\tfr->resume = %d; s->io.ip = %d; s->io.gas_used = g; return %s;\n", g.resumes, in.pos, code)
+	g.printf("R%d:\n", g.resumes)
+}
+
+// instruction writes the C code of an instruction, it returns whether the instruction ends the
+// straight run of the code
+func (g *generator) instruction(in instruction) (bool, error) {
+	a := in.args
+	v := in.valueID
+	switch in.op {
+	case opcodes.Nop:
+	case opcodes.Unreachable:
+		g.printf("\tTRAP(TRAP_UNREACHABLE);\n")
+		return true, nil
+	case opcodes.FPDisabledError:
+		g.printf("\tTRAP(TRAP_FP_DISABLED);\n")
+		return true, nil
+	case opcodes.AddGas:
+		return false, errGasInstructions
+
+	case opcodes.Select:
+		g.printf("\t{ int64_t a = %s, b = %s; int32_t c = (int32_t)%s; %s }\n",
+			g.reg(a[0]), g.reg(a[1]), g.reg(a[2]), g.setReg(v, "c != 0 ? a : b"))
+	case opcodes.I32Const:
+		g.printf("\t%s\n", g.setReg(v, fmt.Sprintf("%dLL", uint64(a[0]))))
+	case opcodes.I64Const:
+		g.printf("\t%s\n", g.setReg(v, fmt.Sprintf("(int64_t)0x%xULL", in.imm)))
+
+	case opcodes.Jmp:
+		g.printf("\ts->yielded = %s; goto L%d;\n", g.reg(a[1]), a[0])
+		return true, nil
+	case opcodes.JmpIf:
+		g.printf("\tif (%s != 0) { s->yielded = %s; goto L%d; }\n", g.reg(a[1]), g.reg(a[2]), a[0])
+		return true, nil
+	case opcodes.JmpEither:
+		g.printf("\t{ int64_t c = %s; s->yielded = %s; if (c != 0) goto L%d; else goto L%d; }\n",
+			g.reg(a[2]), g.reg(a[3]), a[0], a[1])
+		return true, nil
+	case opcodes.JmpTable:
+		count := int(a[0])
+		targets := a[1 : 1+count]
+		def, cond, yielded := a[1+count], a[2+count], a[3+count]
+		g.printf("\t{ int64_t c = %s; s->yielded = %s; switch (c) {", g.reg(cond), g.reg(yielded))
+		for i, t := range targets {
+			g.printf(" case %d: goto L%d;", i, t)
+		}
+		g.printf(" default: goto L%d; } }\n", def)
+		return true, nil
+	case opcodes.ReturnValue:
+		g.printf("\ts->ret = %s; s->io.gas_used = g; return EXIT_RETURN_VALUE;\n", g.reg(a[0]))
+		return true, nil
+	case opcodes.ReturnVoid:
+		g.printf("\ts->io.gas_used = g; return EXIT_RETURN_VOID;\n")
+		return true, nil
+
+	case opcodes.GetLocal:
+		if a[0] >= g.code.numLocals {
+			g.printf("\tTRAP(TRAP_INDEX);\n")
+		} else {
+			g.printf("\t%s\n", g.setReg(v, fmt.Sprintf("l[%d]", a[0])))
+		}
+	case opcodes.SetLocal:
+		if a[0] >= g.code.numLocals {
+			g.printf("\t{ (void)%s; TRAP(TRAP_INDEX); }\n", g.reg(a[1]))
+		} else {
+			g.printf("\tl[%d] = %s;\n", a[0], g.reg(a[1]))
+		}
+	case opcodes.GetGlobal:
+		g.printf("\tif (%d >= s->io.num_globals) TRAP(TRAP_INDEX);\n", a[0])
+		g.printf("\t%s\n", g.setReg(v, fmt.Sprintf("s->io.globals[%d]", a[0])))
+	case opcodes.SetGlobal:
+		g.printf("\t{ int64_t x = %s; if (%d >= s->io.num_globals) TRAP(TRAP_INDEX); s->io.globals[%d] = x; }\n",
+			g.reg(a[1]), a[0], a[0])
+
+	case opcodes.Call:
+		fn, argc := a[0], a[1]
+		if int(fn) >= len(g.module.FunctionCode) {
+			g.printf("\tTRAP(TRAP_INDEX);\n")
+			return true, nil
+		}
+		g.callArgs(a[2 : 2+argc])
+		g.printf("\ts->call_func = %d; s->call_argc = %d; fr->ret_reg = %d;\n", fn, argc, v)
+		g.exit(in, "EXIT_CALL")
+	case opcodes.CallIndirect:
+		if a[1] == 0 {
+			g.printf("\tTRAP(TRAP_INDEX);\n")
+			return true, nil
+		}
+		typeID, argc := a[0], a[1]-1
+		args, item := a[2:2+argc], a[2+argc]
+		types := g.module.Module.Base.Types
+		if types == nil || int(typeID) >= len(types.Entries) {
+			g.printf("\tTRAP(TRAP_INDEX);\n")
+			return true, nil
+		}
+		sig := &types.Entries[typeID]
+		g.printf("\t{ int64_t i = %s; if (i < 0 || (uint64_t)i >= s->io.table_len) TRAP(TRAP_INDEX);\n", g.reg(item))
+		g.printf("\tuint32_t fn = s->io.table[i]; if (fn >= aot_num_funcs) TRAP(TRAP_INDEX);\n")
+		g.printf("\tif (aot_meta[fn].num_params != %d || aot_meta[fn].num_returns != %d) TRAP(TRAP_TYPE);\n",
+			len(sig.ParamTypes), len(sig.ReturnTypes))
+		g.callArgs(args)
+		g.printf("\ts->call_func = fn; s->call_argc = %d; fr->ret_reg = %d; }\n", argc, v)
+		g.exit(in, "EXIT_CALL")
+
+	case opcodes.InvokeImport:
+		g.exit(in, "EXIT_IMPORT")
+	case opcodes.GrowMemory:
+		g.exit(in, "EXIT_GROW")
+	case opcodes.CurrentMemory:
+		g.printf("\tif (!s->io.has_mem) TRAP(TRAP_NO_MEMORY);\n")
+		g.printf("\t%s\n", g.setReg(v, "(int64_t)(ml / 65536)"))
+	case opcodes.Phi:
+		g.printf("\t%s\n", g.setReg(v, "s->yielded"))
+
+	default:
+		if expr, ok := unaryOps[in.op]; ok {
+			g.printf("\t{ int64_t a = %s; %s }\n", g.reg(a[0]), g.setReg(v, expr))
+			break
+		}
+		if expr, ok := divisionOps[in.op]; ok {
+			g.printf("\t{ int64_t a = %s, b = %s, v; %s %s }\n", g.reg(a[0]), g.reg(a[1]), expr, g.setReg(v, "v"))
+			break
+		}
+		if expr, ok := binaryOps[in.op]; ok {
+			g.printf("\t{ int64_t a = %s, b = %s; %s }\n", g.reg(a[0]), g.reg(a[1]), g.setReg(v, expr))
+			break
+		}
+		if mem, ok := loadOps[in.op]; ok {
+			g.printf("\t{ uint64_t ea = (uint64_t)(uint32_t)%s + %dull; %s %s }\n",
+				g.reg(a[2]), a[1], boundCheck(mem.size), g.setReg(v, mem.expr))
+			break
+		}
+		if mem, ok := storeOps[in.op]; ok {
+			g.printf("\t{ uint64_t ea = (uint64_t)(uint32_t)%s + %dull; int64_t x = %s; %s %s; }\n",
+				g.reg(a[2]), a[1], g.reg(a[3]), boundCheck(mem.size), mem.expr)
+			break
+		}
+		return false, errInvalidCode
+	}
+	return false, nil
+}
+
+func boundCheck(size int) string {
+	if size == 1 {
+		return "if (ea >= ml) TRAP(TRAP_MEMORY);"
+	}
+	return fmt.Sprintf("if (ea + %d > mc) TRAP(TRAP_MEMORY);", size)
+}
+
+// callArgs copies the arguments of a call to the state
+func (g *generator) callArgs(args []uint32) {
+	if len(args) > g.maxArgs {
+		g.maxArgs = len(args)
+	}
+	for i, arg := range args {
+		g.printf("\ts->args[%d] = %s;\n", i, g.reg(arg))
+	}
+}
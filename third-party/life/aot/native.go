@@ -0,0 +1,426 @@
+// +build linux,amd64,cgo
+
+package aot
+
+/*
+#cgo LDFLAGS: -ldl
+
+#include <dlfcn.h>
+#include <stdint.h>
+#include <stdlib.h>
+
+// the same layout as struct aot_io of the runtime
+struct aot_io {
+	uint8_t *mem;
+	uint64_t mem_len;
+	uint64_t mem_cap;
+	int64_t *globals;
+	uint64_t num_globals;
+	const uint32_t *table;
+	uint64_t table_len;
+	const uint64_t *seg_gas;
+	uint64_t gas_used;
+	uint64_t gas_limit;
+	uint64_t max_depth;
+	uint64_t max_frames;
+	uint64_t max_slots;
+	uint64_t num_slots;
+	uint64_t has_mem;
+	uint64_t trap;
+	uint64_t func;
+	uint64_t ip;
+	int64_t depth;
+	int64_t *regs;
+	int64_t *locals;
+	int64_t ret;
+	int64_t yielded;
+	int64_t trap_seg;
+	uint64_t trap_op;
+};
+
+typedef const char const_char;
+
+static void *aot_call_new(void *f) {
+	return ((void *(*)(void))f)();
+}
+
+static void aot_call_free(void *f, void *s) {
+	((void (*)(void *))f)(s);
+}
+
+// the go memory is only referenced by the state during a call
+static void aot_bind(struct aot_io *io, uint8_t *mem, uint64_t mem_len, uint64_t mem_cap, int64_t *globals,
+		uint64_t num_globals, const uint32_t *table, uint64_t table_len, const uint64_t *seg_gas) {
+	io->mem = mem;
+	io->mem_len = mem_len;
+	io->mem_cap = mem_cap;
+	io->globals = globals;
+	io->num_globals = num_globals;
+	io->table = table;
+	io->table_len = table_len;
+	io->seg_gas = seg_gas;
+}
+
+static void aot_unbind(struct aot_io *io) {
+	aot_bind(io, NULL, 0, 0, NULL, 0, NULL, 0, NULL);
+}
+
+static int aot_call_enter(void *f, void *s, uint8_t *mem, uint64_t mem_len, uint64_t mem_cap, int64_t *globals,
+		uint64_t num_globals, const uint32_t *table, uint64_t table_len, const uint64_t *seg_gas,
+		uint32_t func, const int64_t *params, uint64_t num_params) {
+	aot_bind(s, mem, mem_len, mem_cap, globals, num_globals, table, table_len, seg_gas);
+	int code = ((int (*)(void *, uint32_t, const int64_t *, uint64_t))f)(s, func, params, num_params);
+	aot_unbind(s);
+	return code;
+}
+
+static int aot_call_resume(void *f, void *s, uint8_t *mem, uint64_t mem_len, uint64_t mem_cap, int64_t *globals,
+		uint64_t num_globals, const uint32_t *table, uint64_t table_len, const uint64_t *seg_gas) {
+	aot_bind(s, mem, mem_len, mem_cap, globals, num_globals, table, table_len, seg_gas);
+	int code = ((int (*)(void *))f)(s);
+	aot_unbind(s);
+	return code;
+}
+*/
+import "C"
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	os_exec "os/exec"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"github.com/dipperin/dipperin-core/third-party/life/compiler/opcodes"
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+)
+
+// Supported reports whether the native modules can be built on this platform
+const Supported = true
+
+// the flags keep the float semantics of go, the compiler mustn't fuse or reorder float operations
+var cflags = []string{"-std=gnu11", "-O2", "-fPIC", "-shared", "-ffp-contract=off", "-fno-fast-math",
+	"-fwrapv", "-fno-strict-aliasing", "-msse2", "-w"}
+
+var (
+	libraries     = map[string]*library{}
+	librariesLock sync.Mutex
+)
+
+type library struct {
+	handle unsafe.Pointer
+	new    unsafe.Pointer
+	free   unsafe.Pointer
+	enter  unsafe.Pointer
+	resume unsafe.Pointer
+}
+
+// Compiler builds the native modules with the C compiler, the shared objects are kept in its directory
+// by the hash of their source so a module is built once
+type Compiler struct {
+	dir string
+	cc  string
+}
+
+// NewCompiler returns a compiler which keeps the native modules in the directory
+func NewCompiler(dir string) *Compiler {
+	cc := os.Getenv("CC")
+	if cc == "" {
+		cc = "cc"
+	}
+	return &Compiler{dir: dir, cc: cc}
+}
+
+// Compile builds the native module of a compiled module
+func (c *Compiler) Compile(compiled *exec.CompiledModule) (*Module, error) {
+	program, err := Generate(compiled)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(program.Source))
+	name := hex.EncodeToString(hash[:])
+	path := filepath.Join(c.dir, name+".so")
+
+	if _, err := os.Stat(path); err != nil {
+		if err := c.build(program.Source, path); err != nil {
+			return nil, err
+		}
+	}
+	lib, err := loadLibrary(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Module{program: program, lib: lib, gas: map[[256]uint64][]uint64{}}, nil
+}
+
+func (c *Compiler) build(source, path string) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	tempDir, err := ioutil.TempDir(c.dir, "build-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	inPath := filepath.Join(tempDir, "module.c")
+	outPath := filepath.Join(tempDir, "module.so")
+	if err := ioutil.WriteFile(inPath, []byte(source), 0600); err != nil {
+		return err
+	}
+	args := append(append([]string{}, cflags...), "-o", outPath, inPath, "-lm")
+	if out, err := os_exec.Command(c.cc, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("aot: cc failed: %v: %s", err, out)
+	}
+	// the rename is atomic, a concurrent build of the same module writes the same file
+	return os.Rename(outPath, path)
+}
+
+func loadLibrary(path string) (*library, error) {
+	librariesLock.Lock()
+	defer librariesLock.Unlock()
+	if lib, ok := libraries[path]; ok {
+		return lib, nil
+	}
+
+	pathC := C.CString(path)
+	defer C.free(unsafe.Pointer(pathC))
+	handle := C.dlopen(pathC, C.RTLD_NOW|C.RTLD_LOCAL)
+	if handle == nil {
+		return nil, fmt.Errorf("aot: dlopen failed: %s", C.GoString(C.dlerror()))
+	}
+	lib := &library{handle: handle}
+	symbol := func(name string) unsafe.Pointer {
+		nameC := C.CString(name)
+		defer C.free(unsafe.Pointer(nameC))
+		return C.dlsym(handle, nameC)
+	}
+	lib.new, lib.free, lib.enter, lib.resume = symbol("aot_new"), symbol("aot_free"), symbol("aot_enter"), symbol("aot_resume")
+	abi, ioSize := symbol("aot_abi"), symbol("aot_io_size")
+	if lib.new == nil || lib.free == nil || lib.enter == nil || lib.resume == nil || abi == nil || ioSize == nil ||
+		*(*uint64)(abi) != abiVersion || *(*uint64)(ioSize) != uint64(C.sizeof_struct_aot_io) {
+		C.dlclose(handle)
+		return nil, errors.New("aot: incompatible native module")
+	}
+	libraries[path] = lib
+	return lib, nil
+}
+
+// Module is a native module, it runs the calls of the virtual machines of its compiled module
+type Module struct {
+	program *Program
+	lib     *library
+
+	gasLock sync.Mutex
+	// the gas of the segments by the costs of the instructions
+	gas map[[256]uint64][]uint64
+}
+
+// segmentGas returns the costs of the instructions and the gas of the segments for the gas table of a
+// virtual machine, false is returned if the table doesn't charge an instruction of the module
+func (m *Module) segmentGas(vm *exec.VirtualMachine) (*[256]uint64, []uint64, bool) {
+	costs := &[256]uint64{}
+	frame := &exec.Frame{}
+	for _, op := range m.program.usedOps {
+		if vm.JumpTable[op].GasCost == nil {
+			return nil, nil, false
+		}
+		cost, err := vm.JumpTable[op].GasCost(vm, frame)
+		if err != nil {
+			return nil, nil, false
+		}
+		costs[op] = cost
+	}
+
+	m.gasLock.Lock()
+	defer m.gasLock.Unlock()
+	gas, ok := m.gas[*costs]
+	if !ok {
+		gas = m.program.SegmentGas(costs)
+		m.gas[*costs] = gas
+	}
+	return costs, gas, true
+}
+
+// trapGas returns the used gas of a trap, the interpreter charges the instructions of the segment up
+// to the trapping instruction or up to the instruction which runs out of gas
+func (m *Module) trapGas(io *C.struct_aot_io, costs *[256]uint64, gasLimit uint64) uint64 {
+	gas := uint64(io.gas_used)
+	if io.trap_seg < 0 || int(io.trap_seg) >= len(m.program.segments) {
+		return gas
+	}
+	segment := m.program.segments[int(io.trap_seg)]
+	if io.trap == trapGas {
+		for _, op := range segment {
+			if costs[op]+gas > gasLimit {
+				break
+			}
+			gas += costs[op]
+		}
+		return gas
+	}
+	for i := int(io.trap_op) + 1; i < len(segment); i++ {
+		gas -= costs[segment[i]]
+	}
+	return gas
+}
+
+type binding struct {
+	mem        *C.uint8_t
+	memLen     C.uint64_t
+	memCap     C.uint64_t
+	globals    *C.int64_t
+	numGlobals C.uint64_t
+	table      *C.uint32_t
+	tableLen   C.uint64_t
+	segGas     *C.uint64_t
+}
+
+func bind(vm *exec.VirtualMachine, segGas []uint64) binding {
+	var b binding
+	if vm.Memory != nil && cap(vm.Memory.Memory) > 0 {
+		mem := vm.Memory.Memory
+		b.mem = (*C.uint8_t)(unsafe.Pointer(&mem[:cap(mem)][0]))
+		b.memLen, b.memCap = C.uint64_t(len(mem)), C.uint64_t(cap(mem))
+	}
+	if len(vm.Globals) > 0 {
+		b.globals, b.numGlobals = (*C.int64_t)(unsafe.Pointer(&vm.Globals[0])), C.uint64_t(len(vm.Globals))
+	}
+	if len(vm.Table) > 0 {
+		b.table, b.tableLen = (*C.uint32_t)(unsafe.Pointer(&vm.Table[0])), C.uint64_t(len(vm.Table))
+	}
+	if len(segGas) > 0 {
+		b.segGas = (*C.uint64_t)(unsafe.Pointer(&segGas[0]))
+	}
+	return b
+}
+
+func int64Slice(p *C.int64_t, n int) []int64 {
+	if n == 0 {
+		return []int64{}
+	}
+	return (*[1 << 30]int64)(unsafe.Pointer(p))[:n:n]
+}
+
+// Execute runs the ignited call of a virtual machine. The machine is left untouched and is run by
+// the interpreter when its gas table can't be used by the native code.
+func (m *Module) Execute(vm *exec.VirtualMachine) {
+	costs, segGas, ok := m.segmentGas(vm)
+	if !ok || vm.CurrentFrame != 0 || vm.Exited {
+		return
+	}
+	state := C.aot_call_new(m.lib.new)
+	if state == nil {
+		return
+	}
+	defer C.aot_call_free(m.lib.free, state)
+
+	io := (*C.struct_aot_io)(state)
+	io.gas_used = C.uint64_t(vm.GasUsed)
+	io.gas_limit = C.uint64_t(vm.GasLimit)
+	io.max_depth = C.uint64_t(vm.Config.MaxCallStackDepth)
+	io.max_frames = C.uint64_t(len(vm.CallStack))
+	io.max_slots = C.uint64_t(vm.Config.MaxValueSlots)
+	io.num_slots = C.uint64_t(vm.NumValueSlots)
+	if vm.Memory != nil {
+		io.has_mem = 1
+	}
+	io.yielded = C.int64_t(vm.Yielded)
+
+	entry := vm.GetCurrentFrame()
+	params := entry.Locals[:vm.FunctionCode[entry.FunctionID].NumParams]
+	var paramsC *C.int64_t
+	if len(params) > 0 {
+		paramsC = (*C.int64_t)(unsafe.Pointer(&params[0]))
+	}
+	b := bind(vm, segGas)
+	code := C.aot_call_enter(m.lib.enter, state, b.mem, b.memLen, b.memCap, b.globals, b.numGlobals, b.table,
+		b.tableLen, b.segGas, C.uint32_t(entry.FunctionID), paramsC, C.uint64_t(len(params)))
+	for {
+		vm.GasUsed = uint64(io.gas_used)
+		vm.Yielded = int64(io.yielded)
+		switch code {
+		case exitDone:
+			vm.CurrentFrame = -1
+			vm.NumValueSlots = int(io.num_slots)
+			vm.Exited = true
+			vm.ReturnValue = int64(io.ret)
+			return
+		case exitImport, exitGrow:
+			if err := m.hostCall(vm, io, int(code)); err != nil {
+				vm.Exited = true
+				vm.ExitError = err
+				return
+			}
+		default:
+			vm.GasUsed = m.trapGas(io, costs, vm.GasLimit)
+			vm.Exited = true
+			if msg, ok := trapMessages[uint32(io.trap)]; ok {
+				vm.ExitError = msg
+			} else {
+				vm.ExitError = fmt.Sprintf("aot: unknown trap %d", io.trap)
+			}
+			return
+		}
+		io.gas_used = C.uint64_t(vm.GasUsed)
+		io.gas_limit = C.uint64_t(vm.GasLimit)
+		b = bind(vm, segGas)
+		code = C.aot_call_resume(m.lib.resume, state, b.mem, b.memLen, b.memCap, b.globals, b.numGlobals, b.table,
+			b.tableLen, b.segGas)
+	}
+}
+
+// hostCall runs an import or a memory growth on the current frame of the native code like the
+// interpreter does. The gas and the growth fail like the interpreter instructions, the panics of the
+// host functions aren't recovered like the delegates of the interpreter.
+func (m *Module) hostCall(vm *exec.VirtualMachine, io *C.struct_aot_io, code int) (err interface{}) {
+	function := vm.FunctionCode[int(io._func)]
+	frame := &vm.CallStack[int(io.depth)]
+	frame.FunctionID = int(io._func)
+	frame.Code = function.Bytes
+	frame.IP = int(io.ip) + 5
+	frame.Regs = int64Slice(io.regs, function.NumRegs)
+	frame.Locals = int64Slice(io.locals, function.NumParams+function.NumLocals)
+	vm.CurrentFrame = int(io.depth)
+	defer func() {
+		// the slices reference the memory of the state which is freed
+		frame.Regs, frame.Locals = nil, nil
+	}()
+
+	valueID := int(exec.LE.Uint32(frame.Code[frame.IP-5 : frame.IP-1]))
+	op := opcodes.InvokeImport
+	if code == exitGrow {
+		op = opcodes.GrowMemory
+	}
+	if err := recoverPanic(func() {
+		cost, err := vm.JumpTable[op].GasCost(vm, frame)
+		if err != nil || cost+vm.GasUsed > vm.GasLimit {
+			panic(fmt.Sprintf("out of gas  cost:%d GasUsed:%d GasLimit:%d", cost, vm.GasUsed, vm.GasLimit))
+		}
+		vm.GasUsed += cost
+		if op == opcodes.GrowMemory {
+			n := int(uint32(frame.Regs[int(exec.LE.Uint32(frame.Code[frame.IP:frame.IP+4]))]))
+			frame.Regs[valueID] = vm.GrowMemory(n)
+		}
+	}); err != nil {
+		return err
+	}
+	if op == opcodes.InvokeImport {
+		importID := int(exec.LE.Uint32(frame.Code[frame.IP : frame.IP+4]))
+		frame.Regs[valueID] = vm.FunctionImports[importID].F.Execute(vm)
+	}
+	return nil
+}
+
+func recoverPanic(f func()) (err interface{}) {
+	defer func() {
+		err = recover()
+	}()
+	f()
+	return nil
+}
@@ -0,0 +1,33 @@
+// +build !linux !amd64 !cgo
+
+package aot
+
+import (
+	"errors"
+
+	"github.com/dipperin/dipperin-core/third-party/life/exec"
+)
+
+// Supported reports whether the native modules can be built on this platform
+const Supported = false
+
+var errNotSupported = errors.New("aot: native modules aren't supported on this platform")
+
+// Compiler builds the native modules, it always fails on this platform
+type Compiler struct{}
+
+// NewCompiler returns a compiler which keeps the native modules in the directory
+func NewCompiler(dir string) *Compiler {
+	return &Compiler{}
+}
+
+// Compile builds the native module of a compiled module
+func (c *Compiler) Compile(compiled *exec.CompiledModule) (*Module, error) {
+	return nil, errNotSupported
+}
+
+// Module is a native module, it runs the calls of the virtual machines of its compiled module
+type Module struct{}
+
+// Execute leaves the virtual machine to the interpreter
+func (m *Module) Execute(vm *exec.VirtualMachine) {}
@@ -0,0 +1,488 @@
+// +build linux,amd64,cgo
+
+package aot
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/third-party/life/compiler"
+	"github.com/dipperin/dipperin-core/third-party/life/compiler/opcodes"
+	life "github.com/dipperin/dipperin-core/third-party/life/exec"
+	"github.com/dipperin/dipperin-core/third-party/life/mem-manage"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	// the interpreter logs every call
+	log.InitLogger(log.LvlError)
+	os.Exit(m.Run())
+}
+
+// program assembles the interpreter code of a function
+type program struct {
+	code []byte
+}
+
+func (p *program) pos() uint32 {
+	return uint32(len(p.code))
+}
+
+func (p *program) op(valueID uint32, op opcodes.Opcode, args ...uint32) uint32 {
+	pos := p.pos()
+	p.code = append(p.code, 0, 0, 0, 0, byte(op))
+	binary.LittleEndian.PutUint32(p.code[pos:], valueID)
+	for _, arg := range args {
+		p.code = append(p.code, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(p.code[len(p.code)-4:], arg)
+	}
+	return pos
+}
+
+func (p *program) i64(valueID uint32, value uint64) {
+	p.op(valueID, opcodes.I64Const)
+	p.code = append(p.code, make([]byte, 8)...)
+	binary.LittleEndian.PutUint64(p.code[len(p.code)-8:], value)
+}
+
+// patch sets an operand of the instruction at a position
+func (p *program) patch(pos uint32, operand int, value uint32) {
+	binary.LittleEndian.PutUint32(p.code[int(pos)+5+4*operand:], value)
+}
+
+func function(numRegs, numParams, numLocals, numReturns int, p *program) compiler.InterpreterCode {
+	return compiler.InterpreterCode{NumRegs: numRegs, NumParams: numParams, NumLocals: numLocals, NumReturns: numReturns, Bytes: p.code}
+}
+
+func newModule(functions ...compiler.InterpreterCode) *life.CompiledModule {
+	return &life.CompiledModule{Module: &compiler.Module{Base: &wasm.Module{}}, FunctionCode: functions}
+}
+
+type result struct {
+	ret     int64
+	failed  bool
+	gasUsed uint64
+	memory  []byte
+	globals []int64
+}
+
+type testResolver struct {
+	calls int
+}
+
+func (r *testResolver) ResolveFunc(module, field string) *life.FunctionImport {
+	switch field {
+	case "add":
+		return &life.FunctionImport{
+			Execute: func(vm *life.VirtualMachine) int64 {
+				r.calls++
+				frame := vm.GetCurrentFrame()
+				return frame.Locals[0] + frame.Locals[1]
+			},
+			GasCost: func(vm *life.VirtualMachine) (uint64, error) {
+				return uint64(vm.GetCurrentFrame().Locals[0] & 0xff), nil
+			},
+		}
+	case "abort":
+		return &life.FunctionImport{
+			Execute: func(vm *life.VirtualMachine) int64 {
+				panic("abort")
+			},
+			GasCost: func(vm *life.VirtualMachine) (uint64, error) {
+				return 1, nil
+			},
+		}
+	}
+	panic("unknown import")
+}
+
+func (r *testResolver) ResolveGlobal(module, field string) int64 {
+	panic("global import not allowed")
+}
+
+var testConfig = life.VMConfig{MaxMemoryPages: 4, MaxCallStackDepth: 64, MaxValueSlots: 4096}
+
+// newVM makes a virtual machine like life.NewVirtualMachineWithModule without the allocations of
+// the memory manager, the memory has more capacity than length like the memory of the interpreter
+func newVM(t *testing.T, compiled *life.CompiledModule, native *Module, gasLimit uint64) *life.VirtualMachine {
+	resolver := &testResolver{}
+	var imports []life.FunctionImportInfo
+	if compiled.Module.Base.Import != nil {
+		for _, imp := range compiled.Module.Base.Import.Entries {
+			imports = append(imports, life.FunctionImportInfo{
+				ModuleName: imp.ModuleName,
+				FieldName:  imp.FieldName,
+				F:          *resolver.ResolveFunc(imp.ModuleName, imp.FieldName),
+			})
+		}
+	}
+	vm := &life.VirtualMachine{
+		Module:          compiled.Module,
+		Config:          testConfig,
+		FunctionCode:    compiled.FunctionCode,
+		FunctionImports: imports,
+		JumpTable:       life.NewPageGasTable(3),
+		CallStack:       make([]life.Frame, testConfig.MaxCallStackDepth+8),
+		CurrentFrame:    -1,
+		Table:           []uint32{4, 1, 0xffffffff},
+		Globals:         []int64{7, -7},
+		Memory: &mem_manage.VmMemory{BuddyMemory: &mem_manage.BuddyMemory{
+			Memory: make([]byte, 65536, 65536+16),
+		}},
+		Exited:   true,
+		GasLimit: gasLimit,
+	}
+	if native != nil {
+		vm.SetAOTService(native)
+	}
+	return vm
+}
+
+func run(t *testing.T, compiled *life.CompiledModule, native *Module, gasLimit uint64, entry int, params ...int64) (res result) {
+	vm := newVM(t, compiled, native, gasLimit)
+	defer func() {
+		if err := recover(); err != nil {
+			res.failed = true
+			res.gasUsed = vm.GasUsed
+		}
+	}()
+	ret, err := vm.Run(entry, params...)
+	return result{ret: ret, failed: err != nil, gasUsed: vm.GasUsed, memory: vm.Memory.Memory[:cap(vm.Memory.Memory)], globals: vm.Globals}
+}
+
+func compile(t *testing.T, compiled *life.CompiledModule) *Module {
+	if _, err := exec.LookPath("cc"); err != nil {
+		t.Skip("no C compiler")
+	}
+	dir, err := ioutil.TempDir("", "aot-test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	native, err := NewCompiler(dir).Compile(compiled)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return native
+}
+
+func assertSame(t *testing.T, compiled *life.CompiledModule, native *Module, gasLimit uint64, entry int, params ...int64) {
+	expected := run(t, compiled, nil, gasLimit, entry, params...)
+	actual := run(t, compiled, native, gasLimit, entry, params...)
+	assert.Equal(t, expected.failed, actual.failed, "function %d params %x", entry, params)
+	assert.Equal(t, expected.ret, actual.ret, "function %d params %x", entry, params)
+	assert.Equal(t, expected.gasUsed, actual.gasUsed, "function %d params %x", entry, params)
+	// the native code charges a segment before running it, so the memory and the globals of a machine
+	// which runs out of gas differ. A failed machine is discarded.
+	if !expected.failed {
+		assert.Equal(t, expected.globals, actual.globals, "function %d params %x", entry, params)
+		assert.True(t, string(expected.memory) == string(actual.memory), "function %d params %x", entry, params)
+	}
+}
+
+var testValues = []int64{
+	0, 1, 2, 3, 31, 32, 33, 63, 64, 65, -1, -2,
+	math.MaxInt32, math.MinInt32, math.MaxUint32, 0x80000000, 0x7fffffff00000001,
+	math.MaxInt64, math.MinInt64, 0x123456789abcdef0,
+	int64(math.Float32bits(1.5)), int64(math.Float32bits(-2.5)), int64(math.Float32bits(0.5)),
+	int64(math.Float32bits(float32(math.Inf(1)))), int64(math.Float32bits(float32(math.Inf(-1)))),
+	int64(math.Float32bits(float32(math.NaN()))), 0x80000000 | 0x7fa00000, int64(math.Float32bits(3e9)),
+	int64(math.Float32bits(-3e9)), int64(math.Float32bits(1e20)),
+	int64(math.Float64bits(1.5)), int64(math.Float64bits(-2.5)), int64(math.Float64bits(-0.5)),
+	int64(math.Float64bits(math.Inf(1))), int64(math.Float64bits(math.Inf(-1))),
+	int64(math.Float64bits(math.NaN())), int64(math.Float64bits(math.Copysign(0, -1))),
+	int64(math.Float64bits(3e9)), int64(math.Float64bits(-3e18)), int64(math.Float64bits(1e300)),
+	int64(math.Float64bits(4503599627370497.5)), int64(math.Float64bits(2.5)),
+}
+
+func TestNative_Arithmetic(t *testing.T) {
+	var functions []compiler.InterpreterCode
+	for op := range binaryOps {
+		p := &program{}
+		p.op(0, opcodes.GetLocal, 0)
+		p.op(1, opcodes.GetLocal, 1)
+		p.op(2, op, 0, 1)
+		p.op(0, opcodes.ReturnValue, 2)
+		functions = append(functions, function(3, 2, 0, 1, p))
+	}
+	unary := len(functions)
+	for op := range unaryOps {
+		p := &program{}
+		p.op(0, opcodes.GetLocal, 0)
+		p.op(1, op, 0)
+		p.op(0, opcodes.ReturnValue, 1)
+		functions = append(functions, function(2, 1, 0, 1, p))
+	}
+	compiled := newModule(functions...)
+	native := compile(t, compiled)
+
+	for i := range functions {
+		if i < unary {
+			for _, a := range testValues {
+				for _, b := range testValues {
+					assertSame(t, compiled, native, 100, i, a, b)
+				}
+			}
+		} else {
+			for _, a := range testValues {
+				assertSame(t, compiled, native, 100, i, a)
+			}
+		}
+	}
+}
+
+func TestNative_Memory(t *testing.T) {
+	var functions []compiler.InterpreterCode
+	for op := range storeOps {
+		p := &program{}
+		p.op(0, opcodes.GetLocal, 0)
+		p.op(1, opcodes.GetLocal, 1)
+		p.op(0, op, 0, 3, 0, 1)
+		p.op(0, opcodes.ReturnVoid)
+		functions = append(functions, function(2, 2, 0, 0, p))
+	}
+	loads := len(functions)
+	for op := range loadOps {
+		p := &program{}
+		p.op(0, opcodes.GetLocal, 0)
+		p.op(1, opcodes.I64Const)
+		p.code = append(p.code, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8)
+		p.op(0, opcodes.I64Store, 0, 5, 1, 1)
+		p.op(2, op, 0, 5, 0)
+		p.op(0, opcodes.ReturnValue, 2)
+		functions = append(functions, function(3, 1, 0, 1, p))
+	}
+	compiled := newModule(functions...)
+	native := compile(t, compiled)
+
+	addresses := []int64{0, 1, 65520, 65525, 65528, 65529, 65530, 65531, 65532, 65533, 65534, 65535,
+		65536, 65540, 65548, -1, -4, -8, -0x100000000}
+	for i := range functions {
+		for _, addr := range addresses {
+			if i < loads {
+				assertSame(t, compiled, native, 100, i, addr, 0x0102030405060708)
+			} else {
+				assertSame(t, compiled, native, 100, i, addr-5)
+			}
+		}
+	}
+}
+
+func TestNative_ControlFlow(t *testing.T) {
+	// sum of 1..n with a loop
+	loop := &program{}
+	loop.op(0, opcodes.GetLocal, 0)
+	loop.op(1, opcodes.I64Const)
+	loop.code = append(loop.code, make([]byte, 8)...)
+	head := loop.op(2, opcodes.I64EqZ, 0)
+	exit := loop.op(0, opcodes.JmpIf, 0, 2, 1)
+	loop.op(1, opcodes.I64Add, 1, 0)
+	loop.i64(3, 1)
+	loop.op(0, opcodes.I64Sub, 0, 3)
+	loop.op(0, opcodes.Jmp, head, 1)
+	loop.patch(exit, 0, loop.op(4, opcodes.Phi))
+	loop.op(0, opcodes.ReturnValue, 4)
+
+	// a jump table on the param, the yielded value is the param
+	table := &program{}
+	table.op(0, opcodes.GetLocal, 0)
+	jump := table.op(0, opcodes.JmpTable, 3, 0, 0, 0, 0, 0, 0)
+	var targets []uint32
+	for i := 0; i < 4; i++ {
+		targets = append(targets, table.pos())
+		table.op(1, opcodes.I32Const, uint32(i*10))
+		table.op(2, opcodes.Phi)
+		table.op(1, opcodes.I64Add, 1, 2)
+		table.op(0, opcodes.ReturnValue, 1)
+	}
+	for i, target := range targets {
+		table.patch(jump, 1+i, target)
+	}
+
+	// factorial by recursion
+	fact := &program{}
+	fact.op(0, opcodes.GetLocal, 0)
+	fact.op(1, opcodes.I64EqZ, 0)
+	base := fact.op(0, opcodes.JmpIf, 0, 1, 0)
+	fact.i64(2, 1)
+	fact.op(2, opcodes.I64Sub, 0, 2)
+	fact.op(3, opcodes.Call, 2, 1, 2)
+	fact.op(3, opcodes.I64Mul, 0, 3)
+	fact.op(0, opcodes.ReturnValue, 3)
+	fact.patch(base, 0, fact.pos())
+	fact.i64(0, 1)
+	fact.op(0, opcodes.ReturnValue, 0)
+
+	// an indirect call of the table, the globals are updated by the callee
+	indirect := &program{}
+	indirect.op(0, opcodes.GetLocal, 0)
+	indirect.op(1, opcodes.I32Const, 3)
+	indirect.op(1, opcodes.I64And, 0, 1)
+	indirect.op(2, opcodes.CallIndirect, 0, 2, 0, 1)
+	indirect.op(3, opcodes.GetGlobal, 1)
+	indirect.op(2, opcodes.I64Add, 2, 3)
+	indirect.op(0, opcodes.ReturnValue, 2)
+
+	// the first entry of the table
+	callee := &program{}
+	callee.op(0, opcodes.GetLocal, 0)
+	callee.op(0, opcodes.SetGlobal, 1, 0)
+	callee.op(1, opcodes.GetGlobal, 0)
+	callee.op(0, opcodes.I64Mul, 0, 1)
+	callee.op(0, opcodes.ReturnValue, 0)
+
+	compiled := newModule(
+		function(5, 1, 0, 1, loop),
+		function(3, 1, 0, 1, table),
+		function(4, 1, 0, 1, fact),
+		function(4, 1, 0, 1, indirect),
+		function(2, 1, 0, 1, callee),
+	)
+	compiled.Module.Base.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{
+		{ParamTypes: []wasm.ValueType{wasm.ValueTypeI64}, ReturnTypes: []wasm.ValueType{wasm.ValueTypeI64}},
+	}}
+	native := compile(t, compiled)
+
+	for _, n := range []int64{0, 1, 2, 3, 4, 10, 62, 63, 64, 100, -1, math.MinInt64} {
+		for _, gas := range []uint64{0, 1, 5, 17, 100, 1000, 1 << 20} {
+			for entry := range compiled.FunctionCode {
+				assertSame(t, compiled, native, gas, entry, n)
+			}
+		}
+	}
+	// every gas limit of a run, the out of gas must fail at the same instruction
+	for gas := uint64(0); gas < 200; gas++ {
+		assertSame(t, compiled, native, gas, 2, 5)
+		assertSame(t, compiled, native, gas, 0, 7)
+	}
+}
+
+func TestNative_HostCalls(t *testing.T) {
+	// the import stubs like the compiler makes them
+	add := &program{}
+	add.op(1, opcodes.InvokeImport, 0)
+	add.op(0, opcodes.ReturnValue, 1)
+	abort := &program{}
+	abort.op(1, opcodes.InvokeImport, 1)
+	abort.op(0, opcodes.ReturnVoid)
+
+	// calls the import, grows the memory and returns the sum of the results
+	main := &program{}
+	main.op(0, opcodes.GetLocal, 0)
+	main.op(1, opcodes.I32Const, 1000)
+	main.op(2, opcodes.Call, 0, 2, 0, 1)
+	main.op(3, opcodes.GrowMemory, 0)
+	main.op(4, opcodes.CurrentMemory)
+	main.op(2, opcodes.I64Add, 2, 3)
+	main.op(2, opcodes.I64Add, 2, 4)
+	main.op(0, opcodes.I32Store8, 0, 0, 0, 2)
+	main.op(0, opcodes.ReturnValue, 2)
+
+	callAbort := &program{}
+	callAbort.op(0, opcodes.Call, 1, 0)
+	callAbort.op(0, opcodes.ReturnVoid)
+
+	compiled := newModule(
+		function(2, 2, 0, 1, add),
+		function(2, 0, 0, 0, abort),
+		function(5, 1, 0, 1, main),
+		function(1, 0, 0, 0, callAbort),
+	)
+	compiled.Module.Base.Import = &wasm.SectionImports{Entries: []wasm.ImportEntry{
+		{ModuleName: "env", FieldName: "add", Type: wasm.FuncImport{}},
+		{ModuleName: "env", FieldName: "abort", Type: wasm.FuncImport{}},
+	}}
+	native := compile(t, compiled)
+
+	for _, n := range []int64{0, 1, 2, 3, 4, 0x1ff, -1} {
+		for gas := uint64(0); gas < 40; gas++ {
+			assertSame(t, compiled, native, gas, 2, n)
+		}
+		assertSame(t, compiled, native, 1<<20, 2, n)
+	}
+	// the panics of the host functions leave the run like the interpreter
+	for gas := uint64(0); gas < 5; gas++ {
+		assertSame(t, compiled, native, gas, 3)
+	}
+	vm := newVM(t, compiled, native, 100)
+	assert.PanicsWithValue(t, "abort", func() {
+		vm.Run(3)
+	})
+}
+
+func TestNative_Traps(t *testing.T) {
+	unreachable := &program{}
+	unreachable.op(0, opcodes.Unreachable)
+
+	// registers, locals and globals out of the range
+	badReg := &program{}
+	badReg.op(0, opcodes.GetLocal, 0)
+	badReg.op(9, opcodes.I64Add, 0, 0)
+	badLocal := &program{}
+	badLocal.op(0, opcodes.GetLocal, 5)
+	badGlobal := &program{}
+	badGlobal.op(0, opcodes.GetGlobal, 2)
+	badYield := &program{}
+	badYield.op(0, opcodes.GetLocal, 0)
+	badYield.op(0, opcodes.JmpIf, 0, 0, 9)
+	badYield.op(0, opcodes.ReturnValue, 0)
+
+	// the end of the code and a table entry without function
+	end := &program{}
+	end.op(0, opcodes.Nop)
+	badTable := &program{}
+	badTable.op(0, opcodes.GetLocal, 0)
+	badTable.op(0, opcodes.CallIndirect, 0, 1, 0)
+	badTable.op(0, opcodes.ReturnValue, 0)
+
+	// deep recursion
+	recursion := &program{}
+	recursion.op(0, opcodes.Call, 7, 0)
+	recursion.op(0, opcodes.ReturnValue, 0)
+
+	compiled := newModule(
+		function(1, 0, 0, 0, unreachable),
+		function(1, 1, 0, 1, badReg),
+		function(1, 1, 0, 1, badLocal),
+		function(1, 0, 0, 1, badGlobal),
+		function(1, 1, 0, 1, badYield),
+		function(1, 0, 0, 0, end),
+		function(1, 1, 0, 1, badTable),
+		function(1, 0, 0, 1, recursion),
+	)
+	compiled.Module.Base.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{{}}}
+	native := compile(t, compiled)
+
+	for entry, code := range compiled.FunctionCode {
+		for _, param := range []int64{0, 1, 2, 3} {
+			params := []int64{param}[:code.NumParams]
+			assertSame(t, compiled, native, 1<<20, entry, params...)
+		}
+	}
+}
+
+func TestGenerate_Contracts(t *testing.T) {
+	paths, err := filepath.Glob("../../../core/vm/test-data/*/*.wasm")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, paths)
+	for _, path := range paths {
+		code, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		compiled, err := life.CompileModule(code, life.VMConfig{}, nil)
+		assert.NoError(t, err)
+		_, err = Generate(compiled)
+		assert.NoError(t, err, path)
+	}
+
+	// the C compiler takes seconds for the large contracts
+	code, err := ioutil.ReadFile("../../../core/vm/test-data/demo/demo.wasm")
+	assert.NoError(t, err)
+	compiled, err := life.CompileModule(code, life.VMConfig{}, nil)
+	assert.NoError(t, err)
+	compile(t, compiled)
+}
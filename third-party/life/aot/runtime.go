@@ -0,0 +1,305 @@
+package aot
+
+// abiVersion is changed when the generated code or the runtime changes, the source of a module
+// includes it so the cached native modules of an older version aren't loaded
+const abiVersion = 1
+
+// the results of the calls into the native module
+const (
+	exitDone   = 0
+	exitTrap   = 1
+	exitImport = 2
+	exitGrow   = 3
+)
+
+// the trap of the gas
+const trapGas = 1
+
+// the reasons of the traps, the interpreter panics with the same messages
+var trapMessages = map[uint32]string{
+	1:  "out of gas",
+	2:  "wasm: unreachable executed",
+	3:  "integer division by zero",
+	4:  "signed integer overflow",
+	5:  "memory access out of bounds",
+	6:  "max call stack depth exceeded",
+	7:  "call stack overflow",
+	8:  "max value slot count exceeded",
+	9:  "index out of range",
+	10: "type mismatch",
+	11: "wasm: floating point disabled",
+	12: "no memory",
+	13: "out of memory",
+	14: "invalid resume point",
+}
+
+// runtimeHeader is the start of the source of every native module. The wasm registers and locals of
+// the frames are kept on a stack in the state instead of the C stack, a call returns to the loop in
+// aot_resume which pushes the frame of the callee, so the nested wasm calls don't use the C stack.
+// The host functions and the memory growth return to go and the frame continues at its resume point
+// when aot_resume is called again.
+const runtimeHeader = `#include <stdint.h>
+#include <string.h>
+#include <stdlib.h>
+#include <math.h>
+#include <emmintrin.h>
+
+enum {
+	EXIT_DONE = 0, EXIT_TRAP = 1, EXIT_IMPORT = 2, EXIT_GROW = 3,
+	EXIT_CALL = 16, EXIT_RETURN_VALUE = 17, EXIT_RETURN_VOID = 18,
+};
+
+enum {
+	TRAP_GAS = 1, TRAP_UNREACHABLE = 2, TRAP_DIV_ZERO = 3, TRAP_OVERFLOW = 4, TRAP_MEMORY = 5,
+	TRAP_DEPTH = 6, TRAP_STACK = 7, TRAP_SLOTS = 8, TRAP_INDEX = 9, TRAP_TYPE = 10,
+	TRAP_FP_DISABLED = 11, TRAP_NO_MEMORY = 12, TRAP_OOM = 13, TRAP_RESUME = 14,
+};
+
+/* shared with go, the layout must match the declaration of the loader */
+struct aot_io {
+	uint8_t *mem;
+	uint64_t mem_len;
+	uint64_t mem_cap;
+	int64_t *globals;
+	uint64_t num_globals;
+	const uint32_t *table;
+	uint64_t table_len;
+	const uint64_t *seg_gas;
+	uint64_t gas_used;
+	uint64_t gas_limit;
+	uint64_t max_depth;
+	uint64_t max_frames;
+	uint64_t max_slots;
+	uint64_t num_slots;
+	uint64_t has_mem;
+	uint64_t trap;
+	uint64_t func;
+	uint64_t ip;
+	int64_t depth;
+	int64_t *regs;
+	int64_t *locals;
+	int64_t ret;
+	int64_t yielded;
+	int64_t trap_seg;
+	uint64_t trap_op;
+};
+
+struct aot_frame {
+	uint32_t func;
+	uint32_t resume;
+	uint32_t ret_reg;
+	uint64_t base;
+};
+
+struct aot_state {
+	struct aot_io io;
+	struct aot_frame *frames;
+	int64_t *slots;
+	uint64_t slots_len;
+	uint64_t slots_cap;
+	int64_t depth;
+	int64_t yielded;
+	int64_t ret;
+	uint32_t call_func;
+	uint32_t call_argc;
+	int64_t *args;
+};
+
+struct aot_meta {
+	uint32_t num_regs;
+	uint32_t num_params;
+	uint32_t num_locals;
+	uint32_t num_returns;
+};
+
+typedef int (*aot_func)(struct aot_state *);
+
+#define LIKELY(x) __builtin_expect(!!(x), 1)
+#define UNLIKELY(x) __builtin_expect(!!(x), 0)
+/* the traps of the instructions keep their position, go charges the segment up to the instruction */
+#define CHARGE(seg) do { if (UNLIKELY(g + sc[seg] > lim)) { ts = (seg); goto oog; } g += sc[seg]; } while (0)
+#define TRAP(code) do { ts = -1; t = (code); goto trap; } while (0)
+#define TRAPAT(seg, op, code) do { ts = (seg); to = (op); t = (code); goto trap; } while (0)
+
+static inline float F32(int64_t x) { uint32_t u = (uint32_t)x; float f; memcpy(&f, &u, 4); return f; }
+static inline double F64(int64_t x) { uint64_t u = (uint64_t)x; double f; memcpy(&f, &u, 8); return f; }
+static inline int64_t B32(float f) { uint32_t u; memcpy(&u, &f, 4); return (int64_t)u; }
+static inline int64_t B64(double f) { uint64_t u; memcpy(&u, &f, 8); return (int64_t)u; }
+static inline int64_t N32(float f) { return f != f ? 0x7FC00000 : B32(f); }
+static inline int64_t N64(double f) { return f != f ? 0x7FF8000000000001LL : B64(f); }
+
+static inline uint32_t rotl32(uint32_t x, uint32_t k) { k &= 31; return (x << k) | (x >> ((32 - k) & 31)); }
+static inline uint64_t rotl64(uint64_t x, uint64_t k) { k &= 63; return (x << k) | (x >> ((64 - k) & 63)); }
+static inline int64_t clz32(uint32_t x) { return x == 0 ? 32 : __builtin_clz(x); }
+static inline int64_t ctz32(uint32_t x) { return x == 0 ? 32 : __builtin_ctz(x); }
+static inline int64_t clz64(uint64_t x) { return x == 0 ? 64 : __builtin_clzll(x); }
+static inline int64_t ctz64(uint64_t x) { return x == 0 ? 64 : __builtin_ctzll(x); }
+
+/* math.Min and math.Max of go */
+static inline double go_min(double x, double y) {
+	if (isinf(x) && x < 0) return x;
+	if (isinf(y) && y < 0) return y;
+	if (x != x || y != y) return NAN;
+	if (x == 0 && x == y) return signbit(x) ? x : y;
+	return x < y ? x : y;
+}
+static inline double go_max(double x, double y) {
+	if (isinf(x) && x > 0) return x;
+	if (isinf(y) && y > 0) return y;
+	if (x != x || y != y) return NAN;
+	if (x == 0 && x == y) return signbit(x) ? y : x;
+	return x > y ? x : y;
+}
+
+/* the conversions of go on amd64, the out of range values are converted to the minimum integer */
+static inline int64_t trunc_f32_i32(float f) { return (int64_t)_mm_cvttss_si32(_mm_set_ss(f)); }
+static inline int64_t trunc_f64_i32(double f) { return (int64_t)_mm_cvttsd_si32(_mm_set_sd(f)); }
+static inline int64_t trunc_f64_i64(double f) { return (int64_t)_mm_cvttsd_si64(_mm_set_sd(f)); }
+
+static inline uint16_t ld16(const uint8_t *p) { uint16_t v; memcpy(&v, p, 2); return v; }
+static inline uint32_t ld32(const uint8_t *p) { uint32_t v; memcpy(&v, p, 4); return v; }
+static inline uint64_t ld64(const uint8_t *p) { uint64_t v; memcpy(&v, p, 8); return v; }
+static inline void st16(uint8_t *p, uint16_t v) { memcpy(p, &v, 2); }
+static inline void st32(uint8_t *p, uint32_t v) { memcpy(p, &v, 4); }
+static inline void st64(uint8_t *p, uint64_t v) { memcpy(p, &v, 8); }
+
+`
+
+// runtimeDriver follows the functions and the tables of the module
+const runtimeDriver = `
+/* pushes the frame of the pending call, like the Call instruction and Frame.Init of the interpreter */
+static int aot_push(struct aot_state *s) {
+	const struct aot_meta *m = &aot_meta[s->call_func];
+	int64_t depth = s->depth + 1;
+	if (s->io.max_depth != 0 && (uint64_t)depth >= s->io.max_depth) return TRAP_DEPTH;
+	if ((uint64_t)depth >= s->io.max_frames) return TRAP_STACK;
+
+	uint64_t n = (uint64_t)m->num_regs + m->num_params + m->num_locals;
+	if (s->io.max_slots != 0 && s->io.num_slots + n > s->io.max_slots) return TRAP_SLOTS;
+	s->io.num_slots += n;
+
+	if (s->slots_len + n > s->slots_cap) {
+		uint64_t cap = s->slots_cap * 2;
+		while (cap < s->slots_len + n) cap *= 2;
+		int64_t *slots = realloc(s->slots, cap * sizeof(int64_t));
+		if (slots == NULL) return TRAP_OOM;
+		s->slots = slots;
+		s->slots_cap = cap;
+	}
+	uint64_t base = s->slots_len;
+	memset(s->slots + base, 0, n * sizeof(int64_t));
+	if (s->call_argc > (uint64_t)m->num_params + m->num_locals) return TRAP_INDEX;
+	memcpy(s->slots + base + m->num_regs, s->args, s->call_argc * sizeof(int64_t));
+	s->slots_len += n;
+
+	s->depth = depth;
+	s->frames[depth].func = s->call_func;
+	s->frames[depth].resume = 0;
+	s->frames[depth].ret_reg = 0;
+	s->frames[depth].base = base;
+	return 0;
+}
+
+static void aot_pop(struct aot_state *s) {
+	const struct aot_meta *m = &aot_meta[s->frames[s->depth].func];
+	uint64_t n = (uint64_t)m->num_regs + m->num_params + m->num_locals;
+	s->io.num_slots -= n;
+	s->slots_len -= n;
+	s->depth--;
+}
+
+static int aot_exit(struct aot_state *s, int code) {
+	if (s->depth >= 0) {
+		struct aot_frame *f = &s->frames[s->depth];
+		s->io.func = f->func;
+		s->io.depth = s->depth;
+		s->io.regs = s->slots + f->base;
+		s->io.locals = s->io.regs + aot_meta[f->func].num_regs;
+	}
+	return code;
+}
+
+static int aot_run(struct aot_state *s) {
+	s->io.trap_seg = -1;
+	for (;;) {
+		int code = aot_funcs[s->frames[s->depth].func](s);
+		switch (code) {
+		case EXIT_CALL:
+			code = aot_push(s);
+			if (code != 0) {
+				s->io.trap = code;
+				return aot_exit(s, EXIT_TRAP);
+			}
+			break;
+		case EXIT_RETURN_VALUE:
+		case EXIT_RETURN_VOID:
+			aot_pop(s);
+			if (s->depth < 0) {
+				s->io.ret = code == EXIT_RETURN_VALUE ? s->ret : 0;
+				return EXIT_DONE;
+			}
+			if (code == EXIT_RETURN_VALUE) {
+				struct aot_frame *f = &s->frames[s->depth];
+				if (f->ret_reg >= aot_meta[f->func].num_regs) {
+					s->io.trap = TRAP_INDEX;
+					return aot_exit(s, EXIT_TRAP);
+				}
+				s->slots[f->base + f->ret_reg] = s->ret;
+			}
+			break;
+		default:
+			return aot_exit(s, code);
+		}
+	}
+}
+
+int aot_resume(struct aot_state *s) {
+	int code = aot_run(s);
+	s->io.yielded = s->yielded;
+	return code;
+}
+
+/* starts the call of the function with the params, the slots of the first frame are counted by go */
+int aot_enter(struct aot_state *s, uint32_t func, const int64_t *params, uint64_t num_params) {
+	if (func >= aot_num_funcs || s->io.max_frames == 0) {
+		s->io.trap = TRAP_INDEX;
+		return EXIT_TRAP;
+	}
+	const struct aot_meta *m = &aot_meta[func];
+	uint64_t n = (uint64_t)m->num_regs + m->num_params + m->num_locals;
+	if (num_params > (uint64_t)m->num_params + m->num_locals) {
+		s->io.trap = TRAP_INDEX;
+		return EXIT_TRAP;
+	}
+
+	s->frames = calloc(s->io.max_frames, sizeof(struct aot_frame));
+	s->slots_cap = n < 4096 ? 4096 : n;
+	s->slots = calloc(s->slots_cap, sizeof(int64_t));
+	s->args = calloc(aot_max_args + 1, sizeof(int64_t));
+	if (s->frames == NULL || s->slots == NULL || s->args == NULL) {
+		s->io.trap = TRAP_OOM;
+		return EXIT_TRAP;
+	}
+	memcpy(s->slots + m->num_regs, params, num_params * sizeof(int64_t));
+	s->slots_len = n;
+	s->depth = 0;
+	s->frames[0].func = func;
+	s->yielded = s->io.yielded;
+	return aot_resume(s);
+}
+
+struct aot_state *aot_new(void) {
+	struct aot_state *s = calloc(1, sizeof(struct aot_state));
+	if (s != NULL) s->depth = -1;
+	return s;
+}
+
+void aot_free(struct aot_state *s) {
+	free(s->frames);
+	free(s->slots);
+	free(s->args);
+	free(s);
+}
+
+const uint64_t aot_io_size = sizeof(struct aot_io);
+`
@@ -20,6 +20,19 @@ func constGasFunc(gas uint64) gasFunc {
 	}
 }
 
+// NewPageGasTable returns a copy of the gas table which charges the memory growth by the grown pages
+func NewPageGasTable(pageGas uint64) [256]Instruction {
+	table := GasTable
+	table[opcodes.GrowMemory] = Instruction{
+		Execute: nil,
+		GasCost: func(vm *VirtualMachine, frame *Frame) (uint64, error) {
+			pages := uint64(uint32(frame.Regs[int(LE.Uint32(frame.Code[frame.IP:frame.IP+4]))]))
+			return 1 + pages*pageGas, nil
+		},
+	}
+	return table
+}
+
 var GasTable = [256]Instruction{
 	opcodes.Nop: {
 		Execute: nil,
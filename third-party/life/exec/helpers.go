@@ -2,9 +2,7 @@ package exec
 
 import (
 	"errors"
-	"fmt"
 	"github.com/dipperin/dipperin-core/third-party/log"
-	"github.com/perlin-network/life/compiler"
 	"github.com/perlin-network/life/utils"
 )
 
@@ -51,32 +49,9 @@ func (vm *VirtualMachine) RunWithGasLimit(entryID, limit int, params ...int64) (
 // Panics on logical errors.
 func (vm *VirtualMachine) Run(entryID int, params ...int64) (retVal int64, retErr error) {
 	vm.Ignite(entryID, params...) // call Ignite() to perform necessary checks even if we are using the AOT mode.
-	// vmcommon.AOTService is nil
+	// the native code runs the whole call and leaves the vm exited
 	if vm.AOTService != nil {
-		recoveryFunc := func() {
-			if err := recover(); err != nil {
-				if err, ok := err.(error); ok {
-					retErr = err
-				} else {
-					panic(err)
-				}
-			} else {
-				vm.CurrentFrame = -1
-			}
-		}
-		targetName := fmt.Sprintf("%s%d", compiler.NGEN_FUNCTION_PREFIX, entryID)
-		switch len(params) {
-		case 0:
-			defer recoveryFunc()
-			return int64(vm.AOTService.UnsafeInvokeFunction_0(vm, targetName)), nil
-		case 1:
-			defer recoveryFunc()
-			return int64(vm.AOTService.UnsafeInvokeFunction_1(vm, targetName, uint64(params[0]))), nil
-		case 2:
-			defer recoveryFunc()
-			return int64(vm.AOTService.UnsafeInvokeFunction_2(vm, targetName, uint64(params[0]), uint64(params[1]))), nil
-		default:
-		}
+		vm.AOTService.Execute(vm)
 	}
 	//vmcommon.Exited = false when vmcommon initializes the call frame in vmcommon.Ignite
 	for !vm.Exited {
@@ -43,10 +43,11 @@ type NCompileConfig struct {
 	DisableMemBoundCheck bool
 }
 
+// AOTService runs the ignited call frame of a virtual machine with the native code of its module.
+// It has the same results and gas usage as the interpreter, and leaves the machine exited like
+// the interpreter does, the host functions are called on the machine and their panics aren't recovered.
 type AOTService interface {
-	UnsafeInvokeFunction_0(vm *VirtualMachine, name string) uint64
-	UnsafeInvokeFunction_1(vm *VirtualMachine, name string, p0 uint64) uint64
-	UnsafeInvokeFunction_2(vm *VirtualMachine, name string, p0, p1 uint64) uint64
+	Execute(vm *VirtualMachine)
 }
 
 // VirtualMachine is a WebAssembly execution environment.
@@ -127,6 +128,29 @@ func ImportGasFunc(vm *VirtualMachine, frame *Frame) (uint64, error) {
 	return vm.FunctionImports[importID].F.GasCost(vm)
 }
 
+// CompiledModule is a WebAssembly module compiled for the interpreter. It isn't modified by the
+// virtual machines instantiated from it, so it can be shared and reused.
+type CompiledModule struct {
+	Module       *compiler.Module
+	FunctionCode []compiler.InterpreterCode
+}
+
+// CompileModule loads a WebAssembly module and compiles it for the interpreter.
+func CompileModule(code []byte, config VMConfig, gasPolicy compiler.GasPolicy) (*CompiledModule, error) {
+	m, err := compiler.LoadModule(code)
+	if err != nil {
+		return nil, err
+	}
+
+	m.DisableFloatingPoint = config.DisableFloatingPoint
+
+	functionCode, err := m.CompileForInterpreter(gasPolicy)
+	if err != nil {
+		return nil, err
+	}
+	return &CompiledModule{Module: m, FunctionCode: functionCode}, nil
+}
+
 // NewVirtualMachine instantiates a virtual machine for a given WebAssembly module, with
 // specific execution options specified under a VMConfig, and a WebAssembly module import
 // resolver.
@@ -140,17 +164,27 @@ func NewVirtualMachine(
 		fmt.Println("Warning: JIT support is removed.")
 	}
 
-	m, err := compiler.LoadModule(code)
+	compiled, err := CompileModule(code, config, gasPolicy)
 	if err != nil {
 		return nil, err
 	}
+	return NewVirtualMachineWithModule(compiled, config, impResolver, gasPolicy)
+}
 
-	m.DisableFloatingPoint = config.DisableFloatingPoint
-
-	functionCode, err := m.CompileForInterpreter(gasPolicy)
-	if err != nil {
-		return nil, err
-	}
+// NewVirtualMachineWithModule instantiates a virtual machine for a compiled WebAssembly module,
+// the compiled module isn't modified so it can be used by other virtual machines at the same time.
+func NewVirtualMachineWithModule(
+	compiled *CompiledModule,
+	config VMConfig,
+	impResolver ImportResolver,
+	gasPolicy compiler.GasPolicy,
+) (_retVM *VirtualMachine, retErr error) {
+	// the imported memory and table are set to the copy of the module
+	base := *compiled.Module.Base
+	module := *compiled.Module
+	module.Base = &base
+	m := &module
+	functionCode := compiled.FunctionCode
 
 	defer utils.CatchPanic(&retErr)
 
@@ -507,6 +541,17 @@ func (vm *VirtualMachine) Ignite(functionID int, params ...int64) {
 	copy(frame.Locals, params)
 }
 
+// GrowMemory grows the memory by n pages, it returns the previous number of pages or -1 if the
+// memory can't grow
+func (vm *VirtualMachine) GrowMemory(n int) int64 {
+	current := len(vm.Memory.Memory) / mem_manage.DefaultPageSize
+	if vm.Config.MaxMemoryPages == 0 || (current+n >= current && current+n <= vm.Config.MaxMemoryPages) {
+		vm.Memory.Memory = append(vm.Memory.Memory, make([]byte, n*mem_manage.DefaultPageSize)...)
+		return int64(current)
+	}
+	return -1
+}
+
 func (vm *VirtualMachine) AddAndCheckGas(delta uint64) bool {
 	newGas := vm.Gas + delta
 	if newGas < vm.Gas {
@@ -1802,13 +1847,7 @@ func (vm *VirtualMachine) Execute() {
 			n := int(uint32(frame.Regs[int(LE.Uint32(frame.Code[frame.IP:frame.IP+4]))]))
 			frame.IP += 4
 
-			current := len(vm.Memory.Memory) / mem_manage.DefaultPageSize
-			if vm.Config.MaxMemoryPages == 0 || (current+n >= current && current+n <= vm.Config.MaxMemoryPages) {
-				frame.Regs[valueID] = int64(current)
-				vm.Memory.Memory = append(vm.Memory.Memory, make([]byte, n*mem_manage.DefaultPageSize)...)
-			} else {
-				frame.Regs[valueID] = -1
-			}
+			frame.Regs[valueID] = vm.GrowMemory(n)
 
 		case opcodes.Phi:
 			frame.Regs[valueID] = vm.Yielded
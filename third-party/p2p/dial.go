@@ -72,6 +72,7 @@ type dialstate struct {
 	ntab        discoverTable
 	netrestrict *netutil.Netlist
 	self        enode.ID
+	banned      func(enode.ID) bool // reports nodes that must not be dialed, may be nil
 
 	lookupRunning bool
 	dialing       map[enode.ID]connFlag
@@ -263,6 +264,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errBanned           = errors.New("is banned")
 )
 
 func (s *dialstate) checkDial(n *enode.Node, peers map[enode.ID]*Peer) error {
@@ -276,6 +278,8 @@ func (s *dialstate) checkDial(n *enode.Node, peers map[enode.ID]*Peer) error {
 		return errSelf
 	case s.netrestrict != nil && !s.netrestrict.Contains(n.IP()):
 		return errNotWhitelisted
+	case s.banned != nil && s.banned(n.ID()):
+		return errBanned
 	case s.hist.contains(n.ID()):
 		return errRecentlyDialed
 	}
@@ -442,6 +442,31 @@ func TestDialStateNetRestrict(t *testing.T) {
 	})
 }
 
+// This test checks that banned nodes are not dialed.
+func TestDialStateBanned(t *testing.T) {
+	table := fakeTable{
+		newNode(uintID(1), net.ParseIP("127.0.0.1")),
+		newNode(uintID(2), net.ParseIP("127.0.0.2")),
+		newNode(uintID(3), net.ParseIP("127.0.0.3")),
+		newNode(uintID(4), net.ParseIP("127.0.0.4")),
+		newNode(uintID(5), net.ParseIP("127.0.0.5")),
+	}
+	state := newDialState(enode.ID{}, nil, nil, table, 10, nil)
+	state.banned = func(id enode.ID) bool { return id != uintID(5) }
+
+	runDialTest(t, dialtest{
+		init: state,
+		rounds: []round{
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: table[4]},
+					&discoverTask{},
+				},
+			},
+		},
+	})
+}
+
 // This test checks that static dials are launched.
 func TestDialStateStaticDial(t *testing.T) {
 	wantStatic := []*enode.Node{
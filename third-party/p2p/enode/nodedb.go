@@ -39,6 +39,8 @@ import (
 const (
 	dbVersionKey = "version" // Version of the database to flush if changes
 	dbItemPrefix = "n:"      // Identifier to prefix node entries with
+	dbBanPrefix  = "b:"      // Identifier to prefix ban records with, kept apart so node expiry leaves them alone
+	dbBanCount   = "bc:"     // Identifier to prefix the temporary ban counts with, kept next to the ban records
 
 	dbDiscoverRoot      = ":discover"
 	dbDiscoverSeq       = dbDiscoverRoot + ":seq"
@@ -326,6 +328,77 @@ func (db *DB) UpdateFindFails(id ID, fails int) error {
 	return db.storeInt64(makeKey(id, dbDiscoverFindFails), int64(fails))
 }
 
+// Ban retrieves the time until which a node is banned and whether a ban record
+// exists at all. A zero time denotes a permanent ban.
+func (db *DB) Ban(id ID) (time.Time, bool) {
+	blob, err := db.lvl.Get(banKey(id), nil)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return decodeBan(blob), true
+}
+
+// UpdateBan records that a node is banned until the given time, a zero time
+// bans it permanently.
+func (db *DB) UpdateBan(id ID, until time.Time) error {
+	var n int64
+	if !until.IsZero() {
+		n = until.Unix()
+	}
+	return db.storeInt64(banKey(id), n)
+}
+
+// DeleteBan lifts the ban of a node.
+func (db *DB) DeleteBan(id ID) error {
+	return db.lvl.Delete(banKey(id), nil)
+}
+
+// Bans returns all bans that are still in force, dropping expired records on the way.
+func (db *DB) Bans() map[ID]time.Time {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbBanPrefix)), nil)
+	defer it.Release()
+
+	now := time.Now()
+	bans := make(map[ID]time.Time)
+	for it.Next() {
+		var id ID
+		copy(id[:], it.Key()[len(dbBanPrefix):])
+		until := decodeBan(it.Value())
+		if !until.IsZero() && !until.After(now) {
+			db.DeleteBan(id)
+			continue
+		}
+		bans[id] = until
+	}
+	return bans
+}
+
+// TempBans retrieves how many times a node has been banned temporarily.
+func (db *DB) TempBans(id ID) int {
+	return int(db.fetchInt64(banCountKey(id)))
+}
+
+// UpdateTempBans stores how many times a node has been banned temporarily.
+func (db *DB) UpdateTempBans(id ID, n int) error {
+	return db.storeInt64(banCountKey(id), int64(n))
+}
+
+func banKey(id ID) []byte {
+	return append([]byte(dbBanPrefix), id[:]...)
+}
+
+func banCountKey(id ID) []byte {
+	return append([]byte(dbBanCount), id[:]...)
+}
+
+func decodeBan(blob []byte) time.Time {
+	n, read := binary.Varint(blob)
+	if read <= 0 || n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(n, 0)
+}
+
 // LocalSeq retrieves the local record sequence counter.
 func (db *DB) localSeq(id ID) uint64 {
 	return db.fetchUint64(makeKey(id, dbLocalSeq))
@@ -370,3 +370,62 @@ func TestDBExpiration(t *testing.T) {
 		}
 	}
 }
+
+func TestDBBans(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	var (
+		temp      = nodeDBExpirationNodes[0].node.ID()
+		permanent = nodeDBExpirationNodes[1].node.ID()
+		expired   = ID{0x01}
+		until     = time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	)
+	if _, ok := db.Ban(temp); ok {
+		t.Fatalf("unexpected ban for unknown node")
+	}
+	if err := db.UpdateBan(temp, until); err != nil {
+		t.Fatalf("failed to store ban: %v", err)
+	}
+	if err := db.UpdateBan(permanent, time.Time{}); err != nil {
+		t.Fatalf("failed to store ban: %v", err)
+	}
+	if err := db.UpdateBan(expired, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("failed to store ban: %v", err)
+	}
+	if have, ok := db.Ban(temp); !ok || !have.Equal(until) {
+		t.Errorf("temporary ban mismatch: have %v, want %v", have, until)
+	}
+
+	// Bans must survive node expiry
+	db.DeleteNode(temp)
+	want := map[ID]time.Time{temp: until, permanent: {}}
+	if have := db.Bans(); !reflect.DeepEqual(have, want) {
+		t.Errorf("bans mismatch: have %v, want %v", have, want)
+	}
+	if _, ok := db.Ban(expired); ok {
+		t.Errorf("expired ban not dropped")
+	}
+
+	if err := db.DeleteBan(permanent); err != nil {
+		t.Fatalf("failed to delete ban: %v", err)
+	}
+	if _, ok := db.Ban(permanent); ok {
+		t.Errorf("ban not deleted")
+	}
+
+	// The temporary ban counts aren't taken for bans and outlive them
+	if n := db.TempBans(temp); n != 0 {
+		t.Errorf("unexpected temporary bans for unbanned node: %d", n)
+	}
+	if err := db.UpdateTempBans(temp, 2); err != nil {
+		t.Fatalf("failed to store temporary bans: %v", err)
+	}
+	db.DeleteBan(temp)
+	if n := db.TempBans(temp); n != 2 {
+		t.Errorf("temporary bans mismatch: have %d, want 2", n)
+	}
+	if have := db.Bans(); len(have) != 0 {
+		t.Errorf("unexpected bans: %v", have)
+	}
+}
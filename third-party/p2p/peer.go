@@ -487,6 +487,13 @@ type CsPeerInfo struct {
 	VerifierAddress string `json:"verifier_address"`
 }
 
+// BannedPeerInfo represents a node the server refuses to connect with
+type BannedPeerInfo struct {
+	ID string `json:"id"`
+	// unix time the ban ends, zero for a permanent ban
+	Until int64 `json:"until"`
+}
+
 type CsPmPeerInfo struct {
 	SelfType     uint64        `json:"self_type"`
 	SelfNodeName string        `json:"self_node_name"`
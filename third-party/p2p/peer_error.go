@@ -117,3 +117,10 @@ func discReasonForError(err error) DiscReason {
 	}
 	return DiscSubprotocolError
 }
+
+// IsMsgDecodeError reports whether err was returned by Msg.Decode for a
+// malformed message payload.
+func IsMsgDecodeError(err error) bool {
+	pe, ok := err.(*peerError)
+	return ok && pe.code == errInvalidMsg
+}
@@ -165,6 +165,8 @@ type Server struct {
 	running bool
 
 	nodedb       *enode.DB
+	banLock      sync.RWMutex // protects banned
+	banned       map[enode.ID]time.Time
 	localnode    *enode.LocalNode
 	ntab         discoverTable
 	listener     net.Listener
@@ -345,6 +347,99 @@ func (srv *Server) RemoveTrustedPeer(node *enode.Node) {
 	}
 }
 
+// BanPeer disconnects the given node and refuses to dial or accept it for the
+// given duration. A non-positive duration bans the node permanently. Bans are
+// stored in the node database and outlive restarts, so does the count of the
+// temporary bans.
+func (srv *Server) BanPeer(id enode.ID, d time.Duration) error {
+	srv.lock.Lock()
+	running, db := srv.running, srv.nodedb
+	srv.lock.Unlock()
+	if !running || db == nil {
+		return errServerStopped
+	}
+
+	var until time.Time
+	if d > 0 {
+		until = time.Now().Add(d)
+	}
+	if err := db.UpdateBan(id, until); err != nil {
+		return err
+	}
+	if d > 0 {
+		if err := db.UpdateTempBans(id, db.TempBans(id)+1); err != nil {
+			return err
+		}
+	}
+	srv.banLock.Lock()
+	srv.banned[id] = until
+	srv.banLock.Unlock()
+
+	select {
+	case srv.peerOp <- func(peers map[enode.ID]*Peer) {
+		if p, ok := peers[id]; ok {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}:
+		<-srv.peerOpDone
+	case <-srv.quit:
+	}
+	return nil
+}
+
+// UnbanPeer lifts the ban of the given node.
+func (srv *Server) UnbanPeer(id enode.ID) error {
+	srv.lock.Lock()
+	running, db := srv.running, srv.nodedb
+	srv.lock.Unlock()
+	if !running || db == nil {
+		return errServerStopped
+	}
+
+	if err := db.DeleteBan(id); err != nil {
+		return err
+	}
+	srv.banLock.Lock()
+	delete(srv.banned, id)
+	srv.banLock.Unlock()
+	return nil
+}
+
+// TempBans returns how many times the given node has been banned temporarily.
+func (srv *Server) TempBans(id enode.ID) int {
+	srv.lock.Lock()
+	db := srv.nodedb
+	srv.lock.Unlock()
+	if db == nil {
+		return 0
+	}
+	return db.TempBans(id)
+}
+
+// BannedPeers returns the nodes that are currently banned together with the
+// time their ban ends. A zero time denotes a permanent ban.
+func (srv *Server) BannedPeers() map[enode.ID]time.Time {
+	now := time.Now()
+	result := make(map[enode.ID]time.Time)
+
+	srv.banLock.RLock()
+	defer srv.banLock.RUnlock()
+	for id, until := range srv.banned {
+		if until.IsZero() || until.After(now) {
+			result[id] = until
+		}
+	}
+	return result
+}
+
+// isBanned reports whether connections to the given node are currently refused.
+func (srv *Server) isBanned(id enode.ID) bool {
+	srv.banLock.RLock()
+	defer srv.banLock.RUnlock()
+	until, ok := srv.banned[id]
+	return ok && (until.IsZero() || until.After(time.Now()))
+}
+
 // SubscribePeers subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -459,6 +554,7 @@ func (srv *Server) Start() (err error) {
 
 	dynPeers := srv.maxDialedConns()
 	dialer := newDialState(srv.localnode.ID(), srv.StaticNodes, srv.BootstrapNodes, srv.ntab, dynPeers, srv.NetRestrict)
+	dialer.banned = srv.isBanned
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
 	return nil
@@ -479,6 +575,9 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.banLock.Lock()
+	srv.banned = db.Bans()
+	srv.banLock.Unlock()
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	srv.localnode.Set(capsByNameAndVersion(srv.ourHandshake.Caps))
@@ -802,6 +901,8 @@ func (srv *Server) encHandshakeChecks(peers map[enode.ID]*Peer, inboundCount int
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case srv.isBanned(c.node.ID()):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
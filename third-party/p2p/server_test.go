@@ -414,6 +414,76 @@ func TestServerAtCap(t *testing.T) {
 	}
 }
 
+func TestServerBanPeer(t *testing.T) {
+	remote := newkey()
+	remoteID := enode.PubkeyToIDV4(&remote.PublicKey)
+	srv := &Server{
+		Config: Config{
+			PrivateKey: newkey(),
+			MaxPeers:   10,
+			NoDial:     true,
+		},
+	}
+	if err := srv.BanPeer(remoteID, 0); err != errServerStopped {
+		t.Fatal("expected error for stopped server, got", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id enode.ID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(&remote.PublicKey, fd)
+		node := enode.SignNull(new(enr.Record), id)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, node: node, cont: make(chan error)}
+	}
+
+	// A connected peer is dropped once banned.
+	if err := srv.checkpoint(newconn(remoteID), srv.addpeer); err != nil {
+		t.Fatalf("could not add conn: %v", err)
+	}
+	if err := srv.BanPeer(remoteID, time.Hour); err != nil {
+		t.Fatalf("could not ban peer: %v", err)
+	}
+	for i := 0; srv.PeerCount() != 0; i++ {
+		if i == 100 {
+			t.Fatal("banned peer not disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := srv.checkpoint(newconn(remoteID), srv.posthandshake); err != DiscUselessPeer {
+		t.Error("wrong error for banned conn:", err)
+	}
+	if until, ok := srv.BannedPeers()[remoteID]; !ok || until.IsZero() {
+		t.Error("temporary ban not listed:", until, ok)
+	}
+	if n := srv.TempBans(remoteID); n != 1 {
+		t.Error("temporary ban not counted:", n)
+	}
+
+	// Permanent bans are stored with a zero time.
+	if err := srv.BanPeer(remoteID, 0); err != nil {
+		t.Fatalf("could not ban peer: %v", err)
+	}
+	if until, ok := srv.nodedb.Ban(remoteID); !ok || !until.IsZero() {
+		t.Error("permanent ban not stored:", until, ok)
+	}
+	if n := srv.TempBans(remoteID); n != 1 {
+		t.Error("permanent ban counted as temporary:", n)
+	}
+
+	if err := srv.UnbanPeer(remoteID); err != nil {
+		t.Fatalf("could not unban peer: %v", err)
+	}
+	if len(srv.BannedPeers()) != 0 {
+		t.Error("ban not lifted")
+	}
+	if err := srv.checkpoint(newconn(remoteID), srv.posthandshake); err != nil {
+		t.Error("unexpected error for unbanned conn:", err)
+	}
+}
+
 func TestServerPeerLimits(t *testing.T) {
 	srvkey := newkey()
 	clientkey := newkey()
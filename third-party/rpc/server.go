@@ -189,6 +189,12 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 			}
 			return nil
 		}
+		if !s.checkRequests(ctx, codec, reqs, batch) {
+			if singleShot {
+				return nil
+			}
+			continue
+		}
 		// If a single shot request is executing, run and return immediately
 		if singleShot {
 			if batch {
@@ -214,12 +220,46 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 	return nil
 }
 
+// checkRequests applies the guard to the requests, the rejected calls are answered with the guard error.
+// It returns false if the whole batch is rejected.
+func (s *Server) checkRequests(ctx context.Context, codec ServerCodec, reqs []*serverRequest, batch bool) bool {
+	if s.guard == nil {
+		return true
+	}
+	if batch {
+		if err := s.guard.CheckBatch(ctx, len(reqs)); err != nil {
+			codec.Write(codec.CreateErrorResponse(nil, err))
+			return false
+		}
+	}
+	for _, req := range reqs {
+		// invalid requests and unsubscriptions don't call any service method
+		if req.err != nil || req.callb == nil {
+			continue
+		}
+		if err := s.guard.CheckCall(ctx, req.svcname, formatName(req.callb.method.Name)); err != nil {
+			req.err = err
+		}
+	}
+	return true
+}
+
+// SetGuard sets the guard checking the requests, it must be called before serving any request.
+func (s *Server) SetGuard(guard Guard) {
+	s.guard = guard
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes the
 // response back using the given codec. It will block until the codec is closed or the server is
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(context.Background(), codec, options)
+}
+
+// serveCodec is ServeCodec with the context carrying the connection information.
+func (s *Server) serveCodec(ctx context.Context, codec ServerCodec, options CodecOption) {
 	defer codec.Close()
-	s.serveRequest(context.Background(), codec, false, options)
+	s.serveRequest(ctx, codec, false, options)
 }
 
 // ServeSingleRequest reads and processes a single RPC request from the given codec. It will not
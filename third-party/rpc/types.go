@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"reflect"
@@ -74,6 +75,17 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   mapset.Set
+
+	guard Guard
+}
+
+// Guard checks the requests before they are executed. It's used to authenticate the clients and limit
+// their request rate, the implementations must be go-routine safe.
+type Guard interface {
+	// CheckBatch checks a batch of the given size, the whole batch is rejected if an error is returned
+	CheckBatch(ctx context.Context, size int) Error
+	// CheckCall checks a call of the service method, the call is rejected if an error is returned
+	CheckCall(ctx context.Context, service, method string) Error
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -80,7 +80,11 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 				}
 				return err
 			}
-			srv.ServeCodec(NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
+			ctx := context.Background()
+			if req := conn.Request(); req != nil {
+				ctx = requestContext(ctx, req)
+			}
+			srv.serveCodec(ctx, NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
 			log.Rpc.Info("call websocket handle end ~~~~~~~~~~~~~~~~~")
 			log.Rpc.Info("")
 		},
@@ -81,6 +81,8 @@ type Database struct {
 	nodesSize     common.StorageSize // Storage size of the nodes cache (exc. flushlist)
 	preimagesSize common.StorageSize // Storage size of the preimages cache
 
+	refCount bool // Whether the committed nodes are reference counted on the disk
+
 	lock sync.RWMutex
 }
 
@@ -614,6 +616,10 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 	// memory cache during commit but not yet in persistent storage). This is ensured
 	// by only uncaching existing data when the database write finalizes.
 	// db.lock.RLock()
+	if db.refCount {
+		refLock.Lock()
+		defer refLock.Unlock()
+	}
 	db.lock.Lock()
 
 	start := time.Now()
@@ -642,7 +648,16 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 	}
 	// Move the trie itself into the batch, flushing if enough data is accumulated
 	nodes, storage := len(db.nodes), db.nodesSize
-	if err := db.commit(node, batch); err != nil {
+	var err error
+	if db.refCount {
+		refs := newRefCache(db.diskdb)
+		if err = db.commitWithRefs(node, batch, refs, make(map[common.Hash]bool)); err == nil {
+			err = refs.flush(batch)
+		}
+	} else {
+		err = db.commit(node, batch)
+	}
+	if err != nil {
 		log.Error("Failed to commit trie from trie database", "err", err)
 		db.lock.Unlock()
 		return err
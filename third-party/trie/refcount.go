@@ -0,0 +1,357 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"sync"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// refKeyPrefix is the database key prefix of the reference count records of the trie nodes.
+var refKeyPrefix = []byte("trie-ref-")
+
+// refLock serialises the updates of the reference count records, the records are shared by
+// all the trie databases on the same disk database.
+var refLock sync.Mutex
+
+// refRecord is the reference count of a node written by a reference counted commit. Only
+// these nodes are deleted when they are released, the nodes without a record (written in
+// the archive mode or by the state sync) are kept forever.
+type refRecord struct {
+	Count uint64
+	// the trie roots referenced by the leaf values of the node, e.g. the contract data roots
+	Values []common.Hash
+}
+
+func refKey(hash common.Hash) []byte {
+	return append(append([]byte{}, refKeyPrefix...), hash[:]...)
+}
+
+// NewDatabaseWithRefCount creates a trie database which counts the references of the
+// committed nodes on the disk, so the old tries can be deleted by ReleaseRoot.
+func NewDatabaseWithRefCount(diskdb ethdb.Database) *Database {
+	db := NewDatabase(diskdb)
+	db.refCount = true
+	return db
+}
+
+// RefCounted returns whether the committed nodes are reference counted.
+func (db *Database) RefCounted() bool {
+	return db.refCount
+}
+
+// RetainRoot adds a reference to the trie root, which keeps the trie on the disk until
+// the root is released.
+func (db *Database) RetainRoot(root common.Hash) error {
+	refLock.Lock()
+	defer refLock.Unlock()
+
+	refs := newRefCache(db.diskdb)
+	if _, err := refs.retain(root); err != nil {
+		return err
+	}
+	batch := db.diskdb.NewBatch()
+	if err := refs.flush(batch); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// ReleaseRoot removes a reference of the trie root, the nodes which aren't referenced
+// any more are deleted from the disk.
+func (db *Database) ReleaseRoot(root common.Hash) error {
+	refLock.Lock()
+	defer refLock.Unlock()
+
+	refs := newRefCache(db.diskdb)
+	batch := db.diskdb.NewBatch()
+	if err := db.release(root, batch, refs); err != nil {
+		return err
+	}
+	if err := refs.flush(batch); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// release is the private locked version of ReleaseRoot.
+func (db *Database) release(hash common.Hash, batch ethdb.Batch, refs *refCache) error {
+	record, err := refs.get(hash)
+	if err != nil || record == nil {
+		return err
+	}
+	if record.Count > 1 {
+		record.Count--
+		refs.put(hash, record)
+		return nil
+	}
+
+	// the children are released by the decoded node, as they are retained by the commit
+	if blob, err := db.diskdb.Get(hash[:]); err == nil {
+		children, _ := nodeRefs(hash, blob)
+		for _, child := range children {
+			if err := db.release(child, batch, refs); err != nil {
+				return err
+			}
+		}
+	}
+	for _, value := range record.Values {
+		if err := db.release(value, batch, refs); err != nil {
+			return err
+		}
+	}
+
+	if err := batch.Delete(hash[:]); err != nil {
+		return err
+	}
+	refs.remove(hash)
+	if batch.ValueSize() >= ethdb.IdealBatchSize {
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+	}
+	return nil
+}
+
+// commitWithRefs is the reference counted version of commit. A node which is already on
+// the disk is skipped, its children are referenced by the commit that wrote it.
+func (db *Database) commitWithRefs(hash common.Hash, batch ethdb.Batch, refs *refCache, written map[common.Hash]bool) error {
+	node, ok := db.nodes[hash]
+	if !ok || written[hash] {
+		return nil
+	}
+	written[hash] = true
+	if has, err := db.diskdb.Has(hash[:]); err != nil || has {
+		return err
+	}
+
+	for _, child := range node.childs() {
+		if err := db.commitWithRefs(child, batch, refs, written); err != nil {
+			return err
+		}
+	}
+
+	blob := node.rlp()
+	if err := batch.Put(hash[:], blob); err != nil {
+		return err
+	}
+	record := &refRecord{}
+	children, values := nodeRefs(hash, blob)
+	for _, child := range children {
+		if _, err := refs.retain(child); err != nil {
+			return err
+		}
+	}
+	for _, value := range values {
+		counted, err := refs.retain(value)
+		if err != nil {
+			return err
+		}
+		if counted {
+			record.Values = append(record.Values, value)
+		}
+	}
+	refs.put(hash, record)
+
+	if batch.ValueSize() >= ethdb.IdealBatchSize {
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+	}
+	return nil
+}
+
+// DeleteNodes deletes the nodes found unreachable by an offline pruning. The references
+// from the deleted nodes to the kept nodes are removed, but the kept nodes are never
+// deleted here even if they aren't referenced any more.
+func (db *Database) DeleteNodes(hashes []common.Hash) error {
+	refLock.Lock()
+	defer refLock.Unlock()
+
+	deleted := make(map[common.Hash]bool, len(hashes))
+	for _, hash := range hashes {
+		deleted[hash] = true
+	}
+
+	refs := newRefCache(db.diskdb)
+	batch := db.diskdb.NewBatch()
+	unref := func(hash common.Hash) error {
+		if deleted[hash] {
+			return nil
+		}
+		record, err := refs.get(hash)
+		if err != nil || record == nil {
+			return err
+		}
+		if record.Count > 0 {
+			record.Count--
+		}
+		refs.put(hash, record)
+		return nil
+	}
+
+	for _, hash := range hashes {
+		record, err := refs.get(hash)
+		if err != nil {
+			return err
+		}
+		if record != nil {
+			if blob, err := db.diskdb.Get(hash[:]); err == nil {
+				children, _ := nodeRefs(hash, blob)
+				for _, child := range children {
+					if err := unref(child); err != nil {
+						return err
+					}
+				}
+			}
+			for _, value := range record.Values {
+				if err := unref(value); err != nil {
+					return err
+				}
+			}
+			refs.remove(hash)
+		}
+
+		if err := batch.Delete(hash[:]); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+
+	if err := refs.flush(batch); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// nodeRefs decodes the node and returns the hashes of its children, and the hash values of
+// its leaves which may be the roots of other tries. The contract data root is saved as the
+// raw hash or the rlp encoded hash.
+func nodeRefs(hash common.Hash, blob []byte) (children []common.Hash, values []common.Hash) {
+	n, err := decodeNode(hash[:], blob, 0)
+	if err != nil {
+		return nil, nil
+	}
+	gatherRefs(n, &children, &values)
+	return
+}
+
+func gatherRefs(n node, children *[]common.Hash, values *[]common.Hash) {
+	switch n := n.(type) {
+	case *shortNode:
+		gatherRefs(n.Val, children, values)
+	case *fullNode:
+		for _, child := range n.Children {
+			gatherRefs(child, children, values)
+		}
+	case hashNode:
+		*children = append(*children, common.BytesToHash(n))
+	case valueNode:
+		if len(n) == common.HashLength || len(n) == common.HashLength+1 {
+			*values = append(*values, common.BytesToHash(n))
+		}
+	}
+}
+
+// refCache buffers the reference count records updated by one operation.
+type refCache struct {
+	diskdb  ethdb.Database
+	records map[common.Hash]*refRecord
+	dirty   map[common.Hash]bool
+}
+
+func newRefCache(diskdb ethdb.Database) *refCache {
+	return &refCache{
+		diskdb:  diskdb,
+		records: make(map[common.Hash]*refRecord),
+		dirty:   make(map[common.Hash]bool),
+	}
+}
+
+// get returns the record of the node, or nil if the node isn't reference counted
+func (c *refCache) get(hash common.Hash) (*refRecord, error) {
+	if record, ok := c.records[hash]; ok {
+		return record, nil
+	}
+
+	key := refKey(hash)
+	has, err := c.diskdb.Has(key)
+	if err != nil || !has {
+		c.records[hash] = nil
+		return nil, err
+	}
+	enc, err := c.diskdb.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	record := &refRecord{}
+	if err := rlp.DecodeBytes(enc, record); err != nil {
+		return nil, err
+	}
+	c.records[hash] = record
+	return record, nil
+}
+
+// retain increases the reference count of the node, it returns false if the node isn't reference counted
+func (c *refCache) retain(hash common.Hash) (bool, error) {
+	record, err := c.get(hash)
+	if err != nil || record == nil {
+		return false, err
+	}
+	record.Count++
+	c.put(hash, record)
+	return true, nil
+}
+
+func (c *refCache) put(hash common.Hash, record *refRecord) {
+	c.records[hash] = record
+	c.dirty[hash] = true
+}
+
+func (c *refCache) remove(hash common.Hash) {
+	c.records[hash] = nil
+	c.dirty[hash] = true
+}
+
+func (c *refCache) flush(batch ethdb.Batch) error {
+	for hash := range c.dirty {
+		if record := c.records[hash]; record == nil {
+			if err := batch.Delete(refKey(hash)); err != nil {
+				return err
+			}
+		} else {
+			enc, err := rlp.EncodeToBytes(record)
+			if err != nil {
+				return err
+			}
+			if err := batch.Put(refKey(hash), enc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
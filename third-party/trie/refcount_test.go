@@ -0,0 +1,191 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// commitRefTrie updates the trie with the values and commits it to the disk
+func commitRefTrie(t *testing.T, db *Database, root common.Hash, values map[string][]byte) common.Hash {
+	tr, err := New(root, db)
+	if err != nil {
+		t.Fatalf("can't open trie %x: %v", root, err)
+	}
+	for k, v := range values {
+		if err := tr.TryUpdate([]byte(k), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root, err = tr.Commit(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Commit(root, false); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func testValues(from, to int, suffix string) map[string][]byte {
+	values := make(map[string][]byte)
+	for i := from; i < to; i++ {
+		values[fmt.Sprintf("key-%d", i)] = []byte(fmt.Sprintf("value-%d-%s", i, suffix))
+	}
+	return values
+}
+
+// checkRefTrie iterates the whole trie, it fails if any node is missing
+func checkRefTrie(root common.Hash, db *Database) error {
+	tr, err := New(root, db)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+	}
+	return it.Error()
+}
+
+func TestDatabase_ReleaseRoot(t *testing.T) {
+	diskdb := ethdb.NewMemDatabase()
+	db := NewDatabaseWithRefCount(diskdb)
+	if !db.RefCounted() {
+		t.Fatal("the database should be reference counted")
+	}
+
+	root1 := commitRefTrie(t, db, common.Hash{}, testValues(0, 100, "a"))
+	if err := db.RetainRoot(root1); err != nil {
+		t.Fatal(err)
+	}
+	root2 := commitRefTrie(t, db, root1, testValues(0, 10, "b"))
+	if err := db.RetainRoot(root2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the root committed again keeps its references
+	commitRefTrie(t, db, root1, testValues(0, 10, "b"))
+
+	if err := db.ReleaseRoot(root1); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRefTrie(root2, db); err != nil {
+		t.Fatalf("the retained trie is broken: %v", err)
+	}
+	if err := checkRefTrie(root1, db); err == nil {
+		t.Fatal("the released trie should be deleted")
+	}
+
+	if err := db.ReleaseRoot(root2); err != nil {
+		t.Fatal(err)
+	}
+	if diskdb.Len() != 0 {
+		t.Fatalf("the nodes or records are left after all tries are released: %d", diskdb.Len())
+	}
+
+	// releasing an unknown root does nothing
+	if err := db.ReleaseRoot(root2); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDatabase_ReleaseRootValues(t *testing.T) {
+	diskdb := ethdb.NewMemDatabase()
+	dataDB := NewDatabaseWithRefCount(diskdb)
+	db := NewDatabaseWithRefCount(diskdb)
+
+	dataRoot := commitRefTrie(t, dataDB, common.Hash{}, testValues(0, 50, "data"))
+	root := commitRefTrie(t, db, common.Hash{}, map[string][]byte{"contract": dataRoot.Bytes()})
+	if err := db.RetainRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ReleaseRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	if diskdb.Len() != 0 {
+		t.Fatalf("the data trie referenced by the leaf should be deleted: %d", diskdb.Len())
+	}
+}
+
+func TestDatabase_ReleaseUncounted(t *testing.T) {
+	diskdb := ethdb.NewMemDatabase()
+	archive := NewDatabase(diskdb)
+	root1 := commitRefTrie(t, archive, common.Hash{}, testValues(0, 100, "a"))
+
+	db := NewDatabaseWithRefCount(diskdb)
+	root2 := commitRefTrie(t, db, root1, testValues(0, 10, "b"))
+	if err := db.RetainRoot(root2); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ReleaseRoot(root1); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ReleaseRoot(root2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the nodes written by the archive database are never deleted
+	if err := checkRefTrie(root1, db); err != nil {
+		t.Fatalf("the uncounted trie is broken: %v", err)
+	}
+	if err := checkRefTrie(root2, db); err == nil {
+		t.Fatal("the released trie should be deleted")
+	}
+}
+
+func TestDatabase_DeleteNodes(t *testing.T) {
+	diskdb := ethdb.NewMemDatabase()
+	db := NewDatabaseWithRefCount(diskdb)
+
+	root1 := commitRefTrie(t, db, common.Hash{}, testValues(0, 100, "a"))
+	root2 := commitRefTrie(t, db, root1, testValues(0, 10, "b"))
+	if err := db.RetainRoot(root2); err != nil {
+		t.Fatal(err)
+	}
+
+	// delete the nodes only used by the first trie
+	kept := make(map[common.Hash]bool)
+	tr, _ := New(root2, db)
+	for it := tr.NodeIterator(nil); it.Next(true); {
+		kept[it.Hash()] = true
+	}
+	var unreachable []common.Hash
+	tr, _ = New(root1, db)
+	for it := tr.NodeIterator(nil); it.Next(true); {
+		if hash := it.Hash(); hash != (common.Hash{}) && !kept[hash] {
+			unreachable = append(unreachable, hash)
+		}
+	}
+	if err := db.DeleteNodes(unreachable); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkRefTrie(root1, db); err == nil {
+		t.Fatal("the unreachable nodes should be deleted")
+	}
+
+	// the references from the deleted nodes are removed
+	if err := db.ReleaseRoot(root2); err != nil {
+		t.Fatal(err)
+	}
+	if diskdb.Len() != 0 {
+		t.Fatalf("the nodes or records are left after all tries are released: %d", diskdb.Len())
+	}
+}
@@ -274,6 +274,11 @@ func includes(addresses []common.Address, a common.Address) bool {
 	return false
 }
 
+// FilterLogs returns the logs matching the addresses and the topics, it's used by the log subscriptions.
+func FilterLogs(logs []*model2.Log, addresses []common.Address, topics [][]common.Hash) []*model2.Log {
+	return filterLogs(logs, nil, nil, addresses, topics)
+}
+
 // filterLogs creates a slice of logs matching the given criteria.
 func filterLogs(logs []*model2.Log, fromBlock, toBlock *big.Int, addresses []common.Address, topics [][]common.Hash) []*model2.Log {
 	var ret []*model2.Log